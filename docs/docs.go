@@ -15,37 +15,56 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/products": {
+        "/api/admin/api-keys": {
             "get": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Все ключи, включая отозванные и истёкшие. Сами ключи не возвращаются.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Auth"
                 ],
-                "summary": "Получение списка всех продуктов",
+                "summary": "Ключи API",
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ",
+                        "description": "Ключи",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main.Product"
+                                "$ref": "#/definitions/models.APIKey"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка на сервере",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "List API keys.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
             },
             "post": {
+                "description": "Ключ передаётся в заголовке X-API-Key вместо Bearer-токена. Он возвращается в поле key только в этом ответе.",
                 "consumes": [
                     "application/json"
                 ],
@@ -53,50 +72,126 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Auth"
                 ],
-                "summary": "Добавить один или несколько продуктов",
+                "summary": "Выдать ключ API",
                 "parameters": [
                     {
-                        "description": "Данные продуктов",
-                        "name": "products",
+                        "description": "Имя, права и срок действия",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.CreateProductRequest"
-                            }
+                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Продукты успешно добавлены",
+                    "201": {
+                        "description": "Ключ выдан",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.Product"
-                            }
+                            "$ref": "#/definitions/models.APIKey"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Некорректные имя, права или срок",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Ошибка на сервере",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Issue an API key with scopes.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
             }
         },
-        "/api/products/{id}": {
-            "put": {
+        "/api/admin/api-keys/{id}": {
+            "delete": {
+                "description": "Ключ перестаёт действовать сразу. Повторный отзыв не ошибка.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Отозвать ключ API",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ключ отозван"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Revoke an API key.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/admin/catalog/diff": {
+            "post": {
+                "description": "Читает каталог другого экземпляра сервиса через GET /api/products и сообщает, каких продуктов в локальном каталоге не хватает, какие отличаются и каких нет в другом окружении. Продукты сопоставляются по SKU, а без SKU — по названию. С apply: true отличия переносятся в локальный каталог: лишние продукты уходят в корзину, отличающиеся обновляются, недостающие создаются. Требуется токен администратора.",
                 "consumes": [
                     "application/json"
                 ],
@@ -104,52 +199,173 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Catalog"
                 ],
-                "summary": "Обновить данные продукта",
+                "summary": "Сравнить каталог с другим окружением",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID продукта",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Окружение и режим",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CatalogDiffRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отличия каталогов",
+                        "schema": {
+                            "$ref": "#/definitions/models.CatalogDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный адрес или ответ другого окружения",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/categories/reassign": {
+            "post": {
+                "description": "Переименовывает категорию from в to на всех продуктах, включая продукты в корзине, в одной транзакции. Если to уже существует, категории сливаются: у продуктов, где были обе, from просто удаляется. Настройки выдачи from переходят к to, если у той нет своих. Вместо product.updated по каждому продукту публикуется одно событие category.reassigned с числом затронутых продуктов. Требуется токен администратора.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Listings"
+                ],
+                "summary": "Перенести категорию",
+                "parameters": [
                     {
-                        "description": "Данные продукта",
-                        "name": "product",
+                        "description": "Исходная и новая категории",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.UpdateProductRequest"
+                            "$ref": "#/definitions/handlers.ReassignCategoryRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Продукт успешно обновлен",
+                        "description": "Категория перенесена",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.CategoryReassignment"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Категории не заданы или совпадают",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Категории from нет ни у одного продукта",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Ошибка на сервере",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/admin/category-listings": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Listings"
+                ],
+                "summary": "Настройки выдачи всех категорий",
+                "responses": {
+                    "200": {
+                        "description": "Настройки выдачи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CategoryListing"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/category-listings/{category}": {
+            "put": {
+                "description": "sort — relevance, price_asc, price_desc, name или newest; badges — featured и in_stock. Настройки применяются к GET /api/products?category=..., когда клиент не задал limit и sort.",
                 "consumes": [
                     "application/json"
                 ],
@@ -157,107 +373,7913 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Listings"
                 ],
-                "summary": "Удалить продукт",
+                "summary": "Задать настройки выдачи категории",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID продукта",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Категория",
+                        "name": "category",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Порядок, размер страницы и значки",
+                        "name": "listing",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CategoryListing"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Продукт успешно удален",
+                        "description": "Настройки сохранены",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.CategoryListing"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные настройки",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Ошибка на сервере",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "main.CreateProductRequest": {
-            "type": "object",
-            "properties": {
-                "categories": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+            },
+            "delete": {
+                "tags": [
+                    "Listings"
+                ],
+                "summary": "Вернуть категории выдачу по умолчанию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Категория",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Настройки удалены"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "У категории нет настроек",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
-                },
-                "description": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "number"
                 }
             }
         },
-        "main.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
+        "/api/admin/chat/sanctions": {
+            "get": {
+                "description": "Действующие мьюты и баны, новые первыми. Требуется токен администратора.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Ограничения пользователей чата",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ChatSanction"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Накладывает мьют (пользователь не может писать) или бан (пользователь отключается и не может подключиться) на срок duration или бессрочно, заменяя прежнее ограничение того же вида. Требуется токен администратора.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Ограничить пользователя чата",
+                "parameters": [
+                    {
+                        "description": "Ограничение",
+                        "name": "sanction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SanctionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Ограничение наложено",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChatSanction"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное ограничение",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/chat/sanctions/{kind}/{username}": {
+            "delete": {
+                "description": "Снимает мьют или бан пользователя. Требуется токен администратора.",
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Снять ограничение пользователя чата",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Вид ограничения: mute или ban",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ограничение снято"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ограничения нет",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/datasets/products.parquet": {
+            "get": {
+                "description": "Анонимизированная выгрузка продуктов с агрегатами отзывов и остатков: product_id, name, description, price, cost_price, categories (через '|'), sku, review_count, rating_avg, stock_quantity. Имена и типы колонок стабильны; cost_price, sku и rating_avg допускают null. Просмотры и заказы сервер не хранит. Выгрузка собирается в фоне: пока она не готова, ответ — 202 с состоянием и Retry-After, и запрос нужно повторить. Готовый файл отдаётся повторно в течение DATASET_TTL.",
+                "produces": [
+                    "application/vnd.apache.parquet",
+                    "application/json"
+                ],
+                "tags": [
+                    "Datasets"
+                ],
+                "summary": "Набор данных продуктов в Parquet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Колонки через запятую (по умолчанию все); в файле идут в порядке набора данных",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл Parquet",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Выгрузка собирается",
+                        "schema": {
+                            "$ref": "#/definitions/service.DatasetExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестная колонка",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Сборка выгрузки не удалась; следующий запрос начнёт новую",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/dlq": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetters"
+                ],
+                "summary": "Список записей очереди недоставленных сообщений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Источник: webhook или job",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество записей (по умолчанию 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeadLetter"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/dlq/discard": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetters"
+                ],
+                "summary": "Удалить записи очереди недоставленных сообщений без повтора",
+                "parameters": [
+                    {
+                        "description": "ID записей",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeadLetterIDsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Количество удалённых записей",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/dlq/retry": {
+            "post": {
+                "description": "Успешно повторённые записи удаляются из очереди.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetters"
+                ],
+                "summary": "Повторить записи очереди недоставленных сообщений",
+                "parameters": [
+                    {
+                        "description": "ID записей",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeadLetterIDsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результаты повтора",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RetryOutcome"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/dlq/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetters"
+                ],
+                "summary": "Запись очереди недоставленных сообщений",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID записи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Полезная нагрузка и ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeadLetter"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запись не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/events/replay": {
+            "post": {
+                "description": "Отправляет сохранённые события за интервал [from, to) выбранному вебхуку с исходными ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Повторно доставить события из журнала",
+                "parameters": [
+                    {
+                        "description": "Интервал, получатель и фильтр типов",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReplayEventsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Итог повторной доставки",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReplayResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вебхук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/experiments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Список экспериментов",
+                "responses": {
+                    "200": {
+                        "description": "Эксперименты",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Experiment"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Посетители распределяются по вариантам пропорционально весам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Создать эксперимент",
+                "parameters": [
+                    {
+                        "description": "Ключ, варианты и активность",
+                        "name": "experiment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Эксперимент создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный эксперимент",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/experiments/by-key/{key}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Эксперимент по ключу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ эксперимента",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эксперимент",
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Эксперимент не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Идемпотентно приводит эксперимент или флаг функции к состоянию из тела запроса — для Terraform и других декларативных инструментов. Ключ берётся из пути; key в теле игнорируется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Создать или заменить эксперимент по ключу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ эксперимента",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Описание, варианты и активность",
+                        "name": "experiment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эксперимент заменён",
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    },
+                    "201": {
+                        "description": "Эксперимент создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный эксперимент",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Удалить эксперимент по ключу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ эксперимента",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Эксперимент удалён"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Эксперимент не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/experiments/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Эксперимент",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID эксперимента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эксперимент",
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Эксперимент не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Изменение вариантов или весов перераспределяет посетителей; у запущенного эксперимента обычно меняют только описание и активность.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Заменить эксперимент",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID эксперимента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ключ, варианты и активность",
+                        "name": "experiment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эксперимент обновлён",
+                        "schema": {
+                            "$ref": "#/definitions/models.Experiment"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный эксперимент",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Эксперимент не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Удалить эксперимент",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID эксперимента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Эксперимент удалён"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Эксперимент не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/experiments/{id}/results": {
+            "get": {
+                "description": "Число уникальных посетителей, получивших каждый вариант. Попадания записываются пакетами, поэтому последние секунды могут быть ещё не учтены.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Результаты эксперимента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID эксперимента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Попадания по вариантам",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExperimentResults"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Эксперимент не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/graphql/usage": {
+            "get": {
+                "description": "Операции по имени и клиенту (заголовки apollographql-client-name/-version) и число операций, запросивших каждое поле схемы. Поля с нулевым счётчиком не использовались с момента запуска.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "Статистика использования GraphQL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA для since и since_local (по умолчанию UTC)",
+                        "name": "Time-Zone",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/graphql.UsageReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный часовой пояс",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/search/zero-results": {
+            "get": {
+                "description": "Что покупатели ищут, но не находят: запросы, повторявшиеся за последние days дней, от самых частых. Учитывается только первая страница выдачи. Дни отсчитываются от полуночи в часовом поясе из заголовка Time-Zone (по умолчанию UTC); в нём же отдаются даты запросов.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Поисковые запросы без результатов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Часовой пояс IANA, например Europe/Moscow",
+                        "name": "Time-Zone",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "За сколько последних дней, включая сегодняшний (по умолчанию 30)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество запросов (по умолчанию 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запросы без результатов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ZeroResultSearch"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры или часовой пояс",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/slo": {
+            "get": {
+                "description": "Для каждого маршрута с целью из SLO_ROUTES: доля хороших запросов (без 5xx и не дольше порога задержки) за окно SLO_WINDOW, остаток бюджета ошибок и скорость его сгорания за час и 5 минут. Счётчики хранятся в памяти экземпляра и обнуляются при перезапуске.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLO"
+                ],
+                "summary": "Соблюдение SLO",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/slo.Status"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Пользователи API",
+                "responses": {
+                    "200": {
+                        "description": "Пользователи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Завести пользователя API",
+                "parameters": [
+                    {
+                        "description": "Имя, пароль и роль",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь заведён",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные имя, пароль или роль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{id}": {
+            "delete": {
+                "description": "Все сессии пользователя завершаются сразу.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Удалить пользователя API",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Пользователь удалён"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{id}/sessions": {
+            "delete": {
+                "description": "Отзывает все refresh-токены пользователя и выданные с ними access-токены, например если его учётные данные скомпрометированы. Пароль не меняется.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Завершить сессии пользователя API",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сессии завершены"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Revoke all sessions of an API user.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/attachments/{id}": {
+            "get": {
+                "description": "Поддерживает заголовок Range для частичной загрузки.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Скачать вложение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID вложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Содержимое файла",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Запрошенный диапазон",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Вложение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Удалить вложение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID вложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вложение удалено",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вложение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/attachments/{id}/image-url": {
+            "get": {
+                "description": "Строит ссылку imgproxy с изменением размера и формата на лету. Ключ подписи остаётся на сервере.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Подписанная ссылка на обработанное изображение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID вложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ширина в пикселях (0 — по пропорциям)",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Высота в пикселях (0 — по пропорциям)",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Способ изменения размера: fit, fill, fill-down, force, auto",
+                        "name": "resize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Формат результата: jpg, png, webp, avif, gif",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Качество от 1 до 100",
+                        "name": "quality",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписанная ссылка",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImageURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры или вложение не изображение",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вложение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit": {
+            "get": {
+                "description": "Успешные изменяющие запросы к API, новые сначала: кто, когда и с какого адреса что изменил. Более ранние записи запрашиваются с before, равным id последней записи предыдущего ответа.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Журнал аудита",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Вызывающий: ID пользователя, api-key:\u003cid\u003e или admin-token",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "create",
+                            "update",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "description": "Действие",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Шаблон маршрута, например /api/products/:id",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID объекта из маршрута",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Записи не раньше этого момента (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Записи раньше этого момента (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Вернуть записи с id меньше указанного",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 100, не больше 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Записи журнала",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Audit log of create, update and delete requests.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/csrf": {
+            "get": {
+                "description": "Для входа с cookie (AUTH_COOKIES): изменяющие запросы с cookie сессии передают этот токен в заголовке X-Csrf-Token. Он же лежит в cookie csrf_token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "CSRF-токен",
+                "responses": {
+                    "200": {
+                        "description": "Токен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CSRFTokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет cookie сессии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "CSRF token for cookie sessions.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/login": {
+            "post": {
+                "description": "Возвращает access-токен (JWT) для заголовка Authorization: Bearer и refresh-токен для его продления. С AUTH_COOKIES токены кладутся ещё и в HttpOnly-cookie access_token и refresh_token, и браузерное приложение может не передавать заголовок Authorization.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Войти по паролю",
+                "parameters": [
+                    {
+                        "description": "Имя и пароль",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Токены",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверное имя или пароль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "username also accepts the account email.",
+                        "type": "changed",
+                        "version": "1.1"
+                    },
+                    {
+                        "date": "2026-10-15",
+                        "description": "With AUTH_COOKIES tokens are also set as HttpOnly cookies.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/logout": {
+            "post": {
+                "description": "Завершает сессию refresh-токена: её refresh- и access-токены, а также access-токен из заголовка Authorization, если он есть, перестают действовать сразу.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Выйти",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен; с AUTH_COOKIES можно не передавать",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Токен отозван"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Вход с cookie: нет CSRF-токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Logout also revokes the session's access tokens.",
+                        "type": "changed",
+                        "version": "1.1"
+                    },
+                    {
+                        "date": "2026-10-15",
+                        "description": "With AUTH_COOKIES the body may be omitted and the session cookies are cleared.",
+                        "type": "changed",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/oauth": {
+            "get": {
+                "description": "Провайдеры OAuth2, через которых можно войти; для каждого показывается кнопка, ведущая на GET /api/auth/oauth/{provider}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Провайдеры входа",
+                "responses": {
+                    "200": {
+                        "description": "Провайдеры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OAuthProvidersResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "OAuth2 login providers.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/oauth/{provider}": {
+            "get": {
+                "description": "Перенаправляет браузер на страницу входа провайдера (google, github). Провайдер вернёт пользователя на /api/auth/oauth/{provider}/callback.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Войти через провайдера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Провайдер",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Переход к провайдеру"
+                    },
+                    "404": {
+                        "description": "Провайдер не включён",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Sign in with Google or GitHub.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/oauth/{provider}/callback": {
+            "get": {
+                "description": "Завершает вход: проверяет state, обменивает код у провайдера и выдаёт пару токенов. Учётная запись провайдера привязывается к пользователю с тем же email, только если адрес подтверждён и провайдером, и у пользователя — то есть тот сам заведён входом через провайдера; к пользователю, зарегистрированному с паролем, она по email не привязывается. Без такого пользователя заводится новый с ролью viewer, если регистрация открыта. Если задан OAUTH_SUCCESS_URL, браузер перенаправляется туда с токенами во фрагменте адреса, иначе токены отдаются JSON. С AUTH_COOKIES токены кладутся ещё и в cookie, как при входе по паролю.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Возврат от провайдера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Провайдер",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State из перехода к провайдеру",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Токены",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tokens"
+                        }
+                    },
+                    "302": {
+                        "description": "Переход на OAUTH_SUCCESS_URL с токенами"
+                    },
+                    "400": {
+                        "description": "Нет кода или state не совпадает",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Провайдер отказал во входе или у учётной записи нет пользователя, а регистрация закрыта",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Провайдер не включён",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email занят пользователем, зарегистрированным с паролем",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере или у провайдера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "OAuth2 callback issuing API tokens.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/refresh": {
+            "post": {
+                "description": "Обменивает refresh-токен на новую пару токенов. Refresh-токен действует один раз; повторное предъявление уже обменянного токена считается кражей и отзывает всю сессию вместе с её access-токенами, так что клиент должен сохранять новый refresh-токен до следующего обмена.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Продлить токены",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен; с AUTH_COOKIES можно не передавать",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новые токены",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh-токен недействителен, истёк или уже использован",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Вход с cookie: нет CSRF-токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Presenting an already rotated refresh token revokes the whole session, including its access tokens.",
+                        "type": "changed",
+                        "version": "1.1"
+                    },
+                    {
+                        "date": "2026-10-15",
+                        "description": "With AUTH_COOKIES the body may be omitted: the refresh_token cookie is used.",
+                        "type": "changed",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/auth/register": {
+            "post": {
+                "description": "Заводит пользователя с ролью viewer; токены выдаёт /api/auth/login. Доступно, если API_REGISTRATION не выключен.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Зарегистрироваться",
+                "parameters": [
+                    {
+                        "description": "Имя, email и пароль",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь заведён",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные имя, email или пароль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя или email заняты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Self-registration of viewer accounts.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/categories/{category}/listing": {
+            "get": {
+                "description": "Порядок, размер страницы и значки, которые витрина применяет к списку категории.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Listings"
+                ],
+                "summary": "Настройки выдачи категории",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Категория",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Настройки выдачи",
+                        "schema": {
+                            "$ref": "#/definitions/models.CategoryListing"
+                        }
+                    },
+                    "404": {
+                        "description": "У категории выдача по умолчанию",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/changelog": {
+            "get": {
+                "description": "Новые поля и операции, изменения поведения, устаревшие и удалённые операции, новые сначала. Журнал собирается из аннотаций маршрутов и меняется только с новой версией сервера; ответ отдаётся с ETag, так что опрашивать его с If-None-Match дёшево.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Changelog"
+                ],
+                "summary": "Журнал изменений API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Только изменения с этой даты (ГГГГ-ММ-ДД)",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Журнал",
+                        "schema": {
+                            "$ref": "#/definitions/models.Changelog"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная дата",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Machine-readable API changelog.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/chat/history": {
+            "get": {
+                "description": "Сообщения комнаты в порядке отправки. Более ранние сообщения запрашиваются с before, равным id первого сообщения предыдущего ответа.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "История чата",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Комната (по умолчанию general)",
+                        "name": "room",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Вернуть сообщения с id меньше указанного",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество сообщений (по умолчанию 50, не больше 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ChatMessage"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/chat/images": {
+            "post": {
+                "description": "Принимает JPEG, PNG или GIF; тип определяется по содержимому. Изображение перекодируется с уменьшением большой стороны, рядом сохраняется миниатюра. ID из ответа передаётся в image_ids сообщения чата. С аутентификацией чата нужен токен пользователя, и приложить изображение к сообщению сможет только он.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Загрузить изображение для чата",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Изображение",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Изображение загружено",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChatImage"
+                        }
+                    },
+                    "400": {
+                        "description": "Недопустимый тип, размер или разрешение изображения",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена или токен недействителен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/chat/images/{id}": {
+            "get": {
+                "description": "Изображения не меняются после загрузки, поэтому кэшируются без ограничения срока.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Изображение из чата",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID изображения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Изображение",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/chat/images/{id}/thumbnail": {
+            "get": {
+                "produces": [
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Миниатюра изображения из чата",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID изображения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Миниатюра",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/chat/presence": {
+            "get": {
+                "description": "Имена пользователей и число анонимных подключений по комнатам. Учитываются подключения к этому экземпляру сервера; изменения приходят в чат событиями presence.join и presence.leave.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Пользователи чата в сети",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Только указанная комната",
+                        "name": "room",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ws.RoomPresence"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное имя комнаты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/chat/unread": {
+            "get": {
+                "description": "Число непрочитанных личных сообщений пользователя чата, всего и по отправителям. Пользователь определяется по токену чата WebSocket в заголовке Authorization. Сообщение становится прочитанным, когда клиент получателя подтверждает его фреймом ack.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Непрочитанные личные сообщения",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.UnreadMessages"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена пользователя чата",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Чат работает без аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events": {
+            "get": {
+                "description": "Для клиентов за прокси, которые не пропускают WebSocket: те же события шины, что получают клиенты WebSocket, в формате text/event-stream. Поле id каждого события — курсор; после обрыва EventSource передаёт его в заголовке Last-Event-ID, и поток продолжается с места обрыва, пока событие ещё в ленте (последние EVENT_POLL_BUFFER событий). Если часть событий уже недоступна, первым приходит событие gap, и клиенту стоит перечитать состояние через REST. Без Last-Event-ID поток начинается с новых событий.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Поток событий (Server-Sent Events)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "То же, что Last-Event-ID, для первого подключения",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Типы событий через запятую; по умолчанию все",
+                        "name": "types",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный Last-Event-ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/poll": {
+            "get": {
+                "description": "Для клиентов, которым недоступен WebSocket: возвращает события шины после cursor (те же, что получают клиенты WebSocket), а если их нет — ждёт новых до timeout секунд и отвечает пустым списком. Следующий опрос передаёт cursor из ответа. Без cursor возвращаются только события, случившиеся после запроса. Лента хранит последние EVENT_POLL_BUFFER событий в памяти экземпляра; если часть событий после cursor уже недоступна (вытеснены или сервер перезапущен), ответ содержит gap: true и все оставшиеся события.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Длинный опрос событий",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Курсор из предыдущего ответа",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Типы событий через запятую; по умолчанию все",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сколько секунд ждать событий (по умолчанию 25, не больше 60)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "События и курсор следующего опроса",
+                        "schema": {
+                            "$ref": "#/definitions/events.FeedPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный курсор или тайм-аут",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/experiments": {
+            "get": {
+                "description": "Посетитель определяется по заголовку X-User-ID, иначе по cookie experiment_uid, которая выдаётся при первом запросе. Варианты также приходят в заголовке X-Experiments любого ответа.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiments"
+                ],
+                "summary": "Варианты экспериментов посетителя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вариант по ключу эксперимента",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/imports": {
+            "post": {
+                "description": "Продукты создаются сразу. Изображения из image_urls скачиваются в фоне, уменьшаются и прикрепляются как вложения; итог по каждой ссылке — в задаче импорта.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Импортировать продукты с изображениями",
+                "parameters": [
+                    {
+                        "description": "Продукты и ссылки на изображения",
+                        "name": "products",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ImportItem"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Задача импорта создана",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты или cost_price",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/imports/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Состояние задачи импорта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID задачи импорта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Задача и результат по каждой ссылке",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/price-schedules/{id}": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PriceSchedules"
+                ],
+                "summary": "Отменить ожидающее изменение цены",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Изменение цены отменено",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ожидающее изменение не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products": {
+            "get": {
+                "description": "Название и описание локализуются по параметру lang или заголовку Accept-Language. limit не может превышать LIST_MAX_LIMIT (500), а без limit список отдаётся, только пока в каталоге не больше LIST_UNBOUNDED_MAX (1000) продуктов. Для списка категории незаданные limit и sort берутся из настроек её выдачи, а продукты получают значки выдачи.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Получение списка всех продуктов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество продуктов; 0 — все или размер страницы категории",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Полнотекстовый поиск по названию, категориям и описанию",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Порядок: relevance, price_asc, price_desc, name или newest",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык ответа (например, en)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры пагинации или список без limit для большого каталога",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Повтор запроса с тем же телом от того же клиента в течение DEDUP_WINDOW не создаёт продукты заново, а получает ответ первого запроса с заголовком X-Deduplicated: true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Добавить один или несколько продуктов",
+                "parameters": [
+                    {
+                        "description": "Данные продуктов",
+                        "name": "products",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Продукты успешно добавлены",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты или cost_price",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/lookup": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Найти продукт по штрихкоду или SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Штрихкод",
+                        "name": "barcode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Артикул (SKU)",
+                        "name": "sku",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык ответа (например, en)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Найденный продукт",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Не указан штрихкод или SKU",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Обновить данные продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные продукта",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Продукт успешно обновлен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты или cost_price",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Продукт перемещается в корзину и может быть восстановлен до окончания окна хранения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Удалить продукт",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Продукт успешно удален",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Удалять продукты может только admin",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Requires the delete permission (admin role or an API key with the delete scope).",
+                        "type": "changed",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/products/{id}/attachments": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Список вложений продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attachment"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attachments"
+                ],
+                "summary": "Загрузить вложение продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файл вложения",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Вложение загружено",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Недопустимый тип или размер файла",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/jsonld": {
+            "get": {
+                "description": "Разметка schema.org/Product с ценой, наличием и рейтингом для SSR-страниц и SEO-инструментов.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Структурированные данные продукта (JSON-LD)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык ответа (например, en)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Разметка schema.org",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductJSONLD"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/price-schedules": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PriceSchedules"
+                ],
+                "summary": "Список запланированных изменений цены продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PriceSchedule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PriceSchedules"
+                ],
+                "summary": "Запланировать изменение цены продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена и период действия",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreatePriceScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Изменение цены запланировано",
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/translations": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Список переводов продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProductTranslation"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/translations/{locale}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Создать или обновить перевод продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локаль (например, en или en-us)",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Переведённые поля",
+                        "name": "translation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductTranslation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод сохранён",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductTranslation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translations"
+                ],
+                "summary": "Удалить перевод продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локаль",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод удалён",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/boosts": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Множители ранжирования поиска",
+                "responses": {
+                    "200": {
+                        "description": "Множители",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchBoosts"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Релевантность найденного продукта умножается на множители рекомендуемых продуктов, продуктов в наличии и уровня маржи. Значение 1 отключает усиление. Переиндексация не нужна.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Заменить множители ранжирования поиска",
+                "parameters": [
+                    {
+                        "description": "Множители",
+                        "name": "boosts",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchBoosts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Множители сохранены",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchBoosts"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные множители",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/did-you-mean": {
+            "get": {
+                "description": "Если по запросу нашлось мало продуктов, предлагает запрос, в котором слова с опечатками заменены похожими словами каталога.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Подсказка \"возможно, вы имели в виду\"",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подсказка",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchSuggestion"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/featured": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Рекомендуемые продукты",
+                "responses": {
+                    "200": {
+                        "description": "ID рекомендуемых продуктов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/featured/{id}": {
+            "put": {
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Отметить продукт как рекомендуемый",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Продукт отмечен"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Снять отметку рекомендуемого продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Отметка снята"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не отмечен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/index": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Состояние поискового индекса",
+                "responses": {
+                    "200": {
+                        "description": "Версия словаря и число продуктов в очереди переиндексации",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchIndexStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/reindex": {
+            "post": {
+                "description": "Переиндексация выполняется в фоне; ход виден в GET /api/search/index.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Переиндексировать все продукты",
+                "responses": {
+                    "202": {
+                        "description": "Переиндексация запущена",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchIndexStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/stopwords": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Стоп-слова поиска",
+                "responses": {
+                    "200": {
+                        "description": "Стоп-слова",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/stopwords/{word}": {
+            "put": {
+                "description": "Стоп-слова не индексируются и отбрасываются из поисковых запросов. Изменение запускает переиндексацию продуктов.",
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Добавить стоп-слово",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Стоп-слово",
+                        "name": "word",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Стоп-слово добавлено"
+                    },
+                    "400": {
+                        "description": "Некорректное слово",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Удалить стоп-слово",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Стоп-слово",
+                        "name": "word",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Стоп-слово удалено"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Стоп-слово не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/synonyms": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Группы синонимов поиска",
+                "responses": {
+                    "200": {
+                        "description": "Группы синонимов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SynonymGroup"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Слова группы считаются равнозначными при поиске. Изменение запускает переиндексацию продуктов.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Добавить группу синонимов",
+                "parameters": [
+                    {
+                        "description": "Слова группы",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SynonymsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Группа создана",
+                        "schema": {
+                            "$ref": "#/definitions/models.SynonymGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные слова",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/synonyms/{id}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Заменить слова группы синонимов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Слова группы",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SynonymsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Группа обновлена",
+                        "schema": {
+                            "$ref": "#/definitions/models.SynonymGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные слова",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Удалить группу синонимов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Группа удалена"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/settings": {
+            "get": {
+                "description": "Логотип, цвет оформления, валюта, язык по умолчанию и контакты витрины арендатора из заголовка X-Tenant. У арендатора без настроек валюта и язык — значения сервера.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Настройки витрины",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Арендатор (по умолчанию default)",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Настройки витрины",
+                        "schema": {
+                            "$ref": "#/definitions/models.StorefrontSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное имя арендатора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет настройки витрины арендатора из заголовка X-Tenant целиком; пустое поле означает «не задано». Требуется токен администратора.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Сохранить настройки витрины",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Арендатор (по умолчанию default)",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Настройки витрины",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StorefrontSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Настройки сохранены",
+                        "schema": {
+                            "$ref": "#/definitions/models.StorefrontSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные настройки",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Список удалённых продуктов, доступных для восстановления",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TrashedProduct"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash/{id}/restore": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Восстановить удалённый продукт",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Продукт восстановлен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена: запись продуктов требует аутентификации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль не даёт права менять продукты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукта нет в корзине или окно восстановления истекло",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Мой профиль",
+                "responses": {
+                    "200": {
+                        "description": "Профиль",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не принадлежит пользователю API",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Profile of the calling API user.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            },
+            "put": {
+                "description": "Смена email или пароля требует current_password. Смена пароля завершает все сессии пользователя, включая текущую.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Изменить мой профиль",
+                "parameters": [
+                    {
+                        "description": "Изменения профиля",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Профиль",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные email, имя или пароль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Неверный текущий пароль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не принадлежит пользователю API",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email занят",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "Update email, display name or password of the calling API user.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/users/me/identities": {
+            "get": {
+                "description": "Учётные записи Google и GitHub, через которые пользователь входит.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Мои учётные записи у провайдеров",
+                "responses": {
+                    "200": {
+                        "description": "Учётные записи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserIdentity"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не принадлежит пользователю API",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "x-changelog": [
+                    {
+                        "date": "2026-10-15",
+                        "description": "OAuth2 identities linked to the calling API user.",
+                        "type": "added",
+                        "version": "1.1"
+                    }
+                ]
+            }
+        },
+        "/api/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Список вебхуков",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Секрет для проверки подписи возвращается только в этом ответе. Необязательный key задаёт постоянный внешний ключ; занятый ключ — 409. URL должен вести на публичный адрес: доставки во внутреннюю сеть и перенаправления не выполняются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Зарегистрировать вебхук",
+                "parameters": [
+                    {
+                        "description": "URL получателя и типы событий",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Вебхук создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ключ занят",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/by-key/{key}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Вебхук по ключу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Внешний ключ вебхука",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вебхук",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вебхук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Идемпотентно приводит вебхук с постоянным внешним ключом к состоянию из тела запроса — для Terraform и других декларативных инструментов. Незаданные поля получают значения по умолчанию, кроме секрета: без него секрет существующего вебхука не меняется, а сгенерированный для нового возвращается только в ответе на создание.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Создать или заменить вебхук по ключу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Внешний ключ вебхука",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Желаемое состояние вебхука",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PutWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вебхук заменён",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "201": {
+                        "description": "Вебхук создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Удалить вебхук по ключу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Внешний ключ вебхука",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Вебхук удалён"
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вебхук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/events": {
+            "get": {
+                "description": "Для каждого типа события возвращает описание, JSON Schema поля data и пример.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Каталог типов событий вебхуков",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/events.Definition"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Удалить вебхук",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вебхук удалён",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вебхук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/webhooks/{id}/test": {
+            "post": {
+                "description": "Синхронно доставляет подписанный пример события и возвращает результат доставки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Отправить тестовое событие вебхуку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Тип события (по умолчанию product.created)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TestWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат доставки",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный тип события",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Вебхук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Строгий режим: неизвестные поля или значения не того типа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FieldErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ws/protocol": {
+            "get": {
+                "description": "Машиночитаемое описание протокола чата /api/ws и потока /api/ws/products: JSON Schema конверта {type, version, id, payload}, схемы полезной нагрузки входящих и исходящих сообщений по типу и версии и коды ошибок фреймов error. Схемы входящих сообщений — те же, по которым сервер проверяет фреймы.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Протокол WebSocket",
+                "responses": {
+                    "200": {
+                        "description": "Описание протокола",
+                        "schema": {
+                            "$ref": "#/definitions/ws.Protocol"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ws/stats": {
+            "get": {
+                "description": "Подключения, комнаты, сообщения в секунду и счётчики отброшенных сообщений этого экземпляра — те же значения, что ws_* в /metrics. Счётчики растут с запуска сервера.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Состояние чата WebSocket",
+                "responses": {
+                    "200": {
+                        "description": "Состояние чата",
+                        "schema": {
+                            "$ref": "#/definitions/ws.Stats"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/embed/products/{id}": {
+            "get": {
+                "description": "HTML-страница с названием, ценой и наличием продукта для встраивания на сайты партнёров через iframe. Данные продукта встроены в страницу блоком application/json, а код и стили разрешены в Content-Security-Policy по хешу; цена и наличие обновляются по событиям /api/ws/products. Название локализуется по параметру lang или заголовку Accept-Language.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Embed"
+                ],
+                "summary": "Виджет продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык виджета (например, en)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница виджета",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/embed/products/{id}/data": {
+            "get": {
+                "description": "Цена, наличие и изображение продукта, которыми виджет обновляет себя после событий продукта.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Embed"
+                ],
+                "summary": "Данные виджета продукта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык названия (например, en)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductWidget"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Возвращает 503, пока после запуска прогреваются пулы соединений, словарь поиска и кэш списка продуктов, и после начала остановки сервера. Балансировщик должен направлять запросы только на экземпляры с ответом 200.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Готовность к трафику",
+                "responses": {
+                    "200": {
+                        "description": "ready",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "warming up или shutting down",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ResourceTypes": {
+            "get": {
+                "description": "Сервер поддерживает один тип ресурсов — User. Требуется токен SCIM.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Типы ресурсов SCIM",
+                "responses": {
+                    "200": {
+                        "description": "Типы ресурсов",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ServiceProviderConfig": {
+            "get": {
+                "description": "Какие возможности SCIM 2.0 поддерживает сервер: PATCH и фильтры есть, массовых операций, сортировки и смены пароля нет. Требуется токен SCIM.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Возможности SCIM",
+                "responses": {
+                    "200": {
+                        "description": "Возможности сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users": {
+            "get": {
+                "description": "Страница сотрудников в порядке заведения. Из фильтров поддерживаются userName eq \"...\" (без учёта регистра) и externalId eq \"...\". Требуется токен SCIM.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Сотрудники",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Фильтр, например userName eq \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер первого результата, с 1",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 100, не больше 500)",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница сотрудников",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неподдерживаемый фильтр",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Заводит учётную запись сотрудника. Имя удалённого сотрудника можно занять снова. Требуется токен SCIM.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Завести сотрудника",
+                "parameters": [
+                    {
+                        "description": "Пользователь",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Сотрудник заведён",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные атрибуты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users/{id}": {
+            "get": {
+                "description": "Требуется токен SCIM.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Сотрудник",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сотрудника",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сотрудник",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMUser"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Сотрудник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет атрибуты сотрудника целиком; отсутствующий атрибут очищается, а без active сотрудник активен. Отключённый сотрудник отключается от чата. Требуется токен SCIM.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Заменить сотрудника",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сотрудника",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пользователь",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сотрудник изменён",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные атрибуты",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Сотрудник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет учётную запись: токены сотрудника перестают действовать, а его подключения к чату закрываются. Требуется токен SCIM.",
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Удалить сотрудника",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сотрудника",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сотрудник удалён"
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Сотрудник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Применяет операции add, replace и remove к атрибутам userName, externalId, displayName, active, emails и roles. Так провайдер удостоверений обычно отключает сотрудника: replace active false. Требуется токен SCIM.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Изменить сотрудника",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сотрудника",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Операции",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMPatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сотрудник изменён",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная операция",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет токена SCIM",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Сотрудник не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка на сервере",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Публичное состояние сервиса: время работы экземпляра, состояние зависимостей и инциденты — периоды быстрого сгорания бюджета ошибок SLO — за STATUS_INCIDENT_WINDOW. Отвечает HTML-страницей, а при format=json или Accept: application/json — JSON. Состояние собирается не чаще раза в 10 секунд.",
+                "produces": [
+                    "text/html",
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Страница статуса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "json — ответ в JSON",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Состояние сервиса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServiceStatus"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "events.Definition": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "example": {},
+                "schema": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "id": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "RequestID — ID запроса, в ходе которого произошло событие; позволяет\nпроследить путь от API-вызова до вебхука или фрейма WebSocket.",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "events.FeedPage": {
+            "type": "object",
+            "properties": {
+                "cursor": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/events.Event"
+                    }
+                },
+                "gap": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "graphql.FieldUsage": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "graphql.OperationUsage": {
+            "type": "object",
+            "properties": {
+                "client_name": {
+                    "type": "string"
+                },
+                "client_version": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "type": "string"
+                }
+            }
+        },
+        "graphql.UsageReport": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/graphql.FieldUsage"
+                    }
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/graphql.OperationUsage"
+                    }
+                },
+                "since": {
+                    "type": "string"
+                },
+                "since_local": {
+                    "description": "SinceLocal — Since для людей в часовом поясе из заголовка Time-Zone.",
+                    "type": "string",
+                    "example": "15 Oct 2026 09:00 MSK"
+                }
+            }
+        },
+        "handlers.CSRFTokenResponse": {
+            "type": "object",
+            "properties": {
+                "csrf_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CatalogDiffRequest": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "description": "APIKey — токен другого экземпляра; с токеном администратора\nсравнивается и себестоимость.",
+                    "type": "string"
+                },
+                "apply": {
+                    "description": "Apply переносит отличия в локальный каталог.",
+                    "type": "boolean"
+                },
+                "url": {
+                    "description": "URL — базовый адрес другого экземпляра сервиса.",
+                    "type": "string",
+                    "example": "https://staging.example.com"
+                }
+            }
+        },
+        "handlers.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt — срок действия; без него ключ бессрочный.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "feed-importer"
+                },
+                "scopes": {
+                    "description": "Scopes — права ключа: read, write, delete.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "handlers.CreatePriceScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Password — от 8 до 72 байт.",
+                    "type": "string",
+                    "example": "correct horse battery staple"
+                },
+                "role": {
+                    "description": "Role — admin, editor, moderator или viewer; по умолчанию viewer.",
+                    "type": "string",
+                    "example": "editor"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "handlers.DeadLetterIDsRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FieldErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Request body does not match the model"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jsonnaming.FieldError"
+                    }
+                }
+            }
+        },
+        "handlers.ImageURLResponse": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "correct horse battery staple"
+                },
+                "username": {
+                    "description": "Username — имя или email.",
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "handlers.OAuthProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "github",
+                        "google"
+                    ]
+                }
+            }
+        },
+        "handlers.PutWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active по умолчанию true.",
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "product.created"
+                    ]
+                },
+                "secret": {
+                    "description": "Secret — секрет подписи (16-64 символа); без него у нового вебхука\nсекрет генерируется, у существующего остаётся прежним.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://crm.example.com/hooks/catalog"
+                }
+            }
+        },
+        "handlers.ReassignCategoryRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "phones"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "smartphones"
+                }
+            }
+        },
+        "handlers.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RegisterRequest": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string",
+                    "example": "Катя"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "katya@example.com"
+                },
+                "password": {
+                    "description": "Password — от 8 до 72 байт.",
+                    "type": "string",
+                    "example": "correct horse battery staple"
+                },
+                "username": {
+                    "description": "Username — 3-32 латинские буквы, цифры, '.', '-' или '_'; под этим\nименем пользователь пишет в чат.",
+                    "type": "string",
+                    "example": "katya"
+                }
+            }
+        },
+        "handlers.ReplayEventsRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "limit": {
+                    "description": "Limit — максимальное число событий за один вызов (по умолчанию 1000).",
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "types": {
+                    "description": "Types ограничивает повтор указанными типами событий; пусто — все типы.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.SCIMError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "userName is already taken"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scimType": {
+                    "type": "string",
+                    "example": "uniqueness"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "409"
+                }
+            }
+        },
+        "handlers.SCIMListResponse": {
+            "type": "object",
+            "properties": {
+                "Resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SCIMUser"
+                    }
+                },
+                "itemsPerPage": {
+                    "type": "integer"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.SCIMMeta": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string",
+                    "example": "https://shop.example.com/scim/v2/Users/2819c223a7f3e4b1d0c5f6a7b8c9d0e1"
+                },
+                "resourceType": {
+                    "type": "string",
+                    "example": "User"
+                }
+            }
+        },
+        "handlers.SCIMPatchOperation": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "type": "string",
+                    "example": "replace"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
+        "handlers.SCIMPatchRequest": {
+            "type": "object",
+            "properties": {
+                "Operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SCIMPatchOperation"
+                    }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.SCIMUser": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "displayName": {
+                    "type": "string",
+                    "example": "Alice Smith"
+                },
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SCIMValue"
+                    }
+                },
+                "externalId": {
+                    "type": "string",
+                    "example": "00u1ab2cd3EF4gh5i6j7"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "2819c223a7f3e4b1d0c5f6a7b8c9d0e1"
+                },
+                "meta": {
+                    "$ref": "#/definitions/handlers.SCIMMeta"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SCIMValue"
+                    }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userName": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "handlers.SCIMValue": {
+            "type": "object",
+            "properties": {
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "work"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "moderator"
+                }
+            }
+        },
+        "handlers.SanctionRequest": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Duration — срок в формате Go, например 30m или 24h; пустой — бессрочно.",
+                    "type": "string",
+                    "example": "24h"
+                },
+                "kind": {
+                    "description": "Kind — mute или ban.",
+                    "type": "string",
+                    "example": "ban"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "spam"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "handlers.SynonymsRequest": {
+            "type": "object",
+            "properties": {
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "laptop",
+                        "notebook"
+                    ]
+                }
+            }
+        },
+        "handlers.TestWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "event": {
+                    "type": "string",
+                    "example": "product.created"
+                }
+            }
+        },
+        "handlers.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "description": "CurrentPassword нужен для смены email или пароля.",
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Катя"
+                },
+                "email": {
+                    "description": "Email — пустая строка удаляет адрес.",
+                    "type": "string",
+                    "example": "katya@example.com"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "jsonnaming.FieldError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "unknown field"
+                },
+                "path": {
+                    "description": "Path — путь к полю с именами из запроса, например items[0].pricee;\nпусто — весь документ.",
+                    "type": "string",
+                    "example": "pricee"
+                }
+            }
+        },
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "Key — сам ключ; только в ответе на создание.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "feed-importer"
+                },
+                "prefix": {
+                    "description": "Prefix — начало ключа, по которому его можно узнать в списке.",
+                    "type": "string",
+                    "example": "ak_3f9c2b1e"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes — права ключа: read, write, delete.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "models.AggregateRatingJSONLD": {
+            "type": "object",
+            "properties": {
+                "@type": {
+                    "type": "string"
+                },
+                "bestRating": {
+                    "type": "integer"
+                },
+                "ratingValue": {
+                    "type": "number"
+                },
+                "reviewCount": {
+                    "type": "integer"
+                },
+                "worstRating": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Attachment": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_sets": {
+                    "description": "ImageSets — те же пресеты в виде для \u003cpicture\u003e/srcset: источники в\nсовременных форматах и запасной вариант в исходном формате.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ImageSet"
+                    }
+                },
+                "images": {
+                    "description": "Images — подписанные ссылки на уменьшенные варианты изображения по\nназванию пресета; только для картинок и при настроенном imgproxy.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action — create, update или delete по методу запроса.",
+                    "type": "string",
+                    "example": "update"
+                },
+                "actor": {
+                    "description": "Actor — subject вызывающего: ID пользователя API, api-key:\u003cid\u003e или\nadmin-token; пусто — анонимный запрос.",
+                    "type": "string",
+                    "example": "7"
+                },
+                "actor_name": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "changes": {
+                    "description": "Changes — изменённые поля с прежним и новым значениями. Прежние\nзначения известны, если их сообщил сервис; иначе в new — поля тела\nзапроса. Пароли, секреты и токены заменены на [redacted].",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.AuditChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "method": {
+                    "type": "string",
+                    "example": "PUT"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/api/products/42"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "resource": {
+                    "description": "Resource — шаблон маршрута, например /api/products/:id, а у\nGraphQL — graphql: и имена полей мутации.",
+                    "type": "string",
+                    "example": "/api/products/:id"
+                },
+                "resource_id": {
+                    "type": "string",
+                    "example": "42"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "models.CatalogChange": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "price",
+                        "description"
+                    ]
+                },
+                "key": {
+                    "type": "string",
+                    "example": "SKU-42"
+                },
+                "local": {
+                    "description": "Local — продукт локального каталога, Remote — другого окружения.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    ]
+                },
+                "remote": {
+                    "$ref": "#/definitions/models.Product"
+                }
+            }
+        },
+        "models.CatalogDiff": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "description": "Added — продукты, которых нет в локальном каталоге.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Product"
+                    }
+                },
+                "ambiguous": {
+                    "description": "Ambiguous — ключи сопоставления, под которыми в одном из каталогов\nнесколько продуктов; такие продукты не сравниваются и не меняются.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "applied": {
+                    "description": "Applied — отличия перенесены в локальный каталог.",
+                    "type": "boolean"
+                },
+                "changed": {
+                    "description": "Changed — продукты, поля которых отличаются.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CatalogChange"
+                    }
+                },
+                "removed": {
+                    "description": "Removed — локальные продукты, которых нет в другом окружении.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Product"
+                    }
+                }
+            }
+        },
+        "models.CategoryListing": {
+            "type": "object",
+            "properties": {
+                "badges": {
+                    "description": "Badges — значки, которые выдача показывает у продуктов.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "featured",
+                        "in_stock"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "Ноутбуки"
+                },
+                "page_size": {
+                    "description": "PageSize — размер страницы по умолчанию; 0 — без ограничения.",
+                    "type": "integer",
+                    "example": 24
+                },
+                "sort": {
+                    "description": "Sort — порядок по умолчанию; пусто — по релевантности.",
+                    "type": "string",
+                    "example": "price_asc"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CategoryReassignment": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "phones"
+                },
+                "listing_moved": {
+                    "description": "ListingMoved — настройки выдачи From перенесены на To; если у To были\nсвои настройки, они сохраняются, а настройки From удаляются.",
+                    "type": "boolean"
+                },
+                "merged": {
+                    "description": "Merged — у части продуктов уже была категория To, и From у них просто\nудалена.",
+                    "type": "integer"
+                },
+                "products": {
+                    "description": "Products — сколько продуктов, включая продукты в корзине, сменили\nкатегорию.",
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "smartphones"
+                }
+            }
+        },
+        "models.Changelog": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChangelogEntry"
+                    }
+                },
+                "version": {
+                    "description": "Version — текущая версия API.",
+                    "type": "string",
+                    "example": "1.1"
+                }
+            }
+        },
+        "models.ChangelogEntry": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2026-10-15"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Self-registration of viewer accounts."
+                },
+                "method": {
+                    "description": "Method и Path — операция; пусто у изменений всего API.",
+                    "type": "string",
+                    "example": "POST"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/api/auth/register"
+                },
+                "type": {
+                    "description": "Type — added, changed, deprecated или removed.",
+                    "type": "string",
+                    "example": "added"
+                },
+                "version": {
+                    "description": "Version — версия API, в которой появилось изменение.",
+                    "type": "string",
+                    "example": "1.1"
+                }
+            }
+        },
+        "models.ChatImage": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 960
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 183204
+                },
+                "thumbnail_url": {
+                    "type": "string",
+                    "example": "/api/chat/images/9f86d081884c7d659a2feaa0c55ad015/thumbnail"
+                },
+                "uploader": {
+                    "description": "Uploader — пользователь чата, загрузивший изображение; пусто без\nаутентификации.",
+                    "type": "string",
+                    "example": "alice"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/api/chat/images/9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1280
+                }
+            }
+        },
+        "models.ChatMessage": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "description": "Attachments — изображения сообщения.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChatImage"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "description": "EditedAt — когда текст сообщения последний раз исправили.",
+                    "type": "string"
+                },
+                "envelope_id": {
+                    "description": "EnvelopeID — id конверта chat.message, в котором сообщение разослано\nклиентам v2; по нему клиент отбрасывает повторы из истории.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Привет!"
+                },
+                "recipient": {
+                    "description": "Recipient — получатель личного сообщения; у сообщений комнаты пусто.",
+                    "type": "string"
+                },
+                "room": {
+                    "type": "string",
+                    "example": "general"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "models.ChatSanction": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "CreatedBy — модератор, наложивший ограничение.",
+                    "type": "string",
+                    "example": "alice"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt — когда ограничение снимется само; без него — бессрочно.",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "Kind — mute или ban.",
+                    "type": "string",
+                    "example": "mute"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "spam"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "troll"
+                }
+            }
+        },
+        "models.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "database"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "operational"
+                }
+            }
+        },
+        "models.Experiment": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "new_search_ranking"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperimentVariant"
+                    }
+                }
+            }
+        },
+        "models.ExperimentResults": {
+            "type": "object",
+            "properties": {
+                "experiment_id": {
+                    "type": "integer"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantExposure"
+                    }
+                }
+            }
+        },
+        "models.ExperimentVariant": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "control"
+                },
+                "weight": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "models.ImageImport": {
+            "type": "object",
+            "properties": {
+                "attachment_id": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImageSet": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageSource"
+                    }
+                },
+                "src": {
+                    "description": "Src и SrcSet — запасной вариант для браузеров без поддержки Sources.",
+                    "type": "string"
+                },
+                "srcset": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImageSource": {
+            "type": "object",
+            "properties": {
+                "srcset": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportItem": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "badges": {
+                    "description": "Badges — значки, которые показывает выдача категории, например featured.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "barcode": {
+                    "type": "string"
+                },
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "cost_price": {
+                    "description": "CostPrice — себестоимость и Margin — доля маржи (price - cost_price) /\nprice; внутренние данные, которые по auth.FieldPolicy видят только\nадминистраторы.",
+                    "type": "number"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_urls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "locale": {
+                    "description": "Locale — язык, на котором возвращены name и description (пусто для языка по умолчанию).",
+                    "type": "string"
+                },
+                "margin": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "upcoming_prices": {
+                    "description": "UpcomingPrices — запланированные, но ещё не применённые изменения цены.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceSchedule"
+                    }
+                }
+            }
+        },
+        "models.ImportJob": {
+            "type": "object",
+            "properties": {
+                "attached": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageImport"
+                    }
+                },
+                "product_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Incident": {
+            "type": "object",
+            "properties": {
+                "resolved_at": {
+                    "description": "ResolvedAt — окончание инцидента; без него инцидент продолжается.",
+                    "type": "string"
+                },
+                "route": {
+                    "type": "string",
+                    "example": "GET /api/products"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MarginTier": {
+            "type": "object",
+            "properties": {
+                "boost": {
+                    "type": "number",
+                    "example": 1.2
+                },
+                "min_margin": {
+                    "type": "number",
+                    "example": 0.3
+                }
+            }
+        },
+        "models.OfferJSONLD": {
+            "type": "object",
+            "properties": {
+                "@type": {
+                    "type": "string"
+                },
+                "availability": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "priceCurrency": {
+                    "type": "string"
+                },
+                "priceValidUntil": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PriceSchedule": {
+            "type": "object",
+            "properties": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Product": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "badges": {
+                    "description": "Badges — значки, которые показывает выдача категории, например featured.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "barcode": {
+                    "type": "string"
+                },
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "cost_price": {
+                    "description": "CostPrice — себестоимость и Margin — доля маржи (price - cost_price) /\nprice; внутренние данные, которые по auth.FieldPolicy видят только\nадминистраторы.",
+                    "type": "number"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "locale": {
+                    "description": "Locale — язык, на котором возвращены name и description (пусто для языка по умолчанию).",
+                    "type": "string"
+                },
+                "margin": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "upcoming_prices": {
+                    "description": "UpcomingPrices — запланированные, но ещё не применённые изменения цены.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceSchedule"
+                    }
+                }
+            }
+        },
+        "models.ProductJSONLD": {
+            "type": "object",
+            "properties": {
+                "@context": {
+                    "type": "string"
+                },
+                "@id": {
+                    "type": "string"
+                },
+                "@type": {
+                    "type": "string"
+                },
+                "aggregateRating": {
+                    "$ref": "#/definitions/models.AggregateRatingJSONLD"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "gtin": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "offers": {
+                    "$ref": "#/definitions/models.OfferJSONLD"
+                },
+                "sku": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductTranslation": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductWidget": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image": {
+                    "description": "Image — ссылка на изображение продукта; относительная, если imgproxy не\nнастроен.",
+                    "type": "string"
+                },
+                "in_stock": {
+                    "description": "InStock — есть ли продукт на складах; без данных об остатках не\nуказывается.",
+                    "type": "boolean"
+                },
+                "locale": {
+                    "description": "Locale — язык названия (пусто для языка по умолчанию).",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "url": {
+                    "description": "URL — страница продукта на сайте, если задан PRODUCT_PAGE_URL.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReplayResult": {
+            "type": "object",
+            "properties": {
+                "delivered": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebhookDelivery"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RetryOutcome": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SearchBoosts": {
+            "type": "object",
+            "properties": {
+                "featured": {
+                    "description": "Featured — для продуктов, отмеченных как рекомендуемые.",
+                    "type": "number",
+                    "example": 2
+                },
+                "in_stock": {
+                    "description": "InStock — для продуктов, которые есть хотя бы на одном складе.",
+                    "type": "number",
+                    "example": 1.5
+                },
+                "margin_tiers": {
+                    "description": "MarginTiers — уровни маржи; применяется уровень с наибольшим\nMinMargin, которого достигает продукт.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MarginTier"
+                    }
+                }
+            }
+        },
+        "models.SearchIndexStatus": {
+            "type": "object",
+            "properties": {
+                "pending": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SearchSuggestion": {
+            "type": "object",
+            "properties": {
+                "did_you_mean": {
+                    "type": "string",
+                    "example": "ноутбук"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "нотбук"
+                }
+            }
+        },
+        "models.ServiceStatus": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "dependencies": {
+                    "description": "Dependencies — состояние зависимостей без подробностей ошибок.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DependencyStatus"
+                    }
+                },
+                "incidents": {
+                    "description": "Incidents — недавние инциденты, новые первыми.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Incident"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status — operational, degraded или outage.",
+                    "type": "string",
+                    "example": "operational"
+                },
+                "uptime_seconds": {
+                    "type": "integer",
+                    "example": 86400
+                }
+            }
+        },
+        "models.StorefrontSettings": {
+            "type": "object",
+            "properties": {
+                "contact_email": {
+                    "type": "string",
+                    "example": "shop@example.com"
+                },
+                "contact_phone": {
+                    "type": "string",
+                    "example": "+7 495 000-00-00"
+                },
+                "currency": {
+                    "description": "Currency — валюта цен витрины (ISO 4217).",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "default_locale": {
+                    "description": "DefaultLocale — язык витрины, если клиент не выбрал свой.",
+                    "type": "string",
+                    "example": "ru"
+                },
+                "logo_url": {
+                    "description": "LogoURL — адрес логотипа, абсолютный http(s).",
+                    "type": "string",
+                    "example": "https://cdn.example.com/logo.svg"
+                },
+                "primary_color": {
+                    "description": "PrimaryColor — основной цвет оформления, #RRGGBB.",
+                    "type": "string",
+                    "example": "#1a73e8"
+                },
+                "tenant": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SynonymGroup": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Tokens": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
                 }
             }
         },
-        "main.Product": {
+        "models.TrashedProduct": {
             "type": "object",
             "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "badges": {
+                    "description": "Badges — значки, которые показывает выдача категории, например featured.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "barcode": {
+                    "type": "string"
+                },
                 "categories": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "cost_price": {
+                    "description": "CostPrice — себестоимость и Margin — доля маржи (price - cost_price) /\nprice; внутренние данные, которые по auth.FieldPolicy видят только\nадминистраторы.",
+                    "type": "number"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "locale": {
+                    "description": "Locale — язык, на котором возвращены name и description (пусто для языка по умолчанию).",
+                    "type": "string"
+                },
+                "margin": {
+                    "type": "number"
+                },
                 "name": {
                     "type": "string"
                 },
                 "price": {
                     "type": "number"
+                },
+                "purge_at": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "upcoming_prices": {
+                    "description": "UpcomingPrices — запланированные, но ещё не применённые изменения цены.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceSchedule"
+                    }
                 }
             }
         },
-        "main.UpdateProductRequest": {
+        "models.UnreadMessages": {
             "type": "object",
             "properties": {
-                "categories": {
+                "by_sender": {
+                    "description": "BySender — число непрочитанных сообщений по отправителям.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Катя"
+                },
+                "email": {
+                    "description": "Email уникален без учёта регистра; по нему тоже можно войти.",
+                    "type": "string",
+                    "example": "editor@example.com"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "description": "Role — admin, editor, moderator или viewer.",
+                    "type": "string",
+                    "example": "editor"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "models.UserIdentity": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email — адрес у провайдера на момент привязки.",
+                    "type": "string",
+                    "example": "katya@example.com"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "github"
+                }
+            }
+        },
+        "models.VariantExposure": {
+            "type": "object",
+            "properties": {
+                "subjects": {
+                    "type": "integer"
+                },
+                "variant": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "description": {
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "Key — постоянный внешний ключ, по которому вебхуком управляют\nдекларативно (PUT /api/webhooks/by-key/{key}); у вебхуков, созданных\nбез ключа, пуст.",
+                    "type": "string",
+                    "example": "orders-to-crm"
+                },
+                "secret": {
+                    "description": "Secret возвращается только при создании; им подписываются доставки.",
                     "type": "string"
                 },
-                "name": {
+                "url": {
                     "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
                 },
-                "price": {
+                "error": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ZeroResultSearch": {
+            "type": "object",
+            "properties": {
+                "first_seen": {
+                    "description": "FirstSeen и LastSeen — те же даты для людей в часовом поясе из\nзаголовка Time-Zone.",
+                    "type": "string",
+                    "example": "3 Oct 2026 09:15 MSK"
+                },
+                "first_seen_at": {
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "last_seen": {
+                    "type": "string",
+                    "example": "15 Oct 2026 14:03 MSK"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "беспроводная зарядка"
+                }
+            }
+        },
+        "service.DatasetExport": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "product_id",
+                        "price"
+                    ]
+                },
+                "error": {
+                    "description": "Error — причина неудачи сборки.",
+                    "type": "string"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                }
+            }
+        },
+        "slo.Status": {
+            "type": "object",
+            "properties": {
+                "alerting": {
+                    "type": "boolean"
+                },
+                "budget_remaining": {
+                    "description": "BudgetRemaining — оставшаяся доля бюджета ошибок; отрицательная, если\nцель за окно уже нарушена.",
+                    "type": "number"
+                },
+                "burn_rate_1h": {
+                    "description": "BurnRate1h и BurnRate5m — скорость сгорания бюджета: 1 означает, что\nбюджет закончится ровно к концу окна.",
+                    "type": "number"
+                },
+                "burn_rate_5m": {
                     "type": "number"
+                },
+                "compliance": {
+                    "description": "Compliance — доля хороших запросов за окно; 1, если запросов не было.",
+                    "type": "number"
+                },
+                "good": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "route": {
+                    "type": "string",
+                    "example": "GET /api/products"
+                },
+                "target": {
+                    "type": "number",
+                    "example": 0.995
+                },
+                "window": {
+                    "type": "string",
+                    "example": "24h0m0s"
+                }
+            }
+        },
+        "ws.CloseSpec": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 1013
+                },
+                "description": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "rate limit exceeded"
+                },
+                "retryable": {
+                    "description": "Retryable — стоит ли клиенту переподключиться.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "ws.ErrorSpec": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "invalid_payload"
+                },
+                "description": {
+                    "type": "string"
+                }
+            }
+        },
+        "ws.MessageSpec": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string",
+                    "example": "chat.message"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "ws.Protocol": {
+            "type": "object",
+            "properties": {
+                "close_codes": {
+                    "description": "CloseCodes — коды и причины фреймов закрытия, с которыми сервер\nотключает клиента.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ws.CloseSpec"
+                    }
+                },
+                "envelope": {
+                    "description": "Envelope — JSON Schema конверта входящего сообщения.",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ws.ErrorSpec"
+                    }
+                },
+                "inbound": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ws.MessageSpec"
+                    }
+                },
+                "outbound": {
+                    "description": "Outbound — сообщения сервера в конвертах Envelope, включая события\nпотока /api/ws/products.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ws.MessageSpec"
+                    }
+                },
+                "versions": {
+                    "description": "Versions — версии протокола, которые клиент может предложить в hello.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "ws.RoomPresence": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "Anonymous — подключения, не назвавшие имени.",
+                    "type": "integer"
+                },
+                "room": {
+                    "type": "string",
+                    "example": "general"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "alice",
+                        "bob"
+                    ]
+                }
+            }
+        },
+        "ws.Stats": {
+            "type": "object",
+            "properties": {
+                "connections": {
+                    "description": "Connections — открытые подключения, ConnectionsOpened — все\nподключения с запуска: по скорости его роста видны всплески\nпереподключений.",
+                    "type": "integer",
+                    "example": 120
+                },
+                "connections_opened": {
+                    "type": "integer",
+                    "example": 5400
+                },
+                "dropped_broadcasts": {
+                    "type": "integer"
+                },
+                "dropped_ephemeral": {
+                    "type": "integer"
+                },
+                "dropped_events": {
+                    "description": "DroppedEvents — события продуктов, не попавшие в переполненные\nочереди клиентов; DroppedEphemeral — так же отброшенные индикаторы\nнабора и подтверждения доставки; DroppedBroadcasts — события, которые\nне попали в переполненную очередь рассылки и не достались никому.",
+                    "type": "integer"
+                },
+                "fanout_latency_avg_seconds": {
+                    "description": "FanoutLatencyAvg и FanoutLatencyMax — средняя и наибольшая за\nпоследнюю минуту задержка рассылки в секундах: от того, как Run\nположил конверт в очередь клиента, до записи в его соединение.",
+                    "type": "number",
+                    "example": 0.002
+                },
+                "fanout_latency_max_seconds": {
+                    "type": "number",
+                    "example": 0.05
+                },
+                "limit_disconnects": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "description": "Messages — разосланные сообщения чата, включая личные;\nMessagesPerSecond — их среднее за последнюю минуту.",
+                    "type": "integer",
+                    "example": 90210
+                },
+                "messages_per_second": {
+                    "type": "number",
+                    "example": 3.5
+                },
+                "rooms": {
+                    "description": "Rooms — комнаты, в которых есть хотя бы одно подключение.",
+                    "type": "integer",
+                    "example": 8
+                },
+                "slow_disconnects": {
+                    "description": "SlowDisconnects и LimitDisconnects — клиенты, отключённые из-за\nпереполнения очереди и за превышение ограничений на фреймы.",
+                    "type": "integer"
                 }
             }
         }
@@ -266,12 +8288,12 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
+	Version:          "1.1",
 	Host:             "",
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "TEST API",
-	Description:      "",
+	Description:      "Поля JSON в примерах — в snake_case; с JSON_FIELD_NAMING=camelCase REST отвечает в camelCase и принимает оба стиля. Заданная явно, настройка переводит в тот же стиль и ключи ответов GraphQL (кроме начинающихся с _); без неё GraphQL отвечает ключами по схеме в camelCase.\nЧастота запросов REST ограничена: сверх бюджета ответ — 429 с Retry-After, остаток бюджета — в заголовках X-RateLimit-Limit, X-RateLimit-Remaining и X-RateLimit-Reset.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
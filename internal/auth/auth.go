@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+// Роли пользователей API. Что разрешено каждой роли, описывает
+// rolePermissions.
+const (
+	RoleAdmin = "admin"
+	// RoleEditor может создавать и менять продукты.
+	RoleEditor = "editor"
+	// RoleModerator может ограничивать пользователей чата.
+	RoleModerator = "moderator"
+	// RoleViewer может только читать.
+	RoleViewer = "viewer"
+)
+
+var (
+	// ErrUnauthenticated — запрос без учётных данных или с недействительными.
+	ErrUnauthenticated = errors.New("authentication required")
+	// ErrForbidden — учётные данные верны, но роли недостаточно.
+	ErrForbidden = errors.New("insufficient permissions")
+)
+
+// Principal — аутентифицированный вызывающий.
+type Principal struct {
+	Subject string
+	Role    string
+	// Name — отображаемое имя пользователя; пусто у служебных токенов.
+	Name string
+	// Scopes ограничивает права ключа API; nil — права определяет роль.
+	Scopes []Permission
+	// TokenID — claim jti токена; пусто у токенов без него.
+	TokenID string
+}
+
+// Authenticator проверяет токен из заголовка Authorization (без префикса Bearer).
+type Authenticator interface {
+	Authenticate(token string) (Principal, error)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает вызывающего; ok == false для анонимного запроса.
+func FromContext(ctx context.Context) (Principal, bool) {
+	if ctx == nil {
+		return Principal{}, false
+	}
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Require проверяет, что в контексте есть вызывающий с указанной ролью.
+func Require(ctx context.Context, role string) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if p.Role != role {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// BearerToken извлекает токен из значения заголовка Authorization.
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Chain проверяет токен по очереди каждым Authenticator и принимает первый
+// успешный результат.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(token string) (Principal, error) {
+	for _, authenticator := range c {
+		if principal, err := authenticator.Authenticate(token); err == nil {
+			return principal, nil
+		}
+	}
+	return Principal{}, ErrUnauthenticated
+}
+
+// Namespaced добавляет к имени вызывающего префикс своего источника токенов.
+// Нужен там, где вызывающий определяется только по имени, как в чате: иначе
+// пользователь, зарегистрировавшийся под именем сотрудника, выдавал бы себя
+// за него.
+type Namespaced struct {
+	inner  Authenticator
+	prefix string
+}
+
+func NewNamespaced(inner Authenticator, prefix string) *Namespaced {
+	return &Namespaced{inner: inner, prefix: prefix}
+}
+
+func (n *Namespaced) Authenticate(token string) (Principal, error) {
+	principal, err := n.inner.Authenticate(token)
+	if err != nil {
+		return Principal{}, err
+	}
+	if principal.Name != "" {
+		principal.Name = n.prefix + principal.Name
+	}
+	return principal, nil
+}
+
+// StaticTokens — фиксированный список административных токенов из конфигурации.
+// Хранятся только хеши, сравнение — за постоянное время.
+type StaticTokens struct {
+	hashes [][sha256.Size]byte
+}
+
+func NewStaticTokens(tokens []string) *StaticTokens {
+	t := &StaticTokens{}
+	for _, token := range tokens {
+		t.hashes = append(t.hashes, sha256.Sum256([]byte(token)))
+	}
+	return t
+}
+
+func (t *StaticTokens) Authenticate(token string) (Principal, error) {
+	hash := sha256.Sum256([]byte(token))
+	match := 0
+	for _, h := range t.hashes {
+		match |= subtle.ConstantTimeCompare(hash[:], h[:])
+	}
+	if match == 0 {
+		return Principal{}, ErrUnauthenticated
+	}
+	return Principal{Subject: "admin-token", Role: RoleAdmin}, nil
+}
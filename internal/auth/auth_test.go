@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+type staticAuthenticator map[string]Principal
+
+func (s staticAuthenticator) Authenticate(token string) (Principal, error) {
+	if principal, ok := s[token]; ok {
+		return principal, nil
+	}
+	return Principal{}, ErrUnauthenticated
+}
+
+func TestNamespacedChain(t *testing.T) {
+	staff := staticAuthenticator{"staff-token": {Subject: "7", Name: "alice", Role: RoleModerator}}
+	users := staticAuthenticator{"user-token": {Subject: "42", Name: "alice", Role: RoleViewer}}
+	chain := Chain{staff, NewNamespaced(users, "api:")}
+
+	tests := []struct {
+		token    string
+		wantName string
+		wantErr  error
+	}{
+		{token: "staff-token", wantName: "alice"},
+		{token: "user-token", wantName: "api:alice"},
+		{token: "other", wantErr: ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		principal, err := chain.Authenticate(tt.token)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("Authenticate(%q) error = %v, want %v", tt.token, err, tt.wantErr)
+		}
+		if principal.Name != tt.wantName {
+			t.Fatalf("Authenticate(%q) name = %q, want %q", tt.token, principal.Name, tt.wantName)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+// Account — учётная запись пользователя в каталоге сотрудников.
+type Account struct {
+	Role   string
+	Active bool
+}
+
+// Directory — каталог сотрудников, который ведёт провайдер удостоверений.
+// Account вызывается на каждой проверке токена и не должен ходить в базу.
+type Directory interface {
+	Account(username string) (Account, bool)
+}
+
+// Provisioned проверяет токены через inner и применяет к ним каталог: роль
+// пользователя из каталога заменяет роль из токена, а отключённый или
+// удалённый из каталога пользователь не проходит проверку, даже если срок
+// его токена не истёк. Пользователи, которых в каталоге нет, проверяются
+// только по токену.
+type Provisioned struct {
+	inner     Authenticator
+	directory Directory
+}
+
+func NewProvisioned(inner Authenticator, directory Directory) *Provisioned {
+	return &Provisioned{inner: inner, directory: directory}
+}
+
+func (p *Provisioned) Authenticate(token string) (Principal, error) {
+	principal, err := p.inner.Authenticate(token)
+	if err != nil {
+		return Principal{}, err
+	}
+	account, ok := p.directory.Account(principal.Name)
+	if !ok {
+		return principal, nil
+	}
+	if !account.Active {
+		return Principal{}, ErrUnauthenticated
+	}
+	principal.Role = account.Role
+	return principal, nil
+}
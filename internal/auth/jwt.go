@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// jwtLeeway — допустимое расхождение часов с выпустившим токен сервисом.
+const jwtLeeway = 30 * time.Second
+
+// JWTOptions настраивает проверку JWT.
+type JWTOptions struct {
+	// Secret — общий ключ HMAC-SHA256 (алгоритм HS256).
+	Secret string
+	// Issuer и Audience, если заданы, должны совпадать с iss и aud токена.
+	Issuer   string
+	Audience string
+}
+
+// JWTVerifier проверяет JWT, подписанные HS256. Токены с другим алгоритмом,
+// в том числе "none", отвергаются.
+type JWTVerifier struct {
+	secret   []byte
+	issuer   string
+	audience string
+}
+
+func NewJWTVerifier(opts JWTOptions) *JWTVerifier {
+	return &JWTVerifier{secret: []byte(opts.Secret), issuer: opts.Issuer, audience: opts.Audience}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+type jwtClaims struct {
+	Subject           string   `json:"sub"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Role              string   `json:"role"`
+	Issuer            string   `json:"iss"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         *int64   `json:"exp"`
+	NotBefore         *int64   `json:"nbf"`
+	ID                string   `json:"jti"`
+}
+
+// audience — claim aud, который по RFC 7519 бывает строкой или массивом.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate проверяет подпись и сроки токена. Имя пользователя берётся
+// из preferred_username, затем из name, затем из sub.
+func (v *JWTVerifier) Authenticate(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrUnauthenticated
+	}
+	var header jwtHeader
+	if !decodeSegment(parts[0], &header) || header.Alg != "HS256" {
+		return Principal{}, ErrUnauthenticated
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrUnauthenticated
+	}
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return Principal{}, ErrUnauthenticated
+	}
+
+	var claims jwtClaims
+	if !decodeSegment(parts[1], &claims) || claims.Subject == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	now := time.Now()
+	if claims.ExpiresAt == nil || now.After(time.Unix(*claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return Principal{}, ErrUnauthenticated
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return Principal{}, ErrUnauthenticated
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return Principal{}, ErrUnauthenticated
+	}
+	if v.audience != "" && !claims.Audience.contains(v.audience) {
+		return Principal{}, ErrUnauthenticated
+	}
+
+	name := claims.PreferredUsername
+	if name == "" {
+		name = claims.Name
+	}
+	if name == "" {
+		name = claims.Subject
+	}
+	return Principal{Subject: claims.Subject, Role: claims.Role, Name: name, TokenID: claims.ID}, nil
+}
+
+// JWTSigner выпускает JWT с подписью HS256, которые принимает JWTVerifier с
+// теми же параметрами.
+type JWTSigner struct {
+	secret   []byte
+	issuer   string
+	audience string
+}
+
+func NewJWTSigner(opts JWTOptions) *JWTSigner {
+	return &JWTSigner{secret: []byte(opts.Secret), issuer: opts.Issuer, audience: opts.Audience}
+}
+
+type signedClaims struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Role              string `json:"role,omitempty"`
+	Issuer            string `json:"iss,omitempty"`
+	Audience          string `json:"aud,omitempty"`
+	IssuedAt          int64  `json:"iat"`
+	ExpiresAt         int64  `json:"exp"`
+	ID                string `json:"jti,omitempty"`
+}
+
+// Sign выпускает токен вызывающего p, действующий ttl, и возвращает его
+// вместе со сроком действия. p.TokenID становится claim jti, по которому
+// токен можно отозвать.
+func (s *JWTSigner) Sign(p Principal, ttl time.Duration) (string, time.Time) {
+	now := time.Now()
+	expires := now.Add(ttl)
+	header, _ := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	claims, _ := json.Marshal(signedClaims{
+		Subject: p.Subject, PreferredUsername: p.Name, Role: p.Role,
+		Issuer: s.issuer, Audience: s.audience, IssuedAt: now.Unix(), ExpiresAt: expires.Unix(),
+		ID: p.TokenID,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), expires
+}
+
+func decodeSegment(segment string, v interface{}) bool {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	return err == nil && json.Unmarshal(data, v) == nil
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+// signToken собирает JWT с произвольными заголовком и claims, подписывая
+// его HS256 ключом secret.
+func signToken(t *testing.T, header, claims map[string]interface{}, secret string) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := segment(header) + "." + segment(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTVerifierAuthenticate(t *testing.T) {
+	now := time.Now().Unix()
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"sub": "7", "role": RoleEditor, "preferred_username": "editor", "exp": now + 60}
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	unsignedNone := func() string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+		claims, _ := json.Marshal(valid())
+		return header + "." + base64.RawURLEncoding.EncodeToString(claims) + "."
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		opts  JWTOptions
+		ok    bool
+	}{
+		{name: "valid", token: signToken(t, hs256, valid(), testSecret), ok: true},
+		{name: "wrong secret", token: signToken(t, hs256, valid(), "other-secret")},
+		{name: "alg none", token: unsignedNone()},
+		{name: "alg HS512", token: signToken(t, map[string]interface{}{"alg": "HS512"}, valid(), testSecret)},
+		{name: "alg missing", token: signToken(t, map[string]interface{}{"typ": "JWT"}, valid(), testSecret)},
+		{name: "expired", token: signToken(t, hs256, with("exp", now-int64(jwtLeeway/time.Second)-1), testSecret)},
+		{name: "expired within leeway", token: signToken(t, hs256, with("exp", now-5), testSecret), ok: true},
+		{name: "without exp", token: signToken(t, hs256, with("exp", nil), testSecret)},
+		{name: "not yet valid", token: signToken(t, hs256, with("nbf", now+3600), testSecret)},
+		{name: "without sub", token: signToken(t, hs256, with("sub", nil), testSecret)},
+		{name: "issuer mismatch", token: signToken(t, hs256, with("iss", "other"), testSecret), opts: JWTOptions{Issuer: "api"}},
+		{name: "issuer match", token: signToken(t, hs256, with("iss", "api"), testSecret), opts: JWTOptions{Issuer: "api"}, ok: true},
+		{name: "audience in list", token: signToken(t, hs256, with("aud", []string{"web", "api"}), testSecret), opts: JWTOptions{Audience: "api"}, ok: true},
+		{name: "audience mismatch", token: signToken(t, hs256, with("aud", "web"), testSecret), opts: JWTOptions{Audience: "api"}},
+		{name: "two segments", token: strings.Join(strings.Split(signToken(t, hs256, valid(), testSecret), ".")[:2], ".")},
+		{name: "garbage", token: "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Secret = testSecret
+			principal, err := NewJWTVerifier(opts).Authenticate(tt.token)
+			if !tt.ok {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Fatalf("Authenticate() = %+v, %v; want ErrUnauthenticated", principal, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if principal.Subject != "7" || principal.Role != RoleEditor || principal.Name != "editor" {
+				t.Fatalf("Authenticate() = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTSignerRoundTrip(t *testing.T) {
+	opts := JWTOptions{Secret: testSecret, Issuer: "api", Audience: "web"}
+	token, expires := NewJWTSigner(opts).Sign(Principal{Subject: "7", Role: RoleAdmin, Name: "root", TokenID: "jti-1"}, time.Minute)
+	if time.Until(expires) <= 0 {
+		t.Fatalf("expires = %v, want in the future", expires)
+	}
+	principal, err := NewJWTVerifier(opts).Authenticate(token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	want := Principal{Subject: "7", Role: RoleAdmin, Name: "root", TokenID: "jti-1"}
+	if principal.Subject != want.Subject || principal.Role != want.Role || principal.Name != want.Name || principal.TokenID != want.TokenID {
+		t.Fatalf("Authenticate() = %+v, want %+v", principal, want)
+	}
+
+	// Подпись покрывает claims: токен с подменённой ролью не принимается.
+	parts := strings.Split(token, ".")
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	forged := strings.Replace(string(claims), `"role":"admin"`, `"role":"editor"`, 1)
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(forged))
+	if _, err := NewJWTVerifier(opts).Authenticate(strings.Join(parts, ".")); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("forged token: err = %v, want ErrUnauthenticated", err)
+	}
+}
@@ -0,0 +1,23 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// passwordCost — стоимость bcrypt; хеш хранит её, поэтому стоимость можно
+// повысить, не ломая сохранённые пароли.
+const passwordCost = 12
+
+// MaxPasswordLength — длина пароля в байтах, которую учитывает bcrypt;
+// более длинные пароли HashPassword отвергает.
+const MaxPasswordLength = 72
+
+// HashPassword возвращает bcrypt-хеш пароля со случайной солью.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	return string(hash), err
+}
+
+// CheckPassword сравнивает пароль с хешем из HashPassword за постоянное
+// время. Пустой или повреждённый хеш не подходит ни к одному паролю.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$2a$12$") {
+		t.Fatalf("HashPassword() = %q, want a bcrypt hash with cost 12", hash)
+	}
+	if !CheckPassword(hash, "correct horse battery staple") {
+		t.Fatal("CheckPassword() rejected the right password")
+	}
+	for _, wrong := range []string{"", "correct horse battery stapl", "Correct horse battery staple"} {
+		if CheckPassword(hash, wrong) {
+			t.Errorf("CheckPassword(%q) accepted a wrong password", wrong)
+		}
+	}
+
+	again, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == hash {
+		t.Fatal("two hashes of one password are equal: salt is not random")
+	}
+}
+
+func TestCheckPasswordRejectsMalformedHashes(t *testing.T) {
+	for _, hash := range []string{"", "plain", "$2a$12$short", "pbkdf2-sha256$600000$c2FsdA$a2V5"} {
+		if CheckPassword(hash, "") || CheckPassword(hash, "plain") {
+			t.Errorf("CheckPassword(%q) accepted a password", hash)
+		}
+	}
+}
+
+func TestHashPasswordRejectsTooLongPasswords(t *testing.T) {
+	if _, err := HashPassword(strings.Repeat("a", MaxPasswordLength)); err != nil {
+		t.Fatalf("HashPassword(%d bytes) error = %v", MaxPasswordLength, err)
+	}
+	if _, err := HashPassword(strings.Repeat("a", MaxPasswordLength+1)); err == nil {
+		t.Fatalf("HashPassword(%d bytes) succeeded; bcrypt would ignore the tail", MaxPasswordLength+1)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"sort"
+)
+
+// Permission — действие, право на которое проверяется у вызывающего.
+type Permission string
+
+const (
+	// PermRead — чтение каталога.
+	PermRead Permission = "read"
+	// PermWrite — создание и изменение продуктов и связанных с ними данных.
+	PermWrite Permission = "write"
+	// PermDelete — удаление продуктов.
+	PermDelete Permission = "delete"
+	// PermModerate — ограничение пользователей чата.
+	PermModerate Permission = "moderate"
+	// PermManageUsers — управление пользователями API.
+	PermManageUsers Permission = "manage_users"
+)
+
+// rolePermissions — единая таблица прав ролей. По ней проверяют доступ REST,
+// GraphQL и WebSocket, поэтому новую роль или право достаточно добавить
+// сюда. Вызывающий без роли или с неизвестной ролью может только читать,
+// как viewer.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:     {PermRead, PermWrite, PermDelete, PermModerate, PermManageUsers},
+	RoleEditor:    {PermRead, PermWrite},
+	RoleModerator: {PermRead, PermModerate},
+	RoleViewer:    {PermRead},
+}
+
+// Allowed сообщает, разрешено ли роли role действие perm.
+func Allowed(role string, perm Permission) bool {
+	permissions, ok := rolePermissions[role]
+	if !ok {
+		permissions = rolePermissions[RoleViewer]
+	}
+	for _, p := range permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Can сообщает, разрешено ли вызывающему действие perm: ключу API — по
+// его Scopes, остальным — по роли.
+func (p Principal) Can(perm Permission) bool {
+	if p.Scopes == nil {
+		return Allowed(p.Role, perm)
+	}
+	for _, scope := range p.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission проверяет, что в контексте есть вызывающий, которому
+// разрешено действие perm.
+func RequirePermission(ctx context.Context, perm Permission) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !p.Can(perm) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ValidRole сообщает, что role — одна из известных ролей.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Roles возвращает известные роли по алфавиту.
+func Roles() []string {
+	roles := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
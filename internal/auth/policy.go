@@ -0,0 +1,36 @@
+package auth
+
+import "context"
+
+// FieldRule — поле ответа, которое видят только вызывающие с ролью Role.
+type FieldRule struct {
+	// Type — GraphQL-тип, которому принадлежит поле, например "Product".
+	Type string
+	// GraphQL — имя поля в GraphQL-схеме.
+	GraphQL string
+	// JSON — ключ поля в JSON-запросах и ответах REST.
+	JSON string
+	Role string
+}
+
+// FieldPolicy — единое описание полей с ограниченным доступом. По нему REST
+// вырезает ключи из ответов и тел запросов, а GraphQL проверяет роль в
+// резолверах полей и отклоняет мутации, которые эти поля записывают. Новое
+// внутреннее поле модели достаточно добавить сюда.
+var FieldPolicy = []FieldRule{
+	{Type: "Product", GraphQL: "costPrice", JSON: "cost_price", Role: RoleAdmin},
+	{Type: "Product", GraphQL: "margin", JSON: "margin", Role: RoleAdmin},
+}
+
+// HiddenKeys возвращает JSON-ключи полей, которые вызывающий из ctx видеть
+// не должен; пустой результат — ограничений нет.
+func HiddenKeys(ctx context.Context) map[string]bool {
+	p, ok := FromContext(ctx)
+	hidden := make(map[string]bool)
+	for _, rule := range FieldPolicy {
+		if !ok || p.Role != rule.Role {
+			hidden[rule.JSON] = true
+		}
+	}
+	return hidden
+}
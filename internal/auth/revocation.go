@@ -0,0 +1,36 @@
+package auth
+
+// RevocationList — токены, отозванные до истечения срока.
+type RevocationList interface {
+	// Revoked сообщает, отозван ли токен с claim jti tokenID.
+	Revoked(tokenID string) (bool, error)
+}
+
+// Revocable проверяет токены через inner и отвергает отозванные по списку.
+// Токены без jti отозвать нельзя: они действуют до истечения срока.
+type Revocable struct {
+	inner Authenticator
+	list  RevocationList
+}
+
+func NewRevocable(inner Authenticator, list RevocationList) *Revocable {
+	return &Revocable{inner: inner, list: list}
+}
+
+func (r *Revocable) Authenticate(token string) (Principal, error) {
+	principal, err := r.inner.Authenticate(token)
+	if err != nil {
+		return Principal{}, err
+	}
+	if principal.TokenID == "" {
+		return principal, nil
+	}
+	revoked, err := r.list.Revoked(principal.TokenID)
+	if err != nil {
+		return Principal{}, err
+	}
+	if revoked {
+		return Principal{}, ErrUnauthenticated
+	}
+	return principal, nil
+}
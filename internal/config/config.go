@@ -0,0 +1,527 @@
+package config
+
+import (
+	"github.com/joho/godotenv"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Port string
+	DB   DBConfig
+	// DefaultLocale — язык, в котором хранятся основные поля продуктов.
+	DefaultLocale string
+	// Currency — валюта цен каталога (ISO 4217).
+	Currency string
+	// ProductURL — адрес страницы продукта на сайте, {id} заменяется на ID;
+	// используется в структурированных данных JSON-LD.
+	ProductURL string
+	// ProductCacheTTL — время жизни закэшированных страниц списка продуктов.
+	ProductCacheTTL time.Duration
+	// PriceScheduleInterval — период проверки расписания цен.
+	PriceScheduleInterval time.Duration
+	// TrashRetention — сколько удалённый продукт можно восстановить из корзины.
+	TrashRetention time.Duration
+	// TrashSweepInterval — период окончательной очистки корзины.
+	TrashSweepInterval time.Duration
+	// WebhookTimeout — таймаут одной доставки вебхука.
+	WebhookTimeout time.Duration
+	// GraphQLTimeout — предельное время выполнения одного GraphQL-запроса;
+	// по его истечении запросы к базе отменяются.
+	GraphQLTimeout time.Duration
+	// GraphQLDebug добавляет в ошибки GraphQL исходный текст и стек вызова.
+	// Включать только при разработке.
+	GraphQLDebug bool
+	// GraphQLPlayground включает GraphiQL на /api/graphql/playground.
+	GraphQLPlayground bool
+	// GraphQLIntrospection разрешает запросы __schema и __type. В продакшене
+	// обычно выключается: схема доступна как SDL на /api/graphql/schema.graphql,
+	// а GraphiQL без интроспекции не работает.
+	GraphQLIntrospection bool
+	// GraphQLTracing — трассировка резолверов в extensions.tracing: off,
+	// header (по заголовку X-GraphQL-Tracing: 1) или always.
+	GraphQLTracing string
+	// GraphQLAPQCacheSize — сколько автоматически зарегистрированных
+	// persisted queries хранить в памяти.
+	GraphQLAPQCacheSize int64
+	// GraphQLPersistedQueries — путь к манифесту заранее известных запросов.
+	GraphQLPersistedQueries string
+	// GraphQLPersistedOnly разрешает выполнять только запросы из манифеста.
+	GraphQLPersistedOnly bool
+	// GraphQLCostBudget — суммарная стоимость GraphQL-запросов одного клиента
+	// за GraphQLCostWindow; 0 отключает ограничение.
+	GraphQLCostBudget int64
+	GraphQLCostWindow time.Duration
+	// GraphQLCostListSize — предполагаемая длина списка без first, last или limit.
+	GraphQLCostListSize int64
+	// GraphQLResponseCacheTTL — время жизни закэшированных ответов анонимных
+	// запросов на чтение; 0 отключает кэш.
+	GraphQLResponseCacheTTL  time.Duration
+	GraphQLResponseCacheSize int64
+	// AdminTokens — Bearer-токены администраторов: с ними GraphQL разрешает
+	// мутации и служебные поля вроде себестоимости.
+	AdminTokens []string
+	// JWTSecret — ключ HS256 для JWT пользователей; пустой — чат WebSocket
+	// работает без аутентификации.
+	JWTSecret string
+	// JWTIssuer и JWTAudience, если заданы, проверяются в claims iss и aud.
+	JWTIssuer   string
+	JWTAudience string
+	// AttachmentsDir — каталог для файлов, прикреплённых к продуктам.
+	AttachmentsDir string
+	// AttachmentMaxSize — максимальный размер вложения в байтах.
+	AttachmentMaxSize int64
+	// AttachmentTypes — допустимые MIME-типы вложений.
+	AttachmentTypes []string
+	// Imgproxy — подписанные ссылки на изображения через imgproxy/CDN.
+	Imgproxy ImgproxyConfig
+	// ImageImportMaxSize — предельный размер изображения, скачиваемого при импорте.
+	ImageImportMaxSize int64
+	// ImageImportMaxSide — большая сторона в пикселях, до которой уменьшаются
+	// импортированные изображения.
+	ImageImportMaxSide int64
+	// ImageImportTimeout — таймаут скачивания одного изображения.
+	ImageImportTimeout time.Duration
+	// ImageImportInterval — период разбора очереди изображений импорта.
+	ImageImportInterval time.Duration
+	// SearchIndexInterval — период переиндексации продуктов после изменения
+	// синонимов и стоп-слов.
+	SearchIndexInterval time.Duration
+	// SearchVocabularyInterval — период перестройки словаря подсказок
+	// "возможно, вы имели в виду".
+	SearchVocabularyInterval time.Duration
+	// SearchSuggestBelow — при скольких найденных продуктах (не включая) поиск
+	// предлагает исправленный запрос; 0 отключает подсказки.
+	SearchSuggestBelow int64
+	// ExperimentFlushInterval — период записи попаданий в A/B-эксперименты.
+	ExperimentFlushInterval time.Duration
+	// SLOs — цели уровня обслуживания маршрутов.
+	SLOs []SLOConfig
+	// SLOWindow — окно, за которое считается соблюдение целей.
+	SLOWindow time.Duration
+	// SLOFastBurn — скорость сгорания бюджета ошибок, при которой
+	// поднимается тревога.
+	SLOFastBurn float64
+	// SLOCheckInterval — период проверки скорости сгорания.
+	SLOCheckInterval time.Duration
+	// WarmUpConnections — сколько соединений каждого пула открыть при
+	// прогреве до перехода /readyz в готовность.
+	WarmUpConnections int64
+	// WarmUpTimeout ограничивает прогрев; после него сервер объявляет
+	// готовность, даже если прогрев не закончен.
+	WarmUpTimeout time.Duration
+	// ListMaxLimit — наибольший limit списка продуктов; 0 — без ограничения.
+	ListMaxLimit int64
+	// ListUnboundedMax — размер каталога, после которого список без limit
+	// отвергается с ошибкой 400; 0 — без ограничения.
+	ListUnboundedMax int64
+	// ChatReplayMessages — сколько последних сообщений комнаты получает
+	// подключившийся клиент чата (не больше 200); 0 — не отправлять.
+	ChatReplayMessages int64
+	// WSPingInterval — как часто сервер пингует клиентов чата WebSocket.
+	WSPingInterval time.Duration
+	// WSPongTimeout — через сколько без pong и фреймов подключение чата
+	// считается мёртвым; должен быть больше WSPingInterval.
+	WSPongTimeout time.Duration
+	// WSIdleTimeout закрывает подключения чата без фреймов клиента дольше
+	// этого времени; 0 — не закрывать.
+	WSIdleTimeout time.Duration
+	// WSSendBuffer — размер очереди рассылки одного клиента чата.
+	WSSendBuffer int64
+	// DedupWindow — в течение какого времени повтор POST /api/products с тем
+	// же телом от того же клиента получает ответ первого запроса; 0 — не
+	// схлопывать повторы.
+	DedupWindow time.Duration
+	// EventPollBuffer — сколько последних событий хранит лента длинного
+	// опроса GET /api/events/poll.
+	EventPollBuffer int64
+	// CatalogDiffTimeout — таймаут одного запроса к каталогу другого
+	// окружения при сравнении каталогов.
+	CatalogDiffTimeout time.Duration
+	// WSMaxMessageSize — наибольшее сообщение клиента WebSocket в байтах;
+	// 0 — без ограничения.
+	WSMaxMessageSize int64
+	// WSMessageRate и WSMessageBurst — сколько сообщений в секунду в среднем
+	// и подряд может присылать одно подключение WebSocket; WSMessageRate 0 —
+	// без ограничения.
+	WSMessageRate  float64
+	WSMessageBurst int64
+	// CacheBackplane включает обмен сообщениями о сбросе кэшей каталога
+	// между экземплярами через LISTEN/NOTIFY Postgres.
+	CacheBackplane bool
+	// ChatBannedWords — слова, которые чат заменяет звёздочками; пусто —
+	// фильтр выключен.
+	ChatBannedWords []string
+	// EmbedFrameAncestors — источники CSP frame-ancestors, которым
+	// разрешено встраивать виджет продукта; пусто — любые сайты.
+	EmbedFrameAncestors []string
+	// StatusIncidentWindow — за какой срок страница статуса показывает
+	// инциденты.
+	StatusIncidentWindow time.Duration
+	// ShutdownTimeout ограничивает остановку сервера по SIGTERM: закрытие
+	// подключений WebSocket и завершение запросов.
+	ShutdownTimeout time.Duration
+	// ChatImagesDir — каталог изображений, загруженных для сообщений чата.
+	ChatImagesDir string
+	// ChatImageMaxSize — максимальный размер загружаемого изображения чата
+	// в байтах.
+	ChatImageMaxSize int64
+	// ChatImageMaxSide — до какой большей стороны уменьшаются изображения
+	// чата, а ChatImageThumbnailSide — большая сторона их миниатюр.
+	ChatImageMaxSide       int64
+	ChatImageThumbnailSide int64
+	// ChatImageTypes — допустимые MIME-типы изображений чата.
+	ChatImageTypes []string
+	// SCIMTokens — Bearer-токены, с которыми провайдер удостоверений
+	// управляет сотрудниками через SCIM; пусто — SCIM недоступен.
+	SCIMTokens []string
+	// DatasetTTL — сколько готовая выгрузка набора данных отдаётся
+	// повторно, прежде чем следующий запрос соберёт новую.
+	DatasetTTL time.Duration
+	// APIJWTSecret — ключ HS256 токенов, которые пользователи API получают
+	// по паролю; пустой — вход по паролю выключен, а запись продуктов
+	// доступна без токена. Отдельный от JWTSecret, чтобы токены чата не
+	// открывали доступ к API.
+	APIJWTSecret string
+	// APITokenTTL и APIRefreshTTL — сроки действия access- и
+	// refresh-токенов пользователей API.
+	APITokenTTL   time.Duration
+	APIRefreshTTL time.Duration
+	// JSONFieldNaming — стиль имён полей JSON: snake_case или camelCase.
+	// Заданный явно, он действует и на ключи ответов GraphQL; пустая строка —
+	// snake_case в REST и имена схемы в GraphQL, как до появления настройки.
+	JSONFieldNaming string
+	// StrictJSON отклоняет JSON-тела REST с неизвестными полями и значениями
+	// не того типа для всех запросов; без него строгий режим включает
+	// заголовок Prefer: handling=strict.
+	StrictJSON bool
+	// APIRegistration открывает POST /api/auth/register: с ним пользователи
+	// API с ролью viewer заводятся без администратора.
+	APIRegistration bool
+	// OAuthProviders — провайдеры входа через OAuth2 (google, github);
+	// работают только вместе с APIJWTSecret.
+	OAuthProviders []OAuthProviderConfig
+	// OAuthBaseURL — внешний адрес сервиса, из которого строится
+	// redirect_uri для провайдеров; пустой — адрес из запроса.
+	OAuthBaseURL string
+	// OAuthSuccessURL — страница, которой после входа через провайдера
+	// передаются токены во фрагменте адреса; пустой — токены отдаются JSON.
+	OAuthSuccessURL string
+	// RateLimitRead, RateLimitWrite и RateLimitAuth — сколько запросов REST
+	// на чтение, на запись и к /api/auth клиент делает за RateLimitWindow;
+	// 0 снимает ограничение.
+	RateLimitRead   int64
+	RateLimitWrite  int64
+	RateLimitAuth   int64
+	RateLimitWindow time.Duration
+	// CORSAllowedOrigins — источники браузерных приложений на других
+	// доменах, которым открыт API, например https://admin.example.com;
+	// "*" — любые. Пусто — API доступен только страницам своего источника.
+	CORSAllowedOrigins []string
+	// AuthCookies включает вход в браузере с токенами в HttpOnly-cookie
+	// вместо заголовка Authorization; изменяющие запросы с такими cookie
+	// требуют CSRF-токена.
+	AuthCookies bool
+}
+
+type DBConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	// Replicas — региональные реплики только для чтения; учётные данные и
+	// имя базы те же, что у основного сервера.
+	Replicas []ReplicaConfig
+	// ReplicaProbeInterval — период проверки доступности и задержки реплик.
+	ReplicaProbeInterval time.Duration
+	// ReplicaMaxLag — отставание репликации, после которого реплика
+	// считается нездоровой и чтение уходит на другие узлы.
+	ReplicaMaxLag time.Duration
+}
+
+// ImgproxyConfig описывает imgproxy; пустой URL отключает ссылки на варианты изображений.
+type ImgproxyConfig struct {
+	URL string
+	// Key и Salt в hex, как IMGPROXY_KEY и IMGPROXY_SALT самого imgproxy.
+	Key           string
+	Salt          string
+	SignatureSize int64
+	// Formats — современные форматы вариантов изображений (avif, webp).
+	Formats []string
+	// SourceBase — откуда imgproxy берёт оригиналы: "local:///" при общем
+	// каталоге вложений или адрес этого API, доступный imgproxy.
+	SourceBase string
+}
+
+// SLOConfig — цель маршрута: доля Target запросов должна завершаться без
+// ошибки сервера не дольше Latency.
+type SLOConfig struct {
+	Route   string
+	Latency time.Duration
+	Target  float64
+}
+
+// OAuthProviderConfig — приложение, зарегистрированное у провайдера OAuth2.
+type OAuthProviderConfig struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+}
+
+// ReplicaConfig описывает одну реплику для чтения.
+type ReplicaConfig struct {
+	Region string
+	Host   string
+	Port   string
+}
+
+// Load читает конфигурацию из окружения, предварительно подгружая .env, если он есть.
+func Load() Config {
+	if err := godotenv.Load(); err != nil {
+		log.Println("Не найден файл .env, используются значения по умолчанию")
+	}
+
+	return Config{
+		Port: getEnv("PORT", "8080"),
+		DB: DBConfig{
+			Host:                 os.Getenv("DB_HOST"),
+			Port:                 os.Getenv("DB_PORT"),
+			User:                 os.Getenv("DB_USER"),
+			Password:             os.Getenv("DB_PASSWORD"),
+			Name:                 os.Getenv("DB_NAME"),
+			Replicas:             getReplicas("DB_READ_REPLICAS", os.Getenv("DB_PORT")),
+			ReplicaProbeInterval: getDuration("DB_REPLICA_PROBE_INTERVAL", 10*time.Second),
+			ReplicaMaxLag:        getDuration("DB_REPLICA_MAX_LAG", 5*time.Second),
+		},
+		DefaultLocale:            getEnv("DEFAULT_LOCALE", "ru"),
+		Currency:                 getEnv("CATALOG_CURRENCY", "RUB"),
+		ProductURL:               getEnv("PRODUCT_PAGE_URL", ""),
+		ProductCacheTTL:          getDuration("PRODUCT_CACHE_TTL", 30*time.Second),
+		PriceScheduleInterval:    getDuration("PRICE_SCHEDULE_INTERVAL", time.Minute),
+		TrashRetention:           getDuration("TRASH_RETENTION", 72*time.Hour),
+		TrashSweepInterval:       getDuration("TRASH_SWEEP_INTERVAL", 10*time.Minute),
+		WebhookTimeout:           getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		GraphQLTimeout:           getDuration("GRAPHQL_TIMEOUT", 30*time.Second),
+		GraphQLDebug:             getBool("GRAPHQL_DEBUG", false),
+		GraphQLPlayground:        getBool("GRAPHQL_PLAYGROUND", false),
+		GraphQLIntrospection:     getBool("GRAPHQL_INTROSPECTION", true),
+		GraphQLTracing:           getEnv("GRAPHQL_TRACING", "off"),
+		GraphQLAPQCacheSize:      getInt64("GRAPHQL_APQ_CACHE_SIZE", 1000),
+		GraphQLPersistedQueries:  os.Getenv("GRAPHQL_PERSISTED_QUERIES"),
+		GraphQLPersistedOnly:     getBool("GRAPHQL_PERSISTED_ONLY", false),
+		GraphQLCostBudget:        getInt64("GRAPHQL_COST_BUDGET", 10000),
+		GraphQLCostWindow:        getDuration("GRAPHQL_COST_WINDOW", time.Minute),
+		GraphQLCostListSize:      getInt64("GRAPHQL_COST_LIST_SIZE", 100),
+		GraphQLResponseCacheTTL:  getDuration("GRAPHQL_RESPONSE_CACHE_TTL", 5*time.Second),
+		GraphQLResponseCacheSize: getInt64("GRAPHQL_RESPONSE_CACHE_SIZE", 1000),
+		AdminTokens:              getList("ADMIN_TOKENS", nil),
+		JWTSecret:                os.Getenv("JWT_SECRET"),
+		JWTIssuer:                os.Getenv("JWT_ISSUER"),
+		JWTAudience:              os.Getenv("JWT_AUDIENCE"),
+		AttachmentsDir:           getEnv("ATTACHMENTS_DIR", "./uploads/attachments"),
+		AttachmentMaxSize:        getInt64("ATTACHMENT_MAX_SIZE", 20<<20),
+		AttachmentTypes: getList("ATTACHMENT_TYPES", []string{
+			"application/pdf", "application/zip", "text/plain", "text/csv", "image/png", "image/jpeg",
+		}),
+		Imgproxy: ImgproxyConfig{
+			URL:           os.Getenv("IMGPROXY_URL"),
+			Key:           os.Getenv("IMGPROXY_KEY"),
+			Salt:          os.Getenv("IMGPROXY_SALT"),
+			SignatureSize: getInt64("IMGPROXY_SIGNATURE_SIZE", 32),
+			Formats:       getList("IMGPROXY_FORMATS", []string{"avif", "webp"}),
+			SourceBase:    getEnv("IMGPROXY_SOURCE_BASE", "local:///"),
+		},
+		ImageImportMaxSize:       getInt64("IMAGE_IMPORT_MAX_SIZE", 10<<20),
+		ImageImportMaxSide:       getInt64("IMAGE_IMPORT_MAX_SIDE", 2048),
+		ImageImportTimeout:       getDuration("IMAGE_IMPORT_TIMEOUT", 30*time.Second),
+		ImageImportInterval:      getDuration("IMAGE_IMPORT_INTERVAL", 5*time.Second),
+		SearchIndexInterval:      getDuration("SEARCH_INDEX_INTERVAL", 5*time.Second),
+		SearchVocabularyInterval: getDuration("SEARCH_VOCABULARY_INTERVAL", 10*time.Minute),
+		SearchSuggestBelow:       getInt64("SEARCH_SUGGEST_BELOW", 3),
+		ExperimentFlushInterval:  getDuration("EXPERIMENT_FLUSH_INTERVAL", 10*time.Second),
+		SLOs:                     getSLOs("SLO_ROUTES"),
+		SLOWindow:                getDuration("SLO_WINDOW", 24*time.Hour),
+		SLOFastBurn:              getFloat("SLO_FAST_BURN", 14.4),
+		SLOCheckInterval:         getDuration("SLO_CHECK_INTERVAL", time.Minute),
+		WarmUpConnections:        getInt64("WARMUP_CONNECTIONS", 10),
+		WarmUpTimeout:            getDuration("WARMUP_TIMEOUT", 30*time.Second),
+		ListMaxLimit:             getInt64("LIST_MAX_LIMIT", 500),
+		ListUnboundedMax:         getInt64("LIST_UNBOUNDED_MAX", 1000),
+		ChatReplayMessages:       getInt64("CHAT_REPLAY_MESSAGES", 50),
+		WSPingInterval:           getDuration("WS_PING_INTERVAL", 30*time.Second),
+		WSPongTimeout:            getDuration("WS_PONG_TIMEOUT", time.Minute),
+		WSIdleTimeout:            getDuration("WS_IDLE_TIMEOUT", 0),
+		WSSendBuffer:             getInt64("WS_SEND_BUFFER", 64),
+		DedupWindow:              getDuration("DEDUP_WINDOW", 5*time.Second),
+		EventPollBuffer:          getInt64("EVENT_POLL_BUFFER", 1000),
+		CatalogDiffTimeout:       getDuration("CATALOG_DIFF_TIMEOUT", 30*time.Second),
+		WSMaxMessageSize:         getInt64("WS_MAX_MESSAGE_SIZE", 64<<10),
+		WSMessageRate:            getFloat("WS_MESSAGE_RATE", 10),
+		WSMessageBurst:           getInt64("WS_MESSAGE_BURST", 20),
+		CacheBackplane:           getBool("CACHE_BACKPLANE", true),
+		ChatBannedWords:          getList("CHAT_BANNED_WORDS", nil),
+		EmbedFrameAncestors:      getList("EMBED_FRAME_ANCESTORS", nil),
+		StatusIncidentWindow:     getDuration("STATUS_INCIDENT_WINDOW", 7*24*time.Hour),
+		ShutdownTimeout:          getDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		ChatImagesDir:            getEnv("CHAT_IMAGES_DIR", "./uploads/chat"),
+		ChatImageMaxSize:         getInt64("CHAT_IMAGE_MAX_SIZE", 5<<20),
+		ChatImageMaxSide:         getInt64("CHAT_IMAGE_MAX_SIDE", 2048),
+		ChatImageThumbnailSide:   getInt64("CHAT_IMAGE_THUMBNAIL_SIDE", 320),
+		ChatImageTypes:           getList("CHAT_IMAGE_TYPES", []string{"image/jpeg", "image/png", "image/gif"}),
+		SCIMTokens:               getList("SCIM_TOKENS", nil),
+		DatasetTTL:               getDuration("DATASET_TTL", time.Hour),
+		APIJWTSecret:             os.Getenv("API_JWT_SECRET"),
+		APITokenTTL:              getDuration("API_TOKEN_TTL", 15*time.Minute),
+		APIRefreshTTL:            getDuration("API_REFRESH_TTL", 30*24*time.Hour),
+		JSONFieldNaming:          os.Getenv("JSON_FIELD_NAMING"),
+		StrictJSON:               getBool("STRICT_JSON", false),
+		APIRegistration:          getBool("API_REGISTRATION", true),
+		OAuthProviders:           getOAuthProviders("OAUTH_PROVIDERS"),
+		OAuthBaseURL:             os.Getenv("OAUTH_BASE_URL"),
+		OAuthSuccessURL:          os.Getenv("OAUTH_SUCCESS_URL"),
+		RateLimitRead:            getInt64("RATE_LIMIT_READ", 600),
+		RateLimitWrite:           getInt64("RATE_LIMIT_WRITE", 60),
+		RateLimitAuth:            getInt64("RATE_LIMIT_AUTH", 10),
+		RateLimitWindow:          getDuration("RATE_LIMIT_WINDOW", time.Minute),
+		CORSAllowedOrigins:       getList("CORS_ALLOWED_ORIGINS", nil),
+		AuthCookies:              getBool("AUTH_COOKIES", false),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getInt64(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getList читает список значений, разделённых запятыми.
+func getList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getReplicas читает реплики в формате "регион=хост[:порт],..."; без порта
+// используется порт основного сервера.
+func getReplicas(key, defaultPort string) []ReplicaConfig {
+	var replicas []ReplicaConfig
+	for _, item := range getList(key, nil) {
+		region, addr, ok := strings.Cut(item, "=")
+		if !ok || region == "" || addr == "" {
+			log.Printf("Некорректная реплика %q в %s, ожидается регион=хост:порт", item, key)
+			continue
+		}
+		host, port := addr, defaultPort
+		if h, p, err := net.SplitHostPort(addr); err == nil {
+			host, port = h, p
+		}
+		replicas = append(replicas, ReplicaConfig{Region: region, Host: host, Port: port})
+	}
+	return replicas
+}
+
+// getOAuthProviders читает список провайдеров, например "google,github";
+// учётные данные каждого — в OAUTH_<ИМЯ>_CLIENT_ID и
+// OAUTH_<ИМЯ>_CLIENT_SECRET. Провайдер без них пропускается.
+func getOAuthProviders(key string) []OAuthProviderConfig {
+	var providers []OAuthProviderConfig
+	for _, name := range getList(key, nil) {
+		name = strings.ToLower(name)
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		provider := OAuthProviderConfig{
+			Name:         name,
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		}
+		if provider.ClientID == "" || provider.ClientSecret == "" {
+			log.Printf("Провайдер %q из %s пропущен: не заданы %sCLIENT_ID и %sCLIENT_SECRET", name, key, prefix, prefix)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// getSLOs читает цели в формате "МЕТОД /путь=задержка@процент,...",
+// например "GET /api/products=300ms@99.5".
+func getSLOs(key string) []SLOConfig {
+	var slos []SLOConfig
+	for _, item := range getList(key, nil) {
+		route, objective, ok := strings.Cut(item, "=")
+		latency, target, ok2 := strings.Cut(objective, "@")
+		d, err := time.ParseDuration(latency)
+		percent, err2 := strconv.ParseFloat(target, 64)
+		if !ok || !ok2 || !strings.Contains(route, " /") || err != nil || err2 != nil || d <= 0 || percent <= 0 || percent >= 100 {
+			log.Printf("Некорректная цель %q в %s, ожидается МЕТОД /путь=задержка@процент", item, key)
+			continue
+		}
+		slos = append(slos, SLOConfig{Route: strings.TrimSpace(route), Latency: d, Target: percent / 100})
+	}
+	return slos
+}
+
+func getFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %g", key, value, fallback)
+		return fallback
+	}
+	return f
+}
+
+func getBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %t", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"github.com/lib/pq"
+	"log"
+	"server/internal/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backplaneChannel — канал NOTIFY, через который экземпляры сервиса
+// обмениваются сообщениями.
+const backplaneChannel = "backplane"
+
+// Backplane рассылает короткие сообщения всем экземплярам сервиса через
+// LISTEN/NOTIFY основной базы: Redis или NATS для этого не нужны, а база у
+// экземпляров и так общая. Сообщение — только тема, например «каталог
+// изменился»; доставка не гарантируется, поэтому подписчики должны
+// переживать потерю сообщения, как кэши с TTL.
+type Backplane struct {
+	primary  *sql.DB
+	listener *pq.Listener
+	// instance отличает сообщения этого экземпляра, которые приходят и ему.
+	instance string
+
+	mu       sync.RWMutex
+	handlers map[string][]func()
+}
+
+// OpenBackplane открывает отдельное соединение для LISTEN к основной базе
+// и подписывается на канал. Сообщения отправляются через пул primary.
+func OpenBackplane(cfg config.DBConfig, primary *sql.DB) (*Backplane, error) {
+	listener := pq.NewListener(connString(cfg, cfg.Host, cfg.Port), time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("Соединение backplane: %v", err)
+			}
+		})
+	if err := listener.Listen(backplaneChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	instance := make([]byte, 8)
+	rand.Read(instance)
+	return &Backplane{primary: primary, listener: listener, instance: hex.EncodeToString(instance), handlers: make(map[string][]func())}, nil
+}
+
+// Subscribe вызывает fn при каждом сообщении topic от других экземпляров.
+func (b *Backplane) Subscribe(topic string, fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], fn)
+}
+
+// Publish сообщает topic остальным экземплярам. Ошибка только логируется:
+// вызывающий уже записал изменения, а подписчики догонят их по TTL. Вызов
+// на nil-backplane ничего не делает, как для одиночного экземпляра.
+func (b *Backplane) Publish(topic string) {
+	if b == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := b.primary.ExecContext(ctx, "SELECT pg_notify($1, $2)", backplaneChannel, b.instance+" "+topic); err != nil {
+		log.Printf("Не удалось отправить сообщение backplane %s: %v", topic, err)
+	}
+}
+
+// Run доставляет сообщения подписчикам. Блокирует вызывающего, запускать в
+// отдельной горутине. После переподключения сообщения могли потеряться,
+// поэтому вызываются подписчики всех тем.
+func (b *Backplane) Run() {
+	for notification := range b.listener.Notify {
+		if notification == nil {
+			b.mu.RLock()
+			for _, handlers := range b.handlers {
+				for _, fn := range handlers {
+					fn()
+				}
+			}
+			b.mu.RUnlock()
+			continue
+		}
+		instance, topic, ok := strings.Cut(notification.Extra, " ")
+		if !ok || instance == b.instance {
+			continue
+		}
+		b.mu.RLock()
+		handlers := b.handlers[topic]
+		b.mu.RUnlock()
+		for _, fn := range handlers {
+			fn()
+		}
+	}
+}
+
+// Close закрывает соединение LISTEN; Run после этого завершается.
+func (b *Backplane) Close() error {
+	return b.listener.Close()
+}
@@ -0,0 +1,378 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	"server/internal/config"
+	"time"
+)
+
+// Open подключается к Postgres и применяет миграции схемы.
+func Open(cfg config.DBConfig) (*sql.DB, error) {
+	db, err := connect(cfg, cfg.Host, cfg.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// connString собирает строку подключения к указанному хосту с учётными
+// данными cfg. Сессия работает в UTC, чтобы TIMESTAMPTZ читались без
+// смещения часового пояса сервера БД.
+func connString(cfg config.DBConfig, host, port string) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable timezone=UTC",
+		host, port, cfg.User, cfg.Password, cfg.Name)
+}
+
+// connect открывает пул соединений к указанному хосту с учётными данными cfg.
+func connect(cfg config.DBConfig, host, port string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", connString(cfg, host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	db.SetConnMaxLifetime(time.Hour)
+	return db, nil
+}
+
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS products (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			price DECIMAL(10, 2) NOT NULL,
+			description TEXT,
+			categories TEXT[]
+		);
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode VARCHAR(64);
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price DECIMAL(10, 2);
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS search_version INTEGER;
+		CREATE INDEX IF NOT EXISTS products_search_idx ON products USING GIN (search_vector);
+		CREATE TABLE IF NOT EXISTS search_synonyms (
+			id SERIAL PRIMARY KEY,
+			terms TEXT[] NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS search_stopwords (
+			word VARCHAR(64) PRIMARY KEY
+		);
+		-- Версия словаря: продукты, проиндексированные под другую версию,
+		-- переиндексирует фоновая задача.
+		CREATE TABLE IF NOT EXISTS search_index_state (
+			id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+			version INTEGER NOT NULL
+		);
+		INSERT INTO search_index_state (version) VALUES (1) ON CONFLICT DO NOTHING;
+		-- Множители ранжирования результатов поиска; 1 — без усиления.
+		CREATE TABLE IF NOT EXISTS search_boosts (
+			id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+			featured DOUBLE PRECISION NOT NULL DEFAULT 1,
+			in_stock DOUBLE PRECISION NOT NULL DEFAULT 1,
+			margin_tiers JSONB NOT NULL DEFAULT '[]'
+		);
+		INSERT INTO search_boosts (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
+		CREATE TABLE IF NOT EXISTS featured_products (
+			product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		-- Словарь проиндексированных слов для подсказок "возможно, вы имели в
+		-- виду"; похожие слова ищутся по триграммам.
+		CREATE EXTENSION IF NOT EXISTS pg_trgm;
+		CREATE TABLE IF NOT EXISTS search_vocabulary (
+			word TEXT PRIMARY KEY,
+			documents INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS search_vocabulary_trgm_idx ON search_vocabulary USING GIN (word gin_trgm_ops);
+		CREATE TABLE IF NOT EXISTS search_zero_results (
+			query VARCHAR(255) PRIMARY KEY,
+			hits INTEGER NOT NULL DEFAULT 1,
+			first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (sku);
+		CREATE UNIQUE INDEX IF NOT EXISTS products_barcode_key ON products (barcode);
+		CREATE TABLE IF NOT EXISTS price_schedules (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			price DECIMAL(10, 2) NOT NULL,
+			original_price DECIMAL(10, 2),
+			starts_at TIMESTAMPTZ NOT NULL,
+			ends_at TIMESTAMPTZ,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		);
+		CREATE INDEX IF NOT EXISTS price_schedules_product_idx ON price_schedules (product_id, status);
+		CREATE TABLE IF NOT EXISTS product_translations (
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			locale VARCHAR(16) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			PRIMARY KEY (product_id, locale)
+		);
+		CREATE TABLE IF NOT EXISTS product_attachments (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			file_name VARCHAR(255) NOT NULL,
+			content_type VARCHAR(255) NOT NULL,
+			size BIGINT NOT NULL,
+			storage_key VARCHAR(64) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS product_attachments_product_idx ON product_attachments (product_id);
+		CREATE TABLE IF NOT EXISTS attachment_blobs (
+			storage_key VARCHAR(64) PRIMARY KEY,
+			sha256 CHAR(64) UNIQUE,
+			size BIGINT NOT NULL,
+			ref_count INTEGER NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		-- Файлы, загруженные до появления учёта ссылок: хеш у них неизвестен,
+		-- поэтому с новыми загрузками они не объединяются, но счётчик ведётся.
+		INSERT INTO attachment_blobs (storage_key, size, ref_count)
+		SELECT storage_key, MAX(size), COUNT(*) FROM product_attachments GROUP BY storage_key
+		ON CONFLICT (storage_key) DO NOTHING;
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id SERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret VARCHAR(64) NOT NULL,
+			events TEXT[] NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS key VARCHAR(64) UNIQUE;
+		CREATE TABLE IF NOT EXISTS event_log (
+			id VARCHAR(32) PRIMARY KEY,
+			type VARCHAR(64) NOT NULL,
+			occurred_at TIMESTAMPTZ NOT NULL,
+			data JSONB NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS event_log_occurred_idx ON event_log (occurred_at);
+		ALTER TABLE event_log ADD COLUMN IF NOT EXISTS request_id VARCHAR(64) NOT NULL DEFAULT '';
+		CREATE TABLE IF NOT EXISTS dead_letters (
+			id SERIAL PRIMARY KEY,
+			source VARCHAR(32) NOT NULL,
+			kind VARCHAR(64) NOT NULL,
+			payload JSONB NOT NULL DEFAULT '{}',
+			error TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			last_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS import_jobs (
+			id SERIAL PRIMARY KEY,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			product_ids INTEGER[] NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ
+		);
+		CREATE TABLE IF NOT EXISTS import_images (
+			id SERIAL PRIMARY KEY,
+			job_id INTEGER NOT NULL REFERENCES import_jobs(id) ON DELETE CASCADE,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			url TEXT NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			error TEXT,
+			attachment_id INTEGER REFERENCES product_attachments(id) ON DELETE SET NULL,
+			claimed_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS import_images_job_idx ON import_images (job_id);
+		CREATE INDEX IF NOT EXISTS import_images_pending_idx ON import_images (id) WHERE status IN ('pending', 'processing');
+		CREATE TABLE IF NOT EXISTS product_reviews (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			author VARCHAR(255) NOT NULL,
+			rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+			body TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS product_reviews_product_idx ON product_reviews (product_id, created_at);
+		CREATE TABLE IF NOT EXISTS product_stock (
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			warehouse VARCHAR(64) NOT NULL,
+			quantity INTEGER NOT NULL CHECK (quantity >= 0),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (product_id, warehouse)
+		);
+		CREATE TABLE IF NOT EXISTS experiments (
+			id SERIAL PRIMARY KEY,
+			key VARCHAR(64) NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			variants JSONB NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		-- Первое попадание пользователя в эксперимент и выданный ему вариант.
+		CREATE TABLE IF NOT EXISTS experiment_exposures (
+			experiment_id INTEGER NOT NULL REFERENCES experiments(id) ON DELETE CASCADE,
+			subject VARCHAR(128) NOT NULL,
+			variant VARCHAR(64) NOT NULL,
+			first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (experiment_id, subject)
+		);
+		CREATE TABLE IF NOT EXISTS messages (
+			id BIGSERIAL PRIMARY KEY,
+			room VARCHAR(64) NOT NULL,
+			username VARCHAR(255) NOT NULL,
+			message TEXT NOT NULL,
+			envelope_id VARCHAR(32) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS messages_room_idx ON messages (room, id);
+		-- Личные сообщения: комната пустая, recipient — имя получателя.
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS recipient VARCHAR(255);
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS read_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS messages_unread_idx ON messages (recipient, id)
+			WHERE recipient IS NOT NULL AND read_at IS NULL;
+		-- Метаданные изображений сообщения на момент отправки.
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS attachments JSONB;
+		-- Правка и удаление сообщений по id конверта. Удалённые сообщения
+		-- остаются в таблице, но не попадают в историю.
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS messages_envelope_idx ON messages (envelope_id);
+		-- Изображения, загруженные для сообщений чата.
+		CREATE TABLE IF NOT EXISTS chat_images (
+			id VARCHAR(32) PRIMARY KEY,
+			uploader VARCHAR(255) NOT NULL DEFAULT '',
+			content_type VARCHAR(64) NOT NULL,
+			size BIGINT NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			storage_key VARCHAR(64) NOT NULL,
+			thumbnail_key VARCHAR(64) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		-- Настройки выдачи категорий для мерчандайзинга.
+		CREATE TABLE IF NOT EXISTS category_listings (
+			category VARCHAR(255) PRIMARY KEY,
+			sort VARCHAR(32) NOT NULL DEFAULT '',
+			page_size INTEGER NOT NULL DEFAULT 0 CHECK (page_size >= 0),
+			badges TEXT[] NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		-- Ограничения пользователей чата: mute и ban.
+		CREATE TABLE IF NOT EXISTS chat_sanctions (
+			username VARCHAR(255) NOT NULL,
+			kind VARCHAR(8) NOT NULL CHECK (kind IN ('mute', 'ban')),
+			reason TEXT NOT NULL DEFAULT '',
+			created_by VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ,
+			PRIMARY KEY (username, kind)
+		);
+		-- Настройки витрин арендаторов.
+		CREATE TABLE IF NOT EXISTS storefront_settings (
+			tenant VARCHAR(64) PRIMARY KEY,
+			logo_url TEXT NOT NULL DEFAULT '',
+			primary_color VARCHAR(7) NOT NULL DEFAULT '',
+			currency VARCHAR(3) NOT NULL DEFAULT '',
+			default_locale VARCHAR(35) NOT NULL DEFAULT '',
+			contact_email VARCHAR(255) NOT NULL DEFAULT '',
+			contact_phone VARCHAR(32) NOT NULL DEFAULT '',
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		-- Сотрудники, которых провайдер удостоверений заводит через SCIM.
+		-- Удалённые остаются с deleted_at, чтобы их токены перестали
+		-- действовать.
+		CREATE TABLE IF NOT EXISTS staff_users (
+			id VARCHAR(32) PRIMARY KEY,
+			user_name VARCHAR(255) NOT NULL UNIQUE,
+			external_id VARCHAR(255) NOT NULL DEFAULT '',
+			display_name VARCHAR(255) NOT NULL DEFAULT '',
+			email VARCHAR(255) NOT NULL DEFAULT '',
+			role VARCHAR(32) NOT NULL DEFAULT '',
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			deleted_at TIMESTAMPTZ
+		);
+		-- Пользователи API, входящие по паролю. Пароль хранится хешем
+		-- PBKDF2, refresh-токены — хешем SHA-256.
+		CREATE TABLE IF NOT EXISTS users (
+			id SERIAL PRIMARY KEY,
+			username VARCHAR(255) NOT NULL UNIQUE,
+			password_hash VARCHAR(255) NOT NULL,
+			role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';
+		UPDATE users SET role = 'viewer' WHERE role = '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name VARCHAR(255) NOT NULL DEFAULT '';
+		CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON users (LOWER(email));
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			token_hash VARCHAR(64) PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS refresh_tokens_user_idx ON refresh_tokens (user_id);
+		-- Refresh-токены одной сессии образуют семейство: обмен помечает
+		-- токен использованным и выдаёт следующий в том же семействе.
+		-- access_jti — access-токен, выданный вместе с refresh-токеном.
+		ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family VARCHAR(64);
+		UPDATE refresh_tokens SET family = token_hash WHERE family IS NULL;
+		ALTER TABLE refresh_tokens ALTER COLUMN family SET NOT NULL;
+		ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS used_at TIMESTAMPTZ;
+		ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS access_jti VARCHAR(64);
+		ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS access_expires_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family);
+		-- Учётные записи у провайдеров OAuth2, через которые входит
+		-- пользователь. Пароля у пользователя, заведённого при таком входе,
+		-- нет: password_hash пустой.
+		CREATE TABLE IF NOT EXISTS user_identities (
+			provider VARCHAR(32) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			email VARCHAR(255),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (provider, subject)
+		);
+		CREATE INDEX IF NOT EXISTS user_identities_user_idx ON user_identities (user_id);
+		-- Журнал аудита: кто, когда и с какого адреса что изменил.
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			actor VARCHAR(255) NOT NULL DEFAULT '',
+			actor_name VARCHAR(255) NOT NULL DEFAULT '',
+			action VARCHAR(16) NOT NULL,
+			method VARCHAR(8) NOT NULL,
+			path TEXT NOT NULL,
+			resource TEXT NOT NULL,
+			resource_id VARCHAR(255) NOT NULL DEFAULT '',
+			status INTEGER NOT NULL,
+			changes JSONB NOT NULL DEFAULT '{}',
+			ip VARCHAR(64) NOT NULL DEFAULT '',
+			request_id VARCHAR(64) NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, id);
+		CREATE INDEX IF NOT EXISTS audit_log_resource_idx ON audit_log (resource, resource_id, id);
+		CREATE INDEX IF NOT EXISTS audit_log_created_idx ON audit_log (created_at);
+		-- Access-токены, отозванные до истечения срока.
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti VARCHAR(64) PRIMARY KEY,
+			expires_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			key_hash VARCHAR(64) NOT NULL UNIQUE,
+			prefix VARCHAR(16) NOT NULL,
+			scopes TEXT[] NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ
+		);
+	`)
+	return err
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"server/internal/config"
+	"sort"
+	"sync"
+	"time"
+)
+
+// probeTimeout ограничивает одну проверку реплики: недоступный регион не
+// должен задерживать проверку остальных.
+const probeTimeout = 2 * time.Second
+
+// latencySmoothing — вес нового замера в скользящей средней задержки, чтобы
+// единичный всплеск не перебрасывал чтение между регионами.
+const latencySmoothing = 0.3
+
+// ReadRouter направляет запросы на чтение в здоровую реплику с наименьшей
+// задержкой. Если здоровых реплик нет, чтение идёт в основную базу.
+type ReadRouter struct {
+	primary  *sql.DB
+	replicas []*replica
+	maxLag   time.Duration
+
+	mu      sync.RWMutex
+	current *replica
+}
+
+type replica struct {
+	region  string
+	db      *sql.DB
+	healthy bool
+	latency time.Duration
+	lag     time.Duration
+	checked time.Time
+}
+
+// ReplicaStatus — состояние реплики на момент последней проверки.
+type ReplicaStatus struct {
+	Region    string        `json:"region"`
+	Healthy   bool          `json:"healthy"`
+	Active    bool          `json:"active"`
+	Latency   time.Duration `json:"latency"`
+	Lag       time.Duration `json:"lag"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// OpenReplicas открывает пулы соединений к репликам из cfg. Миграции к
+// репликам не применяются: схема приходит с основного сервера. До первой
+// проверки все чтения идут в primary.
+func OpenReplicas(cfg config.DBConfig, primary *sql.DB) (*ReadRouter, error) {
+	r := &ReadRouter{primary: primary, maxLag: cfg.ReplicaMaxLag}
+	for _, rc := range cfg.Replicas {
+		db, err := connect(cfg, rc.Host, rc.Port)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.replicas = append(r.replicas, &replica{region: rc.Region, db: db})
+	}
+	return r, nil
+}
+
+// Reader возвращает пул для запросов на чтение.
+func (r *ReadRouter) Reader() *sql.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.current == nil {
+		return r.primary
+	}
+	return r.current.db
+}
+
+// Run проверяет реплики сразу и затем каждые interval. Блокирует вызывающего,
+// запускать в отдельной горутине.
+func (r *ReadRouter) Run(interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	r.Probe()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.Probe()
+	}
+}
+
+// Probe параллельно проверяет все реплики и выбирает активную.
+func (r *ReadRouter) Probe() {
+	type result struct {
+		latency time.Duration
+		lag     time.Duration
+		err     error
+	}
+	results := make([]result, len(r.replicas))
+	var wg sync.WaitGroup
+	for i, rep := range r.replicas {
+		wg.Add(1)
+		go func(i int, rep *replica) {
+			defer wg.Done()
+			results[i].latency, results[i].lag, results[i].err = probe(rep.db)
+		}(i, rep)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var best *replica
+	for i, rep := range r.replicas {
+		res := results[i]
+		wasHealthy := rep.healthy
+		rep.checked = time.Now()
+		rep.healthy = res.err == nil && (r.maxLag <= 0 || res.lag <= r.maxLag)
+		if res.err == nil {
+			rep.lag = res.lag
+			if rep.latency == 0 {
+				rep.latency = res.latency
+			} else {
+				rep.latency += time.Duration(latencySmoothing * float64(res.latency-rep.latency))
+			}
+		}
+		switch {
+		case wasHealthy && res.err != nil:
+			log.Printf("Реплика %s недоступна: %v", rep.region, res.err)
+		case wasHealthy && !rep.healthy:
+			log.Printf("Реплика %s отстаёт на %s, чтение переключается", rep.region, res.lag)
+		case !wasHealthy && rep.healthy:
+			log.Printf("Реплика %s доступна, задержка %s", rep.region, rep.latency)
+		}
+		if rep.healthy && (best == nil || rep.latency < best.latency) {
+			best = rep
+		}
+	}
+	r.current = best
+}
+
+// probe измеряет время ответа реплики и её отставание от primary. Реплика,
+// применившая весь полученный WAL, считается догнавшей: иначе в отсутствие
+// записей отставание по времени последней транзакции росло бы бесконечно.
+func probe(db *sql.DB) (latency, lag time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	start := time.Now()
+	var seconds float64
+	err = db.QueryRowContext(ctx, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0)
+		END::float8`).Scan(&seconds)
+	latency = time.Since(start)
+	return latency, time.Duration(seconds * float64(time.Second)), err
+}
+
+// Status возвращает состояние реплик, отсортированное по региону.
+func (r *ReadRouter) Status() []ReplicaStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := make([]ReplicaStatus, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		statuses = append(statuses, ReplicaStatus{
+			Region:    rep.region,
+			Healthy:   rep.healthy,
+			Active:    rep == r.current,
+			Latency:   rep.latency,
+			Lag:       rep.lag,
+			CheckedAt: rep.checked,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Region < statuses[j].Region })
+	return statuses
+}
+
+// ErrNoHealthyReplicas — ни одна реплика не прошла последнюю проверку, и
+// чтение идёт в основную базу.
+var ErrNoHealthyReplicas = errors.New("no healthy read replicas")
+
+// Check возвращает ErrNoHealthyReplicas, если реплики настроены, но ни одна
+// не здорова по последней проверке.
+func (r *ReadRouter) Check(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.replicas) == 0 {
+		return nil
+	}
+	for _, rep := range r.replicas {
+		if rep.healthy {
+			return nil
+		}
+	}
+	return ErrNoHealthyReplicas
+}
+
+// Close закрывает пулы реплик; основной пул закрывает его владелец.
+func (r *ReadRouter) Close() {
+	for _, rep := range r.replicas {
+		rep.db.Close()
+	}
+}
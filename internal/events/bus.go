@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Event — доменное событие, которое рассылается подписчикам (вебхукам и т.п.).
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+	// RequestID — ID запроса, в ходе которого произошло событие; позволяет
+	// проследить путь от API-вызова до вебхука или фрейма WebSocket.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Bus — внутрипроцессная шина событий. Подписчики вызываются синхронно
+// в горутине публикующего, поэтому не должны блокироваться надолго.
+type Bus struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers []subscriber
+}
+
+type subscriber struct {
+	id uint64
+	fn func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.subscribe(fn)
+}
+
+func (b *Bus) subscribe(fn func(Event)) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	b.subscribers = append(b.subscribers, subscriber{id: b.nextID, fn: fn})
+	return b.nextID
+}
+
+func (b *Bus) unsubscribe(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, sub := range b.subscribers {
+		if sub.id == id {
+			b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Listen подписывает на события канал с буфером buffer и возвращает функцию
+// отписки, после которой канал закрывается. Если получатель не успевает
+// читать, события для него отбрасываются, чтобы не блокировать публикацию.
+func (b *Bus) Listen(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	if b == nil {
+		return ch, func() { close(ch) }
+	}
+
+	var mu sync.Mutex
+	closed := false
+	id := b.subscribe(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	})
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.unsubscribe(id)
+			mu.Lock()
+			closed = true
+			close(ch)
+			mu.Unlock()
+		})
+	}
+}
+
+// Publish создаёт событие с ID запроса из ctx и передаёт его всем подписчикам.
+// Вызов на nil-шине ничего не делает, чтобы сервисы можно было собирать без событий.
+func (b *Bus) Publish(ctx context.Context, eventType string, data interface{}) Event {
+	event := New(eventType, data)
+	event.RequestID = RequestID(ctx)
+	if b == nil {
+		return event
+	}
+
+	b.mu.RLock()
+	subscribers := append([]subscriber{}, b.subscribers...)
+	b.mu.RUnlock()
+	for _, sub := range subscribers {
+		sub.fn(event)
+	}
+	return event
+}
+
+// New создаёт событие с уникальным ID без публикации.
+func New(eventType string, data interface{}) Event {
+	return Event{ID: newID(), Type: eventType, OccurredAt: time.Now().UTC(), Data: data}
+}
+
+func newID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
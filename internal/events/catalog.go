@@ -0,0 +1,160 @@
+package events
+
+const (
+	ProductCreated  = "product.created"
+	ProductUpdated  = "product.updated"
+	ProductDeleted  = "product.deleted"
+	ProductRestored = "product.restored"
+	// StockChanged — изменился остаток продукта на одном складе.
+	StockChanged = "product.stock_changed"
+	// CategoryReassigned — категория перенесена на всех продуктах разом;
+	// одно событие вместо product.updated по каждому продукту.
+	CategoryReassigned = "category.reassigned"
+	// SLOBurnRate не публикуется в шину: тревоги уходят только вебхукам и в
+	// журнал событий, а не клиентам WebSocket.
+	SLOBurnRate = "slo.burn_rate"
+)
+
+// Definition описывает тип события для интеграторов: назначение, JSON Schema
+// поля data и пример полезной нагрузки.
+type Definition struct {
+	Type        string                 `json:"type"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+	Example     interface{}            `json:"example"`
+}
+
+// ProductRef — полезная нагрузка событий, в которых известен только ID продукта.
+type ProductRef struct {
+	ID int `json:"id"`
+}
+
+var productSchema = map[string]interface{}{
+	"$schema":  "http://json-schema.org/draft-07/schema#",
+	"type":     "object",
+	"required": []string{"id", "name", "price"},
+	"properties": map[string]interface{}{
+		"id":          map[string]interface{}{"type": "integer"},
+		"name":        map[string]interface{}{"type": "string"},
+		"price":       map[string]interface{}{"type": "number"},
+		"description": map[string]interface{}{"type": "string"},
+		"categories":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"sku":         map[string]interface{}{"type": "string"},
+		"barcode":     map[string]interface{}{"type": "string"},
+	},
+}
+
+var productRefSchema = map[string]interface{}{
+	"$schema":  "http://json-schema.org/draft-07/schema#",
+	"type":     "object",
+	"required": []string{"id"},
+	"properties": map[string]interface{}{
+		"id": map[string]interface{}{"type": "integer"},
+	},
+}
+
+var stockSchema = map[string]interface{}{
+	"$schema":  "http://json-schema.org/draft-07/schema#",
+	"type":     "object",
+	"required": []string{"product_id", "warehouse", "quantity"},
+	"properties": map[string]interface{}{
+		"product_id": map[string]interface{}{"type": "integer"},
+		"warehouse":  map[string]interface{}{"type": "string"},
+		"quantity":   map[string]interface{}{"type": "integer"},
+		"updated_at": map[string]interface{}{"type": "string", "format": "date-time"},
+	},
+}
+
+var exampleStock = map[string]interface{}{
+	"product_id": 42,
+	"warehouse":  "main",
+	"quantity":   7,
+	"updated_at": "2024-01-01T12:00:00Z",
+}
+
+var exampleProduct = map[string]interface{}{
+	"id":          42,
+	"name":        "Sample product",
+	"price":       199.99,
+	"description": "Sample payload sent by the webhook test endpoint",
+	"categories":  []string{"samples"},
+	"sku":         "SAMPLE-42",
+}
+
+var categoryReassignedSchema = map[string]interface{}{
+	"$schema":  "http://json-schema.org/draft-07/schema#",
+	"type":     "object",
+	"required": []string{"from", "to", "products", "merged", "listing_moved"},
+	"properties": map[string]interface{}{
+		"from":          map[string]interface{}{"type": "string"},
+		"to":            map[string]interface{}{"type": "string"},
+		"products":      map[string]interface{}{"type": "integer"},
+		"merged":        map[string]interface{}{"type": "integer"},
+		"listing_moved": map[string]interface{}{"type": "boolean"},
+	},
+}
+
+var exampleCategoryReassigned = map[string]interface{}{
+	"from":          "phones",
+	"to":            "smartphones",
+	"products":      17,
+	"merged":        3,
+	"listing_moved": true,
+}
+
+var sloAlertSchema = map[string]interface{}{
+	"$schema":  "http://json-schema.org/draft-07/schema#",
+	"type":     "object",
+	"required": []string{"route", "state", "burn_rate_1h", "burn_rate_5m"},
+	"properties": map[string]interface{}{
+		"route":        map[string]interface{}{"type": "string"},
+		"state":        map[string]interface{}{"type": "string", "enum": []string{"firing", "resolved"}},
+		"target":       map[string]interface{}{"type": "number"},
+		"latency_ms":   map[string]interface{}{"type": "integer"},
+		"burn_rate_1h": map[string]interface{}{"type": "number"},
+		"burn_rate_5m": map[string]interface{}{"type": "number"},
+		"threshold":    map[string]interface{}{"type": "number"},
+		"at":           map[string]interface{}{"type": "string", "format": "date-time"},
+	},
+}
+
+var exampleSLOAlert = map[string]interface{}{
+	"route":        "GET /api/products",
+	"state":        "firing",
+	"target":       0.995,
+	"latency_ms":   300,
+	"burn_rate_1h": 16.2,
+	"burn_rate_5m": 21.7,
+	"threshold":    14.4,
+	"at":           "2024-01-01T12:00:00Z",
+}
+
+// Catalog перечисляет все типы событий, которые может получить подписчик.
+var Catalog = []Definition{
+	{Type: ProductCreated, Description: "A product was created.", Schema: productSchema, Example: exampleProduct},
+	{Type: ProductUpdated, Description: "A product was updated; data holds the new state.", Schema: productSchema, Example: exampleProduct},
+	{Type: ProductDeleted, Description: "A product was moved to the trash.", Schema: productRefSchema, Example: ProductRef{ID: 42}},
+	{Type: ProductRestored, Description: "A product was restored from the trash.", Schema: productRefSchema, Example: ProductRef{ID: 42}},
+	{Type: StockChanged, Description: "The stock of a product in one warehouse changed; data holds the new level.", Schema: stockSchema, Example: exampleStock},
+	{Type: CategoryReassigned, Description: "A category was renamed or merged into another across all products; data holds the affected counts.", Schema: categoryReassignedSchema, Example: exampleCategoryReassigned},
+	{Type: SLOBurnRate, Description: "A route started or stopped burning its error budget fast.", Schema: sloAlertSchema, Example: exampleSLOAlert},
+}
+
+// Lookup возвращает описание типа события.
+func Lookup(eventType string) (Definition, bool) {
+	for _, d := range Catalog {
+		if d.Type == eventType {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Sample создаёт пример события указанного типа для тестовой доставки.
+func Sample(eventType string) (Event, bool) {
+	d, ok := Lookup(eventType)
+	if !ok {
+		return Event{}, false
+	}
+	return New(d.Type, d.Example), true
+}
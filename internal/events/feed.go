@@ -0,0 +1,158 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidCursor — курсор не выдавался лентой.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// defaultFeedSize — размер ленты, если он не задан.
+const defaultFeedSize = 1000
+
+// Feed хранит последние события шины с порядковыми номерами, чтобы клиенты
+// без WebSocket забирали их длинным опросом: курсор — номер последнего
+// полученного события. Номера живут в памяти процесса, поэтому курсор
+// содержит эпоху ленты, и курсор до перезапуска считается устаревшим.
+type Feed struct {
+	epoch string
+	size  int
+	mu    sync.Mutex
+	seq   uint64
+	// events — последние size событий; events[i] имеет номер first+i.
+	events []Event
+	first  uint64
+	// notify закрывается и заменяется при каждом новом событии.
+	notify chan struct{}
+}
+
+// FeedPage — события после курсора и курсор для следующего опроса. Gap —
+// часть событий после курсора уже вытеснена из ленты или потеряна при
+// перезапуске, и клиенту стоит перечитать состояние через REST.
+type FeedPage struct {
+	Events []Event `json:"events"`
+	Cursor string  `json:"cursor"`
+	Gap    bool    `json:"gap,omitempty"`
+}
+
+func NewFeed(size int) *Feed {
+	if size <= 0 {
+		size = defaultFeedSize
+	}
+	return &Feed{epoch: newID()[:8], size: size, first: 1, notify: make(chan struct{})}
+}
+
+// Publish добавляет событие в ленту; подписывается на шину событий.
+func (f *Feed) Publish(event Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	f.events = append(f.events, event)
+	if len(f.events) > f.size {
+		f.events = f.events[len(f.events)-f.size:]
+		f.first = f.seq - uint64(f.size) + 1
+	}
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+// FeedEntry — событие ленты и курсор, указывающий сразу после него.
+type FeedEntry struct {
+	Event  Event
+	Cursor string
+}
+
+// Poll возвращает события после cursor, отфильтрованные по types (пусто —
+// все типы). Если их нет, ждёт новых, пока не истечёт ctx, и возвращает
+// пустую страницу с тем же курсором. Пустой cursor — только события,
+// которые появятся после вызова.
+func (f *Feed) Poll(ctx context.Context, cursor string, types []string) (FeedPage, error) {
+	entries, next, gap, err := f.Wait(ctx, cursor, types)
+	if err != nil {
+		return FeedPage{}, err
+	}
+	page := FeedPage{Events: make([]Event, len(entries)), Cursor: next, Gap: gap}
+	for i, entry := range entries {
+		page.Events[i] = entry.Event
+	}
+	return page, nil
+}
+
+// Wait — то же, что Poll, но возвращает курсор каждого события, чтобы
+// поток SSE мог продолжиться с любого из них, и курсор после всех
+// просмотренных событий.
+func (f *Feed) Wait(ctx context.Context, cursor string, types []string) (entries []FeedEntry, next string, gap bool, err error) {
+	f.mu.Lock()
+	after, gap, err := f.parse(cursor)
+	f.mu.Unlock()
+	if err != nil {
+		return nil, "", false, err
+	}
+	for {
+		f.mu.Lock()
+		if after < f.first-1 {
+			after, gap = f.first-1, true
+		}
+		for i := after - (f.first - 1); i < uint64(len(f.events)); i++ {
+			if matches(f.events[i].Type, types) {
+				entries = append(entries, FeedEntry{Event: f.events[i], Cursor: f.cursor(f.first + i)})
+			}
+		}
+		// Неподходящие по типу события тоже пропускаются курсором.
+		after = f.seq
+		notify := f.notify
+		f.mu.Unlock()
+
+		if len(entries) > 0 || gap {
+			return entries, f.cursor(after), gap, nil
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, f.cursor(after), false, nil
+		}
+	}
+}
+
+// parse разбирает курсор; вызывается под mu. Курсор другой эпохи
+// устарел: ленту читают с начала с признаком разрыва.
+func (f *Feed) parse(cursor string) (after uint64, gap bool, err error) {
+	if cursor == "" {
+		return f.seq, false, nil
+	}
+	epoch, number, ok := strings.Cut(cursor, "-")
+	if !ok {
+		return 0, false, ErrInvalidCursor
+	}
+	seq, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, false, ErrInvalidCursor
+	}
+	if epoch != f.epoch {
+		return f.first - 1, true, nil
+	}
+	if seq > f.seq {
+		return 0, false, ErrInvalidCursor
+	}
+	return seq, false, nil
+}
+
+func (f *Feed) cursor(seq uint64) string {
+	return f.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func matches(eventType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
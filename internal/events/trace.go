@@ -0,0 +1,17 @@
+package events
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID сохраняет в контексте ID запроса, вызвавшего изменения.
+// Опубликованные в этом контексте события несут его в поле RequestID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает ID запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
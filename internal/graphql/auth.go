@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"github.com/graphql-go/graphql"
+	"net/http"
+	"server/internal/auth"
+)
+
+// withAuth определяет вызывающего по заголовку Authorization, а без
+// Bearer-токена — по ключу API из X-API-Key. Запрос без учётных данных или
+// с недействительными выполняется анонимно: поля, которые требуют прав,
+// вернут ошибку, а остальные — данные.
+func withAuth(authenticator, apiKeys auth.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := authenticate(r.Context(), authenticator, r.Header.Get("Authorization"))
+		if _, ok := auth.FromContext(ctx); !ok && apiKeys != nil {
+			if key := r.Header.Get("X-API-Key"); key != "" {
+				if principal, err := apiKeys.Authenticate(key); err == nil {
+					ctx = auth.WithPrincipal(ctx, principal)
+				}
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate добавляет в контекст вызывающего, если заголовок содержит
+// действительный Bearer-токен.
+func authenticate(ctx context.Context, authenticator auth.Authenticator, header string) context.Context {
+	if authenticator == nil {
+		return ctx
+	}
+	token, ok := auth.BearerToken(header)
+	if !ok {
+		return ctx
+	}
+	principal, err := authenticator.Authenticate(token)
+	if err != nil {
+		return ctx
+	}
+	return auth.WithPrincipal(ctx, principal)
+}
+
+// requireRole оборачивает резолвер поля проверкой роли. Проверка выполняется
+// до резолвера, так что мутация без прав не успевает ничего изменить; отказ
+// становится ошибкой этого поля, и остальная часть ответа не теряется.
+func requireRole(role string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		if err := auth.Require(params.Context, role); err != nil {
+			return nil, err
+		}
+		return resolve(params)
+	}
+}
+
+// withFieldPolicy оборачивает проверкой роли поля типа typeName, перечисленные
+// в auth.FieldPolicy.
+func withFieldPolicy(typeName string, fields graphql.Fields) graphql.Fields {
+	for _, rule := range auth.FieldPolicy {
+		if field, ok := fields[rule.GraphQL]; ok && rule.Type == typeName {
+			field.Resolve = requireRole(rule.Role, field.Resolve)
+		}
+	}
+	return fields
+}
+
+// checkInputPolicy проверяет аргумент мутации с полями типа typeName по
+// auth.FieldPolicy: поле, которое вызывающему видеть нельзя, ему нельзя и
+// записать, и мутация с ним отклоняется до изменения данных.
+func checkInputPolicy(ctx context.Context, typeName string, input map[string]interface{}) error {
+	for _, rule := range auth.FieldPolicy {
+		if _, ok := input[rule.GraphQL]; ok && rule.Type == typeName {
+			if err := auth.Require(ctx, rule.Role); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// requirePermission оборачивает резолвер поля проверкой права так же, как
+// requireRole — проверкой роли.
+func requirePermission(perm auth.Permission, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		if err := auth.RequirePermission(params.Context, perm); err != nil {
+			return nil, err
+		}
+		return resolve(params)
+	}
+}
+
+// mutationPermissions — права мутаций, которым недостаточно auth.PermWrite.
+var mutationPermissions = map[string]auth.Permission{
+	"deleteProduct": auth.PermDelete,
+}
+
+// withMutationPermissions разрешает мутации только вызывающим с правом
+// из mutationPermissions, а остальные — с auth.PermWrite; права ролей те
+// же, что у REST.
+func withMutationPermissions(fields graphql.Fields) graphql.Fields {
+	for name, field := range fields {
+		perm, ok := mutationPermissions[name]
+		if !ok {
+			perm = auth.PermWrite
+		}
+		field.Resolve = requirePermission(perm, field.Resolve)
+	}
+	return fields
+}
@@ -0,0 +1,33 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"server/internal/auth"
+	"testing"
+)
+
+func TestCheckInputPolicy(t *testing.T) {
+	withRole := func(role string) context.Context {
+		return auth.WithPrincipal(context.Background(), auth.Principal{Subject: "1", Role: role})
+	}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		input map[string]interface{}
+		want  error
+	}{
+		{name: "editor without restricted fields", ctx: withRole(auth.RoleEditor), input: map[string]interface{}{"name": "x", "price": 2.0}},
+		{name: "editor sets costPrice", ctx: withRole(auth.RoleEditor), input: map[string]interface{}{"name": "x", "costPrice": 1.0}, want: auth.ErrForbidden},
+		{name: "editor sets costPrice to null", ctx: withRole(auth.RoleEditor), input: map[string]interface{}{"costPrice": nil}, want: auth.ErrForbidden},
+		{name: "anonymous sets costPrice", ctx: context.Background(), input: map[string]interface{}{"costPrice": 1.0}, want: auth.ErrUnauthenticated},
+		{name: "admin sets costPrice", ctx: withRole(auth.RoleAdmin), input: map[string]interface{}{"costPrice": 1.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInputPolicy(tt.ctx, "Product", tt.input); !errors.Is(err, tt.want) {
+				t.Fatalf("checkInputPolicy() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"server/internal/service"
+	"strconv"
+	"strings"
+)
+
+const cursorPrefix = "product:"
+
+// encodeCursor возвращает непрозрачный курсор продукта.
+func encodeCursor(id int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(raw), cursorPrefix) {
+		if id, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix)); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+	return 0, &service.ValidationError{Message: fmt.Sprintf("invalid cursor %q", cursor)}
+}
+
+type productEdge struct {
+	Node   interface{} `json:"node"`
+	Cursor string      `json:"cursor"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type productConnection struct {
+	Edges      []productEdge `json:"edges"`
+	PageInfo   pageInfo      `json:"pageInfo"`
+	TotalCount int           `json:"totalCount"`
+	// search — поисковый запрос страницы для didYouMean.
+	search string
+}
+
+var pageInfoType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	},
+)
+
+var productEdgeType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "ProductEdge",
+		Fields: graphql.Fields{
+			"node":   &graphql.Field{Type: graphql.NewNonNull(productType)},
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	},
+)
+
+var productConnectionType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "ProductConnection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productEdgeType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	},
+)
+
+// resolveProductsConnection отдаёт продукты в формате Relay Cursor Connections.
+func (r *resolver) resolveProductsConnection(params graphql.ResolveParams) (interface{}, error) {
+	cursorParams := service.CursorParams{Filter: filterFromArgs(params.Args)}
+	cursorParams.First, _ = params.Args["first"].(int)
+	cursorParams.Last, _ = params.Args["last"].(int)
+	lang, _ := params.Args["lang"].(string)
+	cursorParams.Locales = service.LocaleCandidates(lang)
+	if after, ok := params.Args["after"].(string); ok {
+		id, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		cursorParams.After = id
+	}
+	if before, ok := params.Args["before"].(string); ok {
+		id, err := decodeCursor(before)
+		if err != nil {
+			return nil, err
+		}
+		cursorParams.Before = id
+	}
+
+	page, err := r.products.Page(params.Context, cursorParams)
+	if err != nil {
+		return nil, err
+	}
+	connection := productConnection{
+		Edges: make([]productEdge, len(page.Products)),
+		PageInfo: pageInfo{
+			HasNextPage:     page.HasNextPage,
+			HasPreviousPage: page.HasPreviousPage,
+		},
+		TotalCount: page.TotalCount,
+		search:     cursorParams.Filter.Search,
+	}
+	for i, product := range page.Products {
+		connection.Edges[i] = productEdge{Node: product, Cursor: encodeCursor(product.ID)}
+	}
+	if n := len(connection.Edges); n > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[n-1].Cursor
+	}
+	return connection, nil
+}
@@ -0,0 +1,362 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"math"
+	"net"
+	"net/http"
+	"server/internal/auth"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Коды отказа по стоимости запроса.
+const (
+	// CodeRateLimited — клиент исчерпал бюджет стоимости в текущем окне.
+	CodeRateLimited = "RATE_LIMITED"
+	// CodeQueryTooCostly — запрос дороже всего бюджета окна и не пройдёт никогда.
+	CodeQueryTooCostly = "QUERY_TOO_COSTLY"
+)
+
+const (
+	// maxListSize ограничивает множитель списка, заданный аргументом запроса.
+	maxListSize = 10_000
+	// maxQueryCost — потолок оценки: глубоко вложенные списки иначе
+	// переполнили бы int.
+	maxQueryCost = math.MaxInt32
+)
+
+// CostOptions настраивает ограничение стоимости запросов.
+type CostOptions struct {
+	// Budget — суммарная стоимость запросов одного клиента за Window.
+	Budget int
+	Window time.Duration
+	// DefaultListSize — сколько элементов предполагается в списке, размер
+	// которого не задан аргументами first, last или limit.
+	DefaultListSize int
+}
+
+// CostLimiter ограничивает суммарную стоимость GraphQL-запросов клиента в
+// скользящем окне. Клиент — администратор по токену, остальные — по IP.
+// Простое число запросов для GraphQL не годится: один запрос может
+// затребовать тысячи объектов.
+//
+// Стоимость оценивается по тексту запроса до выполнения: каждое поле стоит
+// 1, а поддерево поля-списка умножается на ожидаемое число элементов.
+type CostLimiter struct {
+	schema   *graphql.Schema
+	budget   int
+	window   time.Duration
+	listSize int
+
+	mu      sync.Mutex
+	clients map[string]*costWindow
+	swept   time.Time
+}
+
+// costWindow — стоимости запросов клиента в пределах окна по времени.
+type costWindow struct {
+	entries []costEntry
+	total   int
+}
+
+type costEntry struct {
+	at   time.Time
+	cost int
+}
+
+func NewCostLimiter(schema *graphql.Schema, opts CostOptions) *CostLimiter {
+	return &CostLimiter{
+		schema:   schema,
+		budget:   opts.Budget,
+		window:   opts.Window,
+		listSize: opts.DefaultListSize,
+		clients:  make(map[string]*costWindow),
+		swept:    time.Now(),
+	}
+}
+
+// costError — отказ из-за стоимости запроса.
+type costError struct {
+	cost       int
+	budget     int
+	retryAfter time.Duration
+}
+
+func (e *costError) retrySeconds() int {
+	return int(math.Ceil(e.retryAfter.Seconds()))
+}
+
+func (e *costError) formatted() gqlerrors.FormattedError {
+	extensions := map[string]interface{}{"cost": e.cost, "budget": e.budget}
+	if e.retryAfter <= 0 {
+		extensions["code"] = CodeQueryTooCostly
+		return gqlerrors.FormattedError{
+			Message:    fmt.Sprintf("query cost %d exceeds the budget of %d", e.cost, e.budget),
+			Extensions: extensions,
+		}
+	}
+	extensions["code"] = CodeRateLimited
+	extensions["retryAfter"] = e.retrySeconds()
+	return gqlerrors.FormattedError{
+		Message:    fmt.Sprintf("query cost budget exhausted; retry in %d seconds", e.retrySeconds()),
+		Extensions: extensions,
+	}
+}
+
+func writeCostError(w http.ResponseWriter, err *costError) {
+	status := http.StatusBadRequest
+	if err.retryAfter > 0 {
+		status = http.StatusTooManyRequests
+		w.Header().Set("Retry-After", strconv.Itoa(err.retrySeconds()))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []gqlerrors.FormattedError{err.formatted()},
+	})
+}
+
+// clientID определяет, чей бюджет расходует запрос.
+func clientID(ctx context.Context, remoteAddr string) string {
+	if principal, ok := auth.FromContext(ctx); ok {
+		return "token:" + principal.Subject
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + remoteAddr
+}
+
+// check оценивает стоимость запроса и списывает её с бюджета клиента.
+// Документ с ошибкой разбора ничего не стоит — его отвергнет graphql-go.
+func (l *CostLimiter) check(client, query, operationName string, variables map[string]interface{}) *costError {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+	cost := l.cost(document, operationName, variables)
+	if cost > l.budget {
+		return &costError{cost: cost, budget: l.budget}
+	}
+	if retryAfter := l.spend(client, cost, time.Now()); retryAfter > 0 {
+		return &costError{cost: cost, budget: l.budget, retryAfter: retryAfter}
+	}
+	return nil
+}
+
+// spend списывает cost с бюджета клиента. Если бюджета не хватает, ничего
+// не списывается и возвращается время, через которое из окна уйдёт
+// достаточно прежних запросов.
+func (l *CostLimiter) spend(client string, cost int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := now.Add(-l.window)
+	if now.Sub(l.swept) > l.window {
+		for id, w := range l.clients {
+			if w.expire(start); len(w.entries) == 0 {
+				delete(l.clients, id)
+			}
+		}
+		l.swept = now
+	}
+
+	w := l.clients[client]
+	if w == nil {
+		w = &costWindow{}
+		l.clients[client] = w
+	}
+	w.expire(start)
+	if over := w.total + cost - l.budget; over > 0 {
+		freed := 0
+		for _, entry := range w.entries {
+			if freed += entry.cost; freed >= over {
+				return entry.at.Add(l.window).Sub(now)
+			}
+		}
+	}
+	w.entries = append(w.entries, costEntry{at: now, cost: cost})
+	w.total += cost
+	return 0
+}
+
+// expire убирает запросы, сделанные раньше start.
+func (w *costWindow) expire(start time.Time) {
+	n := 0
+	for n < len(w.entries) && !w.entries[n].at.After(start) {
+		w.total -= w.entries[n].cost
+		n++
+	}
+	w.entries = w.entries[n:]
+}
+
+// cost оценивает стоимость операции документа.
+func (l *CostLimiter) cost(document *ast.Document, operationName string, variables map[string]interface{}) int {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	operation := findOperation(document, operationName)
+	if operation == nil {
+		return 0
+	}
+
+	var root graphql.Type
+	switch operation.Operation {
+	case ast.OperationTypeMutation:
+		root = l.schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = l.schema.SubscriptionType()
+	default:
+		root = l.schema.QueryType()
+	}
+	c := &costCounter{limiter: l, fragments: fragments, variables: variables, visiting: make(map[string]bool)}
+	return c.selectionSet(operation.SelectionSet, root)
+}
+
+// findOperation возвращает операцию документа, которую выполнит graphql-go:
+// названную operationName или первую, если имя не задано.
+func findOperation(document *ast.Document, operationName string) *ast.OperationDefinition {
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+			return operation
+		}
+	}
+	return nil
+}
+
+type costCounter struct {
+	limiter   *CostLimiter
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// visiting защищает от циклических фрагментов, которые отвергнет валидация.
+	visiting map[string]bool
+}
+
+func (c *costCounter) selectionSet(set *ast.SelectionSet, parent graphql.Type) int {
+	if set == nil {
+		return 0
+	}
+	total := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			total = min(total+c.field(selection, parent), maxQueryCost)
+		case *ast.InlineFragment:
+			total = min(total+c.selectionSet(selection.SelectionSet, c.typeCondition(selection.TypeCondition, parent)), maxQueryCost)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			total = min(total+c.selectionSet(fragment.SelectionSet, c.typeCondition(fragment.TypeCondition, parent)), maxQueryCost)
+			c.visiting[name] = false
+		}
+	}
+	return total
+}
+
+func (c *costCounter) typeCondition(condition *ast.Named, parent graphql.Type) graphql.Type {
+	if condition == nil || condition.Name == nil {
+		return parent
+	}
+	if t := c.limiter.schema.Type(condition.Name.Value); t != nil {
+		return t
+	}
+	return parent
+}
+
+func (c *costCounter) field(field *ast.Field, parent graphql.Type) int {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		// Интроспекция не обращается к базе; её поддерево не оцениваем.
+		return 1
+	}
+	var definition *graphql.FieldDefinition
+	switch parent := graphql.GetNamed(parent).(type) {
+	case *graphql.Object:
+		definition = parent.Fields()[name]
+	case *graphql.Interface:
+		definition = parent.Fields()[name]
+	}
+	if definition == nil {
+		return 1
+	}
+
+	size, sized := c.listSize(field.Arguments)
+	if !sized {
+		size = 1
+		if _, isList := graphql.GetNullable(definition.Type).(*graphql.List); isList {
+			size = c.limiter.listSize
+		}
+	}
+	children := c.selectionSet(field.SelectionSet, definition.Type)
+	if children > 0 && size > (maxQueryCost-1)/children {
+		return maxQueryCost
+	}
+	return 1 + size*children
+}
+
+// listSize возвращает размер страницы из аргументов first, last или limit.
+func (c *costCounter) listSize(arguments []*ast.Argument) (int, bool) {
+	for _, argument := range arguments {
+		switch argument.Name.Value {
+		case "first", "last", "limit":
+		default:
+			continue
+		}
+		var value interface{}
+		switch v := argument.Value.(type) {
+		case *ast.IntValue:
+			value = v.Value
+		case *ast.Variable:
+			value = c.variables[v.Name.Value]
+		}
+		size := 0
+		switch value := value.(type) {
+		case string:
+			size, _ = strconv.Atoi(value)
+		case float64:
+			size = int(value)
+		case int:
+			size = value
+		}
+		if size > 0 {
+			return min(size, maxListSize), true
+		}
+	}
+	return 0, false
+}
+
+// withCostLimit отвергает запросы, на которые у клиента не хватает бюджета.
+func withCostLimit(limiter *CostLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := peekRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := limiter.check(clientID(r.Context(), r.RemoteAddr), opts.Query, opts.OperationName, opts.Variables); err != nil {
+			writeCostError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
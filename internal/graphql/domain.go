@@ -0,0 +1,367 @@
+package graphql
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"server/internal/imgproxy"
+	"server/internal/models"
+	"server/internal/service"
+)
+
+// defaultReviewLimit — сколько последних отзывов отдаёт Product.reviews без limit.
+const defaultReviewLimit = 10
+
+var categoryType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "Category",
+		Description: "Catalog category; derived from product category names.",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"productCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.Category).ProductCount, nil
+				},
+			},
+		},
+	},
+)
+
+var reviewType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Review",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"author": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"rating": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "From 1 to 5."},
+			"body":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(dateTimeType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.Review).CreatedAt, nil
+				},
+			},
+		},
+	},
+)
+
+var stockType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "Stock",
+		Description: "Quantity of a product in one warehouse.",
+		Fields: graphql.Fields{
+			"warehouse": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"quantity":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"updatedAt": &graphql.Field{
+				Type: graphql.NewNonNull(dateTimeType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.StockLevel).UpdatedAt, nil
+				},
+			},
+		},
+	},
+)
+
+var reviewInputType = graphql.NewInputObject(
+	graphql.InputObjectConfig{
+		Name: "ReviewInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"productId": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"author":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"rating":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"body":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	},
+)
+
+// addRelations добавляет связи между типами домена. Их резолверам нужны
+// сервис и загрузчики, поэтому они подключаются при сборке схемы, а не в
+// объявлениях типов.
+func (r *resolver) addRelations() {
+	productConnectionType.AddFieldConfig("didYouMean", &graphql.Field{
+		Type:        graphql.String,
+		Description: "Corrected search query when the search found few products; null otherwise.",
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			connection := params.Source.(productConnection)
+			if connection.search == "" {
+				return nil, nil
+			}
+			suggestion, err := r.products.DidYouMean(params.Context, connection.search, connection.TotalCount)
+			if err != nil || suggestion == "" {
+				return nil, err
+			}
+			return suggestion, nil
+		},
+	})
+	productType.AddFieldConfig("categoryNodes", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(categoryType))),
+		Description: "Categories of the product with their sizes; `categories` lists only the names.",
+		Resolve:     r.resolveProductCategories,
+	})
+	productType.AddFieldConfig("reviews", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(reviewType))),
+		Description: "Latest reviews, newest first.",
+		Args: graphql.FieldConfigArgument{
+			"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultReviewLimit},
+		},
+		Resolve: r.resolveProductReviews,
+	})
+	productType.AddFieldConfig("reviewCount", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Int),
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return r.reviewSummary(params, func(s models.ReviewSummary) interface{} { return s.Count })
+		},
+	})
+	productType.AddFieldConfig("averageRating", &graphql.Field{
+		Type:        graphql.Float,
+		Description: "Null when the product has no reviews.",
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return r.reviewSummary(params, func(s models.ReviewSummary) interface{} {
+				if s.Count == 0 {
+					return nil
+				}
+				return s.Average
+			})
+		},
+	})
+	productType.AddFieldConfig("stock", &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stockType))),
+		Resolve: r.resolveProductStock,
+	})
+	productType.AddFieldConfig("totalStock", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Int),
+		Description: "Quantity across all warehouses.",
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			load := r.loadersFrom(params.Context).stock.Load(params.Context, params.Source.(models.Product).ID)
+			return func() (interface{}, error) {
+				levels, _, err := load()
+				total := 0
+				for _, level := range levels {
+					total += level.Quantity
+				}
+				return total, err
+			}, nil
+		},
+	})
+
+	attachmentType.AddFieldConfig("imageUrl", &graphql.Field{
+		Type:        graphql.String,
+		Description: "Signed URL of the image resized and converted on the fly.",
+		Args: graphql.FieldConfigArgument{
+			"width":   &graphql.ArgumentConfig{Type: graphql.Int},
+			"height":  &graphql.ArgumentConfig{Type: graphql.Int},
+			"resize":  &graphql.ArgumentConfig{Type: graphql.String, Description: "fit, fill, fill-down, force or auto."},
+			"format":  &graphql.ArgumentConfig{Type: graphql.String, Description: "jpg, png, webp, avif or gif."},
+			"quality": &graphql.ArgumentConfig{Type: graphql.Int},
+		},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			var opts imgproxy.Options
+			opts.Width, _ = params.Args["width"].(int)
+			opts.Height, _ = params.Args["height"].(int)
+			opts.Resize, _ = params.Args["resize"].(string)
+			opts.Format, _ = params.Args["format"].(string)
+			opts.Quality, _ = params.Args["quality"].(int)
+			return r.products.ImageURL(params.Source.(models.Attachment), opts)
+		},
+	})
+	categoryType.AddFieldConfig("products", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productType))),
+		Args: graphql.FieldConfigArgument{
+			"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+			"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+			"lang":   &graphql.ArgumentConfig{Type: graphql.String},
+		},
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			limit, _ := params.Args["limit"].(int)
+			offset, _ := params.Args["offset"].(int)
+			lang, _ := params.Args["lang"].(string)
+			return r.products.List(params.Context, service.ListParams{
+				Limit:   limit,
+				Offset:  offset,
+				Locales: service.LocaleCandidates(lang),
+				Filter:  service.Filter{Categories: []string{params.Source.(models.Category).Name}},
+			})
+		},
+	})
+	reviewType.AddFieldConfig("product", &graphql.Field{
+		Type: productType,
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return r.loadProduct(params, params.Source.(models.Review).ProductID)
+		},
+	})
+	stockType.AddFieldConfig("product", &graphql.Field{
+		Type: productType,
+		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+			return r.loadProduct(params, params.Source.(models.StockLevel).ProductID)
+		},
+	})
+}
+
+// domainQueries — корневые запросы категорий, отзывов и остатков.
+func (r *resolver) domainQueries() graphql.Fields {
+	return graphql.Fields{
+		"categories": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(categoryType))),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				return r.products.Categories(params.Context)
+			},
+		},
+		"category": &graphql.Field{
+			Type: categoryType,
+			Args: graphql.FieldConfigArgument{
+				"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				name, _ := params.Args["name"].(string)
+				category, err := r.products.Category(params.Context, name)
+				if errors.Is(err, service.ErrNotFound) {
+					return nil, nil
+				}
+				return category, err
+			},
+		},
+		"reviews": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(reviewType))),
+			Description: "Reviews across the catalog, newest first.",
+			Args: graphql.FieldConfigArgument{
+				"productId": &graphql.ArgumentConfig{Type: graphql.Int},
+				"minRating": &graphql.ArgumentConfig{Type: graphql.Int},
+				"limit":     &graphql.ArgumentConfig{Type: graphql.Int},
+				"offset":    &graphql.ArgumentConfig{Type: graphql.Int},
+			},
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				var filter service.ReviewFilter
+				filter.ProductID, _ = params.Args["productId"].(int)
+				filter.MinRating, _ = params.Args["minRating"].(int)
+				filter.Limit, _ = params.Args["limit"].(int)
+				filter.Offset, _ = params.Args["offset"].(int)
+				return r.products.Reviews(params.Context, filter)
+			},
+		},
+		"inventory": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stockType))),
+			Description: "Stock levels by warehouse; `below` keeps only levels under the given quantity.",
+			Args: graphql.FieldConfigArgument{
+				"warehouse": &graphql.ArgumentConfig{Type: graphql.String},
+				"below":     &graphql.ArgumentConfig{Type: graphql.Int},
+			},
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				var filter service.StockFilter
+				filter.Warehouse, _ = params.Args["warehouse"].(string)
+				if below, ok := params.Args["below"].(int); ok {
+					filter.Below = &below
+				}
+				return r.products.Inventory(params.Context, filter)
+			},
+		},
+	}
+}
+
+// domainMutations — изменение отзывов и остатков.
+func (r *resolver) domainMutations() graphql.Fields {
+	return graphql.Fields{
+		"addReview": &graphql.Field{
+			Type: reviewType,
+			Args: graphql.FieldConfigArgument{
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(reviewInputType)},
+			},
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				input, _ := params.Args["input"].(map[string]interface{})
+				var review models.Review
+				review.ProductID, _ = input["productId"].(int)
+				review.Author, _ = input["author"].(string)
+				review.Rating, _ = input["rating"].(int)
+				review.Body, _ = input["body"].(string)
+				return r.products.AddReview(params.Context, review)
+			},
+		},
+		"setStock": &graphql.Field{
+			Type: stockType,
+			Args: graphql.FieldConfigArgument{
+				"productId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"warehouse": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"quantity":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				var level models.StockLevel
+				level.ProductID, _ = params.Args["productId"].(int)
+				level.Warehouse, _ = params.Args["warehouse"].(string)
+				level.Quantity, _ = params.Args["quantity"].(int)
+				return r.products.SetStock(params.Context, level)
+			},
+		},
+	}
+}
+
+func (r *resolver) resolveProductCategories(params graphql.ResolveParams) (interface{}, error) {
+	names := params.Source.(models.Product).Categories
+	loader := r.loadersFrom(params.Context).categorySizes
+	loads := make([]func() (int, bool, error), len(names))
+	for i, name := range names {
+		loads[i] = loader.Load(params.Context, name)
+	}
+	return func() (interface{}, error) {
+		categories := make([]models.Category, 0, len(names))
+		for i, name := range names {
+			size, _, err := loads[i]()
+			if err != nil {
+				return nil, err
+			}
+			categories = append(categories, models.Category{Name: name, ProductCount: size})
+		}
+		return categories, nil
+	}, nil
+}
+
+func (r *resolver) resolveProductReviews(params graphql.ResolveParams) (interface{}, error) {
+	limit, _ := params.Args["limit"].(int)
+	if limit <= 0 {
+		return nil, &service.ValidationError{Message: "limit must be positive"}
+	}
+	key := reviewsKey{ProductID: params.Source.(models.Product).ID, Limit: limit}
+	load := r.loadersFrom(params.Context).reviews.Load(params.Context, key)
+	return func() (interface{}, error) {
+		reviews, _, err := load()
+		if reviews == nil {
+			reviews = []models.Review{}
+		}
+		return reviews, err
+	}, nil
+}
+
+// reviewSummary отдаёт поле сводки отзывов продукта; для продукта без отзывов
+// field получает нулевую сводку.
+func (r *resolver) reviewSummary(params graphql.ResolveParams, field func(models.ReviewSummary) interface{}) (interface{}, error) {
+	load := r.loadersFrom(params.Context).reviewSummaries.Load(params.Context, params.Source.(models.Product).ID)
+	return func() (interface{}, error) {
+		summary, _, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return field(summary), nil
+	}, nil
+}
+
+func (r *resolver) resolveProductStock(params graphql.ResolveParams) (interface{}, error) {
+	load := r.loadersFrom(params.Context).stock.Load(params.Context, params.Source.(models.Product).ID)
+	return func() (interface{}, error) {
+		levels, _, err := load()
+		if levels == nil {
+			levels = []models.StockLevel{}
+		}
+		return levels, err
+	}, nil
+}
+
+// loadProduct загружает связанный продукт через загрузчик запроса; удалённый
+// продукт разрешается в null.
+func (r *resolver) loadProduct(params graphql.ResolveParams, id int) (interface{}, error) {
+	load := r.loadersFrom(params.Context).products.Load(params.Context, productKey{ID: id})
+	return func() (interface{}, error) {
+		product, found, err := load()
+		if err != nil || !found {
+			return nil, err
+		}
+		return product, nil
+	}, nil
+}
@@ -0,0 +1,157 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/service"
+	"strings"
+)
+
+// Коды ошибок в extensions.code.
+const (
+	CodeNotFound         = "NOT_FOUND"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeInternal         = "INTERNAL"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodeForbidden        = "FORBIDDEN"
+)
+
+// resolverError запоминает стек в момент, когда резолвер вернул ошибку, и ID
+// запроса для журнала; стек отдаётся клиенту только в отладочном режиме.
+type resolverError struct {
+	err       error
+	stack     string
+	requestID string
+}
+
+func (e *resolverError) Error() string {
+	return e.err.Error()
+}
+
+func (e *resolverError) Unwrap() error {
+	return e.err
+}
+
+// withErrorStacks оборачивает резолверы полей, чтобы их ошибки несли стек вызова.
+func withErrorStacks(fields graphql.Fields) graphql.Fields {
+	for _, field := range fields {
+		if field.Resolve == nil {
+			continue
+		}
+		resolve := field.Resolve
+		field.Resolve = func(params graphql.ResolveParams) (interface{}, error) {
+			result, err := resolve(params)
+			if err == nil {
+				return result, nil
+			}
+			wrapped := &resolverError{err: err, stack: string(debug.Stack())}
+			if params.Context != nil {
+				wrapped.requestID = events.RequestID(params.Context)
+			}
+			return result, wrapped
+		}
+	}
+	return fields
+}
+
+// rootCause разворачивает обёртки graphql-go до исходной ошибки резолвера.
+// Ошибки разбора и валидации документа исходной ошибки не имеют — для них nil.
+func rootCause(err error) error {
+	for err != nil {
+		switch e := err.(type) {
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// formatError преобразует ошибку выполнения в ответ клиенту: известные ошибки
+// сервиса получают код и исходное сообщение, остальные скрываются за INTERNAL
+// и пишутся в журнал. В отладочном режиме добавляются исходный текст и стек.
+func formatError(err error, debugMode bool) gqlerrors.FormattedError {
+	formatted := gqlerrors.FormatError(err)
+	cause := rootCause(err)
+
+	var validation *service.ValidationError
+	var withStack *resolverError
+	errors.As(cause, &withStack)
+	code := CodeInternal
+	switch {
+	case cause == nil:
+		code = CodeValidationFailed
+	case errors.Is(cause, service.ErrNotFound):
+		code = CodeNotFound
+	case errors.Is(cause, auth.ErrUnauthenticated):
+		code = CodeUnauthenticated
+	case errors.Is(cause, auth.ErrForbidden):
+		code = CodeForbidden
+	case errors.As(cause, &validation):
+		code = CodeValidationFailed
+		formatted.Message = validation.Message
+	default:
+		requestID := ""
+		if withStack != nil {
+			requestID = withStack.requestID
+		}
+		log.Printf("Внутренняя ошибка GraphQL (запрос %s, путь %v): %v", requestID, formatted.Path, cause)
+		formatted.Message = "internal server error"
+	}
+	formatted.Extensions = map[string]interface{}{"code": code}
+
+	if debugMode && cause != nil {
+		exception := map[string]interface{}{"message": cause.Error()}
+		if withStack != nil {
+			exception["type"] = fmt.Sprintf("%T", withStack.err)
+			exception["stacktrace"] = strings.Split(strings.TrimSpace(withStack.stack), "\n")
+		} else {
+			exception["type"] = fmt.Sprintf("%T", cause)
+		}
+		formatted.Extensions["exception"] = exception
+	}
+	return formatted
+}
+
+// formatErrors применяет formatError к ошибкам результата.
+func formatErrors(errs []gqlerrors.FormattedError, debugMode bool) []gqlerrors.FormattedError {
+	formatted := make([]gqlerrors.FormattedError, len(errs))
+	for i, err := range errs {
+		formatted[i] = formatError(err, debugMode)
+	}
+	return formatted
+}
+
+// requestError — отказ в выполнении запроса до его разбора.
+type requestError struct {
+	code    string
+	message string
+	status  int
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func (e *requestError) formatted() gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{Message: e.message, Extensions: map[string]interface{}{"code": e.code}}
+}
+
+func writeRequestError(w http.ResponseWriter, err *requestError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []gqlerrors.FormattedError{err.formatted()},
+	})
+}
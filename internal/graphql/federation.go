@@ -0,0 +1,174 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strconv"
+	"strings"
+)
+
+// Поддержка Apollo Federation: сервис выступает подграфом продуктов общего
+// графа. Роутер федерации получает SDL подграфа через _service и загружает
+// продукты по ключу через _entities.
+
+// entityKeys — ключи сущностей федерации по имени типа.
+var entityKeys = map[string]string{
+	"Product": "id",
+}
+
+// federationTypes — служебные типы федерации; в SDL подграфа их не печатают,
+// их добавляет сам роутер.
+var federationTypes = map[string]bool{
+	"_Any": true, "_Entity": true, "_Service": true,
+}
+
+// anyType — представление сущности: объект с __typename и полями ключа.
+var anyType = graphql.NewScalar(graphql.ScalarConfig{
+	Name: "_Any",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: func(value ast.Value) interface{} {
+		return literalValue(value)
+	},
+})
+
+// literalValue преобразует литерал запроса в значение, как если бы оно
+// пришло в JSON-переменной.
+func literalValue(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = literalValue(field.Value)
+		}
+		return object
+	case *ast.ListValue:
+		list := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			list[i] = literalValue(item)
+		}
+		return list
+	case *ast.IntValue:
+		parsed, _ := strconv.ParseFloat(value.Value, 64)
+		return parsed
+	case *ast.FloatValue:
+		parsed, _ := strconv.ParseFloat(value.Value, 64)
+		return parsed
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.StringValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	}
+	return nil
+}
+
+var serviceType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "_Service",
+	Fields: graphql.Fields{
+		"sdl": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				return PrintSubgraphSchema(&params.Info.Schema), nil
+			},
+		},
+	},
+})
+
+var entityType = graphql.NewUnion(graphql.UnionConfig{
+	Name:  "_Entity",
+	Types: []*graphql.Object{productType},
+	ResolveType: func(params graphql.ResolveTypeParams) *graphql.Object {
+		return productType
+	},
+})
+
+// federationQueries — поля Query, которые федерация требует от подграфа.
+func (r *resolver) federationQueries() graphql.Fields {
+	return graphql.Fields{
+		"_service": &graphql.Field{
+			Type: graphql.NewNonNull(serviceType),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				return struct{}{}, nil
+			},
+		},
+		"_entities": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(entityType)),
+			Args: graphql.FieldConfigArgument{
+				"representations": &graphql.ArgumentConfig{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(anyType))),
+				},
+			},
+			Resolve: r.resolveEntities,
+		},
+	}
+}
+
+// resolveEntities загружает сущности по представлениям одним пакетом через
+// DataLoader. Ненайденные сущности возвращаются как null.
+func (r *resolver) resolveEntities(params graphql.ResolveParams) (interface{}, error) {
+	representations, _ := params.Args["representations"].([]interface{})
+	loads := make([]func() (interface{}, error), len(representations))
+	for i, representation := range representations {
+		fields, _ := representation.(map[string]interface{})
+		typename, _ := fields["__typename"].(string)
+		if typename != "Product" {
+			return nil, fmt.Errorf("unknown entity type %q", typename)
+		}
+		id, ok := entityID(fields["id"])
+		if !ok {
+			return nil, fmt.Errorf("representation %d has no valid Product id", i)
+		}
+		load := r.loadersFrom(params.Context).products.Load(params.Context, productKey{ID: id})
+		loads[i] = func() (interface{}, error) {
+			product, found, err := load()
+			if err != nil || !found {
+				return nil, err
+			}
+			return product, nil
+		}
+	}
+	return func() (interface{}, error) {
+		entities := make([]interface{}, len(loads))
+		for i, load := range loads {
+			entity, err := load()
+			if err != nil {
+				return nil, err
+			}
+			entities[i] = entity
+		}
+		return entities, nil
+	}, nil
+}
+
+// entityID принимает ключ и числом, и строкой: роутеры федерации передают
+// ключи так, как получили их от других подграфов.
+func entityID(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case float64:
+		return int(value), value == float64(int(value))
+	case int:
+		return value, true
+	case string:
+		id, err := strconv.Atoi(value)
+		return id, err == nil
+	}
+	return 0, false
+}
+
+// PrintSubgraphSchema возвращает SDL подграфа для _service: схему с
+// директивами @key, но без служебных типов и полей федерации.
+func PrintSubgraphSchema(schema *graphql.Schema) string {
+	return printSchema(schema, true)
+}
+
+// isFederationField сообщает, относится ли поле корневого типа к федерации.
+func isFederationField(name string) bool {
+	return strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "__")
+}
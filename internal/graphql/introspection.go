@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/visitor"
+	"net/http"
+)
+
+// CodeIntrospectionDisabled — запрос обращается к __schema или __type, а
+// интроспекция выключена.
+const CodeIntrospectionDisabled = "INTROSPECTION_DISABLED"
+
+var errIntrospectionDisabled = &requestError{
+	CodeIntrospectionDisabled,
+	"GraphQL introspection is disabled; fetch the schema from /api/graphql/schema.graphql",
+	http.StatusBadRequest,
+}
+
+// usesIntrospection сообщает, запрашивает ли документ __schema или __type, в
+// том числе внутри фрагментов. __typename интроспекцией не считается: без
+// него не работают клиентские кэши. Документ с ошибкой разбора пропускается
+// — его отвергнет сам graphql-go.
+func usesIntrospection(query string) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+	found := false
+	visitor.Visit(document, &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			"Field": {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					if field, ok := p.Node.(*ast.Field); ok && field.Name != nil {
+						if name := field.Name.Value; name == "__schema" || name == "__type" {
+							found = true
+							return visitor.ActionBreak, nil
+						}
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	}, nil)
+	return found
+}
+
+// withoutIntrospection отвергает запросы с интроспекцией до выполнения.
+// Схема по-прежнему доступна в виде SDL через NewSDLHandler.
+func withoutIntrospection(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := peekRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if usesIntrospection(opts.Query) {
+			writeRequestError(w, errIntrospectionDisabled)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,191 @@
+package graphql
+
+import (
+	"context"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"server/internal/models"
+	"server/internal/service"
+	"sync"
+)
+
+// Loader собирает ключи, запрошенные резолверами на одном уровне выполнения,
+// и загружает их одним вызовом batch. Резолвер возвращает thunk, а graphql-go
+// вызывает thunk'и только после обхода всех полей уровня, поэтому к первому
+// вызову в pending накапливаются ключи всех соседних полей. Результаты
+// кэшируются до конца запроса.
+type Loader[K comparable, V any] struct {
+	batch   func(ctx context.Context, keys []K) (map[K]V, error)
+	mu      sync.Mutex
+	pending []K
+	entries map[K]*loaderEntry[V]
+}
+
+type loaderEntry[V any] struct {
+	done  bool
+	value V
+	found bool
+	err   error
+}
+
+func NewLoader[K comparable, V any](batch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{batch: batch, entries: make(map[K]*loaderEntry[V])}
+}
+
+// Load ставит ключ в очередь и возвращает функцию, которая дождётся загрузки.
+// found == false означает, что batch не вернул значение для ключа.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (value V, found bool, err error) {
+	l.mu.Lock()
+	if _, ok := l.entries[key]; !ok {
+		l.entries[key] = &loaderEntry[V]{}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		entry := l.entries[key]
+		if !entry.done {
+			l.dispatch(ctx)
+		}
+		return entry.value, entry.found, entry.err
+	}
+}
+
+// dispatch загружает все ожидающие ключи; вызывается под мьютексом.
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		entry := l.entries[key]
+		entry.done = true
+		if err != nil {
+			entry.err = err
+			continue
+		}
+		entry.value, entry.found = values[key]
+	}
+}
+
+// productKey — ключ загрузки продукта: одни и те же ID в разных локалях
+// загружаются отдельно.
+type productKey struct {
+	ID   int
+	Lang string
+}
+
+// reviewsKey — ключ загрузки последних отзывов продукта с их лимитом.
+type reviewsKey struct {
+	ProductID int
+	Limit     int
+}
+
+// loaders — загрузчики одного GraphQL-запроса.
+type loaders struct {
+	products        *Loader[productKey, models.Product]
+	reviews         *Loader[reviewsKey, []models.Review]
+	reviewSummaries *Loader[int, models.ReviewSummary]
+	stock           *Loader[int, []models.StockLevel]
+	categorySizes   *Loader[string, int]
+}
+
+func newLoaders(products *service.ProductService) *loaders {
+	return &loaders{
+		products: NewLoader(func(ctx context.Context, keys []productKey) (map[productKey]models.Product, error) {
+			idsByLang := make(map[string][]int)
+			for _, key := range keys {
+				idsByLang[key.Lang] = append(idsByLang[key.Lang], key.ID)
+			}
+			result := make(map[productKey]models.Product, len(keys))
+			for lang, ids := range idsByLang {
+				found, err := products.GetMany(ctx, ids, service.LocaleCandidates(lang))
+				if err != nil {
+					return nil, err
+				}
+				for id, product := range found {
+					result[productKey{ID: id, Lang: lang}] = product
+				}
+			}
+			return result, nil
+		}),
+		reviews: NewLoader(func(ctx context.Context, keys []reviewsKey) (map[reviewsKey][]models.Review, error) {
+			idsByLimit := make(map[int][]int)
+			for _, key := range keys {
+				idsByLimit[key.Limit] = append(idsByLimit[key.Limit], key.ProductID)
+			}
+			result := make(map[reviewsKey][]models.Review, len(keys))
+			for limit, ids := range idsByLimit {
+				found, err := products.LatestReviews(ctx, ids, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, id := range ids {
+					result[reviewsKey{ProductID: id, Limit: limit}] = found[id]
+				}
+			}
+			return result, nil
+		}),
+		reviewSummaries: NewLoader(func(ctx context.Context, ids []int) (map[int]models.ReviewSummary, error) {
+			return products.ReviewSummaries(ctx, ids)
+		}),
+		stock: NewLoader(func(ctx context.Context, ids []int) (map[int][]models.StockLevel, error) {
+			return products.StockFor(ctx, ids)
+		}),
+		categorySizes: NewLoader(func(ctx context.Context, names []string) (map[string]int, error) {
+			return products.CategorySizes(ctx, names)
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+// loadersFrom возвращает загрузчики запроса. Вне обычного выполнения (например,
+// при выдаче событий подписки) создаются новые, без общего кэша.
+func (r *resolver) loadersFrom(ctx context.Context) *loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*loaders); ok {
+		return l
+	}
+	return newLoaders(r.products)
+}
+
+// loaderExtension создаёт свежие загрузчики для каждого запроса.
+type loaderExtension struct {
+	products *service.ProductService
+}
+
+func (e *loaderExtension) Name() string {
+	return "dataloader"
+}
+
+func (e *loaderExtension) Init(ctx context.Context, params *graphql.Params) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, loadersKey{}, newLoaders(e.products))
+}
+
+func (e *loaderExtension) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+func (e *loaderExtension) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+func (e *loaderExtension) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(*graphql.Result) {}
+}
+
+func (e *loaderExtension) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	return ctx, func(interface{}, error) {}
+}
+
+func (e *loaderExtension) HasResult() bool {
+	return false
+}
+
+func (e *loaderExtension) GetResult(context.Context) interface{} {
+	return nil
+}
@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/graphql-go/graphql"
+	"net/http"
+	"server/internal/jsonnaming"
+	"strings"
+)
+
+// withNaming переводит ключи data ответа и пути ошибок из camelCase схемы в
+// стиль codec, чтобы ответы GraphQL были названы так же, как ответы REST.
+// Псевдонимы из запроса переводятся вместе с именами полей. Ключи,
+// начинающиеся с "_", и всё под ними не меняются: интроспекция, __typename
+// и поля федерации (_entities, _service) нужны инструментам и шлюзу в виде
+// по схеме.
+func withNaming(codec *jsonnaming.Codec, next http.Handler) http.Handler {
+	if codec == nil || codec.Style() == jsonnaming.CamelCase {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		body := recorder.body.Bytes()
+		if renamed, err := renameResponse(codec, body); err == nil {
+			body = renamed
+		}
+		for name, values := range recorder.header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(body)
+	})
+}
+
+// renameResponse переименовывает ключи в JSON-ответе graphql-go. Числа
+// разбираются как json.Number, чтобы не терять точность.
+func renameResponse(codec *jsonnaming.Codec, body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err != nil {
+		return nil, err
+	}
+	if data, ok := response["data"]; ok {
+		response["data"] = renameKeys(codec, data)
+	}
+	if errs, ok := response["errors"].([]interface{}); ok {
+		for _, err := range errs {
+			if err, ok := err.(map[string]interface{}); ok {
+				if path, ok := err["path"].([]interface{}); ok {
+					renamePath(codec, path)
+				}
+			}
+		}
+	}
+	return json.MarshalIndent(response, "", "\t")
+}
+
+// renameResult переименовывает ключи результата подписки до отправки.
+func renameResult(codec *jsonnaming.Codec, result *graphql.Result) {
+	if codec == nil || codec.Style() == jsonnaming.CamelCase {
+		return
+	}
+	result.Data = renameKeys(codec, result.Data)
+	for _, err := range result.Errors {
+		renamePath(codec, err.Path)
+	}
+}
+
+func renameKeys(codec *jsonnaming.Codec, value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if strings.HasPrefix(key, "_") {
+				renamed[key] = item
+				continue
+			}
+			renamed[codec.CamelKey(key)] = renameKeys(codec, item)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range value {
+			value[i] = renameKeys(codec, item)
+		}
+	}
+	return value
+}
+
+// renamePath переименовывает ключи в пути ошибки; индексы списков и пути
+// под ключами с "_" остаются как есть.
+func renamePath(codec *jsonnaming.Codec, path []interface{}) {
+	for i, segment := range path {
+		key, ok := segment.(string)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, "_") {
+			return
+		}
+		path[i] = codec.CamelKey(key)
+	}
+}
@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"server/internal/jsonnaming"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamingRenamesResponseKeys(t *testing.T) {
+	pageInfo := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.Boolean},
+			"imageURL":    &graphql.Field{Type: graphql.String},
+			"failedField": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(graphql.ResolveParams) (interface{}, error) {
+					return nil, errors.New("boom")
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"pageInfo": &graphql.Field{
+					Type: pageInfo,
+					Resolve: func(graphql.ResolveParams) (interface{}, error) {
+						return map[string]interface{}{"hasNextPage": true, "imageURL": "a.png"}, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const query = `{"query":"{ pageInfo { hasNextPage imageURL failedField __typename } myAlias: pageInfo { hasNextPage } __schema { queryType { name } } }"}`
+
+	tests := []struct {
+		name      string
+		naming    *jsonnaming.Codec
+		wantData  string
+		wantError []interface{}
+	}{
+		{
+			name:      "schema names",
+			wantData:  `{"pageInfo":{"hasNextPage":true,"imageURL":"a.png","failedField":null,"__typename":"PageInfo"},"myAlias":{"hasNextPage":true},"__schema":{"queryType":{"name":"Query"}}}`,
+			wantError: []interface{}{"pageInfo", "failedField"},
+		},
+		{
+			name:      "camelCase",
+			naming:    jsonnaming.New(jsonnaming.CamelCase),
+			wantData:  `{"pageInfo":{"hasNextPage":true,"imageURL":"a.png","failedField":null,"__typename":"PageInfo"},"myAlias":{"hasNextPage":true},"__schema":{"queryType":{"name":"Query"}}}`,
+			wantError: []interface{}{"pageInfo", "failedField"},
+		},
+		{
+			name:      "snake_case",
+			naming:    jsonnaming.New(jsonnaming.SnakeCase),
+			wantData:  `{"page_info":{"has_next_page":true,"image_url":"a.png","failed_field":null,"__typename":"PageInfo"},"my_alias":{"has_next_page":true},"__schema":{"queryType":{"name":"Query"}}}`,
+			wantError: []interface{}{"page_info", "failed_field"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/graphql", NewHandler(&schema, HandlerOptions{Timeout: time.Second, Naming: tt.naming}))
+			req := httptest.NewRequest(fiber.MethodPost, "/graphql", strings.NewReader(query))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			var got struct {
+				Data   map[string]interface{} `json:"data"`
+				Errors []struct {
+					Path []interface{} `json:"path"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode %s: %v", body, err)
+			}
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.wantData), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Data, want) {
+				t.Fatalf("data = %v, want %v", got.Data, want)
+			}
+			if len(got.Errors) != 1 || !reflect.DeepEqual(got.Errors[0].Path, tt.wantError) {
+				t.Fatalf("errors = %+v, want path %v", got.Errors, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package graphql
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Коды ошибок Automatic Persisted Queries; первые два совпадают с Apollo,
+// чтобы клиенты без доработок повторяли запрос с полным текстом.
+const (
+	CodePersistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	CodePersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+	CodePersistedQueryNotAllowed   = "PERSISTED_QUERY_NOT_ALLOWED"
+)
+
+// PersistedQueries хранит тексты запросов по SHA-256 для Automatic Persisted
+// Queries: клиент отправляет хеш, а полный текст — только если сервер его ещё
+// не знает. Автоматически зарегистрированные запросы вытесняются по LRU;
+// запросы из манифеста хранятся всегда. В режиме allowlist выполняются
+// только запросы из манифеста.
+type PersistedQueries struct {
+	mu        sync.Mutex
+	capacity  int
+	cache     map[string]*list.Element
+	order     *list.List
+	manifest  map[string]string
+	allowlist bool
+}
+
+type persistedEntry struct {
+	hash  string
+	query string
+}
+
+// PersistedOptions настраивает хранилище запросов.
+type PersistedOptions struct {
+	// CacheSize — сколько автоматически зарегистрированных запросов хранить.
+	CacheSize int
+	// ManifestPath — JSON-файл с заранее известными запросами: либо объект
+	// {"<sha256>": "<query>"}, либо манифест Apollo с массивом operations.
+	ManifestPath string
+	// AllowlistOnly запрещает запросы, которых нет в манифесте, и регистрацию новых.
+	AllowlistOnly bool
+}
+
+// NewPersistedQueries создаёт хранилище и загружает манифест, если он задан.
+func NewPersistedQueries(opts PersistedOptions) (*PersistedQueries, error) {
+	p := &PersistedQueries{
+		capacity:  opts.CacheSize,
+		cache:     make(map[string]*list.Element),
+		order:     list.New(),
+		manifest:  make(map[string]string),
+		allowlist: opts.AllowlistOnly,
+	}
+	if opts.AllowlistOnly && opts.ManifestPath == "" {
+		return nil, fmt.Errorf("persisted query allowlist requires a manifest")
+	}
+	if opts.ManifestPath != "" {
+		if err := p.loadManifest(opts.ManifestPath); err != nil {
+			return nil, fmt.Errorf("persisted query manifest: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *PersistedQueries) loadManifest(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var apollo struct {
+		Operations []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+	queries := make(map[string]string)
+	if err := json.Unmarshal(data, &apollo); err == nil && apollo.Operations != nil {
+		for _, op := range apollo.Operations {
+			queries[op.ID] = op.Body
+		}
+	} else if err := json.Unmarshal(data, &queries); err != nil {
+		return err
+	}
+	for hash, query := range queries {
+		if queryHash(query) != strings.ToLower(hash) {
+			return fmt.Errorf("hash %s does not match its query", hash)
+		}
+		p.manifest[strings.ToLower(hash)] = query
+	}
+	return nil
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// persistedQueryExtension — extensions.persistedQuery запроса по протоколу Apollo.
+type persistedQueryExtension struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// resolve возвращает текст запроса, который нужно выполнить: по хешу из
+// хранилища или переданный клиентом, регистрируя его под этим хешем.
+func (p *PersistedQueries) resolve(query string, ext *persistedQueryExtension) (string, *requestError) {
+	if ext == nil {
+		if p.allowlist && !p.inManifest(queryHash(query)) {
+			return "", &requestError{CodePersistedQueryNotAllowed, "Only persisted queries are allowed", http.StatusBadRequest}
+		}
+		return query, nil
+	}
+	if ext.Version != 1 {
+		return "", &requestError{CodePersistedQueryNotSupported, "Unsupported persisted query version", http.StatusBadRequest}
+	}
+	hash := strings.ToLower(ext.SHA256Hash)
+
+	if query == "" {
+		if stored, ok := p.lookup(hash); ok {
+			return stored, nil
+		}
+		if p.allowlist {
+			return "", &requestError{CodePersistedQueryNotAllowed, "Only persisted queries are allowed", http.StatusBadRequest}
+		}
+		return "", &requestError{CodePersistedQueryNotFound, "PersistedQueryNotFound", http.StatusOK}
+	}
+
+	if queryHash(query) != hash {
+		return "", &requestError{CodeValidationFailed, "provided sha does not match query", http.StatusBadRequest}
+	}
+	if p.allowlist {
+		if !p.inManifest(hash) {
+			return "", &requestError{CodePersistedQueryNotAllowed, "Only persisted queries are allowed", http.StatusBadRequest}
+		}
+		return query, nil
+	}
+	p.store(hash, query)
+	return query, nil
+}
+
+// inManifest не берёт блокировку: манифест не меняется после загрузки.
+func (p *PersistedQueries) inManifest(hash string) bool {
+	_, ok := p.manifest[hash]
+	return ok
+}
+
+func (p *PersistedQueries) lookup(hash string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if query, ok := p.manifest[hash]; ok {
+		return query, true
+	}
+	if el, ok := p.cache[hash]; ok {
+		p.order.MoveToFront(el)
+		return el.Value.(*persistedEntry).query, true
+	}
+	return "", false
+}
+
+func (p *PersistedQueries) store(hash, query string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.manifest[hash]; ok || p.capacity <= 0 {
+		return
+	}
+	if el, ok := p.cache[hash]; ok {
+		p.order.MoveToFront(el)
+		return
+	}
+	p.cache[hash] = p.order.PushFront(&persistedEntry{hash: hash, query: query})
+	for p.order.Len() > p.capacity {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.cache, oldest.Value.(*persistedEntry).hash)
+	}
+}
+
+// withPersistedQueries подставляет текст запроса по хешу из
+// extensions.persistedQuery до того, как запрос разберёт graphql-go.
+// Поддерживаются GET и POST с JSON — именно так APQ отправляют клиенты.
+func withPersistedQueries(p *PersistedQueries, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			values := r.URL.Query()
+			var ext *persistedQueryExtension
+			if raw := values.Get("extensions"); raw != "" {
+				var err error
+				if ext, err = decodePersistedExtension([]byte(raw)); err != nil {
+					writeRequestError(w, &requestError{CodeValidationFailed, "Invalid extensions parameter", http.StatusBadRequest})
+					return
+				}
+			}
+			query, pqErr := p.resolve(values.Get("query"), ext)
+			if pqErr != nil {
+				writeRequestError(w, pqErr)
+				return
+			}
+			values.Set("query", query)
+			r.URL.RawQuery = values.Encode()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if contentType != "application/json" {
+			// Другие форматы тела APQ не поддерживают, но в режиме allowlist
+			// через них нельзя обойти проверку.
+			if p.allowlist {
+				writeRequestError(w, &requestError{CodePersistedQueryNotAllowed, "Only persisted queries are allowed", http.StatusBadRequest})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			// Некорректное тело пусть отвергнет сам обработчик GraphQL.
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		var query string
+		if raw, ok := fields["query"]; ok {
+			json.Unmarshal(raw, &query)
+		}
+		var ext *persistedQueryExtension
+		if raw, ok := fields["extensions"]; ok {
+			if ext, err = decodePersistedExtension(raw); err != nil {
+				writeRequestError(w, &requestError{CodeValidationFailed, "Invalid extensions field", http.StatusBadRequest})
+				return
+			}
+		}
+		resolved, pqErr := p.resolve(query, ext)
+		if pqErr != nil {
+			writeRequestError(w, pqErr)
+			return
+		}
+		if resolved != query {
+			fields["query"], _ = json.Marshal(resolved)
+			body, _ = json.Marshal(fields)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodePersistedExtension извлекает persistedQuery из extensions; nil — если
+// клиент его не передал.
+func decodePersistedExtension(raw []byte) (*persistedQueryExtension, error) {
+	var extensions struct {
+		PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+	}
+	if err := json.Unmarshal(raw, &extensions); err != nil {
+		return nil, err
+	}
+	return extensions.PersistedQuery, nil
+}
@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// playgroundPage — GraphiQL с клиентом graphql-ws для подписок. Ресурсы
+// загружаются из CDN, поэтому страница предназначена только для разработки.
+const playgroundPage = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>GraphiQL</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css" />
+</head>
+<body>
+  <div id="graphiql">Loading…</div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphql-ws@5/umd/graphql-ws.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script>
+    const config = __CONFIG__;
+    const wsScheme = location.protocol === 'https:' ? 'wss://' : 'ws://';
+    const fetcher = GraphiQL.createFetcher({
+      url: config.endpoint,
+      wsClient: graphqlWs.createClient({ url: wsScheme + location.host + config.subscriptionEndpoint }),
+    });
+    ReactDOM.createRoot(document.getElementById('graphiql'))
+      .render(React.createElement(GraphiQL, { fetcher, defaultEditorToolsVisibility: true }));
+  </script>
+</body>
+</html>
+`
+
+// NewPlaygroundHandler отдаёт страницу GraphiQL, работающую с endpoint и
+// subscriptionEndpoint (graphql-transport-ws).
+func NewPlaygroundHandler(endpoint, subscriptionEndpoint string) fiber.Handler {
+	config, _ := json.Marshal(map[string]string{"endpoint": endpoint, "subscriptionEndpoint": subscriptionEndpoint})
+	page := strings.Replace(playgroundPage, "__CONFIG__", string(config), 1)
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(page)
+	}
+}
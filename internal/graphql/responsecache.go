@@ -0,0 +1,198 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/printer"
+	"net/http"
+	"server/internal/auth"
+	"sync"
+	"time"
+)
+
+// responseCacheHeader сообщает клиенту, взят ли ответ из кэша: HIT или MISS.
+const responseCacheHeader = "X-GraphQL-Cache"
+
+// ResponseCacheOptions настраивает кэш ответов.
+type ResponseCacheOptions struct {
+	// TTL — сколько ответ остаётся в кэше, если каталог не менялся.
+	TTL time.Duration
+	// MaxEntries ограничивает число закэшированных ответов.
+	MaxEntries int
+}
+
+// ResponseCache хранит готовые ответы анонимных запросов на чтение. Ключ —
+// нормализованный текст запроса, имя операции и переменные, поэтому запросы,
+// отличающиеся только форматированием, попадают в одну запись. Кэш целиком
+// сбрасывается через Invalidate при любом изменении каталога и после каждой
+// мутации.
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+	// generation растёт при каждом сбросе; ответ, начатый до сброса, не
+	// сохраняется, чтобы не вернуть в кэш устаревшие данные.
+	generation uint64
+}
+
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+func NewResponseCache(opts ResponseCacheOptions) *ResponseCache {
+	return &ResponseCache{
+		ttl:        opts.TTL,
+		maxEntries: opts.MaxEntries,
+		entries:    make(map[string]cachedResponse),
+	}
+}
+
+// Invalidate сбрасывает все закэшированные ответы.
+func (c *ResponseCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cachedResponse)
+	c.generation++
+}
+
+func (c *ResponseCache) get(key string) (cachedResponse, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	return entry, c.generation, ok
+}
+
+func (c *ResponseCache) set(key string, generation uint64, entry cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if len(c.entries) >= c.maxEntries {
+		now := time.Now()
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		// Все записи свежие: уступаем место произвольной.
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	entry.expiresAt = time.Now().Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// cacheKey возвращает ключ запроса и признак мутации. Пустой ключ — запрос
+// кэшировать нельзя: это не запрос на чтение или его не удалось разобрать.
+func cacheKey(query, operationName string, variables map[string]interface{}) (string, bool) {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return "", false
+	}
+	operation := findOperation(document, operationName)
+	if operation == nil {
+		return "", false
+	}
+	if operation.Operation != ast.OperationTypeQuery {
+		return "", operation.Operation == ast.OperationTypeMutation
+	}
+	normalized, ok := printer.Print(document).(string)
+	if !ok {
+		return "", false
+	}
+	// encoding/json сортирует ключи объектов, поэтому порядок переменных в
+	// запросе на ключ не влияет.
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return "", false
+	}
+	return normalized + "\x00" + operationName + "\x00" + string(vars), false
+}
+
+// withResponseCache отдаёт анонимным запросам на чтение закэшированный ответ
+// и сбрасывает кэш после мутаций. Запросы администраторов и трассируемые
+// запросы выполняются всегда.
+func withResponseCache(cache *ResponseCache, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := peekRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key, mutation := cacheKey(opts.Query, opts.OperationName, opts.Variables)
+		if mutation {
+			// Изменения через сервис продуктов и так сбрасывают кэш, но
+			// мутация могла затронуть и то, о чём сервис не сообщает.
+			defer cache.Invalidate()
+		}
+		_, admin := auth.FromContext(r.Context())
+		if key == "" || admin || isTruthy(r.Header.Get(tracingHeader)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		entry, generation, ok := cache.get(key)
+		if ok {
+			w.Header().Set("Content-Type", entry.contentType)
+			w.Header().Set(responseCacheHeader, "HIT")
+			w.WriteHeader(http.StatusOK)
+			w.Write(entry.body)
+			return
+		}
+
+		recorder := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		for name, values := range recorder.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(responseCacheHeader, "MISS")
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+
+		// Ответы с ошибками (таймаут, недоступная база) не кэшируются.
+		var result struct {
+			Errors json.RawMessage `json:"errors"`
+		}
+		if recorder.status != http.StatusOK || json.Unmarshal(recorder.body.Bytes(), &result) != nil || result.Errors != nil {
+			return
+		}
+		cache.set(key, generation, cachedResponse{
+			contentType: recorder.header.Get("Content-Type"),
+			body:        recorder.body.Bytes(),
+		})
+	})
+}
+
+// responseRecorder собирает ответ обработчика, чтобы его можно было
+// сохранить в кэш.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
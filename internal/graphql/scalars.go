@@ -0,0 +1,108 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// decimalType — денежные суммы. Значение передаётся строкой ("19.99"), чтобы
+// клиенты не округляли его через двоичный float. Сервис хранит цены как
+// float64 поверх DECIMAL(10, 2): кратчайшее десятичное представление такого
+// числа совпадает с записанным в базе, так что строка точна.
+var decimalType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Decimal",
+	Description: "Exact decimal number serialized as a string, e.g. \"19.99\".",
+	Serialize:   serializeDecimal,
+	ParseValue:  parseDecimal,
+	ParseLiteral: func(value ast.Value) interface{} {
+		// Числовые литералы в тексте запроса тоже точны: разбираем их запись.
+		switch value := value.(type) {
+		case *ast.StringValue:
+			return parseDecimal(value.Value)
+		case *ast.IntValue:
+			return parseDecimal(value.Value)
+		case *ast.FloatValue:
+			return parseDecimal(value.Value)
+		}
+		return nil
+	},
+})
+
+func serializeDecimal(value interface{}) interface{} {
+	switch value := value.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case *float64:
+		if value == nil {
+			return nil
+		}
+		return serializeDecimal(*value)
+	case int:
+		return strconv.Itoa(value)
+	case string:
+		if decimalPattern.MatchString(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// parseDecimal принимает только строки: число из JSON-переменных уже прошло
+// через float и могло потерять точность. nil graphql-go превращает в ошибку
+// "invalid value".
+func parseDecimal(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || !decimalPattern.MatchString(s) {
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// dateTimeType — момент времени в RFC 3339, всегда в UTC, чтобы клиенты не
+// зависели от часового пояса сервера и базы.
+var dateTimeType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "DateTime",
+	Description: "Point in time serialized as an RFC 3339 string in UTC, e.g. \"2024-05-01T12:00:00Z\".",
+	Serialize:   serializeDateTime,
+	ParseValue:  parseDateTime,
+	ParseLiteral: func(value ast.Value) interface{} {
+		if value, ok := value.(*ast.StringValue); ok {
+			return parseDateTime(value.Value)
+		}
+		return nil
+	},
+})
+
+func serializeDateTime(value interface{}) interface{} {
+	switch value := value.(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		if value == nil {
+			return nil
+		}
+		return serializeDateTime(*value)
+	}
+	return nil
+}
+
+func parseDateTime(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
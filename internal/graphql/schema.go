@@ -0,0 +1,357 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"github.com/gofiber/adaptor/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/handler"
+	"io"
+	"net/http"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/jsonnaming"
+	"server/internal/models"
+	"server/internal/service"
+	"time"
+)
+
+// resolver связывает поля схемы с сервисным слоем.
+type resolver struct {
+	products    *service.ProductService
+	attachments *service.AttachmentService
+}
+
+// NewSchema собирает GraphQL-схему поверх сервисов продуктов и вложений;
+// подписки получают изменения из шины событий.
+func NewSchema(products *service.ProductService, attachments *service.AttachmentService, bus *events.Bus) (graphql.Schema, error) {
+	r := &resolver{products: products, attachments: attachments}
+	r.addRelations()
+
+	rootQuery := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: withErrorStacks(mergeFields(graphql.Fields{
+			"products": &graphql.Field{
+				Type:        graphql.NewList(productType),
+				Description: "Products of a page. With category, unspecified limit and sort come from the category listing settings.",
+				Args: withFilterArgs(graphql.FieldConfigArgument{
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+					"lang":   &graphql.ArgumentConfig{Type: graphql.String},
+					"sort":   &graphql.ArgumentConfig{Type: productSortType},
+				}),
+				Resolve: r.resolveProducts,
+			},
+			"productsConnection": &graphql.Field{
+				Type:        graphql.NewNonNull(productConnectionType),
+				Description: "Cursor-paginated products ordered by id (Relay connection spec).",
+				Args: withFilterArgs(graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+					"last":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"before": &graphql.ArgumentConfig{Type: graphql.String},
+					"lang":   &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: r.resolveProductsConnection,
+			},
+			"product": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"id":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"lang": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.resolveProduct,
+			},
+		}, r.domainQueries(), r.federationQueries())),
+	})
+
+	rootMutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: withErrorStacks(withMutationPermissions(mergeFields(graphql.Fields{
+			"createProduct": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(productInputType)},
+				},
+				Resolve: r.createProduct,
+			},
+			"importProducts": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productImportResultType))),
+				Description: "Creates products in one transaction. Invalid or duplicate items are skipped and reported in their result; the rest are committed.",
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productInputType)))},
+				},
+				Resolve: r.importProducts,
+			},
+			"updateProduct": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(productInputType)},
+				},
+				Resolve: r.updateProduct,
+			},
+			"deleteProduct": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.deleteProduct,
+			},
+			"uploadProductImage": &graphql.Field{
+				Type:        graphql.NewNonNull(attachmentType),
+				Description: "Attaches an image to the product. The file is sent as a multipart request part (GraphQL multipart request spec).",
+				Args: graphql.FieldConfigArgument{
+					"productId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"file":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(uploadType)},
+				},
+				Resolve: r.uploadProductImage,
+			},
+		}, r.domainMutations()))),
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:        rootQuery,
+		Mutation:     rootMutation,
+		Subscription: newSubscriptionType(bus),
+	})
+	if err != nil {
+		return schema, err
+	}
+	schema.AddExtensions(&loaderExtension{products: products})
+	return schema, nil
+}
+
+// mergeFields объединяет наборы полей корневого типа.
+func mergeFields(sets ...graphql.Fields) graphql.Fields {
+	merged := graphql.Fields{}
+	for _, fields := range sets {
+		for name, field := range fields {
+			merged[name] = field
+		}
+	}
+	return merged
+}
+
+// HandlerOptions настраивает HTTP-эндпоинт GraphQL.
+type HandlerOptions struct {
+	// Timeout ограничивает контекст запроса, чтобы долгие запросы к базе
+	// отменялись, а не выполнялись до конца.
+	Timeout time.Duration
+	// Debug добавляет в ошибки исходный текст и стек; только для разработки.
+	Debug bool
+	// Persisted включает Automatic Persisted Queries; nil — запросы только
+	// полным текстом.
+	Persisted *PersistedQueries
+	// Auth проверяет Bearer-токены; nil — все запросы анонимны, и поля
+	// только для администраторов недоступны.
+	Auth auth.Authenticator
+	// APIKeys проверяет ключи API из заголовка X-API-Key у запросов без
+	// Bearer-токена; nil — ключи не принимаются. Только для HTTP.
+	APIKeys auth.Authenticator
+	// DisableIntrospection запрещает запросы __schema и __type; клиенты
+	// получают схему через NewSDLHandler.
+	DisableIntrospection bool
+	// Tracing — режим трассировки резолверов (TracingOff, TracingHeader,
+	// TracingAlways); пустая строка — выключено. Только для HTTP.
+	Tracing string
+	// Costs ограничивает стоимость запросов клиента; nil — без ограничения.
+	Costs *CostLimiter
+	// Responses кэширует ответы анонимных запросов на чтение; nil — без кэша.
+	// Попадание в кэш не расходует бюджет стоимости клиента.
+	Responses *ResponseCache
+	// Naming переводит ключи ответа из camelCase схемы в стиль кодека; nil —
+	// ключи по схеме и псевдонимам запроса.
+	Naming *jsonnaming.Codec
+}
+
+// NewHandler возвращает Fiber-обработчик GraphQL-эндпоинта. Контекст запроса
+// передаётся резолверам.
+func NewHandler(schema *graphql.Schema, opts HandlerOptions) fiber.Handler {
+	newHandler := func(schema *graphql.Schema) http.Handler {
+		return handler.New(&handler.Config{
+			Schema: schema,
+			Pretty: true,
+			FormatErrorFn: func(err error) gqlerrors.FormattedError {
+				return formatError(err, opts.Debug)
+			},
+		})
+	}
+	h := newHandler(schema)
+	if opts.Tracing != "" && opts.Tracing != TracingOff {
+		h = withTracing(opts.Tracing, h, newHandler(tracedSchema(schema)))
+	}
+	if opts.DisableIntrospection {
+		h = withoutIntrospection(h)
+	}
+	if opts.Costs != nil {
+		h = withCostLimit(opts.Costs, h)
+	}
+	if opts.Responses != nil {
+		h = withResponseCache(opts.Responses, h)
+	}
+	if opts.Persisted != nil {
+		h = withPersistedQueries(opts.Persisted, h)
+	}
+	h = withNaming(opts.Naming, h)
+	return adaptor.HTTPHandler(withTimeout(opts.Timeout, withClient(withAuth(opts.Auth, opts.APIKeys, withUploads(h)))))
+}
+
+// peekRequest разбирает запрос во всех форматах, которые понимает graphql-go,
+// и возвращает тело на место для настоящего обработчика.
+func peekRequest(r *http.Request) (*handler.RequestOptions, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	opts := handler.NewRequestOptions(r)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return opts, nil
+}
+
+func withTimeout(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (r *resolver) resolveProducts(params graphql.ResolveParams) (interface{}, error) {
+	limit, _ := params.Args["limit"].(int)
+	offset, _ := params.Args["offset"].(int)
+	lang, _ := params.Args["lang"].(string)
+	sort, _ := params.Args["sort"].(string)
+	return r.products.List(params.Context, service.ListParams{
+		Limit:   limit,
+		Offset:  offset,
+		Locales: service.LocaleCandidates(lang),
+		Filter:  filterFromArgs(params.Args),
+		Sort:    sort,
+	})
+}
+
+// withFilterArgs добавляет к аргументам поля аргументы фильтра продуктов.
+func withFilterArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args["search"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Full-text search over name, categories and description, with synonyms applied.",
+	}
+	args["nameContains"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Case-insensitive substring of the product name.",
+	}
+	args["minPrice"] = &graphql.ArgumentConfig{Type: decimalType}
+	args["maxPrice"] = &graphql.ArgumentConfig{Type: decimalType}
+	args["categories"] = &graphql.ArgumentConfig{
+		Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+		Description: "Matches products in at least one of the categories.",
+	}
+	args["category"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Matches products in the category.",
+	}
+	return args
+}
+
+func filterFromArgs(args map[string]interface{}) service.Filter {
+	var filter service.Filter
+	filter.Search, _ = args["search"].(string)
+	filter.NameContains, _ = args["nameContains"].(string)
+	if minPrice, ok := args["minPrice"].(float64); ok {
+		filter.MinPrice = &minPrice
+	}
+	if maxPrice, ok := args["maxPrice"].(float64); ok {
+		filter.MaxPrice = &maxPrice
+	}
+	filter.Category, _ = args["category"].(string)
+	if categories, ok := args["categories"].([]interface{}); ok {
+		for _, category := range categories {
+			if name, ok := category.(string); ok {
+				filter.Categories = append(filter.Categories, name)
+			}
+		}
+	}
+	return filter
+}
+
+// resolveProduct возвращает null без ошибки, если продукт не найден. Продукты,
+// запрошенные в одной операции несколькими полями, загружаются одним запросом.
+func (r *resolver) resolveProduct(params graphql.ResolveParams) (interface{}, error) {
+	id, _ := params.Args["id"].(int)
+	lang, _ := params.Args["lang"].(string)
+	load := r.loadersFrom(params.Context).products.Load(params.Context, productKey{ID: id, Lang: lang})
+	return func() (interface{}, error) {
+		product, found, err := load()
+		if err != nil || !found {
+			return nil, err
+		}
+		return product, nil
+	}, nil
+}
+
+func (r *resolver) createProduct(params graphql.ResolveParams) (interface{}, error) {
+	input, _ := params.Args["input"].(map[string]interface{})
+	if err := checkInputPolicy(params.Context, "Product", input); err != nil {
+		return nil, err
+	}
+	created, err := r.products.Create(params.Context, []models.Product{productFromInput(input)})
+	if err != nil {
+		return nil, err
+	}
+	return created[0], nil
+}
+
+func (r *resolver) importProducts(params graphql.ResolveParams) (interface{}, error) {
+	inputs, _ := params.Args["input"].([]interface{})
+	products := make([]models.Product, 0, len(inputs))
+	for _, input := range inputs {
+		fields, _ := input.(map[string]interface{})
+		if err := checkInputPolicy(params.Context, "Product", fields); err != nil {
+			return nil, err
+		}
+		products = append(products, productFromInput(fields))
+	}
+	return r.products.Import(params.Context, products)
+}
+
+func (r *resolver) updateProduct(params graphql.ResolveParams) (interface{}, error) {
+	id, _ := params.Args["id"].(int)
+	input, _ := params.Args["input"].(map[string]interface{})
+	if err := checkInputPolicy(params.Context, "Product", input); err != nil {
+		return nil, err
+	}
+	if err := r.products.Update(params.Context, id, productFromInput(input)); err != nil {
+		return nil, err
+	}
+	// Только что записанный продукт читаем из основной базы: реплика может отставать.
+	return r.products.Get(service.FromPrimary(params.Context), id, nil)
+}
+
+func (r *resolver) deleteProduct(params graphql.ResolveParams) (interface{}, error) {
+	id, _ := params.Args["id"].(int)
+	if err := r.products.Delete(params.Context, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *resolver) uploadProductImage(params graphql.ResolveParams) (interface{}, error) {
+	productID, _ := params.Args["productId"].(int)
+	header, err := uploadFrom(params.Context, params.Args["file"])
+	if err != nil {
+		return nil, err
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return r.attachments.UploadImage(productID, header.Filename, header.Size, file)
+}
@@ -0,0 +1,223 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"sort"
+	"strings"
+)
+
+// builtinTypes не печатаются в SDL: они определены спецификацией GraphQL.
+var builtinTypes = map[string]bool{
+	"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true,
+}
+
+// PrintSchema возвращает SDL схемы. Типы и поля упорядочены по имени, чтобы
+// вывод (и его хеш) не зависел от порядка обхода map.
+func PrintSchema(schema *graphql.Schema) string {
+	return printSchema(schema, false)
+}
+
+// printSchema печатает SDL; subgraph — вариант для федерации (см.
+// PrintSubgraphSchema).
+func printSchema(schema *graphql.Schema, subgraph bool) string {
+	typeMap := schema.TypeMap()
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		if strings.HasPrefix(name, "__") || builtinTypes[name] || (subgraph && federationTypes[name]) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var blocks []string
+	if definition := printSchemaDefinition(schema); definition != "" {
+		blocks = append(blocks, definition)
+	}
+	for _, name := range names {
+		blocks = append(blocks, printType(typeMap[name], subgraph))
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// printSchemaDefinition печатает блок schema только для нестандартных имён корневых типов.
+func printSchemaDefinition(schema *graphql.Schema) string {
+	roots := []struct {
+		operation string
+		object    *graphql.Object
+		standard  string
+	}{
+		{"query", schema.QueryType(), "Query"},
+		{"mutation", schema.MutationType(), "Mutation"},
+		{"subscription", schema.SubscriptionType(), "Subscription"},
+	}
+	custom := false
+	var lines []string
+	for _, root := range roots {
+		if root.object == nil {
+			continue
+		}
+		custom = custom || root.object.Name() != root.standard
+		lines = append(lines, fmt.Sprintf("  %s: %s", root.operation, root.object.Name()))
+	}
+	if !custom {
+		return ""
+	}
+	return "schema {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+func printType(t graphql.Type, subgraph bool) string {
+	var b strings.Builder
+	b.WriteString(printDescription(t.Description(), ""))
+	switch t := t.(type) {
+	case *graphql.Scalar:
+		fmt.Fprintf(&b, "scalar %s", t.Name())
+	case *graphql.Object:
+		fmt.Fprintf(&b, "type %s", t.Name())
+		if interfaces := t.Interfaces(); len(interfaces) > 0 {
+			names := make([]string, len(interfaces))
+			for i, iface := range interfaces {
+				names[i] = iface.Name()
+			}
+			b.WriteString(" implements " + strings.Join(names, " & "))
+		}
+		fields := t.Fields()
+		if subgraph {
+			if key, ok := entityKeys[t.Name()]; ok {
+				fmt.Fprintf(&b, " @key(fields: %q)", key)
+			}
+			fields = withoutFederationFields(fields)
+		}
+		b.WriteString(printFields(fields))
+	case *graphql.Interface:
+		fmt.Fprintf(&b, "interface %s", t.Name())
+		b.WriteString(printFields(t.Fields()))
+	case *graphql.Union:
+		names := make([]string, len(t.Types()))
+		for i, member := range t.Types() {
+			names[i] = member.Name()
+		}
+		fmt.Fprintf(&b, "union %s = %s", t.Name(), strings.Join(names, " | "))
+	case *graphql.Enum:
+		fmt.Fprintf(&b, "enum %s {\n", t.Name())
+		for _, value := range t.Values() {
+			b.WriteString(printDescription(value.Description, "  "))
+			b.WriteString("  " + value.Name + printDeprecated(value.DeprecationReason) + "\n")
+		}
+		b.WriteString("}")
+	case *graphql.InputObject:
+		fmt.Fprintf(&b, "input %s {\n", t.Name())
+		fields := t.Fields()
+		for _, name := range sortedKeys(fields) {
+			field := fields[name]
+			b.WriteString(printDescription(field.PrivateDescription, "  "))
+			fmt.Fprintf(&b, "  %s: %s%s\n", name, field.Type, printDefault(field.DefaultValue))
+		}
+		b.WriteString("}")
+	}
+	return b.String()
+}
+
+// withoutFederationFields убирает _service и _entities из полей Query.
+func withoutFederationFields(fields graphql.FieldDefinitionMap) graphql.FieldDefinitionMap {
+	filtered := make(graphql.FieldDefinitionMap, len(fields))
+	for name, field := range fields {
+		if !isFederationField(name) {
+			filtered[name] = field
+		}
+	}
+	return filtered
+}
+
+func printFields(fields graphql.FieldDefinitionMap) string {
+	var b strings.Builder
+	b.WriteString(" {\n")
+	for _, name := range sortedKeys(fields) {
+		field := fields[name]
+		b.WriteString(printDescription(field.Description, "  "))
+		fmt.Fprintf(&b, "  %s%s: %s%s\n", name, printArgs(field.Args), field.Type, printDeprecated(field.DeprecationReason))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func printArgs(args []*graphql.Argument) string {
+	if len(args) == 0 {
+		return ""
+	}
+	sorted := append([]*graphql.Argument{}, args...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PrivateName < sorted[j].PrivateName })
+
+	var b strings.Builder
+	b.WriteString("(\n")
+	for _, arg := range sorted {
+		b.WriteString(printDescription(arg.PrivateDescription, "    "))
+		fmt.Fprintf(&b, "    %s: %s%s\n", arg.PrivateName, arg.Type, printDefault(arg.DefaultValue))
+	}
+	b.WriteString("  )")
+	return b.String()
+}
+
+func printDefault(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return " = " + string(encoded)
+}
+
+func printDeprecated(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	encoded, _ := json.Marshal(reason)
+	return " @deprecated(reason: " + string(encoded) + ")"
+}
+
+func printDescription(description, indent string) string {
+	if description == "" {
+		return ""
+	}
+	if !strings.Contains(description, "\n") {
+		encoded, _ := json.Marshal(description)
+		return indent + string(encoded) + "\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(description, `"""`, `\"""`), "\n")
+	return indent + `"""` + "\n" + indent + strings.Join(lines, "\n"+indent) + "\n" + indent + `"""` + "\n"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NewSDLHandler отдаёт SDL схемы. Хеш содержимого передаётся в ETag и
+// X-Schema-Hash, чтобы CI потребителей мог сравнивать версии схемы.
+func NewSDLHandler(schema *graphql.Schema) fiber.Handler {
+	sdl := PrintSchema(schema)
+	sum := sha256.Sum256([]byte(sdl))
+	hash := hex.EncodeToString(sum[:])
+	etag := `"` + hash + `"`
+
+	return func(c *fiber.Ctx) error {
+		c.Set("ETag", etag)
+		c.Set("X-Schema-Hash", "sha256:"+hash)
+		if c.Get("If-None-Match") == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		c.Set("Content-Type", "text/plain; charset=utf-8")
+		return c.SendString(sdl)
+	}
+}
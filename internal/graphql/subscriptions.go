@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"server/internal/events"
+)
+
+// subscriptionBuffer — сколько событий может ждать отправки одному подписчику.
+const subscriptionBuffer = 64
+
+// newSubscriptionType описывает подписки на изменения продуктов. Данные
+// события становятся корнем выполнения, поэтому Resolve возвращает Source.
+func newSubscriptionType(bus *events.Bus) *graphql.Object {
+	field := func(outputType graphql.Output, eventType, description string) *graphql.Field {
+		return &graphql.Field{
+			Type:        outputType,
+			Description: description,
+			Subscribe:   subscribeTo(bus, eventType),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				return params.Source, nil
+			},
+		}
+	}
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"productCreated": field(productType, events.ProductCreated, "Emitted after a product is created."),
+			"productUpdated": field(productType, events.ProductUpdated, "Emitted after a product is updated."),
+			"productDeleted": field(graphql.Int, events.ProductDeleted, "Emitted with the id of a product moved to trash."),
+		},
+	})
+}
+
+// subscribeTo возвращает источник событий указанного типа; подписка на шине
+// снимается, когда клиент отменяет контекст операции.
+func subscribeTo(bus *events.Bus, eventType string) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		listener, cancel := bus.Listen(subscriptionBuffer)
+		out := make(chan interface{})
+		go func() {
+			defer close(out)
+			defer cancel()
+			for {
+				select {
+				case <-params.Context.Done():
+					return
+				case event := <-listener:
+					if event.Type != eventType {
+						continue
+					}
+					payload := event.Data
+					if ref, ok := payload.(events.ProductRef); ok {
+						payload = ref.ID
+					}
+					select {
+					case out <- payload:
+					case <-params.Context.Done():
+						return
+					}
+				}
+			}
+		}()
+		return out, nil
+	}
+}
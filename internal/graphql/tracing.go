@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Режимы трассировки резолверов.
+const (
+	// TracingOff — трассировка выключена.
+	TracingOff = "off"
+	// TracingHeader — трассировка запросов с заголовком X-GraphQL-Tracing: 1.
+	TracingHeader = "header"
+	// TracingAlways — трассировка каждого запроса.
+	TracingAlways = "always"
+)
+
+// tracingHeader включает трассировку отдельного запроса в режиме TracingHeader.
+const tracingHeader = "X-GraphQL-Tracing"
+
+// ParseTracingMode проверяет значение режима трассировки из конфигурации.
+func ParseTracingMode(mode string) (string, error) {
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case TracingOff, TracingHeader, TracingAlways:
+		return mode, nil
+	case "":
+		return TracingOff, nil
+	}
+	return "", fmt.Errorf("unknown GraphQL tracing mode %q", mode)
+}
+
+// tracedSchema возвращает копию схемы с расширением трассировки. Расширение
+// graphql-go не может решить по контексту, добавлять ли результат, поэтому
+// трассируемые запросы выполняются на отдельной копии схемы.
+func tracedSchema(schema *graphql.Schema) *graphql.Schema {
+	traced := *schema
+	traced.AddExtensions(&tracingExtension{})
+	return &traced
+}
+
+// withTracing направляет запрос в traced, если для него нужна трассировка.
+func withTracing(mode string, plain, traced http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mode == TracingAlways || (mode == TracingHeader && isTruthy(r.Header.Get(tracingHeader))) {
+			traced.ServeHTTP(w, r)
+			return
+		}
+		plain.ServeHTTP(w, r)
+	})
+}
+
+func isTruthy(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// tracingExtension записывает время разбора, валидации и каждого резолвера в
+// формате Apollo Tracing (extensions.tracing). Для полей, загружаемых пакетно
+// через DataLoader, время резолвера покрывает только постановку в очередь:
+// сам пакетный запрос входит лишь в общую длительность.
+type tracingExtension struct{}
+
+type traceKey struct{}
+
+type timing struct {
+	StartOffset int64 `json:"startOffset"`
+	Duration    int64 `json:"duration"`
+}
+
+type resolverTrace struct {
+	Path        []interface{} `json:"path"`
+	ParentType  string        `json:"parentType"`
+	FieldName   string        `json:"fieldName"`
+	ReturnType  string        `json:"returnType"`
+	StartOffset int64         `json:"startOffset"`
+	Duration    int64         `json:"duration"`
+}
+
+// trace — трассировка одного запроса; резолверы могут завершаться из разных
+// горутин, поэтому список защищён мьютексом.
+type trace struct {
+	start      time.Time
+	mu         sync.Mutex
+	parsing    timing
+	validation timing
+	resolvers  []resolverTrace
+}
+
+func (t *trace) since(at time.Time) int64 {
+	return at.Sub(t.start).Nanoseconds()
+}
+
+func (t *trace) phase(target *timing) func() {
+	started := time.Now()
+	return func() {
+		t.mu.Lock()
+		*target = timing{StartOffset: t.since(started), Duration: time.Since(started).Nanoseconds()}
+		t.mu.Unlock()
+	}
+}
+
+func traceFrom(ctx context.Context) *trace {
+	t, _ := ctx.Value(traceKey{}).(*trace)
+	return t
+}
+
+func (e *tracingExtension) Name() string {
+	return "tracing"
+}
+
+func (e *tracingExtension) Init(ctx context.Context, params *graphql.Params) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, traceKey{}, &trace{start: time.Now()})
+}
+
+func (e *tracingExtension) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	t := traceFrom(ctx)
+	done := t.phase(&t.parsing)
+	return ctx, func(error) { done() }
+}
+
+func (e *tracingExtension) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	t := traceFrom(ctx)
+	done := t.phase(&t.validation)
+	return ctx, func([]gqlerrors.FormattedError) { done() }
+}
+
+func (e *tracingExtension) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(*graphql.Result) {}
+}
+
+func (e *tracingExtension) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	t := traceFrom(ctx)
+	started := time.Now()
+	return ctx, func(interface{}, error) {
+		resolver := resolverTrace{
+			Path:        info.Path.AsArray(),
+			ParentType:  info.ParentType.Name(),
+			FieldName:   info.FieldName,
+			ReturnType:  info.ReturnType.String(),
+			StartOffset: t.since(started),
+			Duration:    time.Since(started).Nanoseconds(),
+		}
+		t.mu.Lock()
+		t.resolvers = append(t.resolvers, resolver)
+		t.mu.Unlock()
+	}
+}
+
+func (e *tracingExtension) HasResult() bool {
+	return true
+}
+
+func (e *tracingExtension) GetResult(ctx context.Context) interface{} {
+	t := traceFrom(ctx)
+	if t == nil {
+		return nil
+	}
+	end := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return map[string]interface{}{
+		"version":    1,
+		"startTime":  t.start.UTC().Format(time.RFC3339Nano),
+		"endTime":    end.UTC().Format(time.RFC3339Nano),
+		"duration":   t.since(end),
+		"parsing":    t.parsing,
+		"validation": t.validation,
+		"execution":  map[string]interface{}{"resolvers": t.resolvers},
+	}
+}
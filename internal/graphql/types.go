@@ -0,0 +1,273 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"server/internal/models"
+	"server/internal/service"
+)
+
+var priceScheduleType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "PriceSchedule",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.Int},
+			"price": &graphql.Field{Type: decimalType},
+			"startsAt": &graphql.Field{
+				Type: dateTimeType,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.PriceSchedule).StartsAt, nil
+				},
+			},
+			"endsAt": &graphql.Field{
+				Type: dateTimeType,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.PriceSchedule).EndsAt, nil
+				},
+			},
+		},
+	},
+)
+
+var imageVariantType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "ImageVariant",
+		Description: "Signed URL of a resized image preset.",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"url":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	},
+)
+
+var imageSourceType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "ImageSource",
+		Description: "One <source> of a <picture> element.",
+		Fields: graphql.Fields{
+			"type":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"srcset": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	},
+)
+
+var imageSetType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "ImageSet",
+		Description: "Image preset as AVIF/WebP sources with 1x/2x densities and a fallback in the original format.",
+		Fields: graphql.Fields{
+			"name":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"width":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"height":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"sources": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(imageSourceType)))},
+			"src":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"srcset":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	},
+)
+
+// namedImageSet — ImageSet с именем пресета для списка в GraphQL; поля
+// повторены, так как резолвер по умолчанию не видит встроенные структуры.
+type namedImageSet struct {
+	Name    string               `json:"name"`
+	Width   int                  `json:"width"`
+	Height  int                  `json:"height"`
+	Sources []models.ImageSource `json:"sources"`
+	Src     string               `json:"src"`
+	SrcSet  string               `json:"srcset"`
+}
+
+type imageVariant struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+var attachmentType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Attachment",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.Int},
+			"size": &graphql.Field{Type: graphql.Float},
+			"url":  &graphql.Field{Type: graphql.String},
+			"fileName": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.Attachment).FileName, nil
+				},
+			},
+			"contentType": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.Attachment).ContentType, nil
+				},
+			},
+			"createdAt": &graphql.Field{
+				Type: dateTimeType,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.Attachment).CreatedAt, nil
+				},
+			},
+			"images": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(imageVariantType)),
+				Description: "Preset image variants; null for non-image attachments or when image processing is off.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					images := params.Source.(models.Attachment).Images
+					if images == nil {
+						return nil, nil
+					}
+					variants := make([]imageVariant, 0, len(images))
+					for _, name := range sortedKeys(images) {
+						variants = append(variants, imageVariant{Name: name, URL: images[name]})
+					}
+					return variants, nil
+				},
+			},
+			"imageSets": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(imageSetType)),
+				Description: "Preset image variants for responsive <picture> markup.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					sets := params.Source.(models.Attachment).ImageSets
+					if sets == nil {
+						return nil, nil
+					}
+					named := make([]namedImageSet, 0, len(sets))
+					for _, name := range sortedKeys(sets) {
+						set := sets[name]
+						named = append(named, namedImageSet{
+							Name: name, Width: set.Width, Height: set.Height,
+							Sources: set.Sources, Src: set.Src, SrcSet: set.SrcSet,
+						})
+					}
+					return named, nil
+				},
+			},
+		},
+	},
+)
+
+var productInputType = graphql.NewInputObject(
+	graphql.InputObjectConfig{
+		Name: "ProductInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"price":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(decimalType)},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"categories":  &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"sku":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"barcode":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"costPrice":   &graphql.InputObjectFieldConfig{Type: decimalType, Description: "Purchase cost of the product. Requires an admin token."},
+		},
+	},
+)
+
+// productFromInput преобразует аргумент ProductInput в модель продукта.
+func productFromInput(input map[string]interface{}) models.Product {
+	product := models.Product{}
+	product.Name, _ = input["name"].(string)
+	product.Price, _ = input["price"].(float64)
+	product.Description, _ = input["description"].(string)
+	product.SKU, _ = input["sku"].(string)
+	product.Barcode, _ = input["barcode"].(string)
+	if costPrice, ok := input["costPrice"].(float64); ok {
+		product.CostPrice = &costPrice
+	}
+	if categories, ok := input["categories"].([]interface{}); ok {
+		for _, category := range categories {
+			if name, ok := category.(string); ok {
+				product.Categories = append(product.Categories, name)
+			}
+		}
+	}
+	return product
+}
+
+var productSortType = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "ProductSort",
+	Description: "Order of a product list.",
+	Values: graphql.EnumValueConfigMap{
+		"RELEVANCE":  &graphql.EnumValueConfig{Value: service.SortRelevance, Description: "Search relevance, or id without a search."},
+		"PRICE_ASC":  &graphql.EnumValueConfig{Value: service.SortPriceAsc},
+		"PRICE_DESC": &graphql.EnumValueConfig{Value: service.SortPriceDesc},
+		"NAME":       &graphql.EnumValueConfig{Value: service.SortName},
+		"NEWEST":     &graphql.EnumValueConfig{Value: service.SortNewest, Description: "Most recently added first."},
+	},
+})
+
+var productType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Product",
+		Fields: withFieldPolicy("Product", graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.Int},
+			"name":        &graphql.Field{Type: graphql.String},
+			"price":       &graphql.Field{Type: decimalType},
+			"description": &graphql.Field{Type: graphql.String},
+			"categories":  &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"sku":         &graphql.Field{Type: graphql.String},
+			"barcode":     &graphql.Field{Type: graphql.String},
+			"locale":      &graphql.Field{Type: graphql.String},
+			"costPrice": &graphql.Field{
+				Type:        decimalType,
+				Description: "Purchase cost of the product. Requires an admin token.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if costPrice := params.Source.(models.Product).CostPrice; costPrice != nil {
+						return *costPrice, nil
+					}
+					return nil, nil
+				},
+			},
+			"margin": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "Share of the price left after the purchase cost, (price - costPrice) / price. Requires an admin token.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if margin := params.Source.(models.Product).Margin; margin != nil {
+						return *margin, nil
+					}
+					return nil, nil
+				},
+			},
+			"upcomingPrices": &graphql.Field{
+				Type: graphql.NewList(priceScheduleType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(models.Product).UpcomingPrices, nil
+				},
+			},
+			"attachments": &graphql.Field{Type: graphql.NewList(attachmentType)},
+			"badges": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+				Description: "Badges the category listing shows for the product, e.g. featured or in_stock.",
+			},
+		}),
+	},
+)
+
+var productImportResultType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "ProductImportResult",
+		Description: "Outcome of one importProducts item: the created product or the reason it was skipped.",
+		Fields: graphql.Fields{
+			"index": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Position of the item in the input list.",
+			},
+			"id": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if product := params.Source.(models.ProductImportResult).Product; product != nil {
+						return product.ID, nil
+					}
+					return nil, nil
+				},
+			},
+			"product": &graphql.Field{
+				Type: productType,
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					// Поля Product ожидают значение, а не указатель.
+					if product := params.Source.(models.ProductImportResult).Product; product != nil {
+						return *product, nil
+					}
+					return nil, nil
+				},
+			},
+			"error": &graphql.Field{Type: graphql.String},
+		},
+	},
+)
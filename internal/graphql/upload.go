@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"server/internal/service"
+	"strconv"
+	"strings"
+)
+
+// Загрузка файлов по GraphQL multipart request spec
+// (github.com/jaydenseric/graphql-multipart-request-spec): запрос
+// multipart/form-data содержит поле operations с обычным JSON-запросом, поле
+// map, связывающее части с файлами с путями переменных, и сами файлы.
+
+const (
+	// uploadMemory — сколько данных multipart-запроса держать в памяти;
+	// остальное graphql-go не видит, а net/http сбрасывает во временные файлы.
+	uploadMemory = 10 << 20
+	// maxUploads ограничивает число файлов в одном запросе.
+	maxUploads = 10
+)
+
+// uploadRef — значение скаляра Upload: имя части multipart-запроса с файлом.
+// Сам файл резолвер берёт из контекста через uploadFrom.
+type uploadRef string
+
+var uploadType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Upload",
+	Description: "File sent as a part of a multipart request (GraphQL multipart request spec).",
+	Serialize: func(value interface{}) interface{} {
+		return nil
+	},
+	ParseValue: func(value interface{}) interface{} {
+		if ref, ok := value.(string); ok {
+			return uploadRef(ref)
+		}
+		return nil
+	},
+	ParseLiteral: func(value ast.Value) interface{} {
+		// Файл нельзя записать литералом: он приходит только через переменные.
+		return nil
+	},
+})
+
+type uploadsKey struct{}
+
+// uploadFrom возвращает файл, на который ссылается аргумент типа Upload.
+func uploadFrom(ctx context.Context, value interface{}) (*multipart.FileHeader, error) {
+	ref, ok := value.(uploadRef)
+	files, _ := ctx.Value(uploadsKey{}).(map[string]*multipart.FileHeader)
+	if !ok || files[string(ref)] == nil {
+		return nil, &service.ValidationError{Message: "file must be sent as a multipart request part"}
+	}
+	return files[string(ref)], nil
+}
+
+// withUploads превращает multipart-запрос в обычный JSON-запрос: на место
+// файлов в переменных подставляются имена их частей, а сами файлы кладутся
+// в контекст. Остальные обработчики цепочки видят запрос как любой другой.
+func withUploads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.Method != http.MethodPost || contentType != "multipart/form-data" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := r.ParseMultipartForm(uploadMemory); err != nil {
+			writeRequestError(w, &requestError{CodeValidationFailed, "Invalid multipart request: " + err.Error(), http.StatusBadRequest})
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		body, files, err := parseUploadRequest(r.MultipartForm)
+		if err != nil {
+			writeRequestError(w, &requestError{CodeValidationFailed, err.Error(), http.StatusBadRequest})
+			return
+		}
+		r.Header.Set("Content-Type", "application/json")
+		r.ContentLength = int64(len(body))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), uploadsKey{}, files)))
+	})
+}
+
+// parseUploadRequest собирает JSON-запрос из полей operations и map.
+func parseUploadRequest(form *multipart.Form) ([]byte, map[string]*multipart.FileHeader, error) {
+	if len(form.Value["operations"]) != 1 || len(form.Value["map"]) != 1 {
+		return nil, nil, fmt.Errorf("multipart request needs one operations and one map field")
+	}
+	decoder := json.NewDecoder(strings.NewReader(form.Value["operations"][0]))
+	decoder.UseNumber()
+	var operations map[string]interface{}
+	if err := decoder.Decode(&operations); err != nil {
+		return nil, nil, fmt.Errorf("operations must be a JSON object; batched operations are not supported")
+	}
+	var paths map[string][]string
+	if err := json.Unmarshal([]byte(form.Value["map"][0]), &paths); err != nil {
+		return nil, nil, fmt.Errorf("map must be a JSON object of file paths")
+	}
+	if len(paths) > maxUploads {
+		return nil, nil, fmt.Errorf("at most %d files can be uploaded at once", maxUploads)
+	}
+
+	files := make(map[string]*multipart.FileHeader, len(paths))
+	for name, targets := range paths {
+		if len(form.File[name]) != 1 {
+			return nil, nil, fmt.Errorf("map refers to missing file part %q", name)
+		}
+		files[name] = form.File[name][0]
+		for _, target := range targets {
+			if err := setUploadPath(operations, strings.Split(target, "."), name); err != nil {
+				return nil, nil, fmt.Errorf("map path %q: %w", target, err)
+			}
+		}
+	}
+	body, err := json.Marshal(operations)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, files, nil
+}
+
+// setUploadPath заменяет null по пути вида variables.files.0 на имя части.
+// Пути вне переменных не допускаются: файл может быть только их значением.
+func setUploadPath(operations map[string]interface{}, path []string, name string) error {
+	if len(path) < 2 || path[0] != "variables" {
+		return fmt.Errorf("must point into variables")
+	}
+	var parent interface{} = operations
+	for i, segment := range path {
+		last := i == len(path)-1
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			value, ok := container[segment]
+			if !ok {
+				return fmt.Errorf("no such variable")
+			}
+			if last {
+				if value != nil {
+					return fmt.Errorf("variable must be null")
+				}
+				container[segment] = name
+				return nil
+			}
+			parent = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(container) {
+				return fmt.Errorf("no such list item")
+			}
+			if last {
+				if container[index] != nil {
+					return fmt.Errorf("variable must be null")
+				}
+				container[index] = name
+				return nil
+			}
+			parent = container[index]
+		default:
+			return fmt.Errorf("no such variable")
+		}
+	}
+	return nil
+}
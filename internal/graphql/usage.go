@@ -0,0 +1,206 @@
+package graphql
+
+import (
+	"context"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"net/http"
+	"server/internal/events"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Заголовки, которыми клиенты Apollo сообщают своё имя и версию.
+const (
+	clientNameHeader    = "apollographql-client-name"
+	clientVersionHeader = "apollographql-client-version"
+)
+
+type clientKey struct{}
+
+// clientInfo — клиент, от которого пришёл запрос.
+type clientInfo struct {
+	Name    string
+	Version string
+}
+
+// withClient сохраняет в контексте запроса имя и версию клиента из заголовков,
+// а также ID запроса, чтобы он попал в события, опубликованные мутациями.
+func withClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientInfo{Name: r.Header.Get(clientNameHeader), Version: r.Header.Get(clientVersionHeader)}
+		ctx := context.WithValue(r.Context(), clientKey{}, client)
+		if id := r.Header.Get("X-Request-ID"); id != "" {
+			ctx = events.WithRequestID(ctx, id)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type operationKey struct {
+	Operation     string
+	ClientName    string
+	ClientVersion string
+}
+
+// OperationUsage — число выполнений операции одним клиентом.
+type OperationUsage struct {
+	Operation     string `json:"operation"`
+	ClientName    string `json:"client_name"`
+	ClientVersion string `json:"client_version"`
+	Count         int64  `json:"count"`
+}
+
+// FieldUsage — число операций, в которых запрашивалось поле. Поля схемы,
+// которые ни разу не запрашивались, присутствуют с нулевым счётчиком.
+type FieldUsage struct {
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	Count int64  `json:"count"`
+}
+
+// UsageReport — статистика использования схемы с момента запуска сервера,
+// сгруппированная как в отчётах Apollo Studio: по операциям и по полям типов.
+type UsageReport struct {
+	Since time.Time `json:"since"`
+	// SinceLocal — Since для людей в часовом поясе из заголовка Time-Zone.
+	SinceLocal string           `json:"since_local,omitempty" example:"15 Oct 2026 09:00 MSK"`
+	Operations []OperationUsage `json:"operations"`
+	Fields     []FieldUsage     `json:"fields"`
+}
+
+// Usage — расширение graphql-go, собирающее статистику операций и полей.
+type Usage struct {
+	schema     *graphql.Schema
+	since      time.Time
+	mu         sync.Mutex
+	operations map[operationKey]int64
+	fields     map[string]int64
+}
+
+// NewUsage подключает сбор статистики к схеме.
+func NewUsage(schema *graphql.Schema) *Usage {
+	u := &Usage{
+		schema:     schema,
+		since:      time.Now().UTC(),
+		operations: make(map[operationKey]int64),
+		fields:     make(map[string]int64),
+	}
+	schema.AddExtensions(u)
+	return u
+}
+
+// usageRecord накапливает использование в рамках одного запроса.
+type usageRecord struct {
+	mu        sync.Mutex
+	operation string
+	client    clientInfo
+	fields    map[string]bool
+}
+
+type usageKey struct{}
+
+func (u *Usage) Name() string {
+	return "usage"
+}
+
+func (u *Usage) Init(ctx context.Context, params *graphql.Params) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client, _ := ctx.Value(clientKey{}).(clientInfo)
+	record := &usageRecord{operation: params.OperationName, client: client, fields: make(map[string]bool)}
+	return context.WithValue(ctx, usageKey{}, record)
+}
+
+func (u *Usage) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+func (u *Usage) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+// ExecutionDidStart переносит статистику запроса в общие счётчики по его завершении.
+func (u *Usage) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(*graphql.Result) {
+		record, ok := ctx.Value(usageKey{}).(*usageRecord)
+		if !ok {
+			return
+		}
+		record.mu.Lock()
+		defer record.mu.Unlock()
+
+		operation := record.operation
+		if operation == "" {
+			operation = "anonymous"
+		}
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.operations[operationKey{operation, record.client.Name, record.client.Version}]++
+		for field := range record.fields {
+			u.fields[field]++
+		}
+	}
+}
+
+func (u *Usage) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	if record, ok := ctx.Value(usageKey{}).(*usageRecord); ok && info.ParentType != nil {
+		record.mu.Lock()
+		record.fields[info.ParentType.Name()+"."+info.FieldName] = true
+		if record.operation == "" {
+			if operation, ok := info.Operation.(*ast.OperationDefinition); ok && operation.Name != nil {
+				record.operation = operation.Name.Value
+			}
+		}
+		record.mu.Unlock()
+	}
+	return ctx, func(interface{}, error) {}
+}
+
+func (u *Usage) HasResult() bool {
+	return false
+}
+
+func (u *Usage) GetResult(context.Context) interface{} {
+	return nil
+}
+
+// Report возвращает накопленную статистику; поля отсортированы по возрастанию
+// счётчика, чтобы неиспользуемые оказались в начале.
+func (u *Usage) Report() UsageReport {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	report := UsageReport{Since: u.since, Operations: []OperationUsage{}, Fields: []FieldUsage{}}
+	for key, count := range u.operations {
+		report.Operations = append(report.Operations, OperationUsage{
+			Operation: key.Operation, ClientName: key.ClientName, ClientVersion: key.ClientVersion, Count: count,
+		})
+	}
+	sort.Slice(report.Operations, func(i, j int) bool { return report.Operations[i].Count > report.Operations[j].Count })
+
+	for typeName, t := range u.schema.TypeMap() {
+		object, ok := t.(*graphql.Object)
+		if !ok || strings.HasPrefix(typeName, "__") {
+			continue
+		}
+		for fieldName := range object.Fields() {
+			report.Fields = append(report.Fields, FieldUsage{Type: typeName, Field: fieldName, Count: u.fields[typeName+"."+fieldName]})
+		}
+	}
+	sort.Slice(report.Fields, func(i, j int) bool {
+		a, b := report.Fields[i], report.Fields[j]
+		if a.Count != b.Count {
+			return a.Count < b.Count
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Field < b.Field
+	})
+	return report
+}
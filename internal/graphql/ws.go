@@ -0,0 +1,338 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"log"
+	"server/internal/auth"
+	"server/internal/jsonnaming"
+	"sync"
+	"time"
+)
+
+// Сообщения протокола graphql-transport-ws (https://github.com/enisdenjo/graphql-ws).
+const (
+	wsSubprotocol = "graphql-transport-ws"
+
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+// Коды закрытия, определённые протоколом.
+const (
+	closeBadRequest      = 4400
+	closeUnauthorized    = 4401
+	closeInitTimeout     = 4408
+	closeSubscriberTaken = 4409
+	closeTooManyInits    = 4429
+)
+
+const connectionInitTimeout = 10 * time.Second
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type subscribePayload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+	Extensions    struct {
+		PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// wsSession — состояние одного подключения: подтверждение инициализации,
+// контекст с вызывающим, определённым при инициализации, и активные операции
+// по ID клиента.
+type wsSession struct {
+	conn            *websocket.Conn
+	schema          *graphql.Schema
+	debug           bool
+	persisted       *PersistedQueries
+	auth            auth.Authenticator
+	noIntrospection bool
+	costs           *CostLimiter
+	naming          *jsonnaming.Codec
+	remoteAddr      string
+	ctx             context.Context
+	writeMu         sync.Mutex
+	mu              sync.Mutex
+	acked           bool
+	operations      map[string]context.CancelFunc
+}
+
+// NewSubscriptionHandler обслуживает GraphQL поверх WebSocket по протоколу
+// graphql-transport-ws: подписки, а также запросы и мутации. Из opts
+// учитываются Debug, Persisted, Auth, DisableIntrospection и Costs; Timeout к
+// долгоживущим подпискам не применяется. Браузеры не умеют передавать
+// заголовки при открытии WebSocket, поэтому токен можно прислать и в payload
+// connection_init как {"Authorization": "Bearer <token>"}.
+func NewSubscriptionHandler(schema *graphql.Schema, opts HandlerOptions) fiber.Handler {
+	return websocket.New(func(c *websocket.Conn) {
+		s := &wsSession{
+			conn:            c,
+			schema:          schema,
+			debug:           opts.Debug,
+			persisted:       opts.Persisted,
+			auth:            opts.Auth,
+			noIntrospection: opts.DisableIntrospection,
+			costs:           opts.Costs,
+			naming:          opts.Naming,
+			remoteAddr:      c.RemoteAddr().String(),
+			ctx:             context.Background(),
+			operations:      make(map[string]context.CancelFunc),
+		}
+		defer s.stopAll()
+		defer c.Close()
+
+		initTimer := time.AfterFunc(connectionInitTimeout, func() {
+			if !s.isAcked() {
+				s.close(closeInitTimeout, "Connection initialisation timeout")
+			}
+		})
+		defer initTimer.Stop()
+
+		for {
+			var msg wsMessage
+			if err := c.ReadJSON(&msg); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Printf("Ошибка GraphQL WebSocket: %v", err)
+				}
+				return
+			}
+			if !s.handle(msg) {
+				return
+			}
+		}
+	}, websocket.Config{Subprotocols: []string{wsSubprotocol}})
+}
+
+// handle обрабатывает сообщение клиента; false означает, что соединение закрыто.
+func (s *wsSession) handle(msg wsMessage) bool {
+	switch msg.Type {
+	case msgConnectionInit:
+		var payload struct {
+			Authorization string `json:"Authorization"`
+		}
+		json.Unmarshal(msg.Payload, &payload)
+		header := payload.Authorization
+		if header == "" {
+			header = s.conn.Headers("Authorization")
+		}
+		ctx := authenticate(context.Background(), s.auth, header)
+
+		s.mu.Lock()
+		already := s.acked
+		s.acked = true
+		if !already {
+			s.ctx = ctx
+		}
+		s.mu.Unlock()
+		if already {
+			s.close(closeTooManyInits, "Too many initialisation requests")
+			return false
+		}
+		s.send(wsMessage{Type: msgConnectionAck})
+	case msgPing:
+		s.send(wsMessage{Type: msgPong})
+	case msgPong:
+	case msgSubscribe:
+		if !s.isAcked() {
+			s.close(closeUnauthorized, "Unauthorized")
+			return false
+		}
+		var payload subscribePayload
+		if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+			s.close(closeBadRequest, "Invalid subscribe message")
+			return false
+		}
+		if s.persisted != nil {
+			query, err := s.persisted.resolve(payload.Query, payload.Extensions.PersistedQuery)
+			if err != nil {
+				errs, _ := json.Marshal([]gqlerrors.FormattedError{err.formatted()})
+				s.send(wsMessage{ID: msg.ID, Type: msgError, Payload: errs})
+				return true
+			}
+			payload.Query = query
+		}
+		if payload.Query == "" {
+			s.close(closeBadRequest, "Invalid subscribe message")
+			return false
+		}
+		if s.noIntrospection && usesIntrospection(payload.Query) {
+			errs, _ := json.Marshal([]gqlerrors.FormattedError{errIntrospectionDisabled.formatted()})
+			s.send(wsMessage{ID: msg.ID, Type: msgError, Payload: errs})
+			return true
+		}
+		if s.costs != nil {
+			if err := s.costs.check(clientID(s.ctx, s.remoteAddr), payload.Query, payload.OperationName, payload.Variables); err != nil {
+				errs, _ := json.Marshal([]gqlerrors.FormattedError{err.formatted()})
+				s.send(wsMessage{ID: msg.ID, Type: msgError, Payload: errs})
+				return true
+			}
+		}
+		s.mu.Lock()
+		_, taken := s.operations[msg.ID]
+		var ctx context.Context
+		if !taken {
+			ctx, s.operations[msg.ID] = context.WithCancel(s.ctx)
+		}
+		s.mu.Unlock()
+		if taken {
+			s.close(closeSubscriberTaken, "Subscriber for "+msg.ID+" already exists")
+			return false
+		}
+		go s.run(ctx, msg.ID, payload)
+	case msgComplete:
+		s.stop(msg.ID)
+	default:
+		s.close(closeBadRequest, "Unknown message type "+msg.Type)
+		return false
+	}
+	return true
+}
+
+// run выполняет операцию и пересылает её результаты клиенту.
+func (s *wsSession) run(ctx context.Context, id string, payload subscribePayload) {
+	params := graphql.Params{
+		Schema:         *s.schema,
+		RequestString:  payload.Query,
+		VariableValues: payload.Variables,
+		OperationName:  payload.OperationName,
+		Context:        ctx,
+	}
+
+	if !isSubscription(payload.Query, payload.OperationName) {
+		s.sendResult(id, graphql.Do(params))
+		s.finish(id)
+		return
+	}
+
+	results := graphql.Subscribe(params)
+	defer func() {
+		// Горутина подписки блокируется на отправке, пока канал не вычитан.
+		go func() {
+			for range results {
+			}
+		}()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case result, ok := <-results:
+			if !ok {
+				s.finish(id)
+				return
+			}
+			if !s.sendResult(id, result) {
+				s.stop(id)
+				return
+			}
+		}
+	}
+}
+
+// sendResult отправляет результат как next, а ошибки запроса без данных — как error.
+func (s *wsSession) sendResult(id string, result *graphql.Result) bool {
+	result.Errors = formatErrors(result.Errors, s.debug)
+	renameResult(s.naming, result)
+	if result.Data == nil && len(result.Errors) > 0 {
+		errs, _ := json.Marshal(result.Errors)
+		s.send(wsMessage{ID: id, Type: msgError, Payload: errs})
+		return false
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Ошибка сериализации результата GraphQL: %v", err)
+		return false
+	}
+	s.send(wsMessage{ID: id, Type: msgNext, Payload: data})
+	return true
+}
+
+// finish сообщает клиенту о завершении операции, если он её ещё не отменил.
+func (s *wsSession) finish(id string) {
+	s.mu.Lock()
+	cancel, active := s.operations[id]
+	delete(s.operations, id)
+	s.mu.Unlock()
+	if active {
+		cancel()
+		s.send(wsMessage{ID: id, Type: msgComplete})
+	}
+}
+
+func (s *wsSession) stop(id string) {
+	s.mu.Lock()
+	cancel, active := s.operations[id]
+	delete(s.operations, id)
+	s.mu.Unlock()
+	if active {
+		cancel()
+	}
+}
+
+func (s *wsSession) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, cancel := range s.operations {
+		cancel()
+		delete(s.operations, id)
+	}
+}
+
+func (s *wsSession) isAcked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acked
+}
+
+func (s *wsSession) send(msg wsMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteJSON(msg); err != nil {
+		log.Printf("Ошибка отправки GraphQL WebSocket: %v", err)
+	}
+}
+
+func (s *wsSession) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	s.conn.Close()
+}
+
+// isSubscription определяет тип выполняемой операции. Ошибки разбора
+// игнорируются: их вернёт graphql.Do.
+func isSubscription(query, operationName string) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+			return operation.Operation == ast.OperationTypeSubscription
+		}
+	}
+	return false
+}
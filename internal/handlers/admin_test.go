@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/http/httptest"
+	"server/internal/auth"
+	"testing"
+)
+
+// TestAdminRoutesRequireAdmin проверяет, что служебные маршруты отвечают
+// 401 без токена и 403 не администратору, не доходя до сервисов.
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), auth.Principal{Subject: "1", Role: role}))
+		}
+		return c.Next()
+	})
+	for _, h := range []interface{ Register(fiber.Router) }{
+		NewWebhookHandler(nil), NewDeadLetterHandler(nil), NewEventHandler(nil, nil, nil), NewSearchHandler(nil),
+		NewExperimentHandler(nil), NewGraphQLUsageHandler(nil), NewListingHandler(nil), NewSLOHandler(nil),
+	} {
+		h.Register(app)
+	}
+
+	routes := []struct{ method, path string }{
+		{fiber.MethodGet, "/api/webhooks"},
+		{fiber.MethodPost, "/api/webhooks"},
+		{fiber.MethodGet, "/api/webhooks/by-key/orders"},
+		{fiber.MethodPut, "/api/webhooks/by-key/orders"},
+		{fiber.MethodDelete, "/api/webhooks/by-key/orders"},
+		{fiber.MethodDelete, "/api/webhooks/1"},
+		{fiber.MethodPost, "/api/webhooks/1/test"},
+		{fiber.MethodGet, "/api/admin/dlq"},
+		{fiber.MethodGet, "/api/admin/dlq/1"},
+		{fiber.MethodPost, "/api/admin/dlq/retry"},
+		{fiber.MethodPost, "/api/admin/dlq/discard"},
+		{fiber.MethodPost, "/api/admin/events/replay"},
+		{fiber.MethodPost, "/api/search/synonyms"},
+		{fiber.MethodPut, "/api/search/synonyms/1"},
+		{fiber.MethodDelete, "/api/search/synonyms/1"},
+		{fiber.MethodPut, "/api/search/stopwords/the"},
+		{fiber.MethodDelete, "/api/search/stopwords/the"},
+		{fiber.MethodPost, "/api/search/reindex"},
+		{fiber.MethodPut, "/api/search/boosts"},
+		{fiber.MethodPut, "/api/search/featured/1"},
+		{fiber.MethodDelete, "/api/search/featured/1"},
+		{fiber.MethodGet, "/api/admin/search/zero-results"},
+		{fiber.MethodGet, "/api/admin/experiments"},
+		{fiber.MethodPost, "/api/admin/experiments"},
+		{fiber.MethodGet, "/api/admin/experiments/by-key/checkout"},
+		{fiber.MethodPut, "/api/admin/experiments/by-key/checkout"},
+		{fiber.MethodDelete, "/api/admin/experiments/by-key/checkout"},
+		{fiber.MethodGet, "/api/admin/experiments/1"},
+		{fiber.MethodPut, "/api/admin/experiments/1"},
+		{fiber.MethodDelete, "/api/admin/experiments/1"},
+		{fiber.MethodGet, "/api/admin/experiments/1/results"},
+		{fiber.MethodGet, "/api/admin/graphql/usage"},
+		{fiber.MethodGet, "/api/admin/category-listings"},
+		{fiber.MethodPut, "/api/admin/category-listings/phones"},
+		{fiber.MethodDelete, "/api/admin/category-listings/phones"},
+		{fiber.MethodGet, "/api/admin/slo"},
+	}
+	for _, route := range routes {
+		for role, want := range map[string]int{"": fiber.StatusUnauthorized, auth.RoleEditor: fiber.StatusForbidden} {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			req.Header.Set("X-Test-Role", role)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != want {
+				t.Errorf("%s %s as %q: status %d, want %d", route.method, route.path, role, resp.StatusCode, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/service"
+	"time"
+)
+
+// APIKeyHandler управляет ключами API машинных клиентов.
+type APIKeyHandler struct {
+	keys *service.APIKeyService
+}
+
+func NewAPIKeyHandler(keys *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+func (h *APIKeyHandler) Register(router fiber.Router) {
+	router.Get("/api/admin/api-keys", h.listKeys)
+	router.Post("/api/admin/api-keys", h.createKey)
+	router.Delete("/api/admin/api-keys/:id", h.revokeKey)
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" example:"feed-importer"`
+	// Scopes — права ключа: read, write, delete.
+	Scopes []string `json:"scopes" example:"read,write"`
+	// ExpiresAt — срок действия; без него ключ бессрочный.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// @Summary Ключи API
+// @Description Все ключи, включая отозванные и истёкшие. Сами ключи не возвращаются.
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} models.APIKey "Ключи"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "List API keys."}]
+// @Router /api/admin/api-keys [get]
+func (h *APIKeyHandler) listKeys(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	keys, err := h.keys.List(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(keys)
+}
+
+// @Summary Выдать ключ API
+// @Description Ключ передаётся в заголовке X-API-Key вместо Bearer-токена. Он возвращается в поле key только в этом ответе.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param key body CreateAPIKeyRequest true "Имя, права и срок действия"
+// @Success 201 {object} models.APIKey "Ключ выдан"
+// @Failure 400 {object} ErrorResponse "Некорректные имя, права или срок"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Issue an API key with scopes."}]
+// @Router /api/admin/api-keys [post]
+func (h *APIKeyHandler) createKey(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req CreateAPIKeyRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	key, err := h.keys.Create(c.UserContext(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+// @Summary Отозвать ключ API
+// @Description Ключ перестаёт действовать сразу. Повторный отзыв не ошибка.
+// @Tags Auth
+// @Param id path int true "ID ключа"
+// @Success 204 "Ключ отозван"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Ключ не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Revoke an API key."}]
+// @Router /api/admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) revokeKey(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid key id"})
+	}
+	if err := h.keys.Revoke(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/imgproxy"
+	"server/internal/service"
+)
+
+// AttachmentHandler обслуживает загрузку и скачивание вложений продуктов.
+type AttachmentHandler struct {
+	attachments *service.AttachmentService
+}
+
+func NewAttachmentHandler(attachments *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachments: attachments}
+}
+
+func (h *AttachmentHandler) Register(router fiber.Router) {
+	router.Get("/api/products/:id/attachments", h.listAttachments)
+	router.Post("/api/products/:id/attachments", h.uploadAttachment)
+	router.Get("/api/attachments/:id", h.downloadAttachment)
+	router.Get("/api/attachments/:id/image-url", h.getImageURL)
+	router.Delete("/api/attachments/:id", h.deleteAttachment)
+}
+
+// @Summary Список вложений продукта
+// @Tags Attachments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {array} models.Attachment "Успешный ответ"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/attachments [get]
+func (h *AttachmentHandler) listAttachments(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	attachments, err := h.attachments.List(productID)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(attachments)
+}
+
+// @Summary Загрузить вложение продукта
+// @Tags Attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param file formData file true "Файл вложения"
+// @Success 201 {object} models.Attachment "Вложение загружено"
+// @Failure 400 {object} ErrorResponse "Недопустимый тип или размер файла"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/attachments [post]
+func (h *AttachmentHandler) uploadAttachment(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "file form field is required"})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+	}
+	defer file.Close()
+
+	attachment, err := h.attachments.Upload(productID, header.Filename, header.Size, file)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(attachment)
+}
+
+// @Summary Скачать вложение
+// @Description Поддерживает заголовок Range для частичной загрузки.
+// @Tags Attachments
+// @Produce octet-stream
+// @Param id path int true "ID вложения"
+// @Success 200 {file} file "Содержимое файла"
+// @Success 206 {file} file "Запрошенный диапазон"
+// @Failure 404 {object} ErrorResponse "Вложение не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/attachments/{id} [get]
+func (h *AttachmentHandler) downloadAttachment(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid attachment id"})
+	}
+	attachment, path, err := h.attachments.Get(id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := c.Download(path, attachment.FileName); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	return nil
+}
+
+// @Summary Удалить вложение
+// @Tags Attachments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID вложения"
+// @Success 200 {object} map[string]string "Вложение удалено"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 404 {object} ErrorResponse "Вложение не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/attachments/{id} [delete]
+func (h *AttachmentHandler) deleteAttachment(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid attachment id"})
+	}
+	if err := h.attachments.Delete(id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Attachment deleted successfully"})
+}
+
+type ImageURLResponse struct {
+	URL string `json:"url"`
+}
+
+// @Summary Подписанная ссылка на обработанное изображение
+// @Description Строит ссылку imgproxy с изменением размера и формата на лету. Ключ подписи остаётся на сервере.
+// @Tags Attachments
+// @Produce json
+// @Param id path int true "ID вложения"
+// @Param width query int false "Ширина в пикселях (0 — по пропорциям)"
+// @Param height query int false "Высота в пикселях (0 — по пропорциям)"
+// @Param resize query string false "Способ изменения размера: fit, fill, fill-down, force, auto"
+// @Param format query string false "Формат результата: jpg, png, webp, avif, gif"
+// @Param quality query int false "Качество от 1 до 100"
+// @Success 200 {object} ImageURLResponse "Подписанная ссылка"
+// @Failure 400 {object} ErrorResponse "Некорректные параметры или вложение не изображение"
+// @Failure 404 {object} ErrorResponse "Вложение не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/attachments/{id}/image-url [get]
+func (h *AttachmentHandler) getImageURL(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid attachment id"})
+	}
+	opts := imgproxy.Options{
+		Width:   c.QueryInt("width"),
+		Height:  c.QueryInt("height"),
+		Resize:  c.Query("resize"),
+		Format:  c.Query("format"),
+		Quality: c.QueryInt("quality"),
+	}
+	url, err := h.attachments.ImageURL(id, opts)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(ImageURLResponse{URL: url})
+}
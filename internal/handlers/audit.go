@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"log"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/models"
+	"server/internal/service"
+	"strings"
+	"time"
+)
+
+// auditTimeout ограничивает запись в журнал аудита после ответа.
+const auditTimeout = 5 * time.Second
+
+// Audit записывает в журнал аудита каждый успешный изменяющий запрос к API:
+// POST — create, PUT и PATCH — update, DELETE — delete, а у GraphQL —
+// мутации. Изменения берутся у сервиса, если он сообщил прежнее и новое
+// состояние объекта, иначе — поля тела запроса. Вход и обмен токенов под
+// /api/auth не записываются, регистрация записывается, а повторы, которые
+// схлопнул Deduplicate, — нет. Ставится после аутентификации, чтобы в
+// записи был вызывающий.
+func Audit(audit *service.AuditService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var action string
+		switch c.Method() {
+		case fiber.MethodPost:
+			action = service.AuditCreate
+		case fiber.MethodPut, fiber.MethodPatch:
+			action = service.AuditUpdate
+		case fiber.MethodDelete:
+			action = service.AuditDelete
+		default:
+			return c.Next()
+		}
+		path := c.Path()
+		if strings.HasPrefix(path, "/api/auth/") && path != "/api/auth/register" {
+			return c.Next()
+		}
+		var body []byte
+		if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+			body = append([]byte(nil), c.Body()...)
+		}
+		entry := models.AuditEntry{Action: action, Method: c.Method(), Path: path}
+		graphQL := path == "/api/graphql"
+		if graphQL {
+			mutation, ok := graphQLMutation(c, body)
+			if !ok {
+				return c.Next()
+			}
+			entry.Action, entry.Resource, entry.Changes = mutation.action, mutation.resource, mutation.changes
+		}
+
+		changes := &service.AuditChanges{}
+		c.SetUserContext(service.WithAudit(c.UserContext(), changes))
+		if err := c.Next(); err != nil {
+			return err
+		}
+		entry.Status = c.Response().StatusCode()
+		if entry.Status >= fiber.StatusBadRequest || (graphQL && graphQLFailed(c.Response().Body())) {
+			return nil
+		}
+		// Повтор, схлопнутый Deduplicate, ничего не изменил: его ответ —
+		// ответ первого запроса, который уже записан.
+		if string(c.Response().Header.Peek(deduplicatedHeader)) == "true" {
+			return nil
+		}
+
+		if !graphQL {
+			entry.Resource, entry.ResourceID = c.Route().Path, c.Params("id")
+			if diff, ok := changes.Diff(); ok {
+				entry.Changes = diff
+			} else {
+				entry.Changes = bodyChanges(body)
+			}
+		}
+		ctx := c.UserContext()
+		if principal, ok := auth.FromContext(ctx); ok {
+			entry.Actor, entry.ActorName = principal.Subject, principal.Name
+		}
+		entry.IP, entry.RequestID = c.IP(), events.RequestID(ctx)
+		// Запрос уже выполнен, поэтому отмена его контекста не должна
+		// терять запись.
+		recordCtx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+		defer cancel()
+		if err := audit.Record(recordCtx, entry); err != nil {
+			log.Printf("Не удалось записать %s %s в журнал аудита: %v", entry.Method, entry.Path, err)
+		}
+		return nil
+	}
+}
+
+// bodyChanges — поля тела запроса как новые значения; тело не объект JSON
+// записывается целиком в поле body.
+func bodyChanges(body []byte) map[string]models.AuditChange {
+	var value interface{}
+	if json.Unmarshal(body, &value) != nil {
+		return map[string]models.AuditChange{}
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return map[string]models.AuditChange{"body": {New: value}}
+	}
+	return service.AuditDiff(nil, body)
+}
+
+type auditedMutation struct {
+	action   string
+	resource string
+	changes  map[string]models.AuditChange
+}
+
+// graphQLMutation разбирает запрос GraphQL; ok ложно, если это не мутация.
+// Контекст запроса до резолверов GraphQL не доходит, поэтому изменения —
+// переменные мутации. Запросы только с хешем сохранённого запроса не
+// разбираются и не записываются: текст мутации в них не передаётся.
+func graphQLMutation(c *fiber.Ctx, body []byte) (auditedMutation, bool) {
+	if body == nil && strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		// Загрузка файлов: JSON-запрос лежит в поле operations.
+		body = []byte(c.FormValue("operations"))
+	}
+	var req struct {
+		Query         string          `json:"query"`
+		OperationName string          `json:"operationName"`
+		Variables     json.RawMessage `json:"variables"`
+	}
+	if json.Unmarshal(body, &req) != nil || req.Query == "" {
+		return auditedMutation{}, false
+	}
+	document, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return auditedMutation{}, false
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		if op, ok := definition.(*ast.OperationDefinition); ok &&
+			(req.OperationName == "" || (op.Name != nil && op.Name.Value == req.OperationName)) {
+			operation = op
+			break
+		}
+	}
+	if operation == nil || operation.Operation != ast.OperationTypeMutation || operation.SelectionSet == nil {
+		return auditedMutation{}, false
+	}
+
+	var fields []string
+	for _, selection := range operation.SelectionSet.Selections {
+		if field, ok := selection.(*ast.Field); ok {
+			fields = append(fields, field.Name.Value)
+		}
+	}
+	mutation := auditedMutation{
+		action:   service.AuditUpdate,
+		resource: "graphql:" + strings.Join(fields, ","),
+		changes:  service.AuditDiff(nil, []byte(req.Variables)),
+	}
+	if len(fields) > 0 {
+		switch name := fields[0]; {
+		case strings.HasPrefix(name, "create"), strings.HasPrefix(name, "add"), strings.HasPrefix(name, "import"):
+			mutation.action = service.AuditCreate
+		case strings.HasPrefix(name, "delete"), strings.HasPrefix(name, "remove"):
+			mutation.action = service.AuditDelete
+		}
+	}
+	return mutation, true
+}
+
+// graphQLFailed сообщает, что мутация ничего не изменила: GraphQL отвечает
+// 200 и на ошибку, а её поля в data тогда null.
+func graphQLFailed(body []byte) bool {
+	var resp struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []json.RawMessage          `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) != nil || len(resp.Errors) == 0 {
+		return false
+	}
+	for _, value := range resp.Data {
+		if string(value) != "null" {
+			return false
+		}
+	}
+	return true
+}
+
+// AuditHandler отдаёт журнал аудита для проверок соответствия.
+type AuditHandler struct {
+	audit *service.AuditService
+}
+
+func NewAuditHandler(audit *service.AuditService) *AuditHandler {
+	return &AuditHandler{audit: audit}
+}
+
+func (h *AuditHandler) Register(router fiber.Router) {
+	router.Get("/api/audit", h.getAudit)
+}
+
+// @Summary Журнал аудита
+// @Description Успешные изменяющие запросы к API, новые сначала: кто, когда и с какого адреса что изменил. Более ранние записи запрашиваются с before, равным id последней записи предыдущего ответа.
+// @Tags Audit
+// @Produce json
+// @Param actor query string false "Вызывающий: ID пользователя, api-key:<id> или admin-token"
+// @Param action query string false "Действие" Enums(create, update, delete)
+// @Param resource query string false "Шаблон маршрута, например /api/products/:id"
+// @Param resource_id query string false "ID объекта из маршрута"
+// @Param since query string false "Записи не раньше этого момента (RFC 3339)"
+// @Param until query string false "Записи раньше этого момента (RFC 3339)"
+// @Param before query int false "Вернуть записи с id меньше указанного"
+// @Param limit query int false "Количество записей (по умолчанию 100, не больше 1000)"
+// @Success 200 {array} models.AuditEntry "Записи журнала"
+// @Failure 400 {object} ErrorResponse "Некорректные параметры"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Audit log of create, update and delete requests."}]
+// @Router /api/audit [get]
+func (h *AuditHandler) getAudit(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	query := service.AuditQuery{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		Resource:   c.Query("resource"),
+		ResourceID: c.Query("resource_id"),
+		Before:     int64(c.QueryInt("before")),
+		Limit:      c.QueryInt("limit"),
+	}
+	for _, bound := range []struct {
+		name  string
+		value *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		if raw := c.Query(bound.name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: bound.name + " must be an RFC 3339 timestamp"})
+			}
+			*bound.value = t
+		}
+	}
+	entries, err := h.audit.List(c.UserContext(), query)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(entries)
+}
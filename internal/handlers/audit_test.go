@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"net/http/httptest"
+	"server/internal/service"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Базы в тестах нет, поэтому запись в журнал аудита видна по сообщению
+// о неудачной записи.
+func TestAuditSkipsDeduplicatedRequests(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var logged bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logged)
+
+	app := fiber.New()
+	app.Use("/api", Audit(service.NewAuditService(db)))
+	app.Post("/api/products", Deduplicate(time.Minute), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	for i, wantRecorded := range []bool{true, false} {
+		logged.Reset()
+		req := httptest.NewRequest(fiber.MethodPost, "/api/products", strings.NewReader(`[{"name":"x","price":1}]`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if _, err := app.Test(req, 5000); err != nil {
+			t.Fatal(err)
+		}
+		if recorded := strings.Contains(logged.String(), "журнал аудита"); recorded != wantRecorded {
+			t.Fatalf("request %d recorded = %v, want %v", i+1, recorded, wantRecorded)
+		}
+	}
+}
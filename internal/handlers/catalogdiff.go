@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/service"
+)
+
+// CatalogDiffHandler сравнивает каталог с другим окружением.
+type CatalogDiffHandler struct {
+	differ *service.CatalogDiffer
+}
+
+func NewCatalogDiffHandler(differ *service.CatalogDiffer) *CatalogDiffHandler {
+	return &CatalogDiffHandler{differ: differ}
+}
+
+func (h *CatalogDiffHandler) Register(router fiber.Router) {
+	router.Post("/api/admin/catalog/diff", h.diffCatalog)
+}
+
+// CatalogDiffRequest — окружение, с которым сравнивается каталог.
+type CatalogDiffRequest struct {
+	// URL — базовый адрес другого экземпляра сервиса.
+	URL string `json:"url" example:"https://staging.example.com"`
+	// APIKey — токен другого экземпляра; с токеном администратора
+	// сравнивается и себестоимость.
+	APIKey string `json:"api_key"`
+	// Apply переносит отличия в локальный каталог.
+	Apply bool `json:"apply"`
+}
+
+// @Summary Сравнить каталог с другим окружением
+// @Description Читает каталог другого экземпляра сервиса через GET /api/products и сообщает, каких продуктов в локальном каталоге не хватает, какие отличаются и каких нет в другом окружении. Продукты сопоставляются по SKU, а без SKU — по названию. С apply: true отличия переносятся в локальный каталог: лишние продукты уходят в корзину, отличающиеся обновляются, недостающие создаются. Требуется токен администратора.
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param request body CatalogDiffRequest true "Окружение и режим"
+// @Success 200 {object} models.CatalogDiff "Отличия каталогов"
+// @Failure 400 {object} ErrorResponse "Некорректный адрес или ответ другого окружения"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/catalog/diff [post]
+func (h *CatalogDiffHandler) diffCatalog(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req CatalogDiffRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	diff, err := h.differ.Diff(c.UserContext(), service.CatalogSource{URL: req.URL, APIKey: req.APIKey}, req.Apply)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(diff)
+}
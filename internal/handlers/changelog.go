@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"server/internal/service"
+	"time"
+)
+
+// ChangelogHandler отдаёт журнал изменений API.
+type ChangelogHandler struct {
+	changelog *service.Changelog
+}
+
+func NewChangelogHandler(changelog *service.Changelog) *ChangelogHandler {
+	return &ChangelogHandler{changelog: changelog}
+}
+
+func (h *ChangelogHandler) Register(router fiber.Router) {
+	router.Get("/api/changelog", etag.New(), h.getChangelog)
+}
+
+// @Summary Журнал изменений API
+// @Description Новые поля и операции, изменения поведения, устаревшие и удалённые операции, новые сначала. Журнал собирается из аннотаций маршрутов и меняется только с новой версией сервера; ответ отдаётся с ETag, так что опрашивать его с If-None-Match дёшево.
+// @Tags Changelog
+// @Produce json
+// @Param since query string false "Только изменения с этой даты (ГГГГ-ММ-ДД)"
+// @Success 200 {object} models.Changelog "Журнал"
+// @Failure 400 {object} ErrorResponse "Некорректная дата"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Machine-readable API changelog."}]
+// @Router /api/changelog [get]
+func (h *ChangelogHandler) getChangelog(c *fiber.Ctx) error {
+	since := c.Query("since")
+	if since != "" {
+		if _, err := time.Parse(time.DateOnly, since); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "since must be a date in YYYY-MM-DD format"})
+		}
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.changelog.Entries(since))
+}
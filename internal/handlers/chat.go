@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/service"
+	"server/internal/ws"
+)
+
+// ChatHandler отдаёт историю чата WebSocket, список пользователей в сети,
+// число непрочитанных личных сообщений, описание протокола WebSocket и
+// состояние чата для мониторинга.
+type ChatHandler struct {
+	history *service.ChatHistory
+	chat    *ws.Chat
+	// users проверяет токены пользователей чата; nil — чат без
+	// аутентификации, и личных сообщений нет.
+	users auth.Authenticator
+}
+
+func NewChatHandler(history *service.ChatHistory, chat *ws.Chat, users auth.Authenticator) *ChatHandler {
+	return &ChatHandler{history: history, chat: chat, users: users}
+}
+
+func (h *ChatHandler) Register(router fiber.Router) {
+	router.Get("/api/chat/history", h.getHistory)
+	router.Get("/api/chat/presence", h.getPresence)
+	router.Get("/api/chat/unread", h.getUnread)
+	router.Get("/api/ws/protocol", h.getProtocol)
+	router.Get("/api/ws/stats", h.getStats)
+}
+
+// @Summary История чата
+// @Description Сообщения комнаты в порядке отправки. Более ранние сообщения запрашиваются с before, равным id первого сообщения предыдущего ответа.
+// @Tags Chat
+// @Produce json
+// @Param room query string false "Комната (по умолчанию general)"
+// @Param before query int false "Вернуть сообщения с id меньше указанного"
+// @Param limit query int false "Количество сообщений (по умолчанию 50, не больше 200)"
+// @Success 200 {array} models.ChatMessage "Успешный ответ"
+// @Failure 400 {object} ErrorResponse "Некорректные параметры"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/chat/history [get]
+func (h *ChatHandler) getHistory(c *fiber.Ctx) error {
+	room := c.Query("room", ws.DefaultRoom)
+	if !ws.ValidRoom(room) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid room name"})
+	}
+	messages, err := h.history.List(c.UserContext(), service.HistoryQuery{
+		Room:   room,
+		Before: int64(c.QueryInt("before")),
+		Limit:  c.QueryInt("limit"),
+	})
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(messages)
+}
+
+// @Summary Пользователи чата в сети
+// @Description Имена пользователей и число анонимных подключений по комнатам. Учитываются подключения к этому экземпляру сервера; изменения приходят в чат событиями presence.join и presence.leave.
+// @Tags Chat
+// @Produce json
+// @Param room query string false "Только указанная комната"
+// @Success 200 {array} ws.RoomPresence "Успешный ответ"
+// @Failure 400 {object} ErrorResponse "Некорректное имя комнаты"
+// @Router /api/chat/presence [get]
+func (h *ChatHandler) getPresence(c *fiber.Ctx) error {
+	room := c.Query("room")
+	if room != "" && !ws.ValidRoom(room) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid room name"})
+	}
+	return c.JSON(h.chat.Presence(room))
+}
+
+// @Summary Непрочитанные личные сообщения
+// @Description Число непрочитанных личных сообщений пользователя чата, всего и по отправителям. Пользователь определяется по токену чата WebSocket в заголовке Authorization. Сообщение становится прочитанным, когда клиент получателя подтверждает его фреймом ack.
+// @Tags Chat
+// @Produce json
+// @Success 200 {object} models.UnreadMessages "Успешный ответ"
+// @Failure 401 {object} ErrorResponse "Нет токена пользователя чата"
+// @Failure 501 {object} ErrorResponse "Чат работает без аутентификации"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/chat/unread [get]
+func (h *ChatHandler) getUnread(c *fiber.Ctx) error {
+	if h.users == nil {
+		return c.Status(fiber.StatusNotImplemented).JSON(ErrorResponse{Error: "Chat authentication is not configured"})
+	}
+	user, ok, err := chatUser(c, h.users)
+	if !ok {
+		return err
+	}
+	unread, err := h.history.UnreadCount(c.UserContext(), user.Name)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(unread)
+}
+
+// chatUser проверяет токен пользователя чата из заголовка Authorization.
+// Если ok ложно, ответ 401 уже записан и вызывающий возвращает err.
+func chatUser(c *fiber.Ctx, users auth.Authenticator) (user auth.Principal, ok bool, err error) {
+	token, found := auth.BearerToken(c.Get(fiber.HeaderAuthorization))
+	if !found {
+		return auth.Principal{}, false, c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{Error: "Missing token"})
+	}
+	user, err = users.Authenticate(token)
+	if err != nil {
+		return auth.Principal{}, false, c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{Error: "Invalid token"})
+	}
+	return user, true, nil
+}
+
+// @Summary Протокол WebSocket
+// @Description Машиночитаемое описание протокола чата /api/ws и потока /api/ws/products: JSON Schema конверта {type, version, id, payload}, схемы полезной нагрузки входящих и исходящих сообщений по типу и версии и коды ошибок фреймов error. Схемы входящих сообщений — те же, по которым сервер проверяет фреймы.
+// @Tags Chat
+// @Produce json
+// @Success 200 {object} ws.Protocol "Описание протокола"
+// @Router /api/ws/protocol [get]
+func (h *ChatHandler) getProtocol(c *fiber.Ctx) error {
+	return c.JSON(ws.Spec())
+}
+
+// @Summary Состояние чата WebSocket
+// @Description Подключения, комнаты, сообщения в секунду и счётчики отброшенных сообщений этого экземпляра — те же значения, что ws_* в /metrics. Счётчики растут с запуска сервера.
+// @Tags Chat
+// @Produce json
+// @Success 200 {object} ws.Stats "Состояние чата"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Router /api/ws/stats [get]
+func (h *ChatHandler) getStats(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(h.chat.Stats())
+}
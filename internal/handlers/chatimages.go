@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/service"
+)
+
+// ChatImageHandler принимает изображения для сообщений чата и отдаёт их.
+type ChatImageHandler struct {
+	images *service.ChatImageService
+	// users проверяет токены пользователей чата; nil — чат без
+	// аутентификации, и загружать изображения может любой.
+	users auth.Authenticator
+}
+
+func NewChatImageHandler(images *service.ChatImageService, users auth.Authenticator) *ChatImageHandler {
+	return &ChatImageHandler{images: images, users: users}
+}
+
+func (h *ChatImageHandler) Register(router fiber.Router) {
+	router.Post("/api/chat/images", h.uploadImage)
+	router.Get("/api/chat/images/:id", h.getImage)
+	router.Get("/api/chat/images/:id/thumbnail", h.getThumbnail)
+}
+
+// @Summary Загрузить изображение для чата
+// @Description Принимает JPEG, PNG или GIF; тип определяется по содержимому. Изображение перекодируется с уменьшением большой стороны, рядом сохраняется миниатюра. ID из ответа передаётся в image_ids сообщения чата. С аутентификацией чата нужен токен пользователя, и приложить изображение к сообщению сможет только он.
+// @Tags Chat
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Изображение"
+// @Success 201 {object} models.ChatImage "Изображение загружено"
+// @Failure 400 {object} ErrorResponse "Недопустимый тип, размер или разрешение изображения"
+// @Failure 401 {object} ErrorResponse "Нет токена или токен недействителен"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/chat/images [post]
+func (h *ChatImageHandler) uploadImage(c *fiber.Ctx) error {
+	var uploader string
+	if h.users != nil {
+		user, ok, err := chatUser(c, h.users)
+		if !ok {
+			return err
+		}
+		uploader = user.Name
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "file form field is required"})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+	}
+	defer file.Close()
+
+	image, err := h.images.Upload(c.UserContext(), uploader, header.Size, file)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(image)
+}
+
+// @Summary Изображение из чата
+// @Description Изображения не меняются после загрузки, поэтому кэшируются без ограничения срока.
+// @Tags Chat
+// @Produce image/jpeg,image/png
+// @Param id path string true "ID изображения"
+// @Success 200 {file} file "Изображение"
+// @Failure 404 {object} ErrorResponse "Изображение не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/chat/images/{id} [get]
+func (h *ChatImageHandler) getImage(c *fiber.Ctx) error {
+	return h.sendImage(c, false)
+}
+
+// @Summary Миниатюра изображения из чата
+// @Tags Chat
+// @Produce image/jpeg,image/png
+// @Param id path string true "ID изображения"
+// @Success 200 {file} file "Миниатюра"
+// @Failure 404 {object} ErrorResponse "Изображение не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/chat/images/{id}/thumbnail [get]
+func (h *ChatImageHandler) getThumbnail(c *fiber.Ctx) error {
+	return h.sendImage(c, true)
+}
+
+func (h *ChatImageHandler) sendImage(c *fiber.Ctx, thumbnail bool) error {
+	image, path, err := h.images.Open(c.UserContext(), c.Params("id"), thumbnail)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := c.SendFile(path); err != nil {
+		return err
+	}
+	// Файлы хранятся без расширения, поэтому тип задаётся из метаданных.
+	c.Set(fiber.HeaderContentType, image.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	return nil
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// CORS открывает API браузерным приложениям с источников origins ("*" —
+// с любых); без origins браузер пускает к API только страницы того же
+// источника. Cookie в запросах с других источников не передаются: такие
+// приложения входят с заголовком Authorization. Источник не вида
+// схема://хост[:порт] — ошибка.
+func CORS(origins []string) (fiber.Handler, error) {
+	config := cors.Config{
+		AllowMethods:  "GET,POST,PUT,DELETE",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-API-Key, Prefer, X-Request-ID, X-User-ID, X-Tenant, Time-Zone, X-GraphQL-Tracing, Apollographql-Client-Name, Apollographql-Client-Version",
+		ExposeHeaders: "X-Request-ID, Preference-Applied, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Experiments, X-GraphQL-Cache, X-Deduplicated",
+	}
+	switch {
+	case slices.Contains(origins, "*"):
+		config.AllowOrigins = "*"
+	case len(origins) == 0:
+		config.AllowOriginsFunc = func(string) bool { return false }
+	default:
+		for _, origin := range origins {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+				return nil, fmt.Errorf("origin %q must be scheme://host[:port]", origin)
+			}
+		}
+		config.AllowOrigins = strings.Join(origins, ",")
+	}
+	return cors.New(config), nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"server/internal/auth"
+	"server/internal/parquet"
+	"server/internal/service"
+	"strings"
+)
+
+// datasetRetryAfter — через сколько секунд стоит повторить запрос выгрузки,
+// которая ещё собирается.
+const datasetRetryAfter = "5"
+
+// DatasetHandler отдаёт анонимизированные наборы данных для анализа.
+type DatasetHandler struct {
+	datasets *service.DatasetService
+}
+
+func NewDatasetHandler(datasets *service.DatasetService) *DatasetHandler {
+	return &DatasetHandler{datasets: datasets}
+}
+
+func (h *DatasetHandler) Register(router fiber.Router) {
+	router.Get("/api/admin/datasets/products.parquet", h.getProducts)
+}
+
+// @Summary Набор данных продуктов в Parquet
+// @Description Анонимизированная выгрузка продуктов с агрегатами отзывов и остатков: product_id, name, description, price, cost_price, categories (через '|'), sku, review_count, rating_avg, stock_quantity. Имена и типы колонок стабильны; cost_price, sku и rating_avg допускают null. Просмотры и заказы сервер не хранит. Выгрузка собирается в фоне: пока она не готова, ответ — 202 с состоянием и Retry-After, и запрос нужно повторить. Готовый файл отдаётся повторно в течение DATASET_TTL.
+// @Tags Datasets
+// @Produce application/vnd.apache.parquet,json
+// @Param columns query string false "Колонки через запятую (по умолчанию все); в файле идут в порядке набора данных"
+// @Success 200 {file} file "Файл Parquet"
+// @Success 202 {object} service.DatasetExport "Выгрузка собирается"
+// @Failure 400 {object} ErrorResponse "Неизвестная колонка"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Сборка выгрузки не удалась; следующий запрос начнёт новую"
+// @Router /api/admin/datasets/products.parquet [get]
+func (h *DatasetHandler) getProducts(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var columns []string
+	for _, column := range strings.Split(c.Query("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	export, err := h.datasets.Products(columns)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	switch export.Status {
+	case service.DatasetReady:
+		c.Set(fiber.HeaderContentType, parquet.ContentType)
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="products.parquet"`)
+		c.Set(fiber.HeaderLastModified, export.GeneratedAt.UTC().Format(http.TimeFormat))
+		return c.Send(export.Data)
+	case service.DatasetFailed:
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: export.Error})
+	default:
+		c.Set(fiber.HeaderRetryAfter, datasetRetryAfter)
+		return c.Status(fiber.StatusAccepted).JSON(export)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"sync"
+	"time"
+)
+
+// deduplicatedHeader отмечает ответ, повторённый для дубликата запроса.
+const deduplicatedHeader = "X-Deduplicated"
+
+// dedupEntry — результат первого из одинаковых запросов. done закрывается,
+// когда ответ записан; до этого дубликаты ждут его.
+type dedupEntry struct {
+	done        chan struct{}
+	status      int
+	contentType []byte
+	body        []byte
+	expiresAt   time.Time
+}
+
+type deduplicator struct {
+	window  time.Duration
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*dedupEntry
+	swept   time.Time
+}
+
+// Deduplicate схлопывает повторы запроса: одинаковое тело от того же клиента
+// на тот же маршрут в пределах window получает ответ первого запроса, а не
+// выполняется ещё раз. Так двойной клик в админке не создаёт два продукта.
+// Пока первый запрос выполняется, дубликат ждёт его ответа. Ответы 5xx не
+// запоминаются, чтобы клиент мог повторить запрос после сбоя. window <= 0
+// отключает проверку.
+func Deduplicate(window time.Duration) fiber.Handler {
+	d := &deduplicator{window: window, entries: make(map[[sha256.Size]byte]*dedupEntry)}
+	return func(c *fiber.Ctx) error {
+		if window <= 0 {
+			return c.Next()
+		}
+		key := d.key(c)
+		entry, first := d.claim(key)
+		if !first {
+			select {
+			case <-entry.done:
+			case <-c.UserContext().Done():
+				return c.UserContext().Err()
+			}
+			if entry.status != 0 {
+				c.Set(deduplicatedHeader, "true")
+				c.Response().Header.SetContentTypeBytes(entry.contentType)
+				return c.Status(entry.status).Send(entry.body)
+			}
+			// Первый запрос завершился ошибкой сервера — выполняем этот.
+			return c.Next()
+		}
+
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err != nil || status >= fiber.StatusInternalServerError {
+			d.release(key, entry)
+			return err
+		}
+		entry.status = status
+		entry.contentType = append([]byte(nil), c.Response().Header.ContentType()...)
+		entry.body = append([]byte(nil), c.Response().Body()...)
+		d.mu.Lock()
+		entry.expiresAt = time.Now().Add(d.window)
+		d.mu.Unlock()
+		close(entry.done)
+		return nil
+	}
+}
+
+// key определяет запрос по клиенту, методу, пути и телу. Клиент —
+// вызывающий, которого установила аутентификация, а у анонимного запроса —
+// IP-адрес. Заголовкам вроде X-User-ID не доверяем: подставив чужой ID,
+// клиент получил бы ответ на чужой запрос.
+func (d *deduplicator) key(c *fiber.Ctx) [sha256.Size]byte {
+	client := "ip:" + c.IP()
+	if principal, ok := auth.FromContext(c.UserContext()); ok {
+		client = "subject:" + principal.Subject
+	}
+	h := sha256.New()
+	for _, part := range []string{client, c.Method(), c.Path()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(c.Body())
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// claim возвращает запись запроса; first == true, если запрос первый в
+// окне и его нужно выполнить.
+func (d *deduplicator) claim(key [sha256.Size]byte) (*dedupEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if now.Sub(d.swept) >= d.window {
+		for k, entry := range d.entries {
+			if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+				delete(d.entries, k)
+			}
+		}
+		d.swept = now
+	}
+	if entry, ok := d.entries[key]; ok && (entry.expiresAt.IsZero() || now.Before(entry.expiresAt)) {
+		return entry, false
+	}
+	entry := &dedupEntry{done: make(chan struct{})}
+	d.entries[key] = entry
+	return entry, true
+}
+
+// release забывает неудачный запрос и будит ждущие дубликаты, чтобы они
+// выполнились сами.
+func (d *deduplicator) release(key [sha256.Size]byte, entry *dedupEntry) {
+	d.mu.Lock()
+	if d.entries[key] == entry {
+		delete(d.entries, key)
+	}
+	d.mu.Unlock()
+	close(entry.done)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/http/httptest"
+	"server/internal/auth"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeduplicateKeysByCaller(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  map[string]string
+		second map[string]string
+		want   bool
+	}{
+		{name: "same subject", first: map[string]string{"X-Test-Subject": "1"}, second: map[string]string{"X-Test-Subject": "1"}, want: true},
+		{name: "other subject", first: map[string]string{"X-Test-Subject": "1"}, second: map[string]string{"X-Test-Subject": "2"}, want: false},
+		{name: "anonymous from the same IP", want: true},
+		{
+			name:   "X-User-ID is not trusted",
+			first:  map[string]string{userIDHeader: "1"},
+			second: map[string]string{userIDHeader: "2"},
+			want:   true,
+		},
+		{
+			name:   "anonymous and authenticated",
+			first:  map[string]string{"X-Test-Subject": "1"},
+			second: map[string]string{},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				if subject := c.Get("X-Test-Subject"); subject != "" {
+					c.SetUserContext(auth.WithPrincipal(c.UserContext(), auth.Principal{Subject: subject, Role: auth.RoleEditor}))
+				}
+				return c.Next()
+			})
+			app.Post("/api/products", Deduplicate(time.Minute), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusCreated)
+			})
+
+			var deduplicated bool
+			for _, headers := range []map[string]string{tt.first, tt.second} {
+				req := httptest.NewRequest(fiber.MethodPost, "/api/products", strings.NewReader(`[{"name":"x","price":1}]`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				for name, value := range headers {
+					req.Header.Set(name, value)
+				}
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				deduplicated = resp.Header.Get(deduplicatedHeader) == "true"
+			}
+			if deduplicated != tt.want {
+				t.Fatalf("second request deduplicated = %v, want %v", deduplicated, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/service"
+)
+
+// DeadLetterHandler предоставляет разбор, повтор и удаление записей DLQ.
+type DeadLetterHandler struct {
+	dlq *service.DeadLetterQueue
+}
+
+func NewDeadLetterHandler(dlq *service.DeadLetterQueue) *DeadLetterHandler {
+	return &DeadLetterHandler{dlq: dlq}
+}
+
+func (h *DeadLetterHandler) Register(router fiber.Router) {
+	router.Get("/api/admin/dlq", h.listDeadLetters)
+	router.Get("/api/admin/dlq/:id", h.getDeadLetter)
+	router.Post("/api/admin/dlq/retry", h.retryDeadLetters)
+	router.Post("/api/admin/dlq/discard", h.discardDeadLetters)
+}
+
+type DeadLetterIDsRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// @Summary Список записей очереди недоставленных сообщений
+// @Tags DeadLetters
+// @Produce json
+// @Param source query string false "Источник: webhook или job"
+// @Param limit query int false "Максимальное количество записей (по умолчанию 100)"
+// @Success 200 {array} models.DeadLetter "Успешный ответ"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/dlq [get]
+func (h *DeadLetterHandler) listDeadLetters(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	letters, err := h.dlq.List(c.Query("source"), c.QueryInt("limit", 100))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(letters)
+}
+
+// @Summary Запись очереди недоставленных сообщений
+// @Tags DeadLetters
+// @Produce json
+// @Param id path int true "ID записи"
+// @Success 200 {object} models.DeadLetter "Полезная нагрузка и ошибка"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Запись не найдена"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/dlq/{id} [get]
+func (h *DeadLetterHandler) getDeadLetter(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid dead letter id"})
+	}
+	letter, err := h.dlq.Get(id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(letter)
+}
+
+// @Summary Повторить записи очереди недоставленных сообщений
+// @Description Успешно повторённые записи удаляются из очереди.
+// @Tags DeadLetters
+// @Accept json
+// @Produce json
+// @Param request body DeadLetterIDsRequest true "ID записей"
+// @Success 200 {array} models.RetryOutcome "Результаты повтора"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/dlq/retry [post]
+func (h *DeadLetterHandler) retryDeadLetters(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req DeadLetterIDsRequest
+	if err := parseBody(c, &req); err != nil || len(req.IDs) == 0 {
+		return invalidBody(c, err, "ids are required")
+	}
+	outcomes, err := h.dlq.Retry(req.IDs)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(outcomes)
+}
+
+// @Summary Удалить записи очереди недоставленных сообщений без повтора
+// @Tags DeadLetters
+// @Accept json
+// @Produce json
+// @Param request body DeadLetterIDsRequest true "ID записей"
+// @Success 200 {object} map[string]int "Количество удалённых записей"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/dlq/discard [post]
+func (h *DeadLetterHandler) discardDeadLetters(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req DeadLetterIDsRequest
+	if err := parseBody(c, &req); err != nil || len(req.IDs) == 0 {
+		return invalidBody(c, err, "ids are required")
+	}
+	discarded, err := h.dlq.Discard(req.IDs)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"discarded": discarded})
+}
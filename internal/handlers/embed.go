@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"html/template"
+	"server/internal/events"
+	"server/internal/models"
+	"server/internal/service"
+	"strings"
+)
+
+// widgetStyle и widgetScript — оформление и код виджета продукта. Они
+// разрешаются в Content-Security-Policy по хешу, поэтому их нельзя менять
+// на лету: данные продукта передаются отдельным блоком application/json.
+const widgetStyle = `
+body{margin:0;font:14px/1.4 system-ui,sans-serif;color:#222;background:transparent}
+.widget{display:flex;gap:12px;align-items:center;padding:12px;border:1px solid #ddd;border-radius:8px;background:#fff}
+.widget img{width:64px;height:64px;object-fit:cover;border-radius:4px}
+.widget a{color:inherit}
+.name{font-weight:600}
+.price{font-size:18px;margin:2px 0}
+.stock{font-size:12px;color:#1e7d32}
+.stock.out,.stock.gone{color:#b3261e}
+`
+
+const widgetScript = `
+(function () {
+  var data = JSON.parse(document.getElementById('widget-data').textContent);
+  var lang = document.documentElement.lang || undefined;
+  var query = location.search;
+
+  function render(product) {
+    var price = document.getElementById('widget-price');
+    try {
+      price.textContent = new Intl.NumberFormat(lang, { style: 'currency', currency: product.currency }).format(product.price);
+    } catch (e) {
+      price.textContent = product.price.toFixed(2) + ' ' + product.currency;
+    }
+    document.getElementById('widget-name').textContent = product.name;
+    var stock = document.getElementById('widget-stock');
+    stock.className = 'stock' + (product.in_stock === false ? ' out' : '');
+    stock.textContent = product.in_stock === undefined ? '' : product.in_stock ? 'In stock' : 'Out of stock';
+  }
+
+  function unavailable() {
+    var stock = document.getElementById('widget-stock');
+    stock.className = 'stock gone';
+    stock.textContent = 'Unavailable';
+  }
+
+  var pending = false;
+  function refresh() {
+    if (pending) return;
+    pending = true;
+    fetch(location.pathname + '/data' + query)
+      .then(function (res) {
+        if (res.status === 404) return unavailable();
+        if (res.ok) return res.json().then(render);
+      })
+      .catch(function () {})
+      .then(function () { pending = false; });
+  }
+
+  function connect() {
+    var scheme = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    var socket = new WebSocket(scheme + '//' + location.host + '/api/ws/products?ids=' + data.id +
+      '&types=` + events.ProductUpdated + "," + events.ProductDeleted + "," + events.ProductRestored + "," + events.StockChanged + `');
+    socket.onmessage = refresh;
+    socket.onclose = function () { setTimeout(connect, 5000); };
+  }
+
+  render(data);
+  connect();
+})();
+`
+
+var widgetPage = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html{{with .Product.Locale}} lang="{{.}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Product.Name}}</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+<div class="widget">
+{{if .Product.Image}}<img src="{{.Product.Image}}" alt="">{{end}}
+<div>
+<div class="name">{{if .Product.URL}}<a id="widget-name" href="{{.Product.URL}}" target="_blank" rel="noopener">{{.Product.Name}}</a>{{else}}<span id="widget-name">{{.Product.Name}}</span>{{end}}</div>
+<div class="price" id="widget-price">{{printf "%.2f" .Product.Price}} {{.Product.Currency}}</div>
+<div class="stock{{if .OutOfStock}} out{{end}}" id="widget-stock">{{.Stock}}</div>
+</div>
+</div>
+<script type="application/json" id="widget-data">{{.Data}}</script>
+<script>{{.Script}}</script>
+</body>
+</html>
+`))
+
+// widgetPolicy — Content-Security-Policy страницы виджета без
+// frame-ancestors: разрешены только собственные стили и код виджета по
+// хешу, изображения и запросы к API.
+var widgetPolicy = "default-src 'none'; script-src " + cspHash(widgetScript) + "; style-src " + cspHash(widgetStyle) +
+	"; img-src 'self' https: data:; connect-src 'self' ws: wss:; base-uri 'none'; form-action 'none'"
+
+func cspHash(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+// EmbedHandler отдаёт виджет продукта, который партнёры встраивают на свои
+// сайты через iframe.
+type EmbedHandler struct {
+	products *service.ProductService
+	// frameAncestors — значение директивы frame-ancestors.
+	frameAncestors string
+}
+
+// NewEmbedHandler создаёт обработчик виджета. frameAncestors — сайты,
+// которым разрешено встраивать виджет; пустой список разрешает любые.
+func NewEmbedHandler(products *service.ProductService, frameAncestors []string) *EmbedHandler {
+	ancestors := "*"
+	if len(frameAncestors) > 0 {
+		ancestors = strings.Join(frameAncestors, " ")
+	}
+	return &EmbedHandler{products: products, frameAncestors: ancestors}
+}
+
+func (h *EmbedHandler) Register(router fiber.Router) {
+	router.Get("/embed/products/:id", h.getWidget)
+	router.Get("/embed/products/:id/data", h.getWidgetData)
+}
+
+// widgetView — данные страницы виджета.
+type widgetView struct {
+	Product    models.ProductWidget
+	Stock      string
+	OutOfStock bool
+	Data       template.JS
+	Style      template.CSS
+	Script     template.JS
+}
+
+// @Summary Виджет продукта
+// @Description HTML-страница с названием, ценой и наличием продукта для встраивания на сайты партнёров через iframe. Данные продукта встроены в страницу блоком application/json, а код и стили разрешены в Content-Security-Policy по хешу; цена и наличие обновляются по событиям /api/ws/products. Название локализуется по параметру lang или заголовку Accept-Language.
+// @Tags Embed
+// @Produce html
+// @Param id path int true "ID продукта"
+// @Param lang query string false "Язык виджета (например, en)"
+// @Success 200 {string} string "Страница виджета"
+// @Failure 400 {object} ErrorResponse "Некорректный ID"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /embed/products/{id} [get]
+func (h *EmbedHandler) getWidget(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	widget, err := h.products.Widget(c.UserContext(), id, requestLocales(c))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	// json.Marshal экранирует <, > и &, поэтому данные не закроют тег script.
+	data, err := json.Marshal(widget)
+	if err != nil {
+		return err
+	}
+	view := widgetView{Product: widget, Data: template.JS(data), Style: template.CSS(widgetStyle), Script: template.JS(widgetScript)}
+	if widget.InStock != nil {
+		view.Stock, view.OutOfStock = "In stock", !*widget.InStock
+		if view.OutOfStock {
+			view.Stock = "Out of stock"
+		}
+	}
+	var page bytes.Buffer
+	if err := widgetPage.Execute(&page, view); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentSecurityPolicy, widgetPolicy+"; frame-ancestors "+h.frameAncestors)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	// Без lang язык страницы выбран по Accept-Language: общий кэш должен
+	// хранить её для каждого языка отдельно.
+	c.Vary(fiber.HeaderAcceptLanguage)
+	c.Type("html", "utf-8")
+	return c.Send(page.Bytes())
+}
+
+// @Summary Данные виджета продукта
+// @Description Цена, наличие и изображение продукта, которыми виджет обновляет себя после событий продукта.
+// @Tags Embed
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param lang query string false "Язык названия (например, en)"
+// @Success 200 {object} models.ProductWidget "Успешный ответ"
+// @Failure 400 {object} ErrorResponse "Некорректный ID"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /embed/products/{id}/data [get]
+func (h *EmbedHandler) getWidgetData(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	widget, err := h.products.Widget(c.UserContext(), id, requestLocales(c))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Vary(fiber.HeaderAcceptLanguage)
+	return c.JSON(widget)
+}
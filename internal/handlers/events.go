@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/service"
+	"strings"
+	"time"
+)
+
+// EventHandler обслуживает административные операции с журналом событий и
+// длинный опрос событий для клиентов без WebSocket.
+type EventHandler struct {
+	log      *service.EventLog
+	webhooks *service.WebhookService
+	feed     *events.Feed
+}
+
+func NewEventHandler(log *service.EventLog, webhooks *service.WebhookService, feed *events.Feed) *EventHandler {
+	return &EventHandler{log: log, webhooks: webhooks, feed: feed}
+}
+
+func (h *EventHandler) Register(router fiber.Router) {
+	router.Post("/api/admin/events/replay", h.replayEvents)
+	router.Get("/api/events/poll", h.pollEvents)
+	router.Get("/api/events", h.streamEvents)
+}
+
+const (
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 60 * time.Second
+	// sseHeartbeat — как часто поток SSE без событий шлёт комментарий, чтобы
+	// прокси не закрыли соединение, а сервер заметил отключение клиента.
+	sseHeartbeat = 15 * time.Second
+	// sseRetry — через сколько миллисекунд EventSource переподключается.
+	sseRetry = 3000
+)
+
+// eventTypes читает список типов событий из параметра types.
+func eventTypes(c *fiber.Ctx) []string {
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// @Summary Длинный опрос событий
+// @Description Для клиентов, которым недоступен WebSocket: возвращает события шины после cursor (те же, что получают клиенты WebSocket), а если их нет — ждёт новых до timeout секунд и отвечает пустым списком. Следующий опрос передаёт cursor из ответа. Без cursor возвращаются только события, случившиеся после запроса. Лента хранит последние EVENT_POLL_BUFFER событий в памяти экземпляра; если часть событий после cursor уже недоступна (вытеснены или сервер перезапущен), ответ содержит gap: true и все оставшиеся события.
+// @Tags Events
+// @Produce json
+// @Param cursor query string false "Курсор из предыдущего ответа"
+// @Param types query string false "Типы событий через запятую; по умолчанию все"
+// @Param timeout query int false "Сколько секунд ждать событий (по умолчанию 25, не больше 60)"
+// @Success 200 {object} events.FeedPage "События и курсор следующего опроса"
+// @Failure 400 {object} ErrorResponse "Некорректный курсор или тайм-аут"
+// @Router /api/events/poll [get]
+func (h *EventHandler) pollEvents(c *fiber.Ctx) error {
+	timeout := defaultPollTimeout
+	if c.Query("timeout") != "" {
+		seconds := c.QueryInt("timeout", -1)
+		if seconds < 0 || time.Duration(seconds)*time.Second > maxPollTimeout {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "timeout must be between 0 and 60 seconds"})
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+	defer cancel()
+	page, err := h.feed.Poll(ctx, c.Query("cursor"), eventTypes(c))
+	if errors.Is(err, events.ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid cursor"})
+	}
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(page)
+}
+
+type ReplayEventsRequest struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	WebhookID int       `json:"webhook_id"`
+	// Types ограничивает повтор указанными типами событий; пусто — все типы.
+	Types []string `json:"types"`
+	// Limit — максимальное число событий за один вызов (по умолчанию 1000).
+	Limit int `json:"limit"`
+}
+
+const maxReplayEvents = 1000
+
+// @Summary Повторно доставить события из журнала
+// @Description Отправляет сохранённые события за интервал [from, to) выбранному вебхуку с исходными ID.
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param request body ReplayEventsRequest true "Интервал, получатель и фильтр типов"
+// @Success 200 {object} models.ReplayResult "Итог повторной доставки"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Вебхук не найден"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/events/replay [post]
+func (h *EventHandler) replayEvents(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req ReplayEventsRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	if req.WebhookID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "webhook_id is required"})
+	}
+	if req.Limit <= 0 || req.Limit > maxReplayEvents {
+		req.Limit = maxReplayEvents
+	}
+
+	found, err := h.log.Find(service.EventQuery{From: req.From, To: req.To, Types: req.Types, Limit: req.Limit})
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	result, err := h.webhooks.Replay(req.WebhookID, found)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(result)
+}
+
+// @Summary Поток событий (Server-Sent Events)
+// @Description Для клиентов за прокси, которые не пропускают WebSocket: те же события шины, что получают клиенты WebSocket, в формате text/event-stream. Поле id каждого события — курсор; после обрыва EventSource передаёт его в заголовке Last-Event-ID, и поток продолжается с места обрыва, пока событие ещё в ленте (последние EVENT_POLL_BUFFER событий). Если часть событий уже недоступна, первым приходит событие gap, и клиенту стоит перечитать состояние через REST. Без Last-Event-ID поток начинается с новых событий.
+// @Tags Events
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "id последнего полученного события"
+// @Param last_event_id query string false "То же, что Last-Event-ID, для первого подключения"
+// @Param types query string false "Типы событий через запятую; по умолчанию все"
+// @Success 200 {string} string "Поток событий"
+// @Failure 400 {object} ErrorResponse "Некорректный Last-Event-ID"
+// @Router /api/events [get]
+func (h *EventHandler) streamEvents(c *fiber.Ctx) error {
+	cursor := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	types := eventTypes(c)
+	// Курсор проверяется до начала потока, чтобы ответить 400, а не
+	// оборвать поток.
+	entries, next, gap, err := h.feed.Wait(expired(), cursor, types)
+	if errors.Is(err, events.ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid Last-Event-ID"})
+	}
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// nginx иначе копит ответ в буфере.
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+		for {
+			if gap {
+				w.WriteString("event: gap\ndata: {}\n\n")
+			}
+			for _, entry := range entries {
+				data, err := json.Marshal(entry.Event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", entry.Cursor, entry.Event.Type, data)
+			}
+			if len(entries) == 0 && !gap {
+				w.WriteString(": heartbeat\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				// Клиент отключился.
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), sseHeartbeat)
+			entries, next, gap, err = h.feed.Wait(ctx, next, types)
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// expired возвращает уже отменённый контекст: Wait с ним не ждёт событий.
+func expired() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
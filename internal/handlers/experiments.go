@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"log"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// experimentCookie хранит анонимный ID посетителя для экспериментов.
+	experimentCookie = "experiment_uid"
+	// experimentsHeader перечисляет варианты посетителя: "key=variant, ...".
+	experimentsHeader = "X-Experiments"
+	// userIDHeader — ID пользователя, которым витрина заменяет анонимный ID,
+	// чтобы вариант не менялся между устройствами.
+	userIDHeader = "X-User-ID"
+)
+
+// ExperimentHandler управляет A/B-экспериментами и отдаёт варианты посетителю.
+type ExperimentHandler struct {
+	experiments *service.ExperimentService
+}
+
+func NewExperimentHandler(experiments *service.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{experiments: experiments}
+}
+
+func (h *ExperimentHandler) Register(router fiber.Router) {
+	router.Get("/api/experiments", h.getAssignments)
+	router.Get("/api/admin/experiments", h.listExperiments)
+	router.Post("/api/admin/experiments", h.createExperiment)
+	router.Get("/api/admin/experiments/by-key/:key", h.getExperimentByKey)
+	router.Put("/api/admin/experiments/by-key/:key", h.putExperiment)
+	router.Delete("/api/admin/experiments/by-key/:key", h.deleteExperimentByKey)
+	router.Get("/api/admin/experiments/:id", h.getExperiment)
+	router.Put("/api/admin/experiments/:id", h.updateExperiment)
+	router.Delete("/api/admin/experiments/:id", h.deleteExperiment)
+	router.Get("/api/admin/experiments/:id/results", h.getResults)
+}
+
+// experimentSubject определяет посетителя: по X-User-ID или по cookie,
+// которую выдаёт при первом визите.
+func experimentSubject(c *fiber.Ctx) string {
+	if id := c.Get(userIDHeader); id != "" {
+		return "user:" + id
+	}
+	id := c.Cookies(experimentCookie)
+	if id == "" {
+		id = utils.UUIDv4()
+		// Запрос тоже получает cookie, чтобы обработчик увидел тот же ID.
+		c.Request().Header.SetCookie(experimentCookie, id)
+		c.Cookie(&fiber.Cookie{
+			Name:     experimentCookie,
+			Value:    id,
+			Path:     "/",
+			Expires:  time.Now().AddDate(1, 0, 0),
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+	}
+	return "anon:" + id
+}
+
+// Experiments распределяет посетителя по активным экспериментам и сообщает
+// варианты в заголовке X-Experiments. Служебные маршруты /api/admin не
+// считаются попаданием в эксперимент. Сбой экспериментов не мешает запросу.
+func (h *ExperimentHandler) Experiments() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if strings.HasPrefix(c.Path(), "/api/admin") {
+			return c.Next()
+		}
+		assignments, err := h.experiments.Assign(c.UserContext(), experimentSubject(c))
+		if err != nil {
+			log.Printf("Не удалось распределить посетителя по экспериментам: %v", err)
+			return c.Next()
+		}
+		if len(assignments) > 0 {
+			pairs := make([]string, 0, len(assignments))
+			for key, variant := range assignments {
+				pairs = append(pairs, key+"="+variant)
+			}
+			sort.Strings(pairs)
+			c.Set(experimentsHeader, strings.Join(pairs, ", "))
+		}
+		return c.Next()
+	}
+}
+
+// @Summary Варианты экспериментов посетителя
+// @Description Посетитель определяется по заголовку X-User-ID, иначе по cookie experiment_uid, которая выдаётся при первом запросе. Варианты также приходят в заголовке X-Experiments любого ответа.
+// @Tags Experiments
+// @Produce json
+// @Param X-User-ID header string false "ID пользователя"
+// @Success 200 {object} map[string]string "Вариант по ключу эксперимента"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/experiments [get]
+func (h *ExperimentHandler) getAssignments(c *fiber.Ctx) error {
+	assignments, err := h.experiments.Assign(c.UserContext(), experimentSubject(c))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(assignments)
+}
+
+// @Summary Список экспериментов
+// @Tags Experiments
+// @Produce json
+// @Success 200 {array} models.Experiment "Эксперименты"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments [get]
+func (h *ExperimentHandler) listExperiments(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	experiments, err := h.experiments.List(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(experiments)
+}
+
+// @Summary Создать эксперимент
+// @Description Посетители распределяются по вариантам пропорционально весам.
+// @Tags Experiments
+// @Accept json
+// @Produce json
+// @Param experiment body models.Experiment true "Ключ, варианты и активность"
+// @Success 201 {object} models.Experiment "Эксперимент создан"
+// @Failure 400 {object} ErrorResponse "Некорректный эксперимент"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments [post]
+func (h *ExperimentHandler) createExperiment(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var experiment models.Experiment
+	if err := parseBody(c, &experiment); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	created, err := h.experiments.Create(c.UserContext(), experiment)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// @Summary Эксперимент
+// @Tags Experiments
+// @Produce json
+// @Param id path int true "ID эксперимента"
+// @Success 200 {object} models.Experiment "Эксперимент"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Эксперимент не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/{id} [get]
+func (h *ExperimentHandler) getExperiment(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid experiment id"})
+	}
+	experiment, err := h.experiments.Get(c.UserContext(), id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(experiment)
+}
+
+// @Summary Заменить эксперимент
+// @Description Изменение вариантов или весов перераспределяет посетителей; у запущенного эксперимента обычно меняют только описание и активность.
+// @Tags Experiments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID эксперимента"
+// @Param experiment body models.Experiment true "Ключ, варианты и активность"
+// @Success 200 {object} models.Experiment "Эксперимент обновлён"
+// @Failure 400 {object} ErrorResponse "Некорректный эксперимент"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Эксперимент не найден"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/{id} [put]
+func (h *ExperimentHandler) updateExperiment(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid experiment id"})
+	}
+	var experiment models.Experiment
+	if err := parseBody(c, &experiment); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	updated, err := h.experiments.Update(c.UserContext(), id, experiment)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(updated)
+}
+
+// @Summary Эксперимент по ключу
+// @Tags Experiments
+// @Produce json
+// @Param key path string true "Ключ эксперимента"
+// @Success 200 {object} models.Experiment "Эксперимент"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Эксперимент не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/by-key/{key} [get]
+func (h *ExperimentHandler) getExperimentByKey(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	experiment, err := h.experiments.GetByKey(c.UserContext(), c.Params("key"))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(experiment)
+}
+
+// @Summary Создать или заменить эксперимент по ключу
+// @Description Идемпотентно приводит эксперимент или флаг функции к состоянию из тела запроса — для Terraform и других декларативных инструментов. Ключ берётся из пути; key в теле игнорируется.
+// @Tags Experiments
+// @Accept json
+// @Produce json
+// @Param key path string true "Ключ эксперимента"
+// @Param experiment body models.Experiment true "Описание, варианты и активность"
+// @Success 200 {object} models.Experiment "Эксперимент заменён"
+// @Success 201 {object} models.Experiment "Эксперимент создан"
+// @Failure 400 {object} ErrorResponse "Некорректный эксперимент"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/by-key/{key} [put]
+func (h *ExperimentHandler) putExperiment(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var experiment models.Experiment
+	if err := parseBody(c, &experiment); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	put, created, err := h.experiments.Put(c.UserContext(), c.Params("key"), experiment)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if created {
+		return c.Status(fiber.StatusCreated).JSON(put)
+	}
+	return c.JSON(put)
+}
+
+// @Summary Удалить эксперимент по ключу
+// @Tags Experiments
+// @Param key path string true "Ключ эксперимента"
+// @Success 204 "Эксперимент удалён"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Эксперимент не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/by-key/{key} [delete]
+func (h *ExperimentHandler) deleteExperimentByKey(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := h.experiments.DeleteByKey(c.UserContext(), c.Params("key")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Удалить эксперимент
+// @Tags Experiments
+// @Param id path int true "ID эксперимента"
+// @Success 204 "Эксперимент удалён"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Эксперимент не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/{id} [delete]
+func (h *ExperimentHandler) deleteExperiment(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid experiment id"})
+	}
+	if err := h.experiments.Delete(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Результаты эксперимента
+// @Description Число уникальных посетителей, получивших каждый вариант. Попадания записываются пакетами, поэтому последние секунды могут быть ещё не учтены.
+// @Tags Experiments
+// @Produce json
+// @Param id path int true "ID эксперимента"
+// @Success 200 {object} models.ExperimentResults "Попадания по вариантам"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Эксперимент не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/experiments/{id}/results [get]
+func (h *ExperimentHandler) getResults(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid experiment id"})
+	}
+	results, err := h.experiments.Results(c.UserContext(), id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(results)
+}
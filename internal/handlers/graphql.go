@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/graphql"
+)
+
+// GraphQLUsageHandler отдаёт статистику использования GraphQL-схемы.
+type GraphQLUsageHandler struct {
+	usage *graphql.Usage
+}
+
+func NewGraphQLUsageHandler(usage *graphql.Usage) *GraphQLUsageHandler {
+	return &GraphQLUsageHandler{usage: usage}
+}
+
+func (h *GraphQLUsageHandler) Register(router fiber.Router) {
+	router.Get("/api/admin/graphql/usage", h.getUsage)
+}
+
+// @Summary Статистика использования GraphQL
+// @Description Операции по имени и клиенту (заголовки apollographql-client-name/-version) и число операций, запросивших каждое поле схемы. Поля с нулевым счётчиком не использовались с момента запуска.
+// @Tags GraphQL
+// @Produce json
+// @Param Time-Zone header string false "Часовой пояс IANA для since и since_local (по умолчанию UTC)"
+// @Success 200 {object} graphql.UsageReport "Успешный ответ"
+// @Failure 400 {object} ErrorResponse "Некорректный часовой пояс"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Router /api/admin/graphql/usage [get]
+func (h *GraphQLUsageHandler) getUsage(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	loc, ok := requestLocation(c)
+	if !ok {
+		return invalidTimeZone(c)
+	}
+	report := h.usage.Report()
+	report.Since = report.Since.In(loc)
+	report.SinceLocal = report.Since.Format(humanTimeLayout)
+	return c.JSON(report)
+}
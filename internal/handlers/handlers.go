@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/service"
+)
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ProductHandler обслуживает REST-эндпоинты продуктов поверх сервисного слоя.
+type ProductHandler struct {
+	products *service.ProductService
+}
+
+func NewProductHandler(products *service.ProductService) *ProductHandler {
+	return &ProductHandler{products: products}
+}
+
+// Register подключает маршруты продуктов к роутеру.
+func (h *ProductHandler) Register(router fiber.Router) {
+	router.Get("/api/products", h.getProducts)
+	router.Get("/api/products/lookup", h.lookupProduct)
+	router.Post("/api/products", h.addProducts)
+	router.Put("/api/products/:id", h.updateProduct)
+	router.Delete("/api/products/:id", h.deleteProduct)
+	router.Get("/api/products/:id/jsonld", h.getProductJSONLD)
+	router.Get("/api/products/:id/price-schedules", h.getPriceSchedules)
+	router.Post("/api/products/:id/price-schedules", h.createPriceSchedule)
+	router.Delete("/api/price-schedules/:id", h.cancelPriceSchedule)
+	router.Get("/api/products/:id/translations", h.getProductTranslations)
+	router.Put("/api/products/:id/translations/:locale", h.putProductTranslation)
+	router.Delete("/api/products/:id/translations/:locale", h.deleteProductTranslation)
+	router.Get("/api/trash", h.getTrash)
+	router.Post("/api/trash/:id/restore", h.restoreProduct)
+}
+
+// writeServiceError переводит ошибки сервисного слоя в HTTP-ответы.
+func writeServiceError(c *fiber.Ctx, err error) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: validationErr.Message})
+	case errors.Is(err, service.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "Not found"})
+	case errors.Is(err, service.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{Error: "Already exists"})
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{Error: "Authentication required"})
+	case errors.Is(err, auth.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Insufficient permissions"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+	}
+}
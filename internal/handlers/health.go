@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"sync/atomic"
+)
+
+// HealthHandler отвечает на проверки живости и готовности. Сервер жив сразу
+// после запуска, а готов принимать трафик — после MarkReady, когда закончен
+// прогрев, и до MarkStopping, когда начинается остановка.
+type HealthHandler struct {
+	ready    atomic.Bool
+	stopping atomic.Bool
+}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+func (h *HealthHandler) Register(router fiber.Router) {
+	router.Get("/health", h.getHealth)
+	router.Get("/readyz", h.getReady)
+}
+
+// MarkReady переводит /readyz в состояние готовности.
+func (h *HealthHandler) MarkReady() {
+	h.ready.Store(true)
+}
+
+// MarkStopping переводит /readyz в состояние остановки, чтобы балансировщик
+// перестал направлять трафик на экземпляр.
+func (h *HealthHandler) MarkStopping() {
+	h.stopping.Store(true)
+}
+
+func (h *HealthHandler) getHealth(c *fiber.Ctx) error {
+	return c.SendString("hello")
+}
+
+// @Summary Готовность к трафику
+// @Description Возвращает 503, пока после запуска прогреваются пулы соединений, словарь поиска и кэш списка продуктов, и после начала остановки сервера. Балансировщик должен направлять запросы только на экземпляры с ответом 200.
+// @Tags Health
+// @Produce plain
+// @Success 200 {string} string "ready"
+// @Failure 503 {string} string "warming up или shutting down"
+// @Router /readyz [get]
+func (h *HealthHandler) getReady(c *fiber.Ctx) error {
+	if h.stopping.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("shutting down")
+	}
+	if !h.ready.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("warming up")
+	}
+	return c.SendString("ready")
+}
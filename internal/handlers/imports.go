@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/models"
+	"server/internal/service"
+)
+
+// ImportHandler принимает пакетный импорт продуктов с изображениями по ссылкам.
+type ImportHandler struct {
+	imports *service.ImportService
+}
+
+func NewImportHandler(imports *service.ImportService) *ImportHandler {
+	return &ImportHandler{imports: imports}
+}
+
+func (h *ImportHandler) Register(router fiber.Router) {
+	router.Post("/api/imports", h.createImport)
+	router.Get("/api/imports/:id", h.getImport)
+}
+
+// @Summary Импортировать продукты с изображениями
+// @Description Продукты создаются сразу. Изображения из image_urls скачиваются в фоне, уменьшаются и прикрепляются как вложения; итог по каждой ссылке — в задаче импорта.
+// @Tags Imports
+// @Accept json
+// @Produce json
+// @Param products body []models.ImportItem true "Продукты и ссылки на изображения"
+// @Success 202 {object} models.ImportJob "Задача импорта создана"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты или cost_price"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/imports [post]
+func (h *ImportHandler) createImport(c *fiber.Ctx) error {
+	var items []models.ImportItem
+	if err := parseBody(c, &items); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	job, err := h.imports.Create(c.UserContext(), items)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// @Summary Состояние задачи импорта
+// @Tags Imports
+// @Produce json
+// @Param id path int true "ID задачи импорта"
+// @Success 200 {object} models.ImportJob "Задача и результат по каждой ссылке"
+// @Failure 404 {object} ErrorResponse "Задача не найдена"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/imports/{id} [get]
+func (h *ImportHandler) getImport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid import id"})
+	}
+	job, err := h.imports.Get(id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(job)
+}
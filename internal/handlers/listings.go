@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+)
+
+// ListingHandler управляет настройками выдачи категорий.
+type ListingHandler struct {
+	products *service.ProductService
+}
+
+func NewListingHandler(products *service.ProductService) *ListingHandler {
+	return &ListingHandler{products: products}
+}
+
+func (h *ListingHandler) Register(router fiber.Router) {
+	router.Get("/api/categories/:category/listing", h.getListing)
+	router.Get("/api/admin/category-listings", h.listListings)
+	router.Put("/api/admin/category-listings/:category", h.setListing)
+	router.Delete("/api/admin/category-listings/:category", h.deleteListing)
+	router.Post("/api/admin/categories/reassign", h.reassignCategory)
+}
+
+// ReassignCategoryRequest — перенос продуктов из одной категории в другую.
+type ReassignCategoryRequest struct {
+	From string `json:"from" example:"phones"`
+	To   string `json:"to" example:"smartphones"`
+}
+
+// categoryParam возвращает раскодированное имя категории из пути: имена
+// категорий могут содержать пробелы и кириллицу.
+func categoryParam(c *fiber.Ctx) (string, bool) {
+	category, err := url.PathUnescape(c.Params("category"))
+	return category, err == nil && category != ""
+}
+
+// @Summary Настройки выдачи категории
+// @Description Порядок, размер страницы и значки, которые витрина применяет к списку категории.
+// @Tags Listings
+// @Produce json
+// @Param category path string true "Категория"
+// @Success 200 {object} models.CategoryListing "Настройки выдачи"
+// @Failure 404 {object} ErrorResponse "У категории выдача по умолчанию"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/categories/{category}/listing [get]
+func (h *ListingHandler) getListing(c *fiber.Ctx) error {
+	category, ok := categoryParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid category"})
+	}
+	listing, err := h.products.CategoryListing(c.UserContext(), category)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(listing)
+}
+
+// @Summary Настройки выдачи всех категорий
+// @Tags Listings
+// @Produce json
+// @Success 200 {array} models.CategoryListing "Настройки выдачи"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/category-listings [get]
+func (h *ListingHandler) listListings(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	listings, err := h.products.CategoryListings(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(listings)
+}
+
+// @Summary Задать настройки выдачи категории
+// @Description sort — relevance, price_asc, price_desc, name или newest; badges — featured и in_stock. Настройки применяются к GET /api/products?category=..., когда клиент не задал limit и sort.
+// @Tags Listings
+// @Accept json
+// @Produce json
+// @Param category path string true "Категория"
+// @Param listing body models.CategoryListing true "Порядок, размер страницы и значки"
+// @Success 200 {object} models.CategoryListing "Настройки сохранены"
+// @Failure 400 {object} ErrorResponse "Некорректные настройки"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/category-listings/{category} [put]
+func (h *ListingHandler) setListing(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	category, ok := categoryParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid category"})
+	}
+	var listing models.CategoryListing
+	if err := parseBody(c, &listing); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	listing.Category = category
+	saved, err := h.products.SetCategoryListing(c.UserContext(), listing)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(saved)
+}
+
+// @Summary Вернуть категории выдачу по умолчанию
+// @Tags Listings
+// @Param category path string true "Категория"
+// @Success 204 "Настройки удалены"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "У категории нет настроек"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/category-listings/{category} [delete]
+func (h *ListingHandler) deleteListing(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	category, ok := categoryParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid category"})
+	}
+	if err := h.products.DeleteCategoryListing(c.UserContext(), category); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Перенести категорию
+// @Description Переименовывает категорию from в to на всех продуктах, включая продукты в корзине, в одной транзакции. Если to уже существует, категории сливаются: у продуктов, где были обе, from просто удаляется. Настройки выдачи from переходят к to, если у той нет своих. Вместо product.updated по каждому продукту публикуется одно событие category.reassigned с числом затронутых продуктов. Требуется токен администратора.
+// @Tags Listings
+// @Accept json
+// @Produce json
+// @Param request body ReassignCategoryRequest true "Исходная и новая категории"
+// @Success 200 {object} models.CategoryReassignment "Категория перенесена"
+// @Failure 400 {object} ErrorResponse "Категории не заданы или совпадают"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Категории from нет ни у одного продукта"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/categories/reassign [post]
+func (h *ListingHandler) reassignCategory(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req ReassignCategoryRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	result, err := h.products.ReassignCategory(c.UserContext(), req.From, req.To)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(result)
+}
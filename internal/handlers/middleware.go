@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"server/internal/events"
+)
+
+// maxRequestIDLength ограничивает ID, присланный клиентом, размером колонки журнала событий.
+const maxRequestIDLength = 64
+
+// RequestID присваивает запросу ID (или принимает X-Request-ID клиента),
+// возвращает его в ответе и кладёт в контекст, откуда он попадает в события.
+// Заголовок запроса перезаписывается, чтобы ID видели и net/http-обработчики
+// за адаптером (GraphQL).
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = utils.UUIDv4()
+		}
+		c.Request().Header.Set(fiber.HeaderXRequestID, id)
+		c.Set(fiber.HeaderXRequestID, id)
+		c.SetUserContext(events.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
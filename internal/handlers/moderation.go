@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+	"server/internal/ws"
+	"time"
+)
+
+// ModerationHandler управляет ограничениями пользователей чата: мьютами и
+// банами.
+type ModerationHandler struct {
+	moderation *service.ChatModeration
+	chat       *ws.Chat
+}
+
+func NewModerationHandler(moderation *service.ChatModeration, chat *ws.Chat) *ModerationHandler {
+	return &ModerationHandler{moderation: moderation, chat: chat}
+}
+
+func (h *ModerationHandler) Register(router fiber.Router) {
+	router.Get("/api/admin/chat/sanctions", h.listSanctions)
+	router.Post("/api/admin/chat/sanctions", h.imposeSanction)
+	router.Delete("/api/admin/chat/sanctions/:kind/:username", h.liftSanction)
+}
+
+// SanctionRequest — ограничение пользователя чата.
+type SanctionRequest struct {
+	Username string `json:"username" example:"alice"`
+	// Kind — mute или ban.
+	Kind   string `json:"kind" example:"ban"`
+	Reason string `json:"reason,omitempty" example:"spam"`
+	// Duration — срок в формате Go, например 30m или 24h; пустой — бессрочно.
+	Duration string `json:"duration,omitempty" example:"24h"`
+}
+
+// @Summary Ограничения пользователей чата
+// @Description Действующие мьюты и баны, новые первыми. Требуется токен администратора.
+// @Tags Chat
+// @Produce json
+// @Success 200 {array} models.ChatSanction "Успешный ответ"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/chat/sanctions [get]
+func (h *ModerationHandler) listSanctions(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	sanctions, err := h.moderation.List(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(sanctions)
+}
+
+// @Summary Ограничить пользователя чата
+// @Description Накладывает мьют (пользователь не может писать) или бан (пользователь отключается и не может подключиться) на срок duration или бессрочно, заменяя прежнее ограничение того же вида. Требуется токен администратора.
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Param sanction body SanctionRequest true "Ограничение"
+// @Success 201 {object} models.ChatSanction "Ограничение наложено"
+// @Failure 400 {object} ErrorResponse "Некорректное ограничение"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/chat/sanctions [post]
+func (h *ModerationHandler) imposeSanction(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req SanctionRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "duration must be a duration such as 30m or 24h"})
+		}
+		duration = d
+	}
+	sanction := models.ChatSanction{Username: req.Username, Kind: req.Kind, Reason: req.Reason}
+	if principal, ok := auth.FromContext(c.UserContext()); ok {
+		sanction.CreatedBy = principal.Name
+		if sanction.CreatedBy == "" {
+			sanction.CreatedBy = principal.Subject
+		}
+	}
+	sanction, err := h.moderation.Impose(c.UserContext(), sanction, duration)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if sanction.Kind == models.SanctionBan {
+		h.chat.Kick(sanction.Username, "banned")
+	}
+	return c.Status(fiber.StatusCreated).JSON(sanction)
+}
+
+// @Summary Снять ограничение пользователя чата
+// @Description Снимает мьют или бан пользователя. Требуется токен администратора.
+// @Tags Chat
+// @Param kind path string true "Вид ограничения: mute или ban"
+// @Param username path string true "Имя пользователя"
+// @Success 204 "Ограничение снято"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Ограничения нет"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/chat/sanctions/{kind}/{username} [delete]
+func (h *ModerationHandler) liftSanction(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	username, err := url.PathUnescape(c.Params("username"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid username"})
+	}
+	if err := h.moderation.Lift(c.UserContext(), username, c.Params("kind")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
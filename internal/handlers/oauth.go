@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"server/internal/service"
+	"strings"
+	"time"
+)
+
+const (
+	// oauthCookie хранит state и секрет PKCE между переходом к провайдеру
+	// и возвратом от него.
+	oauthCookie = "oauth_state"
+	// oauthCookieTTL — сколько даётся на вход у провайдера.
+	oauthCookieTTL = 10 * time.Minute
+)
+
+// OAuthHandler входит через провайдеров OAuth2.
+type OAuthHandler struct {
+	oauth *service.OAuthService
+	// baseURL — внешний адрес сервиса для redirect_uri; пустой — из запроса.
+	baseURL string
+	// successURL получает токены во фрагменте адреса; пустой — токены
+	// отдаются JSON.
+	successURL string
+	// cookies кладёт токены ещё и в HttpOnly-cookie; см. CookieSessions.
+	cookies bool
+}
+
+func NewOAuthHandler(oauth *service.OAuthService, baseURL, successURL string, cookies bool) *OAuthHandler {
+	return &OAuthHandler{oauth: oauth, baseURL: strings.TrimRight(baseURL, "/"), successURL: successURL, cookies: cookies}
+}
+
+func (h *OAuthHandler) Register(router fiber.Router) {
+	router.Get("/api/auth/oauth", h.listProviders)
+	router.Get("/api/auth/oauth/:provider", h.start)
+	router.Get("/api/auth/oauth/:provider/callback", h.callback)
+}
+
+type OAuthProvidersResponse struct {
+	Providers []string `json:"providers" example:"github,google"`
+}
+
+// @Summary Провайдеры входа
+// @Description Провайдеры OAuth2, через которых можно войти; для каждого показывается кнопка, ведущая на GET /api/auth/oauth/{provider}.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} OAuthProvidersResponse "Провайдеры"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "OAuth2 login providers."}]
+// @Router /api/auth/oauth [get]
+func (h *OAuthHandler) listProviders(c *fiber.Ctx) error {
+	return c.JSON(OAuthProvidersResponse{Providers: h.oauth.Providers()})
+}
+
+// @Summary Войти через провайдера
+// @Description Перенаправляет браузер на страницу входа провайдера (google, github). Провайдер вернёт пользователя на /api/auth/oauth/{provider}/callback.
+// @Tags Auth
+// @Param provider path string true "Провайдер"
+// @Success 302 "Переход к провайдеру"
+// @Failure 404 {object} ErrorResponse "Провайдер не включён"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Sign in with Google or GitHub."}]
+// @Router /api/auth/oauth/{provider} [get]
+func (h *OAuthHandler) start(c *fiber.Ctx) error {
+	state, err := randomHex()
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	verifier, err := randomHex()
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	target, err := h.oauth.AuthURL(c.Params("provider"), h.redirectURI(c), state, verifier)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthCookie,
+		Value:    state + "." + verifier,
+		Path:     "/api/auth/oauth",
+		Expires:  time.Now().Add(oauthCookieTTL),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		// Lax: cookie должна прийти с переходом от провайдера.
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// @Summary Возврат от провайдера
+// @Description Завершает вход: проверяет state, обменивает код у провайдера и выдаёт пару токенов. Учётная запись провайдера привязывается к пользователю с тем же email, только если адрес подтверждён и провайдером, и у пользователя — то есть тот сам заведён входом через провайдера; к пользователю, зарегистрированному с паролем, она по email не привязывается. Без такого пользователя заводится новый с ролью viewer, если регистрация открыта. Если задан OAUTH_SUCCESS_URL, браузер перенаправляется туда с токенами во фрагменте адреса, иначе токены отдаются JSON. С AUTH_COOKIES токены кладутся ещё и в cookie, как при входе по паролю.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Провайдер"
+// @Param code query string true "Код авторизации"
+// @Param state query string true "State из перехода к провайдеру"
+// @Success 200 {object} models.Tokens "Токены"
+// @Success 302 "Переход на OAUTH_SUCCESS_URL с токенами"
+// @Failure 400 {object} ErrorResponse "Нет кода или state не совпадает"
+// @Failure 401 {object} ErrorResponse "Провайдер отказал во входе или у учётной записи нет пользователя, а регистрация закрыта"
+// @Failure 404 {object} ErrorResponse "Провайдер не включён"
+// @Failure 409 {object} ErrorResponse "Email занят пользователем, зарегистрированным с паролем"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере или у провайдера"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "OAuth2 callback issuing API tokens."}]
+// @Router /api/auth/oauth/{provider}/callback [get]
+func (h *OAuthHandler) callback(c *fiber.Ctx) error {
+	state, verifier, _ := strings.Cut(c.Cookies(oauthCookie), ".")
+	c.ClearCookie(oauthCookie)
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{Error: "Provider denied sign-in: " + reason})
+	}
+	code := c.Query("code")
+	if code == "" || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid OAuth state or code"})
+	}
+	tokens, err := h.oauth.Login(c.UserContext(), c.Params("provider"), code, h.redirectURI(c), verifier)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if h.cookies {
+		setSessionCookies(c, tokens)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if h.successURL == "" {
+		return c.JSON(tokens)
+	}
+	fragment := url.Values{
+		"access_token":  {tokens.AccessToken},
+		"token_type":    {tokens.TokenType},
+		"expires_at":    {tokens.ExpiresAt.Format(time.RFC3339)},
+		"refresh_token": {tokens.RefreshToken},
+	}
+	return c.Redirect(h.successURL+"#"+fragment.Encode(), fiber.StatusFound)
+}
+
+// redirectURI — адрес возврата от провайдера; он же должен быть указан в
+// настройках приложения у провайдера.
+func (h *OAuthHandler) redirectURI(c *fiber.Ctx) string {
+	base := h.baseURL
+	if base == "" {
+		base = c.BaseURL()
+	}
+	return base + "/api/auth/oauth/" + url.PathEscape(c.Params("provider")) + "/callback"
+}
+
+func randomHex() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+type CreatePriceScheduleRequest struct {
+	Price    float64    `json:"price"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// @Summary Запланировать изменение цены продукта
+// @Tags PriceSchedules
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param schedule body CreatePriceScheduleRequest true "Новая цена и период действия"
+// @Success 201 {object} models.PriceSchedule "Изменение цены запланировано"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/price-schedules [post]
+func (h *ProductHandler) createPriceSchedule(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	var req CreatePriceScheduleRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+
+	schedule, err := h.products.SchedulePrice(productID, req.Price, req.StartsAt, req.EndsAt)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(schedule)
+}
+
+// @Summary Список запланированных изменений цены продукта
+// @Tags PriceSchedules
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {array} models.PriceSchedule "Успешный ответ"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/price-schedules [get]
+func (h *ProductHandler) getPriceSchedules(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	schedules, err := h.products.PriceSchedules(productID)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(schedules)
+}
+
+// @Summary Отменить ожидающее изменение цены
+// @Tags PriceSchedules
+// @Accept json
+// @Produce json
+// @Param id path int true "ID расписания"
+// @Success 200 {object} map[string]string "Изменение цены отменено"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 404 {object} ErrorResponse "Ожидающее изменение не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/price-schedules/{id} [delete]
+func (h *ProductHandler) cancelPriceSchedule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid schedule id"})
+	}
+	if err := h.products.CancelPriceSchedule(id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Price schedule cancelled successfully"})
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	"server/internal/models"
+	"server/internal/service"
+	"strconv"
+)
+
+// @Summary Получение списка всех продуктов
+// @Description Название и описание локализуются по параметру lang или заголовку Accept-Language. limit не может превышать LIST_MAX_LIMIT (500), а без limit список отдаётся, только пока в каталоге не больше LIST_UNBOUNDED_MAX (1000) продуктов. Для списка категории незаданные limit и sort берутся из настроек её выдачи, а продукты получают значки выдачи.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param limit query int false "Максимальное количество продуктов; 0 — все или размер страницы категории"
+// @Param offset query int false "Смещение"
+// @Param q query string false "Полнотекстовый поиск по названию, категориям и описанию"
+// @Param category query string false "Категория"
+// @Param sort query string false "Порядок: relevance, price_asc, price_desc, name или newest"
+// @Param lang query string false "Язык ответа (например, en)"
+// @Success 200 {array} models.Product "Успешный ответ"
+// @Failure 400 {object} ErrorResponse "Некорректные параметры пагинации или список без limit для большого каталога"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products [get]
+func (h *ProductHandler) getProducts(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid limit"})
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid offset"})
+	}
+
+	products, err := h.products.List(c.UserContext(), service.ListParams{
+		Limit:   limit,
+		Offset:  offset,
+		Locales: requestLocales(c),
+		Filter:  service.Filter{Search: c.Query("q"), Category: c.Query("category")},
+		Sort:    c.Query("sort"),
+	})
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(products)
+}
+
+// @Summary Найти продукт по штрихкоду или SKU
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param barcode query string false "Штрихкод"
+// @Param sku query string false "Артикул (SKU)"
+// @Param lang query string false "Язык ответа (например, en)"
+// @Success 200 {object} models.Product "Найденный продукт"
+// @Failure 400 {object} ErrorResponse "Не указан штрихкод или SKU"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/lookup [get]
+func (h *ProductHandler) lookupProduct(c *fiber.Ctx) error {
+	product, err := h.products.Lookup(c.UserContext(), c.Query("barcode"), c.Query("sku"), requestLocales(c))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(product)
+}
+
+// @Summary Добавить один или несколько продуктов
+// @Description Повтор запроса с тем же телом от того же клиента в течение DEDUP_WINDOW не создаёт продукты заново, а получает ответ первого запроса с заголовком X-Deduplicated: true.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param products body []models.Product true "Данные продуктов"
+// @Success 200 {array} models.Product "Продукты успешно добавлены"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты или cost_price"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products [post]
+func (h *ProductHandler) addProducts(c *fiber.Ctx) error {
+	var products []models.Product
+
+	if err := parseBody(c, &products); err != nil {
+		// Массив с ошибками не пробуем разобрать как один продукт: иначе
+		// строгий режим сообщит не о тех полях.
+		if bytes.HasPrefix(bytes.TrimSpace(c.Body()), []byte("[")) {
+			return invalidBody(c, err, "Invalid request")
+		}
+		var singleProduct models.Product
+		if err := parseBody(c, &singleProduct); err != nil {
+			return invalidBody(c, err, "Invalid request")
+		}
+		products = append(products, singleProduct)
+	}
+
+	products, err := h.products.Create(c.UserContext(), products)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(products)
+}
+
+// @Summary Обновить данные продукта
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param product body models.Product true "Данные продукта"
+// @Success 200 {object} map[string]string "Продукт успешно обновлен"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты или cost_price"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id} [put]
+func (h *ProductHandler) updateProduct(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	var product models.Product
+	if err := parseBody(c, &product); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+
+	if err := h.products.Update(c.UserContext(), id, product); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Product updated successfully"})
+}
+
+// @Summary Удалить продукт
+// @Description Продукт перемещается в корзину и может быть восстановлен до окончания окна хранения.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {object} map[string]string "Продукт успешно удален"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Удалять продукты может только admin"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "changed", "description": "Requires the delete permission (admin role or an API key with the delete scope)."}]
+// @Router /api/products/{id} [delete]
+func (h *ProductHandler) deleteProduct(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	if err := h.products.Delete(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Product deleted successfully"})
+}
+
+// @Summary Структурированные данные продукта (JSON-LD)
+// @Description Разметка schema.org/Product с ценой, наличием и рейтингом для SSR-страниц и SEO-инструментов.
+// @Tags Products
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param lang query string false "Язык ответа (например, en)"
+// @Success 200 {object} models.ProductJSONLD "Разметка schema.org"
+// @Failure 400 {object} ErrorResponse "Некорректный ID"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/jsonld [get]
+func (h *ProductHandler) getProductJSONLD(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	data, err := h.products.JSONLD(c.UserContext(), id, requestLocales(c), c.BaseURL())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(data, "application/ld+json")
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"server/internal/auth"
+	"strings"
+	"time"
+)
+
+// RateLimits — бюджеты запросов за Window по видам маршрутов; 0 снимает
+// ограничение.
+type RateLimits struct {
+	// Read — GET, HEAD и OPTIONS, Write — остальные методы.
+	Read  int
+	Write int
+	// Auth — вход, регистрация и обмен токенов под /api/auth.
+	Auth   int
+	Window time.Duration
+}
+
+// RateLimit ограничивает частоту запросов REST скользящим окном. Чтение и
+// запись расходуют бюджет вызывающего — ключа API или токена, а без них
+// IP; запросы к /api/auth считаются по IP, чтобы подбор паролей не обходил
+// ограничение сменой учётных данных. Сверх бюджета — 429 с Retry-After.
+// GraphQL ограничивается своим бюджетом стоимости. Ставится после
+// Authenticate и AuthenticateAPIKey.
+func RateLimit(limits RateLimits) fiber.Handler {
+	read := newRateLimiter(limits.Read, limits.Window, callerKey)
+	write := newRateLimiter(limits.Write, limits.Window, callerKey)
+	login := newRateLimiter(limits.Auth, limits.Window, func(c *fiber.Ctx) string { return "ip:" + c.IP() })
+	return func(c *fiber.Ctx) error {
+		switch path := c.Path(); {
+		case strings.HasPrefix(path, "/api/graphql"):
+			return c.Next()
+		case strings.HasPrefix(path, "/api/auth/"):
+			return login(c)
+		}
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return read(c)
+		}
+		return write(c)
+	}
+}
+
+func newRateLimiter(max int, window time.Duration, key func(*fiber.Ctx) string) fiber.Handler {
+	if max <= 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return limiter.New(limiter.Config{
+		Max:               max,
+		Expiration:        window,
+		KeyGenerator:      key,
+		LimiterMiddleware: limiter.SlidingWindow{},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(ErrorResponse{Error: "Too many requests"})
+		},
+	})
+}
+
+// callerKey — чей бюджет расходует запрос: вызывающего, если он
+// аутентифицирован, иначе IP.
+func callerKey(c *fiber.Ctx) string {
+	if principal, ok := auth.FromContext(c.UserContext()); ok {
+		return "token:" + principal.Subject
+	}
+	return "ip:" + c.IP()
+}
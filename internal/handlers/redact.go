@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"io"
+	"server/internal/auth"
+	"server/internal/jsonnaming"
+	"strings"
+)
+
+// Authenticate определяет вызывающего по Bearer-токену и кладёт его в
+// контекст запроса. Запрос без токена или с недействительным токеном
+// выполняется анонимно.
+func Authenticate(authenticator auth.Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := auth.BearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			return c.Next()
+		}
+		principal, err := authenticator.Authenticate(token)
+		if err == nil {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+		}
+		return c.Next()
+	}
+}
+
+// HeaderAPIKey — заголовок, в котором машинные клиенты передают ключ API.
+const HeaderAPIKey = "X-API-Key"
+
+// AuthenticateAPIKey определяет вызывающего по ключу из заголовка X-API-Key,
+// если Authenticate не нашёл Bearer-токена. Запрос с недействительным
+// ключом выполняется анонимно.
+func AuthenticateAPIKey(keys auth.Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderAPIKey)
+		if key == "" {
+			return c.Next()
+		}
+		if _, ok := auth.FromContext(c.UserContext()); ok {
+			return c.Next()
+		}
+		principal, err := keys.Authenticate(key)
+		if err == nil {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+		}
+		return c.Next()
+	}
+}
+
+// RequirePermission пропускает изменяющие запросы (всё, кроме GET, HEAD и
+// OPTIONS) только вызывающим, роли которых разрешено действие perm: без
+// токена — 401, с недостаточной ролью — 403. Чтение остаётся открытым.
+// Ставится после Authenticate.
+func RequirePermission(perm auth.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		if err := auth.RequirePermission(c.UserContext(), perm); err != nil {
+			return writeServiceError(c, err)
+		}
+		return c.Next()
+	}
+}
+
+// RedactFields применяет auth.FieldPolicy к REST: из JSON-тела запроса
+// убираются поля, которые вызывающему менять нельзя, а из JSON-ответа —
+// поля, которые ему видеть нельзя. Порядок остальных полей сохраняется.
+// GraphQL проверяет те же правила сам — в резолверах полей и во входных
+// аргументах мутаций, — поэтому его маршруты middleware пропускает. Ключи
+// ищутся в обоих стилях имён: тело запроса может прийти в snake_case и при
+// naming в camelCase. Поскольку ответ зависит от вызывающего, в нём
+// выставляется Vary по заголовкам, которыми тот представляется: иначе общий
+// кэш отдал бы ответ администратора всем.
+func RedactFields(naming *jsonnaming.Codec) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Vary(fiber.HeaderAuthorization, HeaderAPIKey, fiber.HeaderCookie)
+		if strings.HasPrefix(c.Path(), "/api/graphql") {
+			return c.Next()
+		}
+		hidden := auth.HiddenKeys(c.UserContext())
+		if len(hidden) == 0 {
+			return c.Next()
+		}
+		for key := range hidden {
+			hidden[naming.Key(key)] = true
+		}
+		if isJSON(string(c.Request().Header.ContentType())) && mentions(c.Body(), hidden) {
+			body, err := redactJSON(c.Body(), hidden)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request"})
+			}
+			c.Request().SetBody(body)
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if isJSON(string(c.Response().Header.ContentType())) && mentions(c.Response().Body(), hidden) {
+			body, err := redactJSON(c.Response().Body(), hidden)
+			if err != nil {
+				return err
+			}
+			c.Response().SetBodyRaw(body)
+		}
+		return nil
+	}
+}
+
+// isJSON распознаёт JSON так же, как BodyParser: без учёта регистра, по
+// окончанию json у типа без параметров, в том числе text/json и
+// application/vnd.api+json. Иначе тело, которое BodyParser разберёт как
+// JSON, прошло бы мимо RedactFields.
+func isJSON(contentType string) bool {
+	mediaType := utils.ParseVendorSpecificContentType(utils.ToLower(contentType))
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	return strings.HasSuffix(strings.TrimSpace(mediaType), "json")
+}
+
+// mentions быстро отсеивает тела, в которых скрытых ключей нет, чтобы не
+// разбирать каждый ответ.
+func mentions(body []byte, hidden map[string]bool) bool {
+	for key := range hidden {
+		if bytes.Contains(body, []byte(`"`+key+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON удаляет из JSON-документа ключи hidden на любой глубине.
+func redactJSON(data []byte, hidden map[string]bool) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	if err := copyValue(decoder, &out, hidden); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return out.Bytes(), nil
+}
+
+// copyValue переписывает очередное значение из decoder в out, пропуская
+// скрытые ключи объектов вместе с их значениями.
+func copyValue(decoder *json.Decoder, out *bytes.Buffer, hidden map[string]bool) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return writeScalar(out, token)
+	}
+	switch delim {
+	case '{':
+		out.WriteByte('{')
+		first := true
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			name, _ := key.(string)
+			if hidden[name] {
+				if err := copyValue(decoder, &bytes.Buffer{}, hidden); err != nil {
+					return err
+				}
+				continue
+			}
+			if !first {
+				out.WriteByte(',')
+			}
+			first = false
+			if err := writeScalar(out, name); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if err := copyValue(decoder, out, hidden); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case '[':
+		out.WriteByte('[')
+		for i := 0; decoder.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := copyValue(decoder, out, hidden); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	}
+	// Закрывающий разделитель.
+	_, err = decoder.Token()
+	return err
+}
+
+func writeScalar(out *bytes.Buffer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	out.Write(data)
+	return nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"database/sql"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http/httptest"
+	"server/internal/auth"
+	"server/internal/jsonnaming"
+	"server/internal/service"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	hidden := map[string]bool{"cost_price": true, "margin": true}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "top level", in: `{"id":1,"cost_price":2.5,"name":"x"}`, want: `{"id":1,"name":"x"}`},
+		{name: "first and last keys", in: `{"cost_price":1,"name":"x","margin":0.2}`, want: `{"name":"x"}`},
+		{name: "only hidden keys", in: `{"cost_price":1,"margin":0.2}`, want: `{}`},
+		{name: "nested object", in: `{"product":{"cost_price":1,"price":2}}`, want: `{"product":{"price":2}}`},
+		{name: "array of objects", in: `[{"id":1,"margin":0.1},{"id":2,"margin":null}]`, want: `[{"id":1},{"id":2}]`},
+		{
+			name: "hidden value is an object or array",
+			in:   `{"cost_price":{"amount":[1,2,{"x":3}]},"margin":[{"cost_price":1}],"id":1}`,
+			want: `{"id":1}`,
+		},
+		{
+			name: "deeply nested arrays",
+			in:   `{"items":[{"product":{"id":1,"cost_price":3,"tags":["cost_price"]}}],"total":2}`,
+			want: `{"items":[{"product":{"id":1,"tags":["cost_price"]}}],"total":2}`,
+		},
+		{name: "numbers keep precision", in: `{"price":12345678901234567890.10,"margin":1}`, want: `{"price":12345678901234567890.10}`},
+		{name: "scalars", in: `"cost_price"`, want: `"cost_price"`},
+		{name: "empty containers", in: `{"a":[],"b":{},"c":null,"d":true}`, want: `{"a":[],"b":{},"c":null,"d":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := redactJSON([]byte(tt.in), hidden)
+			if err != nil {
+				t.Fatalf("redactJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("redactJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactJSONRejectsInvalidDocuments(t *testing.T) {
+	for _, in := range []string{``, `{"a":`, `{"a":1}{"b":2}`, `[1,2`, `{"a" 1}`} {
+		if got, err := redactJSON([]byte(in), map[string]bool{"a": true}); err == nil {
+			t.Errorf("redactJSON(%q) = %s, want error", in, got)
+		}
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), auth.Principal{Subject: "1", Role: role}))
+		}
+		return c.Next()
+	})
+	app.Use("/api", RedactFields(jsonnaming.New(jsonnaming.SnakeCase)))
+	app.Post("/api/products", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(c.Body())
+	})
+
+	tests := []struct {
+		role string
+		want string
+	}{
+		{role: "", want: `[{"name":"x","price":2}]`},
+		{role: auth.RoleEditor, want: `[{"name":"x","price":2}]`},
+		{role: auth.RoleAdmin, want: `[{"name":"x","price":2,"cost_price":1,"margin":0.5}]`},
+	}
+	for _, tt := range tests {
+		t.Run("role "+tt.role, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/api/products",
+				strings.NewReader(`[{"name":"x","price":2,"cost_price":1,"margin":0.5}]`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set("X-Test-Role", tt.role)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Fatalf("body = %s, want %s", body, tt.want)
+			}
+			if vary := resp.Header.Get(fiber.HeaderVary); !strings.Contains(vary, fiber.HeaderAuthorization) {
+				t.Fatalf("Vary = %q, want it to include Authorization", vary)
+			}
+		})
+	}
+}
+
+// TestCostPriceWritesByContentType проверяет, что editor не запишет
+// себестоимость ни в одном формате тела, который понимает BodyParser. Базы в
+// тестах нет: 500 значит, что запрос дошёл до хранилища без себестоимости,
+// а 403 — что сервис её отклонил.
+func TestCostPriceWritesByContentType(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), auth.Principal{Subject: "1", Role: role}))
+		}
+		return c.Next()
+	})
+	app.Use("/api", RedactFields(jsonnaming.New(jsonnaming.SnakeCase)))
+	NewProductHandler(service.NewProductService(db, service.Options{})).Register(app)
+
+	const (
+		redacted = fiber.StatusInternalServerError
+		rejected = fiber.StatusForbidden
+	)
+	tests := []struct {
+		name        string
+		role        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{name: "json", role: auth.RoleEditor, contentType: fiber.MIMEApplicationJSON, body: `{"name":"x","price":1,"cost_price":1}`, want: redacted},
+		{name: "text/json", role: auth.RoleEditor, contentType: "text/json", body: `{"name":"x","price":1,"cost_price":1}`, want: redacted},
+		{name: "mixed case type", role: auth.RoleEditor, contentType: "Application/JSON", body: `{"name":"x","price":1,"cost_price":1}`, want: redacted},
+		{name: "vendor type", role: auth.RoleEditor, contentType: "application/vnd.api+json; charset=utf-8", body: `{"name":"x","price":1,"cost_price":1}`, want: redacted},
+		{name: "mixed case key", role: auth.RoleEditor, contentType: fiber.MIMEApplicationJSON, body: `{"name":"x","price":1,"Cost_Price":1}`, want: rejected},
+		{name: "form", role: auth.RoleEditor, contentType: fiber.MIMEApplicationForm, body: "name=x&price=1&costprice=1", want: rejected},
+		{name: "admin", role: auth.RoleAdmin, contentType: fiber.MIMEApplicationForm, body: "name=x&price=1&costprice=1", want: redacted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPut, "/api/products/1", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			req.Header.Set("X-Test-Role", tt.role)
+			resp, err := app.Test(req, 5000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				body, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.want, body)
+			}
+		})
+	}
+}
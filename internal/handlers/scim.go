@@ -0,0 +1,589 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"regexp"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+	"server/internal/ws"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	scimContentType = "application/scim+json"
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+	// scimDefaultCount — размер страницы списка без параметра count.
+	scimDefaultCount = 100
+	// scimKickReason — причина отключения от чата отключённого сотрудника.
+	scimKickReason = "deprovisioned"
+)
+
+// scimFilterPattern — единственные фильтры, которыми провайдеры удостоверений
+// ищут пользователя перед заведением: userName eq "..." и externalId eq "...".
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*(userName|externalId)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// SCIMHandler — SCIM 2.0 (RFC 7643, 7644) для каталога сотрудников:
+// провайдер удостоверений заводит, меняет, отключает и удаляет учётные
+// записи и их роли. Роль передаётся в roles одним значением admin или
+// moderator. Отключённый или удалённый сотрудник отключается от чата этого
+// экземпляра, а его токены перестают действовать.
+type SCIMHandler struct {
+	staff *service.StaffDirectory
+	chat  *ws.Chat
+}
+
+func NewSCIMHandler(staff *service.StaffDirectory, chat *ws.Chat) *SCIMHandler {
+	return &SCIMHandler{staff: staff, chat: chat}
+}
+
+func (h *SCIMHandler) Register(router fiber.Router) {
+	router.Get("/scim/v2/ServiceProviderConfig", h.getServiceProviderConfig)
+	router.Get("/scim/v2/ResourceTypes", h.getResourceTypes)
+	router.Get("/scim/v2/Users", h.listUsers)
+	router.Post("/scim/v2/Users", h.createUser)
+	router.Get("/scim/v2/Users/:id", h.getUser)
+	router.Put("/scim/v2/Users/:id", h.replaceUser)
+	router.Patch("/scim/v2/Users/:id", h.patchUser)
+	router.Delete("/scim/v2/Users/:id", h.deleteUser)
+}
+
+// SCIMValue — элемент многозначного атрибута SCIM: emails или roles.
+type SCIMValue struct {
+	Value   string `json:"value" example:"moderator"`
+	Type    string `json:"type,omitempty" example:"work"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType" example:"User"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location" example:"https://shop.example.com/scim/v2/Users/2819c223a7f3e4b1d0c5f6a7b8c9d0e1"`
+}
+
+// SCIMUser — пользователь в схеме urn:ietf:params:scim:schemas:core:2.0:User.
+// Без active в запросе пользователь заводится активным.
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty" example:"2819c223a7f3e4b1d0c5f6a7b8c9d0e1"`
+	ExternalID  string      `json:"externalId,omitempty" example:"00u1ab2cd3EF4gh5i6j7"`
+	UserName    string      `json:"userName" example:"alice"`
+	DisplayName string      `json:"displayName,omitempty" example:"Alice Smith"`
+	Active      *bool       `json:"active,omitempty"`
+	Emails      []SCIMValue `json:"emails,omitempty"`
+	Roles       []SCIMValue `json:"roles,omitempty"`
+	Meta        *SCIMMeta   `json:"meta,omitempty"`
+}
+
+type SCIMListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int        `json:"totalResults"`
+	StartIndex   int        `json:"startIndex"`
+	ItemsPerPage int        `json:"itemsPerPage"`
+	Resources    []SCIMUser `json:"Resources"`
+}
+
+// SCIMPatchOperation — операция PATCH; без path value — объект с
+// заменяемыми атрибутами.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op" example:"replace"`
+	Path  string          `json:"path,omitempty" example:"active"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMError — ошибка в формате SCIM; status — код HTTP строкой.
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status" example:"409"`
+	SCIMType string   `json:"scimType,omitempty" example:"uniqueness"`
+	Detail   string   `json:"detail" example:"userName is already taken"`
+}
+
+// scimJSON отвечает телом body с типом application/scim+json.
+func scimJSON(c *fiber.Ctx, status int, body interface{}) error {
+	if err := c.Status(status).JSON(body); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, scimContentType)
+	return nil
+}
+
+func scimError(c *fiber.Ctx, status int, scimType, detail string) error {
+	return scimJSON(c, status, SCIMError{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+// writeSCIMServiceError — writeServiceError в формате ошибок SCIM.
+func writeSCIMServiceError(c *fiber.Ctx, err error) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", validationErr.Message)
+	case errors.Is(err, service.ErrNotFound):
+		return scimError(c, fiber.StatusNotFound, "", "User not found")
+	case errors.Is(err, service.ErrConflict):
+		return scimError(c, fiber.StatusConflict, "uniqueness", "userName is already taken")
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return scimError(c, fiber.StatusUnauthorized, "", "Authentication required")
+	case errors.Is(err, auth.ErrForbidden):
+		return scimError(c, fiber.StatusForbidden, "", "Insufficient permissions")
+	default:
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+}
+
+func (h *SCIMHandler) toSCIM(c *fiber.Ctx, u models.StaffUser) SCIMUser {
+	active := u.Active
+	user := SCIMUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          u.ID,
+		ExternalID:  u.ExternalID,
+		UserName:    u.UserName,
+		DisplayName: u.DisplayName,
+		Active:      &active,
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      u.CreatedAt.UTC(),
+			LastModified: u.UpdatedAt.UTC(),
+			Location:     c.BaseURL() + "/scim/v2/Users/" + u.ID,
+		},
+	}
+	if u.Email != "" {
+		user.Emails = []SCIMValue{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	if u.Role != "" {
+		user.Roles = []SCIMValue{{Value: u.Role, Primary: true}}
+	}
+	return user
+}
+
+// fromSCIM переносит атрибуты пользователя SCIM в учётную запись.
+func fromSCIM(user SCIMUser) (models.StaffUser, error) {
+	u := models.StaffUser{
+		ExternalID:  user.ExternalID,
+		UserName:    user.UserName,
+		DisplayName: user.DisplayName,
+		Active:      user.Active == nil || *user.Active,
+		Email:       primaryValue(user.Emails),
+	}
+	role, err := singleRole(user.Roles)
+	if err != nil {
+		return models.StaffUser{}, err
+	}
+	u.Role = role
+	return u, nil
+}
+
+// primaryValue возвращает основное значение многозначного атрибута, а без
+// отмеченного основным — первое.
+func primaryValue(values []SCIMValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// singleRole возвращает роль из roles: у сотрудника роль одна.
+func singleRole(roles []SCIMValue) (string, error) {
+	role := ""
+	for _, r := range roles {
+		value := strings.ToLower(strings.TrimSpace(r.Value))
+		if value == "" || value == role {
+			continue
+		}
+		if role != "" {
+			return "", &service.ValidationError{Message: "roles must contain at most one role"}
+		}
+		role = value
+	}
+	return role, nil
+}
+
+// requireSCIM пропускает только запросы с токеном SCIM.
+func requireSCIM(c *fiber.Ctx) error {
+	return auth.Require(c.UserContext(), auth.RoleAdmin)
+}
+
+// @Summary Возможности SCIM
+// @Description Какие возможности SCIM 2.0 поддерживает сервер: PATCH и фильтры есть, массовых операций, сортировки и смены пароля нет. Требуется токен SCIM.
+// @Tags SCIM
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Возможности сервера"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) getServiceProviderConfig(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	unsupported := fiber.Map{"supported": false}
+	return scimJSON(c, fiber.StatusOK, fiber.Map{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          fiber.Map{"supported": true},
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": 500},
+		"changePassword": unsupported,
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "A token from SCIM_TOKENS in the Authorization header.",
+		}},
+	})
+}
+
+// @Summary Типы ресурсов SCIM
+// @Description Сервер поддерживает один тип ресурсов — User. Требуется токен SCIM.
+// @Tags SCIM
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Типы ресурсов"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Router /scim/v2/ResourceTypes [get]
+func (h *SCIMHandler) getResourceTypes(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	userType := fiber.Map{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+		"id":       "User",
+		"name":     "User",
+		"endpoint": "/Users",
+		"schema":   scimUserSchema,
+	}
+	return scimJSON(c, fiber.StatusOK, fiber.Map{
+		"schemas":      []string{scimListSchema},
+		"totalResults": 1,
+		"startIndex":   1,
+		"itemsPerPage": 1,
+		"Resources":    []fiber.Map{userType},
+	})
+}
+
+// @Summary Сотрудники
+// @Description Страница сотрудников в порядке заведения. Из фильтров поддерживаются userName eq "..." (без учёта регистра) и externalId eq "...". Требуется токен SCIM.
+// @Tags SCIM
+// @Produce json
+// @Param filter query string false "Фильтр, например userName eq \"alice\""
+// @Param startIndex query int false "Номер первого результата, с 1"
+// @Param count query int false "Размер страницы (по умолчанию 100, не больше 500)"
+// @Success 200 {object} SCIMListResponse "Страница сотрудников"
+// @Failure 400 {object} SCIMError "Неподдерживаемый фильтр"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Failure 500 {object} SCIMError "Ошибка на сервере"
+// @Router /scim/v2/Users [get]
+func (h *SCIMHandler) listUsers(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	query := service.StaffQuery{Offset: c.QueryInt("startIndex", 1) - 1, Limit: c.QueryInt("count", scimDefaultCount)}
+	if filter := c.Query("filter"); filter != "" {
+		m := scimFilterPattern.FindStringSubmatch(filter)
+		if m == nil {
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", `only userName eq "..." and externalId eq "..." filters are supported`)
+		}
+		value, err := strconv.Unquote(m[2])
+		if err != nil {
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", "invalid filter value")
+		}
+		if strings.EqualFold(m[1], "userName") {
+			query.UserName = value
+		} else {
+			query.ExternalID = value
+		}
+	}
+	users, total, err := h.staff.List(c.UserContext(), query)
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	resources := make([]SCIMUser, len(users))
+	for i, u := range users {
+		resources[i] = h.toSCIM(c, u)
+	}
+	return scimJSON(c, fiber.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   max(query.Offset, 0) + 1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// @Summary Завести сотрудника
+// @Description Заводит учётную запись сотрудника. Имя удалённого сотрудника можно занять снова. Требуется токен SCIM.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param user body SCIMUser true "Пользователь"
+// @Success 201 {object} SCIMUser "Сотрудник заведён"
+// @Failure 400 {object} SCIMError "Некорректные атрибуты"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Failure 409 {object} SCIMError "Имя пользователя занято"
+// @Failure 500 {object} SCIMError "Ошибка на сервере"
+// @Router /scim/v2/Users [post]
+func (h *SCIMHandler) createUser(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	var request SCIMUser
+	// Провайдеры присылают application/scim+json, который BodyParser не знает.
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request")
+	}
+	user, err := fromSCIM(request)
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	created, err := h.staff.Create(c.UserContext(), user)
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	response := h.toSCIM(c, created)
+	c.Set(fiber.HeaderLocation, response.Meta.Location)
+	return scimJSON(c, fiber.StatusCreated, response)
+}
+
+// @Summary Сотрудник
+// @Description Требуется токен SCIM.
+// @Tags SCIM
+// @Produce json
+// @Param id path string true "ID сотрудника"
+// @Success 200 {object} SCIMUser "Сотрудник"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Failure 404 {object} SCIMError "Сотрудник не найден"
+// @Failure 500 {object} SCIMError "Ошибка на сервере"
+// @Router /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) getUser(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	user, err := h.staff.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, h.toSCIM(c, user))
+}
+
+// @Summary Заменить сотрудника
+// @Description Заменяет атрибуты сотрудника целиком; отсутствующий атрибут очищается, а без active сотрудник активен. Отключённый сотрудник отключается от чата. Требуется токен SCIM.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сотрудника"
+// @Param user body SCIMUser true "Пользователь"
+// @Success 200 {object} SCIMUser "Сотрудник изменён"
+// @Failure 400 {object} SCIMError "Некорректные атрибуты"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Failure 404 {object} SCIMError "Сотрудник не найден"
+// @Failure 409 {object} SCIMError "Имя пользователя занято"
+// @Failure 500 {object} SCIMError "Ошибка на сервере"
+// @Router /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) replaceUser(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	var request SCIMUser
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request")
+	}
+	user, err := fromSCIM(request)
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	user.ID = c.Params("id")
+	return h.save(c, user)
+}
+
+// @Summary Изменить сотрудника
+// @Description Применяет операции add, replace и remove к атрибутам userName, externalId, displayName, active, emails и roles. Так провайдер удостоверений обычно отключает сотрудника: replace active false. Требуется токен SCIM.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сотрудника"
+// @Param patch body SCIMPatchRequest true "Операции"
+// @Success 200 {object} SCIMUser "Сотрудник изменён"
+// @Failure 400 {object} SCIMError "Некорректная операция"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Failure 404 {object} SCIMError "Сотрудник не найден"
+// @Failure 409 {object} SCIMError "Имя пользователя занято"
+// @Failure 500 {object} SCIMError "Ошибка на сервере"
+// @Router /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) patchUser(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	var request SCIMPatchRequest
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request")
+	}
+	user, err := h.staff.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	for _, op := range request.Operations {
+		if err := applySCIMPatch(&user, op); err != nil {
+			return scimError(c, fiber.StatusBadRequest, err.scimType, err.detail)
+		}
+	}
+	return h.save(c, user)
+}
+
+// save сохраняет изменения сотрудника и отключает от чата отключённого.
+func (h *SCIMHandler) save(c *fiber.Ctx, user models.StaffUser) error {
+	updated, err := h.staff.Update(c.UserContext(), user)
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	if !updated.Active {
+		h.chat.Kick(updated.UserName, scimKickReason)
+	}
+	return scimJSON(c, fiber.StatusOK, h.toSCIM(c, updated))
+}
+
+// @Summary Удалить сотрудника
+// @Description Удаляет учётную запись: токены сотрудника перестают действовать, а его подключения к чату закрываются. Требуется токен SCIM.
+// @Tags SCIM
+// @Param id path string true "ID сотрудника"
+// @Success 204 "Сотрудник удалён"
+// @Failure 401 {object} SCIMError "Нет токена SCIM"
+// @Failure 404 {object} SCIMError "Сотрудник не найден"
+// @Failure 500 {object} SCIMError "Ошибка на сервере"
+// @Router /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) deleteUser(c *fiber.Ctx) error {
+	if err := requireSCIM(c); err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	username, err := h.staff.Delete(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return writeSCIMServiceError(c, err)
+	}
+	h.chat.Kick(username, scimKickReason)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// scimPatchError — отклонённая операция PATCH с типом ошибки SCIM.
+type scimPatchError struct {
+	scimType string
+	detail   string
+}
+
+// applySCIMPatch применяет одну операцию PATCH к учётной записи.
+func applySCIMPatch(user *models.StaffUser, op SCIMPatchOperation) *scimPatchError {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return &scimPatchError{"invalidSyntax", "op must be add, replace or remove"}
+	}
+	if op.Path == "" {
+		if kind == "remove" {
+			return &scimPatchError{"noTarget", "remove requires a path"}
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return &scimPatchError{"invalidValue", "value must be an object of attributes"}
+		}
+		for path, value := range attributes {
+			if err := setSCIMAttribute(user, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if kind == "remove" {
+		return setSCIMAttribute(user, op.Path, nil)
+	}
+	return setSCIMAttribute(user, op.Path, op.Value)
+}
+
+// setSCIMAttribute присваивает атрибуту path значение value; nil очищает
+// атрибут. Фильтр в пути emails вроде emails[type eq "work"].value
+// относится к единственному адресу сотрудника.
+func setSCIMAttribute(user *models.StaffUser, path string, value json.RawMessage) *scimPatchError {
+	attribute := strings.ToLower(path)
+	if i := strings.IndexAny(attribute, "[."); i >= 0 {
+		attribute = attribute[:i]
+	}
+	invalidValue := &scimPatchError{"invalidValue", "invalid value of " + path}
+	switch attribute {
+	case "username", "externalid", "displayname":
+		var s string
+		if value != nil && json.Unmarshal(value, &s) != nil {
+			return invalidValue
+		}
+		switch attribute {
+		case "username":
+			if value == nil {
+				return &scimPatchError{"mutability", "userName is required"}
+			}
+			user.UserName = s
+		case "externalid":
+			user.ExternalID = s
+		default:
+			user.DisplayName = s
+		}
+	case "active":
+		if value == nil {
+			return &scimPatchError{"mutability", "active cannot be removed"}
+		}
+		// Некоторые провайдеры присылают булево значение строкой "False".
+		var active bool
+		if err := json.Unmarshal(value, &active); err != nil {
+			var s string
+			if json.Unmarshal(value, &s) != nil {
+				return invalidValue
+			}
+			parsed, err := strconv.ParseBool(s)
+			if err != nil {
+				return invalidValue
+			}
+			active = parsed
+		}
+		user.Active = active
+	case "emails", "roles":
+		var values []SCIMValue
+		if value != nil && json.Unmarshal(value, &values) != nil {
+			// Путь с фильтром или податрибутом указывает на одно значение.
+			var single SCIMValue
+			var s string
+			switch {
+			case json.Unmarshal(value, &single) == nil:
+				values = []SCIMValue{single}
+			case json.Unmarshal(value, &s) == nil:
+				values = []SCIMValue{{Value: s}}
+			default:
+				return invalidValue
+			}
+		}
+		if attribute == "emails" {
+			user.Email = primaryValue(values)
+			return nil
+		}
+		role, err := singleRole(values)
+		if err != nil {
+			return invalidValue
+		}
+		user.Role = role
+	default:
+		return &scimPatchError{"invalidPath", "unsupported attribute " + path}
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+	"time"
+)
+
+// SearchHandler управляет словарём поиска — синонимами и стоп-словами — и
+// ранжированием результатов.
+type SearchHandler struct {
+	products *service.ProductService
+}
+
+func NewSearchHandler(products *service.ProductService) *SearchHandler {
+	return &SearchHandler{products: products}
+}
+
+func (h *SearchHandler) Register(router fiber.Router) {
+	router.Get("/api/search/synonyms", h.getSynonyms)
+	router.Post("/api/search/synonyms", h.addSynonyms)
+	router.Put("/api/search/synonyms/:id", h.updateSynonyms)
+	router.Delete("/api/search/synonyms/:id", h.deleteSynonyms)
+	router.Get("/api/search/stopwords", h.getStopwords)
+	router.Put("/api/search/stopwords/:word", h.addStopword)
+	router.Delete("/api/search/stopwords/:word", h.deleteStopword)
+	router.Get("/api/search/index", h.getIndexStatus)
+	router.Post("/api/search/reindex", h.reindex)
+	router.Get("/api/search/boosts", h.getBoosts)
+	router.Put("/api/search/boosts", h.setBoosts)
+	router.Get("/api/search/featured", h.getFeatured)
+	router.Put("/api/search/featured/:id", h.featureProduct)
+	router.Delete("/api/search/featured/:id", h.unfeatureProduct)
+	router.Get("/api/admin/search/zero-results", h.getZeroResults)
+	router.Get("/api/search/did-you-mean", h.didYouMean)
+}
+
+// SynonymsRequest — слова группы синонимов.
+type SynonymsRequest struct {
+	Terms []string `json:"terms" example:"laptop,notebook"`
+}
+
+// @Summary Группы синонимов поиска
+// @Tags Search
+// @Produce json
+// @Success 200 {array} models.SynonymGroup "Группы синонимов"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/synonyms [get]
+func (h *SearchHandler) getSynonyms(c *fiber.Ctx) error {
+	groups, err := h.products.Synonyms(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(groups)
+}
+
+// @Summary Добавить группу синонимов
+// @Description Слова группы считаются равнозначными при поиске. Изменение запускает переиндексацию продуктов.
+// @Tags Search
+// @Accept json
+// @Produce json
+// @Param group body SynonymsRequest true "Слова группы"
+// @Success 201 {object} models.SynonymGroup "Группа создана"
+// @Failure 400 {object} ErrorResponse "Некорректные слова"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/synonyms [post]
+func (h *SearchHandler) addSynonyms(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req SynonymsRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	group, err := h.products.AddSynonyms(c.UserContext(), req.Terms)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(group)
+}
+
+// @Summary Заменить слова группы синонимов
+// @Tags Search
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы"
+// @Param group body SynonymsRequest true "Слова группы"
+// @Success 200 {object} models.SynonymGroup "Группа обновлена"
+// @Failure 400 {object} ErrorResponse "Некорректные слова"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Группа не найдена"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/synonyms/{id} [put]
+func (h *SearchHandler) updateSynonyms(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid synonym group id"})
+	}
+	var req SynonymsRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	group, err := h.products.UpdateSynonyms(c.UserContext(), id, req.Terms)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(group)
+}
+
+// @Summary Удалить группу синонимов
+// @Tags Search
+// @Param id path int true "ID группы"
+// @Success 204 "Группа удалена"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Группа не найдена"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/synonyms/{id} [delete]
+func (h *SearchHandler) deleteSynonyms(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid synonym group id"})
+	}
+	if err := h.products.DeleteSynonyms(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Стоп-слова поиска
+// @Tags Search
+// @Produce json
+// @Success 200 {array} string "Стоп-слова"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/stopwords [get]
+func (h *SearchHandler) getStopwords(c *fiber.Ctx) error {
+	words, err := h.products.Stopwords(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(words)
+}
+
+// @Summary Добавить стоп-слово
+// @Description Стоп-слова не индексируются и отбрасываются из поисковых запросов. Изменение запускает переиндексацию продуктов.
+// @Tags Search
+// @Param word path string true "Стоп-слово"
+// @Success 204 "Стоп-слово добавлено"
+// @Failure 400 {object} ErrorResponse "Некорректное слово"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/stopwords/{word} [put]
+func (h *SearchHandler) addStopword(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := h.products.AddStopword(c.UserContext(), c.Params("word")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Удалить стоп-слово
+// @Tags Search
+// @Param word path string true "Стоп-слово"
+// @Success 204 "Стоп-слово удалено"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Стоп-слово не найдено"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/stopwords/{word} [delete]
+func (h *SearchHandler) deleteStopword(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := h.products.DeleteStopword(c.UserContext(), c.Params("word")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Состояние поискового индекса
+// @Tags Search
+// @Produce json
+// @Success 200 {object} models.SearchIndexStatus "Версия словаря и число продуктов в очереди переиндексации"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/index [get]
+func (h *SearchHandler) getIndexStatus(c *fiber.Ctx) error {
+	status, err := h.products.SearchIndexStatus(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(status)
+}
+
+// @Summary Переиндексировать все продукты
+// @Description Переиндексация выполняется в фоне; ход виден в GET /api/search/index.
+// @Tags Search
+// @Produce json
+// @Success 202 {object} models.SearchIndexStatus "Переиндексация запущена"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/reindex [post]
+func (h *SearchHandler) reindex(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	status, err := h.products.Reindex(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(status)
+}
+
+// @Summary Множители ранжирования поиска
+// @Tags Search
+// @Produce json
+// @Success 200 {object} models.SearchBoosts "Множители"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/boosts [get]
+func (h *SearchHandler) getBoosts(c *fiber.Ctx) error {
+	boosts, err := h.products.SearchBoosts(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(boosts)
+}
+
+// @Summary Заменить множители ранжирования поиска
+// @Description Релевантность найденного продукта умножается на множители рекомендуемых продуктов, продуктов в наличии и уровня маржи. Значение 1 отключает усиление. Переиндексация не нужна.
+// @Tags Search
+// @Accept json
+// @Produce json
+// @Param boosts body models.SearchBoosts true "Множители"
+// @Success 200 {object} models.SearchBoosts "Множители сохранены"
+// @Failure 400 {object} ErrorResponse "Некорректные множители"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/boosts [put]
+func (h *SearchHandler) setBoosts(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req models.SearchBoosts
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	boosts, err := h.products.SetSearchBoosts(c.UserContext(), req)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(boosts)
+}
+
+// @Summary Рекомендуемые продукты
+// @Tags Search
+// @Produce json
+// @Success 200 {array} int "ID рекомендуемых продуктов"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/featured [get]
+func (h *SearchHandler) getFeatured(c *fiber.Ctx) error {
+	ids, err := h.products.FeaturedProducts(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(ids)
+}
+
+// @Summary Отметить продукт как рекомендуемый
+// @Tags Search
+// @Param id path int true "ID продукта"
+// @Success 204 "Продукт отмечен"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/featured/{id} [put]
+func (h *SearchHandler) featureProduct(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	if err := h.products.FeatureProduct(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Снять отметку рекомендуемого продукта
+// @Tags Search
+// @Param id path int true "ID продукта"
+// @Success 204 "Отметка снята"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Продукт не отмечен"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/featured/{id} [delete]
+func (h *SearchHandler) unfeatureProduct(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	if err := h.products.UnfeatureProduct(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Поисковые запросы без результатов
+// @Description Что покупатели ищут, но не находят: запросы, повторявшиеся за последние days дней, от самых частых. Учитывается только первая страница выдачи. Дни отсчитываются от полуночи в часовом поясе из заголовка Time-Zone (по умолчанию UTC); в нём же отдаются даты запросов.
+// @Tags Search
+// @Produce json
+// @Param Time-Zone header string false "Часовой пояс IANA, например Europe/Moscow"
+// @Param days query int false "За сколько последних дней, включая сегодняшний (по умолчанию 30)"
+// @Param limit query int false "Максимальное количество запросов (по умолчанию 100)"
+// @Success 200 {array} models.ZeroResultSearch "Запросы без результатов"
+// @Failure 400 {object} ErrorResponse "Некорректные параметры или часовой пояс"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/search/zero-results [get]
+func (h *SearchHandler) getZeroResults(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	days := c.QueryInt("days", 30)
+	if days <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid days"})
+	}
+	loc, ok := requestLocation(c)
+	if !ok {
+		return invalidTimeZone(c)
+	}
+	since := startOfDay(time.Now(), loc).AddDate(0, 0, 1-days)
+	searches, err := h.products.ZeroResultSearches(c.UserContext(), since, c.QueryInt("limit", 100))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	for i := range searches {
+		search := &searches[i]
+		search.FirstSeenAt, search.LastSeenAt = search.FirstSeenAt.In(loc), search.LastSeenAt.In(loc)
+		search.FirstSeen = search.FirstSeenAt.Format(humanTimeLayout)
+		search.LastSeen = search.LastSeenAt.Format(humanTimeLayout)
+	}
+	return c.JSON(searches)
+}
+
+// @Summary Подсказка "возможно, вы имели в виду"
+// @Description Если по запросу нашлось мало продуктов, предлагает запрос, в котором слова с опечатками заменены похожими словами каталога.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Success 200 {object} models.SearchSuggestion "Подсказка"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/search/did-you-mean [get]
+func (h *SearchHandler) didYouMean(c *fiber.Ctx) error {
+	query := c.Query("q")
+	suggestion, err := h.products.Suggest(c.UserContext(), query)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	result := models.SearchSuggestion{Query: query}
+	if suggestion != "" {
+		result.DidYouMean = &suggestion
+	}
+	return c.JSON(result)
+}
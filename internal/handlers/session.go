@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+	"server/internal/auth"
+	"server/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	// accessCookie и refreshCookie хранят токены входа в браузере, если
+	// включён AUTH_COOKIES.
+	accessCookie  = "access_token"
+	refreshCookie = "refresh_token"
+	// csrfCookie — CSRF-токен, который браузерное приложение возвращает в
+	// заголовке X-Csrf-Token.
+	csrfCookie = "csrf_token"
+)
+
+// csrfTokenKey — ключ CSRF-токена запроса в c.Locals.
+type csrfTokenKey struct{}
+
+// CookieSessions определяет вызывающего по access-токену из cookie, если
+// запрос пришёл без заголовка Authorization и ключа API, и защищает такие
+// запросы от CSRF: изменяющий запрос с cookie сессии должен передать в
+// заголовке X-Csrf-Token значение cookie csrf_token (его же возвращает
+// GET /api/auth/csrf), иначе — 403. Вход, регистрация и OAuth2 cookie
+// сессии не используют и проверку не проходят. Ставится после Authenticate
+// и AuthenticateAPIKey.
+func CookieSessions(authenticator auth.Authenticator) fiber.Handler {
+	protect := csrf.New(csrf.Config{
+		CookieName:        csrfCookie,
+		CookiePath:        "/",
+		CookieSameSite:    fiber.CookieSameSiteStrictMode,
+		CookieSessionOnly: true,
+		Expiration:        24 * time.Hour,
+		ContextKey:        csrfTokenKey{},
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Invalid CSRF token"})
+		},
+	})
+	return func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) != "" || c.Get(HeaderAPIKey) != "" ||
+			(c.Cookies(accessCookie) == "" && c.Cookies(refreshCookie) == "") {
+			return c.Next()
+		}
+		switch path := c.Path(); {
+		case path == "/api/auth/login", path == "/api/auth/register", strings.HasPrefix(path, "/api/auth/oauth"):
+			return c.Next()
+		}
+		if token := c.Cookies(accessCookie); token != "" {
+			if principal, err := authenticator.Authenticate(token); err == nil {
+				c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+			}
+		}
+		return protect(c)
+	}
+}
+
+// setSessionCookies кладёт пару токенов в HttpOnly-cookie: access-токен —
+// до истечения его срока, refresh-токен — до закрытия браузера и только
+// для /api/auth.
+func setSessionCookies(c *fiber.Ctx, tokens models.Tokens) {
+	secure := c.Protocol() == "https"
+	c.Cookie(&fiber.Cookie{
+		Name: accessCookie, Value: tokens.AccessToken, Path: "/api", Expires: tokens.ExpiresAt,
+		Secure: secure, HTTPOnly: true, SameSite: fiber.CookieSameSiteStrictMode,
+	})
+	c.Cookie(&fiber.Cookie{
+		Name: refreshCookie, Value: tokens.RefreshToken, Path: "/api/auth", SessionOnly: true,
+		Secure: secure, HTTPOnly: true, SameSite: fiber.CookieSameSiteStrictMode,
+	})
+}
+
+func clearSessionCookies(c *fiber.Ctx) {
+	for _, cookie := range []*fiber.Cookie{{Name: accessCookie, Path: "/api"}, {Name: refreshCookie, Path: "/api/auth"}} {
+		cookie.Expires = time.Unix(0, 0)
+		cookie.HTTPOnly = true
+		c.Cookie(cookie)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+)
+
+// tenantHeader выбирает арендатора, чью витрину настраивает или читает
+// запрос; без заголовка — service.DefaultTenant.
+const tenantHeader = "X-Tenant"
+
+// SettingsHandler отдаёт и сохраняет настройки витрин арендаторов.
+type SettingsHandler struct {
+	settings *service.SettingsService
+}
+
+func NewSettingsHandler(settings *service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{settings: settings}
+}
+
+func (h *SettingsHandler) Register(router fiber.Router) {
+	router.Get("/api/settings", h.getSettings)
+	router.Put("/api/settings", h.putSettings)
+}
+
+// @Summary Настройки витрины
+// @Description Логотип, цвет оформления, валюта, язык по умолчанию и контакты витрины арендатора из заголовка X-Tenant. У арендатора без настроек валюта и язык — значения сервера.
+// @Tags Settings
+// @Produce json
+// @Param X-Tenant header string false "Арендатор (по умолчанию default)"
+// @Success 200 {object} models.StorefrontSettings "Настройки витрины"
+// @Failure 400 {object} ErrorResponse "Некорректное имя арендатора"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/settings [get]
+func (h *SettingsHandler) getSettings(c *fiber.Ctx) error {
+	settings, err := h.settings.Get(c.UserContext(), c.Get(tenantHeader, service.DefaultTenant))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(settings)
+}
+
+// @Summary Сохранить настройки витрины
+// @Description Заменяет настройки витрины арендатора из заголовка X-Tenant целиком; пустое поле означает «не задано». Требуется токен администратора.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Param X-Tenant header string false "Арендатор (по умолчанию default)"
+// @Param settings body models.StorefrontSettings true "Настройки витрины"
+// @Success 200 {object} models.StorefrontSettings "Настройки сохранены"
+// @Failure 400 {object} ErrorResponse "Некорректные настройки"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/settings [put]
+func (h *SettingsHandler) putSettings(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var settings models.StorefrontSettings
+	if err := parseBody(c, &settings); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	saved, err := h.settings.Put(c.UserContext(), c.Get(tenantHeader, service.DefaultTenant), settings)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(saved)
+}
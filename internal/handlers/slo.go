@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/slo"
+)
+
+// SLOHandler отдаёт соблюдение целей уровня обслуживания.
+type SLOHandler struct {
+	tracker *slo.Tracker
+}
+
+func NewSLOHandler(tracker *slo.Tracker) *SLOHandler {
+	return &SLOHandler{tracker: tracker}
+}
+
+func (h *SLOHandler) Register(router fiber.Router) {
+	router.Get("/api/admin/slo", h.getSLO)
+}
+
+// @Summary Соблюдение SLO
+// @Description Для каждого маршрута с целью из SLO_ROUTES: доля хороших запросов (без 5xx и не дольше порога задержки) за окно SLO_WINDOW, остаток бюджета ошибок и скорость его сгорания за час и 5 минут. Счётчики хранятся в памяти экземпляра и обнуляются при перезапуске.
+// @Tags SLO
+// @Produce json
+// @Success 200 {array} slo.Status "Успешный ответ"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Router /api/admin/slo [get]
+func (h *SLOHandler) getSLO(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(h.tracker.Statuses())
+}
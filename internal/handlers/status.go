@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	"html/template"
+	"server/internal/models"
+	"server/internal/service"
+	"time"
+)
+
+// statusStyle — оформление страницы статуса; разрешено в
+// Content-Security-Policy по хешу, как у виджета продукта.
+const statusStyle = `
+body{max-width:720px;margin:40px auto;padding:0 16px;font:15px/1.5 system-ui,sans-serif;color:#222}
+h1{font-size:22px}
+.banner{padding:12px 16px;border-radius:8px;font-weight:600;color:#fff}
+.operational{background:#1e7d32}.degraded{background:#b26a00}.outage{background:#b3261e}
+table{width:100%;border-collapse:collapse;margin:16px 0}
+td,th{text-align:left;padding:6px 0;border-bottom:1px solid #eee}
+.muted{color:#666;font-size:13px}
+`
+
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"label":    statusLabel,
+	"datetime": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 MST") },
+	"duration": func(seconds int64) string { return (time.Duration(seconds) * time.Second).String() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Service status</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+<h1>Service status</h1>
+<div class="banner {{.Status.Status}}">{{label .Status.Status}}</div>
+<table>
+<tr><th>Component</th><th>Status</th></tr>
+{{range .Status.Dependencies}}<tr><td>{{.Name}}</td><td>{{label .Status}}</td></tr>
+{{end}}</table>
+<h2>Recent incidents</h2>
+{{if .Status.Incidents}}<table>
+<tr><th>Affected</th><th>Started</th><th>Resolved</th></tr>
+{{range .Status.Incidents}}<tr><td>{{.Route}}</td><td>{{datetime .StartedAt}}</td><td>{{with .ResolvedAt}}{{datetime .}}{{else}}Ongoing{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No incidents reported.</p>{{end}}
+<p class="muted">Up for {{duration .Status.UptimeSeconds}}. Checked at {{datetime .Status.CheckedAt}}.</p>
+</body>
+</html>
+`))
+
+var statusPolicy = "default-src 'none'; style-src " + cspHash(statusStyle) + "; base-uri 'none'; form-action 'none'"
+
+func statusLabel(status string) string {
+	switch status {
+	case models.StatusOperational:
+		return "Operational"
+	case models.StatusDegraded:
+		return "Degraded performance"
+	default:
+		return "Outage"
+	}
+}
+
+// StatusHandler отдаёт публичную страницу статуса сервиса.
+type StatusHandler struct {
+	status *service.StatusService
+}
+
+func NewStatusHandler(status *service.StatusService) *StatusHandler {
+	return &StatusHandler{status: status}
+}
+
+func (h *StatusHandler) Register(router fiber.Router) {
+	router.Get("/status", h.getStatus)
+}
+
+// @Summary Страница статуса
+// @Description Публичное состояние сервиса: время работы экземпляра, состояние зависимостей и инциденты — периоды быстрого сгорания бюджета ошибок SLO — за STATUS_INCIDENT_WINDOW. Отвечает HTML-страницей, а при format=json или Accept: application/json — JSON. Состояние собирается не чаще раза в 10 секунд.
+// @Tags Health
+// @Produce html
+// @Produce json
+// @Param format query string false "json — ответ в JSON"
+// @Success 200 {object} models.ServiceStatus "Состояние сервиса"
+// @Router /status [get]
+func (h *StatusHandler) getStatus(c *fiber.Ctx) error {
+	status := h.status.Status(c.UserContext())
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	if c.Query("format") == "json" || c.Accepts(fiber.MIMETextHTML, fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
+		return c.JSON(status)
+	}
+	var page bytes.Buffer
+	err := statusPage.Execute(&page, struct {
+		Status models.ServiceStatus
+		Style  template.CSS
+	}{status, template.CSS(statusStyle)})
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentSecurityPolicy, statusPolicy)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Type("html", "utf-8")
+	return c.Send(page.Bytes())
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"server/internal/jsonnaming"
+	"strings"
+)
+
+// strictBodyLocal — ключ Locals, под которым StrictBodies передаёт
+// обработчикам кодек для строгой проверки тела запроса.
+const strictBodyLocal = "strict-body"
+
+// FieldErrorResponse — ответ 422 на тело запроса, которое в строгом режиме
+// не подходит к модели.
+type FieldErrorResponse struct {
+	Error  string                  `json:"error" example:"Request body does not match the model"`
+	Fields []jsonnaming.FieldError `json:"fields"`
+}
+
+// StrictBodies включает строгий разбор JSON-тел: неизвестные поля и
+// значения не того типа отклоняются с 422 и путями полей, а не
+// отбрасываются молча. always включает его для всех запросов; клиент может
+// выбрать режим сам заголовком Prefer: handling=strict или
+// handling=lenient (RFC 7240).
+func StrictBodies(naming *jsonnaming.Codec, always bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		strict := always
+		for _, preference := range strings.Split(c.Get("Prefer"), ",") {
+			switch strings.ToLower(strings.TrimSpace(preference)) {
+			case "handling=strict":
+				strict = true
+				c.Set("Preference-Applied", "handling=strict")
+			case "handling=lenient":
+				strict = false
+				c.Set("Preference-Applied", "handling=lenient")
+			}
+		}
+		if strict {
+			c.Locals(strictBodyLocal, naming)
+		}
+		return c.Next()
+	}
+}
+
+// parseBody разбирает тело запроса как BodyParser, а в строгом режиме
+// сначала сверяет JSON с моделью out; расхождения — jsonnaming.FieldErrors.
+func parseBody(c *fiber.Ctx, out interface{}) error {
+	if naming, ok := c.Locals(strictBodyLocal).(*jsonnaming.Codec); ok && isJSON(string(c.Request().Header.ContentType())) {
+		if err := naming.Check(c.Body(), out); err != nil {
+			return err
+		}
+	}
+	return c.BodyParser(out)
+}
+
+// invalidBody отвечает на тело, которое не удалось разобрать: 422 с полями
+// в строгом режиме, иначе 400 с message.
+func invalidBody(c *fiber.Ctx, err error, message string) error {
+	var fields jsonnaming.FieldErrors
+	if errors.As(err, &fields) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(FieldErrorResponse{
+			Error: "Request body does not match the model", Fields: fields,
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: message})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+const (
+	// timeZoneHeader — часовой пояс клиента в виде имени IANA
+	// (Europe/Moscow), в котором отчёты показывают даты для людей.
+	timeZoneHeader = "Time-Zone"
+	// humanTimeLayout — формат дат для людей в отчётах.
+	humanTimeLayout = "2 Jan 2006 15:04 MST"
+)
+
+// requestLocation возвращает часовой пояс из заголовка Time-Zone; без
+// заголовка — UTC. Время сервера не используется никогда, поэтому Local
+// тоже отвергается.
+func requestLocation(c *fiber.Ctx) (*time.Location, bool) {
+	name := c.Get(timeZoneHeader)
+	if name == "" {
+		return time.UTC, true
+	}
+	if name == "Local" {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+func invalidTimeZone(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid Time-Zone header: expected an IANA time zone name such as Europe/Moscow"})
+}
+
+// startOfDay возвращает полночь дня t в часовом поясе loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/models"
+	"server/internal/service"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// requestLocales возвращает желаемые локали запроса в порядке предпочтения:
+// сначала ?lang=, затем Accept-Language с учётом q-весов.
+func requestLocales(c *fiber.Ctx) []string {
+	if lang := c.Query("lang"); lang != "" {
+		return service.LocaleCandidates(lang)
+	}
+
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(c.Get(fiber.HeaderAcceptLanguage), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		tags = append(tags, weighted{locale: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	var locales []string
+	for _, tag := range tags {
+		locales = append(locales, service.LocaleCandidates(tag.locale)...)
+	}
+	return locales
+}
+
+// @Summary Список переводов продукта
+// @Tags Translations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {array} models.ProductTranslation "Успешный ответ"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/translations [get]
+func (h *ProductHandler) getProductTranslations(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	translations, err := h.products.Translations(productID)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(translations)
+}
+
+// @Summary Создать или обновить перевод продукта
+// @Tags Translations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param locale path string true "Локаль (например, en или en-us)"
+// @Param translation body models.ProductTranslation true "Переведённые поля"
+// @Success 200 {object} models.ProductTranslation "Перевод сохранён"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 404 {object} ErrorResponse "Продукт не найден"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/translations/{locale} [put]
+func (h *ProductHandler) putProductTranslation(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	var t models.ProductTranslation
+	if err := parseBody(c, &t); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	t.ProductID = productID
+	t.Locale = c.Params("locale")
+
+	t, err = h.products.PutTranslation(t)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(t)
+}
+
+// @Summary Удалить перевод продукта
+// @Tags Translations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param locale path string true "Локаль"
+// @Success 200 {object} map[string]string "Перевод удалён"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/products/{id}/translations/{locale} [delete]
+func (h *ProductHandler) deleteProductTranslation(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	if err := h.products.DeleteTranslation(productID, c.Params("locale")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Translation deleted successfully"})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Список удалённых продуктов, доступных для восстановления
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.TrashedProduct "Успешный ответ"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/trash [get]
+func (h *ProductHandler) getTrash(c *fiber.Ctx) error {
+	trashed, err := h.products.Trash()
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(trashed)
+}
+
+// @Summary Восстановить удалённый продукт
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {object} map[string]string "Продукт восстановлен"
+// @Failure 401 {object} ErrorResponse "Нет токена: запись продуктов требует аутентификации"
+// @Failure 403 {object} ErrorResponse "Роль не даёт права менять продукты"
+// @Failure 404 {object} ErrorResponse "Продукта нет в корзине или окно восстановления истекло"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/trash/{id}/restore [post]
+func (h *ProductHandler) restoreProduct(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid product id"})
+	}
+	if err := h.products.Restore(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Product restored successfully"})
+}
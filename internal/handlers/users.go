@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/models"
+	"server/internal/service"
+	"strconv"
+)
+
+// UserHandler выдаёт токены пользователям API по паролю, даёт им вести свой
+// профиль и управляет пользователями.
+type UserHandler struct {
+	users *service.UserService
+	opts  UserHandlerOptions
+}
+
+type UserHandlerOptions struct {
+	// Registration открывает регистрацию без администратора.
+	Registration bool
+	// Cookies кладёт выданные токены ещё и в HttpOnly-cookie для
+	// браузерного приложения; см. CookieSessions.
+	Cookies bool
+}
+
+func NewUserHandler(users *service.UserService, opts UserHandlerOptions) *UserHandler {
+	return &UserHandler{users: users, opts: opts}
+}
+
+func (h *UserHandler) Register(router fiber.Router) {
+	if h.opts.Registration {
+		router.Post("/api/auth/register", h.register)
+	}
+	if h.opts.Cookies {
+		router.Get("/api/auth/csrf", h.csrfToken)
+	}
+	router.Post("/api/auth/login", h.login)
+	router.Post("/api/auth/refresh", h.refresh)
+	router.Post("/api/auth/logout", h.logout)
+	router.Get("/api/users/me", h.getProfile)
+	router.Put("/api/users/me", h.updateProfile)
+	router.Get("/api/users/me/identities", h.listIdentities)
+	router.Get("/api/admin/users", h.listUsers)
+	router.Post("/api/admin/users", h.createUser)
+	router.Delete("/api/admin/users/:id", h.deleteUser)
+	router.Delete("/api/admin/users/:id/sessions", h.revokeSessions)
+}
+
+type RegisterRequest struct {
+	// Username — 3-32 латинские буквы, цифры, '.', '-' или '_'; под этим
+	// именем пользователь пишет в чат.
+	Username    string `json:"username" example:"katya"`
+	Email       string `json:"email" example:"katya@example.com"`
+	DisplayName string `json:"display_name,omitempty" example:"Катя"`
+	// Password — от 8 до 72 байт.
+	Password string `json:"password" example:"correct horse battery staple"`
+}
+
+type LoginRequest struct {
+	// Username — имя или email.
+	Username string `json:"username" example:"editor"`
+	Password string `json:"password" example:"correct horse battery staple"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" example:"editor"`
+	// Password — от 8 до 72 байт.
+	Password string `json:"password" example:"correct horse battery staple"`
+	// Role — admin, editor, moderator или viewer; по умолчанию viewer.
+	Role string `json:"role,omitempty" example:"editor"`
+}
+
+// UpdateProfileRequest — изменения профиля; отсутствующие поля не меняются.
+type UpdateProfileRequest struct {
+	// Email — пустая строка удаляет адрес.
+	Email       *string `json:"email,omitempty" example:"katya@example.com"`
+	DisplayName *string `json:"display_name,omitempty" example:"Катя"`
+	Password    *string `json:"password,omitempty"`
+	// CurrentPassword нужен для смены email или пароля.
+	CurrentPassword string `json:"current_password,omitempty"`
+}
+
+// @Summary Зарегистрироваться
+// @Description Заводит пользователя с ролью viewer; токены выдаёт /api/auth/login. Доступно, если API_REGISTRATION не выключен.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param user body RegisterRequest true "Имя, email и пароль"
+// @Success 201 {object} models.User "Пользователь заведён"
+// @Failure 400 {object} ErrorResponse "Некорректные имя, email или пароль"
+// @Failure 409 {object} ErrorResponse "Имя или email заняты"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Self-registration of viewer accounts."}]
+// @Router /api/auth/register [post]
+func (h *UserHandler) register(c *fiber.Ctx) error {
+	var req RegisterRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	user, err := h.users.Register(c.UserContext(), req.Username, req.Email, req.DisplayName, req.Password)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(user)
+}
+
+// @Summary Войти по паролю
+// @Description Возвращает access-токен (JWT) для заголовка Authorization: Bearer и refresh-токен для его продления. С AUTH_COOKIES токены кладутся ещё и в HttpOnly-cookie access_token и refresh_token, и браузерное приложение может не передавать заголовок Authorization.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param credentials body LoginRequest true "Имя и пароль"
+// @Success 200 {object} models.Tokens "Токены"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Неверное имя или пароль"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "changed", "description": "username also accepts the account email."}, {"version": "1.1", "date": "2026-10-15", "type": "added", "description": "With AUTH_COOKIES tokens are also set as HttpOnly cookies."}]
+// @Router /api/auth/login [post]
+func (h *UserHandler) login(c *fiber.Ctx) error {
+	var req LoginRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	tokens, err := h.users.Login(c.UserContext(), req.Username, req.Password)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return h.sendTokens(c, tokens)
+}
+
+// @Summary Продлить токены
+// @Description Обменивает refresh-токен на новую пару токенов. Refresh-токен действует один раз; повторное предъявление уже обменянного токена считается кражей и отзывает всю сессию вместе с её access-токенами, так что клиент должен сохранять новый refresh-токен до следующего обмена.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest false "Refresh-токен; с AUTH_COOKIES можно не передавать"
+// @Success 200 {object} models.Tokens "Новые токены"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Refresh-токен недействителен, истёк или уже использован"
+// @Failure 403 {object} ErrorResponse "Вход с cookie: нет CSRF-токена"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "changed", "description": "Presenting an already rotated refresh token revokes the whole session, including its access tokens."}, {"version": "1.1", "date": "2026-10-15", "type": "changed", "description": "With AUTH_COOKIES the body may be omitted: the refresh_token cookie is used."}]
+// @Router /api/auth/refresh [post]
+func (h *UserHandler) refresh(c *fiber.Ctx) error {
+	refreshToken, err := h.refreshToken(c)
+	if err != nil || refreshToken == "" {
+		return invalidBody(c, err, "Invalid request")
+	}
+	tokens, err := h.users.Refresh(c.UserContext(), refreshToken)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return h.sendTokens(c, tokens)
+}
+
+// @Summary Выйти
+// @Description Завершает сессию refresh-токена: её refresh- и access-токены, а также access-токен из заголовка Authorization, если он есть, перестают действовать сразу.
+// @Tags Auth
+// @Accept json
+// @Param request body RefreshRequest false "Refresh-токен; с AUTH_COOKIES можно не передавать"
+// @Success 204 "Токен отозван"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 403 {object} ErrorResponse "Вход с cookie: нет CSRF-токена"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "changed", "description": "Logout also revokes the session's access tokens."}, {"version": "1.1", "date": "2026-10-15", "type": "changed", "description": "With AUTH_COOKIES the body may be omitted and the session cookies are cleared."}]
+// @Router /api/auth/logout [post]
+func (h *UserHandler) logout(c *fiber.Ctx) error {
+	refreshToken, err := h.refreshToken(c)
+	if err != nil || refreshToken == "" {
+		return invalidBody(c, err, "Invalid request")
+	}
+	principal, _ := auth.FromContext(c.UserContext())
+	if err := h.users.Logout(c.UserContext(), refreshToken, principal.TokenID); err != nil {
+		return writeServiceError(c, err)
+	}
+	if h.opts.Cookies {
+		clearSessionCookies(c)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// refreshToken берёт refresh-токен из тела запроса, а с cookie-сессиями и
+// без тела — из cookie.
+func (h *UserHandler) refreshToken(c *fiber.Ctx) (string, error) {
+	if h.opts.Cookies && len(c.Body()) == 0 {
+		return c.Cookies(refreshCookie), nil
+	}
+	var req RefreshRequest
+	if err := parseBody(c, &req); err != nil {
+		return "", err
+	}
+	return req.RefreshToken, nil
+}
+
+// sendTokens отдаёт выданную пару токенов, а с cookie-сессиями ещё и
+// кладёт её в cookie.
+func (h *UserHandler) sendTokens(c *fiber.Ctx, tokens models.Tokens) error {
+	if h.opts.Cookies {
+		setSessionCookies(c, tokens)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(tokens)
+}
+
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// @Summary CSRF-токен
+// @Description Для входа с cookie (AUTH_COOKIES): изменяющие запросы с cookie сессии передают этот токен в заголовке X-Csrf-Token. Он же лежит в cookie csrf_token.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} CSRFTokenResponse "Токен"
+// @Failure 401 {object} ErrorResponse "Нет cookie сессии"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "CSRF token for cookie sessions."}]
+// @Router /api/auth/csrf [get]
+func (h *UserHandler) csrfToken(c *fiber.Ctx) error {
+	token, ok := c.Locals(csrfTokenKey{}).(string)
+	if !ok {
+		return writeServiceError(c, auth.ErrUnauthenticated)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(CSRFTokenResponse{CSRFToken: token})
+}
+
+// @Summary Мой профиль
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} models.User "Профиль"
+// @Failure 401 {object} ErrorResponse "Нет токена"
+// @Failure 404 {object} ErrorResponse "Токен не принадлежит пользователю API"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Profile of the calling API user."}]
+// @Router /api/users/me [get]
+func (h *UserHandler) getProfile(c *fiber.Ctx) error {
+	id, err := currentUserID(c)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	user, err := h.users.Get(c.UserContext(), id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(user)
+}
+
+// @Summary Мои учётные записи у провайдеров
+// @Description Учётные записи Google и GitHub, через которые пользователь входит.
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} models.UserIdentity "Учётные записи"
+// @Failure 401 {object} ErrorResponse "Нет токена"
+// @Failure 404 {object} ErrorResponse "Токен не принадлежит пользователю API"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "OAuth2 identities linked to the calling API user."}]
+// @Router /api/users/me/identities [get]
+func (h *UserHandler) listIdentities(c *fiber.Ctx) error {
+	id, err := currentUserID(c)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	identities, err := h.users.Identities(c.UserContext(), id)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(identities)
+}
+
+// @Summary Изменить мой профиль
+// @Description Смена email или пароля требует current_password. Смена пароля завершает все сессии пользователя, включая текущую.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param profile body UpdateProfileRequest true "Изменения профиля"
+// @Success 200 {object} models.User "Профиль"
+// @Failure 400 {object} ErrorResponse "Некорректные email, имя или пароль"
+// @Failure 401 {object} ErrorResponse "Нет токена"
+// @Failure 403 {object} ErrorResponse "Неверный текущий пароль"
+// @Failure 404 {object} ErrorResponse "Токен не принадлежит пользователю API"
+// @Failure 409 {object} ErrorResponse "Email занят"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Update email, display name or password of the calling API user."}]
+// @Router /api/users/me [put]
+func (h *UserHandler) updateProfile(c *fiber.Ctx) error {
+	id, err := currentUserID(c)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	var req UpdateProfileRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	user, err := h.users.UpdateProfile(c.UserContext(), id, service.ProfileUpdate{
+		Email: req.Email, DisplayName: req.DisplayName, Password: req.Password, CurrentPassword: req.CurrentPassword,
+	})
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(user)
+}
+
+// currentUserID возвращает ID пользователя API, которому выдан токен
+// запроса. У административных токенов и ключей API профиля нет.
+func currentUserID(c *fiber.Ctx) (int, error) {
+	principal, ok := auth.FromContext(c.UserContext())
+	if !ok {
+		return 0, auth.ErrUnauthenticated
+	}
+	id, err := strconv.Atoi(principal.Subject)
+	if err != nil || principal.Scopes != nil {
+		return 0, service.ErrNotFound
+	}
+	return id, nil
+}
+
+// @Summary Пользователи API
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} models.User "Пользователи"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/users [get]
+func (h *UserHandler) listUsers(c *fiber.Ctx) error {
+	if err := auth.RequirePermission(c.UserContext(), auth.PermManageUsers); err != nil {
+		return writeServiceError(c, err)
+	}
+	users, err := h.users.List(c.UserContext())
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(users)
+}
+
+// @Summary Завести пользователя API
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param user body CreateUserRequest true "Имя, пароль и роль"
+// @Success 201 {object} models.User "Пользователь заведён"
+// @Failure 400 {object} ErrorResponse "Некорректные имя, пароль или роль"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} ErrorResponse "Имя занято"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/users [post]
+func (h *UserHandler) createUser(c *fiber.Ctx) error {
+	if err := auth.RequirePermission(c.UserContext(), auth.PermManageUsers); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req CreateUserRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	user, err := h.users.Create(c.UserContext(), req.Username, req.Password, req.Role)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(user)
+}
+
+// @Summary Удалить пользователя API
+// @Description Все сессии пользователя завершаются сразу.
+// @Tags Auth
+// @Param id path int true "ID пользователя"
+// @Success 204 "Пользователь удалён"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/admin/users/{id} [delete]
+func (h *UserHandler) deleteUser(c *fiber.Ctx) error {
+	if err := auth.RequirePermission(c.UserContext(), auth.PermManageUsers); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid user id"})
+	}
+	if err := h.users.Delete(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Завершить сессии пользователя API
+// @Description Отзывает все refresh-токены пользователя и выданные с ними access-токены, например если его учётные данные скомпрометированы. Пароль не меняется.
+// @Tags Auth
+// @Param id path int true "ID пользователя"
+// @Success 204 "Сессии завершены"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @x-changelog [{"version": "1.1", "date": "2026-10-15", "type": "added", "description": "Revoke all sessions of an API user."}]
+// @Router /api/admin/users/{id}/sessions [delete]
+func (h *UserHandler) revokeSessions(c *fiber.Ctx) error {
+	if err := auth.RequirePermission(c.UserContext(), auth.PermManageUsers); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid user id"})
+	}
+	if err := h.users.RevokeSessions(c.UserContext(), id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/models"
+	"server/internal/service"
+)
+
+// WebhookHandler управляет подписками на события и их тестовой доставкой.
+type WebhookHandler struct {
+	webhooks *service.WebhookService
+}
+
+func NewWebhookHandler(webhooks *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+func (h *WebhookHandler) Register(router fiber.Router) {
+	router.Get("/api/webhooks/events", h.getEventCatalog)
+	router.Get("/api/webhooks", h.listWebhooks)
+	router.Post("/api/webhooks", h.createWebhook)
+	router.Get("/api/webhooks/by-key/:key", h.getWebhookByKey)
+	router.Put("/api/webhooks/by-key/:key", h.putWebhook)
+	router.Delete("/api/webhooks/by-key/:key", h.deleteWebhookByKey)
+	router.Delete("/api/webhooks/:id", h.deleteWebhook)
+	router.Post("/api/webhooks/:id/test", h.testWebhook)
+}
+
+// PutWebhookRequest — полное желаемое состояние вебхука.
+type PutWebhookRequest struct {
+	URL    string   `json:"url" example:"https://crm.example.com/hooks/catalog"`
+	Events []string `json:"events" example:"product.created"`
+	// Secret — секрет подписи (16-64 символа); без него у нового вебхука
+	// секрет генерируется, у существующего остаётся прежним.
+	Secret string `json:"secret,omitempty"`
+	// Active по умолчанию true.
+	Active *bool `json:"active,omitempty"`
+}
+
+type TestWebhookRequest struct {
+	Event string `json:"event" example:"product.created"`
+}
+
+// @Summary Каталог типов событий вебхуков
+// @Description Для каждого типа события возвращает описание, JSON Schema поля data и пример.
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} events.Definition "Успешный ответ"
+// @Router /api/webhooks/events [get]
+func (h *WebhookHandler) getEventCatalog(c *fiber.Ctx) error {
+	return c.JSON(events.Catalog)
+}
+
+// @Summary Список вебхуков
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook "Успешный ответ"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks [get]
+func (h *WebhookHandler) listWebhooks(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	webhooks, err := h.webhooks.List()
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(webhooks)
+}
+
+// @Summary Зарегистрировать вебхук
+// @Description Секрет для проверки подписи возвращается только в этом ответе. Необязательный key задаёт постоянный внешний ключ; занятый ключ — 409. URL должен вести на публичный адрес: доставки во внутреннюю сеть и перенаправления не выполняются.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body models.Webhook true "URL получателя и типы событий"
+// @Success 201 {object} models.Webhook "Вебхук создан"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} ErrorResponse "Ключ занят"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks [post]
+func (h *WebhookHandler) createWebhook(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var webhook models.Webhook
+	if err := parseBody(c, &webhook); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	created, err := h.webhooks.Create(webhook)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// @Summary Вебхук по ключу
+// @Tags Webhooks
+// @Produce json
+// @Param key path string true "Внешний ключ вебхука"
+// @Success 200 {object} models.Webhook "Вебхук"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Вебхук не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks/by-key/{key} [get]
+func (h *WebhookHandler) getWebhookByKey(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	webhook, err := h.webhooks.GetByKey(c.Params("key"))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(webhook)
+}
+
+// @Summary Создать или заменить вебхук по ключу
+// @Description Идемпотентно приводит вебхук с постоянным внешним ключом к состоянию из тела запроса — для Terraform и других декларативных инструментов. Незаданные поля получают значения по умолчанию, кроме секрета: без него секрет существующего вебхука не меняется, а сгенерированный для нового возвращается только в ответе на создание.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param key path string true "Внешний ключ вебхука"
+// @Param webhook body PutWebhookRequest true "Желаемое состояние вебхука"
+// @Success 200 {object} models.Webhook "Вебхук заменён"
+// @Success 201 {object} models.Webhook "Вебхук создан"
+// @Failure 400 {object} ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks/by-key/{key} [put]
+func (h *WebhookHandler) putWebhook(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	var req PutWebhookRequest
+	if err := parseBody(c, &req); err != nil {
+		return invalidBody(c, err, "Invalid request")
+	}
+	webhook := models.Webhook{URL: req.URL, Events: req.Events, Secret: req.Secret, Active: true}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	put, created, err := h.webhooks.Put(c.Params("key"), webhook)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if created {
+		return c.Status(fiber.StatusCreated).JSON(put)
+	}
+	return c.JSON(put)
+}
+
+// @Summary Удалить вебхук по ключу
+// @Tags Webhooks
+// @Param key path string true "Внешний ключ вебхука"
+// @Success 204 "Вебхук удалён"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Вебхук не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks/by-key/{key} [delete]
+func (h *WebhookHandler) deleteWebhookByKey(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := h.webhooks.DeleteByKey(c.Params("key")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary Удалить вебхук
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "ID вебхука"
+// @Success 200 {object} map[string]string "Вебхук удалён"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Вебхук не найден"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks/{id} [delete]
+func (h *WebhookHandler) deleteWebhook(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid webhook id"})
+	}
+	if err := h.webhooks.Delete(id); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Webhook deleted successfully"})
+}
+
+// @Summary Отправить тестовое событие вебхуку
+// @Description Синхронно доставляет подписанный пример события и возвращает результат доставки.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "ID вебхука"
+// @Param request body TestWebhookRequest false "Тип события (по умолчанию product.created)"
+// @Success 200 {object} models.WebhookDelivery "Результат доставки"
+// @Failure 400 {object} ErrorResponse "Неизвестный тип события"
+// @Failure 401 {object} ErrorResponse "Нет токена администратора"
+// @Failure 403 {object} ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} ErrorResponse "Вебхук не найден"
+// @Failure 422 {object} FieldErrorResponse "Строгий режим: неизвестные поля или значения не того типа"
+// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
+// @Router /api/webhooks/{id}/test [post]
+func (h *WebhookHandler) testWebhook(c *fiber.Ctx) error {
+	if err := auth.Require(c.UserContext(), auth.RoleAdmin); err != nil {
+		return writeServiceError(c, err)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid webhook id"})
+	}
+	req := TestWebhookRequest{Event: events.ProductCreated}
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return invalidBody(c, err, "Invalid request")
+		}
+	}
+
+	delivery, err := h.webhooks.SendTest(id, req.Event)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(delivery)
+}
@@ -0,0 +1,211 @@
+package imgproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Допустимые значения параметров обработки.
+var (
+	resizeTypes = map[string]bool{"fit": true, "fill": true, "fill-down": true, "force": true, "auto": true}
+	formats     = map[string]bool{"jpg": true, "png": true, "webp": true, "avif": true, "gif": true}
+)
+
+// DefaultFormats — современные форматы, в которых отдаются варианты пресетов,
+// в порядке предпочтения: браузер берёт первый поддерживаемый.
+var DefaultFormats = []string{"avif", "webp"}
+
+// ContentType возвращает MIME-тип формата imgproxy.
+func ContentType(format string) string {
+	if format == "jpg" {
+		return "image/jpeg"
+	}
+	return "image/" + format
+}
+
+// MaxSide ограничивает размеры, которые можно запросить: подписанные ссылки
+// на гигантские изображения нагружали бы imgproxy за наш счёт.
+const MaxSide = 4096
+
+// Options — параметры обработки изображения. Нулевая ширина или высота
+// означает "по пропорциям", пустой формат — исходный формат.
+type Options struct {
+	Width   int
+	Height  int
+	Resize  string
+	Format  string
+	Quality int
+}
+
+// Validate проверяет параметры, пришедшие от клиента.
+func (o Options) Validate() error {
+	if o.Width < 0 || o.Width > MaxSide || o.Height < 0 || o.Height > MaxSide {
+		return fmt.Errorf("width and height must be between 0 and %d", MaxSide)
+	}
+	if o.Resize != "" && !resizeTypes[o.Resize] {
+		return fmt.Errorf("unsupported resize type %q", o.Resize)
+	}
+	if o.Format != "" && !formats[o.Format] {
+		return fmt.Errorf("unsupported format %q", o.Format)
+	}
+	if o.Quality < 0 || o.Quality > 100 {
+		return errors.New("quality must be between 0 and 100")
+	}
+	return nil
+}
+
+// Preset — именованный набор параметров, ссылки на который отдаются вместе
+// с вложением.
+type Preset struct {
+	Name string
+	Options
+}
+
+// DefaultPresets — варианты изображений, которые получает каждое вложение-картинка.
+var DefaultPresets = []Preset{
+	{Name: "thumbnail", Options: Options{Width: 160, Height: 160, Resize: "fill"}},
+	{Name: "card", Options: Options{Width: 480, Height: 480, Resize: "fit"}},
+	{Name: "full", Options: Options{Width: 1600, Height: 1600, Resize: "fit"}},
+}
+
+// Signer строит подписанные ссылки imgproxy. Ключ и соль не покидают сервер:
+// клиент получает только готовые ссылки.
+type Signer struct {
+	baseURL string
+	key     []byte
+	salt    []byte
+	size    int
+	presets []Preset
+	formats []string
+}
+
+type Config struct {
+	// BaseURL — адрес imgproxy или CDN перед ним.
+	BaseURL string
+	// Key и Salt — IMGPROXY_KEY и IMGPROXY_SALT в hex. Если оба пусты, ссылки
+	// не подписываются (imgproxy без проверки подписи).
+	Key  string
+	Salt string
+	// SignatureSize — IMGPROXY_SIGNATURE_SIZE; 0 — полная подпись (32 байта).
+	SignatureSize int
+	// Presets — варианты для каждого изображения; nil — DefaultPresets.
+	Presets []Preset
+	// Formats — современные форматы вариантов; nil — DefaultFormats.
+	Formats []string
+}
+
+func NewSigner(cfg Config) (*Signer, error) {
+	key, err := hex.DecodeString(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("imgproxy key: %w", err)
+	}
+	salt, err := hex.DecodeString(cfg.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("imgproxy salt: %w", err)
+	}
+	if (len(key) == 0) != (len(salt) == 0) {
+		return nil, errors.New("imgproxy key and salt must be set together")
+	}
+	size := cfg.SignatureSize
+	if size <= 0 || size > sha256.Size {
+		size = sha256.Size
+	}
+	presets := cfg.Presets
+	if presets == nil {
+		presets = DefaultPresets
+	}
+	formatList := cfg.Formats
+	if formatList == nil {
+		formatList = DefaultFormats
+	}
+	for _, format := range formatList {
+		if !formats[format] {
+			return nil, fmt.Errorf("unsupported imgproxy format %q", format)
+		}
+	}
+	return &Signer{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		key:     key,
+		salt:    salt,
+		size:    size,
+		presets: presets,
+		formats: formatList,
+	}, nil
+}
+
+// URL возвращает подписанную ссылку на обработанное изображение source.
+// Источник кодируется в base64url, поэтому может содержать любые символы.
+func (s *Signer) URL(source string, opts Options) string {
+	var path strings.Builder
+	if opts.Width > 0 || opts.Height > 0 || opts.Resize != "" {
+		resize := opts.Resize
+		if resize == "" {
+			resize = "fit"
+		}
+		fmt.Fprintf(&path, "/rs:%s:%d:%d", resize, opts.Width, opts.Height)
+	}
+	if opts.Quality > 0 {
+		fmt.Fprintf(&path, "/q:%d", opts.Quality)
+	}
+	path.WriteString("/")
+	path.WriteString(base64.RawURLEncoding.EncodeToString([]byte(source)))
+	if opts.Format != "" {
+		path.WriteString(".")
+		path.WriteString(opts.Format)
+	}
+	return s.baseURL + "/" + s.sign(path.String()) + path.String()
+}
+
+// Variants возвращает ссылки на все предустановленные варианты изображения.
+func (s *Signer) Variants(source string) map[string]string {
+	variants := make(map[string]string, len(s.presets))
+	for _, preset := range s.presets {
+		variants[preset.Name] = s.URL(source, preset.Options)
+	}
+	return variants
+}
+
+// Presets возвращает пресеты вариантов.
+func (s *Signer) Presets() []Preset {
+	return s.presets
+}
+
+// Formats возвращает современные форматы, в которых строятся варианты.
+func (s *Signer) Formats() []string {
+	return s.formats
+}
+
+// SrcSet возвращает значение srcset с вариантами для экранов 1x и 2x.
+// Вариант 2x пропускается, если он превысил бы MaxSide.
+func (s *Signer) SrcSet(source string, opts Options) string {
+	var candidates []string
+	for _, density := range []int{1, 2} {
+		scaled := opts
+		scaled.Width *= density
+		scaled.Height *= density
+		if scaled.Width > MaxSide || scaled.Height > MaxSide {
+			break
+		}
+		descriptor := fmt.Sprintf("%dx", density)
+		if scaled.Width > 0 {
+			descriptor = fmt.Sprintf("%dw", scaled.Width)
+		}
+		candidates = append(candidates, s.URL(source, scaled)+" "+descriptor)
+	}
+	return strings.Join(candidates, ", ")
+}
+
+func (s *Signer) sign(path string) string {
+	if len(s.key) == 0 {
+		return "insecure"
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(s.salt)
+	mac.Write([]byte(path))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:s.size])
+}
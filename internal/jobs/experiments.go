@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"server/internal/service"
+)
+
+// ExposureFlusher записывает накопленные попадания пользователей в эксперименты.
+type ExposureFlusher struct {
+	experiments *service.ExperimentService
+}
+
+func NewExposureFlusher(experiments *service.ExperimentService) *ExposureFlusher {
+	return &ExposureFlusher{experiments: experiments}
+}
+
+func (j *ExposureFlusher) Name() string {
+	return "experiment_exposures"
+}
+
+func (j *ExposureFlusher) RunOnce() error {
+	_, err := j.experiments.FlushExposures()
+	return err
+}
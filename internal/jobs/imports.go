@@ -0,0 +1,20 @@
+package jobs
+
+import "server/internal/service"
+
+// ImageImporter загружает изображения, поставленные в очередь импортом продуктов.
+type ImageImporter struct {
+	imports *service.ImportService
+}
+
+func NewImageImporter(imports *service.ImportService) *ImageImporter {
+	return &ImageImporter{imports: imports}
+}
+
+func (j *ImageImporter) Name() string {
+	return "image_importer"
+}
+
+func (j *ImageImporter) RunOnce() error {
+	return j.imports.ProcessPending()
+}
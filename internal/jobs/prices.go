@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"server/internal/service"
+)
+
+// PriceScheduler применяет и откатывает запланированные цены.
+type PriceScheduler struct {
+	products *service.ProductService
+}
+
+func NewPriceScheduler(products *service.ProductService) *PriceScheduler {
+	return &PriceScheduler{products: products}
+}
+
+func (j *PriceScheduler) Name() string {
+	return "price_scheduler"
+}
+
+func (j *PriceScheduler) RunOnce() error {
+	return j.products.ApplyPriceSchedules()
+}
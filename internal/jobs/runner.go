@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"server/internal/models"
+	"server/internal/service"
+	"time"
+)
+
+// Job — фоновая задача, выполняемая периодически.
+type Job interface {
+	Name() string
+	RunOnce() error
+}
+
+// Runner запускает задачи по расписанию и отправляет неудачные запуски в DLQ.
+type Runner struct {
+	dlq  *service.DeadLetterQueue
+	jobs map[string]Job
+}
+
+func NewRunner(dlq *service.DeadLetterQueue) *Runner {
+	return &Runner{dlq: dlq, jobs: make(map[string]Job)}
+}
+
+// Every регистрирует задачу и запускает её в отдельной горутине с указанным периодом.
+func (r *Runner) Every(job Job, interval time.Duration) {
+	r.jobs[job.Name()] = job
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := job.RunOnce(); err != nil {
+				log.Printf("Ошибка фоновой задачи %s: %v", job.Name(), err)
+				r.dlq.Add(service.DeadLetterJob, job.Name(), nil, err)
+			}
+		}
+	}()
+}
+
+// Retry повторно запускает задачу из записи DLQ; используется как обработчик
+// повтора для источника "job".
+func (r *Runner) Retry(entry models.DeadLetter) error {
+	job, ok := r.jobs[entry.Kind]
+	if !ok {
+		return fmt.Errorf("unknown job %q", entry.Kind)
+	}
+	return job.RunOnce()
+}
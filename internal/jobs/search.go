@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"log"
+	"server/internal/service"
+)
+
+// SearchIndexer переиндексирует продукты после изменения синонимов и стоп-слов.
+type SearchIndexer struct {
+	products *service.ProductService
+}
+
+func NewSearchIndexer(products *service.ProductService) *SearchIndexer {
+	return &SearchIndexer{products: products}
+}
+
+func (j *SearchIndexer) Name() string {
+	return "search_indexer"
+}
+
+func (j *SearchIndexer) RunOnce() error {
+	reindexed, err := j.products.ReindexPending()
+	if reindexed > 0 {
+		log.Printf("Переиндексировано продуктов для поиска: %d", reindexed)
+	}
+	return err
+}
+
+// SearchVocabulary перестраивает словарь подсказок "возможно, вы имели в виду".
+type SearchVocabulary struct {
+	products *service.ProductService
+}
+
+func NewSearchVocabulary(products *service.ProductService) *SearchVocabulary {
+	return &SearchVocabulary{products: products}
+}
+
+func (j *SearchVocabulary) Name() string {
+	return "search_vocabulary"
+}
+
+func (j *SearchVocabulary) RunOnce() error {
+	_, err := j.products.RefreshVocabulary()
+	return err
+}
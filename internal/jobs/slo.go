@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"server/internal/slo"
+)
+
+// SLOChecker поднимает и снимает тревоги о быстром сгорании бюджета ошибок.
+type SLOChecker struct {
+	tracker *slo.Tracker
+}
+
+func NewSLOChecker(tracker *slo.Tracker) *SLOChecker {
+	return &SLOChecker{tracker: tracker}
+}
+
+func (j *SLOChecker) Name() string {
+	return "slo_check"
+}
+
+func (j *SLOChecker) RunOnce() error {
+	j.tracker.Check()
+	return nil
+}
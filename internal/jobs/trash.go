@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"log"
+	"server/internal/service"
+)
+
+// TrashSweeper окончательно удаляет продукты, чьё окно восстановления истекло,
+// вместе с файлами их вложений.
+type TrashSweeper struct {
+	products    *service.ProductService
+	attachments *service.AttachmentService
+}
+
+func NewTrashSweeper(products *service.ProductService, attachments *service.AttachmentService) *TrashSweeper {
+	return &TrashSweeper{products: products, attachments: attachments}
+}
+
+func (j *TrashSweeper) Name() string {
+	return "trash_sweeper"
+}
+
+func (j *TrashSweeper) RunOnce() error {
+	purged, keys, err := j.products.PurgeTrash()
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Из корзины окончательно удалено продуктов: %d", purged)
+	}
+	return j.attachments.RemoveFiles(keys)
+}
@@ -0,0 +1,463 @@
+// Package jsonnaming кодирует и разбирает JSON REST API с выбранным стилем
+// имён полей. Модели описывают поля в snake_case тегами json; в режиме
+// camelCase кодек переименовывает поля структур при кодировании и
+// принимает оба стиля при разборе. Ключи map — данные, а не имена полей,
+// поэтому они не меняются. Ключи ответов GraphQL, названные по схеме в
+// camelCase, переводит в стиль кодека CamelKey.
+package jsonnaming
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Стили имён полей.
+const (
+	SnakeCase = "snake_case"
+	CamelCase = "camelCase"
+)
+
+// Parse проверяет стиль имён; пустая строка — SnakeCase.
+func Parse(style string) (string, error) {
+	switch style {
+	case "", SnakeCase:
+		return SnakeCase, nil
+	case CamelCase:
+		return CamelCase, nil
+	default:
+		return "", fmt.Errorf("unknown JSON field naming %q: use %s or %s", style, SnakeCase, CamelCase)
+	}
+}
+
+// Codec кодирует и разбирает JSON в одном стиле имён. Методы Marshal и
+// Unmarshal подходят для fiber.Config.JSONEncoder и JSONDecoder.
+type Codec struct {
+	style string
+	// fields — описание полей по типу структуры.
+	fields sync.Map
+}
+
+func New(style string) *Codec {
+	return &Codec{style: style}
+}
+
+// Style возвращает стиль имён кодека.
+func (c *Codec) Style() string {
+	return c.style
+}
+
+// Key возвращает имя поля name из тега json в стиле кодека.
+func (c *Codec) Key(name string) string {
+	if c.style != CamelCase {
+		return name
+	}
+	return camel(name)
+}
+
+// CamelKey возвращает имя name из camelCase в стиле кодека.
+func (c *Codec) CamelKey(name string) string {
+	if c.style == CamelCase {
+		return name
+	}
+	return snake(name)
+}
+
+func (c *Codec) Marshal(v interface{}) ([]byte, error) {
+	if c.style != CamelCase {
+		return json.Marshal(v)
+	}
+	return json.Marshal(c.encode(reflect.ValueOf(v)))
+}
+
+func (c *Codec) Unmarshal(data []byte, v interface{}) error {
+	if c.style != CamelCase {
+		return json.Unmarshal(data, v)
+	}
+	renamed, err := c.decode(data, reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(renamed, v)
+}
+
+// camel переводит имя из snake_case в camelCase: cost_price — costPrice.
+func camel(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			b.WriteByte('_')
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
+
+// snake переводит имя из camelCase в snake_case: costPrice — cost_price,
+// imageURL — image_url.
+func snake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// field — поле структуры, которое попадает в JSON.
+type field struct {
+	// index — путь к полю с учётом встроенных структур.
+	index []int
+	// name — имя из тега json, key — имя в стиле кодека.
+	name      string
+	key       string
+	omitEmpty bool
+	// quoted — опция ,string: число или bool кодируется строкой.
+	quoted bool
+	// tagged — имя задано тегом, а не взято из имени поля.
+	tagged bool
+}
+
+// structFields описывает поля типа t по правилам encoding/json: поля
+// встроенных структур без тега поднимаются на уровень выше, а из
+// одноимённых полей остаётся самое мелкое; при равной глубине — с тегом,
+// а если неясно, какое, — ни одного.
+func (c *Codec) structFields(t reflect.Type) []field {
+	if cached, ok := c.fields.Load(t); ok {
+		return cached.([]field)
+	}
+	var candidates []field
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			path := append(append([]int(nil), index...), i)
+			if sf.Anonymous && name == "" {
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, path)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			tagged := name != ""
+			if !tagged {
+				name = sf.Name
+			}
+			candidates = append(candidates, field{
+				index:     path,
+				name:      name,
+				key:       c.Key(name),
+				omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+				quoted:    strings.Contains(","+opts+",", ",string,"),
+				tagged:    tagged,
+			})
+		}
+	}
+	walk(t, nil)
+
+	byName := make(map[string][]field)
+	for _, f := range candidates {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var fields []field
+	for _, f := range candidates {
+		if dominant, ok := dominantField(byName[f.name]); ok && reflect.DeepEqual(dominant.index, f.index) {
+			fields = append(fields, f)
+		}
+	}
+	c.fields.Store(t, fields)
+	return fields
+}
+
+// dominantField выбирает из одноимённых полей то, которое попадает в JSON.
+func dominantField(candidates []field) (field, bool) {
+	var shallowest []field
+	for _, f := range candidates {
+		switch {
+		case len(shallowest) == 0 || len(f.index) < len(shallowest[0].index):
+			shallowest = []field{f}
+		case len(f.index) == len(shallowest[0].index):
+			shallowest = append(shallowest, f)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	var tagged []field
+	for _, f := range shallowest {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return field{}, false
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encode переводит значение в дерево, которое json.Marshal кодирует с
+// переименованными полями. Типы со своим MarshalJSON или MarshalText
+// кодируются как есть.
+func (c *Codec) encode(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	t := v.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	if v.CanAddr() && (reflect.PointerTo(t).Implements(marshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)) {
+		return v.Addr().Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return c.encode(v.Elem())
+	case reflect.Struct:
+		// Копия адресуема: так находятся MarshalJSON с получателем-указателем.
+		if !v.CanAddr() {
+			copied := reflect.New(t).Elem()
+			copied.Set(v)
+			v = copied
+		}
+		var obj object
+		for _, f := range c.structFields(t) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmpty(fv)) {
+				continue
+			}
+			var value interface{}
+			if f.quoted {
+				value = quoted{fv.Interface()}
+			} else {
+				value = c.encode(fv)
+			}
+			obj = append(obj, member{f.key, value})
+		}
+		return obj
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := reflect.MakeMapWithSize(reflect.MapOf(t.Key(), reflect.TypeOf((*interface{})(nil)).Elem()), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value := c.encode(iter.Value())
+			if value == nil {
+				out.SetMapIndex(iter.Key(), reflect.Zero(out.Type().Elem()))
+				continue
+			}
+			out.SetMapIndex(iter.Key(), reflect.ValueOf(value))
+		}
+		return out.Interface()
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = c.encode(v.Index(i))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// fieldByIndex возвращает поле по пути; ok == false, если путь проходит
+// через nil-указатель на встроенную структуру.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmpty — правило omitempty из encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// object — объект JSON с ключами в порядке полей структуры.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// quoted кодирует значение поля с опцией ,string.
+type quoted struct {
+	value interface{}
+}
+
+func (q quoted) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(q.value)
+	if err != nil {
+		return nil, err
+	}
+	switch reflect.ValueOf(q.value).Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return json.Marshal(string(data))
+	}
+	return data, nil
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decode переименовывает в data ключи объектов, которые соответствуют
+// полям типа t в стиле кодека, в имена из тегов json. Ключи в snake_case
+// остаются как есть, так что принимаются оба стиля. Данные, которые не
+// подходят к типу, возвращаются без изменений: ошибку сообщит
+// json.Unmarshal.
+func (c *Codec) decode(data []byte, t reflect.Type) ([]byte, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		if t.Implements(unmarshalerType) {
+			return data, nil
+		}
+		t = t.Elem()
+	}
+	if t == nil || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return data, nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var raw map[string]json.RawMessage
+		if json.Unmarshal(data, &raw) != nil {
+			return data, nil
+		}
+		byKey := make(map[string]field)
+		for _, f := range c.structFields(t) {
+			byKey[f.key] = f
+		}
+		renamed := make(map[string]json.RawMessage, len(raw))
+		for key, value := range raw {
+			f, ok := byKey[key]
+			if !ok {
+				renamed[key] = value
+				continue
+			}
+			// Если пришли оба варианта имени, прав snake_case.
+			if _, exists := raw[f.name]; exists && f.name != key {
+				continue
+			}
+			decoded, err := c.decode(value, t.FieldByIndex(f.index).Type)
+			if err != nil {
+				return nil, err
+			}
+			renamed[f.name] = decoded
+		}
+		return json.Marshal(renamed)
+	case reflect.Slice, reflect.Array:
+		var raw []json.RawMessage
+		if json.Unmarshal(data, &raw) != nil {
+			return data, nil
+		}
+		for i, value := range raw {
+			decoded, err := c.decode(value, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			raw[i] = decoded
+		}
+		return json.Marshal(raw)
+	case reflect.Map:
+		var raw map[string]json.RawMessage
+		if json.Unmarshal(data, &raw) != nil {
+			return data, nil
+		}
+		for key, value := range raw {
+			decoded, err := c.decode(value, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			raw[key] = decoded
+		}
+		return json.Marshal(raw)
+	default:
+		return data, nil
+	}
+}
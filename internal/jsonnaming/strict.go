@@ -0,0 +1,198 @@
+package jsonnaming
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError — поле тела запроса, которое не подходит к модели.
+type FieldError struct {
+	// Path — путь к полю с именами из запроса, например items[0].pricee;
+	// пусто — весь документ.
+	Path    string `json:"path" example:"pricee"`
+	Message string `json:"message" example:"unknown field"`
+}
+
+// FieldErrors — все расхождения документа с моделью.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Path + ": " + fe.Message
+	}
+	return "invalid fields: " + strings.Join(parts, "; ")
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Check сверяет документ data с типом v и возвращает FieldErrors со всеми
+// полями, которые json.Unmarshal молча отбросил бы, и значениями не того
+// типа. Поле известно, если совпадает с именем из тега json или с именем в
+// стиле кодека без учёта регистра, как при разборе. Синтаксические ошибки
+// Check не сообщает: их вернёт сам разбор.
+func (c *Codec) Check(data []byte, v interface{}) error {
+	if !json.Valid(data) {
+		return nil
+	}
+	var errs FieldErrors
+	c.check(bytes.TrimSpace(data), reflect.TypeOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *Codec) check(data []byte, t reflect.Type, path string, errs *FieldErrors) {
+	if t == nil || bytes.Equal(data, []byte("null")) {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(unmarshalerType) || ptr.Implements(textUnmarshalerType) {
+		if t.Kind() != reflect.Struct && ptr.Implements(textUnmarshalerType) && data[0] != '"' {
+			*errs = append(*errs, mismatch(path, "string", data))
+			return
+		}
+		if err := json.Unmarshal(data, reflect.New(t).Interface()); err != nil {
+			*errs = append(*errs, FieldError{Path: path, Message: "invalid value: " + err.Error()})
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		members, ok := objectMembers(data)
+		if !ok {
+			*errs = append(*errs, mismatch(path, "object", data))
+			return
+		}
+		fields := c.structFields(t)
+		for _, m := range members {
+			f, ok := matchField(fields, m.key)
+			if !ok {
+				*errs = append(*errs, FieldError{Path: joinPath(path, m.key), Message: "unknown field"})
+				continue
+			}
+			if f.quoted {
+				if m.value[0] != '"' {
+					*errs = append(*errs, mismatch(joinPath(path, m.key), "string", m.value))
+				}
+				continue
+			}
+			c.check(m.value, t.FieldByIndex(f.index).Type, joinPath(path, m.key), errs)
+		}
+	case reflect.Map:
+		members, ok := objectMembers(data)
+		if !ok {
+			*errs = append(*errs, mismatch(path, "object", data))
+			return
+		}
+		for _, m := range members {
+			c.check(m.value, t.Elem(), joinPath(path, m.key), errs)
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			c.checkScalar(data, t, path, "string", errs)
+			return
+		}
+		var items []json.RawMessage
+		if data[0] != '[' || json.Unmarshal(data, &items) != nil {
+			*errs = append(*errs, mismatch(path, "array", data))
+			return
+		}
+		for i, item := range items {
+			c.check(bytes.TrimSpace(item), t.Elem(), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case reflect.String:
+		c.checkScalar(data, t, path, "string", errs)
+	case reflect.Bool:
+		c.checkScalar(data, t, path, "boolean", errs)
+	case reflect.Float32, reflect.Float64:
+		c.checkScalar(data, t, path, "number", errs)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		c.checkScalar(data, t, path, "integer", errs)
+	}
+}
+
+// checkScalar проверяет значение простого типа разбором в него.
+func (c *Codec) checkScalar(data []byte, t reflect.Type, path, expected string, errs *FieldErrors) {
+	if json.Unmarshal(data, reflect.New(t).Interface()) != nil {
+		*errs = append(*errs, mismatch(path, expected, data))
+	}
+}
+
+// matchField находит поле по ключу так же, как json.Unmarshal после
+// переименования кодеком.
+func matchField(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if strings.EqualFold(key, f.name) || strings.EqualFold(key, f.key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func mismatch(path, expected string, data []byte) FieldError {
+	got := "number"
+	switch data[0] {
+	case '{':
+		got = "object"
+	case '[':
+		got = "array"
+	case '"':
+		got = "string"
+	case 't', 'f':
+		got = "boolean"
+	default:
+		// Число не того вида: дробное или вне диапазона.
+		if expected == "integer" {
+			return FieldError{Path: path, Message: "expected integer, got " + string(data)}
+		}
+	}
+	return FieldError{Path: path, Message: "expected " + expected + ", got " + got}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+type objectMember struct {
+	key   string
+	value []byte
+}
+
+// objectMembers возвращает пары объекта JSON в порядке документа.
+func objectMembers(data []byte) ([]objectMember, bool) {
+	if len(data) == 0 || data[0] != '{' {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, false
+	}
+	var members []objectMember
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, false
+		}
+		members = append(members, objectMember{key, bytes.TrimSpace(value)})
+	}
+	return members, true
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"server/internal/service"
+	"sync"
+	"time"
+)
+
+// RegisterCatalog добавляет бизнес-метрики каталога. Агрегаты запрашиваются
+// из базы не чаще раза в секунду, даже если их читают несколько gauge.
+func RegisterCatalog(r *Registry, products *service.ProductService) {
+	var (
+		mu        sync.Mutex
+		cached    service.CatalogStats
+		fetchedAt time.Time
+	)
+	stats := func() (service.CatalogStats, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(fetchedAt) < time.Second {
+			return cached, nil
+		}
+		s, err := products.Stats()
+		if err != nil {
+			return s, err
+		}
+		cached, fetchedAt = s, time.Now()
+		return s, nil
+	}
+
+	r.GaugeFunc("catalog_products", "Number of products in the catalog.", func() (float64, error) {
+		s, err := stats()
+		return float64(s.Products), err
+	})
+	r.GaugeFunc("catalog_value", "Sum of current prices of all products.", func() (float64, error) {
+		s, err := stats()
+		return s.CatalogValue, err
+	})
+	r.GaugeVecFunc("catalog_price_changes", "Scheduled price changes by status.", func() ([]Sample, error) {
+		s, err := stats()
+		return []Sample{
+			{Labels: map[string]string{"status": "pending"}, Value: float64(s.PendingPriceChanges)},
+			{Labels: map[string]string{"status": "active"}, Value: float64(s.ActivePriceChanges)},
+		}, err
+	})
+	r.GaugeFunc("catalog_attachments", "Number of product attachments.", func() (float64, error) {
+		s, err := stats()
+		return float64(s.Attachments), err
+	})
+	r.GaugeFunc("catalog_attachment_bytes", "Total size of product attachments in bytes.", func() (float64, error) {
+		s, err := stats()
+		return float64(s.AttachmentBytes), err
+	})
+	r.GaugeVecFunc("catalog_category_products", "Number of products per category.", func() ([]Sample, error) {
+		counts, err := products.CategoryCounts()
+		if err != nil {
+			return nil, err
+		}
+		samples := make([]Sample, 0, len(counts))
+		for category, count := range counts {
+			samples = append(samples, Sample{Labels: map[string]string{"category": category}, Value: float64(count)})
+		}
+		return samples, nil
+	})
+}
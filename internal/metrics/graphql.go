@@ -0,0 +1,29 @@
+package metrics
+
+import "server/internal/graphql"
+
+// RegisterGraphQLUsage добавляет счётчики использования GraphQL-операций и полей.
+func RegisterGraphQLUsage(r *Registry, usage *graphql.Usage) {
+	r.CounterVecFunc("graphql_operations", "Executed GraphQL operations by operation and client name.", func() ([]Sample, error) {
+		report := usage.Report()
+		samples := make([]Sample, 0, len(report.Operations))
+		for _, op := range report.Operations {
+			samples = append(samples, Sample{
+				Labels: map[string]string{"operation": op.Operation, "client": op.ClientName, "client_version": op.ClientVersion},
+				Value:  float64(op.Count),
+			})
+		}
+		return samples, nil
+	})
+	r.CounterVecFunc("graphql_field_usage", "GraphQL operations that requested a field, including never used fields.", func() ([]Sample, error) {
+		report := usage.Report()
+		samples := make([]Sample, 0, len(report.Fields))
+		for _, field := range report.Fields {
+			samples = append(samples, Sample{
+				Labels: map[string]string{"type": field.Type, "field": field.Field},
+				Value:  float64(field.Count),
+			})
+		}
+		return samples, nil
+	})
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const contentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Sample — одно значение метрики с необязательными метками.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	collect func() ([]Sample, error)
+}
+
+// Registry собирает метрики и отдаёт их в формате OpenMetrics.
+// Значения вычисляются в момент запроса, поэтому отражают текущее состояние.
+type Registry struct {
+	mu       sync.RWMutex
+	families []family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// GaugeFunc регистрирует gauge, значение которого вычисляет fn.
+func (r *Registry) GaugeFunc(name, help string, fn func() (float64, error)) {
+	r.register(family{name: name, help: help, kind: "gauge", collect: func() ([]Sample, error) {
+		v, err := fn()
+		return []Sample{{Value: v}}, err
+	}})
+}
+
+// GaugeVecFunc регистрирует gauge с метками; fn возвращает все текущие значения.
+func (r *Registry) GaugeVecFunc(name, help string, fn func() ([]Sample, error)) {
+	r.register(family{name: name, help: help, kind: "gauge", collect: fn})
+}
+
+// CounterFunc регистрирует монотонный счётчик, значение которого вычисляет fn.
+func (r *Registry) CounterFunc(name, help string, fn func() (float64, error)) {
+	r.register(family{name: name, help: help, kind: "counter", collect: func() ([]Sample, error) {
+		v, err := fn()
+		return []Sample{{Value: v}}, err
+	}})
+}
+
+// CounterVecFunc регистрирует счётчик с метками; fn возвращает все текущие значения.
+func (r *Registry) CounterVecFunc(name, help string, fn func() ([]Sample, error)) {
+	r.register(family{name: name, help: help, kind: "counter", collect: fn})
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// Handler отдаёт все метрики; метрики, которые не удалось вычислить, пропускаются.
+func (r *Registry) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r.mu.RLock()
+		families := append([]family(nil), r.families...)
+		r.mu.RUnlock()
+
+		var b strings.Builder
+		for _, f := range families {
+			samples, err := f.collect()
+			if err != nil {
+				log.Printf("Ошибка вычисления метрики %s: %v", f.name, err)
+				continue
+			}
+			fmt.Fprintf(&b, "# TYPE %s %s\n# HELP %s %s\n", f.name, f.kind, f.name, f.help)
+			suffix := ""
+			if f.kind == "counter" {
+				suffix = "_total"
+			}
+			for _, s := range samples {
+				fmt.Fprintf(&b, "%s%s%s %g\n", f.name, suffix, formatLabels(s.Labels), s.Value)
+			}
+		}
+		b.WriteString("# EOF\n")
+
+		c.Set(fiber.HeaderContentType, contentType)
+		return c.SendString(b.String())
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		pairs[i] = fmt.Sprintf(`%s="%s"`, k, value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import "server/internal/db"
+
+// RegisterReplicas добавляет метрики состояния реплик для чтения.
+func RegisterReplicas(r *Registry, router *db.ReadRouter) {
+	r.GaugeVecFunc("db_replica_up", "Whether a read replica passed the last health probe.", func() ([]Sample, error) {
+		return replicaSamples(router, func(s db.ReplicaStatus) float64 { return boolValue(s.Healthy) }), nil
+	})
+	r.GaugeVecFunc("db_replica_active", "Whether a read replica currently serves catalog reads.", func() ([]Sample, error) {
+		return replicaSamples(router, func(s db.ReplicaStatus) float64 { return boolValue(s.Active) }), nil
+	})
+	r.GaugeVecFunc("db_replica_latency_seconds", "Smoothed probe round-trip time to a read replica.", func() ([]Sample, error) {
+		return replicaSamples(router, func(s db.ReplicaStatus) float64 { return s.Latency.Seconds() }), nil
+	})
+	r.GaugeVecFunc("db_replica_lag_seconds", "Replication lag of a read replica at the last probe.", func() ([]Sample, error) {
+		return replicaSamples(router, func(s db.ReplicaStatus) float64 { return s.Lag.Seconds() }), nil
+	})
+}
+
+func replicaSamples(router *db.ReadRouter, value func(db.ReplicaStatus) float64) []Sample {
+	statuses := router.Status()
+	samples := make([]Sample, 0, len(statuses))
+	for _, s := range statuses {
+		samples = append(samples, Sample{Labels: map[string]string{"region": s.Region}, Value: value(s)})
+	}
+	return samples
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
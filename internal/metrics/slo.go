@@ -0,0 +1,28 @@
+package metrics
+
+import "server/internal/slo"
+
+// RegisterSLO добавляет метрики соблюдения целей уровня обслуживания.
+func RegisterSLO(r *Registry, tracker *slo.Tracker) {
+	r.GaugeVecFunc("slo_compliance_ratio", "Share of good requests to a route over the SLO window.", func() ([]Sample, error) {
+		return sloSamples(tracker, func(s slo.Status) float64 { return s.Compliance }), nil
+	})
+	r.GaugeVecFunc("slo_error_budget_remaining_ratio", "Share of a route's error budget left in the SLO window.", func() ([]Sample, error) {
+		return sloSamples(tracker, func(s slo.Status) float64 { return s.BudgetRemaining }), nil
+	})
+	r.GaugeVecFunc("slo_burn_rate_1h", "Error budget burn rate of a route over the last hour.", func() ([]Sample, error) {
+		return sloSamples(tracker, func(s slo.Status) float64 { return s.BurnRate1h }), nil
+	})
+	r.GaugeVecFunc("slo_burn_rate_5m", "Error budget burn rate of a route over the last five minutes.", func() ([]Sample, error) {
+		return sloSamples(tracker, func(s slo.Status) float64 { return s.BurnRate5m }), nil
+	})
+}
+
+func sloSamples(tracker *slo.Tracker, value func(slo.Status) float64) []Sample {
+	statuses := tracker.Statuses()
+	samples := make([]Sample, 0, len(statuses))
+	for _, s := range statuses {
+		samples = append(samples, Sample{Labels: map[string]string{"route": s.Route}, Value: value(s)})
+	}
+	return samples
+}
@@ -0,0 +1,640 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Product struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Price       float64  `json:"price"`
+	Description string   `json:"description"`
+	Categories  []string `json:"categories"`
+	SKU         string   `json:"sku,omitempty"`
+	Barcode     string   `json:"barcode,omitempty"`
+	// CostPrice — себестоимость и Margin — доля маржи (price - cost_price) /
+	// price; внутренние данные, которые по auth.FieldPolicy видят только
+	// администраторы.
+	CostPrice *float64 `json:"cost_price,omitempty"`
+	Margin    *float64 `json:"margin,omitempty"`
+	// Locale — язык, на котором возвращены name и description (пусто для языка по умолчанию).
+	Locale string `json:"locale,omitempty"`
+	// UpcomingPrices — запланированные, но ещё не применённые изменения цены.
+	UpcomingPrices []PriceSchedule `json:"upcoming_prices,omitempty"`
+	Attachments    []Attachment    `json:"attachments,omitempty"`
+	// Badges — значки, которые показывает выдача категории, например featured.
+	Badges []string `json:"badges,omitempty"`
+}
+
+type PriceSchedule struct {
+	ID        int        `json:"id"`
+	ProductID int        `json:"product_id"`
+	Price     float64    `json:"price"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	Status    string     `json:"status"`
+}
+
+const (
+	PriceSchedulePending   = "pending"
+	PriceScheduleActive    = "active"
+	PriceScheduleDone      = "done"
+	PriceScheduleCancelled = "cancelled"
+)
+
+type ProductTranslation struct {
+	ProductID   int    `json:"product_id"`
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TrashedProduct — удалённый продукт, который ещё можно восстановить до PurgeAt.
+type TrashedProduct struct {
+	Product
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// Attachment — файл, прикреплённый к продукту (паспорт, инструкция и т.п.).
+type Attachment struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+	StorageKey  string    `json:"-"`
+	// Images — подписанные ссылки на уменьшенные варианты изображения по
+	// названию пресета; только для картинок и при настроенном imgproxy.
+	Images map[string]string `json:"images,omitempty"`
+	// ImageSets — те же пресеты в виде для <picture>/srcset: источники в
+	// современных форматах и запасной вариант в исходном формате.
+	ImageSets map[string]ImageSet `json:"image_sets,omitempty"`
+}
+
+// ImageSet — варианты одного пресета изображения с плотностями 1x и 2x.
+type ImageSet struct {
+	Width   int           `json:"width"`
+	Height  int           `json:"height"`
+	Sources []ImageSource `json:"sources"`
+	// Src и SrcSet — запасной вариант для браузеров без поддержки Sources.
+	Src    string `json:"src"`
+	SrcSet string `json:"srcset"`
+}
+
+// ImageSource — элемент <source> для одного формата.
+type ImageSource struct {
+	Type   string `json:"type"`
+	SrcSet string `json:"srcset"`
+}
+
+// Webhook — подписка внешнего получателя на события каталога.
+type Webhook struct {
+	ID int `json:"id"`
+	// Key — постоянный внешний ключ, по которому вебхуком управляют
+	// декларативно (PUT /api/webhooks/by-key/{key}); у вебхуков, созданных
+	// без ключа, пуст.
+	Key    string   `json:"key,omitempty" example:"orders-to-crm"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret возвращается только при создании; им подписываются доставки.
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery — результат попытки доставки события получателю.
+type WebhookDelivery struct {
+	WebhookID  int    `json:"webhook_id"`
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ReplayResult — итог повторной доставки событий из журнала.
+type ReplayResult struct {
+	Total     int               `json:"total"`
+	Delivered int               `json:"delivered"`
+	Failed    int               `json:"failed"`
+	Failures  []WebhookDelivery `json:"failures,omitempty"`
+}
+
+// DeadLetter — неудачная доставка вебхука или запуск фоновой задачи,
+// отложенные для разбора и повтора.
+type DeadLetter struct {
+	ID            int             `json:"id"`
+	Source        string          `json:"source"`
+	Kind          string          `json:"kind"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	CreatedAt     time.Time       `json:"created_at"`
+	LastAttemptAt time.Time       `json:"last_attempt_at"`
+}
+
+// RetryOutcome — результат повтора одной записи DLQ.
+type RetryOutcome struct {
+	ID      int    `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportItem — продукт в пакете импорта вместе со ссылками на его изображения.
+type ImportItem struct {
+	Product
+	ImageURLs []string `json:"image_urls,omitempty"`
+}
+
+// ProductImportResult — итог по одному продукту пакетного создания: ID
+// созданного продукта или причина, по которой он пропущен.
+type ProductImportResult struct {
+	Index   int      `json:"index"`
+	Product *Product `json:"product,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// ImportJob — пакетный импорт продуктов. Продукты создаются сразу, изображения
+// загружаются в фоне; итог по каждой ссылке — в Images.
+type ImportJob struct {
+	ID         int           `json:"id"`
+	Status     string        `json:"status"`
+	ProductIDs []int         `json:"product_ids"`
+	Images     []ImageImport `json:"images"`
+	Attached   int           `json:"attached"`
+	Failed     int           `json:"failed"`
+	CreatedAt  time.Time     `json:"created_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
+
+// ImageImport — загрузка одного изображения по ссылке в рамках импорта.
+type ImageImport struct {
+	ProductID    int    `json:"product_id"`
+	URL          string `json:"url"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	AttachmentID *int   `json:"attachment_id,omitempty"`
+}
+
+const (
+	ImportPending    = "pending"
+	ImportProcessing = "processing"
+	ImportDone       = "done"
+	ImportAttached   = "attached"
+	ImportFailed     = "failed"
+)
+
+// Category — категория каталога с числом продуктов в ней. Отдельной таблицы
+// нет: категории берутся из поля categories продуктов.
+type Category struct {
+	Name         string `json:"name"`
+	ProductCount int    `json:"product_count"`
+}
+
+// Review — отзыв покупателя о продукте.
+type Review struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	Author    string    `json:"author"`
+	Rating    int       `json:"rating"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewSummary — сводка отзывов продукта.
+type ReviewSummary struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+}
+
+// StockLevel — остаток продукта на складе.
+type StockLevel struct {
+	ProductID int       `json:"product_id"`
+	Warehouse string    `json:"warehouse"`
+	Quantity  int       `json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductJSONLD — разметка schema.org/Product для поисковых систем.
+type ProductJSONLD struct {
+	Context         string                 `json:"@context"`
+	Type            string                 `json:"@type"`
+	ID              string                 `json:"@id,omitempty"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description,omitempty"`
+	SKU             string                 `json:"sku,omitempty"`
+	GTIN            string                 `json:"gtin,omitempty"`
+	Category        string                 `json:"category,omitempty"`
+	Image           []string               `json:"image,omitempty"`
+	Offers          OfferJSONLD            `json:"offers"`
+	AggregateRating *AggregateRatingJSONLD `json:"aggregateRating,omitempty"`
+}
+
+// OfferJSONLD — schema.org/Offer: цена и наличие продукта.
+type OfferJSONLD struct {
+	Type            string  `json:"@type"`
+	URL             string  `json:"url,omitempty"`
+	Price           float64 `json:"price"`
+	PriceCurrency   string  `json:"priceCurrency"`
+	PriceValidUntil string  `json:"priceValidUntil,omitempty"`
+	Availability    string  `json:"availability,omitempty"`
+}
+
+// AggregateRatingJSONLD — schema.org/AggregateRating по отзывам продукта.
+type AggregateRatingJSONLD struct {
+	Type        string  `json:"@type"`
+	RatingValue float64 `json:"ratingValue"`
+	ReviewCount int     `json:"reviewCount"`
+	BestRating  int     `json:"bestRating"`
+	WorstRating int     `json:"worstRating"`
+}
+
+// SynonymGroup — слова, которые поиск считает равнозначными.
+type SynonymGroup struct {
+	ID    int      `json:"id"`
+	Terms []string `json:"terms"`
+}
+
+// SearchBoosts — множители, на которые умножается релевантность продукта в
+// результатах поиска. Значение 1 не меняет порядок, больше 1 поднимает
+// продукт, меньше 1 опускает.
+type SearchBoosts struct {
+	// Featured — для продуктов, отмеченных как рекомендуемые.
+	Featured float64 `json:"featured" example:"2"`
+	// InStock — для продуктов, которые есть хотя бы на одном складе.
+	InStock float64 `json:"in_stock" example:"1.5"`
+	// MarginTiers — уровни маржи; применяется уровень с наибольшим
+	// MinMargin, которого достигает продукт.
+	MarginTiers []MarginTier `json:"margin_tiers"`
+}
+
+// MarginTier — множитель для продуктов с маржой (price - cost_price) / price
+// не ниже MinMargin. Продукты без себестоимости уровней не получают.
+type MarginTier struct {
+	MinMargin float64 `json:"min_margin" example:"0.3"`
+	Boost     float64 `json:"boost" example:"1.2"`
+}
+
+// SearchSuggestion — подсказка исправленного запроса; DidYouMean пуст, если
+// по запросу нашлось достаточно продуктов или исправлять нечего.
+type SearchSuggestion struct {
+	Query      string  `json:"query" example:"нотбук"`
+	DidYouMean *string `json:"did_you_mean" example:"ноутбук"`
+}
+
+// ZeroResultSearch — поисковый запрос, по которому ничего не нашлось.
+type ZeroResultSearch struct {
+	Query       string    `json:"query" example:"беспроводная зарядка"`
+	Hits        int       `json:"hits"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	// FirstSeen и LastSeen — те же даты для людей в часовом поясе из
+	// заголовка Time-Zone.
+	FirstSeen string `json:"first_seen" example:"3 Oct 2026 09:15 MSK"`
+	LastSeen  string `json:"last_seen" example:"15 Oct 2026 14:03 MSK"`
+}
+
+// SearchIndexStatus — состояние поискового индекса: версия словаря синонимов
+// и стоп-слов и число продуктов, ещё не переиндексированных под неё.
+type SearchIndexStatus struct {
+	Version int `json:"version"`
+	Pending int `json:"pending"`
+}
+
+// Experiment — A/B-эксперимент. Пользователь попадает в вариант с
+// вероятностью, пропорциональной его весу, и остаётся в нём, пока набор
+// вариантов не изменится.
+type Experiment struct {
+	ID          int                 `json:"id"`
+	Key         string              `json:"key" example:"new_search_ranking"`
+	Description string              `json:"description"`
+	Variants    []ExperimentVariant `json:"variants"`
+	Active      bool                `json:"active"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// ExperimentVariant — вариант эксперимента и его доля трафика.
+type ExperimentVariant struct {
+	Name   string `json:"name" example:"control"`
+	Weight int    `json:"weight" example:"50"`
+}
+
+// ExperimentResults — число пользователей, увидевших каждый вариант.
+type ExperimentResults struct {
+	ExperimentID int               `json:"experiment_id"`
+	Variants     []VariantExposure `json:"variants"`
+}
+
+// VariantExposure — число уникальных пользователей, получивших вариант.
+type VariantExposure struct {
+	Variant  string `json:"variant"`
+	Subjects int    `json:"subjects"`
+}
+
+// ChatMessage — сохранённое сообщение чата WebSocket.
+type ChatMessage struct {
+	ID       int64  `json:"id"`
+	Room     string `json:"room" example:"general"`
+	Username string `json:"username" example:"alice"`
+	Message  string `json:"message" example:"Привет!"`
+	// EnvelopeID — id конверта chat.message, в котором сообщение разослано
+	// клиентам v2; по нему клиент отбрасывает повторы из истории.
+	EnvelopeID string    `json:"envelope_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Recipient — получатель личного сообщения; у сообщений комнаты пусто.
+	Recipient string `json:"recipient,omitempty"`
+	// Attachments — изображения сообщения.
+	Attachments []ChatImage `json:"attachments,omitempty"`
+	// EditedAt — когда текст сообщения последний раз исправили.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+}
+
+// ChatImage — изображение, загруженное для сообщения чата. Изображение
+// перекодируется при загрузке, поэтому ContentType, Size и размеры
+// относятся к сохранённой копии.
+type ChatImage struct {
+	ID           string `json:"id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	ContentType  string `json:"content_type" example:"image/jpeg"`
+	Size         int64  `json:"size" example:"183204"`
+	Width        int    `json:"width" example:"1280"`
+	Height       int    `json:"height" example:"960"`
+	URL          string `json:"url" example:"/api/chat/images/9f86d081884c7d659a2feaa0c55ad015"`
+	ThumbnailURL string `json:"thumbnail_url" example:"/api/chat/images/9f86d081884c7d659a2feaa0c55ad015/thumbnail"`
+	// Uploader — пользователь чата, загрузивший изображение; пусто без
+	// аутентификации.
+	Uploader     string    `json:"uploader,omitempty" example:"alice"`
+	CreatedAt    time.Time `json:"created_at"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+}
+
+// UnreadMessages — непрочитанные личные сообщения пользователя.
+type UnreadMessages struct {
+	Total int `json:"total" example:"3"`
+	// BySender — число непрочитанных сообщений по отправителям.
+	BySender map[string]int `json:"by_sender"`
+}
+
+// CategoryListing — настройки выдачи категории, которые применяются, когда
+// клиент не задал параметры списка сам.
+type CategoryListing struct {
+	Category string `json:"category" example:"Ноутбуки"`
+	// Sort — порядок по умолчанию; пусто — по релевантности.
+	Sort string `json:"sort" example:"price_asc"`
+	// PageSize — размер страницы по умолчанию; 0 — без ограничения.
+	PageSize int `json:"page_size" example:"24"`
+	// Badges — значки, которые выдача показывает у продуктов.
+	Badges    []string  `json:"badges" example:"featured,in_stock"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CatalogDiff — отличия каталога другого окружения от локального. Продукты
+// сопоставляются по SKU, а без SKU — по названию.
+type CatalogDiff struct {
+	// Added — продукты, которых нет в локальном каталоге.
+	Added []Product `json:"added"`
+	// Changed — продукты, поля которых отличаются.
+	Changed []CatalogChange `json:"changed"`
+	// Removed — локальные продукты, которых нет в другом окружении.
+	Removed []Product `json:"removed"`
+	// Ambiguous — ключи сопоставления, под которыми в одном из каталогов
+	// несколько продуктов; такие продукты не сравниваются и не меняются.
+	Ambiguous []string `json:"ambiguous"`
+	// Applied — отличия перенесены в локальный каталог.
+	Applied bool `json:"applied"`
+}
+
+// CatalogChange — продукт, который есть в обоих каталогах, но отличается.
+type CatalogChange struct {
+	Key    string   `json:"key" example:"SKU-42"`
+	Fields []string `json:"fields" example:"price,description"`
+	// Local — продукт локального каталога, Remote — другого окружения.
+	Local  Product `json:"local"`
+	Remote Product `json:"remote"`
+}
+
+// CategoryReassignment — итог переноса продуктов из одной категории в другую.
+type CategoryReassignment struct {
+	From string `json:"from" example:"phones"`
+	To   string `json:"to" example:"smartphones"`
+	// Products — сколько продуктов, включая продукты в корзине, сменили
+	// категорию.
+	Products int `json:"products"`
+	// Merged — у части продуктов уже была категория To, и From у них просто
+	// удалена.
+	Merged int `json:"merged"`
+	// ListingMoved — настройки выдачи From перенесены на To; если у To были
+	// свои настройки, они сохраняются, а настройки From удаляются.
+	ListingMoved bool `json:"listing_moved"`
+}
+
+// StorefrontSettings — оформление и настройки витрины арендатора. Пустые
+// currency и default_locale в ответе заменяются значениями сервера.
+type StorefrontSettings struct {
+	Tenant string `json:"tenant" example:"default"`
+	// LogoURL — адрес логотипа, абсолютный http(s).
+	LogoURL string `json:"logo_url" example:"https://cdn.example.com/logo.svg"`
+	// PrimaryColor — основной цвет оформления, #RRGGBB.
+	PrimaryColor string `json:"primary_color" example:"#1a73e8"`
+	// Currency — валюта цен витрины (ISO 4217).
+	Currency string `json:"currency" example:"RUB"`
+	// DefaultLocale — язык витрины, если клиент не выбрал свой.
+	DefaultLocale string     `json:"default_locale" example:"ru"`
+	ContactEmail  string     `json:"contact_email" example:"shop@example.com"`
+	ContactPhone  string     `json:"contact_phone" example:"+7 495 000-00-00"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// Виды ограничений пользователей чата.
+const (
+	// SanctionMute — пользователь не может писать в чат.
+	SanctionMute = "mute"
+	// SanctionBan — пользователь не может подключиться к чату.
+	SanctionBan = "ban"
+)
+
+// ChatSanction — ограничение пользователя чата, наложенное модератором.
+type ChatSanction struct {
+	Username string `json:"username" example:"troll"`
+	// Kind — mute или ban.
+	Kind   string `json:"kind" example:"mute"`
+	Reason string `json:"reason" example:"spam"`
+	// CreatedBy — модератор, наложивший ограничение.
+	CreatedBy string    `json:"created_by" example:"alice"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt — когда ограничение снимется само; без него — бессрочно.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// StaffUser — учётная запись сотрудника, которую ведёт провайдер
+// удостоверений через SCIM.
+type StaffUser struct {
+	ID string `json:"id" example:"2819c223a7f3e4b1d0c5f6a7b8c9d0e1"`
+	// ExternalID — идентификатор пользователя у провайдера удостоверений.
+	ExternalID  string `json:"external_id,omitempty" example:"00u1ab2cd3EF4gh5i6j7"`
+	UserName    string `json:"user_name" example:"alice"`
+	DisplayName string `json:"display_name,omitempty" example:"Alice Smith"`
+	Email       string `json:"email,omitempty" example:"alice@example.com"`
+	// Role — admin, moderator или пусто.
+	Role      string    `json:"role,omitempty" example:"moderator"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// User — пользователь API, входящий по имени и паролю.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username" example:"editor"`
+	// Email уникален без учёта регистра; по нему тоже можно войти.
+	Email       string `json:"email,omitempty" example:"editor@example.com"`
+	DisplayName string `json:"display_name,omitempty" example:"Катя"`
+	// Role — admin, editor, moderator или viewer.
+	Role      string    `json:"role,omitempty" example:"editor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Tokens — пара токенов пользователя API: access-токен передаётся в
+// заголовке Authorization, refresh-токен один раз обменивается на новую
+// пару.
+type Tokens struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type" example:"Bearer"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// AuditEntry — запись журнала аудита об успешном изменяющем запросе.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Actor — subject вызывающего: ID пользователя API, api-key:<id> или
+	// admin-token; пусто — анонимный запрос.
+	Actor     string `json:"actor,omitempty" example:"7"`
+	ActorName string `json:"actor_name,omitempty" example:"editor"`
+	// Action — create, update или delete по методу запроса.
+	Action string `json:"action" example:"update"`
+	Method string `json:"method" example:"PUT"`
+	Path   string `json:"path" example:"/api/products/42"`
+	// Resource — шаблон маршрута, например /api/products/:id, а у
+	// GraphQL — graphql: и имена полей мутации.
+	Resource   string `json:"resource" example:"/api/products/:id"`
+	ResourceID string `json:"resource_id,omitempty" example:"42"`
+	Status     int    `json:"status" example:"200"`
+	// Changes — изменённые поля с прежним и новым значениями. Прежние
+	// значения известны, если их сообщил сервис; иначе в new — поля тела
+	// запроса. Пароли, секреты и токены заменены на [redacted].
+	Changes   map[string]AuditChange `json:"changes"`
+	IP        string                 `json:"ip" example:"203.0.113.7"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// AuditChange — изменение одного поля; отсутствующее old — поле появилось,
+// отсутствующее new — поле удалено.
+type AuditChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// UserIdentity — учётная запись пользователя у провайдера OAuth2.
+type UserIdentity struct {
+	Provider string `json:"provider" example:"github"`
+	// Email — адрес у провайдера на момент привязки.
+	Email     string    `json:"email,omitempty" example:"katya@example.com"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKey — ключ API машинного клиента. Сам ключ возвращается только при
+// создании; сервер хранит его хеш.
+type APIKey struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" example:"feed-importer"`
+	// Prefix — начало ключа, по которому его можно узнать в списке.
+	Prefix string `json:"prefix" example:"ak_3f9c2b1e"`
+	// Scopes — права ключа: read, write, delete.
+	Scopes    []string   `json:"scopes" example:"read,write"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Key — сам ключ; только в ответе на создание.
+	Key string `json:"key,omitempty"`
+}
+
+// ChangelogEntry — изменение API.
+type ChangelogEntry struct {
+	// Version — версия API, в которой появилось изменение.
+	Version string `json:"version" example:"1.1"`
+	Date    string `json:"date,omitempty" example:"2026-10-15"`
+	// Type — added, changed, deprecated или removed.
+	Type string `json:"type" example:"added"`
+	// Method и Path — операция; пусто у изменений всего API.
+	Method      string `json:"method,omitempty" example:"POST"`
+	Path        string `json:"path,omitempty" example:"/api/auth/register"`
+	Description string `json:"description" example:"Self-registration of viewer accounts."`
+}
+
+// Changelog — изменения API, новые сначала.
+type Changelog struct {
+	// Version — текущая версия API.
+	Version string           `json:"version" example:"1.1"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// ProductWidget — цена и наличие продукта для виджета, который партнёры
+// встраивают на свои сайты.
+type ProductWidget struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency" example:"RUB"`
+	// InStock — есть ли продукт на складах; без данных об остатках не
+	// указывается.
+	InStock *bool `json:"in_stock,omitempty"`
+	// Image — ссылка на изображение продукта; относительная, если imgproxy не
+	// настроен.
+	Image string `json:"image,omitempty"`
+	// URL — страница продукта на сайте, если задан PRODUCT_PAGE_URL.
+	URL string `json:"url,omitempty"`
+	// Locale — язык названия (пусто для языка по умолчанию).
+	Locale string `json:"locale,omitempty"`
+}
+
+// Состояния сервиса и его зависимостей на странице статуса.
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// ServiceStatus — публичное состояние сервиса, по которому клиенты
+// проверяют его сами во время сбоев.
+type ServiceStatus struct {
+	// Status — operational, degraded или outage.
+	Status        string    `json:"status" example:"operational"`
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds int64     `json:"uptime_seconds" example:"86400"`
+	// Dependencies — состояние зависимостей без подробностей ошибок.
+	Dependencies []DependencyStatus `json:"dependencies"`
+	// Incidents — недавние инциденты, новые первыми.
+	Incidents []Incident `json:"incidents"`
+	CheckedAt time.Time  `json:"checked_at"`
+}
+
+// DependencyStatus — состояние зависимости сервиса.
+type DependencyStatus struct {
+	Name   string `json:"name" example:"database"`
+	Status string `json:"status" example:"operational"`
+}
+
+// Incident — период, когда маршрут API отвечал с ошибками или медленно.
+type Incident struct {
+	Route     string    `json:"route" example:"GET /api/products"`
+	StartedAt time.Time `json:"started_at"`
+	// ResolvedAt — окончание инцидента; без него инцидент продолжается.
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,283 @@
+// Package parquet записывает таблицы в формате Apache Parquet: одна группа
+// строк, по одной несжатой странице PLAIN на колонку. Этого хватает для
+// выгрузок, которые читают pandas, Spark и DuckDB, без внешних зависимостей.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ContentType — MIME-тип файлов Parquet.
+const ContentType = "application/vnd.apache.parquet"
+
+const magic = "PAR1"
+
+// Type — тип значений колонки.
+type Type int
+
+const (
+	Int64 Type = iota
+	Double
+	String
+	Boolean
+	// Timestamp — момент времени с точностью до миллисекунды в UTC.
+	Timestamp
+)
+
+// Физические типы, кодировки и логические типы из parquet.thrift.
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData = 0
+)
+
+// Column описывает колонку; значения Optional-колонки могут быть nil.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+// Writer накапливает строки в памяти и записывает файл целиком в WriteTo.
+type Writer struct {
+	columns []Column
+	chunks  []columnChunk
+	rows    int
+}
+
+// columnChunk — значения одной колонки в кодировке PLAIN и, для
+// Optional-колонки, признаки заданности значений.
+type columnChunk struct {
+	values  bytes.Buffer
+	defined []bool
+	// bits и nbits копят значения Boolean-колонки: в PLAIN они
+	// упаковываются по восемь в байт.
+	bits  byte
+	nbits int
+}
+
+func NewWriter(columns []Column) *Writer {
+	return &Writer{columns: columns, chunks: make([]columnChunk, len(columns))}
+}
+
+// Append добавляет строку: по значению на колонку в порядке колонок.
+// Int64 принимает int и int64, Double — float64, String — string,
+// Boolean — bool, Timestamp — time.Time.
+func (w *Writer) Append(row ...interface{}) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+	for i, value := range row {
+		if err := w.check(w.columns[i], value); err != nil {
+			return err
+		}
+	}
+	for i, value := range row {
+		column, chunk := w.columns[i], &w.chunks[i]
+		if column.Optional {
+			chunk.defined = append(chunk.defined, value != nil)
+		}
+		if value == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case int:
+			chunk.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(int64(v))))
+		case int64:
+			chunk.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+		case float64:
+			chunk.values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+		case string:
+			chunk.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			chunk.values.WriteString(v)
+		case time.Time:
+			chunk.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v.UnixMilli())))
+		case bool:
+			if v {
+				chunk.bits |= 1 << chunk.nbits
+			}
+			if chunk.nbits++; chunk.nbits == 8 {
+				chunk.values.WriteByte(chunk.bits)
+				chunk.bits, chunk.nbits = 0, 0
+			}
+		}
+	}
+	w.rows++
+	return nil
+}
+
+func (w *Writer) check(column Column, value interface{}) error {
+	var ok bool
+	switch value.(type) {
+	case nil:
+		ok = column.Optional
+	case int, int64:
+		ok = column.Type == Int64
+	case float64:
+		ok = column.Type == Double
+	case string:
+		ok = column.Type == String
+	case bool:
+		ok = column.Type == Boolean
+	case time.Time:
+		ok = column.Type == Timestamp
+	}
+	if !ok {
+		return fmt.Errorf("parquet: invalid value %T for column %q", value, column.Name)
+	}
+	return nil
+}
+
+// WriteTo записывает файл Parquet со всеми добавленными строками.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var file bytes.Buffer
+	file.WriteString(magic)
+
+	meta := newCompactWriter()
+	meta.beginStruct(0)
+	meta.i32(1, 1)
+	meta.list(2, thriftStruct, len(w.columns)+1)
+	meta.beginStruct(0)
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(w.columns)))
+	meta.endStruct()
+	for _, column := range w.columns {
+		meta.beginStruct(0)
+		meta.i32(1, physicalType(column.Type))
+		repetition := int32(repetitionRequired)
+		if column.Optional {
+			repetition = repetitionOptional
+		}
+		meta.i32(3, repetition)
+		meta.string(4, column.Name)
+		switch column.Type {
+		case String:
+			meta.i32(6, convertedUTF8)
+		case Timestamp:
+			meta.i32(6, convertedTimestampMillis)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, int64(w.rows))
+
+	meta.list(4, thriftStruct, 1)
+	meta.beginStruct(0)
+	meta.list(1, thriftStruct, len(w.columns))
+	var total int64
+	for i, column := range w.columns {
+		offset := int64(file.Len())
+		size := w.writePage(&file, column, &w.chunks[i])
+		total += size
+
+		meta.beginStruct(0)
+		meta.i64(2, offset)
+		meta.beginStruct(3)
+		meta.i32(1, physicalType(column.Type))
+		meta.list(2, thriftI32, 2)
+		meta.listI32(encodingPlain)
+		meta.listI32(encodingRLE)
+		meta.list(3, thriftBinary, 1)
+		meta.listString(column.Name)
+		meta.i32(4, 0)
+		meta.i64(5, int64(w.rows))
+		meta.i64(6, size)
+		meta.i64(7, size)
+		meta.i64(9, offset)
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(w.rows))
+	meta.endStruct()
+	meta.string(6, "server")
+	meta.endStruct()
+
+	footer := meta.buf.Bytes()
+	file.Write(footer)
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	file.WriteString(magic)
+	return file.WriteTo(out)
+}
+
+// writePage записывает колонку одной страницей данных и возвращает её
+// размер вместе с заголовком.
+func (w *Writer) writePage(file *bytes.Buffer, column Column, chunk *columnChunk) int64 {
+	var page bytes.Buffer
+	if column.Optional {
+		levels := encodeLevels(chunk.defined)
+		page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+		page.Write(levels)
+	}
+	page.Write(chunk.values.Bytes())
+	if chunk.nbits > 0 {
+		page.WriteByte(chunk.bits)
+	}
+
+	header := newCompactWriter()
+	header.beginStruct(0)
+	header.i32(1, pageTypeData)
+	header.i32(2, int32(page.Len()))
+	header.i32(3, int32(page.Len()))
+	header.beginStruct(5)
+	header.i32(1, int32(w.rows))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.endStruct()
+	header.endStruct()
+
+	n := header.buf.Len() + page.Len()
+	file.Write(header.buf.Bytes())
+	file.Write(page.Bytes())
+	return int64(n)
+}
+
+// encodeLevels кодирует уровни определения (0 — nil, 1 — значение)
+// сериями RLE гибридной кодировки с шириной в один бит.
+func encodeLevels(defined []bool) []byte {
+	var out []byte
+	for i := 0; i < len(defined); {
+		j := i + 1
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if defined[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+func physicalType(t Type) int32 {
+	switch t {
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	case Boolean:
+		return physicalBoolean
+	default:
+		return physicalInt64
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"github.com/lib/pq"
+	"server/internal/auth"
+	"server/internal/models"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// apiKeyPrefix отличает ключи API от других токенов, например в логах
+	// сканеров утечек.
+	apiKeyPrefix = "ak_"
+	// apiKeyLookupTimeout ограничивает проверку ключа в базе.
+	apiKeyLookupTimeout = 2 * time.Second
+)
+
+// apiKeyScopes — права, которые можно выдать ключу. Управлять
+// пользователями и модерировать чат ключом нельзя.
+var apiKeyScopes = []auth.Permission{auth.PermRead, auth.PermWrite, auth.PermDelete}
+
+// APIKeyService выдаёт и проверяет ключи API машинных клиентов, которые не
+// могут войти по паролю, например импорта фида по расписанию. Ключ
+// хранится хешем и проверяется в базе на каждом запросе, так что отзыв
+// действует сразу на всех экземплярах.
+type APIKeyService struct {
+	db *sql.DB
+}
+
+func NewAPIKeyService(db *sql.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+const apiKeyColumns = "id, name, prefix, scopes, created_at, expires_at, revoked_at"
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }, k *models.APIKey) error {
+	var expiresAt, revokedAt sql.NullTime
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.CreatedAt, &expiresAt, &revokedAt); err != nil {
+		return err
+	}
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return nil
+}
+
+// List возвращает все ключи, включая отозванные и истёкшие.
+func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := scanAPIKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Create выдаёт ключ с правами scopes; expiresAt == nil — бессрочный.
+// Возвращённый ключ содержит Key: позже его узнать нельзя.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 255 {
+		return models.APIKey{}, invalid("name must be 1-255 characters")
+	}
+	scopes, err := normalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return models.APIKey{}, invalid("expires_at must be in the future")
+	}
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return models.APIKey{}, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+
+	var created models.APIKey
+	if err := scanAPIKey(s.db.QueryRowContext(ctx,
+		"INSERT INTO api_keys (name, key_hash, prefix, scopes, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING "+apiKeyColumns,
+		name, hashAPIKey(key), key[:len(apiKeyPrefix)+8], pq.Array(scopes), expiresAt), &created); err != nil {
+		return models.APIKey{}, err
+	}
+	created.Key = key
+	return created, nil
+}
+
+// Revoke отзывает ключ. Повторный отзыв не меняет время первого.
+func (s *APIKeyService) Revoke(ctx context.Context, id int) error {
+	return affectOne(s.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1", id))
+}
+
+// Authenticate проверяет ключ и возвращает вызывающего с правами ключа.
+// Неизвестный, отозванный или истёкший ключ — auth.ErrUnauthenticated.
+func (s *APIKeyService) Authenticate(key string) (auth.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return auth.Principal{}, auth.ErrUnauthenticated
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiKeyLookupTimeout)
+	defer cancel()
+
+	var id int
+	var name string
+	var scopes []string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, scopes FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`,
+		hashAPIKey(key)).Scan(&id, &name, pq.Array(&scopes))
+	if err == sql.ErrNoRows {
+		return auth.Principal{}, auth.ErrUnauthenticated
+	}
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	principal := auth.Principal{Subject: "api-key:" + strconv.Itoa(id), Name: name, Scopes: []auth.Permission{}}
+	for _, scope := range scopes {
+		principal.Scopes = append(principal.Scopes, auth.Permission(scope))
+	}
+	return principal, nil
+}
+
+// normalizeAPIKeyScopes проверяет права ключа и убирает повторы.
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	allowed := make([]string, len(apiKeyScopes))
+	for i, scope := range apiKeyScopes {
+		allowed[i] = string(scope)
+	}
+	if len(scopes) == 0 {
+		return nil, invalid("scopes must list at least one of " + strings.Join(allowed, ", "))
+	}
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if seen[scope] {
+			continue
+		}
+		known := false
+		for _, a := range allowed {
+			known = known || a == scope
+		}
+		if !known {
+			return nil, invalid("unknown scope " + strconv.Quote(scope) + "; available: " + strings.Join(allowed, ", "))
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"server/internal/imgproxy"
+	"server/internal/models"
+	"server/internal/storage"
+	"strings"
+)
+
+const attachmentColumns = "id, product_id, file_name, content_type, size, storage_key, created_at"
+
+func scanAttachment(row interface{ Scan(...interface{}) error }, a *models.Attachment) error {
+	if err := row.Scan(&a.ID, &a.ProductID, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+		return err
+	}
+	a.URL = fmt.Sprintf("/api/attachments/%d", a.ID)
+	return nil
+}
+
+// attachAttachments дополняет продукты списком вложений одним запросом.
+func (s *ProductService) attachAttachments(ctx context.Context, products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	index := make(map[int]int, len(products))
+	for i, product := range products {
+		ids[i] = int64(product.ID)
+		index[product.ID] = i
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT "+attachmentColumns+" FROM product_attachments WHERE product_id = ANY($1) ORDER BY id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Attachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return err
+		}
+		s.images.decorate(&a)
+		i := index[a.ProductID]
+		products[i].Attachments = append(products[i].Attachments, a)
+	}
+	return rows.Err()
+}
+
+// AttachmentService управляет файлами, прикреплёнными к продуктам.
+type AttachmentService struct {
+	db           *sql.DB
+	store        *storage.Local
+	products     *ProductService
+	maxSize      int64
+	allowedTypes map[string]bool
+}
+
+type AttachmentOptions struct {
+	// MaxSize — максимальный размер файла в байтах.
+	MaxSize int64
+	// AllowedTypes — допустимые MIME-типы вложений.
+	AllowedTypes []string
+}
+
+func NewAttachmentService(db *sql.DB, store *storage.Local, products *ProductService, opts AttachmentOptions) *AttachmentService {
+	allowed := make(map[string]bool, len(opts.AllowedTypes))
+	for _, t := range opts.AllowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &AttachmentService{db: db, store: store, products: products, maxSize: opts.MaxSize, allowedTypes: allowed}
+}
+
+// detectContentType определяет MIME-тип по содержимому, уточняя его по расширению,
+// если сигнатура не распознана.
+func detectContentType(head []byte, fileName string) string {
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if contentType == "application/octet-stream" || contentType == "text/plain" {
+		if byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(fileName))); err == nil && byExt != "" {
+			return byExt
+		}
+	}
+	return contentType
+}
+
+// Upload сохраняет файл и привязывает его к продукту.
+func (s *AttachmentService) Upload(productID int, fileName string, size int64, r io.Reader) (models.Attachment, error) {
+	return s.upload(productID, fileName, size, r, false)
+}
+
+// UploadImage работает как Upload, но принимает только картинки.
+func (s *AttachmentService) UploadImage(productID int, fileName string, size int64, r io.Reader) (models.Attachment, error) {
+	return s.upload(productID, fileName, size, r, true)
+}
+
+func (s *AttachmentService) upload(productID int, fileName string, size int64, r io.Reader, imageOnly bool) (models.Attachment, error) {
+	fileName = filepath.Base(strings.TrimSpace(fileName))
+	if fileName == "" || fileName == "." {
+		return models.Attachment{}, invalid("file name is required")
+	}
+	if size > s.maxSize {
+		return models.Attachment{}, invalid(fmt.Sprintf("file exceeds maximum size of %d bytes", s.maxSize))
+	}
+
+	exists, err := s.products.exists(productID)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if !exists {
+		return models.Attachment{}, ErrNotFound
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return models.Attachment{}, err
+	}
+	head = head[:n]
+	contentType := detectContentType(head, fileName)
+	if !s.allowedTypes[contentType] {
+		return models.Attachment{}, invalid(fmt.Sprintf("content type %q is not allowed", contentType))
+	}
+	if imageOnly && !strings.HasPrefix(contentType, "image/") {
+		return models.Attachment{}, invalid(fmt.Sprintf("content type %q is not an image", contentType))
+	}
+
+	// Ограничиваем чтение, чтобы заявленный размер нельзя было обойти.
+	hash := sha256.New()
+	limited := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.maxSize+1)
+	key, written, err := s.store.Save(io.TeeReader(limited, hash))
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if written > s.maxSize {
+		s.store.Remove(key)
+		return models.Attachment{}, invalid(fmt.Sprintf("file exceeds maximum size of %d bytes", s.maxSize))
+	}
+
+	a, storedKey, err := s.insert(productID, fileName, contentType, written, key, hex.EncodeToString(hash.Sum(nil)))
+	// Сохранённая копия не нужна, если запись не удалась или такой файл уже был.
+	if err != nil || storedKey != key {
+		s.store.Remove(key)
+	}
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	s.products.InvalidateCache()
+	s.products.images.decorate(&a)
+	return a, nil
+}
+
+// insert создаёт запись вложения, ссылающуюся на файл с содержимым sum, и
+// возвращает ключ файла, который она использует.
+func (s *AttachmentService) insert(productID int, fileName, contentType string, size int64, key, sum string) (models.Attachment, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Attachment{}, "", err
+	}
+	defer tx.Rollback()
+
+	storedKey, err := acquireBlob(tx, key, sum, size)
+	if err != nil {
+		return models.Attachment{}, "", err
+	}
+	var a models.Attachment
+	err = scanAttachment(tx.QueryRow(
+		"INSERT INTO product_attachments (product_id, file_name, content_type, size, storage_key) VALUES ($1, $2, $3, $4, $5) RETURNING "+attachmentColumns,
+		productID, fileName, contentType, size, storedKey), &a)
+	if err != nil {
+		return models.Attachment{}, "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Attachment{}, "", err
+	}
+	return a, storedKey, nil
+}
+
+func (s *AttachmentService) List(productID int) ([]models.Attachment, error) {
+	rows, err := s.db.Query("SELECT "+attachmentColumns+" FROM product_attachments WHERE product_id = $1 ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var a models.Attachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return nil, err
+		}
+		s.products.images.decorate(&a)
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// Get возвращает метаданные вложения и путь к файлу для отдачи клиенту.
+func (s *AttachmentService) Get(id int) (models.Attachment, string, error) {
+	var a models.Attachment
+	err := scanAttachment(s.db.QueryRow("SELECT "+attachmentColumns+" FROM product_attachments WHERE id = $1", id), &a)
+	if err == sql.ErrNoRows {
+		return models.Attachment{}, "", ErrNotFound
+	}
+	if err != nil {
+		return models.Attachment{}, "", err
+	}
+	return a, s.store.Path(a.StorageKey), nil
+}
+
+// ImageURL возвращает подписанную ссылку imgproxy на вложение-изображение
+// с заданными параметрами обработки.
+func (s *AttachmentService) ImageURL(id int, opts imgproxy.Options) (string, error) {
+	a, _, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+	return s.products.images.URL(a, opts)
+}
+
+// RemoveFiles удаляет файлы вложений, строки которых уже удалены из базы.
+func (s *AttachmentService) RemoveFiles(keys []string) error {
+	for _, key := range keys {
+		if err := s.store.Remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete удаляет вложение; файл удаляется из хранилища, только если на него
+// не ссылаются другие вложения.
+func (s *AttachmentService) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var key string
+	err = tx.QueryRow("DELETE FROM product_attachments WHERE id = $1 RETURNING storage_key", id).Scan(&key)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	orphaned, err := releaseBlobs(tx, []string{key})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.products.InvalidateCache()
+	return s.RemoveFiles(orphaned)
+}
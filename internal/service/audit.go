@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"server/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Действия журнала аудита.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+const (
+	defaultAuditPage = 100
+	maxAuditPage     = 1000
+)
+
+// auditRedacted заменяет в журнале значения полей с паролями, секретами и
+// токенами.
+const auditRedacted = "[redacted]"
+
+// AuditService ведёт журнал аудита изменяющих запросов для проверок
+// соответствия. Записи только добавляются.
+type AuditService struct {
+	db *sql.DB
+}
+
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record добавляет запись в журнал.
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (actor, actor_name, action, method, path, resource, resource_id, status, changes, ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		entry.Actor, entry.ActorName, entry.Action, entry.Method, entry.Path, entry.Resource, entry.ResourceID,
+		entry.Status, changes, entry.IP, entry.RequestID)
+	return err
+}
+
+// AuditQuery отбирает записи журнала; пустые поля не ограничивают выборку.
+// Before — ID, с которого начинается следующая, более ранняя страница.
+type AuditQuery struct {
+	Actor      string
+	Action     string
+	Resource   string
+	ResourceID string
+	Since      time.Time
+	Until      time.Time
+	Before     int64
+	Limit      int
+}
+
+// List возвращает до Limit записей (по умолчанию 100, не больше 1000),
+// новые сначала.
+func (s *AuditService) List(ctx context.Context, q AuditQuery) ([]models.AuditEntry, error) {
+	if q.Limit < 0 || q.Before < 0 {
+		return nil, invalid("limit and before must be non-negative")
+	}
+	if q.Limit > maxAuditPage {
+		return nil, invalid(fmt.Sprintf("limit must not exceed %d", maxAuditPage))
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultAuditPage
+	}
+	switch q.Action {
+	case "", AuditCreate, AuditUpdate, AuditDelete:
+	default:
+		return nil, invalid("action must be create, update or delete")
+	}
+
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if q.Actor != "" {
+		where("actor = $%d", q.Actor)
+	}
+	if q.Action != "" {
+		where("action = $%d", q.Action)
+	}
+	if q.Resource != "" {
+		where("resource = $%d", q.Resource)
+	}
+	if q.ResourceID != "" {
+		where("resource_id = $%d", q.ResourceID)
+	}
+	if !q.Since.IsZero() {
+		where("created_at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		where("created_at < $%d", q.Until)
+	}
+	if q.Before > 0 {
+		where("id < $%d", q.Before)
+	}
+	query := `SELECT id, created_at, actor, actor_name, action, method, path, resource, resource_id, status, changes, ip, request_id
+		FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT %d", q.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.CreatedAt, &entry.Actor, &entry.ActorName, &entry.Action, &entry.Method,
+			&entry.Path, &entry.Resource, &entry.ResourceID, &entry.Status, &changes, &entry.IP, &entry.RequestID); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// AuditChanges собирает изменения, о которых сервисы сообщили за один
+// запрос. Её кладёт в контекст запроса WithAudit.
+type AuditChanges struct {
+	mu       sync.Mutex
+	diff     map[string]models.AuditChange
+	recorded bool
+}
+
+type auditKey struct{}
+
+// WithAudit возвращает контекст, в который сервисы записывают прежние и
+// новые значения изменённых объектов.
+func WithAudit(ctx context.Context, changes *AuditChanges) context.Context {
+	return context.WithValue(ctx, auditKey{}, changes)
+}
+
+// Diff возвращает изменения, о которых сообщили сервисы; ok ложно, если
+// ни один не сообщил.
+func (a *AuditChanges) Diff() (diff map[string]models.AuditChange, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.diff, a.recorded
+}
+
+// auditing сообщает, ведётся ли для запроса журнал аудита: сервису стоит
+// читать прежнее состояние объекта, только если его есть куда записать.
+func auditing(ctx context.Context) bool {
+	_, ok := ctx.Value(auditKey{}).(*AuditChanges)
+	return ok
+}
+
+// recordChange добавляет к изменениям запроса разницу между before и after.
+func recordChange(ctx context.Context, before, after interface{}) {
+	changes, ok := ctx.Value(auditKey{}).(*AuditChanges)
+	if !ok {
+		return
+	}
+	diff := AuditDiff(before, after)
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	if changes.diff == nil {
+		changes.diff = map[string]models.AuditChange{}
+	}
+	for field, change := range diff {
+		changes.diff[field] = change
+	}
+	changes.recorded = true
+}
+
+// AuditDiff сравнивает JSON-представления before и after по полям верхнего
+// уровня; nil — объекта нет. Значения паролей, секретов и токенов
+// заменяются на [redacted].
+func AuditDiff(before, after interface{}) map[string]models.AuditChange {
+	was, now := auditFields(before), auditFields(after)
+	diff := map[string]models.AuditChange{}
+	for field, value := range was {
+		if newValue, ok := now[field]; !ok || !reflect.DeepEqual(value, newValue) {
+			diff[field] = models.AuditChange{Old: value, New: newValue}
+		}
+	}
+	for field, value := range now {
+		if _, ok := was[field]; !ok {
+			diff[field] = models.AuditChange{New: value}
+		}
+	}
+	for field, change := range diff {
+		if sensitiveField(field) {
+			if change.Old != nil {
+				change.Old = auditRedacted
+			}
+			if change.New != nil {
+				change.New = auditRedacted
+			}
+			diff[field] = change
+		}
+	}
+	return diff
+}
+
+// auditFields разбирает объект в поля его JSON-представления; не объект —
+// пусто.
+func auditFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields
+	}
+	data, ok := v.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return fields
+		}
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func sensitiveField(field string) bool {
+	field = strings.ToLower(field)
+	for _, word := range []string{"password", "secret", "token", "apikey", "api_key"} {
+		if strings.Contains(field, word) {
+			return true
+		}
+	}
+	return false
+}
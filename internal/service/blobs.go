@@ -0,0 +1,54 @@
+package service
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+// Файлы вложений хранятся по содержимому: одинаковые файлы, загруженные
+// несколько раз, занимают место один раз. attachment_blobs считает ссылки
+// на каждый файл, и файл удаляется из хранилища, когда ссылок не осталось.
+
+// acquireBlob регистрирует только что сохранённый файл key с хешем sum.
+// Если файл с таким содержимым уже есть, увеличивает его счётчик ссылок и
+// возвращает его ключ — тогда сохранённую копию нужно удалить. Вставка с ON
+// CONFLICT атомарна, так что параллельные загрузки одного файла не создадут
+// две записи.
+func acquireBlob(tx *sql.Tx, key, sum string, size int64) (string, error) {
+	var stored string
+	err := tx.QueryRow(`
+		INSERT INTO attachment_blobs (storage_key, sha256, size, ref_count) VALUES ($1, $2, $3, 1)
+		ON CONFLICT (sha256) DO UPDATE SET ref_count = attachment_blobs.ref_count + 1
+		RETURNING storage_key`, key, sum, size).Scan(&stored)
+	return stored, err
+}
+
+// releaseBlobs снимает по ссылке за каждый ключ (ключи могут повторяться) и
+// удаляет записи файлов, на которые больше никто не ссылается. Возвращает их
+// ключи: файлы нужно удалить из хранилища после фиксации транзакции.
+func releaseBlobs(tx *sql.Tx, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if _, err := tx.Exec(`
+		UPDATE attachment_blobs b SET ref_count = b.ref_count - r.n
+		FROM (SELECT key, COUNT(*) AS n FROM unnest($1::text[]) AS key GROUP BY key) r
+		WHERE b.storage_key = r.key`, pq.Array(keys)); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("DELETE FROM attachment_blobs WHERE storage_key = ANY($1) AND ref_count <= 0 RETURNING storage_key", pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orphaned []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		orphaned = append(orphaned, key)
+	}
+	return orphaned, rows.Err()
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"server/internal/models"
+	"sync"
+	"time"
+)
+
+// listCache кэширует страницы списка продуктов, общий для REST и GraphQL.
+type listCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]listCacheEntry
+	// hooks вызываются при каждом сбросе, например чтобы сбросить кэш
+	// ответов GraphQL.
+	hooks []func()
+	// localHooks вызываются только при изменениях, сделанных этим
+	// экземпляром, например чтобы сообщить о них остальным.
+	localHooks []func()
+}
+
+type listCacheEntry struct {
+	products  []models.Product
+	expiresAt time.Time
+}
+
+func newListCache(ttl time.Duration) *listCache {
+	return &listCache{ttl: ttl, entries: make(map[string]listCacheEntry)}
+}
+
+func (lc *listCache) get(key string) ([]models.Product, bool) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	entry, ok := lc.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.products, true
+}
+
+func (lc *listCache) set(key string, products []models.Product) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.entries[key] = listCacheEntry{products: products, expiresAt: time.Now().Add(lc.ttl)}
+}
+
+// invalidate сбрасывает кэш после любых изменений продуктов.
+func (lc *listCache) invalidate() {
+	lc.drop()
+	lc.mu.RLock()
+	hooks := lc.localHooks
+	lc.mu.RUnlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// drop сбрасывает кэш, не считая это изменением этого экземпляра: после
+// изменений, о которых сообщил другой экземпляр.
+func (lc *listCache) drop() {
+	lc.mu.Lock()
+	lc.entries = make(map[string]listCacheEntry)
+	hooks := lc.hooks
+	lc.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+func (lc *listCache) onInvalidate(fn func()) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.hooks = append(lc.hooks, fn)
+}
+
+func (lc *listCache) onLocalInvalidate(fn func()) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.localHooks = append(lc.localHooks, fn)
+}
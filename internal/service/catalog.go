@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"server/internal/events"
+	"server/internal/models"
+	"strings"
+)
+
+// Categories возвращает категории каталога по алфавиту с числом продуктов.
+func (s *ProductService) Categories(ctx context.Context) ([]models.Category, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT category, COUNT(*) FROM products, UNNEST(categories) AS category
+		WHERE deleted_at IS NULL GROUP BY category ORDER BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.Name, &c.ProductCount); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// Category возвращает категорию по имени; категория без продуктов не существует.
+func (s *ProductService) Category(ctx context.Context, name string) (models.Category, error) {
+	c := models.Category{Name: name}
+	err := s.reader(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM products WHERE $1 = ANY(categories) AND deleted_at IS NULL", name).
+		Scan(&c.ProductCount)
+	if err != nil {
+		return models.Category{}, err
+	}
+	if c.ProductCount == 0 {
+		return models.Category{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// CategorySizes возвращает число продуктов в указанных категориях одним запросом.
+func (s *ProductService) CategorySizes(ctx context.Context, names []string) (map[string]int, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT category, COUNT(*) FROM products, UNNEST(categories) AS category
+		WHERE deleted_at IS NULL AND category = ANY($1) GROUP BY category`, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sizes := make(map[string]int, len(names))
+	for rows.Next() {
+		var (
+			name  string
+			count int
+		)
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, err
+		}
+		sizes[name] = count
+	}
+	return sizes, rows.Err()
+}
+
+const reviewColumns = "id, product_id, author, rating, body, created_at"
+
+func scanReview(row interface{ Scan(...interface{}) error }, r *models.Review) error {
+	return row.Scan(&r.ID, &r.ProductID, &r.Author, &r.Rating, &r.Body, &r.CreatedAt)
+}
+
+// ReviewFilter сужает выборку отзывов; нулевые поля не ограничивают её.
+type ReviewFilter struct {
+	ProductID int
+	MinRating int
+	Limit     int
+	Offset    int
+}
+
+// Reviews возвращает отзывы, новые первыми.
+func (s *ProductService) Reviews(ctx context.Context, filter ReviewFilter) ([]models.Review, error) {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, invalid("limit and offset must be non-negative")
+	}
+	conditions := []string{"TRUE"}
+	var args []interface{}
+	if filter.ProductID != 0 {
+		args = append(args, filter.ProductID)
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", len(args)))
+	}
+	if filter.MinRating != 0 {
+		args = append(args, filter.MinRating)
+		conditions = append(conditions, fmt.Sprintf("rating >= $%d", len(args)))
+	}
+	query := "SELECT " + reviewColumns + " FROM product_reviews WHERE " + strings.Join(conditions, " AND ") + " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	reviews := []models.Review{}
+	for rows.Next() {
+		var r models.Review
+		if err := scanReview(rows, &r); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// LatestReviews загружает не более limit последних отзывов каждого продукта
+// одним запросом.
+func (s *ProductService) LatestReviews(ctx context.Context, productIDs []int, limit int) (map[int][]models.Review, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT `+reviewColumns+` FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY created_at DESC, id DESC) AS n
+			FROM product_reviews WHERE product_id = ANY($1)
+		) ranked WHERE n <= $2 ORDER BY product_id, n`, pq.Array(int64s(productIDs)), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	reviews := make(map[int][]models.Review, len(productIDs))
+	for rows.Next() {
+		var r models.Review
+		if err := scanReview(rows, &r); err != nil {
+			return nil, err
+		}
+		reviews[r.ProductID] = append(reviews[r.ProductID], r)
+	}
+	return reviews, rows.Err()
+}
+
+// ReviewSummaries возвращает число отзывов и средний рейтинг продуктов;
+// продукты без отзывов в результат не попадают.
+func (s *ProductService) ReviewSummaries(ctx context.Context, productIDs []int) (map[int]models.ReviewSummary, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx,
+		"SELECT product_id, COUNT(*), AVG(rating)::float8 FROM product_reviews WHERE product_id = ANY($1) GROUP BY product_id",
+		pq.Array(int64s(productIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	summaries := make(map[int]models.ReviewSummary, len(productIDs))
+	for rows.Next() {
+		var (
+			id      int
+			summary models.ReviewSummary
+		)
+		if err := rows.Scan(&id, &summary.Count, &summary.Average); err != nil {
+			return nil, err
+		}
+		summaries[id] = summary
+	}
+	return summaries, rows.Err()
+}
+
+// AddReview добавляет отзыв к существующему продукту.
+func (s *ProductService) AddReview(ctx context.Context, review models.Review) (models.Review, error) {
+	review.Author = strings.TrimSpace(review.Author)
+	if review.Author == "" {
+		return models.Review{}, invalid("author is required")
+	}
+	if review.Rating < 1 || review.Rating > 5 {
+		return models.Review{}, invalid("rating must be between 1 and 5")
+	}
+	exists, err := s.exists(review.ProductID)
+	if err != nil {
+		return models.Review{}, err
+	}
+	if !exists {
+		return models.Review{}, ErrNotFound
+	}
+
+	var created models.Review
+	err = scanReview(s.db.QueryRowContext(ctx,
+		"INSERT INTO product_reviews (product_id, author, rating, body) VALUES ($1, $2, $3, $4) RETURNING "+reviewColumns,
+		review.ProductID, review.Author, review.Rating, review.Body), &created)
+	return created, err
+}
+
+const stockColumns = "product_id, warehouse, quantity, updated_at"
+
+func scanStock(row interface{ Scan(...interface{}) error }, l *models.StockLevel) error {
+	return row.Scan(&l.ProductID, &l.Warehouse, &l.Quantity, &l.UpdatedAt)
+}
+
+// StockFilter сужает выборку остатков; нулевые поля не ограничивают её.
+type StockFilter struct {
+	Warehouse string
+	// Below — только остатки меньше указанного количества (например, для дозаказа).
+	Below *int
+}
+
+// Inventory возвращает остатки по складам, упорядоченные по складу и продукту.
+// Остатки удалённых продуктов не показываются.
+func (s *ProductService) Inventory(ctx context.Context, filter StockFilter) ([]models.StockLevel, error) {
+	conditions := []string{"p.deleted_at IS NULL"}
+	var args []interface{}
+	if filter.Warehouse != "" {
+		args = append(args, filter.Warehouse)
+		conditions = append(conditions, fmt.Sprintf("s.warehouse = $%d", len(args)))
+	}
+	if filter.Below != nil {
+		args = append(args, *filter.Below)
+		conditions = append(conditions, fmt.Sprintf("s.quantity < $%d", len(args)))
+	}
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT s.product_id, s.warehouse, s.quantity, s.updated_at
+		FROM product_stock s JOIN products p ON p.id = s.product_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY s.warehouse, s.product_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanStockLevels(rows)
+}
+
+// StockFor загружает остатки продуктов по всем складам одним запросом.
+func (s *ProductService) StockFor(ctx context.Context, productIDs []int) (map[int][]models.StockLevel, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx,
+		"SELECT "+stockColumns+" FROM product_stock WHERE product_id = ANY($1) ORDER BY product_id, warehouse",
+		pq.Array(int64s(productIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	levels, err := scanStockLevels(rows)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := make(map[int][]models.StockLevel, len(productIDs))
+	for _, level := range levels {
+		byProduct[level.ProductID] = append(byProduct[level.ProductID], level)
+	}
+	return byProduct, nil
+}
+
+func scanStockLevels(rows *sql.Rows) ([]models.StockLevel, error) {
+	levels := []models.StockLevel{}
+	for rows.Next() {
+		var l models.StockLevel
+		if err := scanStock(rows, &l); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, rows.Err()
+}
+
+// SetStock задаёт остаток продукта на складе.
+func (s *ProductService) SetStock(ctx context.Context, level models.StockLevel) (models.StockLevel, error) {
+	level.Warehouse = strings.TrimSpace(level.Warehouse)
+	if level.Warehouse == "" {
+		return models.StockLevel{}, invalid("warehouse is required")
+	}
+	if level.Quantity < 0 {
+		return models.StockLevel{}, invalid("quantity must be non-negative")
+	}
+	exists, err := s.exists(level.ProductID)
+	if err != nil {
+		return models.StockLevel{}, err
+	}
+	if !exists {
+		return models.StockLevel{}, ErrNotFound
+	}
+
+	var saved models.StockLevel
+	err = scanStock(s.db.QueryRowContext(ctx, `
+		INSERT INTO product_stock (product_id, warehouse, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, warehouse) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
+		RETURNING `+stockColumns,
+		level.ProductID, level.Warehouse, level.Quantity), &saved)
+	if err != nil {
+		return models.StockLevel{}, err
+	}
+	s.events.Publish(ctx, events.StockChanged, saved)
+	return saved, nil
+}
+
+func int64s(ids []int) []int64 {
+	out := make([]int64, len(ids))
+	for i, id := range ids {
+		out[i] = int64(id)
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"server/internal/models"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// remotePageSize — размер страницы при чтении каталога другого окружения;
+// не больше LIST_MAX_LIMIT по умолчанию.
+const remotePageSize = 500
+
+// CatalogDiffer сравнивает локальный каталог с каталогом другого экземпляра
+// сервиса, например staging, и переносит отличия, чтобы продвинуть каталог
+// в production.
+type CatalogDiffer struct {
+	products *ProductService
+	client   *http.Client
+}
+
+func NewCatalogDiffer(products *ProductService, timeout time.Duration) *CatalogDiffer {
+	return &CatalogDiffer{products: products, client: &http.Client{Timeout: timeout}}
+}
+
+// CatalogSource — экземпляр, с которым сравнивается каталог: базовый адрес и
+// токен. С токеном администратора сравнивается и себестоимость.
+type CatalogSource struct {
+	URL    string
+	APIKey string
+}
+
+// Diff сравнивает каталоги; с apply переносит отличия в локальный каталог:
+// лишние продукты уходят в корзину, отличающиеся обновляются, недостающие
+// создаются. Применение не атомарно: при ошибке уже перенесённые изменения
+// остаются.
+func (d *CatalogDiffer) Diff(ctx context.Context, source CatalogSource, apply bool) (models.CatalogDiff, error) {
+	base, err := url.Parse(strings.TrimRight(source.URL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return models.CatalogDiff{}, invalid("url must be an absolute http or https URL")
+	}
+	remote, err := d.fetch(ctx, base, source.APIKey)
+	if err != nil {
+		return models.CatalogDiff{}, err
+	}
+	local, err := d.products.queryProducts(FromPrimary(ctx),
+		"SELECT "+productColumns+" FROM products WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return models.CatalogDiff{}, err
+	}
+	for i := range local {
+		setMargin(&local[i])
+	}
+
+	diff := compareCatalogs(local, remote)
+	if apply {
+		if err := d.apply(ctx, diff); err != nil {
+			return diff, err
+		}
+		diff.Applied = true
+	}
+	return diff, nil
+}
+
+// fetch читает весь каталог экземпляра постранично через GET /api/products.
+func (d *CatalogDiffer) fetch(ctx context.Context, base *url.URL, apiKey string) ([]models.Product, error) {
+	var all []models.Product
+	for offset := 0; ; offset += remotePageSize {
+		page := *base
+		page.Path += "/api/products"
+		page.RawQuery = url.Values{"limit": {strconv.Itoa(remotePageSize)}, "offset": {strconv.Itoa(offset)}}.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, page.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch remote catalog: %w", err)
+		}
+		var products []models.Product
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			resp.Body.Close()
+			return nil, invalid(fmt.Sprintf("remote catalog responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&products)
+		resp.Body.Close()
+		if err != nil {
+			return nil, invalid("remote catalog returned invalid JSON: " + err.Error())
+		}
+		all = append(all, products...)
+		if len(products) < remotePageSize {
+			return all, nil
+		}
+	}
+}
+
+// catalogKey — ключ сопоставления продуктов разных окружений: ID у них
+// свои, поэтому используется SKU, а без него — название.
+func catalogKey(product models.Product) string {
+	if product.SKU != "" {
+		return product.SKU
+	}
+	return "name:" + product.Name
+}
+
+// indexCatalog раскладывает продукты по ключам и отмечает неоднозначные.
+func indexCatalog(products []models.Product, ambiguous map[string]bool) (map[string]models.Product, []string) {
+	byKey := make(map[string]models.Product, len(products))
+	var order []string
+	for _, product := range products {
+		key := catalogKey(product)
+		if _, ok := byKey[key]; ok {
+			ambiguous[key] = true
+			continue
+		}
+		byKey[key] = product
+		order = append(order, key)
+	}
+	return byKey, order
+}
+
+func compareCatalogs(local, remote []models.Product) models.CatalogDiff {
+	ambiguous := map[string]bool{}
+	localByKey, localOrder := indexCatalog(local, ambiguous)
+	remoteByKey, remoteOrder := indexCatalog(remote, ambiguous)
+
+	diff := models.CatalogDiff{Added: []models.Product{}, Changed: []models.CatalogChange{}, Removed: []models.Product{}, Ambiguous: []string{}}
+	for _, key := range remoteOrder {
+		if ambiguous[key] {
+			continue
+		}
+		theirs := remoteByKey[key]
+		ours, ok := localByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, theirs)
+			continue
+		}
+		if fields := changedFields(ours, theirs); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, models.CatalogChange{Key: key, Fields: fields, Local: ours, Remote: theirs})
+		}
+	}
+	for _, key := range localOrder {
+		if _, ok := remoteByKey[key]; !ok && !ambiguous[key] {
+			diff.Removed = append(diff.Removed, localByKey[key])
+		}
+	}
+	for key := range ambiguous {
+		diff.Ambiguous = append(diff.Ambiguous, key)
+	}
+	slices.Sort(diff.Ambiguous)
+	return diff
+}
+
+// changedFields перечисляет отличающиеся поля в именах JSON. Себестоимость
+// сравнивается, только если другое окружение её отдало.
+func changedFields(ours, theirs models.Product) []string {
+	var fields []string
+	if ours.Name != theirs.Name {
+		fields = append(fields, "name")
+	}
+	if ours.Price != theirs.Price {
+		fields = append(fields, "price")
+	}
+	if ours.Description != theirs.Description {
+		fields = append(fields, "description")
+	}
+	if !slices.Equal(ours.Categories, theirs.Categories) {
+		fields = append(fields, "categories")
+	}
+	if ours.SKU != theirs.SKU {
+		fields = append(fields, "sku")
+	}
+	if ours.Barcode != theirs.Barcode {
+		fields = append(fields, "barcode")
+	}
+	if theirs.CostPrice != nil && (ours.CostPrice == nil || *ours.CostPrice != *theirs.CostPrice) {
+		fields = append(fields, "cost_price")
+	}
+	return fields
+}
+
+// apply переносит отличия: сначала удаления, чтобы освободить SKU и
+// штрихкоды, затем изменения и новые продукты.
+func (d *CatalogDiffer) apply(ctx context.Context, diff models.CatalogDiff) error {
+	for _, product := range diff.Removed {
+		if err := d.products.Delete(ctx, product.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("remove product %d: %w", product.ID, err)
+		}
+	}
+	for _, change := range diff.Changed {
+		if err := d.products.Update(ctx, change.Local.ID, importable(change.Remote, change.Local.CostPrice)); err != nil {
+			return fmt.Errorf("update product %d: %w", change.Local.ID, err)
+		}
+	}
+	if len(diff.Added) == 0 {
+		return nil
+	}
+	added := make([]models.Product, len(diff.Added))
+	for i, product := range diff.Added {
+		added[i] = importable(product, nil)
+	}
+	if _, err := d.products.Create(ctx, added); err != nil {
+		return fmt.Errorf("create products: %w", err)
+	}
+	return nil
+}
+
+// importable оставляет от продукта другого окружения переносимые поля;
+// costPrice подставляется, если оно себестоимость не отдало.
+func importable(product models.Product, costPrice *float64) models.Product {
+	if product.CostPrice == nil {
+		product.CostPrice = costPrice
+	}
+	return models.Product{
+		Name: product.Name, Price: product.Price, Description: product.Description, Categories: product.Categories,
+		SKU: product.SKU, Barcode: product.Barcode, CostPrice: product.CostPrice,
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"server/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Типы изменений API.
+const (
+	ChangeAdded      = "added"
+	ChangeChanged    = "changed"
+	ChangeDeprecated = "deprecated"
+	ChangeRemoved    = "removed"
+)
+
+// Changelog — журнал изменений API, собранный из аннотаций обработчиков и
+// общих записей: // @x-changelog у операции описывает её изменения,
+// // @Deprecated у операции добавляет запись deprecated, если её нет в
+// @x-changelog, а изменения всего API перечислены отдельно, по записи на
+// строку. Журнал читается из Swagger-спецификации при запуске, поэтому
+// новое изменение маршрута достаточно описать рядом с ним.
+type Changelog struct {
+	version string
+	entries []models.ChangelogEntry
+}
+
+// swaggerSpec — части Swagger-спецификации, из которых строится журнал.
+type swaggerSpec struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths map[string]map[string]swaggerOperation `json:"paths"`
+}
+
+type swaggerOperation struct {
+	Summary    string                  `json:"summary"`
+	Deprecated bool                    `json:"deprecated"`
+	Changelog  []models.ChangelogEntry `json:"x-changelog"`
+}
+
+// NewChangelog строит журнал из Swagger-спецификации spec и JSON-массива
+// general с изменениями всего API. Запись с неизвестным типом или датой не
+// в формате ГГГГ-ММ-ДД — ошибка.
+func NewChangelog(spec string, general []byte) (*Changelog, error) {
+	var doc swaggerSpec
+	if err := json.Unmarshal([]byte(spec), &doc); err != nil {
+		return nil, fmt.Errorf("parse swagger spec: %w", err)
+	}
+	var apiEntries []models.ChangelogEntry
+	if err := json.Unmarshal(general, &apiEntries); err != nil {
+		return nil, fmt.Errorf("parse API changelog: %w", err)
+	}
+	c := &Changelog{version: doc.Info.Version, entries: []models.ChangelogEntry{}}
+	add := func(entry models.ChangelogEntry) error {
+		switch entry.Type {
+		case ChangeAdded, ChangeChanged, ChangeDeprecated, ChangeRemoved:
+		default:
+			return fmt.Errorf("changelog entry %s %s: unknown type %q", entry.Method, entry.Path, entry.Type)
+		}
+		if entry.Date != "" {
+			if _, err := time.Parse(time.DateOnly, entry.Date); err != nil {
+				return fmt.Errorf("changelog entry %s %s: date must be YYYY-MM-DD", entry.Method, entry.Path)
+			}
+		}
+		if entry.Version == "" {
+			entry.Version = doc.Info.Version
+		}
+		c.entries = append(c.entries, entry)
+		return nil
+	}
+	for _, entry := range apiEntries {
+		if err := add(entry); err != nil {
+			return nil, err
+		}
+	}
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			deprecated := false
+			for _, entry := range operation.Changelog {
+				entry.Method, entry.Path = strings.ToUpper(method), path
+				deprecated = deprecated || entry.Type == ChangeDeprecated
+				if err := add(entry); err != nil {
+					return nil, err
+				}
+			}
+			if operation.Deprecated && !deprecated {
+				if err := add(models.ChangelogEntry{
+					Type: ChangeDeprecated, Method: strings.ToUpper(method), Path: path, Description: operation.Summary,
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	sort.SliceStable(c.entries, func(i, j int) bool {
+		a, b := c.entries[i], c.entries[j]
+		if a.Date != b.Date {
+			return a.Date > b.Date
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return c, nil
+}
+
+// Entries возвращает журнал с записями не раньше since (ГГГГ-ММ-ДД);
+// пустой since — весь журнал. Записи без даты попадают только в весь
+// журнал.
+func (c *Changelog) Entries(since string) models.Changelog {
+	result := models.Changelog{Version: c.version, Entries: []models.ChangelogEntry{}}
+	for _, entry := range c.entries {
+		if since == "" || (entry.Date != "" && entry.Date >= since) {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result
+}
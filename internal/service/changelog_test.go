@@ -0,0 +1,44 @@
+package service
+
+import "testing"
+
+const testSpec = `{
+	"info": {"version": "1.1"},
+	"paths": {
+		"/api/things": {
+			"get": {"summary": "List things", "deprecated": true},
+			"post": {"x-changelog": [{"date": "2026-10-01", "type": "added", "description": "Create things."}]}
+		}
+	}
+}`
+
+func TestNewChangelog(t *testing.T) {
+	general := []byte(`[{"date": "2026-10-10", "type": "changed", "description": "Everything is faster."}]`)
+	changelog, err := NewChangelog(testSpec, general)
+	if err != nil {
+		t.Fatalf("NewChangelog() error = %v", err)
+	}
+	entries := changelog.Entries("").Entries
+	if len(entries) != 3 {
+		t.Fatalf("Entries() = %+v, want 3 entries", entries)
+	}
+	first := entries[0]
+	if first.Path != "" || first.Type != ChangeChanged || first.Version != "1.1" {
+		t.Fatalf("first entry = %+v, want the API-wide change with the spec version", first)
+	}
+	if since := changelog.Entries("2026-10-05").Entries; len(since) != 1 || since[0].Date != "2026-10-10" {
+		t.Fatalf("Entries(2026-10-05) = %+v", since)
+	}
+}
+
+func TestNewChangelogRejectsInvalidEntries(t *testing.T) {
+	for _, general := range []string{
+		`[{"type": "fixed", "description": "Unknown type."}]`,
+		`[{"date": "15.10.2026", "type": "added", "description": "Bad date."}]`,
+		`{"type": "added"}`,
+	} {
+		if _, err := NewChangelog(testSpec, []byte(general)); err == nil {
+			t.Errorf("NewChangelog(%s) succeeded, want error", general)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"server/internal/models"
+	"time"
+)
+
+const (
+	defaultHistorySize = 50
+	maxHistorySize     = 200
+)
+
+const messageColumns = "id, room, username, message, envelope_id, created_at, COALESCE(recipient, ''), attachments, edited_at"
+
+// ChatHistory хранит сообщения чата WebSocket, чтобы переподключившиеся
+// клиенты видели, о чём говорили без них.
+type ChatHistory struct {
+	db *sql.DB
+}
+
+func NewChatHistory(db *sql.DB) *ChatHistory {
+	return &ChatHistory{db: db}
+}
+
+// Append сохраняет сообщение и возвращает его с ID и временем создания.
+// Метаданные изображений сохраняются вместе с сообщением, чтобы история
+// не зависела от таблицы изображений.
+func (h *ChatHistory) Append(ctx context.Context, msg models.ChatMessage) (models.ChatMessage, error) {
+	var attachments []byte
+	if len(msg.Attachments) > 0 {
+		var err error
+		if attachments, err = json.Marshal(msg.Attachments); err != nil {
+			return msg, err
+		}
+	}
+	err := h.db.QueryRowContext(ctx, `
+		INSERT INTO messages (room, username, message, envelope_id, recipient, attachments)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6) RETURNING id, created_at`,
+		msg.Room, msg.Username, msg.Message, msg.EnvelopeID, msg.Recipient, attachments).Scan(&msg.ID, &msg.CreatedAt)
+	return msg, err
+}
+
+// HistoryQuery выбирает сообщения комнаты с ID меньше Before (0 — самые
+// новые). Limit по умолчанию 50.
+type HistoryQuery struct {
+	Room   string
+	Before int64
+	Limit  int
+}
+
+// List возвращает до Limit сообщений, предшествующих Before, в порядке
+// отправки. Следующую, более раннюю страницу клиент запрашивает с Before,
+// равным ID первого сообщения.
+func (h *ChatHistory) List(ctx context.Context, q HistoryQuery) ([]models.ChatMessage, error) {
+	if q.Limit < 0 || q.Before < 0 {
+		return nil, invalid("limit and before must be non-negative")
+	}
+	if q.Limit > maxHistorySize {
+		return nil, invalid(fmt.Sprintf("limit must not exceed %d", maxHistorySize))
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultHistorySize
+	}
+	query := "SELECT " + messageColumns + " FROM messages WHERE room = $1 AND deleted_at IS NULL"
+	args := []interface{}{q.Room}
+	if q.Before > 0 {
+		query += " AND id < $2"
+		args = append(args, q.Before)
+	}
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT %d", q.Limit)
+
+	messages, err := h.queryMessages(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// Recent возвращает последние limit сообщений комнаты (не больше 200) в
+// порядке отправки.
+func (h *ChatHistory) Recent(ctx context.Context, room string, limit int) ([]models.ChatMessage, error) {
+	return h.List(ctx, HistoryQuery{Room: room, Limit: min(limit, maxHistorySize)})
+}
+
+// Unread возвращает до limit (не больше 200) самых ранних непрочитанных
+// личных сообщений пользователя в порядке отправки.
+func (h *ChatHistory) Unread(ctx context.Context, username string, limit int) ([]models.ChatMessage, error) {
+	if limit <= 0 || limit > maxHistorySize {
+		limit = maxHistorySize
+	}
+	return h.queryMessages(ctx, "SELECT "+messageColumns+` FROM messages
+		WHERE recipient = $1 AND read_at IS NULL AND deleted_at IS NULL ORDER BY id LIMIT $2`, username, limit)
+}
+
+// MarkRead отмечает личное сообщение с конвертом envelopeID прочитанным,
+// если его получатель — username.
+func (h *ChatHistory) MarkRead(ctx context.Context, envelopeID, username string) error {
+	_, err := h.db.ExecContext(ctx,
+		"UPDATE messages SET read_at = NOW() WHERE recipient = $1 AND read_at IS NULL AND envelope_id = $2",
+		username, envelopeID)
+	return err
+}
+
+// Find возвращает сообщение с конвертом envelopeID; ok ложно, если такого
+// сообщения нет или оно удалено.
+func (h *ChatHistory) Find(ctx context.Context, envelopeID string) (models.ChatMessage, bool, error) {
+	messages, err := h.queryMessages(ctx, "SELECT "+messageColumns+" FROM messages WHERE envelope_id = $1 AND deleted_at IS NULL", envelopeID)
+	if err != nil || len(messages) == 0 {
+		return models.ChatMessage{}, false, err
+	}
+	return messages[0], true, nil
+}
+
+// Edit заменяет текст сообщения с конвертом envelopeID и возвращает время
+// правки.
+func (h *ChatHistory) Edit(ctx context.Context, envelopeID, text string) (time.Time, error) {
+	var editedAt time.Time
+	err := h.db.QueryRowContext(ctx,
+		"UPDATE messages SET message = $2, edited_at = NOW() WHERE envelope_id = $1 AND deleted_at IS NULL RETURNING edited_at",
+		envelopeID, text).Scan(&editedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, ErrNotFound
+	}
+	return editedAt, err
+}
+
+// Delete помечает сообщение с конвертом envelopeID удалённым: оно пропадает
+// из истории и непрочитанных.
+func (h *ChatHistory) Delete(ctx context.Context, envelopeID string) error {
+	return affectOne(h.db.ExecContext(ctx,
+		"UPDATE messages SET deleted_at = NOW() WHERE envelope_id = $1 AND deleted_at IS NULL", envelopeID))
+}
+
+// UnreadCount считает непрочитанные личные сообщения пользователя.
+func (h *ChatHistory) UnreadCount(ctx context.Context, username string) (models.UnreadMessages, error) {
+	rows, err := h.db.QueryContext(ctx,
+		"SELECT username, COUNT(*) FROM messages WHERE recipient = $1 AND read_at IS NULL AND deleted_at IS NULL GROUP BY username", username)
+	if err != nil {
+		return models.UnreadMessages{}, err
+	}
+	defer rows.Close()
+
+	unread := models.UnreadMessages{BySender: map[string]int{}}
+	for rows.Next() {
+		var sender string
+		var count int
+		if err := rows.Scan(&sender, &count); err != nil {
+			return models.UnreadMessages{}, err
+		}
+		unread.BySender[sender] = count
+		unread.Total += count
+	}
+	return unread, rows.Err()
+}
+
+func (h *ChatHistory) queryMessages(ctx context.Context, query string, args ...interface{}) ([]models.ChatMessage, error) {
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.ChatMessage{}
+	for rows.Next() {
+		var (
+			msg         models.ChatMessage
+			attachments []byte
+		)
+		if err := rows.Scan(&msg.ID, &msg.Room, &msg.Username, &msg.Message, &msg.EnvelopeID, &msg.CreatedAt, &msg.Recipient,
+			&attachments, &msg.EditedAt); err != nil {
+			return nil, err
+		}
+		if attachments != nil {
+			if err := json.Unmarshal(attachments, &msg.Attachments); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
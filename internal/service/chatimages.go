@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"server/internal/imaging"
+	"server/internal/models"
+	"server/internal/storage"
+	"strings"
+)
+
+// imageFormatTypes сопоставляет форматы imaging с MIME-типами, по которым
+// настраиваются допустимые изображения чата.
+var imageFormatTypes = map[string]string{
+	imaging.FormatJPEG: "image/jpeg",
+	imaging.FormatPNG:  "image/png",
+	imaging.FormatGIF:  "image/gif",
+}
+
+const chatImageColumns = "id, uploader, content_type, size, width, height, storage_key, thumbnail_key, created_at"
+
+func scanChatImage(row interface{ Scan(...interface{}) error }, img *models.ChatImage) error {
+	if err := row.Scan(&img.ID, &img.Uploader, &img.ContentType, &img.Size, &img.Width, &img.Height,
+		&img.StorageKey, &img.ThumbnailKey, &img.CreatedAt); err != nil {
+		return err
+	}
+	img.URL = "/api/chat/images/" + img.ID
+	img.ThumbnailURL = img.URL + "/thumbnail"
+	return nil
+}
+
+// ChatImageService хранит изображения, которые пользователи прикрепляют к
+// сообщениям чата. Загруженное изображение перекодируется: так из файла
+// пропадают метаданные вроде EXIF и посторонние данные после картинки, а
+// большая сторона уменьшается до MaxSide. Рядом сохраняется миниатюра.
+type ChatImageService struct {
+	db            *sql.DB
+	store         *storage.Local
+	maxSize       int64
+	maxSide       int
+	thumbnailSide int
+	allowedTypes  map[string]bool
+}
+
+type ChatImageOptions struct {
+	// MaxSize — максимальный размер загружаемого файла в байтах.
+	MaxSize int64
+	// MaxSide — до какой большей стороны уменьшается изображение.
+	MaxSide int
+	// ThumbnailSide — большая сторона миниатюры.
+	ThumbnailSide int
+	// AllowedTypes — допустимые MIME-типы: image/jpeg, image/png, image/gif.
+	AllowedTypes []string
+}
+
+func NewChatImageService(db *sql.DB, store *storage.Local, opts ChatImageOptions) *ChatImageService {
+	allowed := make(map[string]bool, len(opts.AllowedTypes))
+	for _, t := range opts.AllowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &ChatImageService{
+		db:            db,
+		store:         store,
+		maxSize:       opts.MaxSize,
+		maxSide:       opts.MaxSide,
+		thumbnailSide: opts.ThumbnailSide,
+		allowedTypes:  allowed,
+	}
+}
+
+// Upload проверяет и сохраняет изображение пользователя uploader вместе с
+// миниатюрой. Тип определяется по содержимому, а не по имени файла или
+// заголовкам запроса.
+func (s *ChatImageService) Upload(ctx context.Context, uploader string, size int64, r io.Reader) (models.ChatImage, error) {
+	if size > s.maxSize {
+		return models.ChatImage{}, invalid(fmt.Sprintf("image exceeds maximum size of %d bytes", s.maxSize))
+	}
+	// Ограничиваем чтение, чтобы заявленный размер нельзя было обойти.
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return models.ChatImage{}, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return models.ChatImage{}, invalid(fmt.Sprintf("image exceeds maximum size of %d bytes", s.maxSize))
+	}
+
+	config, format, err := imaging.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return models.ChatImage{}, invalid("file is not a JPEG, PNG or GIF image")
+	}
+	if contentType := imageFormatTypes[format]; !s.allowedTypes[contentType] {
+		return models.ChatImage{}, invalid(fmt.Sprintf("content type %q is not allowed", contentType))
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return models.ChatImage{}, invalid(fmt.Sprintf("image dimensions %dx%d are too large", config.Width, config.Height))
+	}
+	decoded, _, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return models.ChatImage{}, invalid(fmt.Sprintf("decode image: %v", err))
+	}
+
+	full := imaging.Fit(decoded, s.maxSide)
+	var fullBuf, thumbnailBuf bytes.Buffer
+	contentType, _, err := imaging.Encode(&fullBuf, full, format)
+	if err != nil {
+		return models.ChatImage{}, err
+	}
+	if _, _, err := imaging.Encode(&thumbnailBuf, imaging.Fit(decoded, s.thumbnailSide), format); err != nil {
+		return models.ChatImage{}, err
+	}
+
+	key, written, err := s.store.Save(&fullBuf)
+	if err != nil {
+		return models.ChatImage{}, err
+	}
+	thumbnailKey, _, err := s.store.Save(&thumbnailBuf)
+	if err != nil {
+		s.store.Remove(key)
+		return models.ChatImage{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		s.store.Remove(key)
+		s.store.Remove(thumbnailKey)
+		return models.ChatImage{}, err
+	}
+
+	bounds := full.Bounds()
+	var img models.ChatImage
+	err = scanChatImage(s.db.QueryRowContext(ctx, `
+		INSERT INTO chat_images (id, uploader, content_type, size, width, height, storage_key, thumbnail_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING `+chatImageColumns,
+		hex.EncodeToString(id), uploader, contentType, written, bounds.Dx(), bounds.Dy(), key, thumbnailKey), &img)
+	if err != nil {
+		s.store.Remove(key)
+		s.store.Remove(thumbnailKey)
+		return models.ChatImage{}, err
+	}
+	return img, nil
+}
+
+// Lookup возвращает найденные изображения в порядке ids; несуществующие ID
+// пропускаются.
+func (s *ChatImageService) Lookup(ctx context.Context, ids []string) ([]models.ChatImage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+chatImageColumns+" FROM chat_images WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[string]models.ChatImage, len(ids))
+	for rows.Next() {
+		var img models.ChatImage
+		if err := scanChatImage(rows, &img); err != nil {
+			return nil, err
+		}
+		byID[img.ID] = img
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	images := make([]models.ChatImage, 0, len(ids))
+	for _, id := range ids {
+		if img, ok := byID[id]; ok {
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+// Open возвращает метаданные изображения и путь к файлу для отдачи клиенту:
+// к самому изображению или, если thumbnail, к миниатюре.
+func (s *ChatImageService) Open(ctx context.Context, id string, thumbnail bool) (models.ChatImage, string, error) {
+	var img models.ChatImage
+	err := scanChatImage(s.db.QueryRowContext(ctx, "SELECT "+chatImageColumns+" FROM chat_images WHERE id = $1", id), &img)
+	if err == sql.ErrNoRows {
+		return models.ChatImage{}, "", ErrNotFound
+	}
+	if err != nil {
+		return models.ChatImage{}, "", err
+	}
+	if thumbnail {
+		return img, s.store.Path(img.ThumbnailKey), nil
+	}
+	return img, s.store.Path(img.StorageKey), nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"server/internal/parquet"
+	"strings"
+	"sync"
+	"time"
+)
+
+// datasetBuildTimeout ограничивает сборку одной выгрузки.
+const datasetBuildTimeout = 10 * time.Minute
+
+// datasetColumn — колонка набора данных и выражение, которым она читается.
+// Имена, типы и допустимость NULL не меняются между выгрузками, чтобы
+// пайплайны, которые читают файлы, не ломались.
+type datasetColumn struct {
+	parquet.Column
+	expr string
+}
+
+// productDatasetColumns — колонки набора данных продуктов в порядке файла.
+// Отзывы входят только агрегатами: авторы и тексты отзывов в выгрузку не
+// попадают. Просмотры и заказы сервер не хранит.
+var productDatasetColumns = []datasetColumn{
+	{parquet.Column{Name: "product_id", Type: parquet.Int64}, "p.id"},
+	{parquet.Column{Name: "name", Type: parquet.String}, "p.name"},
+	{parquet.Column{Name: "description", Type: parquet.String}, "COALESCE(p.description, '')"},
+	{parquet.Column{Name: "price", Type: parquet.Double}, "p.price::float8"},
+	{parquet.Column{Name: "cost_price", Type: parquet.Double, Optional: true}, "p.cost_price::float8"},
+	// categories — категории через '|', в порядке продукта.
+	{parquet.Column{Name: "categories", Type: parquet.String}, "COALESCE(array_to_string(p.categories, '|'), '')"},
+	{parquet.Column{Name: "sku", Type: parquet.String, Optional: true}, "p.sku"},
+	{parquet.Column{Name: "review_count", Type: parquet.Int64},
+		"(SELECT COUNT(*) FROM product_reviews r WHERE r.product_id = p.id)"},
+	{parquet.Column{Name: "rating_avg", Type: parquet.Double, Optional: true},
+		"(SELECT AVG(r.rating)::float8 FROM product_reviews r WHERE r.product_id = p.id)"},
+	{parquet.Column{Name: "stock_quantity", Type: parquet.Int64},
+		"(SELECT COALESCE(SUM(s.quantity), 0) FROM product_stock s WHERE s.product_id = p.id)"},
+}
+
+// ProductDatasetColumns возвращает имена колонок набора данных продуктов.
+func ProductDatasetColumns() []string {
+	names := make([]string, len(productDatasetColumns))
+	for i, column := range productDatasetColumns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// Состояния выгрузки.
+const (
+	DatasetPending = "pending"
+	DatasetReady   = "ready"
+	DatasetFailed  = "failed"
+)
+
+// DatasetExport — выгрузка набора данных с выбранными колонками.
+type DatasetExport struct {
+	Status  string   `json:"status" example:"pending"`
+	Columns []string `json:"columns" example:"product_id,price"`
+	// Error — причина неудачи сборки.
+	Error       string     `json:"error,omitempty"`
+	GeneratedAt *time.Time `json:"generated_at,omitempty"`
+	// Data — файл Parquet готовой выгрузки.
+	Data []byte `json:"-"`
+}
+
+// DatasetService собирает анонимизированные выгрузки для анализа данных.
+// Сборка идёт в фоне: первый запрос её запускает, следующие получают
+// состояние, а после готовности — файл. Готовая выгрузка хранится в памяти
+// ttl и отдаётся повторно; после этого следующий запрос собирает новую.
+type DatasetService struct {
+	reads func() *sql.DB
+	ttl   time.Duration
+
+	mu sync.Mutex
+	// exports — выгрузки по набору колонок.
+	exports map[string]*DatasetExport
+}
+
+func NewDatasetService(reads func() *sql.DB, ttl time.Duration) *DatasetService {
+	return &DatasetService{reads: reads, ttl: ttl, exports: make(map[string]*DatasetExport)}
+}
+
+// Products возвращает выгрузку продуктов с колонками columns (пусто — все
+// колонки) и при необходимости запускает её сборку. Колонки в файле идут в
+// порядке набора данных независимо от порядка в columns. Неудачная сборка
+// сообщается один раз: следующий запрос начинает новую.
+func (s *DatasetService) Products(columns []string) (DatasetExport, error) {
+	selected, err := selectDatasetColumns(columns)
+	if err != nil {
+		return DatasetExport{}, err
+	}
+	names := make([]string, len(selected))
+	for i, column := range selected {
+		names[i] = column.Name
+	}
+	key := strings.Join(names, ",")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, export := range s.exports {
+		if export.Status == DatasetReady && time.Since(*export.GeneratedAt) >= s.ttl {
+			delete(s.exports, k)
+		}
+	}
+	if export, ok := s.exports[key]; ok {
+		if export.Status == DatasetFailed {
+			delete(s.exports, key)
+		}
+		return *export, nil
+	}
+	export := &DatasetExport{Status: DatasetPending, Columns: names}
+	s.exports[key] = export
+	go s.build(key, selected)
+	return *export, nil
+}
+
+func (s *DatasetService) build(key string, columns []datasetColumn) {
+	ctx, cancel := context.WithTimeout(context.Background(), datasetBuildTimeout)
+	defer cancel()
+
+	data, err := s.writeProducts(ctx, columns)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	export := *s.exports[key]
+	if err != nil {
+		log.Printf("Не удалось собрать выгрузку продуктов (%s): %v", key, err)
+		export.Status, export.Error = DatasetFailed, err.Error()
+	} else {
+		now := time.Now()
+		export.Status, export.Data, export.GeneratedAt = DatasetReady, data, &now
+	}
+	s.exports[key] = &export
+}
+
+func (s *DatasetService) writeProducts(ctx context.Context, columns []datasetColumn) ([]byte, error) {
+	exprs := make([]string, len(columns))
+	schema := make([]parquet.Column, len(columns))
+	for i, column := range columns {
+		exprs[i] = column.expr
+		schema[i] = column.Column
+	}
+	rows, err := s.reads().QueryContext(ctx,
+		"SELECT "+strings.Join(exprs, ", ")+" FROM products p WHERE p.deleted_at IS NULL ORDER BY p.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	w := parquet.NewWriter(schema)
+	dest := make([]interface{}, len(columns))
+	for i, column := range columns {
+		switch column.Type {
+		case parquet.Int64:
+			dest[i] = new(sql.NullInt64)
+		case parquet.Double:
+			dest[i] = new(sql.NullFloat64)
+		default:
+			dest[i] = new(sql.NullString)
+		}
+	}
+	row := make([]interface{}, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, d := range dest {
+			row[i] = nil
+			switch v := d.(type) {
+			case *sql.NullInt64:
+				if v.Valid {
+					row[i] = v.Int64
+				}
+			case *sql.NullFloat64:
+				if v.Valid {
+					row[i] = v.Float64
+				}
+			case *sql.NullString:
+				if v.Valid {
+					row[i] = v.String
+				}
+			}
+		}
+		if err := w.Append(row...); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// selectDatasetColumns возвращает колонки с именами names в порядке набора
+// данных; пустой names — все колонки.
+func selectDatasetColumns(names []string) ([]datasetColumn, error) {
+	if len(names) == 0 {
+		return productDatasetColumns, nil
+	}
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		requested[name] = true
+	}
+	var selected []datasetColumn
+	for _, column := range productDatasetColumns {
+		if requested[column.Name] {
+			selected = append(selected, column)
+			delete(requested, column.Name)
+		}
+	}
+	for _, name := range names {
+		if !requested[name] {
+			continue
+		}
+		return nil, invalid(fmt.Sprintf("unknown column %q; available: %s", name, strings.Join(ProductDatasetColumns(), ", ")))
+	}
+	return selected, nil
+}
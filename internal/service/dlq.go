@@ -0,0 +1,157 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"server/internal/models"
+	"sync"
+)
+
+// Источники записей DLQ.
+const (
+	DeadLetterWebhook = "webhook"
+	DeadLetterJob     = "job"
+)
+
+// DeadLetterQueue хранит неудачные доставки и запуски задач до ручного разбора.
+// Повтор выполняет обработчик, зарегистрированный для источника записи.
+type DeadLetterQueue struct {
+	db       *sql.DB
+	mu       sync.RWMutex
+	retriers map[string]func(models.DeadLetter) error
+}
+
+func NewDeadLetterQueue(db *sql.DB) *DeadLetterQueue {
+	return &DeadLetterQueue{db: db, retriers: make(map[string]func(models.DeadLetter) error)}
+}
+
+// RegisterRetrier задаёт обработчик повтора для записей указанного источника.
+func (q *DeadLetterQueue) RegisterRetrier(source string, retry func(models.DeadLetter) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.retriers[source] = retry
+}
+
+// Add помещает неудачную операцию в очередь. Ошибки записи только логируются:
+// вызывающий уже обрабатывает исходный сбой.
+func (q *DeadLetterQueue) Add(source, kind string, payload interface{}, cause error) {
+	if payload == nil {
+		payload = struct{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Не удалось сериализовать запись DLQ %s/%s: %v", source, kind, err)
+		return
+	}
+	_, err = q.db.Exec("INSERT INTO dead_letters (source, kind, payload, error) VALUES ($1, $2, $3, $4)",
+		source, kind, data, cause.Error())
+	if err != nil {
+		log.Printf("Не удалось сохранить запись DLQ %s/%s: %v", source, kind, err)
+	}
+}
+
+const deadLetterColumns = "id, source, kind, payload, error, attempts, created_at, last_attempt_at"
+
+func scanDeadLetter(row interface{ Scan(...interface{}) error }, dl *models.DeadLetter) error {
+	var payload []byte
+	err := row.Scan(&dl.ID, &dl.Source, &dl.Kind, &payload, &dl.Error, &dl.Attempts, &dl.CreatedAt, &dl.LastAttemptAt)
+	dl.Payload = payload
+	return err
+}
+
+// List возвращает записи очереди, новые первыми; source == "" — все источники.
+func (q *DeadLetterQueue) List(source string, limit int) ([]models.DeadLetter, error) {
+	query := "SELECT " + deadLetterColumns + " FROM dead_letters"
+	args := []interface{}{}
+	if source != "" {
+		query += " WHERE source = $1"
+		args = append(args, source)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := []models.DeadLetter{}
+	for rows.Next() {
+		var dl models.DeadLetter
+		if err := scanDeadLetter(rows, &dl); err != nil {
+			return nil, err
+		}
+		letters = append(letters, dl)
+	}
+	return letters, rows.Err()
+}
+
+func (q *DeadLetterQueue) Get(id int) (models.DeadLetter, error) {
+	var dl models.DeadLetter
+	err := scanDeadLetter(q.db.QueryRow("SELECT "+deadLetterColumns+" FROM dead_letters WHERE id = $1", id), &dl)
+	if err == sql.ErrNoRows {
+		return models.DeadLetter{}, ErrNotFound
+	}
+	return dl, err
+}
+
+// Retry повторяет операции; успешные записи удаляются из очереди, у неудачных
+// увеличивается счётчик попыток и обновляется текст ошибки.
+func (q *DeadLetterQueue) Retry(ids []int) ([]models.RetryOutcome, error) {
+	outcomes := make([]models.RetryOutcome, 0, len(ids))
+	for _, id := range ids {
+		outcome := models.RetryOutcome{ID: id}
+		dl, err := q.Get(id)
+		if err != nil {
+			if err != ErrNotFound {
+				return nil, err
+			}
+			outcome.Error = "not found"
+			outcomes = append(outcomes, outcome)
+			continue
+		}
+
+		q.mu.RLock()
+		retry, ok := q.retriers[dl.Source]
+		q.mu.RUnlock()
+		if !ok {
+			outcome.Error = fmt.Sprintf("no retry handler for source %q", dl.Source)
+			outcomes = append(outcomes, outcome)
+			continue
+		}
+
+		if retryErr := retry(dl); retryErr != nil {
+			outcome.Error = retryErr.Error()
+			_, err = q.db.Exec("UPDATE dead_letters SET attempts = attempts + 1, error = $1, last_attempt_at = NOW() WHERE id = $2",
+				retryErr.Error(), id)
+		} else {
+			outcome.Success = true
+			_, err = q.db.Exec("DELETE FROM dead_letters WHERE id = $1", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+// Discard удаляет записи без повтора и возвращает число удалённых.
+func (q *DeadLetterQueue) Discard(ids []int) (int64, error) {
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		ids64[i] = int64(id)
+	}
+	res, err := q.db.Exec("DELETE FROM dead_letters WHERE id = ANY($1)", pq.Array(ids64))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
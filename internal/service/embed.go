@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"server/internal/models"
+	"strconv"
+	"strings"
+)
+
+// widgetImagePreset — пресет imgproxy для изображения виджета.
+const widgetImagePreset = "thumbnail"
+
+// Widget возвращает данные виджета продукта: цену в валюте каталога,
+// наличие по складским остаткам и первое изображение.
+func (s *ProductService) Widget(ctx context.Context, id int, locales []string) (models.ProductWidget, error) {
+	product, err := s.Get(ctx, id, locales)
+	if err != nil {
+		return models.ProductWidget{}, err
+	}
+	stock, err := s.StockFor(ctx, []int{id})
+	if err != nil {
+		return models.ProductWidget{}, err
+	}
+
+	widget := models.ProductWidget{ID: product.ID, Name: product.Name, Price: product.Price, Currency: s.currency, Locale: product.Locale}
+	switch availability(stock[id]) {
+	case schemaOrg + "/InStock":
+		inStock := true
+		widget.InStock = &inStock
+	case schemaOrg + "/OutOfStock":
+		inStock := false
+		widget.InStock = &inStock
+	}
+	if s.productURL != "" {
+		widget.URL = strings.ReplaceAll(s.productURL, "{id}", strconv.Itoa(id))
+	}
+	for _, a := range product.Attachments {
+		if !strings.HasPrefix(a.ContentType, "image/") {
+			continue
+		}
+		if image, ok := a.Images[widgetImagePreset]; ok {
+			widget.Image = image
+		} else {
+			widget.Image = a.URL
+		}
+		break
+	}
+	return widget, nil
+}
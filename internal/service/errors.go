@@ -0,0 +1,24 @@
+package service
+
+import "errors"
+
+// ErrNotFound возвращается, когда запрошенная сущность не существует.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict возвращается, когда сущность с такими уникальными полями уже
+// существует.
+var ErrConflict = errors.New("already exists")
+
+// ValidationError описывает некорректные входные данные; REST отдаёт её как 400,
+// GraphQL — как ошибку поля.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
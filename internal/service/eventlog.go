@@ -0,0 +1,80 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"log"
+	"server/internal/events"
+	"strconv"
+	"time"
+)
+
+// EventLog сохраняет все опубликованные события, чтобы их можно было
+// повторно доставить новым потребителям.
+type EventLog struct {
+	db *sql.DB
+}
+
+func NewEventLog(db *sql.DB) *EventLog {
+	return &EventLog{db: db}
+}
+
+// Record записывает событие; подписывается на шину событий.
+func (l *EventLog) Record(event events.Event) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		log.Printf("Не удалось сериализовать событие %s: %v", event.ID, err)
+		return
+	}
+	_, err = l.db.Exec("INSERT INTO event_log (id, type, occurred_at, data, request_id) VALUES ($1, $2, $3, $4, $5)",
+		event.ID, event.Type, event.OccurredAt, data, event.RequestID)
+	if err != nil {
+		log.Printf("Не удалось сохранить событие %s: %v", event.ID, err)
+	}
+}
+
+// EventQuery выбирает события из журнала за полуоткрытый интервал [From, To).
+type EventQuery struct {
+	From  time.Time
+	To    time.Time
+	Types []string
+	Limit int
+}
+
+// Find возвращает события в порядке возникновения.
+func (l *EventLog) Find(q EventQuery) ([]events.Event, error) {
+	if q.From.IsZero() || q.To.IsZero() || !q.To.After(q.From) {
+		return nil, invalid("from and to are required and to must be after from")
+	}
+	query := "SELECT id, type, occurred_at, data, request_id FROM event_log WHERE occurred_at >= $1 AND occurred_at < $2"
+	args := []interface{}{q.From, q.To}
+	if len(q.Types) > 0 {
+		query += " AND type = ANY($3)"
+		args = append(args, pq.Array(q.Types))
+	}
+	query += " ORDER BY occurred_at, id"
+	if q.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(q.Limit)
+	}
+
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := []events.Event{}
+	for rows.Next() {
+		var (
+			event events.Event
+			data  []byte
+		)
+		if err := rows.Scan(&event.ID, &event.Type, &event.OccurredAt, &data, &event.RequestID); err != nil {
+			return nil, err
+		}
+		event.Data = json.RawMessage(data)
+		found = append(found, event)
+	}
+	return found, rows.Err()
+}
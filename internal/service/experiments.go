@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"regexp"
+	"server/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// experimentsTTL — как долго экземпляр сервера использует загруженный
+	// список активных экспериментов.
+	experimentsTTL = 30 * time.Second
+	// maxExperimentVariants ограничивает число вариантов эксперимента.
+	maxExperimentVariants = 10
+	// maxExperimentSubjectLength — размер колонки subject.
+	maxExperimentSubjectLength = 128
+)
+
+var experimentKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ExperimentService хранит A/B-эксперименты и распределяет по ним
+// пользователей. Вариант вычисляется из хеша ключа эксперимента и ID
+// пользователя, поэтому одинаков на всех экземплярах сервера и не требует
+// хранения. Первые попадания копятся в памяти и записываются пакетом через
+// FlushExposures.
+type ExperimentService struct {
+	db *sql.DB
+
+	mu       sync.Mutex
+	active   []models.Experiment
+	loadedAt time.Time
+
+	pendingMu sync.Mutex
+	pending   map[exposure]string
+}
+
+// exposure — пользователь, получивший вариант эксперимента.
+type exposure struct {
+	experimentID int
+	subject      string
+}
+
+func NewExperimentService(db *sql.DB) *ExperimentService {
+	return &ExperimentService{db: db, pending: make(map[exposure]string)}
+}
+
+func validateExperiment(e models.Experiment) error {
+	if !experimentKeyPattern.MatchString(e.Key) {
+		return invalid("key must be 1-64 lowercase letters, digits, '_' or '-'")
+	}
+	if len(e.Variants) < 2 || len(e.Variants) > maxExperimentVariants {
+		return invalid(fmt.Sprintf("an experiment needs 2 to %d variants", maxExperimentVariants))
+	}
+	seen := make(map[string]bool, len(e.Variants))
+	for _, variant := range e.Variants {
+		if strings.TrimSpace(variant.Name) == "" || len(variant.Name) > 64 {
+			return invalid("variant name must be 1-64 characters")
+		}
+		if seen[variant.Name] {
+			return invalid(fmt.Sprintf("duplicate variant %q", variant.Name))
+		}
+		seen[variant.Name] = true
+		if variant.Weight <= 0 || variant.Weight > 10000 {
+			return invalid("variant weight must be between 1 and 10000")
+		}
+	}
+	return nil
+}
+
+const experimentColumns = "id, key, description, variants, active, created_at"
+
+func scanExperiment(row interface{ Scan(...interface{}) error }, e *models.Experiment) error {
+	var variants []byte
+	if err := row.Scan(&e.ID, &e.Key, &e.Description, &variants, &e.Active, &e.CreatedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(variants, &e.Variants)
+}
+
+func (s *ExperimentService) List(ctx context.Context) ([]models.Experiment, error) {
+	return s.query(ctx, "SELECT "+experimentColumns+" FROM experiments ORDER BY id")
+}
+
+func (s *ExperimentService) query(ctx context.Context, query string, args ...interface{}) ([]models.Experiment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	experiments := []models.Experiment{}
+	for rows.Next() {
+		var e models.Experiment
+		if err := scanExperiment(rows, &e); err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, e)
+	}
+	return experiments, rows.Err()
+}
+
+func (s *ExperimentService) Get(ctx context.Context, id int) (models.Experiment, error) {
+	var e models.Experiment
+	err := scanExperiment(s.db.QueryRowContext(ctx, "SELECT "+experimentColumns+" FROM experiments WHERE id = $1", id), &e)
+	if err == sql.ErrNoRows {
+		return models.Experiment{}, ErrNotFound
+	}
+	return e, err
+}
+
+func (s *ExperimentService) Create(ctx context.Context, e models.Experiment) (models.Experiment, error) {
+	if err := validateExperiment(e); err != nil {
+		return models.Experiment{}, err
+	}
+	variants, err := json.Marshal(e.Variants)
+	if err != nil {
+		return models.Experiment{}, err
+	}
+	err = s.db.QueryRowContext(ctx,
+		"INSERT INTO experiments (key, description, variants, active) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		e.Key, e.Description, variants, e.Active).Scan(&e.ID, &e.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return models.Experiment{}, invalid(fmt.Sprintf("experiment %q already exists", e.Key))
+	}
+	if err != nil {
+		return models.Experiment{}, err
+	}
+	s.reset()
+	return e, nil
+}
+
+// Update заменяет эксперимент. Изменение вариантов или весов
+// перераспределяет пользователей, поэтому для запущенного эксперимента
+// обычно меняют только описание и активность.
+func (s *ExperimentService) Update(ctx context.Context, id int, e models.Experiment) (models.Experiment, error) {
+	if err := validateExperiment(e); err != nil {
+		return models.Experiment{}, err
+	}
+	variants, err := json.Marshal(e.Variants)
+	if err != nil {
+		return models.Experiment{}, err
+	}
+	e.ID = id
+	err = s.db.QueryRowContext(ctx,
+		"UPDATE experiments SET key = $1, description = $2, variants = $3, active = $4 WHERE id = $5 RETURNING created_at",
+		e.Key, e.Description, variants, e.Active, id).Scan(&e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.Experiment{}, ErrNotFound
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return models.Experiment{}, invalid(fmt.Sprintf("experiment %q already exists", e.Key))
+	}
+	if err != nil {
+		return models.Experiment{}, err
+	}
+	s.reset()
+	return e, nil
+}
+
+// Put создаёт или целиком заменяет эксперимент с ключом key: ключ
+// постоянен, поэтому экспериментами и флагами функций можно управлять
+// декларативно, например из Terraform. Повтор того же запроса ничего не
+// меняет. Второй результат — был ли эксперимент создан.
+func (s *ExperimentService) Put(ctx context.Context, key string, e models.Experiment) (models.Experiment, bool, error) {
+	e.Key = key
+	if err := validateExperiment(e); err != nil {
+		return models.Experiment{}, false, err
+	}
+	variants, err := json.Marshal(e.Variants)
+	if err != nil {
+		return models.Experiment{}, false, err
+	}
+	var created bool
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO experiments (key, description, variants, active) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET description = EXCLUDED.description, variants = EXCLUDED.variants,
+			active = EXCLUDED.active
+		RETURNING id, created_at, xmax = 0`,
+		e.Key, e.Description, variants, e.Active).Scan(&e.ID, &e.CreatedAt, &created)
+	if err != nil {
+		return models.Experiment{}, false, err
+	}
+	s.reset()
+	return e, created, nil
+}
+
+// GetByKey возвращает эксперимент с ключом key.
+func (s *ExperimentService) GetByKey(ctx context.Context, key string) (models.Experiment, error) {
+	var e models.Experiment
+	err := scanExperiment(s.db.QueryRowContext(ctx, "SELECT "+experimentColumns+" FROM experiments WHERE key = $1", key), &e)
+	if err == sql.ErrNoRows {
+		return models.Experiment{}, ErrNotFound
+	}
+	return e, err
+}
+
+func (s *ExperimentService) DeleteByKey(ctx context.Context, key string) error {
+	if err := affectOne(s.db.ExecContext(ctx, "DELETE FROM experiments WHERE key = $1", key)); err != nil {
+		return err
+	}
+	s.reset()
+	return nil
+}
+
+func (s *ExperimentService) Delete(ctx context.Context, id int) error {
+	if err := affectOne(s.db.ExecContext(ctx, "DELETE FROM experiments WHERE id = $1", id)); err != nil {
+		return err
+	}
+	s.reset()
+	return nil
+}
+
+// Results возвращает число уникальных пользователей по вариантам, включая
+// варианты, которые ещё никто не получил.
+func (s *ExperimentService) Results(ctx context.Context, id int) (models.ExperimentResults, error) {
+	e, err := s.Get(ctx, id)
+	if err != nil {
+		return models.ExperimentResults{}, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT variant, COUNT(*) FROM experiment_exposures WHERE experiment_id = $1 GROUP BY variant", id)
+	if err != nil {
+		return models.ExperimentResults{}, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var variant string
+		var subjects int
+		if err := rows.Scan(&variant, &subjects); err != nil {
+			return models.ExperimentResults{}, err
+		}
+		counts[variant] = subjects
+	}
+	if err := rows.Err(); err != nil {
+		return models.ExperimentResults{}, err
+	}
+
+	results := models.ExperimentResults{ExperimentID: id, Variants: []models.VariantExposure{}}
+	for _, variant := range e.Variants {
+		results.Variants = append(results.Variants, models.VariantExposure{Variant: variant.Name, Subjects: counts[variant.Name]})
+		delete(counts, variant.Name)
+	}
+	// Варианты, удалённые из эксперимента после начала показов.
+	for variant, subjects := range counts {
+		results.Variants = append(results.Variants, models.VariantExposure{Variant: variant, Subjects: subjects})
+	}
+	return results, nil
+}
+
+func (s *ExperimentService) reset() {
+	s.mu.Lock()
+	s.active = nil
+	s.mu.Unlock()
+}
+
+// activeExperiments возвращает активные эксперименты, перечитывая их по
+// истечении experimentsTTL.
+func (s *ExperimentService) activeExperiments(ctx context.Context) ([]models.Experiment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil && time.Since(s.loadedAt) < experimentsTTL {
+		return s.active, nil
+	}
+	active, err := s.query(ctx, "SELECT "+experimentColumns+" FROM experiments WHERE active ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	s.active, s.loadedAt = active, time.Now()
+	return active, nil
+}
+
+// assignVariant выбирает вариант по хешу ключа эксперимента и пользователя.
+func assignVariant(e models.Experiment, subject string) string {
+	total := 0
+	for _, variant := range e.Variants {
+		total += variant.Weight
+	}
+	sum := sha256.Sum256([]byte(e.Key + "\x00" + subject))
+	point := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for _, variant := range e.Variants {
+		if point < variant.Weight {
+			return variant.Name
+		}
+		point -= variant.Weight
+	}
+	return e.Variants[len(e.Variants)-1].Name
+}
+
+// Assign возвращает варианты активных экспериментов для пользователя по
+// ключам экспериментов и отмечает его попадание в них.
+func (s *ExperimentService) Assign(ctx context.Context, subject string) (map[string]string, error) {
+	if subject == "" || len(subject) > maxExperimentSubjectLength {
+		return nil, invalid(fmt.Sprintf("experiment subject must be 1-%d characters", maxExperimentSubjectLength))
+	}
+	active, err := s.activeExperiments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	assignments := make(map[string]string, len(active))
+	if len(active) == 0 {
+		return assignments, nil
+	}
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	for _, e := range active {
+		variant := assignVariant(e, subject)
+		assignments[e.Key] = variant
+		s.pending[exposure{experimentID: e.ID, subject: subject}] = variant
+	}
+	return assignments, nil
+}
+
+// FlushExposures записывает накопленные попадания и возвращает их число.
+// Повторные попадания пользователя в эксперимент не записываются: в базе
+// остаётся первое.
+func (s *ExperimentService) FlushExposures() (int, error) {
+	s.pendingMu.Lock()
+	pending := s.pending
+	s.pending = make(map[exposure]string)
+	s.pendingMu.Unlock()
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, len(pending))
+	subjects := make([]string, 0, len(pending))
+	variants := make([]string, 0, len(pending))
+	for e, variant := range pending {
+		ids = append(ids, int64(e.experimentID))
+		subjects = append(subjects, e.subject)
+		variants = append(variants, variant)
+	}
+	// Эксперимент могли удалить после выдачи варианта: такие попадания
+	// отбрасываются соединением с experiments.
+	res, err := s.db.Exec(`
+		INSERT INTO experiment_exposures (experiment_id, subject, variant)
+		SELECT p.experiment_id, p.subject, p.variant
+		FROM unnest($1::int[], $2::text[], $3::text[]) AS p(experiment_id, subject, variant)
+		JOIN experiments e ON e.id = p.experiment_id
+		ON CONFLICT (experiment_id, subject) DO NOTHING`,
+		pq.Array(ids), pq.Array(subjects), pq.Array(variants))
+	if err != nil {
+		// Вернём попадания в очередь, чтобы следующий запуск их записал.
+		s.pendingMu.Lock()
+		for e, variant := range pending {
+			if _, ok := s.pending[e]; !ok {
+				s.pending[e] = variant
+			}
+		}
+		s.pendingMu.Unlock()
+		return 0, err
+	}
+	written, _ := res.RowsAffected()
+	return int(written), nil
+}
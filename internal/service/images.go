@@ -0,0 +1,79 @@
+package service
+
+import (
+	"server/internal/imgproxy"
+	"server/internal/models"
+	"strings"
+)
+
+// ImageURLs строит подписанные ссылки imgproxy на вложения-изображения.
+type ImageURLs struct {
+	signer *imgproxy.Signer
+	// sourceBase — откуда imgproxy берёт оригинал: "local:///" для общего с
+	// сервером каталога вложений (IMGPROXY_LOCAL_FILESYSTEM_ROOT) или адрес
+	// этого API, доступный imgproxy.
+	sourceBase string
+}
+
+func NewImageURLs(signer *imgproxy.Signer, sourceBase string) *ImageURLs {
+	return &ImageURLs{signer: signer, sourceBase: sourceBase}
+}
+
+func isImage(a models.Attachment) bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+func (u *ImageURLs) source(a models.Attachment) string {
+	if strings.HasPrefix(u.sourceBase, "local://") {
+		return strings.TrimRight(u.sourceBase, "/") + "/" + a.StorageKey
+	}
+	return strings.TrimRight(u.sourceBase, "/") + a.URL
+}
+
+// decorate заполняет ссылки на предустановленные варианты изображения.
+// Безопасен для nil: без настроенного imgproxy вложение остаётся как есть.
+func (u *ImageURLs) decorate(a *models.Attachment) {
+	if u == nil || !isImage(*a) {
+		return
+	}
+	source := u.source(*a)
+	a.Images = u.signer.Variants(source)
+	a.ImageSets = make(map[string]models.ImageSet, len(u.signer.Presets()))
+	for _, preset := range u.signer.Presets() {
+		set := models.ImageSet{
+			Width:   preset.Width,
+			Height:  preset.Height,
+			Sources: make([]models.ImageSource, 0, len(u.signer.Formats())),
+			Src:     u.signer.URL(source, preset.Options),
+			SrcSet:  u.signer.SrcSet(source, preset.Options),
+		}
+		for _, format := range u.signer.Formats() {
+			opts := preset.Options
+			opts.Format = format
+			set.Sources = append(set.Sources, models.ImageSource{
+				Type:   imgproxy.ContentType(format),
+				SrcSet: u.signer.SrcSet(source, opts),
+			})
+		}
+		a.ImageSets[preset.Name] = set
+	}
+}
+
+// URL возвращает подписанную ссылку на изображение с произвольными параметрами.
+func (u *ImageURLs) URL(a models.Attachment, opts imgproxy.Options) (string, error) {
+	if u == nil {
+		return "", invalid("image processing is not configured")
+	}
+	if !isImage(a) {
+		return "", invalid("attachment is not an image")
+	}
+	if err := opts.Validate(); err != nil {
+		return "", invalid(err.Error())
+	}
+	return u.signer.URL(u.source(a), opts), nil
+}
+
+// ImageURL — ссылка с произвольными параметрами для уже загруженного вложения.
+func (s *ProductService) ImageURL(a models.Attachment, opts imgproxy.Options) (string, error) {
+	return s.images.URL(a, opts)
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"server/internal/imaging"
+	"server/internal/models"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxImagesPerProduct ограничивает число ссылок у одного продукта в импорте.
+	maxImagesPerProduct = 20
+	// importBatchSize — сколько изображений загружается параллельно за один заход.
+	importBatchSize = 8
+	// importClaimTimeout — через сколько взятое в работу изображение считается
+	// брошенным (например, сервер перезапустился) и берётся повторно.
+	importClaimTimeout = 10 * time.Minute
+	// maxImagePixels защищает от изображений, которые при небольшом размере
+	// файла распаковываются в гигабайты памяти.
+	maxImagePixels = 50_000_000
+)
+
+var errPrivateAddress = errors.New("URL resolves to a non-public address")
+
+// ImportService создаёт продукты пакетом и в фоне загружает их изображения по
+// ссылкам: скачивает, проверяет, уменьшает и прикрепляет как вложения.
+type ImportService struct {
+	db          *sql.DB
+	products    *ProductService
+	attachments *AttachmentService
+	client      *http.Client
+	maxSize     int64
+	maxSide     int
+}
+
+type ImportOptions struct {
+	// MaxImageSize — предельный размер скачиваемого изображения в байтах.
+	MaxImageSize int64
+	// MaxImageSide — большая сторона в пикселях, до которой уменьшаются изображения.
+	MaxImageSide int
+	// Timeout — таймаут скачивания одного изображения.
+	Timeout time.Duration
+}
+
+func NewImportService(db *sql.DB, products *ProductService, attachments *AttachmentService, opts ImportOptions) *ImportService {
+	return &ImportService{
+		db:          db,
+		products:    products,
+		attachments: attachments,
+		// Ссылки приходят от клиента. Перенаправления безопасны: каждое
+		// новое соединение проверяет publicTransport.
+		client:  &http.Client{Timeout: opts.Timeout, Transport: publicTransport()},
+		maxSize: opts.MaxImageSize,
+		maxSide: opts.MaxImageSide,
+	}
+}
+
+// publicTransport — транспорт для запросов по адресам, которые прислал
+// клиент: соединения во внутреннюю сеть, с loopback и link-local адресами
+// запрещены. Проверяется уже разрешённый адрес, так что подмена DNS не
+// поможет.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: rejectPrivateAddress}
+	return &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second}
+}
+
+func rejectPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicIP(net.ParseIP(host)) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// isPublicIP сообщает, что адрес в интернете: не loopback, не link-local
+// (там метаданные облака) и не из частных сетей.
+func isPublicIP(ip net.IP) bool {
+	return ip != nil && ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+func validateImageURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid(fmt.Sprintf("image URL %q must be an absolute http(s) URL", raw))
+	}
+	return nil
+}
+
+// Create добавляет продукты и ставит их изображения в очередь загрузки.
+// Продукты доступны сразу; ход загрузки изображений виден через Get.
+func (s *ImportService) Create(ctx context.Context, items []models.ImportItem) (models.ImportJob, error) {
+	if len(items) == 0 {
+		return models.ImportJob{}, invalid("import must contain at least one product")
+	}
+	products := make([]models.Product, len(items))
+	for i, item := range items {
+		if len(item.ImageURLs) > maxImagesPerProduct {
+			return models.ImportJob{}, invalid(fmt.Sprintf("a product can have at most %d image URLs", maxImagesPerProduct))
+		}
+		for _, raw := range item.ImageURLs {
+			if err := validateImageURL(raw); err != nil {
+				return models.ImportJob{}, err
+			}
+		}
+		products[i] = item.Product
+	}
+
+	products, err := s.products.Create(ctx, products)
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = int64(product.ID)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	defer tx.Rollback()
+
+	var jobID int
+	if err := tx.QueryRowContext(ctx, "INSERT INTO import_jobs (status, product_ids) VALUES ($1, $2) RETURNING id",
+		models.ImportPending, pq.Array(ids)).Scan(&jobID); err != nil {
+		return models.ImportJob{}, err
+	}
+	images := 0
+	for i, item := range items {
+		for _, raw := range item.ImageURLs {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO import_images (job_id, product_id, url) VALUES ($1, $2, $3)",
+				jobID, products[i].ID, raw); err != nil {
+				return models.ImportJob{}, err
+			}
+			images++
+		}
+	}
+	if images == 0 {
+		if _, err := tx.ExecContext(ctx, "UPDATE import_jobs SET status = $1, finished_at = NOW() WHERE id = $2",
+			models.ImportDone, jobID); err != nil {
+			return models.ImportJob{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return models.ImportJob{}, err
+	}
+	return s.Get(jobID)
+}
+
+// Get возвращает задачу импорта с результатом по каждой ссылке.
+func (s *ImportService) Get(id int) (models.ImportJob, error) {
+	job := models.ImportJob{ID: id, ProductIDs: []int{}, Images: []models.ImageImport{}}
+	var productIDs []int64
+	var finishedAt sql.NullTime
+	err := s.db.QueryRow("SELECT status, product_ids, created_at, finished_at FROM import_jobs WHERE id = $1", id).
+		Scan(&job.Status, pq.Array(&productIDs), &job.CreatedAt, &finishedAt)
+	if err == sql.ErrNoRows {
+		return models.ImportJob{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	for _, productID := range productIDs {
+		job.ProductIDs = append(job.ProductIDs, int(productID))
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	rows, err := s.db.Query("SELECT product_id, url, status, COALESCE(error, ''), attachment_id FROM import_images WHERE job_id = $1 ORDER BY id", id)
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var image models.ImageImport
+		var attachmentID sql.NullInt64
+		if err := rows.Scan(&image.ProductID, &image.URL, &image.Status, &image.Error, &attachmentID); err != nil {
+			return models.ImportJob{}, err
+		}
+		if attachmentID.Valid {
+			id := int(attachmentID.Int64)
+			image.AttachmentID = &id
+		}
+		switch image.Status {
+		case models.ImportAttached:
+			job.Attached++
+		case models.ImportFailed:
+			job.Failed++
+		}
+		job.Images = append(job.Images, image)
+	}
+	return job, rows.Err()
+}
+
+type claimedImage struct {
+	id        int
+	productID int
+	url       string
+}
+
+// ProcessPending загружает изображения из очереди, пока она не опустеет, и
+// завершает задачи, у которых не осталось необработанных ссылок. Ошибки
+// отдельных ссылок записываются в результат задачи, а не возвращаются.
+func (s *ImportService) ProcessPending() error {
+	for {
+		batch, err := s.claim()
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i, image := range batch {
+			wg.Add(1)
+			go func(i int, image claimedImage) {
+				defer wg.Done()
+				attachmentID, fetchErr := s.fetch(image.productID, image.url)
+				errs[i] = s.complete(image.id, attachmentID, fetchErr)
+			}(i, image)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE import_jobs j SET status = $1, finished_at = NOW()
+		WHERE j.status <> $1
+		  AND NOT EXISTS (SELECT 1 FROM import_images i WHERE i.job_id = j.id AND i.status IN ($2, $3))`,
+		models.ImportDone, models.ImportPending, models.ImportProcessing)
+	return err
+}
+
+// claim берёт в работу очередную порцию изображений. SKIP LOCKED позволяет
+// нескольким экземплярам сервера разбирать очередь без повторной загрузки.
+func (s *ImportService) claim() ([]claimedImage, error) {
+	rows, err := s.db.Query(`
+		WITH claimed AS (
+			UPDATE import_images SET status = $1, claimed_at = NOW()
+			WHERE id IN (
+				SELECT id FROM import_images
+				WHERE status = $2 OR (status = $1 AND claimed_at < $3)
+				ORDER BY id LIMIT $4
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, job_id, product_id, url
+		), started AS (
+			UPDATE import_jobs SET status = $1
+			WHERE status = $2 AND id IN (SELECT job_id FROM claimed)
+		)
+		SELECT id, product_id, url FROM claimed ORDER BY id`,
+		models.ImportProcessing, models.ImportPending, time.Now().Add(-importClaimTimeout), importBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []claimedImage
+	for rows.Next() {
+		var image claimedImage
+		if err := rows.Scan(&image.id, &image.productID, &image.url); err != nil {
+			return nil, err
+		}
+		batch = append(batch, image)
+	}
+	return batch, rows.Err()
+}
+
+func (s *ImportService) complete(id, attachmentID int, fetchErr error) error {
+	if fetchErr != nil {
+		_, err := s.db.Exec("UPDATE import_images SET status = $1, error = $2 WHERE id = $3",
+			models.ImportFailed, fetchErr.Error(), id)
+		return err
+	}
+	_, err := s.db.Exec("UPDATE import_images SET status = $1, attachment_id = $2, error = NULL WHERE id = $3",
+		models.ImportAttached, attachmentID, id)
+	return err
+}
+
+// fetch скачивает изображение, проверяет его, уменьшает до maxSide и
+// прикрепляет к продукту. Изображение всегда перекодируется: заодно
+// отбрасываются метаданные вроде EXIF с координатами съёмки.
+func (s *ImportService) fetch(productID int, rawURL string) (int, error) {
+	resp, err := s.client.Get(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	if resp.ContentLength > s.maxSize {
+		return 0, fmt.Errorf("image exceeds maximum size of %d bytes", s.maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.maxSize+1))
+	if err != nil {
+		return 0, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return 0, fmt.Errorf("image exceeds maximum size of %d bytes", s.maxSize)
+	}
+
+	config, format, err := imaging.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, errors.New("response is not a JPEG, PNG or GIF image")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return 0, fmt.Errorf("image dimensions %dx%d are too large", config.Width, config.Height)
+	}
+	img, _, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	_, ext, err := imaging.Encode(&buf, imaging.Fit(img, s.maxSide), format)
+	if err != nil {
+		return 0, err
+	}
+	attachment, err := s.attachments.Upload(productID, imageFileName(rawURL, ext), int64(buf.Len()), &buf)
+	if errors.Is(err, ErrNotFound) {
+		return 0, errors.New("product no longer exists")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return attachment.ID, nil
+}
+
+// imageFileName строит имя вложения из последнего сегмента пути ссылки с
+// расширением формата, в который изображение перекодировано.
+func imageFileName(rawURL, ext string) string {
+	name := "image"
+	if u, err := url.Parse(rawURL); err == nil {
+		base := path.Base(u.Path)
+		base = strings.TrimSuffix(base, path.Ext(base))
+		if base != "" && base != "." && base != "/" {
+			name = base
+		}
+	}
+	return name + ext
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"server/internal/models"
+	"strconv"
+	"strings"
+)
+
+const schemaOrg = "https://schema.org"
+
+// JSONLD возвращает разметку schema.org/Product для продукта: цену с
+// валютой каталога, наличие по складским остаткам и рейтинг по отзывам.
+// baseURL — адрес API, от которого строятся абсолютные ссылки на изображения.
+func (s *ProductService) JSONLD(ctx context.Context, id int, locales []string, baseURL string) (models.ProductJSONLD, error) {
+	product, err := s.Get(ctx, id, locales)
+	if err != nil {
+		return models.ProductJSONLD{}, err
+	}
+	summaries, err := s.ReviewSummaries(ctx, []int{id})
+	if err != nil {
+		return models.ProductJSONLD{}, err
+	}
+	stock, err := s.StockFor(ctx, []int{id})
+	if err != nil {
+		return models.ProductJSONLD{}, err
+	}
+
+	data := models.ProductJSONLD{
+		Context:     schemaOrg,
+		Type:        "Product",
+		Name:        product.Name,
+		Description: product.Description,
+		SKU:         product.SKU,
+		GTIN:        product.Barcode,
+		Category:    strings.Join(product.Categories, " > "),
+		Offers: models.OfferJSONLD{
+			Type:          "Offer",
+			Price:         product.Price,
+			PriceCurrency: s.currency,
+			Availability:  availability(stock[id]),
+		},
+	}
+	if s.productURL != "" {
+		data.ID = strings.ReplaceAll(s.productURL, "{id}", strconv.Itoa(id))
+		data.Offers.URL = data.ID
+	}
+	// Текущая цена действует до ближайшего запланированного изменения.
+	for _, change := range product.UpcomingPrices {
+		until := change.StartsAt.Format("2006-01-02")
+		if data.Offers.PriceValidUntil == "" || until < data.Offers.PriceValidUntil {
+			data.Offers.PriceValidUntil = until
+		}
+	}
+	for _, a := range product.Attachments {
+		if !strings.HasPrefix(a.ContentType, "image/") {
+			continue
+		}
+		if full, ok := a.Images["full"]; ok {
+			data.Image = append(data.Image, full)
+		} else {
+			data.Image = append(data.Image, strings.TrimRight(baseURL, "/")+a.URL)
+		}
+	}
+	if summary, ok := summaries[id]; ok && summary.Count > 0 {
+		data.AggregateRating = &models.AggregateRatingJSONLD{
+			Type:        "AggregateRating",
+			RatingValue: summary.Average,
+			ReviewCount: summary.Count,
+			BestRating:  5,
+			WorstRating: 1,
+		}
+	}
+	return data, nil
+}
+
+// availability определяет наличие по остаткам; без данных о складах оно
+// не указывается, чтобы не обещать покупателю лишнего.
+func availability(levels []models.StockLevel) string {
+	if len(levels) == 0 {
+		return ""
+	}
+	for _, level := range levels {
+		if level.Quantity > 0 {
+			return schemaOrg + "/InStock"
+		}
+	}
+	return schemaOrg + "/OutOfStock"
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"server/internal/events"
+	"server/internal/models"
+	"strings"
+	"sync"
+)
+
+// Порядки сортировки списка продуктов.
+const (
+	// SortRelevance — по релевантности для поиска, иначе по ID; порядок по
+	// умолчанию.
+	SortRelevance = "relevance"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortName      = "name"
+	// SortNewest — сначала недавно добавленные (по убыванию ID).
+	SortNewest = "newest"
+)
+
+// Sorts перечисляет допустимые порядки сортировки.
+var Sorts = []string{SortRelevance, SortPriceAsc, SortPriceDesc, SortName, SortNewest}
+
+// Значки, которые выдача категории может показывать у продуктов.
+const (
+	BadgeFeatured = "featured"
+	BadgeInStock  = "in_stock"
+)
+
+// Badges перечисляет допустимые значки.
+var Badges = []string{BadgeFeatured, BadgeInStock}
+
+func validSort(sort string) bool {
+	return sort == "" || contains(Sorts, sort)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// listingCache держит настройки выдачи всех категорий: их немного, а
+// читаются они при каждом запросе списка категории.
+type listingCache struct {
+	mu         sync.Mutex
+	byCategory map[string]models.CategoryListing
+}
+
+func (c *listingCache) reset() {
+	c.mu.Lock()
+	c.byCategory = nil
+	c.mu.Unlock()
+}
+
+// listingFor возвращает настройки выдачи категории; false — настроек нет.
+func (s *ProductService) listingFor(ctx context.Context, category string) (models.CategoryListing, bool, error) {
+	s.listings.mu.Lock()
+	defer s.listings.mu.Unlock()
+	if s.listings.byCategory == nil {
+		listings, err := s.CategoryListings(ctx)
+		if err != nil {
+			return models.CategoryListing{}, false, err
+		}
+		s.listings.byCategory = make(map[string]models.CategoryListing, len(listings))
+		for _, listing := range listings {
+			s.listings.byCategory[listing.Category] = listing
+		}
+	}
+	listing, ok := s.listings.byCategory[category]
+	return listing, ok, nil
+}
+
+const listingColumns = "category, sort, page_size, badges, updated_at"
+
+func scanListing(row interface{ Scan(...interface{}) error }, listing *models.CategoryListing) error {
+	return row.Scan(&listing.Category, &listing.Sort, &listing.PageSize, pq.Array(&listing.Badges), &listing.UpdatedAt)
+}
+
+// CategoryListings возвращает настройки выдачи всех категорий.
+func (s *ProductService) CategoryListings(ctx context.Context) ([]models.CategoryListing, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+listingColumns+" FROM category_listings ORDER BY category")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	listings := []models.CategoryListing{}
+	for rows.Next() {
+		var listing models.CategoryListing
+		if err := scanListing(rows, &listing); err != nil {
+			return nil, err
+		}
+		listings = append(listings, listing)
+	}
+	return listings, rows.Err()
+}
+
+// CategoryListing возвращает настройки выдачи категории.
+func (s *ProductService) CategoryListing(ctx context.Context, category string) (models.CategoryListing, error) {
+	listing, ok, err := s.listingFor(ctx, category)
+	if err != nil {
+		return models.CategoryListing{}, err
+	}
+	if !ok {
+		return models.CategoryListing{}, ErrNotFound
+	}
+	return listing, nil
+}
+
+// SetCategoryListing создаёт или заменяет настройки выдачи категории.
+func (s *ProductService) SetCategoryListing(ctx context.Context, listing models.CategoryListing) (models.CategoryListing, error) {
+	listing.Category = strings.TrimSpace(listing.Category)
+	if listing.Category == "" {
+		return models.CategoryListing{}, invalid("category is required")
+	}
+	if !validSort(listing.Sort) {
+		return models.CategoryListing{}, invalid("sort must be one of " + strings.Join(Sorts, ", "))
+	}
+	if listing.PageSize < 0 {
+		return models.CategoryListing{}, invalid("page size must be non-negative")
+	}
+	if s.maxListLimit > 0 && listing.PageSize > s.maxListLimit {
+		return models.CategoryListing{}, invalid(fmt.Sprintf("page size must not exceed %d", s.maxListLimit))
+	}
+	badges := []string{}
+	for _, badge := range listing.Badges {
+		if !contains(Badges, badge) {
+			return models.CategoryListing{}, invalid(fmt.Sprintf("unknown badge %q; badges must be among %s", badge, strings.Join(Badges, ", ")))
+		}
+		if !contains(badges, badge) {
+			badges = append(badges, badge)
+		}
+	}
+
+	var saved models.CategoryListing
+	err := scanListing(s.db.QueryRowContext(ctx, `
+		INSERT INTO category_listings (category, sort, page_size, badges) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (category) DO UPDATE SET sort = EXCLUDED.sort, page_size = EXCLUDED.page_size,
+			badges = EXCLUDED.badges, updated_at = NOW()
+		RETURNING `+listingColumns,
+		listing.Category, listing.Sort, listing.PageSize, pq.Array(badges)), &saved)
+	if err != nil {
+		return models.CategoryListing{}, err
+	}
+	s.listings.reset()
+	s.cache.invalidate()
+	return saved, nil
+}
+
+// DeleteCategoryListing возвращает категории выдачу по умолчанию.
+func (s *ProductService) DeleteCategoryListing(ctx context.Context, category string) error {
+	if err := affectOne(s.db.ExecContext(ctx, "DELETE FROM category_listings WHERE category = $1", category)); err != nil {
+		return err
+	}
+	s.listings.reset()
+	s.cache.invalidate()
+	return nil
+}
+
+// applyListing подставляет настройки выдачи категории в параметры, которые
+// клиент не задал, и возвращает значки выдачи.
+func (s *ProductService) applyListing(ctx context.Context, params *ListParams) ([]string, error) {
+	if params.Filter.Category == "" {
+		return nil, nil
+	}
+	listing, ok, err := s.listingFor(ctx, params.Filter.Category)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if params.Limit == 0 {
+		params.Limit = listing.PageSize
+	}
+	if params.Sort == "" {
+		params.Sort = listing.Sort
+	}
+	return listing.Badges, nil
+}
+
+// sortOrder строит ORDER BY для порядка сортировки; релевантность — через
+// rankOrder с множителями поиска.
+func (d *searchDictionary) sortOrder(sort, search string, args []interface{}) (string, []interface{}) {
+	switch sort {
+	case SortPriceAsc:
+		return "price, id", args
+	case SortPriceDesc:
+		return "price DESC, id", args
+	case SortName:
+		return "LOWER(name), id", args
+	case SortNewest:
+		return "id DESC", args
+	}
+	return d.rankOrder(search, args)
+}
+
+// attachBadges отмечает продукты значками выдачи в порядке badges.
+func (s *ProductService) attachBadges(ctx context.Context, products []models.Product, badges []string) error {
+	if len(products) == 0 || len(badges) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = int64(product.ID)
+	}
+	queries := map[string]string{
+		BadgeFeatured: "SELECT product_id FROM featured_products WHERE product_id = ANY($1)",
+		BadgeInStock:  "SELECT DISTINCT product_id FROM product_stock WHERE product_id = ANY($1) AND quantity > 0",
+	}
+	marked := make(map[string]map[int]bool, len(badges))
+	for _, badge := range badges {
+		rows, err := s.reader(ctx).QueryContext(ctx, queries[badge], pq.Array(ids))
+		if err != nil {
+			return err
+		}
+		marked[badge] = make(map[int]bool)
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			marked[badge][id] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	for i := range products {
+		for _, badge := range badges {
+			if marked[badge][products[i].ID] {
+				products[i].Badges = append(products[i].Badges, badge)
+			}
+		}
+	}
+	return nil
+}
+
+// ReassignCategory переносит все продукты из категории from в to в одной
+// транзакции: переименовывает категорию или, если to уже есть, сливает с
+// ней. Продукты, у которых уже была to, просто теряют from. Настройки выдачи
+// from переходят к to, если у той нет своих. Поисковый индекс затронутых
+// продуктов обновит фоновая переиндексация. Вместо событий по каждому
+// продукту публикуется одно событие category.reassigned с итогом.
+func (s *ProductService) ReassignCategory(ctx context.Context, from, to string) (models.CategoryReassignment, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" {
+		return models.CategoryReassignment{}, invalid("from and to are required")
+	}
+	if from == to {
+		return models.CategoryReassignment{}, invalid("from and to must differ")
+	}
+	result := models.CategoryReassignment{From: from, To: to}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.CategoryReassignment{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		WITH moved AS (
+			UPDATE products p SET
+				categories = CASE WHEN old.merged THEN array_remove(p.categories, $1)
+					ELSE array_replace(p.categories, $1, $2) END,
+				search_version = NULL
+			FROM (SELECT id, $2 = ANY(categories) AS merged FROM products
+				WHERE $1 = ANY(categories) FOR UPDATE) old
+			WHERE p.id = old.id
+			RETURNING old.merged
+		)
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE merged) FROM moved`, from, to).Scan(&result.Products, &result.Merged)
+	if err != nil {
+		return models.CategoryReassignment{}, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE category_listings SET category = $2, updated_at = NOW()
+		WHERE category = $1 AND NOT EXISTS (SELECT 1 FROM category_listings WHERE category = $2)`, from, to)
+	if err != nil {
+		return models.CategoryReassignment{}, err
+	}
+	moved, _ := res.RowsAffected()
+	result.ListingMoved = moved > 0
+	res, err = tx.ExecContext(ctx, "DELETE FROM category_listings WHERE category = $1", from)
+	if err != nil {
+		return models.CategoryReassignment{}, err
+	}
+	dropped, _ := res.RowsAffected()
+	if result.Products == 0 && moved == 0 && dropped == 0 {
+		return models.CategoryReassignment{}, ErrNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return models.CategoryReassignment{}, err
+	}
+
+	s.listings.reset()
+	s.cache.invalidate()
+	s.events.Publish(ctx, events.CategoryReassigned, result)
+	return result, nil
+}
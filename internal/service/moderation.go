@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"server/internal/models"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ModerationTopic — тема backplane, которой экземпляр сообщает остальным об
+// изменении ограничений чата.
+const ModerationTopic = "moderation"
+
+// moderationReloadTimeout ограничивает перечитывание ограничений по
+// сообщению backplane.
+const moderationReloadTimeout = 5 * time.Second
+
+type sanctionKey struct {
+	username string
+	kind     string
+}
+
+// ChatModeration хранит ограничения пользователей чата. Чат проверяет их
+// на каждом сообщении, поэтому действующие ограничения держатся в памяти и
+// перечитываются при изменении.
+type ChatModeration struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	active map[sanctionKey]models.ChatSanction
+	// changed вызываются после изменений, сделанных этим экземпляром.
+	changed []func()
+}
+
+func NewChatModeration(db *sql.DB) *ChatModeration {
+	return &ChatModeration{db: db, active: make(map[sanctionKey]models.ChatSanction)}
+}
+
+// OnLocalChange регистрирует fn, которая вызывается после изменений
+// ограничений, сделанных этим экземпляром, — чтобы сообщить о них остальным.
+func (m *ChatModeration) OnLocalChange(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changed = append(m.changed, fn)
+}
+
+const sanctionColumns = "username, kind, reason, created_by, created_at, expires_at"
+
+// Reload перечитывает действующие ограничения из базы.
+func (m *ChatModeration) Reload(ctx context.Context) error {
+	sanctions, err := m.List(ctx)
+	if err != nil {
+		return err
+	}
+	active := make(map[sanctionKey]models.ChatSanction, len(sanctions))
+	for _, sanction := range sanctions {
+		active[sanctionKey{sanction.Username, sanction.Kind}] = sanction
+	}
+	m.mu.Lock()
+	m.active = active
+	m.mu.Unlock()
+	return nil
+}
+
+// DropCache перечитывает ограничения после изменений, сделанных другим
+// экземпляром.
+func (m *ChatModeration) DropCache() {
+	ctx, cancel := context.WithTimeout(context.Background(), moderationReloadTimeout)
+	defer cancel()
+	if err := m.Reload(ctx); err != nil {
+		log.Printf("Не удалось перечитать ограничения чата: %v", err)
+	}
+}
+
+// Sanction возвращает действующее ограничение kind пользователя username.
+func (m *ChatModeration) Sanction(username, kind string) (models.ChatSanction, bool) {
+	m.mu.RLock()
+	sanction, ok := m.active[sanctionKey{username, kind}]
+	m.mu.RUnlock()
+	if !ok || (sanction.ExpiresAt != nil && !time.Now().Before(*sanction.ExpiresAt)) {
+		return models.ChatSanction{}, false
+	}
+	return sanction, true
+}
+
+// List возвращает действующие ограничения, новые первыми.
+func (m *ChatModeration) List(ctx context.Context) ([]models.ChatSanction, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT "+sanctionColumns+` FROM chat_sanctions
+		WHERE expires_at IS NULL OR expires_at > NOW() ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sanctions := []models.ChatSanction{}
+	for rows.Next() {
+		var s models.ChatSanction
+		if err := rows.Scan(&s.Username, &s.Kind, &s.Reason, &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sanctions = append(sanctions, s)
+	}
+	return sanctions, rows.Err()
+}
+
+// Impose накладывает ограничение на duration (0 — бессрочно), заменяя
+// прежнее ограничение того же вида.
+func (m *ChatModeration) Impose(ctx context.Context, sanction models.ChatSanction, duration time.Duration) (models.ChatSanction, error) {
+	sanction.Username = strings.TrimSpace(sanction.Username)
+	sanction.Reason = strings.TrimSpace(sanction.Reason)
+	if sanction.Username == "" || utf8.RuneCountInString(sanction.Username) > 255 {
+		return models.ChatSanction{}, invalid("username must be 1-255 characters")
+	}
+	if sanction.Kind != models.SanctionMute && sanction.Kind != models.SanctionBan {
+		return models.ChatSanction{}, invalid(fmt.Sprintf("kind must be %s or %s", models.SanctionMute, models.SanctionBan))
+	}
+	if duration < 0 {
+		return models.ChatSanction{}, invalid("duration must not be negative")
+	}
+	if utf8.RuneCountInString(sanction.Reason) > 500 {
+		return models.ChatSanction{}, invalid("reason must be at most 500 characters")
+	}
+	sanction.ExpiresAt = nil
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		sanction.ExpiresAt = &expiresAt
+	}
+	err := m.db.QueryRowContext(ctx, `
+		INSERT INTO chat_sanctions (username, kind, reason, created_by, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (username, kind) DO UPDATE SET reason = EXCLUDED.reason, created_by = EXCLUDED.created_by,
+			created_at = NOW(), expires_at = EXCLUDED.expires_at
+		RETURNING created_at`,
+		sanction.Username, sanction.Kind, sanction.Reason, sanction.CreatedBy, sanction.ExpiresAt).Scan(&sanction.CreatedAt)
+	if err != nil {
+		return models.ChatSanction{}, err
+	}
+	m.mu.Lock()
+	m.active[sanctionKey{sanction.Username, sanction.Kind}] = sanction
+	m.mu.Unlock()
+	m.notify()
+	return sanction, nil
+}
+
+// Lift снимает ограничение kind с пользователя username.
+func (m *ChatModeration) Lift(ctx context.Context, username, kind string) error {
+	err := affectOne(m.db.ExecContext(ctx,
+		"DELETE FROM chat_sanctions WHERE username = $1 AND kind = $2 AND (expires_at IS NULL OR expires_at > NOW())", username, kind))
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.active, sanctionKey{username, kind})
+	m.mu.Unlock()
+	m.notify()
+	return nil
+}
+
+func (m *ChatModeration) notify() {
+	m.mu.RLock()
+	changed := m.changed
+	m.mu.RUnlock()
+	for _, fn := range changed {
+		fn()
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"server/internal/auth"
+	"server/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// oauthTimeout ограничивает каждый запрос к провайдеру OAuth2.
+const oauthTimeout = 10 * time.Second
+
+// ExternalIdentity — пользователь по сведениям провайдера OAuth2.
+type ExternalIdentity struct {
+	Provider string
+	// Subject — неизменный ID пользователя у провайдера.
+	Subject string
+	Email   string
+	// EmailVerified — провайдер подтвердил, что адрес принадлежит
+	// пользователю; только такой адрес связывает учётные записи.
+	EmailVerified bool
+	// Username — предлагаемое имя, Name — отображаемое.
+	Username string
+	Name     string
+}
+
+// oauthProvider — адреса провайдера и чтение профиля пользователя по его
+// access-токену.
+type oauthProvider struct {
+	authURL  string
+	tokenURL string
+	scopes   []string
+	identity func(ctx context.Context, client *http.Client, token string) (ExternalIdentity, error)
+}
+
+// oauthProviders — поддерживаемые провайдеры.
+var oauthProviders = map[string]oauthProvider{
+	// Google — OpenID Connect; профиль берётся из userinfo, поэтому
+	// подпись id_token проверять не нужно.
+	"google": {
+		authURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+		tokenURL: "https://oauth2.googleapis.com/token",
+		scopes:   []string{"openid", "email", "profile"},
+		identity: googleIdentity,
+	},
+	"github": {
+		authURL:  "https://github.com/login/oauth/authorize",
+		tokenURL: "https://github.com/login/oauth/access_token",
+		scopes:   []string{"read:user", "user:email"},
+		identity: githubIdentity,
+	},
+}
+
+// OAuthCredentials — приложение, зарегистрированное у провайдера.
+type OAuthCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// OAuthService входит через провайдеров OAuth2 по схеме authorization code
+// с PKCE и выдаёт пользователю токены UserService.
+type OAuthService struct {
+	users       *UserService
+	client      *http.Client
+	credentials map[string]OAuthCredentials
+	// signup разрешает заводить пользователей при первом входе.
+	signup bool
+}
+
+// NewOAuthService включает провайдеров из credentials; неизвестный
+// провайдер — ошибка.
+func NewOAuthService(users *UserService, credentials map[string]OAuthCredentials, signup bool) (*OAuthService, error) {
+	for name := range credentials {
+		if _, ok := oauthProviders[name]; !ok {
+			return nil, fmt.Errorf("unknown oauth provider %q", name)
+		}
+	}
+	return &OAuthService{
+		users: users, client: &http.Client{Timeout: oauthTimeout}, credentials: credentials, signup: signup,
+	}, nil
+}
+
+// Providers возвращает имена включённых провайдеров.
+func (s *OAuthService) Providers() []string {
+	names := make([]string, 0, len(s.credentials))
+	for name := range s.credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthURL возвращает адрес страницы входа провайдера. state вернётся в
+// redirectURI без изменений, verifier — секрет PKCE, который нужно
+// предъявить в Login. Невключённый провайдер — ErrNotFound.
+func (s *OAuthService) AuthURL(provider, redirectURI, state, verifier string) (string, error) {
+	p, creds, err := s.provider(provider)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {creds.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return p.authURL + "?" + query.Encode(), nil
+}
+
+// Login обменивает код авторизации на профиль пользователя у провайдера и
+// выдаёт пару токенов через UserService.LoginExternal. Код, который
+// провайдер не принял, — auth.ErrUnauthenticated.
+func (s *OAuthService) Login(ctx context.Context, provider, code, redirectURI, verifier string) (models.Tokens, error) {
+	p, creds, err := s.provider(provider)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {creds.ClientID},
+		"client_secret": {creds.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Без него GitHub отвечает в формате формы.
+	req.Header.Set("Accept", "application/json")
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := doJSON(s.client, req, &token)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("oauth %s token: %w", provider, err)
+	}
+	if status != http.StatusOK || token.Error != "" || token.AccessToken == "" {
+		log.Printf("Провайдер %s не обменял код авторизации: %d %s %s", provider, status, token.Error, token.ErrorDescription)
+		return models.Tokens{}, auth.ErrUnauthenticated
+	}
+
+	identity, err := p.identity(ctx, s.client, token.AccessToken)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("oauth %s profile: %w", provider, err)
+	}
+	identity.Provider = provider
+	return s.users.LoginExternal(ctx, identity, s.signup)
+}
+
+func (s *OAuthService) provider(name string) (oauthProvider, OAuthCredentials, error) {
+	creds, ok := s.credentials[name]
+	if !ok {
+		return oauthProvider{}, OAuthCredentials{}, ErrNotFound
+	}
+	return oauthProviders[name], creds, nil
+}
+
+// doJSON выполняет запрос и разбирает JSON-ответ в v; ответ с ошибкой,
+// который не разобрался, не ошибка: о ней скажет статус.
+func doJSON(client *http.Client, req *http.Request, v interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+// getJSON читает ресурс провайдера с его access-токеном.
+func getJSON(ctx context.Context, client *http.Client, target, token string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	status, err := doJSON(client, req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", target, status)
+	}
+	return nil
+}
+
+func googleIdentity(ctx context.Context, client *http.Client, token string) (ExternalIdentity, error) {
+	var profile struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := getJSON(ctx, client, "https://openidconnect.googleapis.com/v1/userinfo", token, &profile); err != nil {
+		return ExternalIdentity{}, err
+	}
+	if profile.Subject == "" {
+		return ExternalIdentity{}, fmt.Errorf("userinfo without sub")
+	}
+	username, _, _ := strings.Cut(profile.Email, "@")
+	return ExternalIdentity{
+		Subject: profile.Subject, Email: profile.Email, EmailVerified: profile.EmailVerified,
+		Username: username, Name: profile.Name,
+	}, nil
+}
+
+// githubIdentity читает профиль GitHub; адрес в профиле может быть скрыт,
+// поэтому email — основной подтверждённый из списка адресов.
+func githubIdentity(ctx context.Context, client *http.Client, token string) (ExternalIdentity, error) {
+	var profile struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user", token, &profile); err != nil {
+		return ExternalIdentity{}, err
+	}
+	if profile.ID == 0 {
+		return ExternalIdentity{}, fmt.Errorf("user without id")
+	}
+	identity := ExternalIdentity{Subject: strconv.FormatInt(profile.ID, 10), Username: profile.Login, Name: profile.Name}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user/emails", token, &emails); err != nil {
+		return ExternalIdentity{}, err
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			identity.Email, identity.EmailVerified = email.Email, true
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"server/internal/models"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// CursorParams задаёт keyset-страницу по ID продукта. After/Before — ID
+// граничных продуктов (0 — без границы); First и Last взаимоисключающие.
+type CursorParams struct {
+	First   int
+	After   int
+	Last    int
+	Before  int
+	Locales []string
+	Filter  Filter
+}
+
+// CursorPage — страница продуктов в порядке возрастания ID.
+type CursorPage struct {
+	Products        []models.Product
+	HasNextPage     bool
+	HasPreviousPage bool
+	TotalCount      int
+}
+
+// Page возвращает keyset-страницу продуктов. В отличие от List она не
+// кэшируется: границы страницы зависят от курсоров клиента.
+func (s *ProductService) Page(ctx context.Context, params CursorParams) (CursorPage, error) {
+	if params.First < 0 || params.Last < 0 {
+		return CursorPage{}, invalid("first and last must be non-negative")
+	}
+	if params.First > 0 && params.Last > 0 {
+		return CursorPage{}, invalid("first and last cannot be combined")
+	}
+	backward := params.Last > 0
+	size := params.First
+	if backward {
+		size = params.Last
+	}
+	if size == 0 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		return CursorPage{}, invalid(fmt.Sprintf("page size must not exceed %d", maxPageSize))
+	}
+
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return CursorPage{}, err
+	}
+	where, args := params.Filter.where(dict)
+	var page CursorPage
+	if err := s.reader(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM products WHERE "+where, args...).Scan(&page.TotalCount); err != nil {
+		return CursorPage{}, err
+	}
+	if page.TotalCount == 0 && params.Filter.Search != "" {
+		s.recordZeroResults(params.Filter.Search)
+	}
+
+	if params.After > 0 {
+		args = append(args, params.After)
+		where += fmt.Sprintf(" AND id > $%d", len(args))
+	}
+	if params.Before > 0 {
+		args = append(args, params.Before)
+		where += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	order := "ASC"
+	if backward {
+		order = "DESC"
+	}
+	// Лишняя строка показывает, есть ли продукты за пределами страницы.
+	args = append(args, size+1)
+	query := fmt.Sprintf("SELECT %s FROM products WHERE %s ORDER BY id %s LIMIT $%d", productColumns, where, order, len(args))
+
+	products, err := s.queryProducts(ctx, query, args...)
+	if err != nil {
+		return CursorPage{}, err
+	}
+	more := len(products) > size
+	if more {
+		products = products[:size]
+	}
+	if backward {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+		page.HasPreviousPage, page.HasNextPage = more, params.Before > 0
+	} else {
+		page.HasNextPage, page.HasPreviousPage = more, params.After > 0
+	}
+
+	if page.Products, err = s.localize(ctx, products, params.Locales); err != nil {
+		return CursorPage{}, err
+	}
+	return page, nil
+}
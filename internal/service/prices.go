@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"server/internal/events"
+	"server/internal/models"
+	"time"
+)
+
+const priceScheduleColumns = "id, product_id, price, starts_at, ends_at, status"
+
+func scanPriceSchedule(row interface{ Scan(...interface{}) error }, schedule *models.PriceSchedule) error {
+	return row.Scan(&schedule.ID, &schedule.ProductID, &schedule.Price, &schedule.StartsAt, &schedule.EndsAt, &schedule.Status)
+}
+
+// attachUpcomingPrices дополняет продукты ожидающими изменениями цены одним запросом.
+func (s *ProductService) attachUpcomingPrices(ctx context.Context, products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	index := make(map[int]int, len(products))
+	for i, product := range products {
+		ids[i] = int64(product.ID)
+		index[product.ID] = i
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT "+priceScheduleColumns+" FROM price_schedules WHERE product_id = ANY($1) AND status = $2 ORDER BY starts_at",
+		pq.Array(ids), models.PriceSchedulePending)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schedule models.PriceSchedule
+		if err := scanPriceSchedule(rows, &schedule); err != nil {
+			return err
+		}
+		i := index[schedule.ProductID]
+		products[i].UpcomingPrices = append(products[i].UpcomingPrices, schedule)
+	}
+	return rows.Err()
+}
+
+// SchedulePrice планирует новую цену продукта; endsAt == nil означает бессрочное изменение.
+func (s *ProductService) SchedulePrice(productID int, price float64, startsAt time.Time, endsAt *time.Time) (models.PriceSchedule, error) {
+	if price < 0 || startsAt.IsZero() {
+		return models.PriceSchedule{}, invalid("price must be non-negative and starts_at is required")
+	}
+	if endsAt != nil && !endsAt.After(startsAt) {
+		return models.PriceSchedule{}, invalid("ends_at must be after starts_at")
+	}
+
+	exists, err := s.exists(productID)
+	if err != nil {
+		return models.PriceSchedule{}, err
+	}
+	if !exists {
+		return models.PriceSchedule{}, ErrNotFound
+	}
+
+	var schedule models.PriceSchedule
+	err = scanPriceSchedule(s.db.QueryRow(
+		"INSERT INTO price_schedules (product_id, price, starts_at, ends_at) VALUES ($1, $2, $3, $4) RETURNING "+priceScheduleColumns,
+		productID, price, startsAt, endsAt), &schedule)
+	if err != nil {
+		return models.PriceSchedule{}, err
+	}
+	s.cache.invalidate()
+	return schedule, nil
+}
+
+func (s *ProductService) PriceSchedules(productID int) ([]models.PriceSchedule, error) {
+	rows, err := s.db.Query("SELECT "+priceScheduleColumns+" FROM price_schedules WHERE product_id = $1 ORDER BY starts_at", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.PriceSchedule{}
+	for rows.Next() {
+		var schedule models.PriceSchedule
+		if err := scanPriceSchedule(rows, &schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// CancelPriceSchedule отменяет ещё не применённое изменение цены.
+func (s *ProductService) CancelPriceSchedule(id int) error {
+	res, err := s.db.Exec("UPDATE price_schedules SET status = $1 WHERE id = $2 AND status = $3",
+		models.PriceScheduleCancelled, id, models.PriceSchedulePending)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	s.cache.invalidate()
+	return nil
+}
+
+// ApplyPriceSchedules применяет наступившие изменения цены и возвращает исходную
+// цену по окончании периода действия. Для каждого продукта с новой ценой
+// публикуется product.updated.
+func (s *ProductService) ApplyPriceSchedules() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	started, err := queryIDs(tx, `
+		WITH due AS (
+			UPDATE price_schedules s
+			SET status = CASE WHEN s.ends_at IS NULL THEN $1 ELSE $2 END, original_price = p.price
+			FROM products p
+			WHERE p.id = s.product_id AND s.status = $3 AND s.starts_at <= NOW()
+			RETURNING s.product_id, s.price
+		)
+		UPDATE products p SET price = due.price FROM due WHERE p.id = due.product_id RETURNING p.id`,
+		models.PriceScheduleDone, models.PriceScheduleActive, models.PriceSchedulePending)
+	if err != nil {
+		return err
+	}
+
+	ended, err := queryIDs(tx, `
+		WITH expired AS (
+			UPDATE price_schedules SET status = $1
+			WHERE status = $2 AND ends_at <= NOW()
+			RETURNING product_id, original_price
+		)
+		UPDATE products p SET price = expired.original_price FROM expired WHERE p.id = expired.product_id RETURNING p.id`,
+		models.PriceScheduleDone, models.PriceScheduleActive)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	changed := append(started, ended...)
+	if len(changed) == 0 {
+		return nil
+	}
+	s.cache.invalidate()
+
+	// Реплика могла ещё не получить новые цены.
+	ctx := FromPrimary(context.Background())
+	products, err := s.GetMany(ctx, changed, nil)
+	if err != nil {
+		return err
+	}
+	for _, id := range changed {
+		if product, ok := products[id]; ok {
+			s.events.Publish(ctx, events.ProductUpdated, publicProduct(product))
+			// Продукт с наступившей и закончившейся ценой публикуется один раз.
+			delete(products, id)
+		}
+	}
+	return nil
+}
+
+// queryIDs выполняет запрос, возвращающий колонку ID.
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
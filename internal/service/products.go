@@ -0,0 +1,680 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"math"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProductService содержит бизнес-логику продуктов, общую для REST и GraphQL:
+// валидацию, кэширование списка, цены по расписанию, вложения и локализацию.
+type ProductService struct {
+	db            *sql.DB
+	reads         func() *sql.DB
+	cache         *listCache
+	defaultLocale string
+	// trashRetention — сколько удалённый продукт остаётся восстановимым.
+	trashRetention time.Duration
+	events         *events.Bus
+	images         *ImageURLs
+	currency       string
+	productURL     string
+	search         *searchCache
+	suggestBelow   int
+	maxListLimit   int
+	unboundedMax   int
+	size           catalogSize
+	listings       listingCache
+}
+
+// catalogSize — число продуктов в каталоге, перечитываемое не чаще раза в
+// catalogSizeTTL: оно нужно только для решения, можно ли отдать весь список.
+type catalogSize struct {
+	mu       sync.Mutex
+	count    int
+	loadedAt time.Time
+}
+
+const catalogSizeTTL = time.Minute
+
+type Options struct {
+	// CacheTTL — время жизни закэшированных страниц списка.
+	CacheTTL time.Duration
+	// DefaultLocale — язык, в котором хранятся основные поля продукта.
+	DefaultLocale string
+	// TrashRetention — окно, в течение которого удалённый продукт можно восстановить.
+	TrashRetention time.Duration
+	// Events — шина, в которую публикуются изменения продуктов; может быть nil.
+	Events *events.Bus
+	// Reads выбирает пул для запросов на чтение каталога, например ближайшую
+	// реплику; если nil, всё читается из основной базы.
+	Reads func() *sql.DB
+	// Images добавляет к вложениям-картинкам ссылки imgproxy; может быть nil.
+	Images *ImageURLs
+	// Currency — код валюты цен каталога (ISO 4217) для структурированных данных.
+	Currency string
+	// ProductURL — адрес страницы продукта на сайте с подстановкой {id};
+	// пустой — ссылка в структурированные данные не попадает.
+	ProductURL string
+	// SuggestBelow — при скольких найденных продуктах (не включая) поиск
+	// предлагает исправленный запрос; 0 отключает подсказки.
+	SuggestBelow int
+	// MaxListLimit — наибольший limit страницы списка; 0 — без ограничения.
+	MaxListLimit int
+	// UnboundedListMax — размер каталога, после которого список без limit
+	// отвергается и клиент должен запрашивать страницы; 0 — без ограничения.
+	UnboundedListMax int
+}
+
+func NewProductService(db *sql.DB, opts Options) *ProductService {
+	return &ProductService{
+		db:             db,
+		reads:          opts.Reads,
+		cache:          newListCache(opts.CacheTTL),
+		defaultLocale:  NormalizeLocale(opts.DefaultLocale),
+		trashRetention: opts.TrashRetention,
+		events:         opts.Events,
+		images:         opts.Images,
+		currency:       opts.Currency,
+		productURL:     opts.ProductURL,
+		search:         &searchCache{},
+		suggestBelow:   opts.SuggestBelow,
+		maxListLimit:   opts.MaxListLimit,
+		unboundedMax:   opts.UnboundedListMax,
+	}
+}
+
+// OnChange регистрирует fn, которая вызывается после каждого изменения
+// каталога, сбрасывающего кэш списка продуктов.
+func (s *ProductService) OnChange(fn func()) {
+	s.cache.onInvalidate(fn)
+}
+
+// CatalogTopic — тема backplane, которой экземпляр сообщает остальным об
+// изменении каталога.
+const CatalogTopic = "catalog"
+
+// OnLocalChange регистрирует fn, которая вызывается только после изменений
+// каталога, сделанных этим экземпляром, — чтобы сообщить о них остальным.
+func (s *ProductService) OnLocalChange(fn func()) {
+	s.cache.onLocalInvalidate(fn)
+}
+
+// DropCaches сбрасывает кэши каталога после изменений, сделанных другим
+// экземпляром: страницы списков, настройки выдачи и словарь поиска.
+// Подписчики OnChange вызываются, OnLocalChange — нет.
+func (s *ProductService) DropCaches() {
+	s.search.reset()
+	s.listings.reset()
+	s.cache.drop()
+}
+
+type primaryKey struct{}
+
+// FromPrimary помечает контекст так, что чтения в нём идут в основную базу.
+// Нужен, когда результат записи читается сразу: реплика может ещё не успеть
+// его получить.
+func FromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// reader возвращает пул для чтения каталога с учётом FromPrimary.
+func (s *ProductService) reader(ctx context.Context) *sql.DB {
+	if s.reads == nil || ctx.Value(primaryKey{}) != nil {
+		return s.db
+	}
+	return s.reads()
+}
+
+// ListParams задаёт страницу списка и желаемые локали в порядке предпочтения.
+// Limit <= 0 означает "без ограничения". Для списка категории
+// (Filter.Category) незаданные Limit и Sort берутся из настроек её выдачи.
+type ListParams struct {
+	Limit   int
+	Offset  int
+	Locales []string
+	Filter  Filter
+	// Sort — один из Sorts; пусто — SortRelevance.
+	Sort string
+}
+
+// Filter сужает список продуктов; пустые поля не ограничивают выборку.
+type Filter struct {
+	// Search — полнотекстовый запрос по названию, категориям и описанию
+	// с учётом синонимов и стоп-слов. List упорядочивает найденное по
+	// релевантности с множителями SearchBoosts, Page — по-прежнему по ID.
+	Search string
+	// NameContains — подстрока названия без учёта регистра.
+	NameContains string
+	MinPrice     *float64
+	MaxPrice     *float64
+	// Categories — продукт должен входить хотя бы в одну из категорий.
+	Categories []string
+	// Category — продукт должен входить в категорию; List применяет к
+	// такому списку настройки выдачи категории.
+	Category string
+}
+
+// where строит условие WHERE и аргументы запроса для фильтра; dict нужен
+// для полнотекстового поиска.
+func (f Filter) where(dict *searchDictionary) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	// Запрос только из стоп-слов выборку не ограничивает.
+	if query := dict.tsquery(f.Search); query != "" {
+		add("search_vector @@ to_tsquery('simple', $%d)", query)
+	}
+	if f.NameContains != "" {
+		add("STRPOS(LOWER(name), LOWER($%d)) > 0", f.NameContains)
+	}
+	if f.MinPrice != nil {
+		add("price >= $%d", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		add("price <= $%d", *f.MaxPrice)
+	}
+	if len(f.Categories) > 0 {
+		add("categories && $%d", pq.Array(f.Categories))
+	}
+	if f.Category != "" {
+		add("$%d = ANY(categories)", f.Category)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (f Filter) cacheKey() string {
+	key := f.Search + "|" + f.NameContains + "|" + strings.Join(f.Categories, ",") + "|" + f.Category
+	if f.MinPrice != nil {
+		key += fmt.Sprintf("|>=%g", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		key += fmt.Sprintf("|<=%g", *f.MaxPrice)
+	}
+	return key
+}
+
+const productColumns = "id, name, price, COALESCE(description, ''), categories, COALESCE(sku, ''), COALESCE(barcode, ''), cost_price"
+
+func scanProduct(row interface{ Scan(...interface{}) error }, product *models.Product, extra ...interface{}) error {
+	var costPrice sql.NullFloat64
+	dest := append([]interface{}{&product.ID, &product.Name, &product.Price, &product.Description,
+		pq.Array(&product.Categories), &product.SKU, &product.Barcode, &costPrice}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	product.CostPrice = nil
+	if costPrice.Valid {
+		product.CostPrice = &costPrice.Float64
+	}
+	setMargin(product)
+	return nil
+}
+
+// setMargin вычисляет маржу продукта по цене и себестоимости.
+func setMargin(product *models.Product) {
+	product.Margin = nil
+	if product.CostPrice != nil && product.Price > 0 {
+		margin := math.Round((product.Price-*product.CostPrice)/product.Price*10000) / 10000
+		product.Margin = &margin
+	}
+}
+
+// publicProduct — продукт для событий: их получают клиенты WebSocket и
+// вебхуки без проверки роли, поэтому поля из auth.FieldPolicy в них не
+// попадают.
+func publicProduct(product models.Product) models.Product {
+	product.CostPrice = nil
+	product.Margin = nil
+	return product
+}
+
+// nullString сохраняет пустые SKU/штрихкоды как NULL, чтобы не нарушать уникальные индексы.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func validateProduct(product models.Product) error {
+	if strings.TrimSpace(product.Name) == "" {
+		return invalid("name is required")
+	}
+	if product.Price < 0 {
+		return invalid("price must be non-negative")
+	}
+	if product.CostPrice != nil && *product.CostPrice < 0 {
+		return invalid("cost price must be non-negative")
+	}
+	return nil
+}
+
+// checkWritableFields отклоняет запись полей из auth.FieldPolicy, которые
+// вызывающему менять нельзя: без токена — auth.ErrUnauthenticated, с
+// другой ролью — auth.ErrForbidden. REST вырезает такие поля из JSON-тел, а
+// GraphQL проверяет аргументы мутаций, но тело может прийти и в другом
+// формате, поэтому правило держит сам сервис. Margin вычисляется, а не
+// сохраняется, и не проверяется.
+func checkWritableFields(ctx context.Context, products ...models.Product) error {
+	if len(auth.HiddenKeys(ctx)) == 0 {
+		return nil
+	}
+	for _, product := range products {
+		product.Margin = nil
+		data, err := json.Marshal(product)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for _, rule := range auth.FieldPolicy {
+			if _, ok := fields[rule.JSON]; ok && rule.Type == "Product" {
+				if err := auth.Require(ctx, rule.Role); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// List возвращает страницу продуктов через кэш и локализует её.
+func (s *ProductService) List(ctx context.Context, params ListParams) ([]models.Product, error) {
+	if params.Limit < 0 || params.Offset < 0 {
+		return nil, invalid("limit and offset must be non-negative")
+	}
+	if !validSort(params.Sort) {
+		return nil, invalid("sort must be one of " + strings.Join(Sorts, ", "))
+	}
+	badges, err := s.applyListing(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkListBounds(ctx, params.Limit); err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%d:%d:%s:%s", params.Limit, params.Offset, params.Sort, params.Filter.cacheKey())
+	products, ok := s.cache.get(key)
+	if !ok {
+		if products, err = s.queryPage(ctx, params, badges); err != nil {
+			return nil, err
+		}
+		s.cache.set(key, products)
+	}
+	if len(products) == 0 && params.Offset == 0 && params.Filter.Search != "" {
+		s.recordZeroResults(params.Filter.Search)
+	}
+	return s.localize(ctx, products, params.Locales)
+}
+
+// checkListBounds защищает от выгрузки всего каталога одним запросом:
+// ограничивает limit и требует его, когда каталог больше unboundedMax.
+func (s *ProductService) checkListBounds(ctx context.Context, limit int) error {
+	if s.maxListLimit > 0 && limit > s.maxListLimit {
+		return invalid(fmt.Sprintf("limit must not exceed %d", s.maxListLimit))
+	}
+	if limit > 0 || s.unboundedMax == 0 {
+		return nil
+	}
+	count, err := s.catalogSize(ctx)
+	if err != nil {
+		return err
+	}
+	if count <= s.unboundedMax {
+		return nil
+	}
+	if s.maxListLimit > 0 {
+		return invalid(fmt.Sprintf("the catalog has more than %d products; request pages with limit (at most %d) and offset", s.unboundedMax, s.maxListLimit))
+	}
+	return invalid(fmt.Sprintf("the catalog has more than %d products; request pages with limit and offset", s.unboundedMax))
+}
+
+func (s *ProductService) catalogSize(ctx context.Context) (int, error) {
+	s.size.mu.Lock()
+	defer s.size.mu.Unlock()
+	if !s.size.loadedAt.IsZero() && time.Since(s.size.loadedAt) < catalogSizeTTL {
+		return s.size.count, nil
+	}
+	var count int
+	if err := s.reader(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM products WHERE deleted_at IS NULL").Scan(&count); err != nil {
+		return 0, err
+	}
+	s.size.count, s.size.loadedAt = count, time.Now()
+	return count, nil
+}
+
+func (s *ProductService) queryPage(ctx context.Context, params ListParams, badges []string) ([]models.Product, error) {
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	where, args := params.Filter.where(dict)
+	order, args := dict.sortOrder(params.Sort, params.Filter.Search, args)
+	query := "SELECT " + productColumns + " FROM products WHERE " + where + " ORDER BY " + order
+	if params.Limit > 0 {
+		args = append(args, params.Limit, params.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	} else if params.Offset > 0 {
+		args = append(args, params.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	products, err := s.queryProducts(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachBadges(ctx, products, badges); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// queryProducts выполняет выборку продуктов и дополняет их ценами и вложениями.
+func (s *ProductService) queryProducts(ctx context.Context, query string, args ...interface{}) ([]models.Product, error) {
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var product models.Product
+		if err := scanProduct(rows, &product); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.attachUpcomingPrices(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := s.attachAttachments(ctx, products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Lookup ищет продукт по штрихкоду или, если он пуст, по SKU.
+func (s *ProductService) Lookup(ctx context.Context, barcode, sku string, locales []string) (models.Product, error) {
+	var column, value string
+	switch {
+	case barcode != "":
+		column, value = "barcode", barcode
+	case sku != "":
+		column, value = "sku", sku
+	default:
+		return models.Product{}, invalid("barcode or sku query parameter is required")
+	}
+	return s.getBy(ctx, column, value, locales)
+}
+
+// Get возвращает продукт по ID.
+func (s *ProductService) Get(ctx context.Context, id int, locales []string) (models.Product, error) {
+	return s.getBy(ctx, "id", id, locales)
+}
+
+// GetMany загружает продукты по списку ID одним запросом; отсутствующие
+// и удалённые продукты в результат не попадают.
+func (s *ProductService) GetMany(ctx context.Context, ids []int, locales []string) (map[int]models.Product, error) {
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		ids64[i] = int64(id)
+	}
+	products, err := s.queryProducts(ctx, "SELECT "+productColumns+" FROM products WHERE id = ANY($1) AND deleted_at IS NULL", pq.Array(ids64))
+	if err != nil {
+		return nil, err
+	}
+	if products, err = s.localize(ctx, products, locales); err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	return byID, nil
+}
+
+// getBy загружает один продукт по значению уникальной колонки.
+func (s *ProductService) getBy(ctx context.Context, column string, value interface{}, locales []string) (models.Product, error) {
+	var product models.Product
+	err := scanProduct(s.reader(ctx).QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE "+column+" = $1 AND deleted_at IS NULL", value), &product)
+	if err == sql.ErrNoRows {
+		return models.Product{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	products := []models.Product{product}
+	if err := s.attachUpcomingPrices(ctx, products); err != nil {
+		return models.Product{}, err
+	}
+	if err := s.attachAttachments(ctx, products); err != nil {
+		return models.Product{}, err
+	}
+	products, err = s.localize(ctx, products, locales)
+	if err != nil {
+		return models.Product{}, err
+	}
+	return products[0], nil
+}
+
+// insertProduct добавляет продукт вместе с поисковым индексом и возвращает его ID.
+func insertProduct(ctx context.Context, db interface {
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}, dict *searchDictionary, product models.Product) (int, error) {
+	query := "INSERT INTO products (name, price, description, categories, sku, barcode, cost_price, search_vector, search_version)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, " + searchVectorExpr(8) + ", $11) RETURNING id"
+	args := append([]interface{}{product.Name, product.Price, product.Description, pq.Array(product.Categories),
+		nullString(product.SKU), nullString(product.Barcode), product.CostPrice}, dict.vectorArgs(product)...)
+	var id int
+	err := db.QueryRowContext(ctx, query, append(args, dict.version)...).Scan(&id)
+	return id, err
+}
+
+// Create добавляет продукты и заполняет их ID.
+func (s *ProductService) Create(ctx context.Context, products []models.Product) ([]models.Product, error) {
+	for _, product := range products {
+		if err := validateProduct(product); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkWritableFields(ctx, products...); err != nil {
+		return nil, err
+	}
+
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		if products[i].ID, err = insertProduct(ctx, s.db, dict, products[i]); err != nil {
+			return nil, err
+		}
+		setMargin(&products[i])
+	}
+	s.cache.invalidate()
+	for _, product := range products {
+		s.events.Publish(ctx, events.ProductCreated, publicProduct(product))
+	}
+	return products, nil
+}
+
+// maxImportProducts ограничивает размер одного пакета Import.
+const maxImportProducts = 1000
+
+// Import создаёт продукты в одной транзакции и возвращает итог по каждому.
+// Невалидные продукты и продукты с уже занятым SKU или штрихкодом
+// пропускаются, остальные фиксируются вместе: каждая вставка идёт в своей
+// точке сохранения, так что конфликт одной строки не обрывает транзакцию.
+// Прочие ошибки базы отменяют весь пакет.
+func (s *ProductService) Import(ctx context.Context, products []models.Product) ([]models.ProductImportResult, error) {
+	if len(products) == 0 {
+		return nil, invalid("import must contain at least one product")
+	}
+	if len(products) > maxImportProducts {
+		return nil, invalid(fmt.Sprintf("import can contain at most %d products", maxImportProducts))
+	}
+	if err := checkWritableFields(ctx, products...); err != nil {
+		return nil, err
+	}
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]models.ProductImportResult, len(products))
+	var created []models.Product
+	for i, product := range products {
+		results[i].Index = i
+		if err := validateProduct(product); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_product"); err != nil {
+			return nil, err
+		}
+		product.ID, err = insertProduct(ctx, tx, dict, product)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_product"); err != nil {
+				return nil, err
+			}
+			results[i].Error = duplicateProductMessage(pqErr.Constraint)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT import_product"); err != nil {
+			return nil, err
+		}
+		setMargin(&product)
+		results[i].Product = &product
+		created = append(created, product)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(created) > 0 {
+		s.cache.invalidate()
+	}
+	for _, product := range created {
+		s.events.Publish(ctx, events.ProductCreated, publicProduct(product))
+	}
+	return results, nil
+}
+
+// duplicateProductMessage описывает нарушение уникального индекса продуктов.
+func duplicateProductMessage(constraint string) string {
+	switch constraint {
+	case "products_sku_key":
+		return "a product with this SKU already exists"
+	case "products_barcode_key":
+		return "a product with this barcode already exists"
+	}
+	return "product conflicts with an existing product"
+}
+
+func (s *ProductService) Update(ctx context.Context, id int, product models.Product) error {
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	if err := checkWritableFields(ctx, product); err != nil {
+		return err
+	}
+
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return err
+	}
+	var before models.Product
+	if auditing(ctx) {
+		if before, err = s.Get(FromPrimary(ctx), id, nil); err != nil {
+			return err
+		}
+	}
+	// Себестоимость видят и меняют только администраторы, поэтому
+	// обновление без неё сохраняет прежнее значение, а не стирает его.
+	query := "UPDATE products SET name=$1, price=$2, description=$3, categories=$4, sku=$5, barcode=$6, cost_price=COALESCE($7, cost_price)," +
+		" search_vector=" + searchVectorExpr(9) + ", search_version=$12 WHERE id=$8 AND deleted_at IS NULL"
+	args := append([]interface{}{product.Name, product.Price, product.Description, pq.Array(product.Categories),
+		nullString(product.SKU), nullString(product.Barcode), product.CostPrice, id}, dict.vectorArgs(product)...)
+	res, err := s.db.ExecContext(ctx, query, append(args, dict.version)...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	s.cache.invalidate()
+	product.ID = id
+	if auditing(ctx) {
+		after := before
+		after.Name, after.Price, after.Description, after.Categories = product.Name, product.Price, product.Description, product.Categories
+		after.SKU, after.Barcode = product.SKU, product.Barcode
+		if product.CostPrice != nil {
+			after.CostPrice = product.CostPrice
+		}
+		setMargin(&after)
+		recordChange(ctx, before, after)
+	}
+	s.events.Publish(ctx, events.ProductUpdated, publicProduct(product))
+	return nil
+}
+
+// Delete перемещает продукт в корзину; окончательно он удаляется после окна восстановления.
+func (s *ProductService) Delete(ctx context.Context, id int) error {
+	var before models.Product
+	if auditing(ctx) {
+		var err error
+		if before, err = s.Get(FromPrimary(ctx), id, nil); err != nil {
+			return err
+		}
+	}
+	res, err := s.db.ExecContext(ctx, "UPDATE products SET deleted_at = NOW() WHERE id=$1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	s.cache.invalidate()
+	recordChange(ctx, before, nil)
+	s.events.Publish(ctx, events.ProductDeleted, events.ProductRef{ID: id})
+	return nil
+}
+
+// InvalidateCache сбрасывает кэш списка, когда связанные с продуктами данные
+// меняются вне ProductService.
+func (s *ProductService) InvalidateCache() {
+	s.cache.invalidate()
+}
+
+func (s *ProductService) exists(id int) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"server/internal/models"
+	"sort"
+)
+
+const (
+	// maxSearchBoost ограничивает множитель, чтобы одно усиление не
+	// перекрывало релевантность полностью.
+	maxSearchBoost = 100
+	// maxMarginTiers ограничивает число уровней маржи.
+	maxMarginTiers = 10
+)
+
+// Результаты поиска в List упорядочены по ts_rank, умноженному на множители
+// SearchBoosts. Множители вычисляются при каждом запросе по текущим данным —
+// наличию на складе, себестоимости, отметке рекомендуемого, — поэтому их
+// изменение не требует переиндексации.
+
+func validateSearchBoosts(boosts models.SearchBoosts) (models.SearchBoosts, error) {
+	check := func(name string, value float64) error {
+		if value <= 0 || value > maxSearchBoost {
+			return invalid(fmt.Sprintf("%s boost must be greater than 0 and at most %d", name, maxSearchBoost))
+		}
+		return nil
+	}
+	if err := check("featured", boosts.Featured); err != nil {
+		return models.SearchBoosts{}, err
+	}
+	if err := check("in-stock", boosts.InStock); err != nil {
+		return models.SearchBoosts{}, err
+	}
+	if len(boosts.MarginTiers) > maxMarginTiers {
+		return models.SearchBoosts{}, invalid(fmt.Sprintf("at most %d margin tiers are allowed", maxMarginTiers))
+	}
+	tiers := append([]models.MarginTier{}, boosts.MarginTiers...)
+	seen := make(map[float64]bool, len(tiers))
+	for _, tier := range tiers {
+		if tier.MinMargin < 0 || tier.MinMargin >= 1 {
+			return models.SearchBoosts{}, invalid("min margin must be at least 0 and less than 1")
+		}
+		if seen[tier.MinMargin] {
+			return models.SearchBoosts{}, invalid(fmt.Sprintf("duplicate margin tier %g", tier.MinMargin))
+		}
+		seen[tier.MinMargin] = true
+		if err := check("margin tier", tier.Boost); err != nil {
+			return models.SearchBoosts{}, err
+		}
+	}
+	// Уровни хранятся от большей маржи к меньшей: в таком порядке их
+	// проверяет CASE в rankOrder.
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinMargin > tiers[j].MinMargin })
+	boosts.MarginTiers = tiers
+	return boosts, nil
+}
+
+// SearchBoosts возвращает множители ранжирования результатов поиска.
+func (s *ProductService) SearchBoosts(ctx context.Context) (models.SearchBoosts, error) {
+	var boosts models.SearchBoosts
+	var tiers []byte
+	err := s.db.QueryRowContext(ctx, "SELECT featured, in_stock, margin_tiers FROM search_boosts").
+		Scan(&boosts.Featured, &boosts.InStock, &tiers)
+	if err != nil {
+		return models.SearchBoosts{}, err
+	}
+	if err := json.Unmarshal(tiers, &boosts.MarginTiers); err != nil {
+		return models.SearchBoosts{}, err
+	}
+	return boosts, nil
+}
+
+// SetSearchBoosts заменяет множители ранжирования.
+func (s *ProductService) SetSearchBoosts(ctx context.Context, boosts models.SearchBoosts) (models.SearchBoosts, error) {
+	boosts, err := validateSearchBoosts(boosts)
+	if err != nil {
+		return models.SearchBoosts{}, err
+	}
+	tiers, err := json.Marshal(boosts.MarginTiers)
+	if err != nil {
+		return models.SearchBoosts{}, err
+	}
+	if _, err := s.db.ExecContext(ctx, "UPDATE search_boosts SET featured = $1, in_stock = $2, margin_tiers = $3",
+		boosts.Featured, boosts.InStock, tiers); err != nil {
+		return models.SearchBoosts{}, err
+	}
+	s.search.reset()
+	s.cache.invalidate()
+	return boosts, nil
+}
+
+// FeaturedProducts возвращает ID рекомендуемых продуктов в порядке отметки.
+func (s *ProductService) FeaturedProducts(ctx context.Context) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.product_id FROM featured_products f JOIN products p ON p.id = f.product_id
+		WHERE p.deleted_at IS NULL ORDER BY f.created_at, f.product_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// FeatureProduct отмечает продукт как рекомендуемый; повторная отметка
+// ничего не меняет.
+func (s *ProductService) FeatureProduct(ctx context.Context, id int) error {
+	err := affectOne(s.db.ExecContext(ctx, `
+		INSERT INTO featured_products (product_id)
+		SELECT id FROM products WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (product_id) DO UPDATE SET created_at = featured_products.created_at`, id))
+	if err != nil {
+		return err
+	}
+	s.cache.invalidate()
+	return nil
+}
+
+func (s *ProductService) UnfeatureProduct(ctx context.Context, id int) error {
+	if err := affectOne(s.db.ExecContext(ctx, "DELETE FROM featured_products WHERE product_id = $1", id)); err != nil {
+		return err
+	}
+	s.cache.invalidate()
+	return nil
+}
+
+// rankOrder строит ORDER BY для фильтра: по релевантности с множителями,
+// если в поисковом запросе остались слова, иначе по ID. Нейтральные
+// множители в запрос не попадают.
+func (d *searchDictionary) rankOrder(search string, args []interface{}) (string, []interface{}) {
+	query := d.tsquery(search)
+	if query == "" {
+		return "id", args
+	}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	rank := "ts_rank(search_vector, to_tsquery('simple', " + arg(query) + "))"
+	if d.boosts.Featured != 1 {
+		rank += " * CASE WHEN EXISTS (SELECT 1 FROM featured_products f WHERE f.product_id = products.id)" +
+			" THEN " + arg(d.boosts.Featured) + "::float8 ELSE 1 END"
+	}
+	if d.boosts.InStock != 1 {
+		rank += " * CASE WHEN EXISTS (SELECT 1 FROM product_stock st WHERE st.product_id = products.id AND st.quantity > 0)" +
+			" THEN " + arg(d.boosts.InStock) + "::float8 ELSE 1 END"
+	}
+	if len(d.boosts.MarginTiers) > 0 {
+		rank += " * CASE"
+		for _, tier := range d.boosts.MarginTiers {
+			rank += " WHEN cost_price IS NOT NULL AND price > 0 AND (price - cost_price) / price >= " + arg(tier.MinMargin) +
+				"::float8 THEN " + arg(tier.Boost) + "::float8"
+		}
+		rank += " ELSE 1 END"
+	}
+	return rank + " DESC, id", args
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"server/internal/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// searchDictionaryTTL — как долго экземпляр сервера использует загруженный
+	// словарь; изменения, сделанные через другой экземпляр, видны не позже.
+	searchDictionaryTTL = 30 * time.Second
+	// searchIndexBatchSize — сколько продуктов переиндексируется за один запрос.
+	searchIndexBatchSize = 200
+	// maxSearchTermLength ограничивает длину синонима и стоп-слова.
+	maxSearchTermLength = 64
+	// maxZeroResultQueryLength — длина, до которой обрезается сохраняемый
+	// запрос без результатов.
+	maxZeroResultQueryLength = 255
+)
+
+// Поиск работает поверх полнотекстового индекса Postgres с конфигурацией
+// simple. Синонимы и стоп-слова применяются на стороне приложения, потому что
+// словари Postgres — файлы на сервере базы, недоступные через SQL: документ
+// продукта при индексации дополняется синонимами его слов, а из запроса
+// выбрасываются стоп-слова. Поэтому любое изменение словаря увеличивает его
+// версию, и фоновая задача переиндексирует продукты под новую версию.
+
+// searchDictionary — загруженный словарь синонимов и стоп-слов вместе с
+// множителями ранжирования.
+type searchDictionary struct {
+	version   int
+	synonyms  map[string][]string
+	stopwords map[string]bool
+	boosts    models.SearchBoosts
+}
+
+// tokenize разбивает текст на слова в нижнем регистре.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// terms возвращает слова текста без стоп-слов.
+func (d *searchDictionary) terms(text string) []string {
+	var terms []string
+	for _, word := range tokenize(text) {
+		if !d.stopwords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// document готовит текст к индексации: слова без стоп-слов и их синонимы.
+func (d *searchDictionary) document(text string) string {
+	var words []string
+	for _, term := range d.terms(text) {
+		words = append(words, term)
+		words = append(words, d.synonyms[term]...)
+	}
+	return strings.Join(words, " ")
+}
+
+// tsquery строит запрос to_tsquery: все слова обязательны, каждое может быть
+// началом слова документа. Пустая строка — в запросе не осталось слов.
+func (d *searchDictionary) tsquery(text string) string {
+	terms := d.terms(text)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// searchVectorExpr — tsvector продукта из трёх документов, начиная с
+// параметра $first: название весит больше категорий, категории — больше описания.
+func searchVectorExpr(first int) string {
+	return fmt.Sprintf("setweight(to_tsvector('simple', $%d), 'A') || setweight(to_tsvector('simple', $%d), 'B') || setweight(to_tsvector('simple', $%d), 'C')",
+		first, first+1, first+2)
+}
+
+// vectorArgs возвращает документы продукта для searchVectorExpr.
+func (d *searchDictionary) vectorArgs(product models.Product) []interface{} {
+	return []interface{}{
+		d.document(product.Name),
+		d.document(strings.Join(product.Categories, " ")),
+		d.document(product.Description),
+	}
+}
+
+// searchCache хранит словарь между запросами.
+type searchCache struct {
+	mu       sync.Mutex
+	dict     *searchDictionary
+	loadedAt time.Time
+}
+
+func (c *searchCache) reset() {
+	c.mu.Lock()
+	c.dict = nil
+	c.mu.Unlock()
+}
+
+// searchDictionary возвращает словарь, перечитывая его из основной базы по
+// истечении searchDictionaryTTL.
+func (s *ProductService) searchDictionary(ctx context.Context) (*searchDictionary, error) {
+	s.search.mu.Lock()
+	defer s.search.mu.Unlock()
+	if s.search.dict != nil && time.Since(s.search.loadedAt) < searchDictionaryTTL {
+		return s.search.dict, nil
+	}
+	dict, err := s.loadSearchDictionary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.search.dict, s.search.loadedAt = dict, time.Now()
+	return dict, nil
+}
+
+func (s *ProductService) loadSearchDictionary(ctx context.Context) (*searchDictionary, error) {
+	dict := &searchDictionary{synonyms: make(map[string][]string), stopwords: make(map[string]bool)}
+	if err := s.db.QueryRowContext(ctx, "SELECT version FROM search_index_state").Scan(&dict.version); err != nil {
+		return nil, err
+	}
+
+	groups, err := s.Synonyms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		for _, term := range group.Terms {
+			for _, other := range group.Terms {
+				if other != term {
+					dict.synonyms[term] = append(dict.synonyms[term], other)
+				}
+			}
+		}
+	}
+
+	words, err := s.Stopwords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range words {
+		dict.stopwords[word] = true
+	}
+
+	if dict.boosts, err = s.SearchBoosts(ctx); err != nil {
+		return nil, err
+	}
+	return dict, nil
+}
+
+// normalizeSearchTerm приводит синоним или стоп-слово к виду, в котором оно
+// хранится: одно слово в нижнем регистре.
+func normalizeSearchTerm(term string) (string, error) {
+	words := tokenize(term)
+	if len(words) != 1 || words[0] != strings.ToLower(strings.TrimSpace(term)) {
+		return "", invalid(fmt.Sprintf("%q must be a single word of letters and digits", term))
+	}
+	if len(words[0]) > maxSearchTermLength {
+		return "", invalid(fmt.Sprintf("%q exceeds %d characters", term, maxSearchTermLength))
+	}
+	return words[0], nil
+}
+
+func normalizeSynonyms(terms []string) ([]string, error) {
+	seen := make(map[string]bool, len(terms))
+	var normalized []string
+	for _, term := range terms {
+		word, err := normalizeSearchTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[word] {
+			seen[word] = true
+			normalized = append(normalized, word)
+		}
+	}
+	if len(normalized) < 2 {
+		return nil, invalid("a synonym group needs at least two different words")
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// Synonyms возвращает группы синонимов.
+func (s *ProductService) Synonyms(ctx context.Context) ([]models.SynonymGroup, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, terms FROM search_synonyms ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.SynonymGroup{}
+	for rows.Next() {
+		var group models.SynonymGroup
+		if err := rows.Scan(&group.ID, pq.Array(&group.Terms)); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// AddSynonyms создаёт группу синонимов и запускает переиндексацию.
+func (s *ProductService) AddSynonyms(ctx context.Context, terms []string) (models.SynonymGroup, error) {
+	terms, err := normalizeSynonyms(terms)
+	if err != nil {
+		return models.SynonymGroup{}, err
+	}
+	group := models.SynonymGroup{Terms: terms}
+	err = s.changeSearchDictionary(ctx, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "INSERT INTO search_synonyms (terms) VALUES ($1) RETURNING id", pq.Array(terms)).Scan(&group.ID)
+	})
+	if err != nil {
+		return models.SynonymGroup{}, err
+	}
+	return group, nil
+}
+
+// UpdateSynonyms заменяет слова группы синонимов.
+func (s *ProductService) UpdateSynonyms(ctx context.Context, id int, terms []string) (models.SynonymGroup, error) {
+	terms, err := normalizeSynonyms(terms)
+	if err != nil {
+		return models.SynonymGroup{}, err
+	}
+	err = s.changeSearchDictionary(ctx, func(tx *sql.Tx) error {
+		return affectOne(tx.ExecContext(ctx, "UPDATE search_synonyms SET terms = $1 WHERE id = $2", pq.Array(terms), id))
+	})
+	if err != nil {
+		return models.SynonymGroup{}, err
+	}
+	return models.SynonymGroup{ID: id, Terms: terms}, nil
+}
+
+func (s *ProductService) DeleteSynonyms(ctx context.Context, id int) error {
+	return s.changeSearchDictionary(ctx, func(tx *sql.Tx) error {
+		return affectOne(tx.ExecContext(ctx, "DELETE FROM search_synonyms WHERE id = $1", id))
+	})
+}
+
+// Stopwords возвращает стоп-слова по алфавиту.
+func (s *ProductService) Stopwords(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT word FROM search_stopwords ORDER BY word")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := []string{}
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// AddStopword добавляет стоп-слово; повторное добавление ничего не меняет.
+func (s *ProductService) AddStopword(ctx context.Context, word string) error {
+	word, err := normalizeSearchTerm(word)
+	if err != nil {
+		return err
+	}
+	return s.changeSearchDictionary(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO search_stopwords (word) VALUES ($1) ON CONFLICT DO NOTHING", word)
+		return err
+	})
+}
+
+func (s *ProductService) DeleteStopword(ctx context.Context, word string) error {
+	return s.changeSearchDictionary(ctx, func(tx *sql.Tx) error {
+		return affectOne(tx.ExecContext(ctx, "DELETE FROM search_stopwords WHERE word = $1", strings.ToLower(word)))
+	})
+}
+
+// affectOne превращает изменение, не затронувшее ни одной строки, в ErrNotFound.
+func affectOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// changeSearchDictionary применяет изменение словаря и в той же транзакции
+// увеличивает его версию, чтобы продукты переиндексировались.
+func (s *ProductService) changeSearchDictionary(ctx context.Context, change func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE search_index_state SET version = version + 1"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.search.reset()
+	s.cache.invalidate()
+	return nil
+}
+
+// Reindex запускает полную переиндексацию продуктов, например после
+// восстановления базы из резервной копии.
+func (s *ProductService) Reindex(ctx context.Context) (models.SearchIndexStatus, error) {
+	if _, err := s.db.ExecContext(ctx, "UPDATE search_index_state SET version = version + 1"); err != nil {
+		return models.SearchIndexStatus{}, err
+	}
+	s.search.reset()
+	return s.SearchIndexStatus(ctx)
+}
+
+// SearchIndexStatus возвращает версию словаря и число продуктов, которые
+// ещё не переиндексированы под неё.
+func (s *ProductService) SearchIndexStatus(ctx context.Context) (models.SearchIndexStatus, error) {
+	var status models.SearchIndexStatus
+	err := s.db.QueryRowContext(ctx, `
+		SELECT v.version, (SELECT COUNT(*) FROM products WHERE search_version IS DISTINCT FROM v.version)
+		FROM search_index_state v`).Scan(&status.Version, &status.Pending)
+	return status, err
+}
+
+// ReindexPending переиндексирует продукты, проиндексированные под прежнюю
+// версию словаря, и возвращает их число. Обновление строки проверяет, что
+// продукт не изменился после чтения: иначе его индекс уже записал Update.
+func (s *ProductService) ReindexPending() (int, error) {
+	ctx := context.Background()
+	dict, err := s.loadSearchDictionary(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.search.mu.Lock()
+	s.search.dict, s.search.loadedAt = dict, time.Now()
+	s.search.mu.Unlock()
+
+	update := "UPDATE products SET search_vector = " + searchVectorExpr(1) + ", search_version = $4" +
+		" WHERE id = $5 AND name = $6 AND COALESCE(description, '') = $7 AND categories IS NOT DISTINCT FROM $8"
+	total := 0
+	for {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT id, name, COALESCE(description, ''), categories FROM products
+			WHERE search_version IS DISTINCT FROM $1 ORDER BY id LIMIT $2`, dict.version, searchIndexBatchSize)
+		if err != nil {
+			return total, err
+		}
+		var batch []models.Product
+		for rows.Next() {
+			var product models.Product
+			if err := rows.Scan(&product.ID, &product.Name, &product.Description, pq.Array(&product.Categories)); err != nil {
+				rows.Close()
+				return total, err
+			}
+			batch = append(batch, product)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		updated := 0
+		for _, product := range batch {
+			args := append(dict.vectorArgs(product), dict.version, product.ID, product.Name, product.Description, pq.Array(product.Categories))
+			res, err := s.db.ExecContext(ctx, update, args...)
+			if err != nil {
+				return total, err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				updated++
+			}
+		}
+		total += updated
+		// Все продукты порции изменились во время индексации — следующий
+		// запуск задачи прочитает их заново.
+		if updated == 0 {
+			break
+		}
+	}
+	if total > 0 {
+		s.cache.invalidate()
+	}
+	return total, nil
+}
+
+// recordZeroResults запоминает поисковый запрос, по которому ничего не
+// нашлось. Запросы сводятся к словам в нижнем регистре, чтобы варианты
+// написания складывались в один счётчик. Запись идёт в фоне: поиск её не
+// ждёт, ошибки только логируются.
+func (s *ProductService) recordZeroResults(search string) {
+	query := []rune(strings.Join(tokenize(search), " "))
+	if len(query) == 0 {
+		return
+	}
+	if len(query) > maxZeroResultQueryLength {
+		query = query[:maxZeroResultQueryLength]
+	}
+	go func(query string) {
+		_, err := s.db.Exec(`
+			INSERT INTO search_zero_results (query) VALUES ($1)
+			ON CONFLICT (query) DO UPDATE SET hits = search_zero_results.hits + 1, last_seen_at = NOW()`, query)
+		if err != nil {
+			log.Printf("Не удалось записать поиск без результатов %q: %v", query, err)
+		}
+	}(string(query))
+}
+
+// ZeroResultSearches возвращает запросы без результатов, которые повторялись
+// после since, начиная с самых частых.
+func (s *ProductService) ZeroResultSearches(ctx context.Context, since time.Time, limit int) ([]models.ZeroResultSearch, error) {
+	if limit <= 0 || limit > 1000 {
+		return nil, invalid("limit must be between 1 and 1000")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT query, hits, first_seen_at, last_seen_at FROM search_zero_results
+		WHERE last_seen_at >= $1 ORDER BY hits DESC, last_seen_at DESC LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := []models.ZeroResultSearch{}
+	for rows.Next() {
+		var search models.ZeroResultSearch
+		if err := rows.Scan(&search.Query, &search.Hits, &search.FirstSeenAt, &search.LastSeenAt); err != nil {
+			return nil, err
+		}
+		searches = append(searches, search)
+	}
+	return searches, rows.Err()
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"server/internal/models"
+	"strings"
+	"sync"
+)
+
+// DefaultTenant — арендатор запросов, которые его не указали.
+const DefaultTenant = "default"
+
+// SettingsTopic — тема backplane, которой экземпляр сообщает остальным об
+// изменении настроек витрины.
+const SettingsTopic = "settings"
+
+var (
+	tenantPattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	localePattern   = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+	colorPattern    = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	phonePattern    = regexp.MustCompile(`^\+?[0-9 ()-]{3,32}$`)
+)
+
+// ValidTenant сообщает, допустимо ли имя арендатора: строчные латинские
+// буквы, цифры, '_' и '-', до 64 символов.
+func ValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+// SettingsService хранит настройки витрин арендаторов: логотип, цвет,
+// валюту, язык и контакты. Витрина читает их при каждой загрузке, поэтому
+// они держатся в памяти до изменения.
+type SettingsService struct {
+	db *sql.DB
+	// defaults — валюта и язык сервера для арендаторов, не задавших свои.
+	defaults models.StorefrontSettings
+
+	mu       sync.Mutex
+	byTenant map[string]models.StorefrontSettings
+	// generation растёт при каждом изменении; настройки, прочитанные до
+	// изменения, в память не сохраняются.
+	generation uint64
+	// changed вызываются после изменений, сделанных этим экземпляром.
+	changed []func()
+}
+
+func NewSettingsService(db *sql.DB, currency, locale string) *SettingsService {
+	return &SettingsService{
+		db:       db,
+		defaults: models.StorefrontSettings{Currency: currency, DefaultLocale: NormalizeLocale(locale)},
+		byTenant: make(map[string]models.StorefrontSettings),
+	}
+}
+
+// OnLocalChange регистрирует fn, которая вызывается после изменений
+// настроек, сделанных этим экземпляром, — чтобы сообщить о них остальным.
+func (s *SettingsService) OnLocalChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changed = append(s.changed, fn)
+}
+
+// DropCache сбрасывает настройки в памяти после изменений, сделанных
+// другим экземпляром.
+func (s *SettingsService) DropCache() {
+	s.mu.Lock()
+	s.byTenant = make(map[string]models.StorefrontSettings)
+	s.generation++
+	s.mu.Unlock()
+}
+
+const settingsColumns = "tenant, logo_url, primary_color, currency, default_locale, contact_email, contact_phone, updated_at"
+
+// Get возвращает настройки витрины арендатора; у арендатора без настроек —
+// значения по умолчанию.
+func (s *SettingsService) Get(ctx context.Context, tenant string) (models.StorefrontSettings, error) {
+	if !ValidTenant(tenant) {
+		return models.StorefrontSettings{}, invalid("tenant must be 1-64 lowercase letters, digits, '_' or '-'")
+	}
+	s.mu.Lock()
+	settings, ok := s.byTenant[tenant]
+	generation := s.generation
+	s.mu.Unlock()
+	if ok {
+		return settings, nil
+	}
+
+	settings = models.StorefrontSettings{Tenant: tenant}
+	err := s.db.QueryRowContext(ctx, "SELECT "+settingsColumns+" FROM storefront_settings WHERE tenant = $1", tenant).Scan(
+		&settings.Tenant, &settings.LogoURL, &settings.PrimaryColor, &settings.Currency, &settings.DefaultLocale,
+		&settings.ContactEmail, &settings.ContactPhone, &settings.UpdatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.StorefrontSettings{}, err
+	}
+	settings = s.withDefaults(settings)
+	s.mu.Lock()
+	if s.generation == generation {
+		s.byTenant[tenant] = settings
+	}
+	s.mu.Unlock()
+	return settings, nil
+}
+
+// Put заменяет настройки витрины арендатора целиком.
+func (s *SettingsService) Put(ctx context.Context, tenant string, settings models.StorefrontSettings) (models.StorefrontSettings, error) {
+	if !ValidTenant(tenant) {
+		return models.StorefrontSettings{}, invalid("tenant must be 1-64 lowercase letters, digits, '_' or '-'")
+	}
+	settings.Tenant = tenant
+	settings, err := normalizeSettings(settings)
+	if err != nil {
+		return models.StorefrontSettings{}, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO storefront_settings (tenant, logo_url, primary_color, currency, default_locale, contact_email, contact_phone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant) DO UPDATE SET logo_url = EXCLUDED.logo_url, primary_color = EXCLUDED.primary_color,
+			currency = EXCLUDED.currency, default_locale = EXCLUDED.default_locale,
+			contact_email = EXCLUDED.contact_email, contact_phone = EXCLUDED.contact_phone, updated_at = NOW()
+		RETURNING updated_at`,
+		tenant, settings.LogoURL, settings.PrimaryColor, settings.Currency, settings.DefaultLocale,
+		settings.ContactEmail, settings.ContactPhone).Scan(&settings.UpdatedAt)
+	if err != nil {
+		return models.StorefrontSettings{}, err
+	}
+	settings = s.withDefaults(settings)
+
+	s.mu.Lock()
+	s.byTenant[tenant] = settings
+	s.generation++
+	changed := s.changed
+	s.mu.Unlock()
+	for _, fn := range changed {
+		fn()
+	}
+	return settings, nil
+}
+
+func (s *SettingsService) withDefaults(settings models.StorefrontSettings) models.StorefrontSettings {
+	if settings.Currency == "" {
+		settings.Currency = s.defaults.Currency
+	}
+	if settings.DefaultLocale == "" {
+		settings.DefaultLocale = s.defaults.DefaultLocale
+	}
+	return settings
+}
+
+// normalizeSettings приводит поля к каноническому виду и проверяет их.
+// Пустое поле означает «не задано».
+func normalizeSettings(settings models.StorefrontSettings) (models.StorefrontSettings, error) {
+	settings.LogoURL = strings.TrimSpace(settings.LogoURL)
+	settings.PrimaryColor = strings.ToLower(strings.TrimSpace(settings.PrimaryColor))
+	settings.Currency = strings.ToUpper(strings.TrimSpace(settings.Currency))
+	settings.DefaultLocale = NormalizeLocale(settings.DefaultLocale)
+	settings.ContactEmail = strings.TrimSpace(settings.ContactEmail)
+	settings.ContactPhone = strings.TrimSpace(settings.ContactPhone)
+
+	if settings.LogoURL != "" {
+		u, err := url.Parse(settings.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(settings.LogoURL) > 2048 {
+			return settings, invalid("logo_url must be an absolute http or https URL")
+		}
+	}
+	if settings.PrimaryColor != "" && !colorPattern.MatchString(settings.PrimaryColor) {
+		return settings, invalid("primary_color must be a #RRGGBB color")
+	}
+	if settings.Currency != "" && !currencyPattern.MatchString(settings.Currency) {
+		return settings, invalid("currency must be an ISO 4217 code such as RUB")
+	}
+	if settings.DefaultLocale != "" && (len(settings.DefaultLocale) > 35 || !localePattern.MatchString(settings.DefaultLocale)) {
+		return settings, invalid("default_locale must be a language tag such as en or pt-br")
+	}
+	if settings.ContactEmail != "" {
+		address, err := mail.ParseAddress(settings.ContactEmail)
+		if err != nil || address.Address != settings.ContactEmail || len(settings.ContactEmail) > 255 {
+			return settings, invalid("contact_email must be an email address")
+		}
+	}
+	if settings.ContactPhone != "" && !phonePattern.MatchString(settings.ContactPhone) {
+		return settings, invalid("contact_phone must contain only digits, spaces, '+', '-' and parentheses")
+	}
+	return settings, nil
+}
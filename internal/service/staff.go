@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"net/mail"
+	"server/internal/auth"
+	"server/internal/models"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// StaffTopic — тема backplane, которой экземпляр сообщает остальным об
+// изменении каталога сотрудников.
+const StaffTopic = "staff"
+
+const (
+	// staffReloadTimeout ограничивает перечитывание каталога по сообщению
+	// backplane.
+	staffReloadTimeout = 5 * time.Second
+	defaultStaffPage   = 100
+	maxStaffPage       = 500
+)
+
+const staffColumns = "id, external_id, user_name, display_name, email, role, active, created_at, updated_at"
+
+func scanStaffUser(row interface{ Scan(...interface{}) error }, u *models.StaffUser) error {
+	return row.Scan(&u.ID, &u.ExternalID, &u.UserName, &u.DisplayName, &u.Email, &u.Role, &u.Active, &u.CreatedAt, &u.UpdatedAt)
+}
+
+// StaffDirectory — каталог сотрудников, который провайдер удостоверений
+// ведёт через SCIM. Роль и активность проверяются при каждой проверке
+// токена, поэтому они держатся в памяти и перечитываются при изменении.
+// Удалённый сотрудник остаётся в памяти отключённым, чтобы его ещё не
+// истёкшие токены перестали действовать.
+type StaffDirectory struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	accounts map[string]auth.Account
+	// names — имя пользователя по ID, чтобы переименование убирало
+	// прежнее имя.
+	names map[string]string
+	// changed вызываются после изменений, сделанных этим экземпляром.
+	changed []func()
+}
+
+func NewStaffDirectory(db *sql.DB) *StaffDirectory {
+	return &StaffDirectory{db: db, accounts: make(map[string]auth.Account), names: make(map[string]string)}
+}
+
+// OnLocalChange регистрирует fn, которая вызывается после изменений
+// каталога, сделанных этим экземпляром, — чтобы сообщить о них остальным.
+func (d *StaffDirectory) OnLocalChange(fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.changed = append(d.changed, fn)
+}
+
+// Reload перечитывает каталог из базы.
+func (d *StaffDirectory) Reload(ctx context.Context) error {
+	rows, err := d.db.QueryContext(ctx, "SELECT id, user_name, role, active AND deleted_at IS NULL FROM staff_users")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]auth.Account)
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		var account auth.Account
+		if err := rows.Scan(&id, &name, &account.Role, &account.Active); err != nil {
+			return err
+		}
+		accounts[name] = account
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.accounts = accounts
+	d.names = names
+	d.mu.Unlock()
+	return nil
+}
+
+// DropCache перечитывает каталог после изменений, сделанных другим
+// экземпляром.
+func (d *StaffDirectory) DropCache() {
+	ctx, cancel := context.WithTimeout(context.Background(), staffReloadTimeout)
+	defer cancel()
+	if err := d.Reload(ctx); err != nil {
+		log.Printf("Не удалось перечитать каталог сотрудников: %v", err)
+	}
+}
+
+// Account возвращает роль и активность сотрудника username.
+func (d *StaffDirectory) Account(username string) (auth.Account, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	account, ok := d.accounts[username]
+	return account, ok
+}
+
+// StaffQuery выбирает сотрудников: с UserName (без учёта регистра) или
+// ExternalID — только совпадающих. Limit по умолчанию 100, не больше 500.
+type StaffQuery struct {
+	UserName   string
+	ExternalID string
+	Offset     int
+	Limit      int
+}
+
+// List возвращает страницу сотрудников в порядке заведения и общее число
+// подходящих.
+func (d *StaffDirectory) List(ctx context.Context, q StaffQuery) ([]models.StaffUser, int, error) {
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	if q.Limit < 0 {
+		q.Limit = defaultStaffPage
+	}
+	if q.Limit > maxStaffPage {
+		q.Limit = maxStaffPage
+	}
+	where := " WHERE deleted_at IS NULL"
+	args := []interface{}{}
+	if q.UserName != "" {
+		args = append(args, q.UserName)
+		where += fmt.Sprintf(" AND LOWER(user_name) = LOWER($%d)", len(args))
+	}
+	if q.ExternalID != "" {
+		args = append(args, q.ExternalID)
+		where += fmt.Sprintf(" AND external_id = $%d", len(args))
+	}
+
+	var total int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM staff_users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	users := []models.StaffUser{}
+	if q.Limit == 0 {
+		return users, total, nil
+	}
+	args = append(args, q.Limit, q.Offset)
+	rows, err := d.db.QueryContext(ctx, fmt.Sprintf("SELECT "+staffColumns+" FROM staff_users"+where+
+		" ORDER BY created_at, id LIMIT $%d OFFSET $%d", len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u models.StaffUser
+		if err := scanStaffUser(rows, &u); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+func (d *StaffDirectory) Get(ctx context.Context, id string) (models.StaffUser, error) {
+	var u models.StaffUser
+	err := scanStaffUser(d.db.QueryRowContext(ctx, "SELECT "+staffColumns+" FROM staff_users WHERE id = $1 AND deleted_at IS NULL", id), &u)
+	if err == sql.ErrNoRows {
+		return models.StaffUser{}, ErrNotFound
+	}
+	return u, err
+}
+
+// Create заводит сотрудника. Имя удалённого сотрудника можно занять снова:
+// запись получает новый ID. Занятое имя — ErrConflict.
+func (d *StaffDirectory) Create(ctx context.Context, user models.StaffUser) (models.StaffUser, error) {
+	user, err := normalizeStaffUser(user)
+	if err != nil {
+		return models.StaffUser{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return models.StaffUser{}, err
+	}
+	var created models.StaffUser
+	err = scanStaffUser(d.db.QueryRowContext(ctx, `
+		INSERT INTO staff_users (id, external_id, user_name, display_name, email, role, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_name) DO UPDATE SET id = EXCLUDED.id, external_id = EXCLUDED.external_id,
+			display_name = EXCLUDED.display_name, email = EXCLUDED.email, role = EXCLUDED.role, active = EXCLUDED.active,
+			created_at = NOW(), updated_at = NOW(), deleted_at = NULL
+		WHERE staff_users.deleted_at IS NOT NULL
+		RETURNING `+staffColumns,
+		hex.EncodeToString(id), user.ExternalID, user.UserName, user.DisplayName, user.Email, user.Role, user.Active), &created)
+	if err == sql.ErrNoRows {
+		return models.StaffUser{}, ErrConflict
+	}
+	if err != nil {
+		return models.StaffUser{}, err
+	}
+	d.remember(created)
+	return created, nil
+}
+
+// Update заменяет учётную запись сотрудника user.ID целиком.
+func (d *StaffDirectory) Update(ctx context.Context, user models.StaffUser) (models.StaffUser, error) {
+	user, err := normalizeStaffUser(user)
+	if err != nil {
+		return models.StaffUser{}, err
+	}
+	var updated models.StaffUser
+	err = scanStaffUser(d.db.QueryRowContext(ctx, `
+		UPDATE staff_users SET external_id = $2, user_name = $3, display_name = $4, email = $5, role = $6, active = $7,
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+staffColumns,
+		user.ID, user.ExternalID, user.UserName, user.DisplayName, user.Email, user.Role, user.Active), &updated)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return models.StaffUser{}, ErrConflict
+	}
+	if err == sql.ErrNoRows {
+		return models.StaffUser{}, ErrNotFound
+	}
+	if err != nil {
+		return models.StaffUser{}, err
+	}
+	d.remember(updated)
+	return updated, nil
+}
+
+// Delete удаляет сотрудника; его токены перестают действовать. Возвращает
+// имя удалённого пользователя.
+func (d *StaffDirectory) Delete(ctx context.Context, id string) (string, error) {
+	var deleted models.StaffUser
+	err := scanStaffUser(d.db.QueryRowContext(ctx, `
+		UPDATE staff_users SET active = FALSE, updated_at = NOW(), deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+staffColumns, id), &deleted)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	d.remember(deleted)
+	return deleted.UserName, nil
+}
+
+// remember обновляет каталог в памяти после изменения записи u и сообщает
+// об изменении. Удалённая запись приходит с Active == false.
+func (d *StaffDirectory) remember(u models.StaffUser) {
+	d.mu.Lock()
+	if previous, ok := d.names[u.ID]; ok && previous != u.UserName {
+		delete(d.accounts, previous)
+	}
+	d.accounts[u.UserName] = auth.Account{Role: u.Role, Active: u.Active}
+	d.names[u.ID] = u.UserName
+	changed := d.changed
+	d.mu.Unlock()
+	for _, fn := range changed {
+		fn()
+	}
+}
+
+// normalizeStaffUser приводит поля к каноническому виду и проверяет их.
+func normalizeStaffUser(user models.StaffUser) (models.StaffUser, error) {
+	user.UserName = strings.TrimSpace(user.UserName)
+	user.ExternalID = strings.TrimSpace(user.ExternalID)
+	user.DisplayName = strings.TrimSpace(user.DisplayName)
+	user.Email = strings.TrimSpace(user.Email)
+	user.Role = strings.ToLower(strings.TrimSpace(user.Role))
+
+	if user.UserName == "" || utf8.RuneCountInString(user.UserName) > 255 {
+		return user, invalid("userName must be 1-255 characters")
+	}
+	if utf8.RuneCountInString(user.ExternalID) > 255 {
+		return user, invalid("externalId must be at most 255 characters")
+	}
+	if utf8.RuneCountInString(user.DisplayName) > 255 {
+		return user, invalid("displayName must be at most 255 characters")
+	}
+	if user.Email != "" {
+		address, err := mail.ParseAddress(user.Email)
+		if err != nil || address.Address != user.Email || len(user.Email) > 255 {
+			return user, invalid("emails must contain email addresses")
+		}
+	}
+	if user.Role != "" && user.Role != auth.RoleAdmin && user.Role != auth.RoleModerator {
+		return user, invalid(fmt.Sprintf("role must be %s or %s", auth.RoleAdmin, auth.RoleModerator))
+	}
+	return user, nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import "context"
+
+// CatalogStats — агрегированные показатели каталога для бизнес-метрик.
+type CatalogStats struct {
+	Products            int
+	CatalogValue        float64
+	PendingPriceChanges int
+	ActivePriceChanges  int
+	Attachments         int
+	AttachmentBytes     int64
+}
+
+func (s *ProductService) Stats() (CatalogStats, error) {
+	var stats CatalogStats
+	err := s.reader(context.Background()).QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM products WHERE deleted_at IS NULL),
+			(SELECT COALESCE(SUM(price), 0) FROM products WHERE deleted_at IS NULL),
+			(SELECT COUNT(*) FROM price_schedules WHERE status = 'pending'),
+			(SELECT COUNT(*) FROM price_schedules WHERE status = 'active'),
+			(SELECT COUNT(*) FROM product_attachments),
+			(SELECT COALESCE(SUM(size), 0) FROM product_attachments)`).Scan(
+		&stats.Products, &stats.CatalogValue, &stats.PendingPriceChanges,
+		&stats.ActivePriceChanges, &stats.Attachments, &stats.AttachmentBytes)
+	return stats, err
+}
+
+// CategoryCounts возвращает количество продуктов в каждой категории.
+func (s *ProductService) CategoryCounts() (map[string]int, error) {
+	rows, err := s.reader(context.Background()).Query("SELECT category, COUNT(*) FROM products, UNNEST(categories) AS category WHERE deleted_at IS NULL GROUP BY category")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			category string
+			count    int
+		)
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, err
+		}
+		counts[category] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"server/internal/events"
+	"server/internal/models"
+	"server/internal/slo"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// statusTTL — сколько живёт собранное состояние: страницу статуса
+	// открывают массово как раз во время сбоя, и каждый запрос не должен
+	// ходить в зависимости.
+	statusTTL = 10 * time.Second
+	// statusCheckTimeout ограничивает проверку одной зависимости.
+	statusCheckTimeout = 2 * time.Second
+)
+
+// DependencyCheck проверяет зависимость сервиса. Отказ критичной
+// зависимости означает отказ сервиса, некритичной — ухудшение.
+type DependencyCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+// StatusService собирает публичное состояние сервиса: время работы,
+// состояние зависимостей и недавние инциденты. Инциденты — тревоги SLO о
+// быстром сгорании бюджета ошибок из журнала событий.
+type StatusService struct {
+	started time.Time
+	checks  []DependencyCheck
+	events  *EventLog
+	// window — за какой срок показываются инциденты.
+	window time.Duration
+
+	mu     sync.Mutex
+	cached models.ServiceStatus
+}
+
+func NewStatusService(checks []DependencyCheck, eventLog *EventLog, window time.Duration) *StatusService {
+	return &StatusService{started: time.Now(), checks: checks, events: eventLog, window: window}
+}
+
+// Status возвращает состояние сервиса, собранное не раньше statusTTL назад.
+func (s *StatusService) Status(ctx context.Context) models.ServiceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cached.CheckedAt.IsZero() && time.Since(s.cached.CheckedAt) < statusTTL {
+		return s.cached
+	}
+
+	now := time.Now()
+	status := models.ServiceStatus{
+		Status:        models.StatusOperational,
+		StartedAt:     s.started.UTC(),
+		UptimeSeconds: int64(now.Sub(s.started).Seconds()),
+		Dependencies:  s.checkDependencies(ctx),
+		CheckedAt:     now.UTC(),
+	}
+	for _, dependency := range status.Dependencies {
+		if dependency.Status == models.StatusOutage {
+			status.Status = models.StatusOutage
+		} else if dependency.Status == models.StatusDegraded && status.Status == models.StatusOperational {
+			status.Status = models.StatusDegraded
+		}
+	}
+	// Журнал событий хранится в основной базе: если она недоступна,
+	// страница показывает состояние без инцидентов.
+	status.Incidents = []models.Incident{}
+	if incidents, err := s.incidents(now); err == nil {
+		status.Incidents = incidents
+	}
+	for _, incident := range status.Incidents {
+		if incident.ResolvedAt == nil && status.Status == models.StatusOperational {
+			status.Status = models.StatusDegraded
+		}
+	}
+	s.cached = status
+	return status
+}
+
+// checkDependencies проверяет зависимости параллельно.
+func (s *StatusService) checkDependencies(ctx context.Context) []models.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, statusCheckTimeout)
+	defer cancel()
+	dependencies := make([]models.DependencyStatus, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dependencies[i] = models.DependencyStatus{Name: check.Name, Status: models.StatusOperational}
+			if err := check.Check(ctx); err != nil {
+				dependencies[i].Status = models.StatusDegraded
+				if check.Critical {
+					dependencies[i].Status = models.StatusOutage
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return dependencies
+}
+
+// incidents сводит тревоги SLO за window в инциденты: тревога открывает
+// инцидент маршрута, её снятие закрывает.
+func (s *StatusService) incidents(now time.Time) ([]models.Incident, error) {
+	alerts, err := s.events.Find(EventQuery{From: now.Add(-s.window), To: now.Add(time.Second), Types: []string{events.SLOBurnRate}})
+	if err != nil {
+		return nil, err
+	}
+	incidents := []models.Incident{}
+	open := make(map[string]int)
+	for _, event := range alerts {
+		var alert slo.Alert
+		if err := json.Unmarshal(event.Data.(json.RawMessage), &alert); err != nil {
+			continue
+		}
+		switch alert.State {
+		case slo.AlertFiring:
+			if _, ok := open[alert.Route]; !ok {
+				open[alert.Route] = len(incidents)
+				incidents = append(incidents, models.Incident{Route: alert.Route, StartedAt: alert.At})
+			}
+		case slo.AlertResolved:
+			if i, ok := open[alert.Route]; ok {
+				resolvedAt := alert.At
+				incidents[i].ResolvedAt = &resolvedAt
+				delete(open, alert.Route)
+			}
+		}
+	}
+	// Трекер SLO хранит историю в памяти и после перезапуска не снимает
+	// тревогу, поднятую до него: такой инцидент считается закончившимся к
+	// запуску.
+	for _, i := range open {
+		if incidents[i].StartedAt.Before(s.started) {
+			resolvedAt := s.started.UTC()
+			incidents[i].ResolvedAt = &resolvedAt
+		}
+	}
+	sort.SliceStable(incidents, func(i, j int) bool { return incidents[i].StartedAt.After(incidents[j].StartedAt) })
+	return incidents, nil
+}
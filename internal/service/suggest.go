@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"strconv"
+	"strings"
+)
+
+// minVocabularyWordLength — слова короче не исправляются: у них слишком мало
+// триграмм, чтобы сходство что-то значило.
+const minVocabularyWordLength = 3
+
+// RefreshVocabulary перестраивает словарь подсказок из поискового индекса и
+// возвращает число слов. Словарь строится из документов продуктов, поэтому
+// синонимы тоже становятся допустимыми исправлениями.
+func (s *ProductService) RefreshVocabulary() (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM search_vocabulary"); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO search_vocabulary (word, documents)
+		SELECT word, ndoc FROM ts_stat('SELECT search_vector FROM products WHERE deleted_at IS NULL')
+		WHERE length(word) >= $1 AND word !~ '^[0-9]+$'`, minVocabularyWordLength)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	words, _ := res.RowsAffected()
+	return int(words), nil
+}
+
+// DidYouMean предлагает исправленный запрос, если по search нашлось меньше
+// SuggestBelow продуктов. Каждое слово запроса заменяется самым похожим по
+// триграммам словом словаря, при равенстве — более частым. Пустая строка —
+// подсказки нет: результатов достаточно или исправлять нечего.
+func (s *ProductService) DidYouMean(ctx context.Context, search string, found int) (string, error) {
+	if found >= s.suggestBelow {
+		return "", nil
+	}
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return "", err
+	}
+	terms := dict.terms(search)
+	if len(terms) == 0 {
+		return "", nil
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, `
+		SELECT COALESCE((
+			SELECT v.word FROM search_vocabulary v
+			WHERE length(t.term) >= $2 AND v.word % t.term
+			ORDER BY similarity(v.word, t.term) DESC, v.documents DESC, v.word LIMIT 1
+		), t.term)
+		FROM unnest($1::text[]) WITH ORDINALITY AS t(term, n) ORDER BY t.n`,
+		pq.Array(terms), minVocabularyWordLength)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	corrected := make([]string, 0, len(terms))
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return "", err
+		}
+		corrected = append(corrected, word)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	suggestion := strings.Join(corrected, " ")
+	if suggestion == strings.Join(terms, " ") {
+		return "", nil
+	}
+	return suggestion, nil
+}
+
+// Suggest считает продукты по запросу и возвращает подсказку DidYouMean.
+// Подсчёт останавливается на SuggestBelow: точное число не нужно.
+func (s *ProductService) Suggest(ctx context.Context, search string) (string, error) {
+	if s.suggestBelow <= 0 || strings.TrimSpace(search) == "" {
+		return "", nil
+	}
+	dict, err := s.searchDictionary(ctx)
+	if err != nil {
+		return "", err
+	}
+	where, args := Filter{Search: search}.where(dict)
+	args = append(args, s.suggestBelow)
+	var found int
+	err = s.reader(ctx).QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM (SELECT 1 FROM products WHERE "+where+" LIMIT $"+strconv.Itoa(len(args))+") matched",
+		args...).Scan(&found)
+	if err != nil {
+		return "", err
+	}
+	return s.DidYouMean(ctx, search, found)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"server/internal/models"
+	"strings"
+)
+
+func NormalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// LocaleCandidates раскрывает локаль в список кандидатов: "en-us" -> ["en-us", "en"].
+func LocaleCandidates(locale string) []string {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		return nil
+	}
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	return candidates
+}
+
+// localize возвращает копию продуктов с переведёнными полями для первой
+// доступной локали из списка; при отсутствии перевода остаются значения по умолчанию.
+func (s *ProductService) localize(ctx context.Context, products []models.Product, locales []string) ([]models.Product, error) {
+	var wanted []string
+	for _, locale := range locales {
+		if locale == s.defaultLocale {
+			break
+		}
+		wanted = append(wanted, locale)
+	}
+	if len(products) == 0 || len(wanted) == 0 {
+		return products, nil
+	}
+
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = int64(product.ID)
+	}
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT product_id, locale, name, COALESCE(description, '') FROM product_translations WHERE product_id = ANY($1) AND locale = ANY($2)",
+		pq.Array(ids), pq.Array(wanted))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := make(map[int]map[string]models.ProductTranslation)
+	for rows.Next() {
+		var t models.ProductTranslation
+		if err := rows.Scan(&t.ProductID, &t.Locale, &t.Name, &t.Description); err != nil {
+			return nil, err
+		}
+		if translations[t.ProductID] == nil {
+			translations[t.ProductID] = make(map[string]models.ProductTranslation)
+		}
+		translations[t.ProductID][t.Locale] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	localized := make([]models.Product, len(products))
+	copy(localized, products)
+	for i := range localized {
+		for _, locale := range wanted {
+			if t, ok := translations[localized[i].ID][locale]; ok {
+				localized[i].Name = t.Name
+				if t.Description != "" {
+					localized[i].Description = t.Description
+				}
+				localized[i].Locale = locale
+				break
+			}
+		}
+	}
+	return localized, nil
+}
+
+func (s *ProductService) Translations(productID int) ([]models.ProductTranslation, error) {
+	rows, err := s.db.Query("SELECT product_id, locale, name, COALESCE(description, '') FROM product_translations WHERE product_id = $1 ORDER BY locale", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []models.ProductTranslation{}
+	for rows.Next() {
+		var t models.ProductTranslation
+		if err := rows.Scan(&t.ProductID, &t.Locale, &t.Name, &t.Description); err != nil {
+			return nil, err
+		}
+		translations = append(translations, t)
+	}
+	return translations, rows.Err()
+}
+
+// PutTranslation создаёт или заменяет перевод продукта для локали.
+func (s *ProductService) PutTranslation(t models.ProductTranslation) (models.ProductTranslation, error) {
+	t.Locale = NormalizeLocale(t.Locale)
+	if t.Locale == "" || strings.TrimSpace(t.Name) == "" {
+		return t, invalid("locale and name are required")
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO product_translations (product_id, locale, name, description) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, locale) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description`,
+		t.ProductID, t.Locale, t.Name, t.Description)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return t, ErrNotFound
+	}
+	return t, err
+}
+
+func (s *ProductService) DeleteTranslation(productID int, locale string) error {
+	_, err := s.db.Exec("DELETE FROM product_translations WHERE product_id = $1 AND locale = $2", productID, NormalizeLocale(locale))
+	return err
+}
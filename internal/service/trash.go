@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"server/internal/events"
+	"server/internal/models"
+	"time"
+)
+
+// Trash возвращает продукты, удалённые в пределах окна восстановления.
+func (s *ProductService) Trash() ([]models.TrashedProduct, error) {
+	rows, err := s.db.Query("SELECT " + productColumns + ", deleted_at FROM products WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trashed := []models.TrashedProduct{}
+	for rows.Next() {
+		var t models.TrashedProduct
+		if err := scanProduct(rows, &t.Product, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		t.PurgeAt = t.DeletedAt.Add(s.trashRetention)
+		trashed = append(trashed, t)
+	}
+	return trashed, rows.Err()
+}
+
+// Restore возвращает продукт из корзины, если окно восстановления ещё не истекло.
+func (s *ProductService) Restore(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE products SET deleted_at = NULL WHERE id = $1 AND deleted_at > $2",
+		id, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	s.cache.invalidate()
+	s.events.Publish(ctx, events.ProductRestored, events.ProductRef{ID: id})
+	return nil
+}
+
+// PurgeTrash окончательно удаляет продукты с истёкшим окном восстановления и
+// возвращает ключи файлов их вложений, на которые больше никто не ссылается:
+// их нужно удалить из хранилища.
+func (s *ProductService) PurgeTrash() (purged int64, storageKeys []string, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	cutoff := time.Now().Add(-s.trashRetention)
+	rows, err := tx.Query(`
+		DELETE FROM product_attachments
+		WHERE product_id IN (SELECT id FROM products WHERE deleted_at <= $1)
+		RETURNING storage_key`, cutoff)
+	if err != nil {
+		return 0, nil, err
+	}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		storageKeys = append(storageKeys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	if storageKeys, err = releaseBlobs(tx, storageKeys); err != nil {
+		return 0, nil, err
+	}
+
+	res, err := tx.Exec("DELETE FROM products WHERE deleted_at <= $1", cutoff)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	purged, _ = res.RowsAffected()
+	return purged, storageKeys, nil
+}
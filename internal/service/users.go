@@ -0,0 +1,627 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"net/mail"
+	"regexp"
+	"server/internal/auth"
+	"server/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// minPasswordLength — наименьшая длина пароля пользователя API.
+	minPasswordLength = 8
+	// maxPasswordLength — больше bcrypt не учитывает.
+	maxPasswordLength = auth.MaxPasswordLength
+	// revocationLookupTimeout ограничивает проверку токена по списку
+	// отозванных.
+	revocationLookupTimeout = 2 * time.Second
+)
+
+// dummyPasswordHash сравнивается с паролем, когда пользователя нет: вход
+// под несуществующим именем длится столько же, сколько с неверным паролем,
+// и по времени ответа нельзя узнать, какие имена заняты.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("")
+	return hash
+})
+
+// UserService хранит пользователей API и выдаёт им токены. Access-токен —
+// короткоживущий JWT; refresh-токен — случайная строка, которая хранится
+// хешем и при обмене на новую пару сразу перестаёт действовать. Все
+// refresh-токены, полученные обменами от одного входа, — одна сессия:
+// повторное предъявление уже обменянного токена означает, что его
+// украли, и сессия отзывается целиком вместе с её access-токенами.
+// UserService — ещё и auth.RevocationList отозванных access-токенов.
+type UserService struct {
+	db         *sql.DB
+	signer     *auth.JWTSigner
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+type UserOptions struct {
+	// AccessTTL — срок действия access-токена, RefreshTTL — refresh-токена.
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+func NewUserService(db *sql.DB, signer *auth.JWTSigner, opts UserOptions) *UserService {
+	return &UserService{db: db, signer: signer, accessTTL: opts.AccessTTL, refreshTTL: opts.RefreshTTL}
+}
+
+// usernamePattern — имя при регистрации. Оно же имя в чате, куда личные
+// сообщения адресуются как "@name ", поэтому пробелов в нём нет.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,32}$`)
+
+const userColumns = "id, username, COALESCE(email, ''), display_name, role, created_at"
+
+func scanUser(row interface{ Scan(...interface{}) error }, u *models.User) error {
+	return row.Scan(&u.ID, &u.Username, &u.Email, &u.DisplayName, &u.Role, &u.CreatedAt)
+}
+
+func (s *UserService) List(ctx context.Context) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := scanUser(rows, &u); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// Create заводит пользователя; без роли он получает auth.RoleViewer.
+// Занятое имя — ErrConflict.
+func (s *UserService) Create(ctx context.Context, username, password, role string) (models.User, error) {
+	username = strings.TrimSpace(username)
+	role = strings.ToLower(strings.TrimSpace(role))
+	if username == "" || utf8.RuneCountInString(username) > 255 {
+		return models.User{}, invalid("username must be 1-255 characters")
+	}
+	if err := validatePassword(password); err != nil {
+		return models.User{}, err
+	}
+	if role == "" {
+		role = auth.RoleViewer
+	}
+	if !auth.ValidRole(role) {
+		return models.User{}, invalid("role must be one of " + strings.Join(auth.Roles(), ", "))
+	}
+	return s.insert(ctx, models.User{Username: username, Role: role}, password)
+}
+
+// Register заводит пользователя с ролью auth.RoleViewer по его собственной
+// заявке. Имя — 3-32 латинские буквы, цифры, точки, дефисы и
+// подчёркивания; занятые имя или email — ErrConflict.
+func (s *UserService) Register(ctx context.Context, username, email, displayName, password string) (models.User, error) {
+	username = strings.TrimSpace(username)
+	if !usernamePattern.MatchString(username) {
+		return models.User{}, invalid("username must be 3-32 latin letters, digits, '.', '-' or '_'")
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return models.User{}, err
+	}
+	if email == "" {
+		return models.User{}, invalid("email is required")
+	}
+	displayName, err = normalizeDisplayName(displayName)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := validatePassword(password); err != nil {
+		return models.User{}, err
+	}
+	return s.insert(ctx, models.User{Username: username, Email: email, DisplayName: displayName, Role: auth.RoleViewer}, password)
+}
+
+func (s *UserService) insert(ctx context.Context, user models.User, password string) (models.User, error) {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+	var created models.User
+	err = scanUser(s.db.QueryRowContext(ctx, `
+		INSERT INTO users (username, email, display_name, password_hash, role) VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING `+userColumns,
+		user.Username, user.Email, user.DisplayName, hash, user.Role), &created)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return models.User{}, ErrConflict
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	return created, nil
+}
+
+// Get возвращает пользователя по ID.
+func (s *UserService) Get(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id), &user)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	return user, err
+}
+
+// ProfileUpdate — изменения профиля пользователем; nil — поле не меняется.
+// Смена email или пароля требует текущего пароля.
+type ProfileUpdate struct {
+	Email           *string
+	DisplayName     *string
+	Password        *string
+	CurrentPassword string
+}
+
+// UpdateProfile меняет профиль пользователя id. Неверный текущий пароль —
+// auth.ErrForbidden, занятый email — ErrConflict. После смены пароля
+// все сессии пользователя отзываются, и войти придётся заново.
+func (s *UserService) UpdateProfile(ctx context.Context, id int, update ProfileUpdate) (models.User, error) {
+	var err error
+	if update.Email != nil {
+		email, err := normalizeEmail(*update.Email)
+		if err != nil {
+			return models.User{}, err
+		}
+		update.Email = &email
+	}
+	if update.DisplayName != nil {
+		displayName, err := normalizeDisplayName(*update.DisplayName)
+		if err != nil {
+			return models.User{}, err
+		}
+		update.DisplayName = &displayName
+	}
+	var newHash string
+	if update.Password != nil {
+		if err := validatePassword(*update.Password); err != nil {
+			return models.User{}, err
+		}
+		if newHash, err = auth.HashPassword(*update.Password); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	var hash string
+	err = tx.QueryRowContext(ctx, "SELECT password_hash FROM users WHERE id = $1 FOR UPDATE", id).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if (update.Email != nil || update.Password != nil) && !auth.CheckPassword(hash, update.CurrentPassword) {
+		return models.User{}, auth.ErrForbidden
+	}
+	if newHash != "" {
+		hash = newHash
+		if err := revokeSessions(ctx, tx, "user_id = $1", id); err != nil {
+			return models.User{}, err
+		}
+	}
+	var user models.User
+	err = scanUser(tx.QueryRowContext(ctx, `
+		UPDATE users SET
+			email = CASE WHEN $2 THEN NULLIF($3, '') ELSE email END,
+			email_verified = email_verified AND NOT $2,
+			display_name = COALESCE($4, display_name),
+			password_hash = $5
+		WHERE id = $1
+		RETURNING `+userColumns,
+		id, update.Email != nil, stringValue(update.Email), update.DisplayName, hash), &user)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return models.User{}, ErrConflict
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, tx.Commit()
+}
+
+// Delete удаляет пользователя и отзывает все его сессии.
+func (s *UserService) Delete(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeSessions(ctx, tx, "user_id = $1", id); err != nil {
+		return err
+	}
+	if err := affectOne(tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeSessions отзывает все сессии пользователя id: его refresh-токены и
+// выданные с ними access-токены перестают действовать сразу.
+func (s *UserService) RevokeSessions(ctx context.Context, id int) error {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return revokeSessions(ctx, s.db, "user_id = $1", id)
+}
+
+// Login проверяет имя или email и пароль и выдаёт пару токенов. Неверные
+// имя или пароль — auth.ErrUnauthenticated.
+func (s *UserService) Login(ctx context.Context, username, password string) (models.Tokens, error) {
+	if len(password) > maxPasswordLength {
+		return models.Tokens{}, auth.ErrUnauthenticated
+	}
+	var user models.User
+	var hash string
+	// Имя важнее email: имя пользователя, заведённого администратором,
+	// может совпасть с чужим email.
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+`, password_hash FROM users
+		WHERE username = $1 OR LOWER(email) = LOWER($1)
+		ORDER BY username = $1 DESC LIMIT 1`,
+		strings.TrimSpace(username)).Scan(&user.ID, &user.Username, &user.Email, &user.DisplayName, &user.Role, &user.CreatedAt, &hash)
+	if err == sql.ErrNoRows {
+		auth.CheckPassword(dummyPasswordHash(), password)
+		return models.Tokens{}, auth.ErrUnauthenticated
+	}
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if !auth.CheckPassword(hash, password) {
+		return models.Tokens{}, auth.ErrUnauthenticated
+	}
+	return s.issue(ctx, s.db, user, "")
+}
+
+// LoginExternal выдаёт пару токенов пользователю, который вошёл через
+// провайдера OAuth2. Учётная запись провайдера, которая уже привязана,
+// определяет пользователя; иначе она привязывается к пользователю с тем же
+// email, если адрес подтвердил провайдер и он подтверждён у пользователя
+// (тот заведён входом через провайдера), а если такого нет и signup
+// разрешён — к новому пользователю с ролью auth.RoleViewer и без пароля.
+// Без привязки и без signup — auth.ErrUnauthenticated; если email занят
+// пользователем с неподтверждённым адресом — ErrConflict.
+func (s *UserService) LoginExternal(ctx context.Context, identity ExternalIdentity, signup bool) (models.Tokens, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	err = scanUser(tx.QueryRowContext(ctx, `
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.display_name, u.role, u.created_at
+		FROM user_identities i JOIN users u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2`, identity.Provider, identity.Subject), &user)
+	if err == sql.ErrNoRows {
+		user, err = s.linkIdentity(ctx, tx, identity, signup)
+	}
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	tokens, err := s.issue(ctx, tx, user, "")
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return tokens, tx.Commit()
+}
+
+// linkIdentity привязывает новую учётную запись провайдера, как описано у
+// LoginExternal. Пользователь с тем же email подходит, только если и его
+// адрес подтверждён: email, указанный при регистрации с паролем, никто не
+// проверял, и иначе любой, кто завёл у провайдера учётную запись на чужой
+// адрес, входил бы под пользователем, который этот адрес указал.
+func (s *UserService) linkIdentity(ctx context.Context, tx *sql.Tx, identity ExternalIdentity, signup bool) (models.User, error) {
+	var user models.User
+	err := sql.ErrNoRows
+	if identity.Email != "" && identity.EmailVerified {
+		err = scanUser(tx.QueryRowContext(ctx,
+			"SELECT "+userColumns+" FROM users WHERE LOWER(email) = LOWER($1) AND email_verified", identity.Email), &user)
+	}
+	if err == sql.ErrNoRows {
+		if !signup {
+			return models.User{}, auth.ErrUnauthenticated
+		}
+		user, err = s.insertExternal(ctx, tx, identity)
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO user_identities (provider, subject, user_id, email) VALUES ($1, $2, $3, NULLIF($4, ''))",
+		identity.Provider, identity.Subject, user.ID, identity.Email); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// insertExternal заводит пользователя без пароля. Имя берётся из
+// предложенного провайдером и при совпадении с занятым получает номер.
+func (s *UserService) insertExternal(ctx context.Context, tx *sql.Tx, identity ExternalIdentity) (models.User, error) {
+	email := ""
+	if identity.EmailVerified {
+		email = identity.Email
+	}
+	displayName, err := normalizeDisplayName(identity.Name)
+	if err != nil {
+		displayName = ""
+	}
+	base := externalUsername(identity.Username)
+	for n := 1; n <= 100; n++ {
+		username := base
+		if n > 1 {
+			username = base + "-" + strconv.Itoa(n)
+		}
+		var user models.User
+		err := scanUser(tx.QueryRowContext(ctx, `
+			INSERT INTO users (username, email, email_verified, display_name, password_hash, role)
+			VALUES ($1, NULLIF($2, ''), $2 <> '', $3, '', $4)
+			ON CONFLICT (username) DO NOTHING
+			RETURNING `+userColumns,
+			username, email, displayName, auth.RoleViewer), &user)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return models.User{}, ErrConflict
+		}
+		return user, err
+	}
+	return models.User{}, ErrConflict
+}
+
+// externalUsername приводит имя у провайдера к usernamePattern: лишние
+// символы отбрасываются, короткое имя дополняется, длинное обрезается с
+// запасом под номер.
+func externalUsername(suggested string) string {
+	var b strings.Builder
+	for _, r := range suggested {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if len(name) > 28 {
+		name = name[:28]
+	}
+	if len(name) < 3 {
+		name = "user" + name
+	}
+	return name
+}
+
+// Identities возвращает учётные записи провайдеров, привязанные к
+// пользователю id.
+func (s *UserService) Identities(ctx context.Context, id int) ([]models.UserIdentity, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT provider, COALESCE(email, ''), created_at FROM user_identities
+		WHERE user_id = $1 ORDER BY provider`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		var identity models.UserIdentity
+		if err := rows.Scan(&identity.Provider, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// Refresh обменивает refresh-токен на новую пару той же сессии. Токен
+// действует один раз: повторный обмен — auth.ErrUnauthenticated, а сессия,
+// к которой он относится, отзывается.
+func (s *UserService) Refresh(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	var family string
+	var used bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.display_name, u.role, u.created_at, t.family, t.used_at IS NOT NULL
+		FROM refresh_tokens t JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.expires_at > NOW()
+		FOR UPDATE OF t`, hashRefreshToken(refreshToken)).Scan(
+		&user.ID, &user.Username, &user.Email, &user.DisplayName, &user.Role, &user.CreatedAt, &family, &used)
+	if err == sql.ErrNoRows {
+		return models.Tokens{}, auth.ErrUnauthenticated
+	}
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if used {
+		if err := revokeSessions(ctx, tx, "family = $1", family); err != nil {
+			return models.Tokens{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return models.Tokens{}, err
+		}
+		log.Printf("Повторно предъявлен обменянный refresh-токен пользователя %s: сессия отозвана", user.Username)
+		return models.Tokens{}, auth.ErrUnauthenticated
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1", hashRefreshToken(refreshToken)); err != nil {
+		return models.Tokens{}, err
+	}
+	tokens, err := s.issue(ctx, tx, user, family)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return tokens, tx.Commit()
+}
+
+// Logout завершает сессию refresh-токена и отзывает access-токен
+// accessTokenID, с которым пришёл запрос; пустой accessTokenID — запрос без
+// access-токена. Неизвестный refresh-токен не ошибка.
+func (s *UserService) Logout(ctx context.Context, refreshToken, accessTokenID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeSessions(ctx, tx,
+		"family = (SELECT family FROM refresh_tokens WHERE token_hash = $1)", hashRefreshToken(refreshToken)); err != nil {
+		return err
+	}
+	if accessTokenID != "" {
+		// Срок токена здесь неизвестен, но дольше accessTTL он не живёт.
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+			ON CONFLICT (jti) DO NOTHING`,
+			accessTokenID, time.Now().Add(s.accessTTL+time.Minute)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Revoked сообщает, отозван ли access-токен с claim jti tokenID.
+func (s *UserService) Revoked(tokenID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), revocationLookupTimeout)
+	defer cancel()
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())", tokenID).Scan(&revoked)
+	return revoked, err
+}
+
+type execer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+// issue выдаёт пользователю access-токен и сохраняет новый refresh-токен
+// сессии family; пустой family начинает новую сессию. Заодно удаляются
+// его истёкшие refresh-токены.
+func (s *UserService) issue(ctx context.Context, db execer, user models.User, family string) (models.Tokens, error) {
+	refresh, err := randomToken()
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	tokenID, err := randomToken()
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if family == "" {
+		family = hashRefreshToken(refresh)
+	}
+	access, expires := s.signer.Sign(auth.Principal{
+		Subject: strconv.Itoa(user.ID), Name: user.Username, Role: user.Role, TokenID: tokenID,
+	}, s.accessTTL)
+	if _, err := db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at <= NOW()", user.ID); err != nil {
+		return models.Tokens{}, err
+	}
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (token_hash, user_id, expires_at, family, access_jti, access_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		hashRefreshToken(refresh), user.ID, time.Now().Add(s.refreshTTL), family, tokenID, expires); err != nil {
+		return models.Tokens{}, err
+	}
+	return models.Tokens{AccessToken: access, TokenType: "Bearer", ExpiresAt: expires, RefreshToken: refresh}, nil
+}
+
+// revokeSessions удаляет refresh-токены, выбранные условием where с
+// параметром $1 = arg, и вносит в список отозванных ещё действующие
+// access-токены, выданные вместе с ними. Заодно из списка удаляются
+// истёкшие токены.
+func revokeSessions(ctx context.Context, db execer, where string, arg interface{}) error {
+	// Минута запаса покрывает расхождение часов, которое допускает проверка JWT.
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		SELECT access_jti, access_expires_at + INTERVAL '1 minute' FROM refresh_tokens
+		WHERE access_jti IS NOT NULL AND access_expires_at + INTERVAL '1 minute' > NOW() AND `+where+`
+		ON CONFLICT (jti) DO NOTHING`, arg); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE "+where, arg); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= NOW()")
+	return err
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return invalid(fmt.Sprintf("password must be %d-%d bytes", minPasswordLength, maxPasswordLength))
+	}
+	return nil
+}
+
+// normalizeEmail проверяет адрес; пустая строка — адреса нет.
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > 255 {
+		return "", invalid("email must be an email address")
+	}
+	return email, nil
+}
+
+func normalizeDisplayName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 255 {
+		return "", invalid("display_name must be at most 255 characters")
+	}
+	return name, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WarmUp готовит сервис к первым запросам после запуска: открывает
+// connections соединений в пулах основной базы и реплики для чтения,
+// загружает словарь поиска и кладёт в кэш первую страницу списка продуктов,
+// которую без параметров запрашивают и REST, и GraphQL.
+//
+// Запросы сервиса не подготавливаются заранее (lib/pq готовит их при каждом
+// вызове), поэтому прогрев сводится к соединениям и однократному выполнению
+// основных запросов, после которого их планы и данные уже в кэшах Postgres.
+func (s *ProductService) WarmUp(ctx context.Context, connections int) error {
+	if err := warmPool(ctx, s.db, connections); err != nil {
+		return fmt.Errorf("primary pool: %w", err)
+	}
+	if reads := s.reader(ctx); reads != s.db {
+		if err := warmPool(ctx, reads, connections); err != nil {
+			return fmt.Errorf("read pool: %w", err)
+		}
+	}
+	if _, err := s.searchDictionary(ctx); err != nil {
+		return fmt.Errorf("search dictionary: %w", err)
+	}
+	// Большой каталог без limit не отдаётся; тогда прогревается первая
+	// страница наибольшего размера.
+	params := ListParams{}
+	if count, err := s.catalogSize(ctx); err != nil {
+		return fmt.Errorf("catalog size: %w", err)
+	} else if s.unboundedMax > 0 && count > s.unboundedMax {
+		params.Limit = s.maxListLimit
+	}
+	if _, err := s.List(ctx, params); err != nil {
+		return fmt.Errorf("product list: %w", err)
+	}
+	return nil
+}
+
+// warmPool одновременно занимает n соединений пула, чтобы пул их открыл, и
+// возвращает их простаивающими.
+func warmPool(ctx context.Context, db *sql.DB, n int) error {
+	// Больше предела пула занять нельзя: Conn ждал бы до истечения ctx.
+	if limit := db.Stats().MaxOpenConnections; limit > 0 && n > limit {
+		n = limit
+	}
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+		if err := conn.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"server/internal/events"
+	"server/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookService хранит подписки на события и доставляет им подписанные запросы.
+type WebhookService struct {
+	db     *sql.DB
+	client *http.Client
+	queue  chan events.Event
+	dlq    *DeadLetterQueue
+}
+
+// NewWebhookService создаёт сервис; неудачные доставки попадают в dlq.
+func NewWebhookService(db *sql.DB, timeout time.Duration, dlq *DeadLetterQueue) *WebhookService {
+	// Адрес вебхука задаёт клиент API, поэтому во внутреннюю сеть доставки
+	// не идут, а перенаправления не выполняются: получатель отвечает сам.
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: publicTransport(),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &WebhookService{
+		db:     db,
+		client: client,
+		queue:  make(chan events.Event, 256),
+		dlq:    dlq,
+	}
+}
+
+// webhookDeadLetter — полезная нагрузка записи DLQ для неудачной доставки.
+type webhookDeadLetter struct {
+	WebhookID int          `json:"webhook_id"`
+	Event     events.Event `json:"event"`
+}
+
+const webhookColumns = "id, COALESCE(key, ''), url, events, active, created_at"
+
+var webhookKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func scanWebhook(row interface{ Scan(...interface{}) error }, w *models.Webhook) error {
+	return row.Scan(&w.ID, &w.Key, &w.URL, pq.Array(&w.Events), &w.Active, &w.CreatedAt)
+}
+
+func validateWebhook(w models.Webhook) error {
+	if w.Key != "" && !webhookKeyPattern.MatchString(w.Key) {
+		return invalid("key must be 1-64 lowercase letters, digits, '_' or '-'")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid("url must be an absolute http(s) URL")
+	}
+	// Имена проверит при доставке publicTransport; явно внутренний адрес
+	// отвергается сразу.
+	if ip := net.ParseIP(u.Hostname()); (ip != nil && !isPublicIP(ip)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return invalid("url must point to a public address")
+	}
+	if len(w.Events) == 0 {
+		return invalid("at least one event type is required")
+	}
+	for _, eventType := range w.Events {
+		if _, ok := events.Lookup(eventType); !ok {
+			return invalid(fmt.Sprintf("unknown event type %q", eventType))
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) List() ([]models.Webhook, error) {
+	rows, err := s.db.Query("SELECT " + webhookColumns + " FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var w models.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// Create регистрирует вебхук и генерирует секрет подписи, который
+// возвращается получателю только в ответе на создание. Занятый ключ —
+// ErrConflict.
+func (s *WebhookService) Create(w models.Webhook) (models.Webhook, error) {
+	if err := validateWebhook(w); err != nil {
+		return models.Webhook{}, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	var created models.Webhook
+	err = scanWebhook(s.db.QueryRow(
+		"INSERT INTO webhooks (key, url, secret, events) VALUES (NULLIF($1, ''), $2, $3, $4) RETURNING "+webhookColumns,
+		w.Key, w.URL, secret, pq.Array(w.Events)), &created)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return models.Webhook{}, ErrConflict
+	}
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	created.Secret = secret
+	return created, nil
+}
+
+// Put создаёт или целиком заменяет вебхук с ключом key — для управления
+// вебхуками из Terraform и других декларативных инструментов. Повтор
+// того же запроса ничего не меняет. Секрет w.Secret, если задан, заменяет
+// прежний; без него у нового вебхука секрет генерируется и возвращается,
+// а у существующего остаётся прежним. Второй результат — был ли вебхук
+// создан.
+func (s *WebhookService) Put(key string, w models.Webhook) (models.Webhook, bool, error) {
+	w.Key = key
+	if !webhookKeyPattern.MatchString(key) {
+		return models.Webhook{}, false, invalid("key must be 1-64 lowercase letters, digits, '_' or '-'")
+	}
+	if err := validateWebhook(w); err != nil {
+		return models.Webhook{}, false, err
+	}
+	secret := w.Secret
+	if secret != "" && (len(secret) < minWebhookSecretLength || len(secret) > maxWebhookSecretLength) {
+		return models.Webhook{}, false, invalid(fmt.Sprintf("secret must be %d-%d characters",
+			minWebhookSecretLength, maxWebhookSecretLength))
+	}
+	generated := secret == ""
+	if generated {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return models.Webhook{}, false, err
+		}
+	}
+
+	var put models.Webhook
+	var created bool
+	err := s.db.QueryRow(`
+		INSERT INTO webhooks (key, url, secret, events, active) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO UPDATE SET url = EXCLUDED.url, events = EXCLUDED.events, active = EXCLUDED.active,
+			secret = CASE WHEN $6 THEN webhooks.secret ELSE EXCLUDED.secret END
+		RETURNING `+webhookColumns+`, xmax = 0`,
+		key, w.URL, secret, pq.Array(w.Events), w.Active, generated).Scan(
+		&put.ID, &put.Key, &put.URL, pq.Array(&put.Events), &put.Active, &put.CreatedAt, &created)
+	if err != nil {
+		return models.Webhook{}, false, err
+	}
+	if created && generated {
+		put.Secret = secret
+	}
+	return put, created, nil
+}
+
+// GetByKey возвращает вебхук с внешним ключом key.
+func (s *WebhookService) GetByKey(key string) (models.Webhook, error) {
+	var w models.Webhook
+	err := scanWebhook(s.db.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE key = $1", key), &w)
+	if err == sql.ErrNoRows {
+		return models.Webhook{}, ErrNotFound
+	}
+	return w, err
+}
+
+func (s *WebhookService) DeleteByKey(key string) error {
+	return affectOne(s.db.Exec("DELETE FROM webhooks WHERE key = $1", key))
+}
+
+const (
+	// minWebhookSecretLength и maxWebhookSecretLength ограничивают секрет,
+	// заданный клиентом; сгенерированный секрет — 64 hex-символа.
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 64
+)
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *WebhookService) Delete(id int) error {
+	res, err := s.db.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Sign вычисляет подпись доставки: HMAC-SHA256 от "<timestamp>.<body>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver отправляет событие получателю и возвращает результат попытки.
+func (s *WebhookService) deliver(webhookID int, target, secret string, event events.Event) (result models.WebhookDelivery) {
+	result = models.WebhookDelivery{WebhookID: webhookID, EventID: event.ID, EventType: event.Type}
+	started := time.Now()
+	refused := false
+	defer func() {
+		if !refused {
+			result.DurationMS = time.Since(started).Milliseconds()
+		}
+	}()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event.Type)
+	req.Header.Set("X-Webhook-Delivery", event.ID)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(secret, timestamp, body))
+	if event.RequestID != "" {
+		req.Header.Set("X-Request-ID", event.RequestID)
+	}
+
+	resp, err := s.client.Do(req)
+	if errors.Is(err, errPrivateAddress) {
+		// Ни ошибка соединения, ни время ответа не должны рассказать о
+		// внутренней сети.
+		refused = true
+		result.Error = "webhook URL resolves to a non-public address"
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Success {
+		result.Error = resp.Status
+	}
+	return result
+}
+
+// SendTest синхронно доставляет вебхуку пример события указанного типа.
+func (s *WebhookService) SendTest(id int, eventType string) (models.WebhookDelivery, error) {
+	event, ok := events.Sample(eventType)
+	if !ok {
+		return models.WebhookDelivery{}, invalid(fmt.Sprintf("unknown event type %q", eventType))
+	}
+
+	target, secret, err := s.target(id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return s.deliver(id, target, secret, event), nil
+}
+
+// target возвращает URL и секрет вебхука.
+func (s *WebhookService) target(id int) (string, string, error) {
+	var target, secret string
+	err := s.db.QueryRow("SELECT url, secret FROM webhooks WHERE id = $1", id).Scan(&target, &secret)
+	if err == sql.ErrNoRows {
+		return "", "", ErrNotFound
+	}
+	return target, secret, err
+}
+
+// RetryDeadLetter повторяет доставку из записи DLQ; используется как обработчик
+// повтора для источника "webhook".
+func (s *WebhookService) RetryDeadLetter(entry models.DeadLetter) error {
+	var payload webhookDeadLetter
+	if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+		return err
+	}
+	target, secret, err := s.target(payload.WebhookID)
+	if err != nil {
+		return err
+	}
+	if result := s.deliver(payload.WebhookID, target, secret, payload.Event); !result.Success {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// Replay последовательно доставляет события указанному вебхуку, сохраняя их
+// исходные ID, чтобы получатель мог отбросить уже обработанные.
+func (s *WebhookService) Replay(id int, replayed []events.Event) (models.ReplayResult, error) {
+	target, secret, err := s.target(id)
+	if err != nil {
+		return models.ReplayResult{}, err
+	}
+
+	result := models.ReplayResult{Total: len(replayed)}
+	for _, event := range replayed {
+		delivery := s.deliver(id, target, secret, event)
+		if delivery.Success {
+			result.Delivered++
+			continue
+		}
+		result.Failed++
+		result.Failures = append(result.Failures, delivery)
+	}
+	return result, nil
+}
+
+// Enqueue ставит событие в очередь доставки; подписывается на шину событий.
+// При переполнении очереди событие отбрасывается, чтобы не блокировать запись.
+func (s *WebhookService) Enqueue(event events.Event) {
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("Очередь вебхуков переполнена, событие %s (%s) отброшено", event.ID, event.Type)
+	}
+}
+
+// Run доставляет события из очереди всем активным подписчикам соответствующего типа.
+func (s *WebhookService) Run() {
+	for event := range s.queue {
+		rows, err := s.db.Query("SELECT id, url, secret FROM webhooks WHERE active AND $1 = ANY(events)", event.Type)
+		if err != nil {
+			log.Printf("Ошибка выборки вебхуков: %v", err)
+			continue
+		}
+		type target struct {
+			id          int
+			url, secret string
+		}
+		var targets []target
+		for rows.Next() {
+			var t target
+			if err := rows.Scan(&t.id, &t.url, &t.secret); err != nil {
+				log.Printf("Ошибка выборки вебхуков: %v", err)
+				continue
+			}
+			targets = append(targets, t)
+		}
+		rows.Close()
+
+		for _, t := range targets {
+			if result := s.deliver(t.id, t.url, t.secret, event); !result.Success {
+				log.Printf("Не удалось доставить событие %s вебхуку %d: %s", event.ID, t.id, result.Error)
+				s.dlq.Add(DeadLetterWebhook, event.Type, webhookDeadLetter{WebhookID: t.id, Event: event}, errors.New(result.Error))
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"server/internal/events"
+	"server/internal/models"
+	"testing"
+	"time"
+)
+
+func TestValidateWebhookRejectsInternalAddresses(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{url: "https://hooks.example.com/events", ok: true},
+		{url: "http://203.0.113.7:8080/hook", ok: true},
+		{url: "http://127.0.0.1/hook"},
+		{url: "http://localhost:9000/hook"},
+		{url: "http://169.254.169.254/latest/meta-data/"},
+		{url: "http://10.0.0.5/hook"},
+		{url: "http://192.168.1.1/hook"},
+		{url: "http://[::1]/hook"},
+		{url: "http://0.0.0.0/hook"},
+		{url: "ftp://example.com/hook"},
+	}
+	for _, tt := range tests {
+		err := validateWebhook(models.Webhook{URL: tt.url, Events: []string{events.ProductCreated}})
+		if (err == nil) != tt.ok {
+			t.Errorf("validateWebhook(%q) error = %v, want ok = %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestDeliverRefusesNonPublicTargets(t *testing.T) {
+	called := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer target.Close()
+
+	s := NewWebhookService(nil, time.Second, nil)
+	event, _ := events.Sample(events.ProductCreated)
+	result := s.deliver(1, target.URL, "secret", event)
+	if called {
+		t.Fatal("delivery reached a loopback address")
+	}
+	if result.Success || result.StatusCode != 0 || result.DurationMS != 0 {
+		t.Fatalf("deliver() = %+v, want a refusal without status or timing", result)
+	}
+}
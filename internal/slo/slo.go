@@ -0,0 +1,253 @@
+package slo
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// burnLongWindow и burnShortWindow — окна быстрого сгорания бюджета
+	// ошибок: тревога поднимается, только если бюджет быстро сгорает в обоих,
+	// чтобы короткий всплеск не будил дежурного, а закончившийся инцидент
+	// быстро снимал тревогу.
+	burnLongWindow  = time.Hour
+	burnShortWindow = 5 * time.Minute
+	// minBurnRequests — сколько запросов нужно в коротком окне, чтобы судить
+	// о сгорании: при единичных запросах одна ошибка даёт огромную скорость.
+	minBurnRequests = 20
+)
+
+// Alert states.
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Objective — цель уровня обслуживания маршрута: доля Target запросов
+// должна завершаться без ошибки сервера (5xx) не дольше Latency.
+type Objective struct {
+	// Route — метод и шаблон пути Fiber, например "GET /api/products/:id".
+	Route   string
+	Latency time.Duration
+	// Target — доля хороших запросов, например 0.995.
+	Target float64
+}
+
+// Status — соблюдение цели маршрута за окно трекера.
+type Status struct {
+	Route     string  `json:"route" example:"GET /api/products"`
+	LatencyMS int64   `json:"latency_ms" example:"300"`
+	Target    float64 `json:"target" example:"0.995"`
+	Window    string  `json:"window" example:"24h0m0s"`
+	Requests  int     `json:"requests"`
+	Good      int     `json:"good"`
+	// Compliance — доля хороших запросов за окно; 1, если запросов не было.
+	Compliance float64 `json:"compliance"`
+	// BudgetRemaining — оставшаяся доля бюджета ошибок; отрицательная, если
+	// цель за окно уже нарушена.
+	BudgetRemaining float64 `json:"budget_remaining"`
+	// BurnRate1h и BurnRate5m — скорость сгорания бюджета: 1 означает, что
+	// бюджет закончится ровно к концу окна.
+	BurnRate1h float64 `json:"burn_rate_1h"`
+	BurnRate5m float64 `json:"burn_rate_5m"`
+	Alerting   bool    `json:"alerting"`
+}
+
+// Alert — начало или окончание быстрого сгорания бюджета ошибок маршрута.
+type Alert struct {
+	Route      string    `json:"route"`
+	State      string    `json:"state"`
+	Target     float64   `json:"target"`
+	LatencyMS  int64     `json:"latency_ms"`
+	BurnRate1h float64   `json:"burn_rate_1h"`
+	BurnRate5m float64   `json:"burn_rate_5m"`
+	Threshold  float64   `json:"threshold"`
+	At         time.Time `json:"at"`
+}
+
+// Options настраивает трекер.
+type Options struct {
+	// Window — окно, за которое считается соблюдение цели.
+	Window time.Duration
+	// FastBurn — скорость сгорания бюджета, при которой поднимается тревога.
+	FastBurn float64
+	// Notify получает тревоги и их снятие; может быть nil.
+	Notify func(Alert)
+}
+
+// Tracker считает хорошие и плохие запросы маршрутов с целями по минутам в
+// памяти экземпляра сервера. После перезапуска история начинается заново.
+type Tracker struct {
+	window   time.Duration
+	fastBurn float64
+	notify   func(Alert)
+
+	mu     sync.Mutex
+	routes map[string]*routeState
+}
+
+type routeState struct {
+	objective Objective
+	// buckets — кольцо поминутных счётчиков.
+	buckets  []bucket
+	alerting bool
+}
+
+type bucket struct {
+	minute int64
+	total  int
+	good   int
+}
+
+func NewTracker(objectives []Objective, opts Options) *Tracker {
+	size := int(max(opts.Window, burnLongWindow) / time.Minute)
+	t := &Tracker{
+		window:   opts.Window,
+		fastBurn: opts.FastBurn,
+		notify:   opts.Notify,
+		routes:   make(map[string]*routeState, len(objectives)),
+	}
+	for _, objective := range objectives {
+		t.routes[objective.Route] = &routeState{objective: objective, buckets: make([]bucket, size)}
+	}
+	return t
+}
+
+// Middleware учитывает запросы к маршрутам, для которых заданы цели.
+func (t *Tracker) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err != nil {
+			// Ошибку ещё не превратил в ответ ErrorHandler приложения.
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+		t.record(c.Method()+" "+c.Route().Path, status, time.Since(start), start)
+		return err
+	}
+}
+
+func (t *Tracker) record(route string, status int, elapsed time.Duration, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.routes[route]
+	if !ok {
+		return
+	}
+	minute := at.Unix() / 60
+	b := &state.buckets[minute%int64(len(state.buckets))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if status < 500 && elapsed <= state.objective.Latency {
+		b.good++
+	}
+}
+
+// count суммирует запросы маршрута за последние span до now.
+func (s *routeState) count(span time.Duration, now time.Time) (total, good int) {
+	last := now.Unix() / 60
+	first := last - int64(span/time.Minute) + 1
+	for _, b := range s.buckets {
+		if b.minute >= first && b.minute <= last {
+			total += b.total
+			good += b.good
+		}
+	}
+	return total, good
+}
+
+// burnRate — отношение доли плохих запросов к доле, допустимой целью.
+func (s *routeState) burnRate(span time.Duration, now time.Time) (float64, int) {
+	total, good := s.count(span, now)
+	if total == 0 || s.objective.Target >= 1 {
+		return 0, total
+	}
+	return float64(total-good) / float64(total) / (1 - s.objective.Target), total
+}
+
+func (t *Tracker) status(s *routeState, now time.Time) Status {
+	total, good := s.count(t.window, now)
+	status := Status{
+		Route:           s.objective.Route,
+		LatencyMS:       s.objective.Latency.Milliseconds(),
+		Target:          s.objective.Target,
+		Window:          t.window.String(),
+		Requests:        total,
+		Good:            good,
+		Compliance:      1,
+		BudgetRemaining: 1,
+		Alerting:        s.alerting,
+	}
+	if total > 0 {
+		status.Compliance = float64(good) / float64(total)
+		if s.objective.Target < 1 {
+			status.BudgetRemaining = 1 - (1-status.Compliance)/(1-s.objective.Target)
+		}
+	}
+	status.BurnRate1h, _ = s.burnRate(burnLongWindow, now)
+	status.BurnRate5m, _ = s.burnRate(burnShortWindow, now)
+	return status
+}
+
+// Statuses возвращает соблюдение целей всех маршрутов в порядке маршрутов.
+func (t *Tracker) Statuses() []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	statuses := make([]Status, 0, len(t.routes))
+	for _, state := range t.routes {
+		statuses = append(statuses, t.status(state, now))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Route < statuses[j].Route })
+	return statuses
+}
+
+// Check поднимает тревогу по маршрутам, где бюджет ошибок быстро сгорает
+// и в часовом, и в пятиминутном окне, и снимает её, когда сгорание в
+// коротком окне прекратилось.
+func (t *Tracker) Check() {
+	now := time.Now()
+	var alerts []Alert
+	t.mu.Lock()
+	for _, state := range t.routes {
+		long, _ := state.burnRate(burnLongWindow, now)
+		short, requests := state.burnRate(burnShortWindow, now)
+		burning := long >= t.fastBurn && short >= t.fastBurn && requests >= minBurnRequests
+		cooled := short < t.fastBurn
+		if burning == state.alerting || (!burning && !cooled) {
+			continue
+		}
+		state.alerting = burning
+		alert := Alert{
+			Route:      state.objective.Route,
+			State:      AlertResolved,
+			Target:     state.objective.Target,
+			LatencyMS:  state.objective.Latency.Milliseconds(),
+			BurnRate1h: long,
+			BurnRate5m: short,
+			Threshold:  t.fastBurn,
+			At:         now.UTC(),
+		}
+		if burning {
+			alert.State = AlertFiring
+		}
+		alerts = append(alerts, alert)
+	}
+	t.mu.Unlock()
+
+	for _, alert := range alerts {
+		log.Printf("SLO %s: %s, скорость сгорания бюджета %.1f за час и %.1f за 5 минут", alert.Route, alert.State, alert.BurnRate1h, alert.BurnRate5m)
+		if t.notify != nil {
+			t.notify(alert)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local хранит файлы в каталоге на диске под случайно сгенерированными ключами.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+// Save записывает содержимое r в новый файл и возвращает его ключ и размер.
+func (l *Local) Save(r io.Reader) (string, int64, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", 0, err
+	}
+	key := hex.EncodeToString(buf)
+
+	f, err := os.Create(l.Path(key))
+	if err != nil {
+		return "", 0, err
+	}
+	size, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(l.Path(key))
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+// Path возвращает путь к файлу по ключу.
+func (l *Local) Path(key string) string {
+	return filepath.Join(l.dir, filepath.Base(key))
+}
+
+func (l *Local) Remove(key string) error {
+	err := os.Remove(l.Path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
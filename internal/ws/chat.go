@@ -0,0 +1,1477 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"log"
+	"regexp"
+	"server/internal/auth"
+	"server/internal/events"
+	"server/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRoom — комната подключений к /api/ws без указания комнаты.
+	DefaultRoom = "general"
+	// maxRoomsPerClient ограничивает число комнат одного подключения.
+	maxRoomsPerClient = 16
+	// authTimeout — сколько подключение без токена в адресе ждёт фрейм auth.
+	authTimeout = 10 * time.Second
+	// historyTimeout ограничивает запись сообщения в историю и её чтение.
+	historyTimeout = 5 * time.Second
+	// directReplay — сколько непрочитанных личных сообщений получает
+	// пользователь при подключении.
+	directReplay = 100
+	// defaultSendBuffer — размер очереди рассылки клиента по умолчанию.
+	defaultSendBuffer = 64
+	// writeWait ограничивает запись одного фрейма в соединение.
+	writeWait = 10 * time.Second
+	// maxMessageImages ограничивает число изображений в одном сообщении.
+	maxMessageImages = 4
+)
+
+// principalLocal — ключ Locals, под которым обработчик передаёт подключению
+// пользователя, аутентифицированного по токену из адреса.
+const principalLocal = "ws_principal"
+
+var roomPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// mentionPattern — личное сообщение вида "@bob привет".
+var mentionPattern = regexp.MustCompile(`^@(\S{1,64})\s`)
+
+// ValidRoom сообщает, допустимо ли имя комнаты: строчные латинские буквы,
+// цифры, '_' и '-', до 64 символов, например "product-42".
+func ValidRoom(room string) bool {
+	return roomPattern.MatchString(room)
+}
+
+type Message struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+	// Room — комната сообщения; пустая у входящих сообщений означает
+	// комнату, указанную при подключении.
+	Room string `json:"room,omitempty"`
+	// To — получатель личного сообщения. С аутентификацией сообщение,
+	// начинающееся с "@имя ", тоже личное. У личных сообщений нет комнаты.
+	To string `json:"to,omitempty"`
+	// ImageIDs — изображения входящего сообщения, загруженные через
+	// POST /api/chat/images. Сервер заменяет их на Attachments.
+	ImageIDs []string `json:"image_ids,omitempty"`
+	// Attachments — метаданные изображений сообщения.
+	Attachments []models.ChatImage `json:"attachments,omitempty"`
+	// EditedAt — когда сообщение исправили; есть только у повторённых из
+	// истории сообщений.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+}
+
+// client — подключение чата. Запись в соединение сериализуется мьютексом:
+// рассылку пишет writePump, а ответы на фреймы — горутина подключения.
+type client struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	// version — согласованная версия протокола; до рукопожатия ProtocolV1.
+	version atomic.Int32
+	// username — имя пользователя для presence: из токена, а без
+	// аутентификации — из hello. Меняется только горутиной подключения под
+	// Chat.mu.
+	username string
+	// authenticated — пользователь подтвердил личность токеном.
+	authenticated atomic.Bool
+	// role — роль пользователя из токена; меняется и читается только
+	// горутиной подключения.
+	role string
+	// room — комната из адреса подключения.
+	room string
+	// rooms — комнаты, в которых состоит клиент; защищены Chat.mu.
+	rooms map[string]bool
+	// negotiated — клиент прислал hello и получил welcome.
+	negotiated atomic.Bool
+	// replayed — клиенту отправлена история комнаты из адреса.
+	replayed atomic.Bool
+	// admitted — клиент впущен в чат и получает события продуктов.
+	admitted atomic.Bool
+	// send — очередь рассылки клиенту; пишет в неё и закрывает только Run.
+	send chan Envelope
+	// done закрывается, когда writePump перестал писать в соединение.
+	done chan struct{}
+	// dropped — Run отключил клиента, переполнившего очередь рассылки.
+	dropped atomic.Bool
+	// closeWith — фрейм закрытия, если Run отключил клиента по Kick или при
+	// остановке сервера.
+	closeWith atomic.Pointer[closeFrame]
+	// lastFrame — время последнего фрейма клиента в UnixNano, для IdleTimeout.
+	lastFrame atomic.Int64
+	// deadlineMu не даёт pong продлить срок чтения, который close уже
+	// обнулил, чтобы завершить подключение.
+	deadlineMu sync.Mutex
+	closing    bool
+}
+
+func newClient(conn *websocket.Conn, room string, buffer int) *client {
+	cl := &client{
+		conn:  conn,
+		room:  room,
+		rooms: make(map[string]bool),
+		send:  make(chan Envelope, buffer),
+		done:  make(chan struct{}),
+	}
+	cl.version.Store(ProtocolV1)
+	cl.lastFrame.Store(time.Now().UnixNano())
+	return cl
+}
+
+// keepalive — настройки проверки живости подключений.
+type keepalive struct {
+	ping time.Duration
+	pong time.Duration
+	idle time.Duration
+}
+
+// alive продлевает срок чтения после pong или фрейма клиента.
+func (cl *client) alive(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	cl.deadlineMu.Lock()
+	defer cl.deadlineMu.Unlock()
+	if !cl.closing {
+		cl.conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+}
+
+func (cl *client) writeJSON(v interface{}) error {
+	cl.writeMu.Lock()
+	defer cl.writeMu.Unlock()
+	cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return cl.conn.WriteJSON(v)
+}
+
+// writePump отправляет клиенту рассылку, пока Run не закроет очередь, и
+// пингует его. Подключение без фреймов дольше ka.idle закрывается. После
+// ошибки записи остаток очереди отбрасывается, а чтение прерывается, чтобы
+// обработчик подключения завершился.
+func (cl *client) writePump(ka keepalive, latency *window) {
+	defer close(cl.done)
+	period := ka.ping
+	if period <= 0 {
+		period = ka.idle
+	}
+	var tick <-chan time.Time
+	if period > 0 {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	failed := false
+	fail := func(err error) {
+		log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
+		failed = true
+		cl.conn.SetReadDeadline(time.Now())
+	}
+	for {
+		select {
+		case env, ok := <-cl.send:
+			if !ok {
+				switch frame := cl.closeWith.Load(); {
+				case failed:
+				case frame != nil:
+					cl.close(*frame)
+				case cl.dropped.Load():
+					cl.close(closeSlow)
+				}
+				return
+			}
+			if failed {
+				continue
+			}
+			if err := cl.deliver(env); err != nil {
+				fail(err)
+			} else if !env.queued.IsZero() {
+				now := time.Now()
+				latency.observe(now, now.Sub(env.queued))
+			}
+		case <-tick:
+			if failed {
+				continue
+			}
+			if ka.idle > 0 && time.Since(time.Unix(0, cl.lastFrame.Load())) > ka.idle {
+				cl.close(closeIdle)
+				failed = true
+				continue
+			}
+			if ka.ping > 0 {
+				cl.writeMu.Lock()
+				err := cl.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+				cl.writeMu.Unlock()
+				if err != nil {
+					fail(err)
+				}
+			}
+		}
+	}
+}
+
+// close отправляет клиенту фрейм закрытия и прерывает чтение, чтобы
+// обработчик подключения завершился: Close у перехваченного fasthttp
+// соединения ничего не делает до выхода из обработчика.
+func (cl *client) close(frame closeFrame) {
+	cl.writeMu.Lock()
+	cl.conn.WriteControl(websocket.CloseMessage, frame.message(), time.Now().Add(time.Second))
+	cl.writeMu.Unlock()
+	cl.deadlineMu.Lock()
+	cl.closing = true
+	cl.conn.SetReadDeadline(time.Now())
+	cl.deadlineMu.Unlock()
+}
+
+// moderator сообщает, что роли клиента разрешено модерировать чат
+// (auth.PermModerate). Роль читается только горутиной подключения.
+func (cl *client) moderator() bool {
+	return cl.authenticated.Load() && auth.Allowed(cl.role, auth.PermModerate)
+}
+
+// closed сообщает, что сервер закрыл подключение методом close.
+func (cl *client) closed() bool {
+	cl.deadlineMu.Lock()
+	defer cl.deadlineMu.Unlock()
+	return cl.closing
+}
+
+// deliver отправляет конверт в формате версии протокола клиента. Клиенты v1
+// получают только сообщения чата.
+func (cl *client) deliver(env Envelope) error {
+	if cl.version.Load() >= ProtocolV2 {
+		return cl.writeJSON(env)
+	}
+	if env.Type == TypeChatMessage {
+		return cl.writeJSON(env.Payload)
+	}
+	return nil
+}
+
+// History хранит сообщения чата для повтора переподключившимся клиентам.
+type History interface {
+	Append(ctx context.Context, msg models.ChatMessage) (models.ChatMessage, error)
+	Recent(ctx context.Context, room string, limit int) ([]models.ChatMessage, error)
+	// Unread возвращает непрочитанные личные сообщения пользователя, а
+	// MarkRead отмечает личное сообщение прочитанным его получателем.
+	Unread(ctx context.Context, username string, limit int) ([]models.ChatMessage, error)
+	MarkRead(ctx context.Context, envelopeID, username string) error
+	// Find находит неудалённое сообщение по id конверта, Edit заменяет его
+	// текст, а Delete удаляет сообщение.
+	Find(ctx context.Context, envelopeID string) (models.ChatMessage, bool, error)
+	Edit(ctx context.Context, envelopeID, text string) (time.Time, error)
+	Delete(ctx context.Context, envelopeID string) error
+}
+
+// Moderation хранит ограничения пользователей чата, наложенные
+// модераторами. Sanction вызывается на каждом сообщении и не должен ходить
+// в базу.
+type Moderation interface {
+	Sanction(username, kind string) (models.ChatSanction, bool)
+	Impose(ctx context.Context, sanction models.ChatSanction, duration time.Duration) (models.ChatSanction, error)
+	Lift(ctx context.Context, username, kind string) error
+}
+
+// Images находит изображения, загруженные для сообщений чата. Lookup
+// возвращает найденные изображения в порядке ids и пропускает
+// несуществующие.
+type Images interface {
+	Lookup(ctx context.Context, ids []string) ([]models.ChatImage, error)
+}
+
+// ChatOptions настраивает чат.
+type ChatOptions struct {
+	// Auth проверяет токены подключений; nil — подключения анонимны, а имя
+	// отправителя задаёт сам клиент.
+	Auth auth.Authenticator
+	// History сохраняет сообщения; nil — история не ведётся.
+	History History
+	// Replay — сколько последних сообщений комнаты отправить клиенту при
+	// подключении и входе в комнату; 0 — не отправлять.
+	Replay int
+	// PingInterval — как часто пинговать клиента; 0 — не пинговать.
+	PingInterval time.Duration
+	// PongTimeout — сколько ждать pong или любого фрейма клиента, прежде чем
+	// счесть подключение мёртвым; должен быть больше PingInterval. 0 — ждать
+	// бесконечно.
+	PongTimeout time.Duration
+	// IdleTimeout закрывает подключение, от которого дольше этого не было
+	// фреймов (pong не считается); 0 — не закрывать.
+	IdleTimeout time.Duration
+	// SendBuffer — сколько конвертов может ждать отправки одному клиенту;
+	// 0 — 64.
+	SendBuffer int
+	// Limits — размер и частота фреймов клиента, чтобы одно подключение не
+	// забило рассылку.
+	Limits FrameLimits
+	// Moderation — ограничения пользователей и команды модераторов; nil —
+	// без модерации.
+	Moderation Moderation
+	// Filter проверяет текст сообщений перед рассылкой; nil — не проверять.
+	Filter Filter
+	// Images — изображения сообщений; nil — сообщения без изображений.
+	Images Images
+}
+
+// delivery — конверт в очереди рассылки. Пустая room — всем подключениям,
+// непустой to — только этому подключению, непустой users — всем
+// аутентифицированным подключениям этих пользователей.
+type delivery struct {
+	room  string
+	env   Envelope
+	to    *client
+	users []string
+	// kick — вместо конверта отключить получателей с этой причиной.
+	kick string
+	// except не получает конверт: отправитель индикатора набора.
+	except *client
+	// ephemeral — конверт можно отбросить при переполненной очереди клиента:
+	// индикаторы набора и подтверждения доставки.
+	ephemeral bool
+}
+
+// Chat рассылает сообщения клиентам комнаты, в которую они написаны.
+// События продуктов получают все подключения.
+//
+// Реестр подключений принадлежит горутине Run: подключения регистрируются и
+// снимаются через каналы register и unregister, а рассылка только кладёт
+// конверты в очереди клиентов. В соединение пишет writePump клиента, и
+// обработчик подключения дожидается его завершения, поэтому никто не пишет в
+// соединение, которое Fiber уже вернул в пул. Членство в комнатах меняют
+// горутины подключений, поэтому оно защищено mu.
+//
+// Сообщение чата получает от сервера id — id конверта, — который сервер
+// сообщает отправителю в ack со статусом sent. Получатели v2 подтверждают
+// сообщения фреймом ack, и сервер пересылает подтверждение отправителю со
+// статусом delivered. Индикаторы набора typing рассылаются остальным
+// участникам комнаты.
+//
+// Модераторы — пользователи с ролью moderator или admin в токене —
+// ограничивают других командами в тексте сообщения: "/mute имя
+// [длительность] [причина]", "/ban ...", "/unmute имя" и "/unban имя".
+// Заглушённый пользователь получает ошибку muted на сообщения и индикаторы
+// набора, забаненный — отключается и не может подключиться снова. Перед
+// рассылкой текст сообщения проходит через Filter.
+//
+// К сообщению можно приложить до maxMessageImages изображений, загруженных
+// заранее через REST: клиент присылает их ID в image_ids, а получатели
+// видят метаданные в attachments. С аутентификацией приложить можно только
+// свои изображения.
+//
+// Автор сообщения и модераторы могут исправить его фреймом chat.edit или
+// удалить фреймом chat.delete с id сообщения. Изменение сохраняется в
+// History и рассылается тем, кому сообщение было адресовано. Без
+// аутентификации авторство не проверить, поэтому правка доступна только с
+// ней.
+//
+// Личное сообщение с получателем to доставляется только подключениям
+// получателя и отправителя и сохраняется непрочитанным. Получатель, который
+// был не в сети, получает непрочитанные личные сообщения при подключении, а
+// его ack отмечает сообщение прочитанным. Имена без аутентификации никто не
+// проверяет, поэтому личные сообщения доступны только с ней.
+//
+// Сообщения сохраняются в History, и клиент после рукопожатия hello получает
+// последние Replay сообщений своей комнаты, а после room.join — сообщения
+// новой комнаты. Повторённые конверты помечены replayed и сохраняют исходный
+// id, поэтому клиент может отбросить сообщения, которые уже видел.
+//
+// С аутентификацией подключение попадает в чат только после проверки JWT из
+// параметра token адреса, заголовка Authorization или первого фрейма auth, а
+// имя отправителя сообщений сервер берёт из токена.
+type Chat struct {
+	auth       auth.Authenticator
+	history    History
+	moderation Moderation
+	filter     Filter
+	images     Images
+	replay     int
+	// keepalive — пинги и тайм-ауты подключений.
+	keepalive keepalive
+	// clients — зарегистрированные подключения; доступны только из Run.
+	clients     map[*client]bool
+	register    chan *client
+	unregister  chan *client
+	broadcast   chan delivery
+	mu          sync.RWMutex
+	rooms       map[string]map[*client]bool
+	receipts    *receipts
+	sendBuffer  int
+	limits      FrameLimits
+	connections atomic.Int64
+	// droppedEvents и slowDisconnects считают последствия переполнения
+	// очередей клиентов.
+	droppedEvents   atomic.Int64
+	slowDisconnects atomic.Int64
+	// droppedEphemeral — отброшенные справочные конверты, droppedBroadcasts —
+	// события, не попавшие в переполненную очередь рассылки.
+	droppedEphemeral  atomic.Int64
+	droppedBroadcasts atomic.Int64
+	// connectionsOpened, messages, messageRate и fanoutLatency — для Stats.
+	connectionsOpened atomic.Int64
+	messages          atomic.Int64
+	messageRate       window
+	fanoutLatency     window
+	// limitDisconnects — клиенты, отключённые за превышение Limits.
+	limitDisconnects atomic.Int64
+	// stopping — сервер останавливается: новые подключения не принимаются.
+	stopping atomic.Bool
+	shutdown chan struct{}
+}
+
+func NewChat(opts ChatOptions) *Chat {
+	if opts.SendBuffer <= 0 {
+		opts.SendBuffer = defaultSendBuffer
+	}
+	return &Chat{
+		sendBuffer: opts.SendBuffer,
+		limits:     opts.Limits,
+		auth:       opts.Auth,
+		history:    opts.History,
+		moderation: opts.Moderation,
+		filter:     opts.Filter,
+		images:     opts.Images,
+		replay:     opts.Replay,
+		keepalive:  keepalive{ping: opts.PingInterval, pong: opts.PongTimeout, idle: opts.IdleTimeout},
+		clients:    make(map[*client]bool),
+		register:   make(chan *client),
+		unregister: make(chan *client),
+		broadcast:  make(chan delivery, 64),
+		rooms:      make(map[string]map[*client]bool),
+		receipts:   newReceipts(),
+		shutdown:   make(chan struct{}),
+	}
+}
+
+// Run ведёт реестр подключений и раскладывает рассылку по очередям клиентов;
+// запускается в отдельной горутине.
+func (ch *Chat) Run() {
+	for {
+		select {
+		case cl := <-ch.register:
+			if ch.stopping.Load() {
+				// Подключение успело пройти проверку до остановки.
+				cl.closeWith.Store(&closeShutdown)
+				close(cl.send)
+				continue
+			}
+			ch.clients[cl] = true
+		case cl := <-ch.unregister:
+			ch.drop(cl)
+		case d := <-ch.broadcast:
+			ch.fanOut(d)
+		case <-ch.shutdown:
+			ch.closeAll()
+		}
+	}
+}
+
+// closeAll раскладывает уже поставленную рассылку по очередям и отключает
+// всех клиентов с кодом 1001: writePump досылает очередь клиента и только
+// потом отправляет фрейм закрытия.
+func (ch *Chat) closeAll() {
+	for pending := true; pending; {
+		select {
+		case d := <-ch.broadcast:
+			ch.fanOut(d)
+		default:
+			pending = false
+		}
+	}
+	for cl := range ch.clients {
+		cl.closeWith.Store(&closeShutdown)
+		ch.drop(cl)
+	}
+}
+
+// Shutdown останавливает чат: новые подключения получают 503, а
+// подключённые клиенты — досланную рассылку и фрейм закрытия 1001, чтобы
+// переподключиться к другому экземпляру. Возвращается, когда все
+// подключения закрыты, или с ошибкой ctx. Run должен работать.
+func (ch *Chat) Shutdown(ctx context.Context) error {
+	if !ch.stopping.Swap(true) {
+		select {
+		case ch.shutdown <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return waitClosed(ctx, &ch.connections)
+}
+
+// drop снимает клиента с регистрации и закрывает его очередь рассылки.
+func (ch *Chat) drop(cl *client) {
+	if !ch.clients[cl] {
+		return
+	}
+	delete(ch.clients, cl)
+	close(cl.send)
+}
+
+// fanOut кладёт конверт в очереди получателей, не дожидаясь записи в
+// соединения, так что медленный клиент не задерживает остальных. Если
+// очередь клиента переполнена, событие продукта, индикатор набора или
+// подтверждение доставки для него отбрасывается: они носят справочный
+// характер. Сообщение чата или presence отбросить
+// нельзя — клиент потерял бы часть разговора, — поэтому клиент отключается
+// и после переподключения получает историю.
+func (ch *Chat) fanOut(d delivery) {
+	var targets []*client
+	switch {
+	case d.to != nil:
+		targets = append(targets, d.to)
+	case len(d.users) > 0:
+		ch.mu.RLock()
+		for cl := range ch.clients {
+			if cl.authenticated.Load() && slices.Contains(d.users, cl.username) {
+				targets = append(targets, cl)
+			}
+		}
+		ch.mu.RUnlock()
+	case d.room == "":
+		for cl := range ch.clients {
+			if cl.admitted.Load() {
+				targets = append(targets, cl)
+			}
+		}
+	default:
+		ch.mu.RLock()
+		for cl := range ch.rooms[d.room] {
+			targets = append(targets, cl)
+		}
+		ch.mu.RUnlock()
+	}
+	env := d.env
+	env.queued = time.Now()
+	for _, cl := range targets {
+		if !ch.clients[cl] || cl == d.except {
+			continue
+		}
+		if d.kick != "" {
+			frame := kickFrame(d.kick)
+			cl.closeWith.Store(&frame)
+			ch.drop(cl)
+			continue
+		}
+		select {
+		case cl.send <- env:
+		default:
+			if d.ephemeral {
+				ch.droppedEphemeral.Add(1)
+				continue
+			}
+			if d.room == "" && len(d.users) == 0 {
+				ch.droppedEvents.Add(1)
+				continue
+			}
+			log.Printf("Очередь клиента WebSocket переполнена, клиент отключён")
+			ch.slowDisconnects.Add(1)
+			cl.dropped.Store(true)
+			ch.drop(cl)
+		}
+	}
+}
+
+// toRoom ставит конверт в очередь рассылки комнаты.
+func (ch *Chat) toRoom(room string, env Envelope) {
+	if room != "" {
+		env.Room = room
+	}
+	ch.broadcast <- delivery{room: room, env: env}
+}
+
+// PublishEvent рассылает доменное событие клиентам v2; подписывается на шину
+// событий. При переполнении очереди событие отбрасывается, чтобы не
+// блокировать запись продукта.
+func (ch *Chat) PublishEvent(event events.Event) {
+	select {
+	case ch.broadcast <- delivery{env: Envelope{Type: event.Type, Version: PayloadVersion, ID: event.ID, TS: event.OccurredAt, Payload: event.Data, RequestID: event.RequestID}}:
+	default:
+		ch.droppedBroadcasts.Add(1)
+		log.Printf("Очередь WebSocket переполнена, событие %s (%s) отброшено", event.ID, event.Type)
+	}
+}
+
+// Kick отключает все подключения пользователя username к этому экземпляру
+// с кодом 1008 и причиной reason, например после бана.
+func (ch *Chat) Kick(username, reason string) {
+	ch.broadcast <- delivery{users: []string{username}, kick: reason}
+}
+
+// Connections возвращает число открытых WebSocket-подключений.
+func (ch *Chat) Connections() int64 {
+	return ch.connections.Load()
+}
+
+// DroppedEvents возвращает, сколько событий продуктов не досталось клиентам
+// с переполненной очередью.
+func (ch *Chat) DroppedEvents() int64 {
+	return ch.droppedEvents.Load()
+}
+
+// SlowDisconnects возвращает, сколько клиентов отключено из-за переполнения
+// очереди рассылки.
+func (ch *Chat) SlowDisconnects() int64 {
+	return ch.slowDisconnects.Load()
+}
+
+// LimitDisconnects возвращает, сколько клиентов отключено за слишком
+// большие или слишком частые сообщения.
+func (ch *Chat) LimitDisconnects() int64 {
+	return ch.limitDisconnects.Load()
+}
+
+// join добавляет клиента в комнату; false — клиент уже состоит в предельном
+// числе комнат.
+func (ch *Chat) join(cl *client, room string) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if cl.rooms[room] {
+		return true
+	}
+	if len(cl.rooms) >= maxRoomsPerClient {
+		return false
+	}
+	cl.rooms[room] = true
+	if ch.rooms[room] == nil {
+		ch.rooms[room] = make(map[*client]bool)
+	}
+	ch.rooms[room][cl] = true
+	return true
+}
+
+// leave убирает клиента из комнаты; false — клиент в ней не состоял.
+func (ch *Chat) leave(cl *client, room string) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if !cl.rooms[room] {
+		return false
+	}
+	delete(cl.rooms, room)
+	delete(ch.rooms[room], cl)
+	if len(ch.rooms[room]) == 0 {
+		delete(ch.rooms, room)
+	}
+	return true
+}
+
+func (ch *Chat) inRoom(cl *client, room string) bool {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	return cl.rooms[room]
+}
+
+// remove убирает клиента из всех комнат и возвращает комнаты, в которых он
+// состоял.
+func (ch *Chat) remove(cl *client) []string {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	rooms := make([]string, 0, len(cl.rooms))
+	for room := range cl.rooms {
+		rooms = append(rooms, room)
+		delete(ch.rooms[room], cl)
+		if len(ch.rooms[room]) == 0 {
+			delete(ch.rooms, room)
+		}
+	}
+	cl.rooms = map[string]bool{}
+	return rooms
+}
+
+// setUsername меняет имя клиента под mu: Presence читает имена из других
+// горутин.
+func (ch *Chat) setUsername(cl *client, name string) {
+	ch.mu.Lock()
+	cl.username = name
+	ch.mu.Unlock()
+}
+
+// announce рассылает presence-событие пользователя username в комнате, если
+// в ней нет других его подключений: пользователь с несколькими вкладками
+// входит в комнату с первым подключением и выходит с последним.
+func (ch *Chat) announce(cl *client, room, username, eventType string) {
+	if username == "" || ch.present(room, username, cl) {
+		return
+	}
+	ch.toRoom(room, newEnvelope(eventType, presencePayload{Username: username}))
+}
+
+// present сообщает, есть ли в комнате подключение пользователя, кроме except.
+func (ch *Chat) present(room, username string, except *client) bool {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	for other := range ch.rooms[room] {
+		if other != except && other.username == username {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomPresence — кто сейчас в комнате чата.
+type RoomPresence struct {
+	Room  string   `json:"room" example:"general"`
+	Users []string `json:"users" example:"alice,bob"`
+	// Anonymous — подключения, не назвавшие имени.
+	Anonymous int `json:"anonymous"`
+}
+
+// Presence возвращает участников комнат в порядке имён комнат; непустой
+// room ограничивает ответ одной комнатой. Учитываются только подключения к
+// этому экземпляру сервера.
+func (ch *Chat) Presence(room string) []RoomPresence {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	presence := []RoomPresence{}
+	for name, members := range ch.rooms {
+		if room != "" && name != room {
+			continue
+		}
+		entry := RoomPresence{Room: name, Users: []string{}}
+		seen := make(map[string]bool)
+		for cl := range members {
+			switch {
+			case cl.username == "":
+				entry.Anonymous++
+			case !seen[cl.username]:
+				seen[cl.username] = true
+				entry.Users = append(entry.Users, cl.username)
+			}
+		}
+		sort.Strings(entry.Users)
+		presence = append(presence, entry)
+	}
+	sort.Slice(presence, func(i, j int) bool { return presence[i].Room < presence[j].Room })
+	return presence
+}
+
+// memberOf возвращает комнаты клиента.
+func (ch *Chat) memberOf(cl *client) []string {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	rooms := make([]string, 0, len(cl.rooms))
+	for room := range cl.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// admit впускает клиента в чат и в комнату из адреса подключения;
+// забаненного пользователя отключает.
+func (ch *Chat) admit(cl *client, principal *auth.Principal) {
+	if principal != nil {
+		if ch.banned(cl, principal.Name) {
+			return
+		}
+		ch.setUsername(cl, principal.Name)
+		cl.role = principal.Role
+		cl.authenticated.Store(true)
+	}
+	cl.admitted.Store(true)
+	ch.join(cl, cl.room)
+	ch.announce(cl, cl.room, cl.username, TypePresenceJoin)
+	if cl.negotiated.Load() && !cl.replayed.Swap(true) {
+		ch.replayTo(cl, cl.room)
+		ch.replayDirect(cl)
+	}
+}
+
+// replayTo отправляет клиенту последние сообщения комнаты. Клиент к этому
+// моменту уже в комнате, поэтому новое сообщение может прийти раньше
+// повторённых или продублировать одно из них, но не потеряется.
+func (ch *Chat) replayTo(cl *client, room string) {
+	if ch.history == nil || ch.replay <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	messages, err := ch.history.Recent(ctx, room, ch.replay)
+	if err != nil {
+		log.Printf("Не удалось загрузить историю комнаты %s: %v", room, err)
+		return
+	}
+	for _, msg := range messages {
+		env := Envelope{
+			Type:     TypeChatMessage,
+			Version:  PayloadVersion,
+			ID:       msg.EnvelopeID,
+			TS:       msg.CreatedAt.UTC(),
+			Payload:  Message{Username: msg.Username, Message: msg.Message, Room: msg.Room, Attachments: msg.Attachments, EditedAt: msg.EditedAt},
+			Room:     msg.Room,
+			Replayed: true,
+		}
+		if err := cl.deliver(env); err != nil {
+			log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
+			return
+		}
+	}
+}
+
+// replayDirect отправляет аутентифицированному клиенту непрочитанные личные
+// сообщения, которые пришли, пока пользователь был не в сети.
+func (ch *Chat) replayDirect(cl *client) {
+	if ch.history == nil || !cl.authenticated.Load() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	messages, err := ch.history.Unread(ctx, cl.username, directReplay)
+	if err != nil {
+		log.Printf("Не удалось загрузить личные сообщения %s: %v", cl.username, err)
+		return
+	}
+	for _, msg := range messages {
+		// Отправитель повторённого сообщения не ждёт подтверждения, но ack
+		// получателя отметит сообщение прочитанным.
+		ch.receipts.add(msg.EnvelopeID, nil, msg.Username, "", msg.Recipient)
+		env := Envelope{
+			Type:     TypeChatMessage,
+			Version:  PayloadVersion,
+			ID:       msg.EnvelopeID,
+			TS:       msg.CreatedAt.UTC(),
+			Payload:  Message{Username: msg.Username, Message: msg.Message, To: msg.Recipient, Attachments: msg.Attachments, EditedAt: msg.EditedAt},
+			Replayed: true,
+		}
+		if err := cl.deliver(env); err != nil {
+			log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
+			return
+		}
+	}
+}
+
+// record учитывает сообщение чата в Stats и сохраняет его в историю.
+// Ошибка базы не мешает разослать сообщение: его не увидят только
+// переподключившиеся клиенты.
+func (ch *Chat) record(msg Message, env Envelope) {
+	ch.messages.Add(1)
+	ch.messageRate.observe(time.Now(), 0)
+	if ch.history == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	_, err := ch.history.Append(ctx, models.ChatMessage{
+		Room: msg.Room, Username: msg.Username, Message: msg.Message, EnvelopeID: env.ID, Recipient: msg.To, Attachments: msg.Attachments,
+	})
+	if err != nil {
+		log.Printf("Не удалось сохранить сообщение чата в комнате %s: %v", msg.Room, err)
+	}
+}
+
+// attach заменяет image_ids сообщения метаданными изображений. Все
+// изображения должны существовать, а с аутентификацией — быть загружены
+// отправителем, иначе клиент получает ошибку и сообщение не рассылается.
+func (ch *Chat) attach(cl *client, ref string, msg *Message) bool {
+	ids := msg.ImageIDs
+	msg.ImageIDs = nil
+	if len(ids) == 0 {
+		return true
+	}
+	if ch.images == nil {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "image attachments are not enabled", Field: "payload.image_ids", Ref: ref})
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	images, err := ch.images.Lookup(ctx, ids)
+	if err != nil {
+		log.Printf("Не удалось загрузить изображения сообщения чата: %v", err)
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "images could not be loaded", Field: "payload.image_ids", Ref: ref})
+		return false
+	}
+	if len(images) != len(ids) {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "unknown image id", Field: "payload.image_ids", Ref: ref})
+		return false
+	}
+	if cl.authenticated.Load() {
+		for _, img := range images {
+			if img.Uploader != cl.username {
+				ch.reject(cl, &FrameError{Code: ErrCodeForbidden, Message: "image " + img.ID + " was uploaded by another user",
+					Field: "payload.image_ids", Ref: ref})
+				return false
+			}
+		}
+	}
+	msg.Attachments = images
+	return true
+}
+
+// markRead отмечает личное сообщение id прочитанным получателем username.
+func (ch *Chat) markRead(id, username string) {
+	if ch.history == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	if err := ch.history.MarkRead(ctx, id, username); err != nil {
+		log.Printf("Не удалось отметить личное сообщение %s прочитанным: %v", id, err)
+	}
+}
+
+// Handler обслуживает WebSocket-подключение одного клиента. Клиент попадает
+// в комнату из параметра :room маршрута, а без него — в DefaultRoom; при
+// включённой аутентификации — только после проверки токена. Некорректные
+// фреймы не разрывают соединение: клиент получает фрейм error с описанием.
+// Слишком большие или слишком частые сообщения — разрывают: подключение
+// закрывается с кодом 1009 или 1013 соответственно.
+func (ch *Chat) Handler() fiber.Handler {
+	upgrade := websocket.New(func(c *websocket.Conn) {
+		cl := newClient(c, c.Params("room", DefaultRoom), ch.sendBuffer)
+		go cl.writePump(ch.keepalive, &ch.fanoutLatency)
+		ch.register <- cl
+		if ch.keepalive.ping > 0 {
+			cl.alive(ch.keepalive.pong)
+			c.SetPongHandler(func(string) error {
+				cl.alive(ch.keepalive.pong)
+				return nil
+			})
+		}
+		switch principal, ok := c.Locals(principalLocal).(auth.Principal); {
+		case ok:
+			ch.admit(cl, &principal)
+		case ch.auth == nil:
+			ch.admit(cl, nil)
+		default:
+			// До фрейма auth клиент не получает сообщений чата.
+			timer := time.AfterFunc(authTimeout, func() {
+				if !cl.authenticated.Load() {
+					cl.close(closeAuthTimeout)
+				}
+			})
+			defer timer.Stop()
+		}
+		ch.connections.Add(1)
+		ch.connectionsOpened.Add(1)
+		defer func() {
+			ch.connections.Add(-1)
+			ch.receipts.forget(cl)
+			rooms := ch.remove(cl)
+			ch.unregister <- cl
+			<-cl.done
+			for _, room := range rooms {
+				ch.announce(cl, room, cl.username, TypePresenceLeave)
+			}
+		}()
+		if ch.limits.MaxMessageSize > 0 {
+			c.SetReadLimit(ch.limits.MaxMessageSize)
+		}
+		limiter := newFrameLimiter(ch.limits)
+		for {
+			frameType, data, err := c.ReadMessage()
+			if err != nil {
+				if tooBig(err) {
+					ch.limitDisconnects.Add(1)
+				}
+				// Чтение подключения, закрытого сервером, прерывается
+				// намеренно.
+				if !cl.closed() {
+					log.Printf("Ошибка WebSocket: %v", err)
+				}
+				break
+			}
+			if !limiter.allow(time.Now()) {
+				log.Printf("Клиент WebSocket превысил частоту сообщений, подключение закрыто")
+				ch.limitDisconnects.Add(1)
+				cl.close(closeRateLimited)
+				break
+			}
+			cl.lastFrame.Store(time.Now().UnixNano())
+			if ch.keepalive.ping > 0 {
+				cl.alive(ch.keepalive.pong)
+			}
+			if frameType != websocket.TextMessage {
+				ch.reject(cl, &FrameError{Code: ErrCodeMalformed, Message: "only text frames are supported"})
+				continue
+			}
+			frame, frameErr := decodeFrame(data)
+			if frameErr != nil {
+				ch.reject(cl, frameErr)
+				continue
+			}
+			ch.handle(cl, frame)
+		}
+	})
+	return func(c *fiber.Ctx) error {
+		if ch.stopping.Load() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Server is shutting down"})
+		}
+		if room := c.Params("room"); room != "" && !ValidRoom(room) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid room name"})
+		}
+		if ch.auth != nil {
+			// Браузер не может задать заголовки WebSocket, поэтому токен
+			// принимается и в параметре адреса.
+			token := c.Query("token")
+			if header, ok := auth.BearerToken(c.Get(fiber.HeaderAuthorization)); ok {
+				token = header
+			}
+			if token != "" {
+				principal, err := ch.auth.Authenticate(token)
+				if err != nil {
+					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+				}
+				c.Locals(principalLocal, principal)
+			}
+		}
+		return upgrade(c)
+	}
+}
+
+// handle обрабатывает проверенный по схеме фрейм.
+func (ch *Chat) handle(cl *client, frame inboundFrame) {
+	secured := ch.auth != nil
+	if secured && !cl.authenticated.Load() && frame.Type != TypeAuth && frame.Type != TypeHello {
+		ch.reject(cl, &FrameError{Code: ErrCodeUnauthenticated, Message: "send an auth frame first", Ref: frame.ID})
+		return
+	}
+	switch frame.Type {
+	case TypeAuth:
+		if !secured || cl.authenticated.Load() {
+			ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "connection does not need authentication", Ref: frame.ID})
+			return
+		}
+		var payload authPayload
+		json.Unmarshal(frame.Payload, &payload)
+		principal, err := ch.auth.Authenticate(payload.Token)
+		if err != nil {
+			ch.reject(cl, &FrameError{Code: ErrCodeUnauthenticated, Message: "invalid token", Field: "payload.token", Ref: frame.ID})
+			cl.close(closeInvalidToken)
+			return
+		}
+		ch.ack(cl, ackPayload{Ref: frame.ID})
+		ch.admit(cl, &principal)
+	case TypeHello:
+		var hello helloPayload
+		json.Unmarshal(frame.Payload, &hello)
+		version, ok := negotiate(hello.Versions)
+		if !ok {
+			ch.reject(cl, &FrameError{Code: ErrCodeUnsupportedVer, Message: "no common protocol version",
+				Field: "payload.versions", Ref: frame.ID})
+			return
+		}
+		cl.version.Store(int32(version))
+		if err := cl.writeJSON(newEnvelope(TypeWelcome, welcomePayload{Version: version, Versions: supportedVersions})); err != nil {
+			log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
+		}
+		// Историю отправляем после welcome, чтобы она пришла в формате
+		// согласованной версии; без аутентификации клиент к этому моменту
+		// уже в чате, а с ней — получит историю после auth.
+		cl.negotiated.Store(true)
+		if cl.admitted.Load() && !cl.replayed.Swap(true) {
+			ch.replayTo(cl, cl.room)
+			ch.replayDirect(cl)
+		}
+		if secured {
+			// Имя задаёт токен; hello его не меняет.
+			return
+		}
+		if hello.Username != "" && hello.Username != cl.username {
+			previous := cl.username
+			ch.setUsername(cl, hello.Username)
+			for _, room := range ch.memberOf(cl) {
+				ch.announce(cl, room, previous, TypePresenceLeave)
+				ch.announce(cl, room, cl.username, TypePresenceJoin)
+			}
+		}
+	case TypeRoomJoin:
+		var payload roomPayload
+		json.Unmarshal(frame.Payload, &payload)
+		if !ValidRoom(payload.Room) {
+			ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "invalid room name", Field: "payload.room", Ref: frame.ID})
+			return
+		}
+		joined := ch.inRoom(cl, payload.Room)
+		if !ch.join(cl, payload.Room) {
+			ch.reject(cl, &FrameError{Code: ErrCodeRoomLimit,
+				Message: fmt.Sprintf("a connection can join at most %d rooms", maxRoomsPerClient), Field: "payload.room", Ref: frame.ID})
+			return
+		}
+		ch.ack(cl, ackPayload{Ref: frame.ID})
+		if !joined {
+			ch.announce(cl, payload.Room, cl.username, TypePresenceJoin)
+			ch.replayTo(cl, payload.Room)
+		}
+	case TypeRoomLeave:
+		var payload roomPayload
+		json.Unmarshal(frame.Payload, &payload)
+		if !ch.leave(cl, payload.Room) {
+			ch.reject(cl, &FrameError{Code: ErrCodeNotInRoom, Message: "not a member of the room", Field: "payload.room", Ref: frame.ID})
+			return
+		}
+		ch.ack(cl, ackPayload{Ref: frame.ID})
+		ch.announce(cl, payload.Room, cl.username, TypePresenceLeave)
+	case TypeChatMessage:
+		var msg Message
+		json.Unmarshal(frame.Payload, &msg)
+		username, ok := ch.senderName(cl, frame.ID, msg.Username)
+		if !ok {
+			return
+		}
+		msg.Username = username
+		if ch.silenced(cl, frame.ID, username) {
+			return
+		}
+		if command, ok := parseCommand(msg.Message); ok && ch.moderation != nil {
+			ch.moderate(cl, frame.ID, command)
+			return
+		}
+		if msg.Message == "" && len(msg.ImageIDs) == 0 {
+			ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "message or image_ids is required", Field: "payload.message", Ref: frame.ID})
+			return
+		}
+		if !ch.attach(cl, frame.ID, &msg) {
+			return
+		}
+		if ch.filter != nil {
+			text, ok := ch.filter.Filter(msg.Message)
+			if !ok {
+				ch.reject(cl, &FrameError{Code: ErrCodeFiltered, Message: "message rejected by the content filter", Field: "payload.message", Ref: frame.ID})
+				return
+			}
+			msg.Message = text
+		}
+		// Без аутентификации "@имя" остаётся обычным сообщением комнаты.
+		if msg.To == "" && ch.auth != nil {
+			if m := mentionPattern.FindStringSubmatch(msg.Message); m != nil {
+				msg.To = m[1]
+			}
+		}
+		if msg.To != "" {
+			ch.direct(cl, frame.ID, msg)
+			return
+		}
+		if msg.Room == "" {
+			msg.Room = cl.room
+		}
+		if !ch.inRoom(cl, msg.Room) {
+			ch.reject(cl, &FrameError{Code: ErrCodeNotInRoom, Message: "not a member of the room", Field: "payload.room", Ref: frame.ID})
+			return
+		}
+		env := newEnvelope(TypeChatMessage, msg)
+		ch.record(msg, env)
+		ch.receipts.add(env.ID, cl, msg.Username, msg.Room, "")
+		// ack уходит раньше рассылки, чтобы отправитель узнал id сообщения
+		// до того, как получит его копию.
+		ch.ack(cl, ackPayload{Ref: frame.ID, ID: env.ID, Status: AckSent})
+		ch.toRoom(msg.Room, env)
+	case TypeTyping:
+		var payload typingPayload
+		json.Unmarshal(frame.Payload, &payload)
+		if payload.Username == "" {
+			payload.Username = cl.username
+		}
+		username, ok := ch.senderName(cl, frame.ID, payload.Username)
+		if !ok || ch.silenced(cl, frame.ID, username) {
+			return
+		}
+		room := payload.Room
+		if room == "" {
+			room = cl.room
+		}
+		if !ch.inRoom(cl, room) {
+			ch.reject(cl, &FrameError{Code: ErrCodeNotInRoom, Message: "not a member of the room", Field: "payload.room", Ref: frame.ID})
+			return
+		}
+		env := newEnvelope(TypeTyping, typingPayload{Username: username, Active: payload.Active})
+		env.Room = room
+		ch.broadcast <- delivery{room: room, env: env, except: cl, ephemeral: true}
+		ch.ack(cl, ackPayload{Ref: frame.ID})
+	case TypeChatEdit:
+		var payload chatEditPayload
+		json.Unmarshal(frame.Payload, &payload)
+		original, ok := ch.modifiable(cl, frame.ID, payload.ID)
+		if !ok || ch.silenced(cl, frame.ID, cl.username) {
+			return
+		}
+		text := payload.Message
+		if ch.filter != nil {
+			if text, ok = ch.filter.Filter(text); !ok {
+				ch.reject(cl, &FrameError{Code: ErrCodeFiltered, Message: "message rejected by the content filter", Field: "payload.message", Ref: frame.ID})
+				return
+			}
+		}
+		if text == "" && len(original.Attachments) == 0 {
+			ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "message is required", Field: "payload.message", Ref: frame.ID})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+		editedAt, err := ch.history.Edit(ctx, original.EnvelopeID, text)
+		cancel()
+		if err != nil {
+			log.Printf("Не удалось исправить сообщение чата %s: %v", original.EnvelopeID, err)
+			ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "message could not be edited", Field: "payload.id", Ref: frame.ID})
+			return
+		}
+		ch.ack(cl, ackPayload{Ref: frame.ID})
+		ch.changed(original, newEnvelope(TypeChatEdit, chatEditPayload{
+			ID: original.EnvelopeID, Message: text, Username: original.Username, EditedBy: cl.username, EditedAt: editedAt.UTC(),
+		}))
+	case TypeChatDelete:
+		var payload chatDeletePayload
+		json.Unmarshal(frame.Payload, &payload)
+		original, ok := ch.modifiable(cl, frame.ID, payload.ID)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+		err := ch.history.Delete(ctx, original.EnvelopeID)
+		cancel()
+		if err != nil {
+			log.Printf("Не удалось удалить сообщение чата %s: %v", original.EnvelopeID, err)
+			ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "message could not be deleted", Field: "payload.id", Ref: frame.ID})
+			return
+		}
+		ch.ack(cl, ackPayload{Ref: frame.ID})
+		ch.changed(original, newEnvelope(TypeChatDelete, chatDeletePayload{
+			ID: original.EnvelopeID, Username: original.Username, DeletedBy: cl.username,
+		}))
+	case TypeAck:
+		var payload ackPayload
+		json.Unmarshal(frame.Payload, &payload)
+		ch.delivered(cl, payload.Ref)
+	}
+}
+
+// modifiable находит сообщение id, которое клиент может исправить или
+// удалить: своё, а модератор — любое.
+func (ch *Chat) modifiable(cl *client, ref, id string) (models.ChatMessage, bool) {
+	if ch.auth == nil || ch.history == nil {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "editing messages requires authentication and message history",
+			Field: "payload.id", Ref: ref})
+		return models.ChatMessage{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	msg, found, err := ch.history.Find(ctx, id)
+	if err != nil {
+		log.Printf("Не удалось найти сообщение чата %s: %v", id, err)
+	}
+	if !found {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "unknown message id", Field: "payload.id", Ref: ref})
+		return models.ChatMessage{}, false
+	}
+	if msg.Username != cl.username && !cl.moderator() {
+		ch.reject(cl, &FrameError{Code: ErrCodeForbidden, Message: "only the author or a moderator can change a message", Field: "payload.id", Ref: ref})
+		return models.ChatMessage{}, false
+	}
+	return msg, true
+}
+
+// changed рассылает правку или удаление сообщения msg тем же получателям,
+// что и само сообщение.
+func (ch *Chat) changed(msg models.ChatMessage, env Envelope) {
+	if msg.Recipient != "" {
+		ch.broadcast <- delivery{users: []string{msg.Recipient, msg.Username}, env: env}
+		return
+	}
+	ch.toRoom(msg.Room, env)
+}
+
+// moderationCommand — команда модератора в тексте сообщения чата.
+type moderationCommand struct {
+	kind     string
+	lift     bool
+	username string
+	duration time.Duration
+	reason   string
+	// usage — команда записана неверно; подсказка для ошибки.
+	usage string
+}
+
+var moderationCommands = map[string]moderationCommand{
+	"/mute":   {kind: models.SanctionMute, usage: "/mute <username> [duration] [reason]"},
+	"/ban":    {kind: models.SanctionBan, usage: "/ban <username> [duration] [reason]"},
+	"/unmute": {kind: models.SanctionMute, lift: true, usage: "/unmute <username>"},
+	"/unban":  {kind: models.SanctionBan, lift: true, usage: "/unban <username>"},
+}
+
+// parseCommand разбирает команду модератора; false — текст не команда.
+// Длительность — в формате Go, например 10m или 24h; без неё ограничение
+// бессрочное.
+func parseCommand(text string) (moderationCommand, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return moderationCommand{}, false
+	}
+	command, ok := moderationCommands[fields[0]]
+	if !ok {
+		return moderationCommand{}, false
+	}
+	if len(fields) < 2 || (command.lift && len(fields) > 2) {
+		return command, true
+	}
+	command.username, command.usage = fields[1], ""
+	rest := fields[2:]
+	if len(rest) > 0 {
+		if d, err := time.ParseDuration(rest[0]); err == nil && d > 0 {
+			command.duration, rest = d, rest[1:]
+		}
+	}
+	command.reason = strings.Join(rest, " ")
+	return command, true
+}
+
+// moderate выполняет команду модератора и подтверждает её ack; после бана
+// отключает подключения пользователя к этому экземпляру. Подключения к
+// другим экземплярам закрываются на их следующем фрейме.
+func (ch *Chat) moderate(cl *client, ref string, command moderationCommand) {
+	if !cl.moderator() {
+		ch.reject(cl, &FrameError{Code: ErrCodeForbidden, Message: "moderator role required", Field: "payload.message", Ref: ref})
+		return
+	}
+	if command.usage != "" {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "usage: " + command.usage, Field: "payload.message", Ref: ref})
+		return
+	}
+	if command.username == cl.username {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "moderators cannot restrict themselves", Field: "payload.message", Ref: ref})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+	var err error
+	if command.lift {
+		err = ch.moderation.Lift(ctx, command.username, command.kind)
+	} else {
+		_, err = ch.moderation.Impose(ctx, models.ChatSanction{
+			Username: command.username, Kind: command.kind, Reason: command.reason, CreatedBy: cl.username,
+		}, command.duration)
+	}
+	if err != nil {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload,
+			Message: fmt.Sprintf("%s %s: %v", command.kind, command.username, err), Field: "payload.message", Ref: ref})
+		return
+	}
+	if command.kind == models.SanctionBan && !command.lift {
+		ch.Kick(command.username, "banned")
+	}
+	ch.ack(cl, ackPayload{Ref: ref})
+}
+
+// banned отключает клиента, если пользователь username забанен.
+func (ch *Chat) banned(cl *client, username string) bool {
+	if ch.moderation == nil {
+		return false
+	}
+	if _, ok := ch.moderation.Sanction(username, models.SanctionBan); !ok {
+		return false
+	}
+	cl.close(closeBanned)
+	return true
+}
+
+// silenced отвечает ошибкой muted, если пользователю username запрещено
+// писать, и отключает забаненного.
+func (ch *Chat) silenced(cl *client, ref, username string) bool {
+	if ch.moderation == nil {
+		return false
+	}
+	if ch.banned(cl, username) {
+		return true
+	}
+	sanction, ok := ch.moderation.Sanction(username, models.SanctionMute)
+	if !ok {
+		return false
+	}
+	message := "you are muted"
+	if sanction.ExpiresAt != nil {
+		message += " until " + sanction.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	ch.reject(cl, &FrameError{Code: ErrCodeMuted, Message: message, Ref: ref})
+	return true
+}
+
+// direct сохраняет личное сообщение и доставляет его подключениям получателя
+// и всем подключениям отправителя, чтобы переписка была видна в каждой его
+// вкладке.
+func (ch *Chat) direct(cl *client, ref string, msg Message) {
+	if ch.auth == nil {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "direct messages require authentication", Field: "payload.to", Ref: ref})
+		return
+	}
+	if msg.To == msg.Username {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "cannot send a direct message to yourself", Field: "payload.to", Ref: ref})
+		return
+	}
+	msg.Room = ""
+	env := newEnvelope(TypeChatMessage, msg)
+	ch.record(msg, env)
+	ch.receipts.add(env.ID, cl, msg.Username, "", msg.To)
+	ch.ack(cl, ackPayload{Ref: ref, ID: env.ID, Status: AckSent})
+	ch.broadcast <- delivery{users: []string{msg.To, msg.Username}, env: env}
+}
+
+// senderName возвращает имя отправителя сообщения или индикатора набора: с
+// аутентификацией — из токена, и requested должно с ним совпадать; без неё —
+// requested, которое тогда обязательно.
+func (ch *Chat) senderName(cl *client, ref, requested string) (string, bool) {
+	if ch.auth != nil {
+		if requested != "" && requested != cl.username {
+			ch.reject(cl, &FrameError{Code: ErrCodeUsernameMismatch, Message: "username does not match the authenticated user",
+				Field: "payload.username", Ref: ref})
+			return "", false
+		}
+		return cl.username, true
+	}
+	if requested == "" {
+		ch.reject(cl, &FrameError{Code: ErrCodeInvalidPayload, Message: "username is required", Field: "payload.username", Ref: ref})
+		return "", false
+	}
+	return requested, true
+}
+
+// delivered пересылает отправителю сообщения id подтверждение, что его
+// получил cl; подтверждение личного сообщения от получателя ещё и отмечает
+// его прочитанным. Подтверждения неизвестных и собственных сообщений, а
+// также повторные подтверждения отбрасываются молча: клиент подтверждает и
+// сообщения из истории, отправители которых давно отключились.
+func (ch *Chat) delivered(cl *client, id string) {
+	rec, ok := ch.receipts.deliver(id, cl)
+	if !ok || rec.sender == cl {
+		return
+	}
+	if rec.recipient != "" {
+		if cl.username != rec.recipient {
+			return
+		}
+		ch.markRead(id, cl.username)
+	} else if !ch.inRoom(cl, rec.room) {
+		return
+	}
+	if rec.sender == nil || (rec.username != "" && rec.username == cl.username) {
+		// Та же учётная запись в другой вкладке — не доставка.
+		return
+	}
+	env := newEnvelope(TypeAck, ackPayload{Ref: id, Status: AckDelivered, Username: cl.username})
+	env.Room = rec.room
+	ch.broadcast <- delivery{to: rec.sender, env: env, ephemeral: true}
+}
+
+// ack подтверждает приём конверта клиенту v2, если тот указал id.
+func (ch *Chat) ack(cl *client, payload ackPayload) {
+	if payload.Ref == "" || cl.version.Load() < ProtocolV2 {
+		return
+	}
+	if err := cl.writeJSON(newEnvelope(TypeAck, payload)); err != nil {
+		log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
+	}
+}
+
+func (ch *Chat) reject(cl *client, frameErr *FrameError) {
+	var frame interface{} = ErrorFrame{Type: TypeError, Error: *frameErr}
+	if cl.version.Load() >= ProtocolV2 {
+		frame = newEnvelope(TypeError, frameErr)
+	}
+	if err := cl.writeJSON(frame); err != nil {
+		log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
+	}
+}
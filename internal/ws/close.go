@@ -0,0 +1,58 @@
+package ws
+
+import "github.com/gofiber/websocket/v2"
+
+// closeFrame — код и причина фрейма закрытия, с которым сервер отключает
+// клиента.
+type closeFrame struct {
+	code   int
+	reason string
+}
+
+func (f closeFrame) message() []byte {
+	return websocket.FormatCloseMessage(f.code, f.reason)
+}
+
+// Фреймы закрытия сервера. Код говорит клиенту, стоит ли переподключаться:
+// после 1001 — сразу (к другому экземпляру или с новым подключением), после
+// 1013 — с паузой, а после 1008 с теми же учётными данными переподключаться
+// бесполезно. Подключение, приславшее сообщение больше
+// FrameLimits.MaxMessageSize, закрывается с кодом 1009 самой библиотекой
+// WebSocket.
+var (
+	closeShutdown     = closeFrame{websocket.CloseGoingAway, "server shutting down"}
+	closeIdle         = closeFrame{websocket.CloseGoingAway, "idle timeout"}
+	closeSlow         = closeFrame{websocket.CloseTryAgainLater, "client is too slow"}
+	closeRateLimited  = closeFrame{websocket.CloseTryAgainLater, "rate limit exceeded"}
+	closeAuthTimeout  = closeFrame{websocket.ClosePolicyViolation, "authentication timeout"}
+	closeInvalidToken = closeFrame{websocket.ClosePolicyViolation, "invalid token"}
+	closeBanned       = closeFrame{websocket.ClosePolicyViolation, "banned"}
+)
+
+// kickFrame — фрейм закрытия подключений пользователя, которого отключили
+// через Chat.Kick: после бана или удаления учётной записи.
+func kickFrame(reason string) closeFrame {
+	return closeFrame{websocket.ClosePolicyViolation, reason}
+}
+
+// CloseSpec описывает код и причину фрейма закрытия, с которыми сервер
+// отключает клиента.
+type CloseSpec struct {
+	Code   int    `json:"code" example:"1013"`
+	Reason string `json:"reason" example:"rate limit exceeded"`
+	// Retryable — стоит ли клиенту переподключиться.
+	Retryable   bool   `json:"retryable"`
+	Description string `json:"description"`
+}
+
+var closeDescriptions = []CloseSpec{
+	{closeShutdown.code, closeShutdown.reason, true, "The server is stopping; reconnect right away to reach another instance."},
+	{closeIdle.code, closeIdle.reason, true, "No frames from the client within the idle timeout; reconnect when needed."},
+	{closeSlow.code, closeSlow.reason, true, "The client did not read messages fast enough and its send queue overflowed; reconnect with backoff and fetch the history."},
+	{closeRateLimited.code, closeRateLimited.reason, true, "The client sent frames faster than allowed; reconnect with backoff."},
+	{websocket.CloseMessageTooBig, "", false, "A frame exceeded the maximum message size; resending it will fail again."},
+	{closeAuthTimeout.code, closeAuthTimeout.reason, false, "The client did not send auth in time after connecting without a token."},
+	{closeInvalidToken.code, closeInvalidToken.reason, false, "The token in the auth frame is invalid or expired; reconnect only with a new token."},
+	{closeBanned.code, closeBanned.reason, false, "A moderator banned the user."},
+	{websocket.ClosePolicyViolation, "deprovisioned", false, "The user's staff account was deactivated or deleted."},
+}
@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Filter проверяет текст сообщения чата перед сохранением и рассылкой:
+// возвращает текст, который увидят получатели, или false, если сообщение
+// рассылать нельзя.
+type Filter interface {
+	Filter(text string) (string, bool)
+}
+
+// WordFilter заменяет запрещённые слова звёздочками. Слова сравниваются без
+// учёта регистра и только целиком, поэтому запрещённое слово внутри другого
+// слова не задевается.
+type WordFilter struct {
+	words map[string]bool
+}
+
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{words: make(map[string]bool, len(words))}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			f.words[word] = true
+		}
+	}
+	return f
+}
+
+func (f *WordFilter) Filter(text string) (string, bool) {
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		if !isWordRune(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		if f.words[strings.ToLower(string(runes[start:end]))] {
+			for i := start; i < end; i++ {
+				runes[i] = '*'
+			}
+		}
+		start = end
+	}
+	return string(runes), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,64 @@
+package ws
+
+import (
+	"errors"
+	fastws "github.com/fasthttp/websocket"
+	"time"
+)
+
+// FrameLimits ограничивает фреймы, которые присылает одно подключение.
+type FrameLimits struct {
+	// MaxMessageSize — наибольший размер сообщения клиента в байтах; на
+	// сообщение больше сервер закрывает подключение с кодом 1009. 0 — без
+	// ограничения.
+	MaxMessageSize int64
+	// Rate — сколько сообщений в секунду в среднем может присылать
+	// подключение, Burst — сколько подряд сверх среднего. Превысившее
+	// подключение закрывается с кодом 1013. Rate 0 — без ограничения.
+	Rate  float64
+	Burst int
+}
+
+// frameLimiter — корзина токенов одного подключения. Её использует только
+// горутина, читающая соединение, поэтому блокировка не нужна.
+type frameLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newFrameLimiter(limits FrameLimits) *frameLimiter {
+	if limits.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limits.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &frameLimiter{rate: limits.Rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// allow списывает токен за сообщение клиента; false — подключение
+// превысило ограничение. nil-ограничитель пропускает всё.
+func (l *frameLimiter) allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// tooBig сообщает, что чтение прервано сообщением больше MaxMessageSize;
+// фрейм закрытия с кодом 1009 соединение к этому моменту уже отправило.
+func tooBig(err error) bool {
+	return errors.Is(err, fastws.ErrReadLimit)
+}
@@ -0,0 +1,293 @@
+package ws
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"log"
+	"server/internal/events"
+	"server/internal/models"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// productEventTypes — события, которые получает поток продуктов.
+var productEventTypes = []string{
+	events.ProductCreated,
+	events.ProductUpdated,
+	events.ProductDeleted,
+	events.ProductRestored,
+	events.StockChanged,
+}
+
+// ProductStreamOptions настраивает поток событий продуктов.
+type ProductStreamOptions struct {
+	// PingInterval и PongTimeout — как в ChatOptions.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// SendBuffer — сколько событий может ждать отправки одному подключению;
+	// при переполнении события для него отбрасываются. 0 — 64.
+	SendBuffer int
+	// Limits — как в ChatOptions; клиенту потока писать незачем, поэтому
+	// ограничения могут быть строже.
+	Limits FrameLimits
+}
+
+// ProductStream отправляет подключениям к /api/ws/products события
+// создания, изменения, удаления и восстановления продуктов и изменения
+// остатков — из REST и GraphQL одинаково, — чтобы витрины и панели
+// обновляли цены и остатки без опроса. Поток односторонний: фреймы клиента
+// только продлевают срок жизни подключения. Каждое событие — конверт
+// Envelope протокола v2.
+type ProductStream struct {
+	bus         *events.Bus
+	keepalive   keepalive
+	buffer      int
+	limits      FrameLimits
+	connections atomic.Int64
+	// limitDisconnects — подключения, закрытые за превышение limits.
+	limitDisconnects atomic.Int64
+	// stopping — сервер останавливается; stop закрывается тогда же и
+	// завершает pump подключений.
+	stopping atomic.Bool
+	stop     chan struct{}
+}
+
+func NewProductStream(bus *events.Bus, opts ProductStreamOptions) *ProductStream {
+	if opts.SendBuffer <= 0 {
+		opts.SendBuffer = defaultSendBuffer
+	}
+	return &ProductStream{
+		bus:       bus,
+		keepalive: keepalive{ping: opts.PingInterval, pong: opts.PongTimeout},
+		buffer:    opts.SendBuffer,
+		limits:    opts.Limits,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Shutdown останавливает поток: новые подключения получают 503, а
+// подключённые — события, уже ждущие отправки, и фрейм закрытия 1001.
+// Возвращается, когда все подключения закрыты, или с ошибкой ctx.
+func (s *ProductStream) Shutdown(ctx context.Context) error {
+	if !s.stopping.Swap(true) {
+		close(s.stop)
+	}
+	return waitClosed(ctx, &s.connections)
+}
+
+// Connections возвращает число открытых подключений к потоку.
+func (s *ProductStream) Connections() int64 {
+	return s.connections.Load()
+}
+
+// LimitDisconnects возвращает, сколько подключений закрыто за слишком
+// большие или слишком частые фреймы.
+func (s *ProductStream) LimitDisconnects() int64 {
+	return s.limitDisconnects.Load()
+}
+
+// productFilter ограничивает поток продуктами ids и типами событий types;
+// пустые списки не ограничивают.
+type productFilter struct {
+	ids   map[int]bool
+	types map[string]bool
+}
+
+func (f productFilter) matches(event events.Event) bool {
+	if !f.types[event.Type] {
+		return false
+	}
+	if len(f.ids) == 0 {
+		return true
+	}
+	return f.ids[eventProductID(event)]
+}
+
+// eventProductID возвращает ID продукта, к которому относится событие.
+func eventProductID(event events.Event) int {
+	switch data := event.Data.(type) {
+	case models.Product:
+		return data.ID
+	case events.ProductRef:
+		return data.ID
+	case models.StockLevel:
+		return data.ProductID
+	}
+	return 0
+}
+
+// parseProductFilter читает параметры ids и types адреса подключения.
+func parseProductFilter(c *fiber.Ctx) (productFilter, string) {
+	filter := productFilter{ids: map[int]bool{}, types: map[string]bool{}}
+	for _, value := range strings.Split(c.Query("ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return productFilter{}, "ids must be a comma-separated list of product ids"
+		}
+		filter.ids[id] = true
+	}
+	for _, value := range strings.Split(c.Query("types"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if !contains(productEventTypes, value) {
+			return productFilter{}, "types must be among " + strings.Join(productEventTypes, ", ")
+		}
+		filter.types[value] = true
+	}
+	if len(filter.types) == 0 {
+		for _, t := range productEventTypes {
+			filter.types[t] = true
+		}
+	}
+	return filter, ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler обслуживает подключение к потоку. Параметр ids ограничивает поток
+// продуктами с указанными ID, types — типами событий.
+func (s *ProductStream) Handler() fiber.Handler {
+	upgrade := websocket.New(func(c *websocket.Conn) {
+		filter := c.Locals(productFilterLocal).(productFilter)
+		listener, cancel := s.bus.Listen(s.buffer)
+		defer cancel()
+		s.connections.Add(1)
+		defer s.connections.Add(-1)
+
+		// closing не даёт pong продлить срок чтения, который pump обнулил.
+		var closing atomic.Bool
+		alive := func() {
+			if s.keepalive.pong > 0 && !closing.Load() {
+				c.SetReadDeadline(time.Now().Add(s.keepalive.pong))
+			}
+		}
+		if s.keepalive.ping > 0 {
+			alive()
+			c.SetPongHandler(func(string) error {
+				alive()
+				return nil
+			})
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.pump(c, listener, filter, &closing)
+		}()
+		if s.limits.MaxMessageSize > 0 {
+			c.SetReadLimit(s.limits.MaxMessageSize)
+		}
+		limiter := newFrameLimiter(s.limits)
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				if tooBig(err) {
+					s.limitDisconnects.Add(1)
+				}
+				break
+			}
+			if !limiter.allow(time.Now()) {
+				s.limitDisconnects.Add(1)
+				closing.Store(true)
+				c.WriteControl(websocket.CloseMessage, closeRateLimited.message(), time.Now().Add(time.Second))
+				break
+			}
+			if s.keepalive.ping > 0 {
+				alive()
+			}
+		}
+		// Отписка закрывает listener, и pump завершается; обработчик ждёт
+		// его, чтобы не писать в соединение, которое Fiber уже вернул в пул.
+		cancel()
+		<-done
+	})
+	return func(c *fiber.Ctx) error {
+		if s.stopping.Load() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Server is shutting down"})
+		}
+		filter, problem := parseProductFilter(c)
+		if problem != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": problem})
+		}
+		c.Locals(productFilterLocal, filter)
+		return upgrade(c)
+	}
+}
+
+// productFilterLocal — ключ Locals, под которым обработчик передаёт фильтр
+// подключению.
+const productFilterLocal = "ws_product_filter"
+
+// flush отправляет события, которые уже ждут в listener.
+func (s *ProductStream) flush(c *websocket.Conn, listener <-chan events.Event, filter productFilter) {
+	for {
+		select {
+		case event, ok := <-listener:
+			if !ok {
+				return
+			}
+			if !filter.matches(event) {
+				continue
+			}
+			c.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.WriteJSON(Envelope{Type: event.Type, Version: PayloadVersion, ID: event.ID, TS: event.OccurredAt, Payload: event.Data, RequestID: event.RequestID}); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// pump пишет события и пинги в соединение, пока listener не закроется или
+// поток не остановят. После
+// ошибки записи чтение прерывается, чтобы обработчик завершился.
+func (s *ProductStream) pump(c *websocket.Conn, listener <-chan events.Event, filter productFilter, closing *atomic.Bool) {
+	var tick <-chan time.Time
+	if s.keepalive.ping > 0 {
+		ticker := time.NewTicker(s.keepalive.ping)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		var err error
+		select {
+		case event, ok := <-listener:
+			if !ok {
+				return
+			}
+			if !filter.matches(event) {
+				continue
+			}
+			c.SetWriteDeadline(time.Now().Add(writeWait))
+			err = c.WriteJSON(Envelope{Type: event.Type, Version: PayloadVersion, ID: event.ID, TS: event.OccurredAt, Payload: event.Data, RequestID: event.RequestID})
+		case <-tick:
+			err = c.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+		case <-s.stop:
+			s.flush(c, listener, filter)
+			closing.Store(true)
+			c.WriteControl(websocket.CloseMessage, closeShutdown.message(), time.Now().Add(time.Second))
+			c.SetReadDeadline(time.Now())
+			return
+		}
+		if err != nil {
+			log.Printf("Ошибка отправки события продукта WebSocket: %v", err)
+			closing.Store(true)
+			c.SetReadDeadline(time.Now())
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Версии протокола чата. Клиенты без рукопожатия работают по v1: получают
+// только сообщения чата в виде {username, message}. Клиенты v2 получают все
+// сообщения в конвертах Envelope.
+const (
+	ProtocolV1 = 1
+	ProtocolV2 = 2
+)
+
+// supportedVersions — версии протокола в порядке предпочтения сервера.
+var supportedVersions = []int{ProtocolV2, ProtocolV1}
+
+// Типы сообщений протокола.
+const (
+	// TypeHello — рукопожатие клиента: поддерживаемые версии и имя пользователя.
+	TypeHello = "hello"
+	// TypeAuth — токен клиента, подключившегося без токена в адресе.
+	TypeAuth = "auth"
+	// TypeWelcome — ответ сервера на hello с выбранной версией.
+	TypeWelcome       = "welcome"
+	TypeChatMessage   = "chat.message"
+	TypePresenceJoin  = "presence.join"
+	TypePresenceLeave = "presence.leave"
+	// TypeRoomJoin и TypeRoomLeave — вход в комнату и выход из неё; сообщения
+	// чата и presence рассылаются только участникам комнаты.
+	TypeRoomJoin  = "room.join"
+	TypeRoomLeave = "room.leave"
+	// TypeTyping — пользователь начал или перестал набирать сообщение в
+	// комнате; рассылается остальным участникам комнаты и не сохраняется.
+	TypeTyping = "typing"
+	// TypeChatEdit и TypeChatDelete — правка и удаление сообщения чата его
+	// автором или модератором. От клиента приходят с id сообщения, а
+	// сервер рассылает их тем, кто сообщение получил: комнате или
+	// участникам личной переписки.
+	TypeChatEdit   = "chat.edit"
+	TypeChatDelete = "chat.delete"
+	// TypeAck от сервера подтверждает приём конверта клиента с непустым id,
+	// а для сообщения чата ещё и сообщает его id и статус доставки. Клиент
+	// отправляет ack с id полученного сообщения чата, и сервер пересылает
+	// его отправителю со статусом delivered.
+	TypeAck   = "ack"
+	TypeError = "error"
+)
+
+// Статусы сообщения чата в подтверждениях ack.
+const (
+	// AckSent — сервер принял сообщение и разослал его комнате.
+	AckSent = "sent"
+	// AckDelivered — сообщение получил другой участник комнаты или адресат
+	// личного сообщения.
+	AckDelivered = "delivered"
+)
+
+// Envelope — сообщение протокола v2. Сообщениям сервера id присваивается
+// сервером; события продуктов сохраняют ID события из шины. Version —
+// версия схемы полезной нагрузки, как у входящих фреймов.
+type Envelope struct {
+	Type    string      `json:"type"`
+	Version int         `json:"version"`
+	ID      string      `json:"id"`
+	TS      time.Time   `json:"ts"`
+	Payload interface{} `json:"payload,omitempty"`
+	// RequestID — ID API-запроса, вызвавшего событие продукта.
+	RequestID string `json:"request_id,omitempty"`
+	// Room — комната сообщения чата или presence; у событий продуктов пустая.
+	Room string `json:"room,omitempty"`
+	// Replayed — сообщение чата из истории, отправленное при подключении.
+	Replayed bool `json:"replayed,omitempty"`
+	// queued — когда Run положил конверт в очередь клиента; по нему
+	// считается задержка рассылки.
+	queued time.Time
+}
+
+func newEnvelope(messageType string, payload interface{}) Envelope {
+	return Envelope{Type: messageType, Version: PayloadVersion, ID: newID(), TS: time.Now().UTC(), Payload: payload}
+}
+
+type helloPayload struct {
+	Versions []int  `json:"versions"`
+	Username string `json:"username"`
+}
+
+type welcomePayload struct {
+	Version  int   `json:"version"`
+	Versions []int `json:"versions"`
+}
+
+type presencePayload struct {
+	Username string `json:"username"`
+}
+
+type authPayload struct {
+	Token string `json:"token"`
+}
+
+type roomPayload struct {
+	Room string `json:"room"`
+}
+
+// ackPayload — подтверждение. Ref — id подтверждаемого конверта: клиента в
+// ответ на его фрейм, сообщения чата в подтверждении доставки. ID — id,
+// присвоенный сервером сообщению чата.
+type ackPayload struct {
+	Ref    string `json:"ref"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status,omitempty"`
+	// Username — получатель сообщения в подтверждении доставки.
+	Username string `json:"username,omitempty"`
+}
+
+type typingPayload struct {
+	Username string `json:"username"`
+	Active   bool   `json:"active"`
+	// Room — комната во входящем фрейме; пустая — комната из адреса.
+	Room string `json:"room,omitempty"`
+}
+
+// chatEditPayload — правка сообщения: ID — id сообщения чата. EditedBy
+// отличается от Username, если сообщение исправил модератор.
+type chatEditPayload struct {
+	ID       string    `json:"id"`
+	Message  string    `json:"message"`
+	Username string    `json:"username"`
+	EditedBy string    `json:"edited_by"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// chatDeletePayload — удаление сообщения id.
+type chatDeletePayload struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	DeletedBy string `json:"deleted_by"`
+}
+
+// negotiate выбирает наибольшую версию, поддерживаемую обеими сторонами.
+func negotiate(offered []int) (int, bool) {
+	for _, version := range supportedVersions {
+		for _, v := range offered {
+			if v == version {
+				return version, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
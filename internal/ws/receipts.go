@@ -0,0 +1,76 @@
+package ws
+
+import "sync"
+
+// maxTrackedMessages — сколько последних сообщений чата помнят отправителя
+// для подтверждений доставки.
+const maxTrackedMessages = 4096
+
+// receipt — отправитель сообщения чата и подключения, подтвердившие его
+// получение. У личного сообщения комната пустая, а recipient — имя
+// получателя; sender пуст у личных сообщений, повторённых из истории.
+type receipt struct {
+	sender    *client
+	username  string
+	room      string
+	recipient string
+	delivered map[*client]bool
+}
+
+// receipts сопоставляет id конверта chat.message с отправителем, чтобы
+// переслать ему подтверждения доставки. Хранятся только последние
+// maxTrackedMessages сообщений: подтверждение более старого сообщения
+// отбрасывается.
+type receipts struct {
+	mu    sync.Mutex
+	byID  map[string]*receipt
+	order []string
+}
+
+func newReceipts() *receipts {
+	return &receipts{byID: make(map[string]*receipt)}
+}
+
+// add запоминает отправителя и адресата сообщения id. Уже известное
+// сообщение не перезаписывается: личное сообщение, повторённое получателю
+// из истории, сохраняет отправителя, если тот ещё в сети.
+func (r *receipts) add(id string, sender *client, username, room, recipient string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[id]; ok {
+		return
+	}
+	if len(r.order) >= maxTrackedMessages {
+		delete(r.byID, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.byID[id] = &receipt{sender: sender, username: username, room: room, recipient: recipient, delivered: make(map[*client]bool)}
+	r.order = append(r.order, id)
+}
+
+// deliver отмечает, что подключение cl получило сообщение id, и возвращает
+// его отправителя, комнату и адресата; sender пуст, если отправитель
+// отключился. ok == false, если сообщение неизвестно или cl уже
+// подтверждал это сообщение.
+func (r *receipts) deliver(id string, cl *client) (receipt, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.byID[id]
+	if rec == nil || rec.delivered[cl] {
+		return receipt{}, false
+	}
+	rec.delivered[cl] = true
+	return receipt{sender: rec.sender, username: rec.username, room: rec.room, recipient: rec.recipient}, true
+}
+
+// forget забывает отключившегося отправителя и его подтверждения.
+func (r *receipts) forget(cl *client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range r.byID {
+		if rec.sender == cl {
+			rec.sender = nil
+		}
+		delete(rec.delivered, cl)
+	}
+}
@@ -0,0 +1,233 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Коды ошибок во фреймах error.
+const (
+	ErrCodeMalformed      = "malformed_frame"
+	ErrCodeUnknownType    = "unknown_type"
+	ErrCodeUnsupportedVer = "unsupported_version"
+	ErrCodeInvalidPayload = "invalid_payload"
+	ErrCodeNotInRoom      = "not_in_room"
+	ErrCodeRoomLimit      = "room_limit"
+	// ErrCodeUnauthenticated — фрейм до аутентификации или неверный токен.
+	ErrCodeUnauthenticated = "unauthenticated"
+	// ErrCodeUsernameMismatch — имя в сообщении не совпадает с именем из токена.
+	ErrCodeUsernameMismatch = "username_mismatch"
+	// ErrCodeMuted — модератор запретил пользователю писать в чат.
+	ErrCodeMuted = "muted"
+	// ErrCodeForbidden — команда модератора от пользователя без роли
+	// модератора, чужое изображение в сообщении или правка чужого сообщения.
+	ErrCodeForbidden = "forbidden"
+	// ErrCodeFiltered — фильтр отклонил сообщение.
+	ErrCodeFiltered = "filtered"
+)
+
+// FrameError описывает отклонённый входящий фрейм; клиенту он уходит в поле error.
+type FrameError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Field — путь к некорректному полю, если ошибка относится к нему.
+	Field string `json:"field,omitempty"`
+	// Ref — id отклонённого конверта, если клиент его указал.
+	Ref string `json:"ref,omitempty"`
+}
+
+func (e *FrameError) Error() string {
+	return e.Message
+}
+
+// ErrorFrame — ответ клиенту на некорректный фрейм.
+type ErrorFrame struct {
+	Type  string     `json:"type"`
+	Error FrameError `json:"error"`
+}
+
+// inboundFrame — конверт входящего сообщения. ID задаёт клиент, чтобы
+// сопоставить с ним ack или error; Version — версия схемы полезной нагрузки,
+// по умолчанию 1.
+type inboundFrame struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id"`
+	Version int             `json:"version"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Типы значений полей полезной нагрузки.
+const (
+	kindString = iota
+	kindIntList
+	kindBool
+	kindStringList
+)
+
+// fieldRule — ограничение на поле полезной нагрузки.
+type fieldRule struct {
+	Name      string
+	Kind      int
+	Required  bool
+	MaxLength int
+	// MaxItems ограничивает длину списка kindStringList; MaxLength тогда
+	// относится к каждому элементу.
+	MaxItems int
+}
+
+type schemaKey struct {
+	Type    string
+	Version int
+}
+
+// inboundSchemas — схемы полезной нагрузки входящих сообщений по типу и версии.
+// Новые версии добавляются рядом со старыми, чтобы прежние клиенты продолжали работать.
+var inboundSchemas = map[schemaKey][]fieldRule{
+	{TypeHello, 1}: {
+		{Name: "versions", Kind: kindIntList, Required: true},
+		{Name: "username", MaxLength: 64},
+	},
+	{TypeAuth, 1}: {
+		{Name: "token", Required: true, MaxLength: 4096},
+	},
+	// username необязателен: с аутентификацией имя берётся из токена.
+	// Сообщение без текста допустимо, если в нём есть изображения.
+	{TypeChatMessage, 1}: {
+		{Name: "username", MaxLength: 64},
+		{Name: "message", MaxLength: 4000},
+		{Name: "image_ids", Kind: kindStringList, MaxItems: maxMessageImages, MaxLength: 32},
+		{Name: "room", MaxLength: 64},
+		{Name: "to", MaxLength: 64},
+	},
+	{TypeRoomJoin, 1}: {
+		{Name: "room", Required: true, MaxLength: 64},
+	},
+	{TypeRoomLeave, 1}: {
+		{Name: "room", Required: true, MaxLength: 64},
+	},
+	{TypeTyping, 1}: {
+		{Name: "active", Kind: kindBool, Required: true},
+		{Name: "username", MaxLength: 64},
+		{Name: "room", MaxLength: 64},
+	},
+	// id — id сообщения чата из ack отправителю или его конверта.
+	{TypeChatEdit, 1}: {
+		{Name: "id", Required: true, MaxLength: 64},
+		{Name: "message", MaxLength: 4000},
+	},
+	{TypeChatDelete, 1}: {
+		{Name: "id", Required: true, MaxLength: 64},
+	},
+	// ref — id полученного сообщения чата.
+	{TypeAck, 1}: {
+		{Name: "ref", Required: true, MaxLength: 64},
+	},
+}
+
+// decodeFrame проверяет входящий фрейм по схеме. Фрейм без type считается
+// сообщением чата версии 1 в формате протокола v1 {username, message}.
+// Ошибка содержит id конверта, если его удалось прочитать.
+func decodeFrame(data []byte) (inboundFrame, *FrameError) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return inboundFrame{}, &FrameError{Code: ErrCodeMalformed, Message: "frame must be a JSON object"}
+	}
+
+	var frame inboundFrame
+	if err := json.Unmarshal(trimmed, &frame); err != nil {
+		return inboundFrame{}, &FrameError{Code: ErrCodeMalformed, Message: "invalid envelope: " + err.Error()}
+	}
+	if frame.Type == "" {
+		frame = inboundFrame{Type: TypeChatMessage, Version: 1, Payload: trimmed}
+	}
+	if frame.Version == 0 {
+		frame.Version = 1
+	}
+
+	rules, ok := inboundSchemas[schemaKey{frame.Type, frame.Version}]
+	if !ok {
+		if !knownType(frame.Type) {
+			return frame, &FrameError{Code: ErrCodeUnknownType, Message: fmt.Sprintf("unknown message type %q", frame.Type), Field: "type", Ref: frame.ID}
+		}
+		return frame, &FrameError{Code: ErrCodeUnsupportedVer,
+			Message: fmt.Sprintf("version %d of %q is not supported", frame.Version, frame.Type), Field: "version", Ref: frame.ID}
+	}
+	if err := validatePayload(frame.Payload, rules); err != nil {
+		err.Ref = frame.ID
+		return frame, err
+	}
+	return frame, nil
+}
+
+func knownType(messageType string) bool {
+	for key := range inboundSchemas {
+		if key.Type == messageType {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePayload(payload json.RawMessage, rules []fieldRule) *FrameError {
+	var fields map[string]json.RawMessage
+	if len(payload) == 0 || json.Unmarshal(payload, &fields) != nil || fields == nil {
+		return &FrameError{Code: ErrCodeInvalidPayload, Message: "payload must be a JSON object", Field: "payload"}
+	}
+	for _, rule := range rules {
+		raw, present := fields[rule.Name]
+		if !present || string(raw) == "null" {
+			if rule.Required {
+				return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " is required", Field: "payload." + rule.Name}
+			}
+			continue
+		}
+		if rule.Kind == kindIntList {
+			var values []int
+			if err := json.Unmarshal(raw, &values); err != nil {
+				return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " must be an array of integers", Field: "payload." + rule.Name}
+			}
+			if rule.Required && len(values) == 0 {
+				return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " must not be empty", Field: "payload." + rule.Name}
+			}
+			continue
+		}
+		if rule.Kind == kindStringList {
+			var values []string
+			if err := json.Unmarshal(raw, &values); err != nil {
+				return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " must be an array of strings", Field: "payload." + rule.Name}
+			}
+			if rule.MaxItems > 0 && len(values) > rule.MaxItems {
+				return &FrameError{Code: ErrCodeInvalidPayload,
+					Message: fmt.Sprintf("%s must have at most %d items", rule.Name, rule.MaxItems), Field: "payload." + rule.Name}
+			}
+			for i, value := range values {
+				if value == "" || (rule.MaxLength > 0 && utf8.RuneCountInString(value) > rule.MaxLength) {
+					return &FrameError{Code: ErrCodeInvalidPayload,
+						Message: fmt.Sprintf("%s items must be 1-%d characters", rule.Name, rule.MaxLength), Field: fmt.Sprintf("payload.%s[%d]", rule.Name, i)}
+				}
+			}
+			continue
+		}
+		if rule.Kind == kindBool {
+			var value bool
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " must be a boolean", Field: "payload." + rule.Name}
+			}
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " must be a string", Field: "payload." + rule.Name}
+		}
+		if rule.Required && value == "" {
+			return &FrameError{Code: ErrCodeInvalidPayload, Message: rule.Name + " must not be empty", Field: "payload." + rule.Name}
+		}
+		if rule.MaxLength > 0 && utf8.RuneCountInString(value) > rule.MaxLength {
+			return &FrameError{Code: ErrCodeInvalidPayload,
+				Message: fmt.Sprintf("%s must be at most %d characters", rule.Name, rule.MaxLength), Field: "payload." + rule.Name}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package ws
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// waitClosed ждёт, пока connections не станет 0, или отмены ctx.
+func waitClosed(ctx context.Context, connections *atomic.Int64) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for connections.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
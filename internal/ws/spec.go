@@ -0,0 +1,221 @@
+package ws
+
+import (
+	"server/internal/events"
+	"sort"
+)
+
+// PayloadVersion — версия схемы полезной нагрузки сообщений сервера. Она
+// растёт, только когда меняется смысл или тип существующего поля; новые
+// необязательные поля версию не меняют.
+const PayloadVersion = 1
+
+// Protocol — машиночитаемое описание протокола WebSocket: конверт, схемы
+// полезной нагрузки сообщений в обе стороны и коды ошибок.
+type Protocol struct {
+	// Versions — версии протокола, которые клиент может предложить в hello.
+	Versions []int `json:"versions"`
+	// Envelope — JSON Schema конверта входящего сообщения.
+	Envelope map[string]interface{} `json:"envelope"`
+	Inbound  []MessageSpec          `json:"inbound"`
+	// Outbound — сообщения сервера в конвертах Envelope, включая события
+	// потока /api/ws/products.
+	Outbound []MessageSpec `json:"outbound"`
+	Errors   []ErrorSpec   `json:"errors"`
+	// CloseCodes — коды и причины фреймов закрытия, с которыми сервер
+	// отключает клиента.
+	CloseCodes []CloseSpec `json:"close_codes"`
+}
+
+// MessageSpec описывает тип сообщения и JSON Schema его полезной нагрузки.
+type MessageSpec struct {
+	Type        string                 `json:"type" example:"chat.message"`
+	Version     int                    `json:"version" example:"1"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+// ErrorSpec описывает код ошибки во фрейме error.
+type ErrorSpec struct {
+	Code        string `json:"code" example:"invalid_payload"`
+	Description string `json:"description"`
+}
+
+var inboundDescriptions = map[string]string{
+	TypeHello:       "Handshake: the protocol versions the client supports. The server answers with welcome; without a handshake the connection speaks v1.",
+	TypeAuth:        "The token of a client that connected without one. Required before any other frame when the chat needs authentication.",
+	TypeChatMessage: "A chat message to a room, or a direct message when to is set or the text starts with \"@name \". image_ids attaches images uploaded with POST /api/chat/images; a message needs text, images or both. A frame without type is a v1 chat message.",
+	TypeRoomJoin:    "Joins a room; chat messages and presence of the room are delivered to its members only.",
+	TypeRoomLeave:   "Leaves a room.",
+	TypeTyping:      "The user started or stopped typing in a room.",
+	TypeChatEdit:    "Replaces the text of the chat message with id id. Only its author or a moderator can edit a message; requires authentication.",
+	TypeChatDelete:  "Deletes the chat message with id id. Only its author or a moderator can delete a message; requires authentication.",
+	TypeAck:         "Confirms that the client received the chat message with id ref.",
+}
+
+var errorDescriptions = []ErrorSpec{
+	{ErrCodeMalformed, "The frame is not a JSON object or not a text frame."},
+	{ErrCodeUnknownType, "The frame type is not part of the protocol."},
+	{ErrCodeUnsupportedVer, "The frame type has no payload schema of the requested version."},
+	{ErrCodeInvalidPayload, "The payload does not match the schema of its type and version, or the request is not valid."},
+	{ErrCodeNotInRoom, "The client is not a member of the room."},
+	{ErrCodeRoomLimit, "The client has joined too many rooms."},
+	{ErrCodeUnauthenticated, "The client has not authenticated or sent an invalid token."},
+	{ErrCodeUsernameMismatch, "The username in the frame differs from the one in the token."},
+	{ErrCodeMuted, "A moderator muted the user."},
+	{ErrCodeForbidden, "A moderator command from a user without the moderator role, an image uploaded by another user, or a change to another user's message."},
+	{ErrCodeFiltered, "The content filter rejected the message."},
+}
+
+var usernameSchema = map[string]interface{}{"type": "string"}
+
+// attachmentSchema — метаданные изображения сообщения, models.ChatImage.
+var attachmentSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"id", "content_type", "size", "width", "height", "url", "thumbnail_url"},
+	"properties": map[string]interface{}{
+		"id":            map[string]interface{}{"type": "string"},
+		"content_type":  map[string]interface{}{"type": "string"},
+		"size":          map[string]interface{}{"type": "integer"},
+		"width":         map[string]interface{}{"type": "integer"},
+		"height":        map[string]interface{}{"type": "integer"},
+		"url":           map[string]interface{}{"type": "string"},
+		"thumbnail_url": map[string]interface{}{"type": "string"},
+		"uploader":      usernameSchema,
+		"created_at":    map[string]interface{}{"type": "string", "format": "date-time"},
+	},
+}
+
+// outboundSpecs — сообщения чата от сервера; события продуктов берутся из
+// каталога событий.
+var outboundSpecs = []MessageSpec{
+	{Type: TypeWelcome, Description: "The answer to hello with the negotiated version.", Schema: objectSchema([]string{"version", "versions"}, map[string]interface{}{
+		"version":  map[string]interface{}{"type": "integer"},
+		"versions": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+	})},
+	{Type: TypeChatMessage, Description: "A chat message with the metadata of its images in attachments; replayed is set on the envelope for history sent on connect.", Schema: objectSchema([]string{"username", "message"}, map[string]interface{}{
+		"username":    usernameSchema,
+		"message":     map[string]interface{}{"type": "string"},
+		"room":        map[string]interface{}{"type": "string"},
+		"to":          map[string]interface{}{"type": "string"},
+		"attachments": map[string]interface{}{"type": "array", "items": attachmentSchema},
+		"edited_at":   map[string]interface{}{"type": "string", "format": "date-time"},
+	})},
+	{Type: TypeChatEdit, Description: "A chat message was edited; sent to the room of the envelope or to both sides of a direct message.", Schema: objectSchema([]string{"id", "message", "username", "edited_by", "edited_at"}, map[string]interface{}{
+		"id":        map[string]interface{}{"type": "string"},
+		"message":   map[string]interface{}{"type": "string"},
+		"username":  usernameSchema,
+		"edited_by": usernameSchema,
+		"edited_at": map[string]interface{}{"type": "string", "format": "date-time"},
+	})},
+	{Type: TypeChatDelete, Description: "A chat message was deleted; sent like chat.edit.", Schema: objectSchema([]string{"id", "username", "deleted_by"}, map[string]interface{}{
+		"id":         map[string]interface{}{"type": "string"},
+		"username":   usernameSchema,
+		"deleted_by": usernameSchema,
+	})},
+	{Type: TypePresenceJoin, Description: "A user joined the room of the envelope.", Schema: objectSchema([]string{"username"}, map[string]interface{}{"username": usernameSchema})},
+	{Type: TypePresenceLeave, Description: "A user left the room of the envelope.", Schema: objectSchema([]string{"username"}, map[string]interface{}{"username": usernameSchema})},
+	{Type: TypeTyping, Description: "A user started or stopped typing in the room of the envelope.", Schema: objectSchema([]string{"username", "active"}, map[string]interface{}{
+		"username": usernameSchema,
+		"active":   map[string]interface{}{"type": "boolean"},
+	})},
+	{Type: TypeAck, Description: "Confirms the client frame with id ref; for a chat message also its server id and delivery status.", Schema: objectSchema([]string{"ref"}, map[string]interface{}{
+		"ref":      map[string]interface{}{"type": "string"},
+		"id":       map[string]interface{}{"type": "string"},
+		"status":   map[string]interface{}{"type": "string", "enum": []string{AckSent, AckDelivered}},
+		"username": usernameSchema,
+	})},
+	{Type: TypeError, Description: "The client frame was rejected; the connection stays open. v1 clients receive {type, error} without an envelope.", Schema: objectSchema([]string{"code", "message"}, map[string]interface{}{
+		"code":    map[string]interface{}{"type": "string"},
+		"message": map[string]interface{}{"type": "string"},
+		"field":   map[string]interface{}{"type": "string"},
+		"ref":     map[string]interface{}{"type": "string"},
+	})},
+}
+
+// Spec возвращает описание протокола. Схемы входящих сообщений строятся из
+// тех же правил, по которым сервер проверяет фреймы.
+func Spec() Protocol {
+	protocol := Protocol{
+		Versions: supportedVersions,
+		Envelope: objectSchema([]string{"type"}, map[string]interface{}{
+			"type":    map[string]interface{}{"type": "string"},
+			"id":      map[string]interface{}{"type": "string", "maxLength": 64},
+			"version": map[string]interface{}{"type": "integer", "minimum": 1, "default": 1},
+			"payload": map[string]interface{}{"type": "object"},
+		}),
+		Errors:     errorDescriptions,
+		CloseCodes: closeDescriptions,
+	}
+	for key, rules := range inboundSchemas {
+		protocol.Inbound = append(protocol.Inbound, MessageSpec{
+			Type: key.Type, Version: key.Version, Description: inboundDescriptions[key.Type], Schema: rulesSchema(rules),
+		})
+	}
+	sort.Slice(protocol.Inbound, func(i, j int) bool {
+		a, b := protocol.Inbound[i], protocol.Inbound[j]
+		return a.Type < b.Type || (a.Type == b.Type && a.Version < b.Version)
+	})
+	for _, spec := range outboundSpecs {
+		spec.Version = PayloadVersion
+		protocol.Outbound = append(protocol.Outbound, spec)
+	}
+	for _, definition := range events.Catalog {
+		if definition.Type == events.SLOBurnRate {
+			continue
+		}
+		protocol.Outbound = append(protocol.Outbound, MessageSpec{
+			Type: definition.Type, Version: PayloadVersion, Description: definition.Description, Schema: definition.Schema,
+		})
+	}
+	return protocol
+}
+
+// rulesSchema переводит правила проверки полезной нагрузки в JSON Schema.
+func rulesSchema(rules []fieldRule) map[string]interface{} {
+	properties := make(map[string]interface{}, len(rules))
+	required := []string{}
+	for _, rule := range rules {
+		var property map[string]interface{}
+		switch rule.Kind {
+		case kindIntList:
+			property = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}}
+			if rule.Required {
+				property["minItems"] = 1
+			}
+		case kindStringList:
+			items := map[string]interface{}{"type": "string", "minLength": 1}
+			if rule.MaxLength > 0 {
+				items["maxLength"] = rule.MaxLength
+			}
+			property = map[string]interface{}{"type": "array", "items": items}
+			if rule.MaxItems > 0 {
+				property["maxItems"] = rule.MaxItems
+			}
+		case kindBool:
+			property = map[string]interface{}{"type": "boolean"}
+		default:
+			property = map[string]interface{}{"type": "string"}
+			if rule.Required {
+				property["minLength"] = 1
+			}
+			if rule.MaxLength > 0 {
+				property["maxLength"] = rule.MaxLength
+			}
+		}
+		properties[rule.Name] = property
+		if rule.Required {
+			required = append(required, rule.Name)
+		}
+	}
+	return objectSchema(required, properties)
+}
+
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"type":       "object",
+		"required":   required,
+		"properties": properties,
+	}
+}
@@ -0,0 +1,119 @@
+package ws
+
+import (
+	"sync"
+	"time"
+)
+
+// statsWindow — за сколько последних секунд усредняются MessagesPerSecond
+// и задержка рассылки.
+const statsWindow = 60
+
+// window копит наблюдения по секундам за последние statsWindow секунд.
+type window struct {
+	mu      sync.Mutex
+	buckets [statsWindow]windowBucket
+}
+
+type windowBucket struct {
+	// second — к какой секунде Unix относится корзина.
+	second int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// observe учитывает событие в момент now; value — его длительность, если
+// она нужна.
+func (w *window) observe(now time.Time, value time.Duration) {
+	second := now.Unix()
+	b := &w.buckets[second%statsWindow]
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if b.second != second {
+		*b = windowBucket{second: second}
+	}
+	b.count++
+	b.sum += value
+	if value > b.max {
+		b.max = value
+	}
+}
+
+// summary возвращает число событий, среднюю и наибольшую длительность за
+// последние statsWindow секунд, не считая текущей.
+func (w *window) summary(now time.Time) (count int64, avg, max time.Duration) {
+	second := now.Unix()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var sum time.Duration
+	for _, b := range w.buckets {
+		if age := second - b.second; age > 0 && age <= statsWindow {
+			count += b.count
+			sum += b.sum
+			if b.max > max {
+				max = b.max
+			}
+		}
+	}
+	if count > 0 {
+		avg = sum / time.Duration(count)
+	}
+	return count, avg, max
+}
+
+// Stats — состояние чата на этом экземпляре для мониторинга. Счётчики
+// растут с запуска сервера.
+type Stats struct {
+	// Connections — открытые подключения, ConnectionsOpened — все
+	// подключения с запуска: по скорости его роста видны всплески
+	// переподключений.
+	Connections       int64 `json:"connections" example:"120"`
+	ConnectionsOpened int64 `json:"connections_opened" example:"5400"`
+	// Rooms — комнаты, в которых есть хотя бы одно подключение.
+	Rooms int `json:"rooms" example:"8"`
+	// Messages — разосланные сообщения чата, включая личные;
+	// MessagesPerSecond — их среднее за последнюю минуту.
+	Messages          int64   `json:"messages" example:"90210"`
+	MessagesPerSecond float64 `json:"messages_per_second" example:"3.5"`
+	// DroppedEvents — события продуктов, не попавшие в переполненные
+	// очереди клиентов; DroppedEphemeral — так же отброшенные индикаторы
+	// набора и подтверждения доставки; DroppedBroadcasts — события, которые
+	// не попали в переполненную очередь рассылки и не достались никому.
+	DroppedEvents     int64 `json:"dropped_events"`
+	DroppedEphemeral  int64 `json:"dropped_ephemeral"`
+	DroppedBroadcasts int64 `json:"dropped_broadcasts"`
+	// SlowDisconnects и LimitDisconnects — клиенты, отключённые из-за
+	// переполнения очереди и за превышение ограничений на фреймы.
+	SlowDisconnects  int64 `json:"slow_disconnects"`
+	LimitDisconnects int64 `json:"limit_disconnects"`
+	// FanoutLatencyAvg и FanoutLatencyMax — средняя и наибольшая за
+	// последнюю минуту задержка рассылки в секундах: от того, как Run
+	// положил конверт в очередь клиента, до записи в его соединение.
+	FanoutLatencyAvg float64 `json:"fanout_latency_avg_seconds" example:"0.002"`
+	FanoutLatencyMax float64 `json:"fanout_latency_max_seconds" example:"0.05"`
+}
+
+// Stats возвращает текущее состояние чата.
+func (ch *Chat) Stats() Stats {
+	ch.mu.RLock()
+	rooms := len(ch.rooms)
+	ch.mu.RUnlock()
+	now := time.Now()
+	messages, _, _ := ch.messageRate.summary(now)
+	_, latencyAvg, latencyMax := ch.fanoutLatency.summary(now)
+	return Stats{
+		Connections:       ch.connections.Load(),
+		ConnectionsOpened: ch.connectionsOpened.Load(),
+		Rooms:             rooms,
+		Messages:          ch.messages.Load(),
+		MessagesPerSecond: float64(messages) / statsWindow,
+		DroppedEvents:     ch.droppedEvents.Load(),
+		DroppedEphemeral:  ch.droppedEphemeral.Load(),
+		DroppedBroadcasts: ch.droppedBroadcasts.Load(),
+		SlowDisconnects:   ch.slowDisconnects.Load(),
+		LimitDisconnects:  ch.limitDisconnects.Load(),
+		FanoutLatencyAvg:  latencyAvg.Seconds(),
+		FanoutLatencyMax:  latencyMax.Seconds(),
+	}
+}
@@ -1,295 +1,515 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
-	"github.com/gofiber/adaptor/v2"
+	"context"
+	_ "embed"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/swagger"
-	"github.com/gofiber/websocket/v2"
-	"github.com/graphql-go/graphql"
-	"github.com/graphql-go/handler"
-	"github.com/joho/godotenv"
-	"github.com/lib/pq"
 	"log"
 	"os"
-	_ "server/docs"
+	"os/signal"
+	"server/docs"
+	"server/internal/auth"
+	"server/internal/config"
+	"server/internal/db"
+	"server/internal/events"
+	"server/internal/graphql"
+	"server/internal/handlers"
+	"server/internal/imgproxy"
+	"server/internal/jobs"
+	"server/internal/jsonnaming"
+	"server/internal/metrics"
+	"server/internal/service"
+	"server/internal/slo"
+	"server/internal/storage"
+	"server/internal/ws"
+	"sync"
+	"syscall"
 	"time"
+	// Базу часовых поясов для заголовка Time-Zone встраиваем в бинарник:
+	// в контейнере её может не быть.
+	_ "time/tzdata"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
-var db *sql.DB
-
-func initDB() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Не найден файл .env, используются значения по умолчанию")
-	}
-
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
-
-	db, err = sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatal(err)
-	}
+// apiChangelog — записи журнала изменений, которые касаются всего API, а
+// не отдельного маршрута; изменения маршрутов описываются в их
+// аннотациях @x-changelog.
+//
+//go:embed changelog.json
+var apiChangelog []byte
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(time.Hour)
+// @title TEST API
+// @version 1.1
+// @description Поля JSON в примерах — в snake_case; с JSON_FIELD_NAMING=camelCase REST отвечает в camelCase и принимает оба стиля. Заданная явно, настройка переводит в тот же стиль и ключи ответов GraphQL (кроме начинающихся с _); без неё GraphQL отвечает ключами по схеме в camelCase.
+// @description Частота запросов REST ограничена: сверх бюджета ответ — 429 с Retry-After, остаток бюджета — в заголовках X-RateLimit-Limit, X-RateLimit-Remaining и X-RateLimit-Reset.
+// @BasePath /
+func main() {
+	cfg := config.Load()
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS products (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(255) NOT NULL,
-			price DECIMAL(10, 2) NOT NULL,
-			description TEXT,
-			categories TEXT[]
-		);
-	`)
+	database, err := db.Open(cfg.DB)
 	if err != nil {
 		log.Fatal(err)
 	}
-}
+	defer database.Close()
 
-type Product struct {
-	ID          int      `json:"id"`
-	Name        string   `json:"name"`
-	Price       float64  `json:"price"`
-	Description string   `json:"description"`
-	Categories  []string `json:"categories"`
-}
-
-// @Summary Получение списка всех продуктов
-// @Tags Products
-// @Accept json
-// @Produce json
-// @Success 200 {array} Product "Успешный ответ"
-// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
-// @Router /api/products [get]
-func getProducts(c *fiber.Ctx) error {
-	rows, err := db.Query("SELECT id, name, price, description, categories FROM products")
+	replicas, err := db.OpenReplicas(cfg.DB, database)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+		log.Fatalf("Не удалось подключиться к репликам: %v", err)
 	}
-	defer rows.Close()
-
-	var products []Product
-	for rows.Next() {
-		var product Product
-		if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Description, pq.Array(&product.Categories)); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+	defer replicas.Close()
+	go replicas.Run(cfg.DB.ReplicaProbeInterval)
+
+	var imageURLs *service.ImageURLs
+	if cfg.Imgproxy.URL != "" {
+		signer, err := imgproxy.NewSigner(imgproxy.Config{
+			BaseURL:       cfg.Imgproxy.URL,
+			Key:           cfg.Imgproxy.Key,
+			Salt:          cfg.Imgproxy.Salt,
+			SignatureSize: int(cfg.Imgproxy.SignatureSize),
+			Formats:       cfg.Imgproxy.Formats,
+		})
+		if err != nil {
+			log.Fatalf("Некорректная настройка imgproxy: %v", err)
 		}
-		products = append(products, product)
+		imageURLs = service.NewImageURLs(signer, cfg.Imgproxy.SourceBase)
 	}
 
-	if err := rows.Err(); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+	bus := events.NewBus()
+	productService := service.NewProductService(database, service.Options{
+		CacheTTL:         cfg.ProductCacheTTL,
+		DefaultLocale:    cfg.DefaultLocale,
+		TrashRetention:   cfg.TrashRetention,
+		Events:           bus,
+		Reads:            replicas.Reader,
+		Images:           imageURLs,
+		Currency:         cfg.Currency,
+		ProductURL:       cfg.ProductURL,
+		SuggestBelow:     int(cfg.SearchSuggestBelow),
+		MaxListLimit:     int(cfg.ListMaxLimit),
+		UnboundedListMax: int(cfg.ListUnboundedMax),
+	})
+	settingsService := service.NewSettingsService(database, cfg.Currency, cfg.DefaultLocale)
+	moderation := service.NewChatModeration(database)
+	if err := moderation.Reload(context.Background()); err != nil {
+		log.Printf("Не удалось загрузить ограничения чата: %v", err)
 	}
-
-	return c.JSON(products)
-}
-
-// @Summary Добавить один или несколько продуктов
-// @Tags Products
-// @Accept json
-// @Produce json
-// @Param products body []Product true "Данные продуктов"
-// @Success 200 {array} Product "Продукты успешно добавлены"
-// @Failure 400 {object} ErrorResponse "Некорректный запрос"
-// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
-// @Router /api/products [post]
-func addProducts(c *fiber.Ctx) error {
-	var products []Product
-
-	if err := c.BodyParser(&products); err != nil {
-		var singleProduct Product
-		if err := c.BodyParser(&singleProduct); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request"})
-		}
-		products = append(products, singleProduct)
+	staff := service.NewStaffDirectory(database)
+	if err := staff.Reload(context.Background()); err != nil {
+		log.Printf("Не удалось загрузить каталог сотрудников: %v", err)
 	}
-
-	query := "INSERT INTO products (name, price, description, categories) VALUES ($1, $2, $3, $4) RETURNING id"
-
-	for i := range products {
-		err := db.QueryRow(query, products[i].Name, products[i].Price, products[i].Description, pq.Array(products[i].Categories)).Scan(&products[i].ID)
+	if cfg.CacheBackplane {
+		// Кэши каталога, настроек, ограничений чата и каталога сотрудников у
+		// каждого экземпляра свои: об изменениях экземпляры сообщают друг другу.
+		backplane, err := db.OpenBackplane(cfg.DB, database)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+			log.Fatalf("Не удалось подключить backplane: %v", err)
 		}
+		defer backplane.Close()
+		go backplane.Run()
+		productService.OnLocalChange(func() { backplane.Publish(service.CatalogTopic) })
+		backplane.Subscribe(service.CatalogTopic, productService.DropCaches)
+		settingsService.OnLocalChange(func() { backplane.Publish(service.SettingsTopic) })
+		backplane.Subscribe(service.SettingsTopic, settingsService.DropCache)
+		moderation.OnLocalChange(func() { backplane.Publish(service.ModerationTopic) })
+		backplane.Subscribe(service.ModerationTopic, moderation.DropCache)
+		staff.OnLocalChange(func() { backplane.Publish(service.StaffTopic) })
+		backplane.Subscribe(service.StaffTopic, staff.DropCache)
 	}
-
-	return c.JSON(products)
-}
-
-// @Summary Обновить данные продукта
-// @Tags Products
-// @Accept json
-// @Produce json
-// @Param id path int true "ID продукта"
-// @Param product body Product true "Данные продукта"
-// @Success 200 {object} map[string]string "Продукт успешно обновлен"
-// @Failure 400 {object} ErrorResponse "Некорректный запрос"
-// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
-// @Router /api/products/{id} [put]
-func updateProduct(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var product Product
-	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request"})
+	experimentService := service.NewExperimentService(database)
+	eventLog := service.NewEventLog(database)
+	bus.Subscribe(eventLog.Record)
+	dlq := service.NewDeadLetterQueue(database)
+	webhookService := service.NewWebhookService(database, cfg.WebhookTimeout, dlq)
+	dlq.RegisterRetrier(service.DeadLetterWebhook, webhookService.RetryDeadLetter)
+	bus.Subscribe(webhookService.Enqueue)
+	go webhookService.Run()
+
+	attachmentStore, err := storage.NewLocal(cfg.AttachmentsDir)
+	if err != nil {
+		log.Fatalf("Не удалось подготовить каталог вложений: %v", err)
 	}
+	attachmentService := service.NewAttachmentService(database, attachmentStore, productService, service.AttachmentOptions{
+		MaxSize:      cfg.AttachmentMaxSize,
+		AllowedTypes: cfg.AttachmentTypes,
+	})
+	importService := service.NewImportService(database, productService, attachmentService, service.ImportOptions{
+		MaxImageSize: cfg.ImageImportMaxSize,
+		MaxImageSide: int(cfg.ImageImportMaxSide),
+		Timeout:      cfg.ImageImportTimeout,
+	})
 
-	query := "UPDATE products SET name=$1, price=$2, description=$3, categories=$4 WHERE id=$5"
-	_, err := db.Exec(query, product.Name, product.Price, product.Description, pq.Array(product.Categories), id)
+	schema, err := graphql.NewSchema(productService, attachmentService, bus)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+		log.Fatalf("Не удалось создать схему GraphQL: %v", err)
 	}
-	return c.JSON(fiber.Map{"message": "Product updated successfully"})
-}
-
-// @Summary Удалить продукт
-// @Tags Products
-// @Accept json
-// @Produce json
-// @Param id path int true "ID продукта"
-// @Success 200 {object} map[string]string "Продукт успешно удален"
-// @Failure 500 {object} ErrorResponse "Ошибка на сервере"
-// @Router /api/products/{id} [delete]
-func deleteProduct(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := "DELETE FROM products WHERE id=$1"
-	_, err := db.Exec(query, id)
+	usage := graphql.NewUsage(&schema)
+	persisted, err := graphql.NewPersistedQueries(graphql.PersistedOptions{
+		CacheSize:     int(cfg.GraphQLAPQCacheSize),
+		ManifestPath:  cfg.GraphQLPersistedQueries,
+		AllowlistOnly: cfg.GraphQLPersistedOnly,
+	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: err.Error()})
+		log.Fatalf("Не удалось загрузить persisted queries: %v", err)
 	}
-	return c.JSON(fiber.Map{"message": "Product deleted successfully"})
-}
 
-var productType = graphql.NewObject(
-	graphql.ObjectConfig{
-		Name: "Product",
-		Fields: graphql.Fields{
-			"id":          &graphql.Field{Type: graphql.Int},
-			"name":        &graphql.Field{Type: graphql.String},
-			"price":       &graphql.Field{Type: graphql.Float},
-			"description": &graphql.Field{Type: graphql.String},
-			"categories":  &graphql.Field{Type: graphql.NewList(graphql.String)},
-		},
-	},
-)
-
-func createSchema() graphql.Schema {
-	rootQuery := graphql.NewObject(graphql.ObjectConfig{
-		Name: "Query",
-		Fields: graphql.Fields{
-			"products": &graphql.Field{
-				Type: graphql.NewList(productType),
-				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
-					rows, err := db.Query("SELECT id, name, price, description, categories FROM products")
-					if err != nil {
-						return nil, err
-					}
-					defer rows.Close()
-
-					var products []Product
-					for rows.Next() {
-						var product Product
-						if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Description, pq.Array(&product.Categories)); err != nil {
-							return nil, err
-						}
-						products = append(products, product)
-					}
-					return products, nil
-				},
-			},
+	objectives := make([]slo.Objective, len(cfg.SLOs))
+	for i, o := range cfg.SLOs {
+		objectives[i] = slo.Objective{Route: o.Route, Latency: o.Latency, Target: o.Target}
+	}
+	tracker := slo.NewTracker(objectives, slo.Options{
+		Window:   cfg.SLOWindow,
+		FastBurn: cfg.SLOFastBurn,
+		// Тревоги не публикуются в шину, чтобы не попасть клиентам WebSocket.
+		Notify: func(alert slo.Alert) {
+			event := events.New(events.SLOBurnRate, alert)
+			eventLog.Record(event)
+			webhookService.Enqueue(event)
 		},
 	})
 
-	schema, err := graphql.NewSchema(graphql.SchemaConfig{
-		Query: rootQuery,
+	apiJWT := auth.JWTOptions{Secret: cfg.APIJWTSecret, Issuer: "server-api"}
+	// apiUsers проверяет JWT пользователей API с учётом отозванных токенов.
+	var userService *service.UserService
+	var apiUsers auth.Authenticator
+	if cfg.APIJWTSecret != "" {
+		userService = service.NewUserService(database, auth.NewJWTSigner(apiJWT),
+			service.UserOptions{AccessTTL: cfg.APITokenTTL, RefreshTTL: cfg.APIRefreshTTL})
+		apiUsers = auth.NewRevocable(auth.NewJWTVerifier(apiJWT), userService)
+	}
+	var chatAuths auth.Chain
+	if cfg.JWTSecret != "" {
+		// Роли и отключение сотрудников, заведённых через SCIM, важнее токена.
+		chatAuths = append(chatAuths, auth.NewProvisioned(
+			auth.NewJWTVerifier(auth.JWTOptions{Secret: cfg.JWTSecret, Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience}), staff))
+	}
+	if cfg.APIJWTSecret != "" {
+		// Пользователи API пишут в чат под именем своей учётной записи, а не
+		// под тем, которое прислал клиент. Имя получает префикс api:, чтобы
+		// зарегистрироваться под именем сотрудника и читать его личные
+		// сообщения было нельзя.
+		chatAuths = append(chatAuths, auth.NewNamespaced(apiUsers, "api:"))
+	}
+	var chatAuth auth.Authenticator
+	if len(chatAuths) > 0 {
+		chatAuth = chatAuths
+	} else {
+		log.Println("JWT_SECRET и API_JWT_SECRET не заданы: чат WebSocket работает без аутентификации")
+	}
+	chatHistory := service.NewChatHistory(database)
+	chatImageStore, err := storage.NewLocal(cfg.ChatImagesDir)
+	if err != nil {
+		log.Fatalf("Не удалось подготовить каталог изображений чата: %v", err)
+	}
+	chatImages := service.NewChatImageService(database, chatImageStore, service.ChatImageOptions{
+		MaxSize:       cfg.ChatImageMaxSize,
+		MaxSide:       int(cfg.ChatImageMaxSide),
+		ThumbnailSide: int(cfg.ChatImageThumbnailSide),
+		AllowedTypes:  cfg.ChatImageTypes,
+	})
+	var chatFilter ws.Filter
+	if len(cfg.ChatBannedWords) > 0 {
+		chatFilter = ws.NewWordFilter(cfg.ChatBannedWords)
+	}
+	wsLimits := ws.FrameLimits{MaxMessageSize: cfg.WSMaxMessageSize, Rate: cfg.WSMessageRate, Burst: int(cfg.WSMessageBurst)}
+	chat := ws.NewChat(ws.ChatOptions{
+		Auth:         chatAuth,
+		History:      chatHistory,
+		Replay:       int(cfg.ChatReplayMessages),
+		PingInterval: cfg.WSPingInterval,
+		PongTimeout:  cfg.WSPongTimeout,
+		IdleTimeout:  cfg.WSIdleTimeout,
+		SendBuffer:   int(cfg.WSSendBuffer),
+		Limits:       wsLimits,
+		Moderation:   moderation,
+		Filter:       chatFilter,
+		Images:       chatImages,
+	})
+	go chat.Run()
+	bus.Subscribe(chat.PublishEvent)
+	productStream := ws.NewProductStream(bus, ws.ProductStreamOptions{
+		PingInterval: cfg.WSPingInterval,
+		PongTimeout:  cfg.WSPongTimeout,
+		SendBuffer:   int(cfg.WSSendBuffer),
+		Limits:       wsLimits,
 	})
+	feed := events.NewFeed(int(cfg.EventPollBuffer))
+	bus.Subscribe(feed.Publish)
+	runner := jobs.NewRunner(dlq)
+	runner.Every(jobs.NewPriceScheduler(productService), cfg.PriceScheduleInterval)
+	runner.Every(jobs.NewTrashSweeper(productService, attachmentService), cfg.TrashSweepInterval)
+	runner.Every(jobs.NewImageImporter(importService), cfg.ImageImportInterval)
+	runner.Every(jobs.NewSearchIndexer(productService), cfg.SearchIndexInterval)
+	runner.Every(jobs.NewSearchVocabulary(productService), cfg.SearchVocabularyInterval)
+	runner.Every(jobs.NewExposureFlusher(experimentService), cfg.ExperimentFlushInterval)
+	runner.Every(jobs.NewSLOChecker(tracker), cfg.SLOCheckInterval)
+	dlq.RegisterRetrier(service.DeadLetterJob, runner.Retry)
+
+	naming, err := jsonnaming.Parse(cfg.JSONFieldNaming)
 	if err != nil {
-		log.Fatalf("Не удалось создать схему GraphQL: %v", err)
+		log.Fatalf("Некорректная настройка JSON_FIELD_NAMING: %v", err)
 	}
-	return schema
-}
-
-type Message struct {
-	Username string `json:"username"`
-	Message  string `json:"message"`
-}
-
-var clients = make(map[*websocket.Conn]bool)
-var broadcast = make(chan Message)
+	// Все JSON-ответы и тела запросов REST проходят через один кодек.
+	codec := jsonnaming.New(naming)
+	app := fiber.New(fiber.Config{
+		// Запас сверх размера вложения или изображения чата на служебные
+		// части multipart-запроса.
+		BodyLimit:   int(max(cfg.AttachmentMaxSize, cfg.ChatImageMaxSize)) + 1<<20,
+		JSONEncoder: codec.Marshal,
+		JSONDecoder: codec.Unmarshal,
+	})
 
-func handleMessages() {
-	for {
-		msg := <-broadcast
-		for client := range clients {
-			if err := client.WriteJSON(msg); err != nil {
-				log.Printf("Ошибка отправки сообщения WebSocket: %v", err)
-				client.Close()
-				delete(clients, client)
-			}
-		}
+	app.Use(handlers.RequestID())
+	app.Use(tracker.Middleware())
+	corsPolicy, err := handlers.CORS(cfg.CORSAllowedOrigins)
+	if err != nil {
+		log.Fatalf("Некорректная настройка CORS_ALLOWED_ORIGINS: %v", err)
 	}
-}
-
-// @title TEST API
-// @version 1.0
-// @BasePath /
-func main() {
-	initDB()
-	defer db.Close()
-
-	app := fiber.New()
-
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE",
-		AllowHeaders: "Origin, Content-Type, Accept",
+	app.Use(corsPolicy)
+	adminAuth := auth.NewStaticTokens(cfg.AdminTokens)
+	// apiAuth проверяет токены REST и GraphQL: административные и, если
+	// включён вход по паролю, JWT пользователей API.
+	var apiAuth auth.Authenticator = adminAuth
+	if userService != nil {
+		apiAuth = auth.Chain{adminAuth, apiUsers}
+	} else {
+		log.Println("API_JWT_SECRET не задан: вход по паролю выключен, запись продуктов — по токенам администратора и ключам API")
+	}
+	apiKeys := service.NewAPIKeyService(database)
+	app.Use("/api", handlers.Authenticate(apiAuth))
+	app.Use("/api", handlers.AuthenticateAPIKey(apiKeys))
+	if userService != nil && cfg.AuthCookies {
+		// Токены в cookie — для браузерного приложения администратора.
+		app.Use("/api", handlers.CookieSessions(apiUsers))
+	}
+	app.Use("/api", handlers.RateLimit(handlers.RateLimits{
+		Read: int(cfg.RateLimitRead), Write: int(cfg.RateLimitWrite), Auth: int(cfg.RateLimitAuth), Window: cfg.RateLimitWindow,
 	}))
+	protectProductWrites(app)
+	// У SCIM свои токены: провайдеру удостоверений не нужен доступ к API
+	// администратора.
+	app.Use("/scim", handlers.Authenticate(auth.NewStaticTokens(cfg.SCIMTokens)))
+	app.Use("/api", handlers.StrictBodies(codec, cfg.StrictJSON))
+	app.Use("/api", handlers.RedactFields(codec))
+	// После RedactFields: в журнал попадает тело запроса без полей, которые
+	// вызывающему менять нельзя и которые сервис не применит.
+	auditService := service.NewAuditService(database)
+	app.Use("/api", handlers.Audit(auditService))
+	experimentHandler := handlers.NewExperimentHandler(experimentService)
+	app.Use("/api", experimentHandler.Experiments())
 
 	app.Static("/", "./public")
 
-	app.Get("/api/products", getProducts)
-	app.Post("/api/products", addProducts)
-	app.Put("/api/products/:id", updateProduct)
-	app.Delete("/api/products/:id", deleteProduct)
-	app.Get("/health", func(c *fiber.Ctx) error { return c.SendString("hello") })
-
-	schema := createSchema()
-	graphqlHandler := handler.New(&handler.Config{
-		Schema: &schema,
-		Pretty: true,
-	})
-	app.All("/api/graphql", adaptor.HTTPHandler(graphqlHandler))
-
-	go handleMessages()
-
-	app.Get("/api/ws", websocket.New(func(c *websocket.Conn) {
-		clients[c] = true
-		defer func() {
-			delete(clients, c)
-			c.Close()
-		}()
-		for {
-			var msg Message
-			if err := c.ReadJSON(&msg); err != nil {
-				log.Printf("Ошибка WebSocket: %v", err)
-				break
+	// Двойной клик по кнопке создания не должен создавать два продукта.
+	app.Post("/api/products", handlers.Deduplicate(cfg.DedupWindow))
+
+	handlers.NewProductHandler(productService).Register(app)
+	handlers.NewAttachmentHandler(attachmentService).Register(app)
+	handlers.NewImportHandler(importService).Register(app)
+	handlers.NewSearchHandler(productService).Register(app)
+	handlers.NewWebhookHandler(webhookService).Register(app)
+	handlers.NewEventHandler(eventLog, webhookService, feed).Register(app)
+	handlers.NewDeadLetterHandler(dlq).Register(app)
+	handlers.NewGraphQLUsageHandler(usage).Register(app)
+	experimentHandler.Register(app)
+	handlers.NewSLOHandler(tracker).Register(app)
+	handlers.NewChatHandler(chatHistory, chat, chatAuth).Register(app)
+	handlers.NewChatImageHandler(chatImages, chatAuth).Register(app)
+	handlers.NewModerationHandler(moderation, chat).Register(app)
+	handlers.NewSCIMHandler(staff, chat).Register(app)
+	handlers.NewListingHandler(productService).Register(app)
+	handlers.NewSettingsHandler(settingsService).Register(app)
+	handlers.NewEmbedHandler(productService, cfg.EmbedFrameAncestors).Register(app)
+	if userService != nil {
+		handlers.NewUserHandler(userService, handlers.UserHandlerOptions{
+			Registration: cfg.APIRegistration, Cookies: cfg.AuthCookies,
+		}).Register(app)
+		if len(cfg.OAuthProviders) > 0 {
+			credentials := map[string]service.OAuthCredentials{}
+			for _, provider := range cfg.OAuthProviders {
+				credentials[provider.Name] = service.OAuthCredentials{ClientID: provider.ClientID, ClientSecret: provider.ClientSecret}
 			}
-			broadcast <- msg
+			oauthService, err := service.NewOAuthService(userService, credentials, cfg.APIRegistration)
+			if err != nil {
+				log.Fatalf("Некорректный OAUTH_PROVIDERS: %v", err)
+			}
+			handlers.NewOAuthHandler(oauthService, cfg.OAuthBaseURL, cfg.OAuthSuccessURL, cfg.AuthCookies).Register(app)
 		}
-	}))
+	} else if len(cfg.OAuthProviders) > 0 {
+		log.Println("OAUTH_PROVIDERS задан без API_JWT_SECRET: вход через провайдеров выключен")
+	}
+	handlers.NewAPIKeyHandler(apiKeys).Register(app)
+	handlers.NewAuditHandler(auditService).Register(app)
+	changelog, err := service.NewChangelog(docs.SwaggerInfo.ReadDoc(), apiChangelog)
+	if err != nil {
+		log.Fatalf("Некорректный журнал изменений API: %v", err)
+	}
+	handlers.NewChangelogHandler(changelog).Register(app)
+	handlers.NewDatasetHandler(service.NewDatasetService(replicas.Reader, cfg.DatasetTTL)).Register(app)
+	handlers.NewCatalogDiffHandler(service.NewCatalogDiffer(productService, cfg.CatalogDiffTimeout)).Register(app)
+
+	registry := metrics.NewRegistry()
+	metrics.RegisterCatalog(registry, productService)
+	metrics.RegisterGraphQLUsage(registry, usage)
+	metrics.RegisterReplicas(registry, replicas)
+	metrics.RegisterSLO(registry, tracker)
+	registry.GaugeFunc("ws_connections", "Number of open WebSocket connections.", func() (float64, error) {
+		return float64(chat.Connections()), nil
+	})
+	registry.GaugeFunc("ws_product_stream_connections", "Number of open connections to the WebSocket product stream.", func() (float64, error) {
+		return float64(productStream.Connections()), nil
+	})
+	registry.CounterFunc("ws_dropped_events_total", "Product events not delivered to WebSocket clients with a full send queue.", func() (float64, error) {
+		return float64(chat.DroppedEvents()), nil
+	})
+	registry.CounterFunc("ws_slow_disconnects_total", "WebSocket clients disconnected because their send queue overflowed.", func() (float64, error) {
+		return float64(chat.SlowDisconnects()), nil
+	})
+	registry.CounterFunc("ws_limit_disconnects_total", "WebSocket connections closed for oversized or too frequent client messages.", func() (float64, error) {
+		return float64(chat.LimitDisconnects() + productStream.LimitDisconnects()), nil
+	})
+	registry.CounterFunc("ws_connections_opened", "WebSocket chat connections opened since start; a fast rise means a reconnection storm.", func() (float64, error) {
+		return float64(chat.Stats().ConnectionsOpened), nil
+	})
+	registry.GaugeFunc("ws_rooms", "Chat rooms with at least one connection.", func() (float64, error) {
+		return float64(chat.Stats().Rooms), nil
+	})
+	registry.CounterFunc("ws_messages", "Chat messages, including direct messages, sent through this instance.", func() (float64, error) {
+		return float64(chat.Stats().Messages), nil
+	})
+	registry.GaugeFunc("ws_messages_per_second", "Chat messages per second averaged over the last minute.", func() (float64, error) {
+		return chat.Stats().MessagesPerSecond, nil
+	})
+	registry.CounterFunc("ws_dropped_ephemeral", "Typing indicators and delivery receipts not delivered to WebSocket clients with a full send queue.", func() (float64, error) {
+		return float64(chat.Stats().DroppedEphemeral), nil
+	})
+	registry.CounterFunc("ws_dropped_broadcasts", "Domain events dropped because the WebSocket broadcast queue was full.", func() (float64, error) {
+		return float64(chat.Stats().DroppedBroadcasts), nil
+	})
+	registry.GaugeFunc("ws_fanout_latency_avg_seconds", "Average delay over the last minute between queueing an envelope for a WebSocket client and writing it to the socket.", func() (float64, error) {
+		return chat.Stats().FanoutLatencyAvg, nil
+	})
+	registry.GaugeFunc("ws_fanout_latency_max_seconds", "Maximum delay over the last minute between queueing an envelope for a WebSocket client and writing it to the socket.", func() (float64, error) {
+		return chat.Stats().FanoutLatencyMax, nil
+	})
+	app.Get("/metrics", registry.Handler())
+	health := handlers.NewHealthHandler()
+	health.Register(app)
+	statusChecks := []service.DependencyCheck{{Name: "database", Critical: true, Check: database.PingContext}}
+	if len(cfg.DB.Replicas) > 0 {
+		// Без здоровых реплик чтение идёт в основную базу: сервис медленнее,
+		// но работает.
+		statusChecks = append(statusChecks, service.DependencyCheck{Name: "read replicas", Check: replicas.Check})
+	}
+	handlers.NewStatusHandler(service.NewStatusService(statusChecks, eventLog, cfg.StatusIncidentWindow)).Register(app)
+
+	if len(cfg.AdminTokens) == 0 {
+		log.Println("ADMIN_TOKENS не заданы: мутации GraphQL недоступны")
+	}
+	if len(cfg.SCIMTokens) == 0 {
+		log.Println("SCIM_TOKENS не заданы: SCIM /scim/v2 недоступен")
+	}
+	tracing, err := graphql.ParseTracingMode(cfg.GraphQLTracing)
+	if err != nil {
+		log.Fatalf("Некорректная настройка GRAPHQL_TRACING: %v", err)
+	}
+	graphqlOptions := graphql.HandlerOptions{
+		Timeout:              cfg.GraphQLTimeout,
+		Debug:                cfg.GraphQLDebug,
+		Persisted:            persisted,
+		Auth:                 apiAuth,
+		APIKeys:              apiKeys,
+		DisableIntrospection: !cfg.GraphQLIntrospection,
+		Tracing:              tracing,
+	}
+	// Без явной настройки клиенты GraphQL получают ключи по схеме, как
+	// раньше, хотя REST отвечает в snake_case.
+	if cfg.JSONFieldNaming != "" {
+		graphqlOptions.Naming = codec
+	}
+	if cfg.GraphQLCostBudget > 0 {
+		graphqlOptions.Costs = graphql.NewCostLimiter(&schema, graphql.CostOptions{
+			Budget:          int(cfg.GraphQLCostBudget),
+			Window:          cfg.GraphQLCostWindow,
+			DefaultListSize: int(cfg.GraphQLCostListSize),
+		})
+	}
+	if cfg.GraphQLResponseCacheTTL > 0 {
+		responses := graphql.NewResponseCache(graphql.ResponseCacheOptions{
+			TTL:        cfg.GraphQLResponseCacheTTL,
+			MaxEntries: int(cfg.GraphQLResponseCacheSize),
+		})
+		productService.OnChange(responses.Invalidate)
+		graphqlOptions.Responses = responses
+	}
+	app.All("/api/graphql", graphql.NewHandler(&schema, graphqlOptions))
+	app.Get("/api/graphql/schema.graphql", graphql.NewSDLHandler(&schema))
+	app.Get("/api/graphql/ws", graphql.NewSubscriptionHandler(&schema, graphqlOptions))
+	if cfg.GraphQLPlayground {
+		app.Get("/api/graphql/playground", graphql.NewPlaygroundHandler("/api/graphql", "/api/graphql/ws"))
+	}
+	app.Get("/api/ws", chat.Handler())
+	// Регистрируется раньше /api/ws/:room, поэтому комнаты products нет, как
+	// и protocol, маршрут которой зарегистрировал обработчик чата.
+	app.Get("/api/ws/products", productStream.Handler())
+	app.Get("/api/ws/:room", chat.Handler())
 
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	log.Println("Сервер запущен на порту 8080")
-	log.Fatal(app.Listen(":8080"))
+	// Прогрев идёт параллельно с приёмом соединений: /health отвечает сразу,
+	// а /readyz — только когда первые запросы уже не будут медленными.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.WarmUpTimeout)
+		defer cancel()
+		started := time.Now()
+		if err := productService.WarmUp(ctx, int(cfg.WarmUpConnections)); err != nil {
+			log.Printf("Прогрев не завершён: %v", err)
+		} else {
+			log.Printf("Прогрев завершён за %s", time.Since(started).Round(time.Millisecond))
+		}
+		health.MarkReady()
+	}()
+
+	stop, cancelStop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer cancelStop()
+	go func() {
+		log.Printf("Сервер запущен на порту %s", cfg.Port)
+		if err := app.Listen(":" + cfg.Port); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	<-stop.Done()
+
+	// Сначала закрываются подключения WebSocket: Fiber при остановке ждёт
+	// завершения обработчиков, а они живут, пока клиент подключён. Клиенты
+	// получают досланную рассылку и фрейм 1001 и переподключаются к другому
+	// экземпляру.
+	log.Println("Остановка сервера")
+	health.MarkStopping()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, stream := range []interface{ Shutdown(context.Context) error }{chat, productStream} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stream.Shutdown(ctx); err != nil {
+				log.Printf("Не все подключения WebSocket закрылись: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		log.Printf("Сервер остановлен не полностью: %v", err)
+	}
+}
+
+// protectProductWrites разрешает менять продукты и связанные с ними
+// ресурсы editor и admin, а удалять продукты — только admin. Проверка стоит
+// и без входа по паролю: тогда писать можно с токеном администратора или
+// ключом API, как в GraphQL.
+func protectProductWrites(app *fiber.App) {
+	for _, prefix := range []string{"/api/products", "/api/price-schedules", "/api/attachments", "/api/imports", "/api/trash"} {
+		app.Use(prefix, handlers.RequirePermission(auth.PermWrite))
+	}
+	app.Delete("/api/products/:id", handlers.RequirePermission(auth.PermDelete))
 }
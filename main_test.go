@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/http/httptest"
+	"server/docs"
+	"server/internal/auth"
+	"server/internal/handlers"
+	"server/internal/service"
+	"testing"
+)
+
+// TestAPIChangelog проверяет, что changelog.json и аннотации @x-changelog
+// разбираются: иначе сервер не запустится.
+func TestAPIChangelog(t *testing.T) {
+	changelog, err := service.NewChangelog(docs.SwaggerInfo.ReadDoc(), apiChangelog)
+	if err != nil {
+		t.Fatalf("NewChangelog() error = %v", err)
+	}
+	general := 0
+	for _, entry := range changelog.Entries("").Entries {
+		if entry.Path == "" {
+			general++
+		}
+	}
+	if general == 0 {
+		t.Fatal("no API-wide entries from changelog.json")
+	}
+}
+
+// TestProtectProductWrites проверяет права на запись продуктов без входа по
+// паролю: токен администратора пишет, анонимный вызов получает 401.
+func TestProtectProductWrites(t *testing.T) {
+	app := fiber.New()
+	app.Use("/api", handlers.Authenticate(auth.NewStaticTokens([]string{"secret"})))
+	protectProductWrites(app)
+	app.All("/api/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{method: fiber.MethodGet, path: "/api/products", want: fiber.StatusNoContent},
+		{method: fiber.MethodPost, path: "/api/products", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodPut, path: "/api/products/1", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodDelete, path: "/api/products/1", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodDelete, path: "/api/price-schedules/1", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodPost, path: "/api/products/1/attachments", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodPost, path: "/api/imports", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodPost, path: "/api/trash/1/restore", want: fiber.StatusUnauthorized},
+		{method: fiber.MethodPost, path: "/api/products", token: "secret", want: fiber.StatusNoContent},
+		{method: fiber.MethodDelete, path: "/api/products/1", token: "secret", want: fiber.StatusNoContent},
+		{method: fiber.MethodPost, path: "/api/products", token: "wrong", want: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s with token %q: status %d, want %d", tt.method, tt.path, tt.token, resp.StatusCode, tt.want)
+		}
+	}
+}